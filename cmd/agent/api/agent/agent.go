@@ -13,6 +13,7 @@ import (
 	"fmt"
 	"net/http"
 	"sort"
+	"time"
 
 	log "github.com/cihub/seelog"
 	"github.com/gorilla/mux"
@@ -25,19 +26,27 @@ import (
 	"github.com/DataDog/datadog-agent/pkg/autodiscovery"
 	"github.com/DataDog/datadog-agent/pkg/collector/py"
 	"github.com/DataDog/datadog-agent/pkg/config"
+	"github.com/DataDog/datadog-agent/pkg/config/settings"
 	"github.com/DataDog/datadog-agent/pkg/flare"
+	"github.com/DataDog/datadog-agent/pkg/logs"
+	"github.com/DataDog/datadog-agent/pkg/logs/diagnostic"
 	"github.com/DataDog/datadog-agent/pkg/status"
 	"github.com/DataDog/datadog-agent/pkg/status/health"
 	"github.com/DataDog/datadog-agent/pkg/util"
 	"github.com/DataDog/datadog-agent/pkg/version"
 )
 
+// streamLogsTimeoutMargin is the time left to end a stream of logs cleanly
+// before the write timeout of the server
+const streamLogsTimeoutMargin = time.Second
+
 // SetupHandlers adds the specific handlers for /agent endpoints
 func SetupHandlers(r *mux.Router) {
 	r.HandleFunc("/version", getVersion).Methods("GET")
 	r.HandleFunc("/hostname", getHostname).Methods("GET")
 	r.HandleFunc("/flare", makeFlare).Methods("POST")
 	r.HandleFunc("/stop", stopAgent).Methods("POST")
+	r.HandleFunc("/reload", reloadAgent).Methods("POST")
 	r.HandleFunc("/status", getStatus).Methods("GET")
 	r.HandleFunc("/status/formatted", getFormattedStatus).Methods("GET")
 	r.HandleFunc("/status/health", getHealth).Methods("GET")
@@ -46,6 +55,12 @@ func SetupHandlers(r *mux.Router) {
 	r.HandleFunc("/{component}/configs", componentConfigHandler).Methods("GET")
 	r.HandleFunc("/gui/csrf-token", getCSRFToken).Methods("GET")
 	r.HandleFunc("/config-check", getConfigCheck).Methods("GET")
+	r.HandleFunc("/stream-logs", streamLogs).Methods("GET")
+	r.HandleFunc("/dogstatsd-capture", captureDogstatsd).Methods("POST")
+	r.HandleFunc("/config/list-runtime", listRuntimeSettings).Methods("GET")
+	r.HandleFunc("/config/show", showConfig).Methods("GET")
+	r.HandleFunc("/config/{setting}", getRuntimeSetting).Methods("GET")
+	r.HandleFunc("/config/{setting}", setRuntimeSetting).Methods("POST")
 }
 
 func stopAgent(w http.ResponseWriter, r *http.Request) {
@@ -58,6 +73,39 @@ func stopAgent(w http.ResponseWriter, r *http.Request) {
 	w.Write(j)
 }
 
+func reloadAgent(w http.ResponseWriter, r *http.Request) {
+	if err := apiutil.Validate(w, r); err != nil {
+		return
+	}
+
+	// a bad edit must not take down the running agent, the configuration is
+	// parsed by another agent process first
+	if err := common.ValidateConfigInSubprocess(); err != nil {
+		log.Errorf("Not reloading, the configuration is invalid: %s", err)
+		body, _ := json.Marshal(map[string]string{"error": fmt.Sprintf("invalid configuration, nothing was reloaded:\n%s", err)})
+		http.Error(w, string(body), 400)
+		return
+	}
+	log.Info("Reloading the check configurations and the log sources")
+
+	result := map[string]interface{}{}
+	if common.AC != nil {
+		added, removed := common.AC.Reload()
+		result["configs_added"] = added
+		result["configs_removed"] = removed
+	}
+	if err := logs.Reload(); err != nil {
+		log.Errorf("Could not reload the logs-agent: %s", err)
+		body, _ := json.Marshal(map[string]string{"error": fmt.Sprintf("could not reload the log sources: %s", err)})
+		http.Error(w, string(body), 500)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	j, _ := json.Marshal(result)
+	w.Write(j)
+}
+
 func getVersion(w http.ResponseWriter, r *http.Request) {
 	if err := apiutil.Validate(w, r); err != nil {
 		return
@@ -99,13 +147,24 @@ func makeFlare(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	// The flare command can restrict the collected sections
+	var sections flare.Sections
+	var req response.FlareRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err == nil && len(req.Sections) > 0 {
+		sections, err = flare.NewSections(req.Sections, nil)
+		if err != nil {
+			http.Error(w, err.Error(), 400)
+			return
+		}
+	}
+
 	logFile := config.Datadog.GetString("log_file")
 	if logFile == "" {
 		logFile = common.DefaultLogFile
 	}
 
 	log.Infof("Making a flare")
-	filePath, err := flare.CreateArchive(false, common.GetDistPath(), common.PyChecksPath, logFile)
+	filePath, err := flare.CreateArchive(false, common.GetDistPath(), common.PyChecksPath, logFile, sections)
 	if err != nil || filePath == "" {
 		if err != nil {
 			log.Errorf("The flare failed to be created: %s", err)
@@ -247,3 +306,146 @@ func getConfigCheck(w http.ResponseWriter, r *http.Request) {
 
 	w.Write(json)
 }
+
+// streamLogs writes the messages processed by the logs-agent as they come, one
+// JSON object per line. The stream ends before the write timeout of the server,
+// the client is expected to reconnect.
+func streamLogs(w http.ResponseWriter, r *http.Request) {
+	if err := apiutil.Validate(w, r); err != nil {
+		return
+	}
+
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		http.Error(w, "streaming is not supported", 500)
+		return
+	}
+	if !logs.GetStatus().IsRunning {
+		http.Error(w, "the logs-agent is not running", 503)
+		return
+	}
+
+	filters := diagnostic.Filters{
+		Source:  r.URL.Query().Get("source"),
+		Service: r.URL.Query().Get("service"),
+	}
+	messages, unsubscribe := diagnostic.Subscribe(filters)
+	defer unsubscribe()
+
+	w.Header().Set("Content-Type", "application/x-ndjson")
+	w.WriteHeader(200)
+	flusher.Flush()
+
+	timeout := config.Datadog.GetDuration("server_timeout") * time.Second
+	if timeout > streamLogsTimeoutMargin {
+		timeout -= streamLogsTimeoutMargin
+	}
+	end := time.After(timeout)
+
+	encoder := json.NewEncoder(w)
+	for {
+		select {
+		case msg := <-messages:
+			if err := encoder.Encode(msg); err != nil {
+				log.Debugf("Stopped streaming logs: %s", err)
+				return
+			}
+			flusher.Flush()
+		case <-end:
+			return
+		case <-r.Context().Done():
+			return
+		}
+	}
+}
+
+// captureDogstatsd starts recording the dogstatsd traffic for the duration
+// given in the query, and returns the path of the capture file
+func captureDogstatsd(w http.ResponseWriter, r *http.Request) {
+	if err := apiutil.Validate(w, r); err != nil {
+		return
+	}
+
+	if common.DSD == nil {
+		http.Error(w, "dogstatsd is not running", 503)
+		return
+	}
+	duration, err := time.ParseDuration(r.URL.Query().Get("duration"))
+	if err != nil {
+		http.Error(w, fmt.Sprintf("invalid duration: %s", err), 400)
+		return
+	}
+
+	path, err := common.DSD.StartCapture(duration)
+	if err != nil {
+		log.Errorf("Could not start the dogstatsd capture: %s", err)
+		http.Error(w, err.Error(), 500)
+		return
+	}
+	w.Write([]byte(path))
+}
+
+func listRuntimeSettings(w http.ResponseWriter, r *http.Request) {
+	if err := apiutil.Validate(w, r); err != nil {
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	j, _ := json.Marshal(settings.RuntimeSettings())
+	w.Write(j)
+}
+
+func showConfig(w http.ResponseWriter, r *http.Request) {
+	if err := apiutil.Validate(w, r); err != nil {
+		return
+	}
+	values, err := config.Provenance()
+	if err != nil {
+		log.Errorf("Could not list the settings: %s", err)
+		body, _ := json.Marshal(map[string]string{"error": err.Error()})
+		http.Error(w, string(body), 500)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	j, _ := json.Marshal(values)
+	w.Write(j)
+}
+
+func getRuntimeSetting(w http.ResponseWriter, r *http.Request) {
+	if err := apiutil.Validate(w, r); err != nil {
+		return
+	}
+	setting := mux.Vars(r)["setting"]
+	value, err := settings.GetRuntimeSetting(setting)
+	if err != nil {
+		runtimeSettingError(w, setting, err)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	j, _ := json.Marshal(map[string]interface{}{"value": value})
+	w.Write(j)
+}
+
+func setRuntimeSetting(w http.ResponseWriter, r *http.Request) {
+	if err := apiutil.Validate(w, r); err != nil {
+		return
+	}
+	setting := mux.Vars(r)["setting"]
+	if err := r.ParseForm(); err != nil {
+		http.Error(w, err.Error(), 400)
+		return
+	}
+	if err := settings.SetRuntimeSetting(setting, r.Form.Get("value")); err != nil {
+		runtimeSettingError(w, setting, err)
+		return
+	}
+	log.Infof("Runtime setting %s changed to %s", setting, r.Form.Get("value"))
+}
+
+func runtimeSettingError(w http.ResponseWriter, setting string, err error) {
+	if _, ok := err.(*settings.SettingNotFoundError); ok {
+		http.Error(w, err.Error(), 404)
+		return
+	}
+	log.Errorf("Could not change the runtime setting %s: %s", setting, err)
+	http.Error(w, err.Error(), 400)
+}
@@ -9,9 +9,16 @@
 package check
 
 import (
+	"encoding/json"
+	"fmt"
 	"net/http"
 
+	log "github.com/cihub/seelog"
 	"github.com/gorilla/mux"
+
+	"github.com/DataDog/datadog-agent/cmd/agent/common"
+	apiutil "github.com/DataDog/datadog-agent/pkg/api/util"
+	"github.com/DataDog/datadog-agent/pkg/collector/py"
 )
 
 // SetupHandlers adds the specific handlers for /check endpoints
@@ -21,9 +28,53 @@ func SetupHandlers(r *mux.Router) {
 	r.HandleFunc("/{name}/reload", reloadCheck).Methods("POST")
 }
 
+// reloadCheck reloads the python modules of a check then replaces its
+// running instances by new ones, loaded from its current configuration. The
+// new instances are all loaded before the running ones are stopped, these keep
+// running if the check can't be loaded anymore.
 func reloadCheck(w http.ResponseWriter, r *http.Request) {
-	w.Header().Set("Content-Type", "text/plain")
-	w.Write([]byte("Not yet implemented."))
+	if err := apiutil.Validate(w, r); err != nil {
+		return
+	}
+
+	name := mux.Vars(r)["name"]
+	if common.AC == nil || common.Coll == nil {
+		writeError(w, fmt.Sprintf("can't reload %s: the collector is not running", name), 503)
+		return
+	}
+
+	if err := py.ReloadCheckModule(name); err != nil && err != py.ErrNotCompiled {
+		log.Errorf("Can't reload %s: %s", name, err)
+		writeError(w, fmt.Sprintf("can't reload %s: %s", name, err), 500)
+		return
+	}
+
+	instances := common.AC.GetChecksByName(name)
+	if len(instances) == 0 {
+		log.Errorf("Can't reload %s: no instance could be loaded from its configuration", name)
+		writeError(w, fmt.Sprintf("can't reload %s: no instance could be loaded from its configuration, see the configcheck command", name), 404)
+		return
+	}
+
+	killed, err := common.Coll.ReloadAllCheckInstances(name, instances)
+	if err != nil {
+		log.Errorf("Error reloading %s: %s", name, err)
+		writeError(w, fmt.Sprintf("error reloading %s: %s", name, err), 500)
+		return
+	}
+	log.Infof("Reloaded %s: removed %d old instance(s) and started %d new instance(s)", name, len(killed), len(instances))
+
+	w.Header().Set("Content-Type", "application/json")
+	j, _ := json.Marshal(map[string]int{
+		"instances_removed": len(killed),
+		"instances_started": len(instances),
+	})
+	w.Write(j)
+}
+
+func writeError(w http.ResponseWriter, message string, code int) {
+	body, _ := json.Marshal(map[string]string{"error": message})
+	http.Error(w, string(body), code)
 }
 
 func listChecks(w http.ResponseWriter, r *http.Request) {
@@ -14,3 +14,8 @@ type ConfigCheckResponse struct {
 	ConfigErrors    map[string]string       `json:"config_errors"`
 	Unresolved      map[string]check.Config `json:"unresolved"`
 }
+
+// FlareRequest holds the options of a flare request
+type FlareRequest struct {
+	Sections []string `json:"sections"`
+}
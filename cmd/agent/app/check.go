@@ -8,6 +8,8 @@ package app
 import (
 	"encoding/json"
 	"fmt"
+	"strings"
+	"text/tabwriter"
 	"time"
 
 	"github.com/fatih/color"
@@ -24,10 +26,14 @@ import (
 )
 
 var (
-	checkRate  bool
-	checkName  string
-	checkDelay int
-	logLevel   string
+	checkRate   bool
+	checkTimes  int
+	checkName   string
+	checkDelay  int
+	logLevel    string
+	formatJSON  bool
+	formatTable bool
+	profile     bool
 )
 
 // Make the check cmd aggregator never flush by setting a very high interval
@@ -37,7 +43,11 @@ func init() {
 	AgentCmd.AddCommand(checkCmd)
 
 	checkCmd.Flags().BoolVarP(&checkRate, "check-rate", "r", false, "check rates by running the check twice")
+	checkCmd.Flags().IntVarP(&checkTimes, "check-times", "t", 1, "number of times to run the check")
+	checkCmd.Flags().BoolVarP(&formatJSON, "json", "j", false, "format aggregator and check runner output as json")
+	checkCmd.Flags().BoolVarP(&formatTable, "table", "", false, "format aggregator output as a table")
 	checkCmd.Flags().StringVarP(&logLevel, "log-level", "l", "", "set the log level (default 'off')")
+	checkCmd.Flags().BoolVarP(&profile, "profile", "", false, "profile the CPU time and the memory allocations of the check runs")
 	checkCmd.Flags().IntVarP(&checkDelay, "delay", "d", 100, "delay between running the check and grabbing the metrics in miliseconds")
 	checkCmd.SetArgs([]string{"checkName"})
 }
@@ -115,23 +125,62 @@ var checkCmd = &cobra.Command{
 			return fmt.Errorf("no valid check found")
 		}
 
-		if len(cs) > 1 {
+		if checkRate && checkTimes < 2 {
+			checkTimes = 2
+		}
+		if checkTimes < 1 {
+			return fmt.Errorf("--check-times must be a positive number, got %d", checkTimes)
+		}
+
+		if len(cs) > 1 && !formatJSON {
 			fmt.Println("Multiple check instances found, running each of them")
 		}
 
+		var instancesData []interface{}
 		for _, c := range cs {
-			s := runCheck(c, agg)
+			s, profiles := runCheck(c, agg)
 
 			// Sleep for a while to allow the aggregator to finish ingesting all the metrics/events/sc
 			time.Sleep(time.Duration(checkDelay) * time.Millisecond)
 
-			printMetrics(agg)
+			if formatJSON {
+				aggregatorData := getMetricsData(agg)
+				instanceData := map[string]interface{}{
+					"aggregator": aggregatorData,
+					"runner":     s,
+				}
+				if profile {
+					instanceData["profiles"] = profiles
+				}
+				instancesData = append(instancesData, instanceData)
+				continue
+			}
+
+			if formatTable {
+				printMetricsTable(agg)
+			} else {
+				printMetrics(agg)
+			}
 
 			checkStatus, _ := status.GetCheckStatus(c, s)
 			fmt.Println(string(checkStatus))
+
+			for i, p := range profiles {
+				fmt.Fprintln(color.Output, fmt.Sprintf("=== %s ===", color.BlueString("Profile of run %d", i+1)))
+				fmt.Println(p)
+			}
+		}
+
+		if formatJSON {
+			j, err := json.MarshalIndent(instancesData, "", "  ")
+			if err != nil {
+				return fmt.Errorf("unable to marshal check output to json: %v", err)
+			}
+			fmt.Println(string(j))
+			return nil
 		}
 
-		if checkRate == false {
+		if checkTimes < 2 {
 			color.Yellow("Check has run only once, if some metrics are missing you can try again with --check-rate to see any other metric if available.")
 		}
 
@@ -139,23 +188,113 @@ var checkCmd = &cobra.Command{
 	},
 }
 
-func runCheck(c check.Check, agg *aggregator.BufferedAggregator) *check.Stats {
+func runCheck(c check.Check, agg *aggregator.BufferedAggregator) (*check.Stats, []string) {
 	s := check.NewStats(c)
-	i := 0
-	times := 1
-	if checkRate {
-		times = 2
+	profiler, canProfile := c.(check.Profiler)
+	if profile && !canProfile {
+		color.Yellow("Check %s can't be profiled, only the Python checks can.", c)
 	}
+
+	var profiles []string
+	i := 0
+	times := checkTimes
 	for i < times {
 		t0 := time.Now()
-		err := c.Run()
+		var err error
+		if profile && canProfile {
+			var p string
+			p, err = profiler.RunProfiled()
+			profiles = append(profiles, p)
+		} else {
+			err = c.Run()
+		}
 		warnings := c.GetWarnings()
 		mStats, _ := c.GetMetricStats()
 		s.Add(time.Since(t0), err, warnings, mStats)
 		i++
 	}
 
-	return s
+	return s, profiles
+}
+
+// getMetricsData grabs everything the aggregator received so far in a json
+// friendly structure
+func getMetricsData(agg *aggregator.BufferedAggregator) map[string]interface{} {
+	aggData := make(map[string]interface{})
+
+	series := agg.GetSeries()
+	if len(series) != 0 {
+		// Series marshal themselves wrapped in a `series` object to match the
+		// v1 API payload, unwrap them to get the raw list
+		var data map[string]interface{}
+		sj, _ := json.Marshal(series)
+		json.Unmarshal(sj, &data)
+		aggData["metrics"] = data["series"]
+	}
+
+	sketches := agg.GetSketches()
+	if len(sketches) != 0 {
+		aggData["sketches"] = sketches
+	}
+
+	serviceChecks := agg.GetServiceChecks()
+	if len(serviceChecks) != 0 {
+		aggData["service_checks"] = serviceChecks
+	}
+
+	events := agg.GetEvents()
+	if len(events) != 0 {
+		aggData["events"] = events
+	}
+
+	return aggData
+}
+
+// printMetricsTable prints the series, service checks and events received by
+// the aggregator as aligned tables, showing the last point of each serie
+func printMetricsTable(agg *aggregator.BufferedAggregator) {
+	w := tabwriter.NewWriter(color.Output, 0, 0, 2, ' ', 0)
+
+	series := agg.GetSeries()
+	if len(series) != 0 {
+		fmt.Fprintln(color.Output, fmt.Sprintf("=== %s ===", color.BlueString("Series")))
+		fmt.Fprintln(w, "METRIC\tTYPE\tVALUE\tHOST\tTAGS")
+		for _, serie := range series {
+			var value float64
+			if len(serie.Points) > 0 {
+				value = serie.Points[len(serie.Points)-1].Value
+			}
+			fmt.Fprintf(w, "%s\t%s\t%v\t%s\t%s\n", serie.Name, serie.MType.String(), value, serie.Host, strings.Join(serie.Tags, ","))
+		}
+		w.Flush()
+	}
+
+	sketches := agg.GetSketches()
+	if len(sketches) != 0 {
+		fmt.Fprintln(color.Output, fmt.Sprintf("=== %s ===", color.BlueString("Sketches")))
+		j, _ := json.MarshalIndent(sketches, "", "  ")
+		fmt.Println(string(j))
+	}
+
+	serviceChecks := agg.GetServiceChecks()
+	if len(serviceChecks) != 0 {
+		fmt.Fprintln(color.Output, fmt.Sprintf("=== %s ===", color.BlueString("Service Checks")))
+		fmt.Fprintln(w, "CHECK\tSTATUS\tHOST\tTAGS\tMESSAGE")
+		for _, sc := range serviceChecks {
+			fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\n", sc.CheckName, sc.Status.String(), sc.Host, strings.Join(sc.Tags, ","), sc.Message)
+		}
+		w.Flush()
+	}
+
+	events := agg.GetEvents()
+	if len(events) != 0 {
+		fmt.Fprintln(color.Output, fmt.Sprintf("=== %s ===", color.BlueString("Events")))
+		fmt.Fprintln(w, "TITLE\tTYPE\tHOST\tTAGS")
+		for _, e := range events {
+			fmt.Fprintf(w, "%s\t%s\t%s\t%s\n", e.Title, e.EventType, e.Host, strings.Join(e.Tags, ","))
+		}
+		w.Flush()
+	}
 }
 
 func printMetrics(agg *aggregator.BufferedAggregator) {
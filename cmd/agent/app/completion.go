@@ -0,0 +1,48 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2018 Datadog, Inc.
+
+package app
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/spf13/cobra"
+
+	"github.com/DataDog/datadog-agent/pkg/util/completion"
+)
+
+func init() {
+	AgentCmd.AddCommand(completionCmd)
+}
+
+var completionCmd = &cobra.Command{
+	Use:   "completion bash|zsh|fish",
+	Short: "Print the shell completion script of the agent commands",
+	Long: `Print the completion script of the agent commands and flags for the given shell.
+To load the completions in the current bash session:
+
+	source <(agent completion bash)
+
+To load them in every session, save the script in the completion directory of
+your shell, for instance /etc/bash_completion.d/agent for bash,
+a directory of $fpath as _agent for zsh, or ~/.config/fish/completions/agent.fish
+for fish.`,
+	Args:         cobra.ExactArgs(1),
+	ValidArgs:    []string{"bash", "zsh", "fish"},
+	SilenceUsage: true,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		switch args[0] {
+		case "bash":
+			return AgentCmd.GenBashCompletion(os.Stdout)
+		case "zsh":
+			return AgentCmd.GenZshCompletion(os.Stdout)
+		case "fish":
+			return completion.GenFishCompletion(AgentCmd, os.Stdout)
+		default:
+			return fmt.Errorf("unsupported shell: %s, valid shells are bash, zsh and fish", args[0])
+		}
+	},
+}
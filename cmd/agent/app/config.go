@@ -0,0 +1,200 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2018 Datadog, Inc.
+
+package app
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"sort"
+	"strings"
+
+	"github.com/fatih/color"
+	"github.com/spf13/cobra"
+
+	"github.com/DataDog/datadog-agent/cmd/agent/common"
+	"github.com/DataDog/datadog-agent/pkg/api/util"
+	"github.com/DataDog/datadog-agent/pkg/config"
+	"github.com/DataDog/datadog-agent/pkg/version"
+)
+
+func init() {
+	AgentCmd.AddCommand(configCommand)
+	configCommand.AddCommand(listRuntimeCommand)
+	configCommand.AddCommand(getConfigCommand)
+	configCommand.AddCommand(setConfigCommand)
+	configCommand.AddCommand(schemaCommand)
+	configCommand.AddCommand(showConfigCommand)
+}
+
+var configCommand = &cobra.Command{
+	Use:   "config",
+	Short: "Print or change the runtime settings of the running agent",
+	Long: `Print or change the settings that can be changed on the running agent without
+restarting it. The changes are lost when the agent restarts.`,
+}
+
+var listRuntimeCommand = &cobra.Command{
+	Use:          "list-runtime",
+	Short:        "List the settings that can be changed at runtime",
+	SilenceUsage: true,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if err := setupRuntimeSettingsClient(); err != nil {
+			return err
+		}
+		r, err := doRuntimeSettingsRequest("GET", "list-runtime", "")
+		if err != nil {
+			return err
+		}
+
+		var descriptions map[string]string
+		if err := json.Unmarshal(r, &descriptions); err != nil {
+			return err
+		}
+		names := make([]string, 0, len(descriptions))
+		for name := range descriptions {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		for _, name := range names {
+			fmt.Fprintf(color.Output, "%s\t%s\n", color.BlueString(name), descriptions[name])
+		}
+		return nil
+	},
+}
+
+var getConfigCommand = &cobra.Command{
+	Use:          "get <setting>",
+	Short:        "Print the value of a runtime setting",
+	Args:         cobra.ExactArgs(1),
+	SilenceUsage: true,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if err := setupRuntimeSettingsClient(); err != nil {
+			return err
+		}
+		r, err := doRuntimeSettingsRequest("GET", args[0], "")
+		if err != nil {
+			return err
+		}
+
+		var value map[string]interface{}
+		if err := json.Unmarshal(r, &value); err != nil {
+			return err
+		}
+		fmt.Fprintf(color.Output, "%s is set to: %v\n", args[0], value["value"])
+		return nil
+	},
+}
+
+var setConfigCommand = &cobra.Command{
+	Use:          "set <setting> <value>",
+	Short:        "Change the value of a runtime setting",
+	Args:         cobra.ExactArgs(2),
+	SilenceUsage: true,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if err := setupRuntimeSettingsClient(); err != nil {
+			return err
+		}
+		if _, err := doRuntimeSettingsRequest("POST", args[0], args[1]); err != nil {
+			return err
+		}
+		fmt.Fprintf(color.Output, "%s is set to: %s\n", args[0], color.GreenString(args[1]))
+		return nil
+	},
+}
+
+var showConfigCommand = &cobra.Command{
+	Use:   "show [prefix]",
+	Short: "Print the settings of the running agent and where their values come from",
+	Long: `Print every setting of the running agent with its current value and its
+source: default, file, env_var or runtime (changed with "config set"). Only the
+settings starting with the given prefix are printed. The credentials are
+scrubbed from the values.`,
+	Args:         cobra.MaximumNArgs(1),
+	SilenceUsage: true,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if err := setupRuntimeSettingsClient(); err != nil {
+			return err
+		}
+		r, err := doRuntimeSettingsRequest("GET", "show", "")
+		if err != nil {
+			return err
+		}
+
+		var values []config.SettingValue
+		if err := json.Unmarshal(r, &values); err != nil {
+			return err
+		}
+		for _, v := range values {
+			if len(args) > 0 && !strings.HasPrefix(v.Key, args[0]) {
+				continue
+			}
+			fmt.Fprintf(color.Output, "%s: %s %s\n", color.BlueString(v.Key), v.Value, color.YellowString("(%s)", v.Source))
+		}
+		return nil
+	},
+}
+
+var schemaCommand = &cobra.Command{
+	Use:   "schema",
+	Short: "Print the schema of the agent settings as JSON",
+	Long: `Print the settings known to this agent binary as JSON: their key, type,
+default value, environment variable and deprecation state. The configuration
+file is not loaded, the output only depends on the binary.`,
+	Args:         cobra.NoArgs,
+	SilenceUsage: true,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		schema := struct {
+			Version  string                 `json:"version"`
+			Settings []config.SettingSchema `json:"settings"`
+		}{
+			Version:  version.AgentVersion,
+			Settings: config.Schema(),
+		}
+		out, err := json.MarshalIndent(schema, "", "  ")
+		if err != nil {
+			return err
+		}
+		fmt.Println(string(out))
+		return nil
+	},
+}
+
+func setupRuntimeSettingsClient() error {
+	err := common.SetupConfig(confFilePath)
+	if err != nil {
+		return fmt.Errorf("unable to set up global agent configuration: %v", err)
+	}
+	if flagNoColor {
+		color.NoColor = true
+	}
+	return util.SetAuthToken()
+}
+
+// doRuntimeSettingsRequest queries the runtime settings endpoints of the
+// agent API, the value is only sent with POST requests
+func doRuntimeSettingsRequest(method, setting, value string) ([]byte, error) {
+	c := util.GetClient(false) // FIX: get certificates right then make this true
+	urlstr := fmt.Sprintf("https://localhost:%v/agent/config/%s", config.Datadog.GetInt("cmd_port"), url.PathEscape(setting))
+
+	var r []byte
+	var err error
+	if method == "POST" {
+		body := url.Values{"value": {value}}.Encode()
+		r, err = util.DoPost(c, urlstr, "application/x-www-form-urlencoded", strings.NewReader(body))
+	} else {
+		r, err = util.DoGet(c, urlstr)
+	}
+	if err != nil {
+		if r != nil && string(r) != "" {
+			fmt.Fprintln(color.Output, fmt.Sprintf("The agent ran into an error: %s", color.RedString(strings.TrimSpace(string(r)))))
+		} else {
+			fmt.Fprintln(color.Output, color.RedString("Could not reach the agent (is it running?)"))
+		}
+		return nil, err
+	}
+	return r, nil
+}
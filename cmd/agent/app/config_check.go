@@ -10,17 +10,25 @@ import (
 
 	"github.com/DataDog/datadog-agent/cmd/agent/common"
 	"github.com/DataDog/datadog-agent/pkg/flare"
+	"github.com/DataDog/datadog-agent/pkg/util/output"
 
 	"github.com/fatih/color"
 	"github.com/spf13/cobra"
 )
 
-var withDebug bool
+var (
+	withDebug         bool
+	configCheckJSON   bool
+	configCheckFormat string
+)
 
 func init() {
 	AgentCmd.AddCommand(configCheckCommand)
 
 	configCheckCommand.Flags().BoolVarP(&withDebug, "verbose", "v", false, "print additional debug info")
+	configCheckCommand.Flags().StringVarP(&configCheckFormat, "output", "o", output.Table, output.FlagUsage)
+	configCheckCommand.Flags().BoolVarP(&configCheckJSON, "json", "j", false, "print out raw json")
+	configCheckCommand.Flags().MarkDeprecated("json", "use -o json instead")
 }
 
 var configCheckCommand = &cobra.Command{
@@ -35,10 +43,9 @@ var configCheckCommand = &cobra.Command{
 		if flagNoColor {
 			color.NoColor = true
 		}
-		err = flare.GetConfigCheck(color.Output, withDebug)
-		if err != nil {
-			return err
+		if configCheckJSON {
+			configCheckFormat = output.JSON
 		}
-		return nil
+		return flare.WriteConfigCheck(color.Output, configCheckFormat, withDebug)
 	},
 }
@@ -0,0 +1,151 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2018 Datadog, Inc.
+
+package app
+
+import (
+	"fmt"
+	"io"
+	"net"
+	"os"
+	"strconv"
+	"time"
+
+	"github.com/fatih/color"
+	"github.com/spf13/cobra"
+
+	"github.com/DataDog/datadog-agent/cmd/agent/common"
+	"github.com/DataDog/datadog-agent/pkg/api/util"
+	"github.com/DataDog/datadog-agent/pkg/config"
+	"github.com/DataDog/datadog-agent/pkg/dogstatsd/replay"
+)
+
+var (
+	captureDuration time.Duration
+	replayFast      bool
+	replayPrint     bool
+)
+
+func init() {
+	AgentCmd.AddCommand(dogstatsdCaptureCmd)
+	dogstatsdCaptureCmd.Flags().DurationVarP(&captureDuration, "duration", "d", time.Minute, "how long to capture the traffic")
+	dogstatsdCaptureCmd.AddCommand(dogstatsdReplayCmd)
+	dogstatsdReplayCmd.Flags().BoolVar(&replayFast, "fast", false, "send the packets as fast as possible instead of reproducing the delays of the capture")
+	dogstatsdReplayCmd.Flags().BoolVar(&replayPrint, "print", false, "print the packets instead of sending them")
+}
+
+var dogstatsdCaptureCmd = &cobra.Command{
+	Use:   "dogstatsd-capture",
+	Short: "Record the dogstatsd traffic received by the running agent",
+	Long: `Record the UDP and Unix socket packets received by the dogstatsd server of the
+running agent in a capture file, to debug malformed packets or metric drops.
+The capture can be sent again to dogstatsd with the replay subcommand.`,
+	SilenceUsage: true,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		err := common.SetupConfig(confFilePath)
+		if err != nil {
+			return fmt.Errorf("unable to set up global agent configuration: %v", err)
+		}
+		if flagNoColor {
+			color.NoColor = true
+		}
+		return requestDogstatsdCapture()
+	},
+}
+
+var dogstatsdReplayCmd = &cobra.Command{
+	Use:   "replay <file>",
+	Short: "Send a dogstatsd capture to the running agent",
+	Long: `Send the packets of a capture file to the dogstatsd server of the running agent,
+on its Unix socket if dogstatsd_socket is set, on its UDP port otherwise.
+The origin of the packets received on the Unix socket is not replayed.`,
+	Args:         cobra.ExactArgs(1),
+	SilenceUsage: true,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		err := common.SetupConfig(confFilePath)
+		if err != nil {
+			return fmt.Errorf("unable to set up global agent configuration: %v", err)
+		}
+		return replayDogstatsdCapture(args[0])
+	},
+}
+
+func requestDogstatsdCapture() error {
+	c := util.GetClient(false) // FIX: get certificates right then make this true
+	urlstr := fmt.Sprintf("https://localhost:%v/agent/dogstatsd-capture?duration=%s", config.Datadog.GetInt("cmd_port"), captureDuration)
+
+	// Set session token
+	err := util.SetAuthToken()
+	if err != nil {
+		return err
+	}
+
+	r, err := util.DoPost(c, urlstr, "text/plain", nil)
+	if err != nil {
+		if r != nil && string(r) != "" {
+			fmt.Fprintln(color.Output, fmt.Sprintf("The agent could not start the capture: %s", color.RedString(string(r))))
+		} else {
+			fmt.Fprintln(color.Output, color.RedString("Could not reach the agent (is it running?)"))
+		}
+		return err
+	}
+
+	path := string(r)
+	fmt.Fprintln(color.Output, fmt.Sprintf("Capturing the dogstatsd traffic for %s in %s", captureDuration, color.YellowString(path)))
+	time.Sleep(captureDuration)
+	fmt.Fprintln(color.Output, fmt.Sprintf("Capture written to %s", color.YellowString(path)))
+	return nil
+}
+
+func replayDogstatsdCapture(path string) error {
+	f, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	r, err := replay.NewReader(f)
+	if err != nil {
+		return err
+	}
+
+	var out io.Writer
+	if replayPrint {
+		out = packetPrinter{}
+	} else {
+		conn, err := dialDogstatsd()
+		if err != nil {
+			return fmt.Errorf("could not connect to dogstatsd: %s", err)
+		}
+		defer conn.Close()
+		out = conn
+	}
+
+	sent, err := replay.Replay(r, out, !replayFast)
+	if !replayPrint {
+		fmt.Printf("Sent %d packets\n", sent)
+	}
+	return err
+}
+
+// dialDogstatsd connects to the Unix socket of dogstatsd if set, to its UDP port otherwise
+func dialDogstatsd() (net.Conn, error) {
+	if socketPath := config.Datadog.GetString("dogstatsd_socket"); socketPath != "" {
+		return net.Dial("unixgram", socketPath)
+	}
+	port := config.Datadog.GetInt("dogstatsd_port")
+	if port <= 0 {
+		return nil, fmt.Errorf("dogstatsd listens on neither udp nor socket")
+	}
+	return net.Dial("udp", net.JoinHostPort("127.0.0.1", strconv.Itoa(port)))
+}
+
+// packetPrinter prints each packet, quoted to show the control characters
+type packetPrinter struct{}
+
+func (packetPrinter) Write(packet []byte) (int, error) {
+	fmt.Println(strconv.Quote(string(packet)))
+	return len(packet), nil
+}
@@ -7,8 +7,10 @@ package app
 
 import (
 	"bytes"
+	"encoding/json"
 	"fmt"
 
+	"github.com/DataDog/datadog-agent/cmd/agent/api/response"
 	"github.com/DataDog/datadog-agent/cmd/agent/common"
 	"github.com/DataDog/datadog-agent/pkg/api/util"
 	"github.com/DataDog/datadog-agent/pkg/config"
@@ -21,6 +23,9 @@ import (
 var (
 	customerEmail string
 	autoconfirm   bool
+	localOnly     bool
+	flareIncludes []string
+	flareExcludes []string
 )
 
 func init() {
@@ -28,6 +33,9 @@ func init() {
 
 	flareCmd.Flags().StringVarP(&customerEmail, "email", "e", "", "Your email")
 	flareCmd.Flags().BoolVarP(&autoconfirm, "send", "s", false, "Automatically send flare (don't prompt for confirmation)")
+	flareCmd.Flags().BoolVarP(&localOnly, "local-only", "l", false, "Only write the flare archive, don't upload it to Datadog")
+	flareCmd.Flags().StringSliceVarP(&flareIncludes, "include", "i", nil, "Only collect these sections (comma separated: config, configcheck, diagnose, docker, envvars, expvar, goroutines, health, logs, perf, status)")
+	flareCmd.Flags().StringSliceVarP(&flareExcludes, "exclude", "x", nil, "Don't collect these sections (comma separated)")
 	flareCmd.SetArgs([]string{"caseID"})
 }
 
@@ -50,9 +58,14 @@ var flareCmd = &cobra.Command{
 			caseID = args[0]
 		}
 
+		sections, err := flare.NewSections(flareIncludes, flareExcludes)
+		if err != nil {
+			return err
+		}
+
 		// The flare command should not log anything, all errors should be reported directly to the console without the log format
 		config.SetupLogger("off", "", "", false, false, "", true, false)
-		if customerEmail == "" {
+		if customerEmail == "" && !localOnly {
 			var err error
 			customerEmail, err = flare.AskForEmail()
 			if err != nil {
@@ -61,11 +74,11 @@ var flareCmd = &cobra.Command{
 			}
 		}
 
-		return requestFlare(caseID)
+		return requestFlare(caseID, sections)
 	},
 }
 
-func requestFlare(caseID string) error {
+func requestFlare(caseID string, sections flare.Sections) error {
 	fmt.Fprintln(color.Output, color.BlueString("Asking the agent to build the flare archive."))
 	var e error
 	c := util.GetClient(false) // FIX: get certificates right then make this true
@@ -82,7 +95,12 @@ func requestFlare(caseID string) error {
 		return e
 	}
 
-	r, e := util.DoPost(c, urlstr, "application/json", bytes.NewBuffer([]byte{}))
+	body, e := json.Marshal(response.FlareRequest{Sections: sections.List()})
+	if e != nil {
+		return e
+	}
+
+	r, e := util.DoPost(c, urlstr, "application/json", bytes.NewBuffer(body))
 	var filePath string
 	if e != nil {
 		if r != nil && string(r) != "" {
@@ -91,7 +109,7 @@ func requestFlare(caseID string) error {
 			fmt.Fprintln(color.Output, color.RedString("The agent was unable to make the flare. (is it running?)"))
 		}
 		fmt.Fprintln(color.Output, color.YellowString("Initiating flare locally."))
-		filePath, e = flare.CreateArchive(true, common.GetDistPath(), common.PyChecksPath, logFile)
+		filePath, e = flare.CreateArchive(true, common.GetDistPath(), common.PyChecksPath, logFile, sections)
 		// enable back color output
 		if flagNoColor {
 			color.NoColor = true
@@ -104,6 +122,11 @@ func requestFlare(caseID string) error {
 		filePath = string(r)
 	}
 
+	if localOnly {
+		fmt.Fprintln(color.Output, fmt.Sprintf("Flare archive written to %s", color.YellowString(filePath)))
+		return nil
+	}
+
 	fmt.Fprintln(color.Output, fmt.Sprintf("%s is going to be uploaded to Datadog", color.YellowString(filePath)))
 	if !autoconfirm {
 		confirmation := flare.AskForConfirmation("Are you sure you want to upload a flare? [Y/N]")
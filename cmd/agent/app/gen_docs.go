@@ -0,0 +1,75 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2018 Datadog, Inc.
+
+package app
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/cobra/doc"
+
+	"github.com/DataDog/datadog-agent/pkg/version"
+)
+
+var (
+	// flags variables
+	docsFormat string
+)
+
+func init() {
+	AgentCmd.AddCommand(genDocsCmd)
+	genDocsCmd.Flags().StringVarP(&docsFormat, "format", "f", "all", "format of the reference: man, markdown or all")
+}
+
+var genDocsCmd = &cobra.Command{
+	Use:   "gen-docs <directory>",
+	Short: "Generate the reference of the agent commands",
+	Long: `Generate the man pages, in the man1 subdirectory, and the markdown reference,
+in the markdown subdirectory, of all the agent commands and flags.`,
+	Args:         cobra.ExactArgs(1),
+	Hidden:       true,
+	SilenceUsage: true,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		// the generation date would change the files at every build
+		AgentCmd.DisableAutoGenTag = true
+
+		switch docsFormat {
+		case "man":
+			return genManPages(filepath.Join(args[0], "man1"))
+		case "markdown":
+			return genMarkdown(filepath.Join(args[0], "markdown"))
+		case "all":
+			if err := genManPages(filepath.Join(args[0], "man1")); err != nil {
+				return err
+			}
+			return genMarkdown(filepath.Join(args[0], "markdown"))
+		default:
+			return fmt.Errorf("unsupported format: %s, valid formats are man, markdown and all", docsFormat)
+		}
+	},
+}
+
+func genManPages(dir string) error {
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return err
+	}
+	header := &doc.GenManHeader{
+		Title:   "DATADOG-AGENT",
+		Section: "1",
+		Source:  fmt.Sprintf("Datadog Agent %s", version.AgentVersion),
+		Manual:  "Datadog Agent Manual",
+	}
+	return doc.GenManTree(AgentCmd, header, dir)
+}
+
+func genMarkdown(dir string) error {
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return err
+	}
+	return doc.GenMarkdownTree(AgentCmd, dir)
+}
@@ -11,6 +11,7 @@ import (
 	"sort"
 	"strconv"
 	"strings"
+	"time"
 
 	"github.com/fatih/color"
 	"github.com/spf13/cobra"
@@ -86,9 +87,26 @@ func requestHealth() error {
 	}
 	if len(s.Unhealthy) > 0 {
 		fmt.Fprintln(color.Output, fmt.Sprintf("=== %s unhealthy components ===", color.RedString(strconv.Itoa(len(s.Unhealthy)))))
-		fmt.Fprintln(color.Output, strings.Join(s.Unhealthy, ", "))
+		for _, name := range s.Unhealthy {
+			fmt.Fprintln(color.Output, formatUnhealthy(name, s))
+		}
 		return fmt.Errorf("found %d unhealthy components", len(s.Unhealthy))
 	}
 
 	return nil
 }
+
+// formatUnhealthy prints how long a component has been stalled, and whether
+// it exceeded its deadline and fails the liveness of the agent
+func formatUnhealthy(name string, s *health.Status) string {
+	line := name
+	if stalled, found := s.Stalled[name]; found {
+		line = fmt.Sprintf("%s: stalled for %s", name, stalled-stalled%time.Second)
+	}
+	for _, expired := range s.Expired {
+		if expired == name {
+			return line + color.RedString(" (deadline exceeded)")
+		}
+	}
+	return line
+}
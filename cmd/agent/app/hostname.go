@@ -6,16 +6,26 @@
 package app
 
 import (
+	"encoding/json"
 	"fmt"
 
+	"github.com/fatih/color"
+	"github.com/spf13/cobra"
+
 	"github.com/DataDog/datadog-agent/cmd/agent/common"
+	apiutil "github.com/DataDog/datadog-agent/pkg/api/util"
 	"github.com/DataDog/datadog-agent/pkg/config"
 	"github.com/DataDog/datadog-agent/pkg/util"
-	"github.com/spf13/cobra"
+)
+
+var (
+	// flags variables
+	hostnameDebug bool
 )
 
 func init() {
 	AgentCmd.AddCommand(getHostnameCommand)
+	getHostnameCommand.Flags().BoolVarP(&hostnameDebug, "debug", "d", false, "print the results of all the hostname providers, and the hostname used by the running agent")
 }
 
 var getHostnameCommand = &cobra.Command{
@@ -32,6 +42,10 @@ func doGetHostname(cmd *cobra.Command, args []string) error {
 	if err != nil {
 		return fmt.Errorf("unable to set up global agent configuration: %v", err)
 	}
+	if hostnameDebug {
+		return printHostnameResolution()
+	}
+
 	hname, err := util.GetHostname()
 	if err != nil {
 		return fmt.Errorf("Error getting the hostname: %v", err)
@@ -40,3 +54,50 @@ func doGetHostname(cmd *cobra.Command, args []string) error {
 	fmt.Println(hname)
 	return nil
 }
+
+// printHostnameResolution prints the providers tried to resolve the hostname,
+// in order, then compares the result with the hostname of the running agent
+func printHostnameResolution() error {
+	if flagNoColor {
+		color.NoColor = true
+	}
+
+	r, err := util.ResolveHostname()
+	fmt.Fprintln(color.Output, "Hostname providers, in order:")
+	for _, step := range r.Steps {
+		result := color.GreenString(step.Hostname)
+		if step.Error != "" {
+			result = color.RedString("error: %s", step.Error)
+		}
+		fmt.Fprintf(color.Output, "  %-8s %s\n", step.Provider, result)
+	}
+	if err != nil {
+		return fmt.Errorf("Error getting the hostname: %v", err)
+	}
+	fmt.Fprintf(color.Output, "Hostname: %s (from %s)\n", color.GreenString(r.Hostname), r.Provider)
+
+	running, err := getRunningAgentHostname()
+	if err != nil {
+		fmt.Fprintf(color.Output, "Could not get the hostname of the running agent: %v\n", err)
+	} else if running != r.Hostname {
+		fmt.Fprintf(color.Output, "%s the running agent uses %s, it resolved its hostname when it started\n",
+			color.YellowString("Warning:"), color.YellowString(running))
+	}
+	return nil
+}
+
+func getRunningAgentHostname() (string, error) {
+	if err := apiutil.SetAuthToken(); err != nil {
+		return "", err
+	}
+	c := apiutil.GetClient(false) // FIX: get certificates right then make this true
+	urlstr := fmt.Sprintf("https://localhost:%v/agent/hostname", config.Datadog.GetInt("cmd_port"))
+
+	r, err := apiutil.DoGet(c, urlstr)
+	if err != nil {
+		return "", err
+	}
+	var hname string
+	err = json.Unmarshal(r, &hname)
+	return hname, err
+}
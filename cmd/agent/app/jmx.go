@@ -13,6 +13,7 @@ import (
 
 	"github.com/DataDog/datadog-agent/cmd/agent/api"
 	"github.com/DataDog/datadog-agent/cmd/agent/common"
+	"github.com/DataDog/datadog-agent/pkg/autodiscovery"
 	"github.com/DataDog/datadog-agent/pkg/collector/check"
 	"github.com/DataDog/datadog-agent/pkg/collector/corechecks/embed"
 	"github.com/DataDog/datadog-agent/pkg/config"
@@ -23,8 +24,9 @@ import (
 var (
 	jmxCmd = &cobra.Command{
 		Use:   "jmx",
-		Short: "",
-		Long:  ``,
+		Short: "Run troubleshooting commands on JMXFetch integrations",
+		Long: `Runs one-shot JMXFetch commands on the JMX configurations resolved by the
+agent, including the autodiscovery templates matching the running services.`,
 	}
 
 	jmxListCmd = &cobra.Command{
@@ -88,6 +90,7 @@ func init() {
 
 	jmxListCmd.PersistentFlags().StringSliceVar(&checks, "checks", []string{}, "JMX checks (ex: jmx,tomcat)")
 	jmxCollectCmd.PersistentFlags().StringSliceVar(&checks, "checks", []string{}, "JMX checks (ex: jmx,tomcat)")
+	jmxCmd.PersistentFlags().StringVarP(&logLevel, "log-level", "l", "", "set the log level of JMXFetch (default to the agent log_level)")
 
 	// attach the command to the root
 	AgentCmd.AddCommand(jmxCmd)
@@ -148,8 +151,14 @@ func runJmxCommand(command string) error {
 	runner.ReportOnConsole = true
 	runner.Command = command
 	runner.IPCPort = api.ServerAddress().Port
+	runner.LogLevel = logLevel
+	if runner.LogLevel == "" {
+		runner.LogLevel = config.Datadog.GetString("log_level")
+	}
 
-	loadConfigs()
+	if err = loadConfigs(); err != nil {
+		return err
+	}
 
 	err = runner.Start()
 	if err != nil {
@@ -165,18 +174,48 @@ func runJmxCommand(command string) error {
 	return nil
 }
 
-func loadConfigs() {
+// loadConfigs hands the resolved JMX configurations to JMXFetch, it fails
+// when none matches, as JMXFetch would have nothing to run
+func loadConfigs() error {
 	fmt.Println("Loading configs :")
 
 	configs := common.AC.GetAllConfigs()
 	includeEverything := len(checks) == 0
+	loaded := make(map[string]bool)
 
 	for _, c := range configs {
 		if check.IsJMXConfig(c.Name, c.InitConfig) && (includeEverything || configIncluded(c)) {
 			fmt.Println("Config ", c.Name, " was loaded.")
 			embed.AddJMXCachedConfig(c)
+			loaded[strings.ToLower(c.Name)] = true
+		}
+	}
+
+	// templates are resolved against the services known by the agent
+	jmxTemplates := make(map[string]bool)
+	for _, tpl := range common.AC.GetUnresolvedTemplates() {
+		if check.IsJMXConfig(tpl.Name, tpl.InitConfig) && (includeEverything || configIncluded(tpl)) {
+			jmxTemplates[tpl.Name] = true
+		}
+	}
+	for name, warnings := range autodiscovery.GetResolveWarnings() {
+		if jmxTemplates[name] {
+			for _, warning := range warnings {
+				fmt.Println("Config ", name, " was not loaded: ", warning)
+			}
+		}
+	}
+
+	for _, c := range checks {
+		if !loaded[strings.ToLower(c)] {
+			fmt.Println("No JMX config found for ", c)
 		}
 	}
+
+	if len(loaded) == 0 {
+		return fmt.Errorf("no JMX configuration to run, check your configuration and the --checks flag")
+	}
+	return nil
 }
 
 func configIncluded(config check.Config) bool {
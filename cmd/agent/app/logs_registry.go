@@ -0,0 +1,156 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2018 Datadog, Inc.
+
+package app
+
+import (
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"github.com/DataDog/datadog-agent/cmd/agent/common"
+	"github.com/DataDog/datadog-agent/pkg/config"
+	"github.com/DataDog/datadog-agent/pkg/logs/auditor"
+)
+
+var (
+	// flags variables
+	registryImportReplace bool
+	registryForce         bool
+)
+
+func init() {
+	AgentCmd.AddCommand(logsRegistryCommand)
+	logsRegistryCommand.AddCommand(logsRegistryExportCommand)
+	logsRegistryCommand.AddCommand(logsRegistryImportCommand)
+	logsRegistryCommand.AddCommand(logsRegistryPruneCommand)
+	logsRegistryImportCommand.Flags().BoolVar(&registryImportReplace, "replace", false, "replace the whole registry instead of merging the imported offsets into it")
+	logsRegistryImportCommand.Flags().BoolVarP(&registryForce, "force", "f", false, "import even if the agent is running")
+	logsRegistryPruneCommand.Flags().BoolVarP(&registryForce, "force", "f", false, "prune even if the agent is running")
+}
+
+var logsRegistryCommand = &cobra.Command{
+	Use:   "logs-registry",
+	Short: "Export, import or prune the offsets of the logs tailers",
+	Long: `Export the registry of the logs-agent, where the offsets up to which the files
+and the containers were tailed are kept, and import it on another host or agent, to
+resume tailing from there without replaying or losing logs.`,
+}
+
+var logsRegistryExportCommand = &cobra.Command{
+	Use:          "export [file]",
+	Short:        "Write the registry of the logs-agent to a file, or to the standard output",
+	Args:         cobra.MaximumNArgs(1),
+	SilenceUsage: true,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		registry, err := readLogsRegistry()
+		if err != nil {
+			return err
+		}
+		if len(args) == 0 {
+			r, err := auditor.MarshalRegistry(registry)
+			if err != nil {
+				return err
+			}
+			fmt.Println(string(r))
+			return nil
+		}
+		if err := auditor.WriteRegistry(args[0], registry); err != nil {
+			return err
+		}
+		fmt.Printf("%d offsets exported to %s\n", len(registry), args[0])
+		return nil
+	},
+}
+
+var logsRegistryImportCommand = &cobra.Command{
+	Use:   "import <file>",
+	Short: "Import the offsets exported by logs-registry export",
+	Long: `Import the offsets exported by logs-registry export into the registry of the
+logs-agent, the agent must be stopped: it overwrites the registry as it runs, and
+only reads it when it starts.`,
+	Args:         cobra.ExactArgs(1),
+	SilenceUsage: true,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if err := common.SetupConfig(confFilePath); err != nil {
+			return fmt.Errorf("unable to set up global agent configuration: %v", err)
+		}
+		if !registryForce {
+			// the agent answers to the API as long as it runs
+			if _, err := getRunningAgentHostname(); err == nil {
+				return fmt.Errorf("the agent is running, stop it before importing the offsets or use --force")
+			}
+		}
+
+		imported, err := auditor.ReadRegistry(args[0])
+		if err != nil {
+			return err
+		}
+
+		registryPath := auditor.RegistryPath(config.Datadog.GetString("logs_config.run_path"))
+		registry := make(map[string]auditor.RegistryEntry)
+		if !registryImportReplace {
+			if registry, err = auditor.ReadRegistry(registryPath); os.IsNotExist(err) {
+				registry = make(map[string]auditor.RegistryEntry)
+			} else if err != nil {
+				return err
+			}
+		}
+
+		// the entries expire a day after their last update, they are
+		// refreshed so that the offsets of an old export are not dropped
+		// as soon as the agent starts
+		now := time.Now().UTC()
+		for identifier, entry := range imported {
+			entry.LastUpdated = now
+			registry[identifier] = entry
+		}
+		if err := auditor.WriteRegistry(registryPath, registry); err != nil {
+			return err
+		}
+		fmt.Printf("%d offsets imported to %s\n", len(imported), registryPath)
+		return nil
+	},
+}
+
+var logsRegistryPruneCommand = &cobra.Command{
+	Use:   "prune",
+	Short: "Remove the expired offsets and the ones of the files which no longer exist",
+	Long: `Remove from the registry of the logs-agent the offsets which were not updated
+for a day and the ones of the files which no longer exist, the agent does it as it
+runs, the agent must be stopped.`,
+	Args:         cobra.NoArgs,
+	SilenceUsage: true,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		registry, err := readLogsRegistry()
+		if err != nil {
+			return err
+		}
+		if !registryForce {
+			if _, err := getRunningAgentHostname(); err == nil {
+				return fmt.Errorf("the agent is running, stop it before pruning the offsets or use --force")
+			}
+		}
+		pruned := auditor.PruneRegistry(registry, time.Now().UTC().Add(-auditor.DefaultTTL))
+		for _, identifier := range pruned {
+			fmt.Printf("%s pruned\n", identifier)
+		}
+		registryPath := auditor.RegistryPath(config.Datadog.GetString("logs_config.run_path"))
+		if err := auditor.WriteRegistry(registryPath, registry); err != nil {
+			return err
+		}
+		fmt.Printf("%d offsets pruned from %s\n", len(pruned), registryPath)
+		return nil
+	},
+}
+
+func readLogsRegistry() (map[string]auditor.RegistryEntry, error) {
+	if err := common.SetupConfig(confFilePath); err != nil {
+		return nil, fmt.Errorf("unable to set up global agent configuration: %v", err)
+	}
+	return auditor.ReadRegistry(auditor.RegistryPath(config.Datadog.GetString("logs_config.run_path")))
+}
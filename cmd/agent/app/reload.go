@@ -0,0 +1,74 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2018 Datadog, Inc.
+
+package app
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+
+	"github.com/spf13/cobra"
+
+	"github.com/DataDog/datadog-agent/cmd/agent/common"
+	"github.com/DataDog/datadog-agent/pkg/api/util"
+	"github.com/DataDog/datadog-agent/pkg/config"
+)
+
+var (
+	reloadCmd = &cobra.Command{
+		Use:   "reload",
+		Short: "Reload the check configurations and the log sources of the running Agent",
+		Long: `Tells the running agent to collect the check configurations again, from the
+configuration files and the autodiscovery providers, scheduling the new checks
+and unscheduling the removed ones, then to restart the logs-agent with the
+current log sources. The agent first validates the configuration in a separate
+process: if datadog.yaml, a check configuration or a log source is invalid, the
+errors are reported and nothing is reloaded. The agent process is not
+restarted, the changes to datadog.yaml still require a restart.`,
+		SilenceUsage: true,
+		RunE:         reload,
+	}
+)
+
+func init() {
+	// attach the command to the root
+	AgentCmd.AddCommand(reloadCmd)
+}
+
+func reload(*cobra.Command, []string) error {
+	// Global Agent configuration
+	err := common.SetupConfig(confFilePath)
+	if err != nil {
+		return fmt.Errorf("unable to set up global agent configuration: %v", err)
+	}
+	c := util.GetClient(false) // FIX: get certificates right then make this true
+
+	// Set session token
+	e := util.SetAuthToken()
+	if e != nil {
+		return e
+	}
+
+	urlstr := fmt.Sprintf("https://localhost:%v/agent/reload", config.Datadog.GetInt("cmd_port"))
+
+	r, e := util.DoPost(c, urlstr, "application/json", bytes.NewBuffer([]byte{}))
+	if e != nil {
+		var errMap = make(map[string]string)
+		json.Unmarshal(r, &errMap)
+		// If the error has been marshalled into a json object, check it and return it properly
+		if err, found := errMap["error"]; found {
+			e = fmt.Errorf(err)
+		}
+		return fmt.Errorf("Error reloading the agent: %v", e)
+	}
+
+	var result map[string]int
+	if err := json.Unmarshal(r, &result); err != nil {
+		return err
+	}
+	fmt.Printf("Agent successfully reloaded: %d new check configuration(s), %d removed\n", result["configs_added"], result["configs_removed"])
+	return nil
+}
@@ -6,10 +6,10 @@
 package app
 
 import (
+	"bytes"
+	"encoding/json"
 	"fmt"
 
-	"strings"
-
 	"github.com/DataDog/datadog-agent/cmd/agent/common"
 	"github.com/DataDog/datadog-agent/pkg/api/util"
 	"github.com/DataDog/datadog-agent/pkg/config"
@@ -17,47 +17,58 @@ import (
 )
 
 func init() {
-	// TODO: re-enable when the API endpoint is implemented
-	// AgentCmd.AddCommand(reloadCheckCommand)
-	checkCmd.SetArgs([]string{"checkName"})
+	checkCmd.AddCommand(reloadCheckCommand)
 }
 
 var reloadCheckCommand = &cobra.Command{
-	Use:   "reload-check <check_name>",
-	Short: "Reload a running check",
-	Long:  ``,
+	Use:   "reload <check_name>",
+	Short: "Reload a check of the running Agent",
+	Long: `Tells the running agent to reload the python code of a check, then to replace
+its running instances by new ones, loaded from its current configuration. The
+new instances are loaded before the running ones are stopped: if the check
+can't be loaded, like when its code has a syntax error, the errors are reported
+and the running instances are kept.`,
+	SilenceUsage: true,
 	RunE: func(cmd *cobra.Command, args []string) error {
-		var checkName string
-		if len(args) != 0 {
-			checkName = args[0]
-		} else {
-			return fmt.Errorf("missing arguments")
+		if len(args) != 1 {
+			return fmt.Errorf("missing the name of the check to reload")
 		}
 
 		err := common.SetupConfig(confFilePath)
 		if err != nil {
 			return fmt.Errorf("unable to set up global agent configuration: %v", err)
 		}
-		return doReloadCheck(checkName)
+		return doReloadCheck(args[0])
 	},
 }
 
 // reload check
 func doReloadCheck(checkName string) error {
-	if checkName == "" {
-		return fmt.Errorf("Must supply a check name to query")
+	c := util.GetClient(false) // FIX: get certificates right then make this true
+
+	// Set session token
+	e := util.SetAuthToken()
+	if e != nil {
+		return e
 	}
 
-	c := util.GetClient(false) // FIX: get certificates right then make this true
 	urlstr := fmt.Sprintf("https://localhost:%v/check/%s/reload", config.Datadog.GetInt("cmd_port"), checkName)
 
-	postbody := ""
-
-	body, e := util.DoPost(c, urlstr, "application/json", strings.NewReader(postbody))
+	r, e := util.DoPost(c, urlstr, "application/json", bytes.NewBuffer([]byte{}))
 	if e != nil {
-		return fmt.Errorf("error getting check status for check %s: %v", checkName, e)
+		var errMap = make(map[string]string)
+		json.Unmarshal(r, &errMap)
+		// If the error has been marshalled into a json object, check it and return it properly
+		if err, found := errMap["error"]; found {
+			e = fmt.Errorf(err)
+		}
+		return fmt.Errorf("Error reloading the check %s: %v", checkName, e)
 	}
 
-	fmt.Printf("Reload check %s: %s\n", checkName, body)
+	var result map[string]int
+	if err := json.Unmarshal(r, &result); err != nil {
+		return err
+	}
+	fmt.Printf("Check %s successfully reloaded: %d instance(s) removed, %d instance(s) started\n", checkName, result["instances_removed"], result["instances_started"])
 	return nil
 }
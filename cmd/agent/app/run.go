@@ -0,0 +1,109 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2018 Datadog, Inc.
+
+package app
+
+import (
+	"fmt"
+	"os"
+	"os/signal"
+	"syscall"
+
+	log "github.com/cihub/seelog"
+	"github.com/spf13/cobra"
+
+	"github.com/DataDog/datadog-agent/cmd/agent/common/signals"
+)
+
+var (
+	runCmd = &cobra.Command{
+		Use:   "run",
+		Short: "Run the Agent",
+		Long: `Runs the agent in the foreground until it receives SIGINT or SIGTERM,
+then flushes the buffered data and exits. A second signal exits right away.`,
+		RunE: run,
+	}
+
+	// startCmd is kept for the existing service definitions
+	startCmd = &cobra.Command{
+		Use:        "start",
+		Short:      "Start the Agent",
+		Long:       `Runs the agent in the foreground`,
+		RunE:       run,
+		Deprecated: "use \"run\" instead to start the Agent",
+	}
+)
+
+var (
+	// flags variables
+	pidfilePath string
+)
+
+func init() {
+	// attach the commands to the root
+	AgentCmd.AddCommand(runCmd)
+	AgentCmd.AddCommand(startCmd)
+
+	// local flags
+	runCmd.Flags().StringVarP(&pidfilePath, "pidfile", "p", "", "path to the pidfile")
+	startCmd.Flags().StringVarP(&pidfilePath, "pidfile", "p", "", "path to the pidfile")
+}
+
+// run the main loop
+func run(cmd *cobra.Command, args []string) error {
+	defer func() {
+		StopAgent()
+	}()
+
+	// Setup a channel to catch OS signals
+	signalCh := make(chan os.Signal, 1)
+	signal.Notify(signalCh, os.Interrupt, syscall.SIGTERM, syscall.SIGPIPE)
+
+	// Make a channel to exit the function
+	stopCh := make(chan error)
+
+	go handleSignals(signalCh, stopCh)
+
+	if err := StartAgent(); err != nil {
+		return err
+	}
+
+	return <-stopCh
+}
+
+// handleSignals asks the main loop to stop on the first stop request, and
+// exits without waiting for the agent to stop on the second one
+func handleSignals(signalCh chan os.Signal, stopCh chan error) {
+	stopping := false
+	requestStop := func(err error) {
+		if stopping {
+			log.Warn("Received a second stop request while stopping, exiting now")
+			log.Flush()
+			os.Exit(1)
+		}
+		stopping = true
+		// don't block on stopCh if the agent failed to start
+		go func() { stopCh <- err }()
+	}
+
+	for {
+		select {
+		case <-signals.Stopper:
+			log.Info("Received stop command, shutting down...")
+			requestStop(nil)
+		case <-signals.ErrorStopper:
+			log.Critical("The Agent has encountered an error, shutting down...")
+			requestStop(fmt.Errorf("shutting down because of an error"))
+		case sig := <-signalCh:
+			if sig == syscall.SIGPIPE {
+				// a closed stdout or stderr, e.g. when journald restarts,
+				// must not kill the agent
+				continue
+			}
+			log.Infof("Received signal '%s', shutting down...", sig)
+			requestStop(nil)
+		}
+	}
+}
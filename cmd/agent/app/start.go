@@ -8,7 +8,6 @@ package app
 import (
 	"fmt"
 	"runtime"
-	"syscall"
 	"time"
 
 	_ "expvar" // Blank import used because this isn't directly used in this file
@@ -16,14 +15,14 @@ import (
 	_ "net/http/pprof" // Blank import used because this isn't directly used in this file
 
 	"os"
-	"os/signal"
 
 	"github.com/DataDog/datadog-agent/cmd/agent/api"
 	"github.com/DataDog/datadog-agent/cmd/agent/common"
-	"github.com/DataDog/datadog-agent/cmd/agent/common/signals"
 	"github.com/DataDog/datadog-agent/cmd/agent/gui"
 	"github.com/DataDog/datadog-agent/pkg/aggregator"
+	"github.com/DataDog/datadog-agent/pkg/api/healthprobe"
 	"github.com/DataDog/datadog-agent/pkg/config"
+	"github.com/DataDog/datadog-agent/pkg/config/settings"
 	"github.com/DataDog/datadog-agent/pkg/dogstatsd"
 	"github.com/DataDog/datadog-agent/pkg/forwarder"
 	"github.com/DataDog/datadog-agent/pkg/logs"
@@ -32,9 +31,10 @@ import (
 	"github.com/DataDog/datadog-agent/pkg/pidfile"
 	"github.com/DataDog/datadog-agent/pkg/serializer"
 	"github.com/DataDog/datadog-agent/pkg/util"
+	"github.com/DataDog/datadog-agent/pkg/util/membudget"
+	"github.com/DataDog/datadog-agent/pkg/util/tracing"
 	"github.com/DataDog/datadog-agent/pkg/version"
 	log "github.com/cihub/seelog"
-	"github.com/spf13/cobra"
 
 	// register core checks
 	_ "github.com/DataDog/datadog-agent/pkg/collector/corechecks/cluster"
@@ -48,21 +48,6 @@ import (
 	_ "github.com/DataDog/datadog-agent/pkg/metadata"
 )
 
-var (
-	startCmd = &cobra.Command{
-		Use:   "start",
-		Short: "Start the Agent",
-		Long:  `Runs the agent in the foreground`,
-		RunE:  start,
-	}
-)
-
-var (
-	// flags variables
-	runForeground bool
-	pidfilePath   string
-)
-
 // run the host metadata collector every 14400 seconds (4 hours)
 const hostMetadataCollectorInterval = 14400
 
@@ -72,52 +57,6 @@ const agentChecksMetadataCollectorInterval = 600
 // run the resources metadata collector every 300 seconds (5 minutes) by default, configurable
 const defaultResourcesMetadataCollectorInterval = 300
 
-func init() {
-	// attach the command to the root
-	AgentCmd.AddCommand(startCmd)
-
-	// local flags
-	startCmd.Flags().StringVarP(&pidfilePath, "pidfile", "p", "", "path to the pidfile")
-}
-
-// Start the main loop
-func start(cmd *cobra.Command, args []string) error {
-	defer func() {
-		StopAgent()
-	}()
-
-	// Setup a channel to catch OS signals
-	signalCh := make(chan os.Signal, 1)
-	signal.Notify(signalCh, os.Interrupt, syscall.SIGTERM)
-
-	// Make a channel to exit the function
-	stopCh := make(chan error)
-
-	go func() {
-		// Set up the signals async so we can Start the agent
-		select {
-		case <-signals.Stopper:
-			log.Info("Received stop command, shutting down...")
-			stopCh <- nil
-		case <-signals.ErrorStopper:
-			log.Critical("The Agent has encountered an error, shutting down...")
-			stopCh <- fmt.Errorf("shutting down because of an error")
-		case sig := <-signalCh:
-			log.Infof("Received signal '%s', shutting down...", sig)
-			stopCh <- nil
-		}
-	}()
-
-	if err := StartAgent(); err != nil {
-		return err
-	}
-
-	select {
-	case err := <-stopCh:
-		return err
-	}
-}
-
 // StartAgent Initializes the agent process
 func StartAgent() error {
 
@@ -155,6 +94,17 @@ func StartAgent() error {
 
 	log.Infof("Starting Datadog Agent v%v", version.AgentVersion)
 
+	// register the settings that can be changed with `agent config set`
+	for _, setting := range []settings.RuntimeSetting{
+		settings.LogLevelRuntimeSetting{},
+		&settings.BlockProfileRateRuntimeSetting{},
+		settings.MutexProfileFractionRuntimeSetting{},
+	} {
+		if err := settings.RegisterRuntimeSetting(setting); err != nil {
+			log.Errorf("Cannot register the runtime setting %s: %v", setting.Name(), err)
+		}
+	}
+
 	// Setup expvar server
 	var port = config.Datadog.GetString("expvar_port")
 	go http.ListenAndServe("127.0.0.1:"+port, http.DefaultServeMux)
@@ -185,6 +135,11 @@ func StartAgent() error {
 		return log.Errorf("Error while starting api server, exiting: %v", err)
 	}
 
+	// start the health probes server, if enabled
+	if err = healthprobe.Serve(config.Datadog.GetInt("health_port")); err != nil {
+		return log.Errorf("Error while starting the health probes server, exiting: %v", err)
+	}
+
 	// start the GUI server
 	guiPort := config.Datadog.GetString("GUI_port")
 	if guiPort == "-1" {
@@ -193,6 +148,12 @@ func StartAgent() error {
 		log.Errorf("Error while starting GUI: %v", err)
 	}
 
+	// start the tracing of the agent internal operations, if enabled
+	tracing.Start()
+
+	// bound the memory of the components, if enabled
+	membudget.Start()
+
 	// setup the forwarder
 	keysPerDomain, err := config.GetMultipleEndpoints()
 	if err != nil {
@@ -214,6 +175,8 @@ func StartAgent() error {
 		common.DSD, err = dogstatsd.NewServer(agg.GetChannels())
 		if err != nil {
 			log.Errorf("Could not start dogstatsd: %s", err)
+		} else if err := settings.RegisterRuntimeSetting(settings.NewDogstatsdStatsRuntimeSetting(common.DSD)); err != nil {
+			log.Errorf("Cannot register the runtime setting dogstatsd_stats: %v", err)
 		}
 	}
 	log.Debugf("statsd started")
@@ -313,11 +276,16 @@ func StopAgent() {
 		common.MetadataScheduler.Stop()
 	}
 	api.StopServer()
+	healthprobe.Stop()
+	// flush the buffered data before stopping the forwarder sending it
+	aggregator.StopDefaultAggregator()
 	if common.Forwarder != nil {
 		common.Forwarder.Stop()
 	}
 	logs.Stop()
 	gui.StopGUIServer()
+	tracing.Stop()
+	membudget.Stop()
 	os.Remove(pidfilePath)
 	log.Info("See ya!")
 	log.Flush()
@@ -9,26 +9,34 @@ import (
 	"bytes"
 	"encoding/json"
 	"fmt"
+	"io"
 	"io/ioutil"
+	"strings"
 
 	"github.com/DataDog/datadog-agent/cmd/agent/common"
 	"github.com/DataDog/datadog-agent/pkg/api/util"
 	"github.com/DataDog/datadog-agent/pkg/config"
 	"github.com/DataDog/datadog-agent/pkg/status"
+	"github.com/DataDog/datadog-agent/pkg/util/output"
 	"github.com/spf13/cobra"
 )
 
 var (
 	jsonStatus      bool
 	prettyPrintJSON bool
+	statusFormat    string
 	statusFilePath  string
 )
 
 func init() {
 	AgentCmd.AddCommand(statusCmd)
-	statusCmd.Flags().BoolVarP(&jsonStatus, "json", "j", false, "print out raw json")
-	statusCmd.Flags().BoolVarP(&prettyPrintJSON, "pretty-json", "p", false, "pretty print JSON")
-	statusCmd.Flags().StringVarP(&statusFilePath, "file", "o", "", "Output the status command to a file")
+	statusCmd.PersistentFlags().StringVarP(&statusFormat, "output", "o", output.Table, output.FlagUsage)
+	statusCmd.PersistentFlags().BoolVarP(&jsonStatus, "json", "j", false, "print out raw json")
+	statusCmd.PersistentFlags().BoolVarP(&prettyPrintJSON, "pretty-json", "p", false, "pretty print JSON")
+	statusCmd.PersistentFlags().MarkDeprecated("json", "use -o json instead")
+	statusCmd.PersistentFlags().MarkDeprecated("pretty-json", "use -o json instead")
+	statusCmd.PersistentFlags().StringVarP(&statusFilePath, "file", "f", "", "Output the status command to a file")
+	statusCmd.AddCommand(componentCmd)
 }
 
 var statusCmd = &cobra.Command{
@@ -40,7 +48,7 @@ var statusCmd = &cobra.Command{
 		if err != nil {
 			return fmt.Errorf("unable to set up global agent configuration: %v", err)
 		}
-		err = requestStatus()
+		err = requestStatus("")
 		if err != nil {
 			return err
 		}
@@ -48,10 +56,36 @@ var statusCmd = &cobra.Command{
 	},
 }
 
-func requestStatus() error {
-	fmt.Printf("Getting the status from the agent.\n\n")
-	var e error
-	var s string
+var componentCmd = &cobra.Command{
+	Use:   "component <name>",
+	Short: "Print the status of a single component",
+	Long:  fmt.Sprintf("Print the status of a single component, one of: %s", strings.Join(status.ComponentNames(), ", ")),
+	Args:  cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		err := common.SetupConfig(confFilePath)
+		if err != nil {
+			return fmt.Errorf("unable to set up global agent configuration: %v", err)
+		}
+		return requestStatus(args[0])
+	},
+}
+
+// requestStatus prints the status of the running agent, or only the status
+// of a component if set
+func requestStatus(component string) error {
+	if jsonStatus || prettyPrintJSON {
+		statusFormat = output.JSON
+	}
+	var out bytes.Buffer
+	writer, e := output.NewWriter(statusFormat, &out)
+	if e != nil {
+		return e
+	}
+
+	// Keep the JSON and YAML outputs parsable by scripts
+	if writer.IsHuman() {
+		fmt.Printf("Getting the status from the agent.\n\n")
+	}
 	c := util.GetClient(false) // FIX: get certificates right then make this true
 	urlstr := fmt.Sprintf("https://localhost:%v/agent/status", config.Datadog.GetInt("cmd_port"))
 
@@ -74,25 +108,36 @@ func requestStatus() error {
 		return e
 	}
 
+	if component != "" && !writer.IsHuman() {
+		r, e = status.GetComponent(r, component)
+		if e != nil {
+			return e
+		}
+	}
+
 	// The rendering is done in the client so that the agent has less work to do
-	if prettyPrintJSON {
-		var prettyJSON bytes.Buffer
-		json.Indent(&prettyJSON, r, "", "  ")
-		s = prettyJSON.String()
-	} else if jsonStatus {
-		s = string(r)
-	} else {
-		formattedStatus, err := status.FormatStatus(r)
+	e = writer.Write(r, func(w io.Writer, payload []byte, wide bool) error {
+		var formattedStatus string
+		var err error
+		if component != "" {
+			formattedStatus, err = status.FormatComponent(payload, component)
+		} else {
+			formattedStatus, err = status.FormatStatus(payload)
+		}
 		if err != nil {
 			return err
 		}
-		s = formattedStatus
+		_, err = fmt.Fprintln(w, formattedStatus)
+		return err
+	})
+	if e != nil {
+		return e
 	}
 
 	if statusFilePath != "" {
-		ioutil.WriteFile(statusFilePath, []byte(s), 0644)
+		ioutil.WriteFile(statusFilePath, out.Bytes(), 0644)
 	} else {
-		fmt.Println(s)
+		fmt.Print(out.String())
 	}
 
 	return nil
@@ -0,0 +1,145 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2018 Datadog, Inc.
+
+package app
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/fatih/color"
+	"github.com/spf13/cobra"
+
+	"github.com/DataDog/datadog-agent/cmd/agent/common"
+	"github.com/DataDog/datadog-agent/pkg/api/util"
+	"github.com/DataDog/datadog-agent/pkg/config"
+	"github.com/DataDog/datadog-agent/pkg/logs/diagnostic"
+)
+
+// streamLogsMaxLineSize is the maximum size of a streamed message, the
+// logs-agent truncates the log lines way before that
+const streamLogsMaxLineSize = 1024 * 1024
+
+var (
+	streamLogsSource  string
+	streamLogsService string
+	streamLogsJSON    bool
+)
+
+func init() {
+	AgentCmd.AddCommand(streamLogsCmd)
+	streamLogsCmd.Flags().StringVar(&streamLogsSource, "source", "", "only print the logs of this source")
+	streamLogsCmd.Flags().StringVar(&streamLogsService, "service", "", "only print the logs of this service")
+	streamLogsCmd.Flags().BoolVarP(&streamLogsJSON, "json", "j", false, "print out raw json")
+}
+
+var streamLogsCmd = &cobra.Command{
+	Use:   "stream-logs",
+	Short: "Stream the logs processed by a running agent",
+	Long: `Print the logs as they are processed by the logs-agent of the running agent,
+with their source, service, tags and the processing rules they matched.
+Logs dropped by a processing rule are printed as well.`,
+	SilenceUsage: true,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		err := common.SetupConfig(confFilePath)
+		if err != nil {
+			return fmt.Errorf("unable to set up global agent configuration: %v", err)
+		}
+		return streamLogs()
+	},
+}
+
+func streamLogs() error {
+	if flagNoColor {
+		color.NoColor = true
+	}
+
+	query := url.Values{}
+	if streamLogsSource != "" {
+		query.Set("source", streamLogsSource)
+	}
+	if streamLogsService != "" {
+		query.Set("service", streamLogsService)
+	}
+	urlstr := fmt.Sprintf("https://localhost:%v/agent/stream-logs?%s", config.Datadog.GetInt("cmd_port"), query.Encode())
+
+	// Set session token
+	err := util.SetAuthToken()
+	if err != nil {
+		return err
+	}
+	c := util.GetClient(false) // FIX: get certificates right then make this true
+
+	if !streamLogsJSON {
+		fmt.Printf("Streaming the logs processed by the agent, press Ctrl+C to stop.\n\n")
+	}
+	// the agent ends the stream before its write timeout, reconnect until interrupted
+	for {
+		if err := streamLogsOnce(c, urlstr); err != nil {
+			fmt.Printf("Could not reach agent: %v \nMake sure the agent is running and logs_enabled is set to true.\n", err)
+			return err
+		}
+	}
+}
+
+// streamLogsOnce prints the messages streamed by the agent until the
+// end of the response
+func streamLogsOnce(c *http.Client, urlstr string) error {
+	req, err := http.NewRequest("GET", urlstr, nil)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Authorization", "Bearer "+util.GetAuthToken())
+
+	resp, err := c.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= 400 {
+		body, _ := ioutil.ReadAll(resp.Body)
+		return fmt.Errorf("%s", strings.TrimSpace(string(body)))
+	}
+
+	scanner := bufio.NewScanner(resp.Body)
+	scanner.Buffer(make([]byte, 0, 64*1024), streamLogsMaxLineSize)
+	for scanner.Scan() {
+		if streamLogsJSON {
+			fmt.Println(scanner.Text())
+			continue
+		}
+		var msg diagnostic.Message
+		if err := json.Unmarshal(scanner.Bytes(), &msg); err != nil {
+			return fmt.Errorf("unexpected message %q: %v", scanner.Text(), err)
+		}
+		fmt.Println(formatStreamedLog(msg))
+	}
+	return scanner.Err()
+}
+
+// formatStreamedLog returns a one-line representation of a message
+func formatStreamedLog(msg diagnostic.Message) string {
+	var b bytes.Buffer
+	if msg.Dropped {
+		b.WriteString(color.RedString("DROPPED "))
+	}
+	fmt.Fprintf(&b, "%s | type:%s source:%s service:%s",
+		color.BlueString(msg.Integration), msg.Type, msg.Source, msg.Service)
+	if len(msg.Tags) > 0 {
+		fmt.Fprintf(&b, " | tags:%s", strings.Join(msg.Tags, ","))
+	}
+	if len(msg.AppliedRules) > 0 {
+		fmt.Fprintf(&b, " | rules:%s", color.YellowString(strings.Join(msg.AppliedRules, ",")))
+	}
+	fmt.Fprintf(&b, " | %s", msg.Content)
+	return b.String()
+}
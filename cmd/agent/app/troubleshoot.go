@@ -0,0 +1,89 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2018 Datadog, Inc.
+
+package app
+
+import (
+	"fmt"
+
+	"github.com/DataDog/datadog-agent/cmd/agent/common"
+	"github.com/DataDog/datadog-agent/pkg/config"
+	"github.com/DataDog/datadog-agent/pkg/flare"
+
+	"github.com/fatih/color"
+	"github.com/spf13/cobra"
+)
+
+var (
+	troubleshootSend  bool
+	troubleshootEmail string
+)
+
+var (
+	troubleshootCmd = &cobra.Command{
+		Use:   "troubleshoot",
+		Short: "Collect diagnostic bundles for the support",
+		Long:  ``,
+	}
+
+	troubleshootKubernetesCmd = &cobra.Command{
+		Use:   "kubernetes [caseID]",
+		Short: "Collect a diagnostic bundle of the connections of the agent to Kubernetes",
+		Long: `Checks the connections of the agent to the Kubernetes components, and writes
+the results in an archive: the connection to the kubelet, the RBAC review and
+latency samples of the apiserver, the metadata of the node, the connection to
+the Cluster Agent and the state of the leader election.`,
+		RunE: doTroubleshootKubernetes,
+	}
+)
+
+func init() {
+	AgentCmd.AddCommand(troubleshootCmd)
+	troubleshootCmd.AddCommand(troubleshootKubernetesCmd)
+
+	troubleshootKubernetesCmd.Flags().BoolVarP(&troubleshootSend, "send", "s", false, "Upload the archive to Datadog, attached to the caseID")
+	troubleshootKubernetesCmd.Flags().StringVarP(&troubleshootEmail, "email", "e", "", "Your email")
+}
+
+func doTroubleshootKubernetes(cmd *cobra.Command, args []string) error {
+	err := common.SetupConfig(confFilePath)
+	if err != nil {
+		return err
+	}
+
+	if flagNoColor {
+		color.NoColor = true
+	}
+
+	caseID := ""
+	if len(args) > 0 {
+		caseID = args[0]
+	}
+
+	// The command should not log anything, the results are in the archive
+	config.SetupLogger("off", "", "", false, false, "", true, false)
+
+	fmt.Fprintln(color.Output, color.BlueString("Checking the connections to the Kubernetes components, this can take a while."))
+	filePath, err := flare.CreateKubernetesArchive()
+	if err != nil {
+		fmt.Printf("The archive failed to be created: %s\n", err)
+		return err
+	}
+	fmt.Fprintln(color.Output, fmt.Sprintf("Kubernetes troubleshooting archive written to %s", color.YellowString(filePath)))
+
+	if !troubleshootSend {
+		return nil
+	}
+	if troubleshootEmail == "" {
+		troubleshootEmail, err = flare.AskForEmail()
+		if err != nil {
+			fmt.Println("Error reading email, please retry or contact support")
+			return err
+		}
+	}
+	response, err := flare.SendFlare(filePath, caseID, troubleshootEmail)
+	fmt.Println(response)
+	return err
+}
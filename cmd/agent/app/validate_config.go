@@ -0,0 +1,44 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2018 Datadog, Inc.
+
+package app
+
+import (
+	"fmt"
+	"os"
+
+	log "github.com/cihub/seelog"
+	"github.com/spf13/cobra"
+
+	"github.com/DataDog/datadog-agent/cmd/agent/common"
+)
+
+func init() {
+	AgentCmd.AddCommand(validateConfigCmd)
+}
+
+var validateConfigCmd = &cobra.Command{
+	Use:   common.ValidateConfigCommand,
+	Short: "Validate the configuration before the running agent applies it",
+	Long: `Load datadog.yaml, the check configurations and the log sources, and print
+the errors found. Run by the agent in a subprocess when it is reloaded, so that
+an invalid configuration is refused without affecting the running agent.`,
+	Args:         cobra.NoArgs,
+	Hidden:       true,
+	SilenceUsage: true,
+	Run: func(cmd *cobra.Command, args []string) {
+		// the warnings of the parsers would be mixed with the errors
+		log.ReplaceLogger(log.Disabled)
+
+		errs := common.ValidateConfig(confFilePath)
+		if len(errs) == 0 {
+			return
+		}
+		for _, err := range errs {
+			fmt.Println(err)
+		}
+		os.Exit(1)
+	},
+}
@@ -0,0 +1,128 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2018 Datadog, Inc.
+
+package common
+
+import (
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+
+	log "github.com/cihub/seelog"
+
+	"github.com/DataDog/datadog-agent/pkg/config"
+	"github.com/DataDog/datadog-agent/pkg/diagnose/diagnosis"
+	logsConfig "github.com/DataDog/datadog-agent/pkg/logs/config"
+)
+
+func init() {
+	diagnosis.Register("File permissions", diagnosePermissions)
+}
+
+// diagnosePermissions checks the agent can write its own log file and
+// read the files collected by the logs-agent
+func diagnosePermissions() error {
+	var lastErr error
+
+	if !config.Datadog.GetBool("disable_file_logging") {
+		logFile := config.Datadog.GetString("log_file")
+		if logFile == "" {
+			logFile = DefaultLogFile
+		}
+		if err := checkWritableFile(logFile); err != nil {
+			log.Error(err)
+			lastErr = diagnosis.WithRemediation(err, fmt.Sprintf("give the user running the agent write access to %s, or change the log_file setting", logFile))
+		} else {
+			log.Infof("agent log file %s is writable", logFile)
+		}
+	}
+
+	if !config.Datadog.GetBool("logs_enabled") && !config.Datadog.GetBool("log_enabled") {
+		return lastErr
+	}
+
+	runPath := config.Datadog.GetString("logs_config.run_path")
+	if err := checkWritableDir(runPath); err != nil {
+		log.Error(err)
+		lastErr = diagnosis.WithRemediation(err, fmt.Sprintf("give the user running the agent write access to %s, or change the logs_config.run_path setting", runPath))
+	} else {
+		log.Infof("logs run path %s is writable", runPath)
+	}
+
+	sources, err := logsConfig.Build()
+	if err != nil {
+		// no sources configured, nothing to check
+		log.Info(err)
+		return lastErr
+	}
+	for _, source := range sources.GetValidSources() {
+		if source.Config.Type != logsConfig.FileType {
+			continue
+		}
+		if err := checkReadableFiles(source.Config.Path); err != nil {
+			log.Errorf("%s: %s", source.Name, err)
+			lastErr = diagnosis.WithRemediation(err, "add the user running the agent to a group allowed to read the collected log files, or change their permissions")
+		} else {
+			log.Infof("%s: %s is readable", source.Name, source.Config.Path)
+		}
+	}
+
+	return lastErr
+}
+
+// checkWritableFile checks a file can be opened for writing, or created if
+// it doesn't exist yet
+func checkWritableFile(path string) error {
+	f, err := os.OpenFile(path, os.O_WRONLY|os.O_APPEND, 0)
+	if os.IsNotExist(err) {
+		return checkWritableDir(filepath.Dir(path))
+	}
+	if err != nil {
+		return err
+	}
+	return f.Close()
+}
+
+// checkWritableDir checks a file can be created in a directory
+func checkWritableDir(dir string) error {
+	f, err := ioutil.TempFile(dir, ".diagnose")
+	if err != nil {
+		return err
+	}
+	f.Close()
+	return os.Remove(f.Name())
+}
+
+// checkReadableFiles checks every file matching pattern can be opened for
+// reading
+func checkReadableFiles(pattern string) error {
+	paths, err := filepath.Glob(pattern)
+	if err != nil {
+		return err
+	}
+	if len(paths) == 0 {
+		// the files may not exist yet, only the directory must be readable
+		dir, err := os.Open(filepath.Dir(pattern))
+		if err != nil {
+			return err
+		}
+		defer dir.Close()
+		if _, err := dir.Readdirnames(1); err != nil && err != io.EOF {
+			return err
+		}
+		log.Warnf("no file matches %s yet", pattern)
+		return nil
+	}
+	for _, path := range paths {
+		f, err := os.Open(path)
+		if err != nil {
+			return err
+		}
+		f.Close()
+	}
+	return nil
+}
@@ -0,0 +1,91 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2018 Datadog, Inc.
+
+package common
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/DataDog/datadog-agent/pkg/autodiscovery/providers"
+	"github.com/DataDog/datadog-agent/pkg/config"
+	logsConfig "github.com/DataDog/datadog-agent/pkg/logs/config"
+)
+
+// ValidateConfigCommand is the hidden command of the agent validating its
+// configuration, run by ValidateConfigInSubprocess
+const ValidateConfigCommand = "validate-config"
+
+// validateConfigTimeout bounds the time the validation subprocess can take
+const validateConfigTimeout = 30 * time.Second
+
+// ValidateConfig loads datadog.yaml, the check configurations of the file
+// provider and the log sources, and returns the errors found. It changes the
+// global configuration, it is meant to run in its own process.
+func ValidateConfig(confFilePath string) []error {
+	if err := SetupConfig(confFilePath); err != nil {
+		return []error{err}
+	}
+
+	var errs []error
+	fileProvider := providers.NewFileConfigProvider([]string{
+		config.Datadog.GetString("confd_path"),
+		filepath.Join(GetDistPath(), "conf.d"),
+	})
+	if _, err := fileProvider.Collect(); err != nil {
+		errs = append(errs, fmt.Errorf("unable to collect the check configurations: %s", err))
+	}
+	checkNames := make([]string, 0, len(fileProvider.Errors))
+	for checkName := range fileProvider.Errors {
+		checkNames = append(checkNames, checkName)
+	}
+	sort.Strings(checkNames)
+	for _, checkName := range checkNames {
+		errs = append(errs, fmt.Errorf("invalid configuration of the %s check: %s", checkName, fileProvider.Errors[checkName]))
+	}
+
+	if config.Datadog.GetBool("logs_enabled") || config.Datadog.GetBool("log_enabled") {
+		if _, err := logsConfig.Build(); err != nil {
+			errs = append(errs, fmt.Errorf("invalid log sources: %s", err))
+		}
+	}
+	return errs
+}
+
+// ValidateConfigInSubprocess runs ValidateConfigCommand in a new agent
+// process, on the configuration the running agent was started with, so that
+// a configuration crashing or hanging the parsers can't take the running
+// agent down. It returns the errors found by the subprocess.
+func ValidateConfigInSubprocess() error {
+	executable, err := os.Executable()
+	if err != nil {
+		return fmt.Errorf("unable to locate the agent executable: %s", err)
+	}
+
+	args := []string{ValidateConfigCommand}
+	if cfgFile := config.Datadog.ConfigFileUsed(); cfgFile != "" {
+		args = append(args, "--cfgpath", cfgFile)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), validateConfigTimeout)
+	defer cancel()
+	output, err := exec.CommandContext(ctx, executable, args...).CombinedOutput()
+	if ctx.Err() == context.DeadlineExceeded {
+		return fmt.Errorf("the validation of the configuration timed out after %s", validateConfigTimeout)
+	}
+	if err != nil {
+		if msg := strings.TrimSpace(string(output)); msg != "" {
+			return fmt.Errorf("%s", msg)
+		}
+		return fmt.Errorf("the validation of the configuration failed: %s", err)
+	}
+	return nil
+}
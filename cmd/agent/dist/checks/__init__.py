@@ -317,7 +317,32 @@ class AgentCheck(object):
     def warning(self, warning_message):
         warning_message = str(warning_message)
         self.log.warning(warning_message)
-        self.warnings.append(warning_message)
+        check_id = getattr(self, 'check_id', None)
+        if check_id:
+            # stored by the agent, so that the warnings aren't lost if the run fails
+            datadog_agent.warning(check_id, warning_message)
+        else:
+            self.warnings.append(warning_message)
+
+    def set_metadata(self, name, value):
+        """
+        Report a metadata value of the check instance, like the version of the
+        software it monitors, sent with the host metadata
+        """
+        if not isinstance(value, basestring):
+            value = str(value)
+        datadog_agent.set_check_metadata(self.check_id, ensure_bytes(name), ensure_bytes(value))
+
+    def send_log(self, log_line, attributes=None):
+        """
+        Send a log line to the logs-agent, with its attributes. The source of
+        the log defaults to the name of the check, the `ddsource`, `service`,
+        `ddtags` and `status` attributes tag the log. The log is dropped if the
+        logs-agent isn't running.
+        """
+        attributes = dict(attributes or {})
+        attributes.setdefault('ddsource', self.name)
+        datadog_agent.send_log(ensure_bytes(log_line), attributes)
 
     def get_warnings(self):
         """
@@ -0,0 +1,71 @@
+# Unless explicitly stated otherwise all files in this repository are licensed
+# under the Apache License Version 2.0.
+# This product includes software developed at Datadog (https://www.datadoghq.com/).
+# Copyright 2018 Datadog, Inc.
+
+import cProfile
+import pstats
+
+try:
+    from cStringIO import StringIO
+except ImportError:
+    from io import StringIO
+
+try:
+    # only part of the standard library from python 3.4, can be installed
+    # with `pytracemalloc` on a patched python 2.7
+    import tracemalloc
+except ImportError:
+    tracemalloc = None
+
+DEFAULT_TOP = 20
+
+
+class CheckProfiler(object):
+    """
+    Profiles the CPU time and the memory allocations of a check run. It has to
+    be started and stopped on the thread running the check.
+    """
+
+    def __init__(self, top=DEFAULT_TOP):
+        self.top = top
+        self.profile = cProfile.Profile()
+        self.tracing = False
+
+    def start(self):
+        # don't take over a trace started by someone else
+        if tracemalloc is not None and not tracemalloc.is_tracing():
+            tracemalloc.start()
+            self.tracing = True
+        self.profile.enable()
+
+    def stop(self):
+        """
+        Stops profiling and returns the report: the functions taking the most
+        time, and the lines allocating the most memory.
+        """
+        self.profile.disable()
+
+        snapshot = None
+        if self.tracing:
+            snapshot = tracemalloc.take_snapshot()
+            tracemalloc.stop()
+            self.tracing = False
+
+        report = StringIO()
+        report.write("=== CPU: top %d functions by cumulative time ===\n" % self.top)
+        stats = pstats.Stats(self.profile, stream=report)
+        stats.sort_stats('cumulative').print_stats(self.top)
+
+        report.write("=== Memory: top %d allocations by line ===\n" % self.top)
+        if snapshot is None:
+            report.write("tracemalloc is not available, the memory allocations are not profiled\n")
+        else:
+            snapshot = snapshot.filter_traces((
+                tracemalloc.Filter(False, tracemalloc.__file__),
+                tracemalloc.Filter(False, __file__),
+            ))
+            for stat in snapshot.statistics('lineno')[:self.top]:
+                report.write("%s\n" % stat)
+
+        return report.getvalue()
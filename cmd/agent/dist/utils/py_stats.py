@@ -0,0 +1,39 @@
+# Unless explicitly stated otherwise all files in this repository are licensed
+# under the Apache License Version 2.0.
+# This product includes software developed at Datadog (https://www.datadoghq.com/).
+# Copyright 2018 Datadog, Inc.
+
+import gc
+import sys
+import threading
+
+
+def get_interpreter_stats():
+    """
+    Return the stats of the interpreter, by name: the objects tracked by the
+    garbage collector and their size, the state of the collector, and the
+    blocks allocated when the interpreter provides them.
+    """
+    objects = gc.get_objects()
+    stats = {
+        'objects': len(objects),
+        'objects.size': sum(sys.getsizeof(o, 0) for o in objects),
+        'gc.garbage': len(gc.garbage),
+        'modules': len(sys.modules),
+        'threads': threading.active_count(),
+    }
+    del objects
+
+    # the allocations since the last collection of each generation
+    for generation, count in enumerate(gc.get_count()):
+        stats['gc.pending.gen%d' % generation] = count
+
+    # only available from python 3.4
+    if hasattr(gc, 'get_stats'):
+        for generation, gen_stats in enumerate(gc.get_stats()):
+            for name in ('collections', 'collected', 'uncollectable'):
+                stats['gc.%s.gen%d' % (name, generation)] = gen_stats[name]
+    if hasattr(sys, 'getallocatedblocks'):
+        stats['allocated_blocks'] = sys.getallocatedblocks()
+
+    return stats
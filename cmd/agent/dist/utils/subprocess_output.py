@@ -10,13 +10,15 @@ import logging
 
 from _util import get_subprocess_output as subprocess_output
 from _util import SubprocessOutputEmptyError  # noqa
+from _util import SubprocessOutputTimeoutError  # noqa
 
 log = logging.getLogger(__name__)
 
-def get_subprocess_output(command, log, raise_on_empty_output=True):
+def get_subprocess_output(command, log, raise_on_empty_output=True, timeout=None):
     """
     Run the given subprocess command and return its output. Raise an Exception
-    if an error occurs.
+    if an error occurs, a SubprocessOutputTimeoutError if it runs longer than
+    `timeout` seconds.
     """
 
     cmd_args = []
@@ -30,7 +32,7 @@ def get_subprocess_output(command, log, raise_on_empty_output=True):
         raise TypeError("command must be a sequence or string")
 
     log.debug("Running get_subprocess_output with cmd: %s", cmd_args)
-    out, err, returncode = subprocess_output(cmd_args, raise_on_empty_output)
+    out, err, returncode = subprocess_output(cmd_args, raise_on_empty_output, timeout or 0)
     log.debug("get_subprocess_output with cmd %s returned (len(out): %d ; len(err): %d ; returncode: %d)", cmd_args, len(out), len(err), returncode)
 
     return (out, err, returncode)
@@ -12,18 +12,23 @@ import (
 	"encoding/json"
 	"fmt"
 	"net/http"
+	"sort"
+	"strconv"
 
 	log "github.com/cihub/seelog"
 	"github.com/gorilla/mux"
 
+	"github.com/DataDog/datadog-agent/cmd/agent/api/response"
 	"github.com/DataDog/datadog-agent/cmd/agent/common"
 	"github.com/DataDog/datadog-agent/cmd/agent/common/signals"
 	apiutil "github.com/DataDog/datadog-agent/pkg/api/util"
+	"github.com/DataDog/datadog-agent/pkg/autodiscovery"
 	"github.com/DataDog/datadog-agent/pkg/config"
 	"github.com/DataDog/datadog-agent/pkg/flare"
 	"github.com/DataDog/datadog-agent/pkg/status"
 	"github.com/DataDog/datadog-agent/pkg/util"
 	as "github.com/DataDog/datadog-agent/pkg/util/kubernetes/apiserver"
+	"github.com/DataDog/datadog-agent/pkg/util/kubernetes/apiserver/leaderelection"
 	"github.com/DataDog/datadog-agent/pkg/version"
 )
 
@@ -32,6 +37,15 @@ var EventChecks = []string{
 	"kubernetes",
 }
 
+// tokenRotator rotates the auth token shared with the node agents,
+// nil if the token is set in the configuration
+var tokenRotator *apiutil.DCATokenRotator
+
+// SetTokenRotator registers the rotator used by the auth-token rotate command
+func SetTokenRotator(r *apiutil.DCATokenRotator) {
+	tokenRotator = r
+}
+
 // SetupHandlers adds the specific handlers for cluster agent endpoints
 func SetupHandlers(r *mux.Router) {
 	r.HandleFunc("/version", getVersion).Methods("GET")
@@ -39,6 +53,9 @@ func SetupHandlers(r *mux.Router) {
 	r.HandleFunc("/flare", makeFlare).Methods("POST")
 	r.HandleFunc("/stop", stopAgent).Methods("POST")
 	r.HandleFunc("/status", getStatus).Methods("GET")
+	r.HandleFunc("/config-check", getConfigCheck).Methods("GET")
+	r.HandleFunc("/leader", getLeader).Methods("GET")
+	r.HandleFunc("/auth-token/rotate", rotateAuthToken).Methods("POST")
 	r.HandleFunc("/api/v1/metadata/{nodeName}/{podName}", getPodMetadata).Methods("GET")
 	r.HandleFunc("/api/v1/metadata/{nodeName}", getNodeMetadata).Methods("GET")
 	r.HandleFunc("/api/v1/metadata", getAllMetadata).Methods("GET")
@@ -67,6 +84,74 @@ func getStatus(w http.ResponseWriter, r *http.Request) {
 	w.Write(jsonStats)
 }
 
+// getConfigCheck returns the checks scheduled by the Cluster Agent, used by the clusterchecks command
+func getConfigCheck(w http.ResponseWriter, r *http.Request) {
+	if err := apiutil.Validate(w, r); err != nil {
+		return
+	}
+
+	var response response.ConfigCheckResponse
+
+	configs := common.AC.GetLoadedConfigs()
+	sort.Slice(configs, func(i, j int) bool {
+		return configs[i].Name < configs[j].Name
+	})
+	response.Configs = configs
+	response.ResolveWarnings = autodiscovery.GetResolveWarnings()
+	response.ConfigErrors = autodiscovery.GetConfigErrors()
+	response.Unresolved = common.AC.GetUnresolvedTemplates()
+
+	json, err := json.Marshal(response)
+	if err != nil {
+		log.Errorf("Unable to marshal config check response: %s", err)
+	}
+
+	w.Write(json)
+}
+
+// getLeader returns the state of the leader election, and whether this
+// Cluster Agent is the leader
+func getLeader(w http.ResponseWriter, r *http.Request) {
+	if err := apiutil.Validate(w, r); err != nil {
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+
+	details := map[string]string{"status": "Disabled"}
+	if config.Datadog.GetBool("leader_election") {
+		details = status.GetLeaderElectionDetails()
+		if leaderEngine, err := leaderelection.GetLeaderEngine(); err == nil {
+			details["identity"] = leaderEngine.HolderIdentity
+			details["isLeader"] = strconv.FormatBool(leaderEngine.IsLeader())
+		}
+	}
+	j, err := json.Marshal(details)
+	if err != nil {
+		http.Error(w, fmt.Sprintf(`{"error":%q}`, err.Error()), 500)
+		return
+	}
+	w.Write(j)
+}
+
+// rotateAuthToken replaces the auth token shared with the node agents without
+// waiting for the rotation interval
+func rotateAuthToken(w http.ResponseWriter, r *http.Request) {
+	if err := apiutil.Validate(w, r); err != nil {
+		return
+	}
+	if tokenRotator == nil {
+		http.Error(w, "the auth token is set in the configuration, it can't be rotated", 409)
+		return
+	}
+	if err := tokenRotator.RotateNow(); err != nil {
+		log.Errorf("Could not rotate the cluster agent auth token: %s", err)
+		http.Error(w, err.Error(), 500)
+		return
+	}
+	log.Infof("Rotated the cluster agent auth token on request")
+	w.Write([]byte("OK"))
+}
+
 // TODO: make sure it works for DCA
 func stopAgent(w http.ResponseWriter, r *http.Request) {
 	if err := apiutil.ValidateDCARequest(w, r); err != nil {
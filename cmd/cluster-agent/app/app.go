@@ -14,17 +14,21 @@ import (
 	_ "expvar" // Blank import used because this isn't directly used in this file
 
 	log "github.com/cihub/seelog"
+	"github.com/fatih/color"
 	"github.com/spf13/cobra"
 
 	"github.com/DataDog/datadog-agent/cmd/agent/common"
 	"github.com/DataDog/datadog-agent/cmd/cluster-agent/api"
+	"github.com/DataDog/datadog-agent/cmd/cluster-agent/api/agent"
 	"github.com/DataDog/datadog-agent/pkg/aggregator"
+	apiutil "github.com/DataDog/datadog-agent/pkg/api/util"
 	"github.com/DataDog/datadog-agent/pkg/clusteragent"
 	"github.com/DataDog/datadog-agent/pkg/config"
 	"github.com/DataDog/datadog-agent/pkg/forwarder"
 	"github.com/DataDog/datadog-agent/pkg/serializer"
 	"github.com/DataDog/datadog-agent/pkg/util"
 	"github.com/DataDog/datadog-agent/pkg/util/kubernetes/apiserver"
+	"github.com/DataDog/datadog-agent/pkg/util/tracing"
 	"github.com/DataDog/datadog-agent/pkg/version"
 )
 
@@ -69,6 +73,29 @@ func init() {
 	ClusterAgentCmd.PersistentFlags().BoolVarP(&flagNoColor, "no-color", "n", false, "disable color output")
 }
 
+// setupConfig reads the datadog-cluster.yaml file of the cfgpath folder, if
+// set, for the commands querying the running Cluster Agent
+func setupConfig() {
+	configFound := false
+	// a path to the folder containing the config file was passed
+	if len(confPath) != 0 {
+		// we'll search for a config file named `datadog-cluster.yaml`
+		config.Datadog.AddConfigPath(confPath)
+		confErr := config.Datadog.ReadInConfig()
+		if confErr != nil {
+			log.Error(confErr)
+		} else {
+			configFound = true
+		}
+	}
+	if !configFound {
+		log.Debugf("Config read from env variables")
+	}
+	if flagNoColor {
+		color.NoColor = true
+	}
+}
+
 func start(cmd *cobra.Command, args []string) error {
 	configFound := false
 
@@ -131,6 +158,13 @@ func start(cmd *cobra.Command, args []string) error {
 		return log.Errorf("Error while starting api server, exiting: %v", err)
 	}
 
+	// share the auth token with the node agents and rotate it, if enabled
+	tokenRotator := startAuthTokenRotation()
+	agent.SetTokenRotator(tokenRotator)
+
+	// start the tracing of the agent internal operations, if enabled
+	tracing.Start()
+
 	// setup the forwarder
 	keysPerDomain, err := config.GetMultipleEndpoints()
 	if err != nil {
@@ -155,6 +189,11 @@ func start(cmd *cobra.Command, args []string) error {
 		log.Errorf("Could not instantiate the API Server Client: %s", err.Error())
 	} else {
 		asc.StartMetadataMapping()
+		if config.Datadog.GetBool("cluster_agent.token_review.enabled") {
+			// accept the tokens of the service accounts allowed by RBAC
+			apiutil.RegisterDCAAuthenticator(apiserver.NewTokenReviewAuthenticator(asc))
+			log.Info("Authenticating the Kubernetes tokens with TokenReviews")
+		}
 	}
 
 	// Setup a channel to catch OS signals
@@ -168,6 +207,10 @@ func start(cmd *cobra.Command, args []string) error {
 	<-signalCh
 
 	clusterAgent.Stop()
+	if tokenRotator != nil {
+		tokenRotator.Stop()
+	}
+	tracing.Stop()
 	log.Info("See ya!")
 	log.Flush()
 	return nil
@@ -0,0 +1,118 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2018 Datadog, Inc.
+
+package app
+
+import (
+	"fmt"
+
+	log "github.com/cihub/seelog"
+	"github.com/fatih/color"
+	"github.com/spf13/cobra"
+
+	"github.com/DataDog/datadog-agent/pkg/api/util"
+	"github.com/DataDog/datadog-agent/pkg/config"
+	"github.com/DataDog/datadog-agent/pkg/util/kubernetes/apiserver"
+	"github.com/DataDog/datadog-agent/pkg/util/kubernetes/apiserver/leaderelection"
+)
+
+func init() {
+	ClusterAgentCmd.AddCommand(authTokenCmd)
+	authTokenCmd.AddCommand(authTokenRotateCmd)
+}
+
+var authTokenCmd = &cobra.Command{
+	Use:   "auth-token",
+	Short: "Manage the auth token shared with the node agents",
+	Long:  ``,
+}
+
+var authTokenRotateCmd = &cobra.Command{
+	Use:   "rotate",
+	Short: "Rotate the auth token shared with the node agents now",
+	Long: `Replace the auth token shared with the node agents without waiting for the
+rotation interval, e.g. after it leaked. The previous token stays valid during
+cluster_agent.auth_token_rotation_overlap. With several replicas, the command
+must be run on the leader.`,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		setupConfig()
+		return requestAuthTokenRotation()
+	},
+}
+
+func requestAuthTokenRotation() error {
+	c := util.GetClient(false) // FIX: get certificates right then make this true
+	urlstr := fmt.Sprintf("https://localhost:%v/auth-token/rotate", config.Datadog.GetInt("cluster_agent_cmd_port"))
+
+	// Set session token
+	err := util.SetAuthToken()
+	if err != nil {
+		return err
+	}
+
+	r, err := util.DoPost(c, urlstr, "text/plain", nil)
+	if err != nil {
+		if r != nil && string(r) != "" {
+			fmt.Fprintln(color.Output, fmt.Sprintf("The Cluster Agent could not rotate the auth token: %s", color.RedString(string(r))))
+		} else {
+			fmt.Fprintln(color.Output, color.RedString("Could not reach the Cluster Agent (is it running?): %s", err))
+		}
+		return err
+	}
+	fmt.Fprintln(color.Output, color.GreenString("The auth token was rotated"))
+	return nil
+}
+
+// startAuthTokenRotation publishes the auth token shared with the node agents,
+// in a Kubernetes Secret if "cluster_agent.auth_token_secret_name" is set, and
+// rotates it on the configured schedule.
+// Returns nil if the token is set in the configuration, as it can't be rotated.
+func startAuthTokenRotation() *util.DCATokenRotator {
+	if config.Datadog.GetString("cluster_agent.auth_token") != "" {
+		if config.Datadog.GetInt("cluster_agent.auth_token_rotation_interval") > 0 {
+			log.Warnf("cluster_agent.auth_token is set in the configuration, it will not be rotated")
+		}
+		return nil
+	}
+
+	var store util.DCATokenStore = util.DCATokenFileStore{}
+	var isLeader func() bool
+	if name := config.Datadog.GetString("cluster_agent.auth_token_secret_name"); name != "" {
+		secretStore, err := apiserver.NewSecretTokenStore(name)
+		if err != nil {
+			log.Errorf("Could not use the secret %q to store the cluster agent auth token: %s", name, err)
+			return nil
+		}
+		store = secretStore
+		isLeader = authTokenLeader()
+	}
+
+	rotator := util.NewDCATokenRotator(store, isLeader)
+	if err := rotator.Sync(); err != nil {
+		log.Errorf("Could not synchronize the cluster agent auth token: %s", err)
+	}
+	rotator.Start()
+	return rotator
+}
+
+// authTokenLeader returns the function electing the Cluster Agent rotating the
+// token shared in the Secret, nil if there is a single Cluster Agent
+func authTokenLeader() func() bool {
+	if !config.Datadog.GetBool("leader_election") {
+		return nil
+	}
+	leaderEngine, err := leaderelection.GetLeaderEngine()
+	if err != nil {
+		log.Errorf("Could not instantiate the leader elector, the cluster agent auth token will be rotated by every replica: %s", err)
+		return nil
+	}
+	return func() bool {
+		if err := leaderEngine.EnsureLeaderElectionRuns(); err != nil {
+			log.Debugf("Leader election process failed to start: %s", err)
+			return false
+		}
+		return leaderEngine.IsLeader()
+	}
+}
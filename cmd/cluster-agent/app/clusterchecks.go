@@ -0,0 +1,40 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2018 Datadog, Inc.
+
+package app
+
+import (
+	"fmt"
+
+	"github.com/fatih/color"
+	"github.com/spf13/cobra"
+
+	"github.com/DataDog/datadog-agent/pkg/config"
+	"github.com/DataDog/datadog-agent/pkg/flare"
+	"github.com/DataDog/datadog-agent/pkg/util/output"
+)
+
+var (
+	clusterChecksVerbose bool
+	clusterChecksFormat  string
+)
+
+func init() {
+	ClusterAgentCmd.AddCommand(clusterChecksCmd)
+	clusterChecksCmd.Flags().BoolVarP(&clusterChecksVerbose, "verbose", "v", false, "print additional debug info")
+	clusterChecksCmd.Flags().StringVarP(&clusterChecksFormat, "output", "o", output.Table, output.FlagUsage)
+}
+
+var clusterChecksCmd = &cobra.Command{
+	Use:   "clusterchecks",
+	Short: "Print the checks scheduled by the Cluster Agent",
+	Long: `Print the configurations of the checks run by the Cluster Agent, loaded from
+the confd_dca_path folder and resolved by autodiscovery.`,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		setupConfig()
+		flare.ConfigCheckURL = fmt.Sprintf("https://localhost:%v/config-check", config.Datadog.GetInt("cluster_agent_cmd_port"))
+		return flare.WriteConfigCheck(color.Output, clusterChecksFormat, clusterChecksVerbose)
+	},
+}
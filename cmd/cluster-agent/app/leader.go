@@ -0,0 +1,79 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2018 Datadog, Inc.
+
+package app
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/fatih/color"
+	"github.com/spf13/cobra"
+
+	"github.com/DataDog/datadog-agent/pkg/api/util"
+	"github.com/DataDog/datadog-agent/pkg/config"
+)
+
+func init() {
+	ClusterAgentCmd.AddCommand(leaderCmd)
+}
+
+var leaderCmd = &cobra.Command{
+	Use:   "leader",
+	Short: "Print the status of the leader election",
+	Long: `Print which Cluster Agent replica is the leader, running the cluster level
+checks and rotating the auth token shared in a Secret.`,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		setupConfig()
+		return requestLeader()
+	},
+}
+
+func requestLeader() error {
+	c := util.GetClient(false) // FIX: get certificates right then make this true
+	urlstr := fmt.Sprintf("https://localhost:%v/leader", config.Datadog.GetInt("cluster_agent_cmd_port"))
+
+	// Set session token
+	err := util.SetAuthToken()
+	if err != nil {
+		return err
+	}
+
+	r, err := util.DoGet(c, urlstr)
+	if err != nil {
+		fmt.Fprintln(color.Output, color.RedString("Could not reach the Cluster Agent (is it running?): %s", err))
+		return err
+	}
+
+	details := make(map[string]string)
+	if err = json.Unmarshal(r, &details); err != nil {
+		return err
+	}
+
+	switch details["status"] {
+	case "Disabled":
+		fmt.Fprintln(color.Output, fmt.Sprintf("Leader election: %s", color.YellowString("disabled")))
+		return nil
+	case "Running":
+	default:
+		fmt.Fprintln(color.Output, fmt.Sprintf("Leader election: %s", color.RedString("failing")))
+		fmt.Fprintln(color.Output, fmt.Sprintf("Error: %s", details["error"]))
+		return nil
+	}
+
+	fmt.Fprintln(color.Output, fmt.Sprintf("Leader election: %s", color.GreenString("running")))
+	leader := details["leaderName"]
+	if details["isLeader"] == "true" {
+		leader += color.GreenString(" (this Cluster Agent)")
+	}
+	fmt.Fprintln(color.Output, fmt.Sprintf("Leader: %s", leader))
+	if identity, found := details["identity"]; found {
+		fmt.Fprintln(color.Output, fmt.Sprintf("This Cluster Agent: %s", identity))
+	}
+	fmt.Fprintln(color.Output, fmt.Sprintf("Acquired: %s", details["acquiredTime"]))
+	fmt.Fprintln(color.Output, fmt.Sprintf("Renewed: %s", details["renewedTime"]))
+	fmt.Fprintln(color.Output, fmt.Sprintf("Transitions: %s", details["transitions"]))
+	return nil
+}
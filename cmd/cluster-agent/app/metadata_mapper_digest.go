@@ -6,21 +6,23 @@
 package app
 
 import (
-	"bytes"
-	"encoding/json"
 	"fmt"
-	"io/ioutil"
+	"io"
+	"os"
 
-	log "github.com/cihub/seelog"
 	"github.com/spf13/cobra"
 
 	"github.com/DataDog/datadog-agent/pkg/api/util"
 	"github.com/DataDog/datadog-agent/pkg/config"
 	"github.com/DataDog/datadog-agent/pkg/status"
+	"github.com/DataDog/datadog-agent/pkg/util/output"
 )
 
+var metaMapperFormat string
+
 func init() {
 	ClusterAgentCmd.AddCommand(metaMapperCmd)
+	metaMapperCmd.Flags().StringVarP(&metaMapperFormat, "output", "o", output.Table, output.FlagUsage)
 }
 
 var metaMapperCmd = &cobra.Command{
@@ -31,21 +33,7 @@ One can easily identify which pods are running on which nodes,
 as well as which services are serving the pods. Or the deployment name for the pod`,
 	Example: "datadog-cluster-agent metamap ip-10-0-115-123",
 	RunE: func(cmd *cobra.Command, args []string) error {
-		configFound := false
-		// a path to the folder containing the config file was passed
-		if len(confPath) != 0 {
-			// we'll search for a config file named `datadog-cluster.yaml`
-			config.Datadog.AddConfigPath(confPath)
-			confErr := config.Datadog.ReadInConfig()
-			if confErr != nil {
-				log.Error(confErr)
-			} else {
-				configFound = true
-			}
-		}
-		if !configFound {
-			log.Debugf("Config read from env variables")
-		}
+		setupConfig()
 		nodeName := ""
 		if len(args) > 0 {
 			nodeName = args[0]
@@ -55,8 +43,10 @@ as well as which services are serving the pods. Or the deployment name for the p
 }
 
 func getMetadataMap(nodeName string) error {
-	var e error
-	var s string
+	writer, e := output.NewWriter(metaMapperFormat, os.Stdout)
+	if e != nil {
+		return e
+	}
 	c := util.GetClient(false) // FIX: get certificates right then make this true
 	var urlstr string
 	if nodeName == "" {
@@ -81,24 +71,12 @@ func getMetadataMap(nodeName string) error {
 	}
 
 	// The rendering is done in the client so that the agent has less work to do
-	if prettyPrintJSON {
-		var prettyJSON bytes.Buffer
-		json.Indent(&prettyJSON, r, "", "  ")
-		s = prettyJSON.String()
-	} else if jsonStatus {
-		s = string(r)
-	} else {
-		formattedMetadataMap, err := status.FormatMetadataMapCLI(r)
+	return writer.Write(r, func(w io.Writer, payload []byte, wide bool) error {
+		formattedMetadataMap, err := status.FormatMetadataMapCLI(payload)
 		if err != nil {
 			return err
 		}
-		s = formattedMetadataMap
-	}
-
-	if statusFilePath != "" {
-		ioutil.WriteFile(statusFilePath, []byte(s), 0644)
-	} else {
-		fmt.Println(s)
-	}
-	return nil
+		_, err = fmt.Fprintln(w, formattedMetadataMap)
+		return err
+	})
 }
@@ -9,27 +9,32 @@ import (
 	"bytes"
 	"encoding/json"
 	"fmt"
+	"io"
 	"io/ioutil"
 
-	log "github.com/cihub/seelog"
 	"github.com/spf13/cobra"
 
 	"github.com/DataDog/datadog-agent/pkg/api/util"
 	"github.com/DataDog/datadog-agent/pkg/config"
 	"github.com/DataDog/datadog-agent/pkg/status"
+	"github.com/DataDog/datadog-agent/pkg/util/output"
 )
 
 var (
 	jsonStatus      bool
 	prettyPrintJSON bool
+	statusFormat    string
 	statusFilePath  string
 )
 
 func init() {
 	ClusterAgentCmd.AddCommand(statusCmd)
+	statusCmd.Flags().StringVarP(&statusFormat, "output", "o", output.Table, output.FlagUsage)
 	statusCmd.Flags().BoolVarP(&jsonStatus, "json", "j", false, "print out raw json")
 	statusCmd.Flags().BoolVarP(&prettyPrintJSON, "pretty-json", "p", false, "pretty print JSON")
-	statusCmd.Flags().StringVarP(&statusFilePath, "file", "o", "", "Output the status command to a file")
+	statusCmd.Flags().MarkDeprecated("json", "use -o json instead")
+	statusCmd.Flags().MarkDeprecated("pretty-json", "use -o json instead")
+	statusCmd.Flags().StringVarP(&statusFilePath, "file", "f", "", "Output the status command to a file")
 }
 
 var statusCmd = &cobra.Command{
@@ -37,21 +42,7 @@ var statusCmd = &cobra.Command{
 	Short: "Print the current status",
 	Long:  ``,
 	RunE: func(cmd *cobra.Command, args []string) error {
-		configFound := false
-		// a path to the folder containing the config file was passed
-		if len(confPath) != 0 {
-			// we'll search for a config file named `datadog-cluster.yaml`
-			config.Datadog.AddConfigPath(confPath)
-			confErr := config.Datadog.ReadInConfig()
-			if confErr != nil {
-				log.Error(confErr)
-			} else {
-				configFound = true
-			}
-		}
-		if !configFound {
-			log.Debugf("Config read from env variables")
-		}
+		setupConfig()
 
 		err := requestStatus()
 		if err != nil {
@@ -62,9 +53,19 @@ var statusCmd = &cobra.Command{
 }
 
 func requestStatus() error {
-	fmt.Printf("Getting the status from the agent.\n")
-	var e error
-	var s string
+	if jsonStatus || prettyPrintJSON {
+		statusFormat = output.JSON
+	}
+	var out bytes.Buffer
+	writer, e := output.NewWriter(statusFormat, &out)
+	if e != nil {
+		return e
+	}
+
+	// Keep the JSON and YAML outputs parsable by scripts
+	if writer.IsHuman() {
+		fmt.Printf("Getting the status from the agent.\n")
+	}
 	c := util.GetClient(false) // FIX: get certificates right then make this true
 	// TODO use https
 	urlstr := fmt.Sprintf("https://localhost:%v/status", config.Datadog.GetInt("cluster_agent_cmd_port"))
@@ -92,24 +93,22 @@ func requestStatus() error {
 	}
 
 	// The rendering is done in the client so that the agent has less work to do
-	if prettyPrintJSON {
-		var prettyJSON bytes.Buffer
-		json.Indent(&prettyJSON, r, "", "  ")
-		s = prettyJSON.String()
-	} else if jsonStatus {
-		s = string(r)
-	} else {
-		formattedStatus, err := status.FormatDCAStatus(r)
+	e = writer.Write(r, func(w io.Writer, payload []byte, wide bool) error {
+		formattedStatus, err := status.FormatDCAStatus(payload)
 		if err != nil {
 			return err
 		}
-		s = formattedStatus
+		_, err = fmt.Fprintln(w, formattedStatus)
+		return err
+	})
+	if e != nil {
+		return e
 	}
 
 	if statusFilePath != "" {
-		ioutil.WriteFile(statusFilePath, []byte(s), 0644)
+		ioutil.WriteFile(statusFilePath, out.Bytes(), 0644)
 	} else {
-		fmt.Println(s)
+		fmt.Print(out.String())
 	}
 
 	return nil
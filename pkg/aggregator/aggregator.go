@@ -13,12 +13,14 @@ import (
 
 	log "github.com/cihub/seelog"
 
+	"github.com/DataDog/datadog-agent/pkg/aggregator/derived"
 	"github.com/DataDog/datadog-agent/pkg/collector/check"
 	"github.com/DataDog/datadog-agent/pkg/config"
 	"github.com/DataDog/datadog-agent/pkg/metrics"
 	"github.com/DataDog/datadog-agent/pkg/metrics/percentile"
 	"github.com/DataDog/datadog-agent/pkg/serializer"
 	"github.com/DataDog/datadog-agent/pkg/status/health"
+	"github.com/DataDog/datadog-agent/pkg/util/tracing"
 )
 
 // DefaultFlushInterval aggregator default flush interval
@@ -115,6 +117,14 @@ func SetDefaultAggregator(agg *BufferedAggregator) {
 	go aggregatorInstance.run()
 }
 
+// StopDefaultAggregator flushes the data buffered by the default aggregator
+// and stops it, see BufferedAggregator.Stop
+func StopDefaultAggregator() {
+	if aggregatorInstance != nil {
+		aggregatorInstance.Stop()
+	}
+}
+
 // BufferedAggregator aggregates metrics in buckets for dogstatsd Metrics
 type BufferedAggregator struct {
 	dogstatsdIn        chan *metrics.MetricSample
@@ -124,6 +134,7 @@ type BufferedAggregator struct {
 	sampler            TimeSampler
 	checkSamplers      map[check.ID]*CheckSampler
 	distSampler        DistSampler
+	derivedMetrics     *derived.Computer
 	serviceChecks      metrics.ServiceChecks
 	events             metrics.Events
 	flushInterval      time.Duration
@@ -134,6 +145,8 @@ type BufferedAggregator struct {
 	hostnameUpdateDone chan struct{}    // signals that the hostname update is finished
 	TickerChan         <-chan time.Time // For test/benchmark purposes: it allows the flush to be controlled from the outside
 	health             *health.Handle
+	stopChan           chan chan struct{}
+	flushWG            sync.WaitGroup // tracks the payloads being serialized
 }
 
 // NewBufferedAggregator instantiates a BufferedAggregator
@@ -146,12 +159,14 @@ func NewBufferedAggregator(s *serializer.Serializer, hostname string, flushInter
 		sampler:            *NewTimeSampler(bucketSize, hostname),
 		checkSamplers:      make(map[check.ID]*CheckSampler),
 		distSampler:        *NewDistSampler(bucketSize, hostname),
+		derivedMetrics:     derived.NewComputerFromConfig(),
 		flushInterval:      flushInterval,
 		serializer:         s,
 		hostname:           hostname,
 		hostnameUpdate:     make(chan string),
 		hostnameUpdateDone: make(chan struct{}),
 		health:             health.Register("aggregator"),
+		stopChan:           make(chan chan struct{}),
 	}
 
 	return aggregator
@@ -214,7 +229,10 @@ func (agg *BufferedAggregator) registerSender(id check.ID) error {
 
 func (agg *BufferedAggregator) deregisterSender(id check.ID) {
 	agg.mu.Lock()
-	delete(agg.checkSamplers, id)
+	if checkSampler, ok := agg.checkSamplers[id]; ok {
+		checkSampler.contextResolver.releaseBudget()
+		delete(agg.checkSamplers, id)
+	}
 	agg.mu.Unlock()
 }
 
@@ -271,6 +289,7 @@ func (agg *BufferedAggregator) addSample(metricSample *metrics.MetricSample, tim
 }
 
 // GetSeries grabs all the series from the queue and clears the queue
+// Derived metrics are computed from the grabbed series and appended to them
 func (agg *BufferedAggregator) GetSeries() metrics.Series {
 	series := agg.sampler.flush(timeNowNano())
 	agg.mu.Lock()
@@ -278,11 +297,12 @@ func (agg *BufferedAggregator) GetSeries() metrics.Series {
 		series = append(series, checkSampler.flush()...)
 	}
 	agg.mu.Unlock()
-	return series
+	return append(series, agg.derivedMetrics.Compute(series)...)
 }
 
-func (agg *BufferedAggregator) flushSeries() {
+func (agg *BufferedAggregator) flushSeries(parent *tracing.Span) {
 	start := time.Now()
+	span := parent.StartChild("aggregator.flush", "series")
 	series := agg.GetSeries()
 
 	// Send along a metric that showcases that this Agent is running (internally, in backend,
@@ -306,9 +326,13 @@ func (agg *BufferedAggregator) flushSeries() {
 	}
 
 	// Serialize and forward in a separate goroutine
+	agg.flushWG.Add(1)
 	go func() {
+		defer agg.flushWG.Done()
 		log.Debug("Flushing ", len(series), " series to the forwarder")
 		err := agg.serializer.SendSeries(series)
+		span.SetMetric("payload.items", float64(len(series)))
+		span.Finish(err)
 		if err != nil {
 			log.Warnf("Error flushing series: %v", err)
 			aggregatorExpvar.Add("SeriesFlushErrors", 1)
@@ -328,9 +352,10 @@ func (agg *BufferedAggregator) GetServiceChecks() metrics.ServiceChecks {
 	return serviceChecks
 }
 
-func (agg *BufferedAggregator) flushServiceChecks() {
+func (agg *BufferedAggregator) flushServiceChecks(parent *tracing.Span) {
 	// Add a simple service check for the Agent status
 	start := time.Now()
+	span := parent.StartChild("aggregator.flush", "service_checks")
 	agg.addServiceCheck(metrics.ServiceCheck{
 		CheckName: "datadog.agent.up",
 		Status:    metrics.ServiceCheckOK,
@@ -348,9 +373,13 @@ func (agg *BufferedAggregator) flushServiceChecks() {
 	}
 
 	// Serialize and forward in a separate goroutine
+	agg.flushWG.Add(1)
 	go func() {
+		defer agg.flushWG.Done()
 		log.Debug("Flushing ", len(serviceChecks), " service checks to the forwarder")
 		err := agg.serializer.SendServiceChecks(serviceChecks)
+		span.SetMetric("payload.items", float64(len(serviceChecks)))
+		span.Finish(err)
 		if err != nil {
 			log.Warnf("Error flushing service checks: %v", err)
 			aggregatorExpvar.Add("ServiceCheckFlushErrors", 1)
@@ -367,7 +396,7 @@ func (agg *BufferedAggregator) GetSketches() percentile.SketchSeriesList {
 	return agg.distSampler.flush(timeNowNano())
 }
 
-func (agg *BufferedAggregator) flushSketches() {
+func (agg *BufferedAggregator) flushSketches(parent *tracing.Span) {
 	// Serialize and forward in a separate goroutine
 	start := time.Now()
 	sketchSeries := agg.GetSketches()
@@ -375,10 +404,15 @@ func (agg *BufferedAggregator) flushSketches() {
 	if len(sketchSeries) == 0 {
 		return
 	}
+	span := parent.StartChild("aggregator.flush", "sketches")
 
+	agg.flushWG.Add(1)
 	go func() {
+		defer agg.flushWG.Done()
 		log.Debug("Flushing ", len(sketchSeries), " sketches to the forwarder")
 		err := agg.serializer.SendSketch(sketchSeries)
+		span.SetMetric("payload.items", float64(len(sketchSeries)))
+		span.Finish(err)
 		if err != nil {
 			log.Warnf("Error flushing sketch: %v", err)
 			aggregatorExpvar.Add("SketchesFlushErrors", 1)
@@ -398,13 +432,14 @@ func (agg *BufferedAggregator) GetEvents() metrics.Events {
 }
 
 // flushEvents serializes and forwards events in a separate goroutine
-func (agg *BufferedAggregator) flushEvents() {
+func (agg *BufferedAggregator) flushEvents(parent *tracing.Span) {
 	// Serialize and forward in a separate goroutine
 	start := time.Now()
 	events := agg.GetEvents()
 	if len(events) == 0 {
 		return
 	}
+	span := parent.StartChild("aggregator.flush", "events")
 	addFlushCount("Events", int64(len(events)))
 
 	// For debug purposes print out all Event/tag combinations
@@ -415,9 +450,13 @@ func (agg *BufferedAggregator) flushEvents() {
 		}
 	}
 
+	agg.flushWG.Add(1)
 	go func() {
+		defer agg.flushWG.Done()
 		log.Debug("Flushing ", len(events), " events to the forwarder")
 		err := agg.serializer.SendEvents(events)
+		span.SetMetric("payload.items", float64(len(events)))
+		span.Finish(err)
 		if err != nil {
 			log.Warnf("Error flushing events: %v", err)
 			aggregatorExpvar.Add("EventsFlushErrors", 1)
@@ -428,10 +467,23 @@ func (agg *BufferedAggregator) flushEvents() {
 }
 
 func (agg *BufferedAggregator) flush() {
-	agg.flushSeries()
-	agg.flushSketches()
-	agg.flushServiceChecks()
-	agg.flushEvents()
+	// The root span only covers the payloads collection, each payload
+	// has its own span covering its serialization and forwarding
+	span := tracing.StartSpan("aggregator.flush", "all")
+	agg.flushSeries(span)
+	agg.flushSketches(span)
+	agg.flushServiceChecks(span)
+	agg.flushEvents(span)
+	span.Finish(nil)
+}
+
+// Stop flushes the data buffered by the aggregator and stops it. It returns
+// once the flushed payloads are handed to the forwarder, the forwarder
+// should be stopped afterwards.
+func (agg *BufferedAggregator) Stop() {
+	done := make(chan struct{})
+	agg.stopChan <- done
+	<-done
 }
 
 func (agg *BufferedAggregator) run() {
@@ -441,6 +493,13 @@ func (agg *BufferedAggregator) run() {
 	}
 	for {
 		select {
+		case done := <-agg.stopChan:
+			log.Info("Stopping the aggregator, flushing the buffered data")
+			agg.flush()
+			agg.flushWG.Wait()
+			agg.health.Deregister()
+			close(done)
+			return
 		case <-agg.health.C:
 		case <-agg.TickerChan:
 			start := time.Now()
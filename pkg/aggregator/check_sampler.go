@@ -9,6 +9,7 @@ import (
 	log "github.com/cihub/seelog"
 
 	"github.com/DataDog/datadog-agent/pkg/metrics"
+	"github.com/DataDog/datadog-agent/pkg/util/membudget"
 )
 
 const checksSourceTypeName = "System"
@@ -25,14 +26,18 @@ type CheckSampler struct {
 func newCheckSampler(hostname string) *CheckSampler {
 	return &CheckSampler{
 		series:          make([]*metrics.Serie, 0),
-		contextResolver: newContextResolver(),
+		contextResolver: newContextResolver(membudget.PriorityHigh),
 		metrics:         metrics.MakeContextMetrics(),
 		defaultHostname: hostname,
 	}
 }
 
 func (cs *CheckSampler) addSample(metricSample *metrics.MetricSample) {
-	contextKey := cs.contextResolver.trackContext(metricSample, metricSample.Timestamp)
+	contextKey, ok := cs.contextResolver.trackContext(metricSample, metricSample.Timestamp)
+	if !ok {
+		log.Debugf("Dropping sample '%s': the metric contexts are over their memory budget", metricSample.Name)
+		return
+	}
 
 	if err := cs.metrics.AddSample(contextKey, metricSample, metricSample.Timestamp, 1); err != nil {
 		log.Debug("Ignoring sample '%s' on host '%s' and tags '%s': %s", metricSample.Name, metricSample.Host, metricSample.Tags, err)
@@ -14,8 +14,12 @@ import (
 
 	"github.com/DataDog/datadog-agent/pkg/aggregator/ckey"
 	"github.com/DataDog/datadog-agent/pkg/metrics"
+	"github.com/DataDog/datadog-agent/pkg/util/membudget"
 )
 
+// contextsBudget is the memory budget shared by the contexts of all the samplers
+var contextsBudget = membudget.Register("aggregator")
+
 // Context holds the elements that form a context, and can be serialized into a context key
 type Context struct {
 	Name string
@@ -27,6 +31,9 @@ type Context struct {
 type ContextResolver struct {
 	contextsByKey map[ckey.ContextKey]*Context
 	lastSeenByKey map[ckey.ContextKey]float64
+	// budget and priority of the new contexts in the memory budget
+	budget   *membudget.Component
+	priority membudget.Priority
 }
 
 // generateContextKey generates the contextKey associated with the context of the metricSample
@@ -34,26 +41,43 @@ func generateContextKey(metricSample *metrics.MetricSample) ckey.ContextKey {
 	return ckey.Generate(metricSample.Name, metricSample.Host, metricSample.Tags)
 }
 
-func newContextResolver() *ContextResolver {
+func newContextResolver(priority membudget.Priority) *ContextResolver {
 	return &ContextResolver{
 		contextsByKey: make(map[ckey.ContextKey]*Context),
 		lastSeenByKey: make(map[ckey.ContextKey]float64),
+		budget:        contextsBudget,
+		priority:      priority,
+	}
+}
+
+// contextSize estimates the memory used by a tracked context
+func contextSize(context *Context) int64 {
+	// the keys and values of the maps, and the headers of the strings
+	size := 128 + len(context.Name) + len(context.Host)
+	for _, tag := range context.Tags {
+		size += 16 + len(tag)
 	}
+	return int64(size)
 }
 
-// trackContext returns the contextKey associated with the context of the metricSample and tracks that context
-func (cr *ContextResolver) trackContext(metricSample *metrics.MetricSample, currentTimestamp float64) ckey.ContextKey {
+// trackContext returns the contextKey associated with the context of the metricSample and tracks that context.
+// It returns false if the context is new and the contexts are over their memory budget, the sample must then be dropped.
+func (cr *ContextResolver) trackContext(metricSample *metrics.MetricSample, currentTimestamp float64) (ckey.ContextKey, bool) {
 	contextKey := generateContextKey(metricSample)
 	if _, ok := cr.contextsByKey[contextKey]; !ok {
-		cr.contextsByKey[contextKey] = &Context{
+		context := &Context{
 			Name: metricSample.Name,
 			Tags: metricSample.Tags,
 			Host: metricSample.Host,
 		}
+		if !cr.budget.Reserve(contextSize(context), cr.priority) {
+			return contextKey, false
+		}
+		cr.contextsByKey[contextKey] = context
 	}
 	cr.lastSeenByKey[contextKey] = currentTimestamp
 
-	return contextKey
+	return contextKey, true
 }
 
 // updateTrackedContext updates the last seen timestamp on a given context key
@@ -82,9 +106,18 @@ func (cr *ContextResolver) expireContexts(expireTimestamp float64) []ckey.Contex
 
 	// Delete expired context keys
 	for _, expiredContextKey := range expiredContextKeys {
+		cr.budget.Release(contextSize(cr.contextsByKey[expiredContextKey]))
 		delete(cr.contextsByKey, expiredContextKey)
 		delete(cr.lastSeenByKey, expiredContextKey)
 	}
 
 	return expiredContextKeys
 }
+
+// releaseBudget gives back the memory budget of all the tracked contexts,
+// the resolver must not be used anymore
+func (cr *ContextResolver) releaseBudget() {
+	for _, context := range cr.contextsByKey {
+		cr.budget.Release(contextSize(context))
+	}
+}
@@ -14,6 +14,7 @@ import (
 
 	"github.com/DataDog/datadog-agent/pkg/aggregator/ckey"
 	"github.com/DataDog/datadog-agent/pkg/metrics"
+	"github.com/DataDog/datadog-agent/pkg/util/membudget"
 )
 
 func TestGenerateContextKey(t *testing.T) {
@@ -66,12 +67,12 @@ func TestTrackContext(t *testing.T) {
 		Tags: mSample3.Tags,
 		Host: mSample3.Host,
 	}
-	contextResolver := newContextResolver()
+	contextResolver := newContextResolver(membudget.PriorityNormal)
 
 	// Track the 2 contexts
-	contextKey1 := contextResolver.trackContext(&mSample1, 1)
-	contextKey2 := contextResolver.trackContext(&mSample2, 1)
-	contextKey3 := contextResolver.trackContext(&mSample3, 1)
+	contextKey1, _ := contextResolver.trackContext(&mSample1, 1)
+	contextKey2, _ := contextResolver.trackContext(&mSample2, 1)
+	contextKey3, _ := contextResolver.trackContext(&mSample3, 1)
 
 	// When we look up the 2 keys, they return the correct contexts
 	context1 := contextResolver.contextsByKey[contextKey1]
@@ -104,11 +105,11 @@ func TestExpireContexts(t *testing.T) {
 		Tags:       []string{"foo", "bar", "baz"},
 		SampleRate: 1,
 	}
-	contextResolver := newContextResolver()
+	contextResolver := newContextResolver(membudget.PriorityNormal)
 
 	// Track the 2 contexts
-	contextKey1 := contextResolver.trackContext(&mSample1, 4)
-	contextKey2 := contextResolver.trackContext(&mSample2, 6)
+	contextKey1, _ := contextResolver.trackContext(&mSample1, 4)
+	contextKey2, _ := contextResolver.trackContext(&mSample2, 6)
 
 	// With an expireTimestap of 3, both contexts are still valid
 	assert.Len(t, contextResolver.expireContexts(3), 0)
@@ -129,3 +130,40 @@ func TestExpireContexts(t *testing.T) {
 	_, ok = contextResolver.contextsByKey[contextKey2]
 	assert.True(t, ok)
 }
+
+func TestTrackContextMemoryBudget(t *testing.T) {
+	mSample1 := metrics.MetricSample{Name: "my.metric.name", Tags: []string{"foo"}}
+	mSample2 := metrics.MetricSample{Name: "my.metric.name", Tags: []string{"bar"}}
+	mSample3 := metrics.MetricSample{Name: "my.metric.name", Tags: []string{"baz"}}
+	size := contextSize(&Context{Name: mSample1.Name, Tags: mSample1.Tags})
+	budget := membudget.Register("test_aggregator")
+	budget.SetLimit(2 * size)
+
+	lowResolver := newContextResolver(membudget.PriorityLow)
+	lowResolver.budget = budget
+	highResolver := newContextResolver(membudget.PriorityHigh)
+	highResolver.budget = budget
+
+	_, ok := lowResolver.trackContext(&mSample1, 1)
+	assert.True(t, ok)
+	// the low priority contexts only get half of the budget
+	_, ok = lowResolver.trackContext(&mSample2, 1)
+	assert.False(t, ok)
+	assert.Len(t, lowResolver.contextsByKey, 1)
+	// the contexts already tracked are still updated
+	_, ok = lowResolver.trackContext(&mSample1, 2)
+	assert.True(t, ok)
+
+	_, ok = highResolver.trackContext(&mSample2, 1)
+	assert.True(t, ok)
+	_, ok = highResolver.trackContext(&mSample3, 1)
+	assert.False(t, ok)
+
+	// expiring a context gives back its budget
+	lowResolver.expireContexts(3)
+	_, ok = highResolver.trackContext(&mSample3, 1)
+	assert.True(t, ok)
+
+	highResolver.releaseBudget()
+	assert.Equal(t, int64(0), budget.Used())
+}
@@ -0,0 +1,178 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2018 Datadog, Inc.
+
+package derived
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	log "github.com/cihub/seelog"
+
+	"github.com/DataDog/datadog-agent/pkg/config"
+	"github.com/DataDog/datadog-agent/pkg/metrics"
+)
+
+// Metric is a derived metric: a named expression computed from other series
+type Metric struct {
+	Name       string
+	Expression *Expression
+}
+
+// Computer computes derived metrics from the series flushed by the aggregator
+type Computer struct {
+	metrics []*Metric
+	// operands holds the names of all the metrics referenced by at least one expression
+	operands map[string]bool
+}
+
+// NewComputer returns a Computer for the given derived metric definitions
+func NewComputer(definitions []config.DerivedMetric) (*Computer, error) {
+	c := &Computer{operands: make(map[string]bool)}
+	for _, def := range definitions {
+		m, err := newMetric(def)
+		if err != nil {
+			return nil, err
+		}
+		c.add(m)
+	}
+	return c, nil
+}
+
+// NewComputerFromConfig returns a Computer built from the `derived_metrics`
+// section of the agent configuration, invalid definitions are skipped
+func NewComputerFromConfig() *Computer {
+	c := &Computer{operands: make(map[string]bool)}
+
+	var definitions []config.DerivedMetric
+	if err := config.Datadog.UnmarshalKey("derived_metrics", &definitions); err != nil {
+		log.Errorf("Unable to parse derived_metrics: %s", err)
+		return c
+	}
+	for _, def := range definitions {
+		m, err := newMetric(def)
+		if err != nil {
+			log.Errorf("Ignoring derived metric: %s", err)
+			continue
+		}
+		c.add(m)
+	}
+	return c
+}
+
+func newMetric(def config.DerivedMetric) (*Metric, error) {
+	if def.Name == "" {
+		return nil, fmt.Errorf("derived metric with expression '%s' has no name", def.Expression)
+	}
+	expr, err := ParseExpression(def.Expression)
+	if err != nil {
+		return nil, fmt.Errorf("invalid expression for derived metric '%s': %s", def.Name, err)
+	}
+	if len(expr.Metrics()) == 0 {
+		return nil, fmt.Errorf("expression for derived metric '%s' doesn't reference any metric", def.Name)
+	}
+	return &Metric{Name: def.Name, Expression: expr}, nil
+}
+
+func (c *Computer) add(m *Metric) {
+	for _, name := range m.Expression.Metrics() {
+		c.operands[name] = true
+	}
+	c.metrics = append(c.metrics, m)
+}
+
+// Len returns the number of derived metrics the Computer handles
+func (c *Computer) Len() int {
+	return len(c.metrics)
+}
+
+// joinKey identifies the series that can be combined together: operands are
+// matched when they share the same host and tags
+type joinKey struct {
+	host string
+	tags string
+}
+
+// operandSeries groups the points of the operand series sharing a joinKey
+type operandSeries struct {
+	tags     []string
+	interval int64
+	// values by timestamp then by metric name
+	values map[float64]map[string]float64
+}
+
+// Compute returns the derived series computed from the given series. A point
+// is only produced for the timestamps at which every operand has a value.
+func (c *Computer) Compute(series metrics.Series) metrics.Series {
+	if c == nil || len(c.metrics) == 0 {
+		return nil
+	}
+
+	groups := make(map[joinKey]*operandSeries)
+	for _, serie := range series {
+		if !c.operands[serie.Name] {
+			continue
+		}
+		key := joinKey{host: serie.Host, tags: tagsKey(serie.Tags)}
+		group, found := groups[key]
+		if !found {
+			group = &operandSeries{
+				tags:     serie.Tags,
+				interval: serie.Interval,
+				values:   make(map[float64]map[string]float64),
+			}
+			groups[key] = group
+		}
+		for _, p := range serie.Points {
+			if _, found := group.values[p.Ts]; !found {
+				group.values[p.Ts] = make(map[string]float64)
+			}
+			group.values[p.Ts][serie.Name] = p.Value
+		}
+	}
+
+	var result metrics.Series
+	for key, group := range groups {
+		timestamps := make([]float64, 0, len(group.values))
+		for ts := range group.values {
+			timestamps = append(timestamps, ts)
+		}
+		sort.Float64s(timestamps)
+
+		for _, m := range c.metrics {
+			var points []metrics.Point
+			for _, ts := range timestamps {
+				value, err := m.Expression.Eval(group.values[ts])
+				if err == errMissingOperand {
+					continue
+				} else if err != nil {
+					log.Debugf("Unable to compute derived metric '%s' at %v: %s", m.Name, ts, err)
+					continue
+				}
+				points = append(points, metrics.Point{Ts: ts, Value: value})
+			}
+			if len(points) == 0 {
+				continue
+			}
+			result = append(result, &metrics.Serie{
+				Name:     m.Name,
+				Points:   points,
+				Tags:     append([]string(nil), group.tags...),
+				Host:     key.host,
+				MType:    metrics.APIGaugeType,
+				Interval: group.interval,
+			})
+		}
+	}
+	return result
+}
+
+// tagsKey returns an order-independent representation of a tag list
+func tagsKey(tags []string) string {
+	sorted := append([]string(nil), tags...)
+	sort.Strings(sorted)
+	return strings.Join(sorted, ",")
+}
@@ -0,0 +1,97 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2018 Datadog, Inc.
+
+package derived
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/DataDog/datadog-agent/pkg/config"
+	"github.com/DataDog/datadog-agent/pkg/metrics"
+)
+
+func TestNewComputerErrors(t *testing.T) {
+	_, err := NewComputer([]config.DerivedMetric{{Expression: "a / b"}})
+	assert.Error(t, err)
+
+	_, err = NewComputer([]config.DerivedMetric{{Name: "ratio", Expression: "a /"}})
+	assert.Error(t, err)
+
+	_, err = NewComputer([]config.DerivedMetric{{Name: "constant", Expression: "1 + 2"}})
+	assert.Error(t, err)
+}
+
+func TestCompute(t *testing.T) {
+	c, err := NewComputer([]config.DerivedMetric{
+		{Name: "cache.hit_ratio", Expression: "cache.hits / (cache.hits + cache.misses)"},
+	})
+	require.NoError(t, err)
+	require.Equal(t, 1, c.Len())
+
+	series := metrics.Series{
+		{
+			Name:     "cache.hits",
+			Host:     "host1",
+			Tags:     []string{"b:2", "a:1"},
+			Interval: 10,
+			Points:   []metrics.Point{{Ts: 10, Value: 3}, {Ts: 20, Value: 1}},
+		},
+		{
+			Name:   "cache.misses",
+			Host:   "host1",
+			Tags:   []string{"a:1", "b:2"},
+			Points: []metrics.Point{{Ts: 10, Value: 1}, {Ts: 30, Value: 5}},
+		},
+		// no matching cache.misses serie for this host
+		{
+			Name:   "cache.hits",
+			Host:   "host2",
+			Tags:   []string{"a:1", "b:2"},
+			Points: []metrics.Point{{Ts: 10, Value: 3}},
+		},
+		// unrelated serie
+		{
+			Name:   "cache.size",
+			Host:   "host1",
+			Tags:   []string{"a:1", "b:2"},
+			Points: []metrics.Point{{Ts: 10, Value: 3}},
+		},
+	}
+
+	derived := c.Compute(series)
+	require.Len(t, derived, 1)
+	assert.Equal(t, "cache.hit_ratio", derived[0].Name)
+	assert.Equal(t, "host1", derived[0].Host)
+	assert.ElementsMatch(t, []string{"a:1", "b:2"}, derived[0].Tags)
+	assert.Equal(t, metrics.APIGaugeType, derived[0].MType)
+	assert.Equal(t, int64(10), derived[0].Interval)
+	assert.Equal(t, []metrics.Point{{Ts: 10, Value: 0.75}}, derived[0].Points)
+}
+
+func TestComputeDivisionByZero(t *testing.T) {
+	c, err := NewComputer([]config.DerivedMetric{{Name: "ratio", Expression: "a / b"}})
+	require.NoError(t, err)
+
+	series := metrics.Series{
+		{Name: "a", Points: []metrics.Point{{Ts: 10, Value: 1}, {Ts: 20, Value: 1}}},
+		{Name: "b", Points: []metrics.Point{{Ts: 10, Value: 0}, {Ts: 20, Value: 4}}},
+	}
+
+	derived := c.Compute(series)
+	require.Len(t, derived, 1)
+	assert.Equal(t, []metrics.Point{{Ts: 20, Value: 0.25}}, derived[0].Points)
+}
+
+func TestComputeNoDefinition(t *testing.T) {
+	var c *Computer
+	assert.Nil(t, c.Compute(metrics.Series{{Name: "a"}}))
+
+	c, err := NewComputer(nil)
+	require.NoError(t, err)
+	assert.Nil(t, c.Compute(metrics.Series{{Name: "a"}}))
+}
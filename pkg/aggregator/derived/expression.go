@@ -0,0 +1,231 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2018 Datadog, Inc.
+
+package derived
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+)
+
+// errMissingOperand is returned when a metric referenced by an expression
+// has no value for the evaluated point
+var errMissingOperand = errors.New("missing operand")
+
+// errDivisionByZero is returned when the right hand side of a division is zero
+var errDivisionByZero = errors.New("division by zero")
+
+// node is an element of a parsed expression
+type node interface {
+	eval(values map[string]float64) (float64, error)
+}
+
+type numberNode float64
+
+func (n numberNode) eval(values map[string]float64) (float64, error) {
+	return float64(n), nil
+}
+
+type metricNode string
+
+func (n metricNode) eval(values map[string]float64) (float64, error) {
+	v, found := values[string(n)]
+	if !found {
+		return 0, errMissingOperand
+	}
+	return v, nil
+}
+
+type negateNode struct {
+	operand node
+}
+
+func (n negateNode) eval(values map[string]float64) (float64, error) {
+	v, err := n.operand.eval(values)
+	return -v, err
+}
+
+type binaryNode struct {
+	op          byte
+	left, right node
+}
+
+func (n binaryNode) eval(values map[string]float64) (float64, error) {
+	l, err := n.left.eval(values)
+	if err != nil {
+		return 0, err
+	}
+	r, err := n.right.eval(values)
+	if err != nil {
+		return 0, err
+	}
+	switch n.op {
+	case '+':
+		return l + r, nil
+	case '-':
+		return l - r, nil
+	case '*':
+		return l * r, nil
+	case '/':
+		if r == 0 {
+			return 0, errDivisionByZero
+		}
+		return l / r, nil
+	}
+	return 0, fmt.Errorf("unknown operator '%c'", n.op)
+}
+
+// Expression is a parsed arithmetic expression over metric names
+type Expression struct {
+	root    node
+	metrics []string
+}
+
+// Metrics returns the names of the metrics referenced by the expression
+func (e *Expression) Metrics() []string {
+	return e.metrics
+}
+
+// Eval computes the value of the expression given the values of its metrics
+func (e *Expression) Eval(values map[string]float64) (float64, error) {
+	return e.root.eval(values)
+}
+
+// ParseExpression parses an expression made of metric names, numbers,
+// parentheses and the `+`, `-`, `*` and `/` operators
+func ParseExpression(input string) (*Expression, error) {
+	p := &parser{input: input, seen: make(map[string]bool)}
+	root, err := p.parseSum()
+	if err != nil {
+		return nil, err
+	}
+	p.skipSpaces()
+	if p.pos < len(p.input) {
+		return nil, fmt.Errorf("unexpected character '%c' at position %d", p.input[p.pos], p.pos)
+	}
+	return &Expression{root: root, metrics: p.metrics}, nil
+}
+
+// parser is a recursive descent parser for expressions
+type parser struct {
+	input   string
+	pos     int
+	metrics []string
+	seen    map[string]bool
+}
+
+func (p *parser) skipSpaces() {
+	for p.pos < len(p.input) && (p.input[p.pos] == ' ' || p.input[p.pos] == '\t') {
+		p.pos++
+	}
+}
+
+// peek returns the next non-space character, or 0 at the end of the input
+func (p *parser) peek() byte {
+	p.skipSpaces()
+	if p.pos >= len(p.input) {
+		return 0
+	}
+	return p.input[p.pos]
+}
+
+func (p *parser) parseSum() (node, error) {
+	left, err := p.parseProduct()
+	if err != nil {
+		return nil, err
+	}
+	for {
+		op := p.peek()
+		if op != '+' && op != '-' {
+			return left, nil
+		}
+		p.pos++
+		right, err := p.parseProduct()
+		if err != nil {
+			return nil, err
+		}
+		left = binaryNode{op: op, left: left, right: right}
+	}
+}
+
+func (p *parser) parseProduct() (node, error) {
+	left, err := p.parseFactor()
+	if err != nil {
+		return nil, err
+	}
+	for {
+		op := p.peek()
+		if op != '*' && op != '/' {
+			return left, nil
+		}
+		p.pos++
+		right, err := p.parseFactor()
+		if err != nil {
+			return nil, err
+		}
+		left = binaryNode{op: op, left: left, right: right}
+	}
+}
+
+func (p *parser) parseFactor() (node, error) {
+	c := p.peek()
+	switch {
+	case c == 0:
+		return nil, fmt.Errorf("unexpected end of expression")
+	case c == '(':
+		p.pos++
+		n, err := p.parseSum()
+		if err != nil {
+			return nil, err
+		}
+		if p.peek() != ')' {
+			return nil, fmt.Errorf("missing closing parenthesis at position %d", p.pos)
+		}
+		p.pos++
+		return n, nil
+	case c == '-':
+		p.pos++
+		operand, err := p.parseFactor()
+		if err != nil {
+			return nil, err
+		}
+		return negateNode{operand: operand}, nil
+	case isDigit(c):
+		start := p.pos
+		for p.pos < len(p.input) && (isDigit(p.input[p.pos]) || p.input[p.pos] == '.') {
+			p.pos++
+		}
+		v, err := strconv.ParseFloat(p.input[start:p.pos], 64)
+		if err != nil {
+			return nil, fmt.Errorf("invalid number '%s': %s", p.input[start:p.pos], err)
+		}
+		return numberNode(v), nil
+	case isMetricStart(c):
+		start := p.pos
+		for p.pos < len(p.input) && isMetricChar(p.input[p.pos]) {
+			p.pos++
+		}
+		name := p.input[start:p.pos]
+		if !p.seen[name] {
+			p.seen[name] = true
+			p.metrics = append(p.metrics, name)
+		}
+		return metricNode(name), nil
+	}
+	return nil, fmt.Errorf("unexpected character '%c' at position %d", c, p.pos)
+}
+
+func isDigit(c byte) bool {
+	return c >= '0' && c <= '9'
+}
+
+func isMetricStart(c byte) bool {
+	return (c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z') || c == '_'
+}
+
+func isMetricChar(c byte) bool {
+	return isMetricStart(c) || isDigit(c) || c == '.'
+}
@@ -0,0 +1,69 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2018 Datadog, Inc.
+
+package derived
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseExpression(t *testing.T) {
+	values := map[string]float64{
+		"my.hits":   3,
+		"my.misses": 1,
+		"other_2":   10,
+	}
+
+	for _, tc := range []struct {
+		expression string
+		metrics    []string
+		expected   float64
+	}{
+		{"my.hits", []string{"my.hits"}, 3},
+		{"my.hits / (my.hits + my.misses)", []string{"my.hits", "my.misses"}, 0.75},
+		{"my.hits + my.misses * 2", []string{"my.hits", "my.misses"}, 5},
+		{"(my.hits + my.misses) * 2", []string{"my.hits", "my.misses"}, 8},
+		{"-my.hits + other_2", []string{"my.hits", "other_2"}, 7},
+		{"100 * my.misses / other_2", []string{"my.misses", "other_2"}, 10},
+		{"my.hits - my.misses - 1.5", []string{"my.hits", "my.misses"}, 0.5},
+	} {
+		t.Run(tc.expression, func(t *testing.T) {
+			expr, err := ParseExpression(tc.expression)
+			require.NoError(t, err)
+			assert.Equal(t, tc.metrics, expr.Metrics())
+			v, err := expr.Eval(values)
+			require.NoError(t, err)
+			assert.InDelta(t, tc.expected, v, 1e-9)
+		})
+	}
+}
+
+func TestParseExpressionErrors(t *testing.T) {
+	for _, expression := range []string{
+		"",
+		"my.hits +",
+		"(my.hits",
+		"my.hits)",
+		"my.hits % 2",
+		"1.2.3",
+	} {
+		_, err := ParseExpression(expression)
+		assert.Error(t, err, expression)
+	}
+}
+
+func TestEvalErrors(t *testing.T) {
+	expr, err := ParseExpression("a / b")
+	require.NoError(t, err)
+
+	_, err = expr.Eval(map[string]float64{"a": 1})
+	assert.Equal(t, errMissingOperand, err)
+
+	_, err = expr.Eval(map[string]float64{"a": 1, "b": 0})
+	assert.Equal(t, errDivisionByZero, err)
+}
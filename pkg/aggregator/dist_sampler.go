@@ -8,9 +8,12 @@
 package aggregator
 
 import (
+	log "github.com/cihub/seelog"
+
 	"github.com/DataDog/datadog-agent/pkg/aggregator/ckey"
 	"github.com/DataDog/datadog-agent/pkg/metrics"
 	"github.com/DataDog/datadog-agent/pkg/metrics/percentile"
+	"github.com/DataDog/datadog-agent/pkg/util/membudget"
 )
 
 // FIXME(Jee) : This should be integrated with time_sampler.go since it
@@ -28,7 +31,7 @@ type DistSampler struct {
 func NewDistSampler(interval int64, defaultHostname string) *DistSampler {
 	return &DistSampler{
 		interval:            interval,
-		contextResolver:     newContextResolver(),
+		contextResolver:     newContextResolver(membudget.PriorityLow),
 		sketchesByTimestamp: map[int64]metrics.ContextSketch{},
 		defaultHostname:     defaultHostname,
 	}
@@ -40,7 +43,11 @@ func (d *DistSampler) calculateBucketStart(timestamp float64) int64 {
 
 // Add the metricSample to the correct sketch
 func (d *DistSampler) addSample(metricSample *metrics.MetricSample, timestamp float64) {
-	contextKey := d.contextResolver.trackContext(metricSample, timestamp)
+	contextKey, ok := d.contextResolver.trackContext(metricSample, timestamp)
+	if !ok {
+		log.Debugf("Dropping sample '%s': the metric contexts are over their memory budget", metricSample.Name)
+		return
+	}
 	bucketStart := d.calculateBucketStart(timestamp)
 	sketch, ok := d.sketchesByTimestamp[bucketStart]
 	if !ok {
@@ -0,0 +1,118 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2018 Datadog, Inc.
+
+package aggregator
+
+import (
+	"sync"
+	"time"
+
+	"github.com/DataDog/datadog-agent/pkg/collector/check"
+	"github.com/DataDog/datadog-agent/pkg/metrics"
+)
+
+// staleTag flags the cached results re-submitted after a failed run
+const staleTag = "stale:true"
+
+// resultsCache keeps the gauges submitted by the last successful run of a
+// check, to re-submit them while its runs fail. The other metric types are
+// not cached: a count would be counted twice, and a rate computed from the
+// same value would be null.
+type resultsCache struct {
+	m        sync.Mutex
+	ttl      time.Duration
+	run      []metrics.MetricSample // submitted by the current run
+	cached   []metrics.MetricSample // submitted by the last successful run
+	cachedAt time.Time
+}
+
+func (rc *resultsCache) record(sample *metrics.MetricSample) {
+	if sample.Mtype != metrics.GaugeType {
+		return
+	}
+	rc.m.Lock()
+	rc.run = append(rc.run, *sample)
+	rc.m.Unlock()
+}
+
+// complete caches the results of the run if it succeeded, or returns the
+// cached results, if they haven't expired, if it failed
+func (rc *resultsCache) complete(success bool, now time.Time) []metrics.MetricSample {
+	rc.m.Lock()
+	defer rc.m.Unlock()
+
+	run := rc.run
+	rc.run = nil
+	if success {
+		rc.cached = run
+		rc.cachedAt = now
+		return nil
+	}
+	if now.Sub(rc.cachedAt) > rc.ttl {
+		rc.cached = nil
+		return nil
+	}
+	return rc.cached
+}
+
+// EnableResultsCache makes the sender of the check keep the gauges of its last
+// successful run, to be re-submitted by CompleteCheckRun during ttl when its
+// runs fail
+func EnableResultsCache(id check.ID, ttl time.Duration) error {
+	sender, err := GetSender(id)
+	if err != nil {
+		return err
+	}
+	s, ok := sender.(*checkSender)
+	if !ok {
+		return nil
+	}
+
+	s.resultsMu.Lock()
+	defer s.resultsMu.Unlock()
+	if s.results == nil {
+		s.results = &resultsCache{}
+	}
+	s.results.m.Lock()
+	s.results.ttl = ttl
+	s.results.m.Unlock()
+	return nil
+}
+
+// CompleteCheckRun is called once a run of the check is over. If the run
+// succeeded, its results are cached. If it failed, the results of the last
+// successful run are submitted again, flagged with a `stale:true` tag, unless
+// they are older than the ttl given to EnableResultsCache. It returns the
+// number of stale samples submitted.
+func CompleteCheckRun(id check.ID, success bool) int {
+	sender, err := senderPool.getSender(id)
+	if err != nil {
+		return 0
+	}
+	s, ok := sender.(*checkSender)
+	if !ok {
+		return 0
+	}
+	s.resultsMu.RLock()
+	results := s.results
+	s.resultsMu.RUnlock()
+	if results == nil {
+		return 0
+	}
+
+	stale := results.complete(success, time.Now())
+	if len(stale) == 0 {
+		return 0
+	}
+	timestamp := timeNowNano()
+	for _, sample := range stale {
+		staleSample := sample
+		staleSample.Tags = append(append(make([]string, 0, len(sample.Tags)+1), sample.Tags...), staleTag)
+		staleSample.Timestamp = timestamp
+		s.smsOut <- senderMetricSample{s.id, &staleSample, false}
+	}
+	s.smsOut <- senderMetricSample{s.id, &metrics.MetricSample{}, true}
+	return len(stale)
+}
@@ -0,0 +1,61 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2018 Datadog, Inc.
+
+package aggregator
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/DataDog/datadog-agent/pkg/metrics"
+)
+
+func TestResultsCache(t *testing.T) {
+	resetAggregator()
+	InitAggregator(nil, "")
+
+	senderMetricSampleChan := make(chan senderMetricSample, 10)
+	serviceCheckChan := make(chan metrics.ServiceCheck, 10)
+	eventChan := make(chan metrics.Event, 10)
+	s := newCheckSender(checkID1, senderMetricSampleChan, serviceCheckChan, eventChan)
+	require.Nil(t, SetSender(s, checkID1))
+
+	// nothing is cached until it is enabled
+	s.Gauge("my.gauge", 1, "my-hostname", []string{"foo"})
+	<-senderMetricSampleChan
+	assert.Equal(t, 0, CompleteCheckRun(checkID1, false))
+
+	require.Nil(t, EnableResultsCache(checkID1, time.Minute))
+
+	// successful run: the gauges are cached
+	s.Gauge("my.gauge", 2, "my-hostname", []string{"foo"})
+	s.Count("my.count", 3, "my-hostname", nil)
+	<-senderMetricSampleChan
+	<-senderMetricSampleChan
+	assert.Equal(t, 0, CompleteCheckRun(checkID1, true))
+
+	// failed run: the cached gauges are re-submitted as stale
+	assert.Equal(t, 1, CompleteCheckRun(checkID1, false))
+	stale := <-senderMetricSampleChan
+	assert.Equal(t, "my.gauge", stale.metricSample.Name)
+	assert.Equal(t, float64(2), stale.metricSample.Value)
+	assert.Equal(t, []string{"foo", "stale:true"}, stale.metricSample.Tags)
+	assert.False(t, stale.commit)
+	commit := <-senderMetricSampleChan
+	assert.True(t, commit.commit)
+
+	// the cache is not changed by the failed runs
+	assert.Equal(t, 1, CompleteCheckRun(checkID1, false))
+	<-senderMetricSampleChan
+	<-senderMetricSampleChan
+
+	// the cached results expire
+	s.results.cachedAt = time.Now().Add(-2 * time.Minute)
+	assert.Equal(t, 0, CompleteCheckRun(checkID1, false))
+	assert.Len(t, senderMetricSampleChan, 0)
+}
@@ -37,10 +37,13 @@ type Sender interface {
 }
 
 type metricStats struct {
-	Metrics       int64
-	Events        int64
-	ServiceChecks int64
-	Lock          sync.RWMutex
+	Metrics           int64
+	Events            int64
+	ServiceChecks     int64
+	TimestampsTooOld  int64
+	TimestampsTooNew  int64
+	TimestampsClamped int64
+	Lock              sync.RWMutex
 }
 
 // RawSender interface to submit samples to aggregator directly
@@ -58,6 +61,12 @@ type checkSender struct {
 	smsOut           chan<- senderMetricSample
 	serviceCheckOut  chan<- metrics.ServiceCheck
 	eventOut         chan<- metrics.Event
+	timestamps       timestampValidator
+
+	// results keeps the gauges of the last successful run, when enabled
+	// with EnableResultsCache
+	results   *resultsCache
+	resultsMu sync.RWMutex
 }
 
 type senderMetricSample struct {
@@ -85,6 +94,7 @@ func newCheckSender(id check.ID, smsOut chan<- senderMetricSample, serviceCheckO
 		eventOut:         eventOut,
 		metricStats:      metricStats{},
 		priormetricStats: metricStats{},
+		timestamps:       newTimestampValidator(),
 	}
 }
 
@@ -138,6 +148,24 @@ func GetDefaultSender() (Sender, error) {
 func (s *checkSender) Commit() {
 	s.smsOut <- senderMetricSample{s.id, &metrics.MetricSample{}, true}
 	s.cyclemetricStats()
+	s.warnOutOfRangeTimestamps()
+}
+
+// warnOutOfRangeTimestamps logs the points of the last run submitted with
+// timestamps outside the window accepted by the intake
+func (s *checkSender) warnOutOfRangeTimestamps() {
+	s.priormetricStats.Lock.RLock()
+	tooOld, tooNew, clamped := s.priormetricStats.TimestampsTooOld, s.priormetricStats.TimestampsTooNew, s.priormetricStats.TimestampsClamped
+	s.priormetricStats.Lock.RUnlock()
+
+	if tooOld == 0 && tooNew == 0 {
+		return
+	}
+	action := "dropped"
+	if clamped > 0 {
+		action = "clamped"
+	}
+	log.Warnf("Check %s submitted %d points with timestamps too far in the past and %d too far in the future, they were %s", s.id, tooOld, tooNew, action)
 }
 
 func (s *checkSender) GetMetricStats() map[string]int64 {
@@ -148,6 +176,9 @@ func (s *checkSender) GetMetricStats() map[string]int64 {
 	metricStats["Metrics"] = s.priormetricStats.Metrics
 	metricStats["Events"] = s.priormetricStats.Events
 	metricStats["ServiceChecks"] = s.priormetricStats.ServiceChecks
+	metricStats["TimestampsTooOld"] = s.priormetricStats.TimestampsTooOld
+	metricStats["TimestampsTooNew"] = s.priormetricStats.TimestampsTooNew
+	metricStats["TimestampsClamped"] = s.priormetricStats.TimestampsClamped
 
 	return metricStats
 }
@@ -158,19 +189,56 @@ func (s *checkSender) cyclemetricStats() {
 	s.priormetricStats.Metrics = s.metricStats.Metrics
 	s.priormetricStats.Events = s.metricStats.Events
 	s.priormetricStats.ServiceChecks = s.metricStats.ServiceChecks
+	s.priormetricStats.TimestampsTooOld = s.metricStats.TimestampsTooOld
+	s.priormetricStats.TimestampsTooNew = s.metricStats.TimestampsTooNew
+	s.priormetricStats.TimestampsClamped = s.metricStats.TimestampsClamped
 	s.metricStats.Metrics = 0
 	s.metricStats.Events = 0
 	s.metricStats.ServiceChecks = 0
+	s.metricStats.TimestampsTooOld = 0
+	s.metricStats.TimestampsTooNew = 0
+	s.metricStats.TimestampsClamped = 0
 	s.metricStats.Lock.Unlock()
 	s.priormetricStats.Lock.Unlock()
 }
 
 // SendRawMetricSample sends the raw sample
 // Useful for testing - submitting precomputed samples.
+// Samples with a timestamp out of the accepted window are dropped or
+// clamped, depending on the `metric_timestamp_policy` setting.
 func (s *checkSender) SendRawMetricSample(sample *metrics.MetricSample) {
+	if sample.Timestamp == 0 {
+		sample.Timestamp = timeNowNano()
+	} else if !s.validateTimestamp(sample) {
+		return
+	}
 	s.smsOut <- senderMetricSample{s.id, sample, false}
 }
 
+// validateTimestamp counts the samples with an out of range timestamp and
+// returns whether the sample should still be sent
+func (s *checkSender) validateTimestamp(sample *metrics.MetricSample) bool {
+	ts, result := s.timestamps.validate(sample.Timestamp, timeNowNano())
+	if result == timestampValid {
+		return true
+	}
+
+	s.metricStats.Lock.Lock()
+	defer s.metricStats.Lock.Unlock()
+	if result == timestampTooOld {
+		s.metricStats.TimestampsTooOld++
+	} else {
+		s.metricStats.TimestampsTooNew++
+	}
+	if !s.timestamps.clamp {
+		log.Debugf("Dropping sample '%s' with out of range timestamp %f", sample.Name, sample.Timestamp)
+		return false
+	}
+	s.metricStats.TimestampsClamped++
+	sample.Timestamp = ts
+	return true
+}
+
 func (s *checkSender) sendMetricSample(metric string, value float64, hostname string, tags []string, mType metrics.MetricType) {
 	log.Trace(mType.String(), " sample: ", metric, ": ", value, " for hostname: ", hostname, " tags: ", tags)
 
@@ -184,6 +252,13 @@ func (s *checkSender) sendMetricSample(metric string, value float64, hostname st
 		Timestamp:  timeNowNano(),
 	}
 
+	// recorded before the aggregator gets the sample
+	s.resultsMu.RLock()
+	if s.results != nil {
+		s.results.record(metricSample)
+	}
+	s.resultsMu.RUnlock()
+
 	s.smsOut <- senderMetricSample{s.id, metricSample, false}
 
 	s.metricStats.Lock.Lock()
@@ -11,6 +11,7 @@ import (
 	"github.com/DataDog/datadog-agent/pkg/aggregator/ckey"
 	"github.com/DataDog/datadog-agent/pkg/config"
 	"github.com/DataDog/datadog-agent/pkg/metrics"
+	"github.com/DataDog/datadog-agent/pkg/util/membudget"
 )
 
 const defaultExpiry = 300.0 // number of seconds after which contexts are expired
@@ -37,7 +38,7 @@ type TimeSampler struct {
 func NewTimeSampler(interval int64, defaultHostname string) *TimeSampler {
 	return &TimeSampler{
 		interval:                    interval,
-		contextResolver:             newContextResolver(),
+		contextResolver:             newContextResolver(membudget.PriorityNormal),
 		metricsByTimestamp:          map[int64]metrics.ContextMetrics{},
 		defaultHostname:             defaultHostname,
 		counterLastSampledByContext: map[ckey.ContextKey]float64{},
@@ -51,7 +52,11 @@ func (s *TimeSampler) calculateBucketStart(timestamp float64) int64 {
 // Add the metricSample to the correct bucket
 func (s *TimeSampler) addSample(metricSample *metrics.MetricSample, timestamp float64) {
 	// Keep track of the context
-	contextKey := s.contextResolver.trackContext(metricSample, timestamp)
+	contextKey, ok := s.contextResolver.trackContext(metricSample, timestamp)
+	if !ok {
+		log.Debugf("Dropping sample '%s': the metric contexts are over their memory budget", metricSample.Name)
+		return
+	}
 
 	bucketStart := s.calculateBucketStart(timestamp)
 	// If it's a new bucket, initialize it
@@ -0,0 +1,63 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2018 Datadog, Inc.
+
+package aggregator
+
+import (
+	log "github.com/cihub/seelog"
+
+	"github.com/DataDog/datadog-agent/pkg/config"
+)
+
+const (
+	timestampPolicyDrop  = "drop"
+	timestampPolicyClamp = "clamp"
+)
+
+type timestampCheck int
+
+const (
+	timestampValid timestampCheck = iota
+	timestampTooOld
+	timestampTooNew
+)
+
+// timestampValidator checks that the timestamps submitted by the checks
+// fall in the window accepted by the intake, out of range points would
+// otherwise be silently lost there
+type timestampValidator struct {
+	maxPast   float64 // in seconds
+	maxFuture float64 // in seconds
+	clamp     bool
+}
+
+func newTimestampValidator() timestampValidator {
+	v := timestampValidator{
+		maxPast:   float64(config.Datadog.GetInt("metric_timestamp_max_past")),
+		maxFuture: float64(config.Datadog.GetInt("metric_timestamp_max_future")),
+	}
+
+	switch policy := config.Datadog.GetString("metric_timestamp_policy"); policy {
+	case timestampPolicyDrop:
+	case timestampPolicyClamp:
+		v.clamp = true
+	default:
+		log.Warnf("Unknown metric_timestamp_policy '%s', out of range points will be dropped", policy)
+	}
+	return v
+}
+
+// validate returns the timestamp to use for the point and whether it was
+// in range. When the timestamp is out of range and the policy is to clamp,
+// the returned timestamp is the closest bound of the window.
+func (v timestampValidator) validate(ts, now float64) (float64, timestampCheck) {
+	switch {
+	case v.maxPast > 0 && ts < now-v.maxPast:
+		return now - v.maxPast, timestampTooOld
+	case v.maxFuture > 0 && ts > now+v.maxFuture:
+		return now + v.maxFuture, timestampTooNew
+	}
+	return ts, timestampValid
+}
@@ -0,0 +1,82 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2018 Datadog, Inc.
+
+package aggregator
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/DataDog/datadog-agent/pkg/metrics"
+)
+
+func TestTimestampValidator(t *testing.T) {
+	v := timestampValidator{maxPast: 3600, maxFuture: 600}
+	now := 100000.0
+
+	for _, tc := range []struct {
+		ts       float64
+		expected float64
+		result   timestampCheck
+	}{
+		{now, now, timestampValid},
+		{now - 3600, now - 3600, timestampValid},
+		{now + 600, now + 600, timestampValid},
+		{now - 3601, now - 3600, timestampTooOld},
+		{12, now - 3600, timestampTooOld},
+		{now + 601, now + 600, timestampTooNew},
+	} {
+		ts, result := v.validate(tc.ts, now)
+		assert.Equal(t, tc.result, result, "timestamp %f", tc.ts)
+		assert.Equal(t, tc.expected, ts, "timestamp %f", tc.ts)
+	}
+
+	// a zero bound disables the check
+	v = timestampValidator{}
+	ts, result := v.validate(12, now)
+	assert.Equal(t, timestampValid, result)
+	assert.Equal(t, 12.0, ts)
+}
+
+func TestSendRawMetricSampleTimestamps(t *testing.T) {
+	senderMetricSampleChan := make(chan senderMetricSample, 10)
+	s := newCheckSender(checkID1, senderMetricSampleChan, nil, nil)
+	s.timestamps = timestampValidator{maxPast: 3600, maxFuture: 600}
+
+	now := timeNowNano()
+	s.SendRawMetricSample(&metrics.MetricSample{Name: "valid", Timestamp: now})
+	s.SendRawMetricSample(&metrics.MetricSample{Name: "unset"})
+	s.SendRawMetricSample(&metrics.MetricSample{Name: "old", Timestamp: now - 7200})
+	s.SendRawMetricSample(&metrics.MetricSample{Name: "new", Timestamp: now + 7200})
+	s.Commit()
+
+	assert.Equal(t, "valid", (<-senderMetricSampleChan).metricSample.Name)
+	unset := <-senderMetricSampleChan
+	assert.Equal(t, "unset", unset.metricSample.Name)
+	assert.NotEqual(t, 0.0, unset.metricSample.Timestamp)
+	assert.True(t, (<-senderMetricSampleChan).commit)
+
+	stats := s.GetMetricStats()
+	assert.Equal(t, int64(1), stats["TimestampsTooOld"])
+	assert.Equal(t, int64(1), stats["TimestampsTooNew"])
+	assert.Equal(t, int64(0), stats["TimestampsClamped"])
+
+	// clamp the out of range timestamps instead of dropping the samples
+	s.timestamps.clamp = true
+	now = timeNowNano()
+	s.SendRawMetricSample(&metrics.MetricSample{Name: "old", Timestamp: now - 7200})
+	s.Commit()
+
+	old := <-senderMetricSampleChan
+	assert.Equal(t, "old", old.metricSample.Name)
+	assert.InDelta(t, now-3600, old.metricSample.Timestamp, 5)
+	assert.True(t, (<-senderMetricSampleChan).commit)
+
+	stats = s.GetMetricStats()
+	assert.Equal(t, int64(1), stats["TimestampsTooOld"])
+	assert.Equal(t, int64(0), stats["TimestampsTooNew"])
+	assert.Equal(t, int64(1), stats["TimestampsClamped"])
+}
@@ -0,0 +1,97 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2018 Datadog, Inc.
+
+/*
+Package healthprobe serves the agent health on a plain HTTP port, without
+authentication, to be used as liveness and readiness probes by orchestrators
+like Kubernetes.
+*/
+package healthprobe
+
+import (
+	"encoding/json"
+	"fmt"
+	stdLog "log"
+	"net"
+	"net/http"
+	"time"
+
+	log "github.com/cihub/seelog"
+	"github.com/gorilla/mux"
+
+	"github.com/DataDog/datadog-agent/pkg/config"
+	"github.com/DataDog/datadog-agent/pkg/status/health"
+)
+
+var listener net.Listener
+
+// getStatus is replaced in unit tests
+var getStatus = health.GetStatus
+
+// Serve starts the probe server on the given port, a zero or negative
+// port disables it
+func Serve(port int) error {
+	if port <= 0 {
+		return nil
+	}
+
+	var err error
+	listener, err = net.Listen("tcp", fmt.Sprintf(":%d", port))
+	if err != nil {
+		return fmt.Errorf("unable to create the health probe server: %v", err)
+	}
+
+	srv := &http.Server{
+		Handler:      newRouter(),
+		ErrorLog:     stdLog.New(&config.ErrorLogWriter{}, "", 0), // log errors to seelog
+		WriteTimeout: 5 * time.Second,
+	}
+	go srv.Serve(listener)
+	log.Infof("Health probes listening on port %d", port)
+	return nil
+}
+
+// Stop closes the listener of the probe server
+func Stop() {
+	if listener != nil {
+		listener.Close()
+		listener = nil
+	}
+}
+
+func newRouter() *mux.Router {
+	r := mux.NewRouter()
+	r.HandleFunc("/live", liveHandler).Methods("GET")
+	r.HandleFunc("/ready", readyHandler).Methods("GET")
+	return r
+}
+
+// liveHandler fails when a component is stalled past its deadline, the
+// agent should then be restarted
+func liveHandler(w http.ResponseWriter, r *http.Request) {
+	s := getStatus()
+	writeStatus(w, s, s.Live())
+}
+
+// readyHandler fails as soon as a component is unhealthy
+func readyHandler(w http.ResponseWriter, r *http.Request) {
+	s := getStatus()
+	writeStatus(w, s, s.Ready())
+}
+
+func writeStatus(w http.ResponseWriter, s health.Status, ok bool) {
+	body, err := json.Marshal(s)
+	if err != nil {
+		log.Errorf("Error marshalling health status. Error: %v, Status: %v", err, s)
+		body, _ = json.Marshal(map[string]string{"error": err.Error()})
+		ok = false
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if !ok {
+		w.WriteHeader(http.StatusInternalServerError)
+	}
+	w.Write(body)
+}
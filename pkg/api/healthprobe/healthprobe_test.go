@@ -0,0 +1,68 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2018 Datadog, Inc.
+
+package healthprobe
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/DataDog/datadog-agent/pkg/status/health"
+)
+
+func probe(t *testing.T, path string, s health.Status) (int, health.Status) {
+	getStatus = func() health.Status { return s }
+	defer func() { getStatus = health.GetStatus }()
+
+	rec := httptest.NewRecorder()
+	req := httptest.NewRequest("GET", path, nil)
+	newRouter().ServeHTTP(rec, req)
+
+	var got health.Status
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &got))
+	return rec.Code, got
+}
+
+func TestProbesHealthy(t *testing.T) {
+	s := health.Status{Healthy: []string{"healthcheck", "forwarder"}}
+
+	for _, path := range []string{"/live", "/ready"} {
+		code, got := probe(t, path, s)
+		assert.Equal(t, http.StatusOK, code, path)
+		assert.Equal(t, s.Healthy, got.Healthy, path)
+	}
+}
+
+func TestProbesStalled(t *testing.T) {
+	s := health.Status{
+		Healthy:   []string{"healthcheck"},
+		Unhealthy: []string{"forwarder"},
+		Stalled:   map[string]time.Duration{"forwarder": 30 * time.Second},
+	}
+
+	// stalled within its deadline: not ready, but still alive
+	code, _ := probe(t, "/live", s)
+	assert.Equal(t, http.StatusOK, code)
+	code, got := probe(t, "/ready", s)
+	assert.Equal(t, http.StatusInternalServerError, code)
+	assert.Equal(t, 30*time.Second, got.Stalled["forwarder"])
+
+	s.Expired = []string{"forwarder"}
+	code, got = probe(t, "/live", s)
+	assert.Equal(t, http.StatusInternalServerError, code)
+	assert.Equal(t, []string{"forwarder"}, got.Expired)
+}
+
+func TestServeDisabled(t *testing.T) {
+	require.NoError(t, Serve(0))
+	assert.Nil(t, listener)
+	Stop()
+}
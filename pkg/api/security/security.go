@@ -157,7 +157,7 @@ func DeleteAuthToken() error {
 // 1st. the configuration value of "cluster_agent.auth_token" in datadog.yaml
 // 2nd. from the filesystem
 // If using the token from the filesystem, the token file must be next to the datadog.yaml
-// with the filename: dca_auth_token
+// with the filename: dca_auth_token, unless "cluster_agent.auth_token_file_path" is set
 func GetClusterAgentAuthToken() (string, error) {
 	authToken := config.Datadog.GetString("cluster_agent.auth_token")
 	if authToken != "" {
@@ -166,19 +166,18 @@ func GetClusterAgentAuthToken() (string, error) {
 	}
 
 	// load the cluster agent auth token from filesystem
-	tokenAbsPath := filepath.Join(config.FileUsedDir(), clusterAgentAuthTokenFilename)
+	tokenAbsPath := clusterAgentAuthTokenPath()
 	log.Debugf("Empty cluster_agent_auth_token, loading from %s", tokenAbsPath)
 
 	// Create a new token if it doesn't exist
 	if _, e := os.Stat(tokenAbsPath); os.IsNotExist(e) {
-		key := make([]byte, authTokenMinimalLen)
-		_, e = rand.Read(key)
+		newToken, e := GenerateClusterAgentAuthToken()
 		if e != nil {
-			return "", fmt.Errorf("error creating authentication token: %s", e)
+			return "", e
 		}
 
 		// Write the auth token to the auth token file (platform-specific)
-		e = saveAuthToken(hex.EncodeToString(key), tokenAbsPath)
+		e = saveAuthToken(newToken, tokenAbsPath)
 		if e != nil {
 			return "", fmt.Errorf("error creating authentication token: %s", e)
 		}
@@ -199,6 +198,46 @@ func GetClusterAgentAuthToken() (string, error) {
 	return authToken, validateAuthToken(authToken)
 }
 
+// GenerateClusterAgentAuthToken returns a new random cluster agent authentication
+// token, hex encoded. Its entropy in bytes is read from "cluster_agent.auth_token_length"
+// and can't be lower than half of the minimal token length.
+func GenerateClusterAgentAuthToken() (string, error) {
+	length := config.Datadog.GetInt("cluster_agent.auth_token_length")
+	if length < authTokenMinimalLen/2 {
+		log.Warnf("cluster_agent.auth_token_length is too low (%d), using %d bytes instead", length, authTokenMinimalLen/2)
+		length = authTokenMinimalLen / 2
+	}
+
+	key := make([]byte, length)
+	if _, err := rand.Read(key); err != nil {
+		return "", fmt.Errorf("error creating authentication token: %s", err)
+	}
+	return hex.EncodeToString(key), nil
+}
+
+// SaveClusterAgentAuthToken overwrites the cluster agent authentication token file,
+// used when the token is rotated
+func SaveClusterAgentAuthToken(authToken string) error {
+	if err := validateAuthToken(authToken); err != nil {
+		return err
+	}
+	tokenAbsPath := clusterAgentAuthTokenPath()
+	if err := saveAuthToken(authToken, tokenAbsPath); err != nil {
+		return fmt.Errorf("error saving authentication token: %s", err)
+	}
+	log.Debugf("cluster_agent_auth_token saved to %s", tokenAbsPath)
+	return nil
+}
+
+// clusterAgentAuthTokenPath returns "cluster_agent.auth_token_file_path" if set,
+// the cluster_agent_auth_token file next to the datadog.yaml otherwise
+func clusterAgentAuthTokenPath() string {
+	if path := config.Datadog.GetString("cluster_agent.auth_token_file_path"); path != "" {
+		return path
+	}
+	return filepath.Join(config.FileUsedDir(), clusterAgentAuthTokenFilename)
+}
+
 // TODO check the token is base64
 func validateAuthToken(authToken string) error {
 	if len(authToken) < authTokenMinimalLen {
@@ -40,3 +40,22 @@ func TestFetchAuthTokenValidGen(t *testing.T) {
 	_, err = os.Stat(expectTokenPath)
 	require.Nil(t, err)
 }
+
+func TestGenerateClusterAgentAuthTokenLength(t *testing.T) {
+	defer config.Datadog.Set("cluster_agent.auth_token_length", 32)
+
+	config.Datadog.Set("cluster_agent.auth_token_length", 48)
+	token, err := GenerateClusterAgentAuthToken()
+	require.Nil(t, err)
+	assert.Len(t, token, 96)
+
+	// too low values are raised to the minimal entropy
+	config.Datadog.Set("cluster_agent.auth_token_length", 4)
+	token, err = GenerateClusterAgentAuthToken()
+	require.Nil(t, err)
+	assert.Len(t, token, authTokenMinimalLen)
+
+	other, err := GenerateClusterAgentAuthToken()
+	require.Nil(t, err)
+	assert.NotEqual(t, token, other)
+}
@@ -0,0 +1,56 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2018 Datadog, Inc.
+
+package util
+
+import (
+	"net/http"
+	"sync"
+
+	log "github.com/cihub/seelog"
+)
+
+// DCAAuthenticator authenticates the requests to the exposed endpoints of the
+// Cluster Agent which don't carry its session token
+type DCAAuthenticator interface {
+	// Authenticate returns whether the bearer token grants access to the
+	// request, and the name of the user it belongs to
+	Authenticate(token string, r *http.Request) (bool, string, error)
+}
+
+var (
+	dcaAuthenticatorsMu sync.RWMutex
+	dcaAuthenticators   []DCAAuthenticator
+)
+
+// RegisterDCAAuthenticator adds an authenticator tried by ValidateDCARequest
+// when the bearer token is not the session token of the Cluster Agent
+func RegisterDCAAuthenticator(a DCAAuthenticator) {
+	dcaAuthenticatorsMu.Lock()
+	defer dcaAuthenticatorsMu.Unlock()
+	dcaAuthenticators = append(dcaAuthenticators, a)
+}
+
+// authenticateDCARequest tries the registered authenticators, in order, until
+// one of them grants access to the request
+func authenticateDCARequest(token string, r *http.Request) bool {
+	dcaAuthenticatorsMu.RLock()
+	defer dcaAuthenticatorsMu.RUnlock()
+	for _, a := range dcaAuthenticators {
+		allowed, user, err := a.Authenticate(token, r)
+		if err != nil {
+			log.Warnf("Could not authenticate the request to %s: %v", r.URL.Path, err)
+			continue
+		}
+		if allowed {
+			log.Tracef("Request to %s authenticated as %s", r.URL.Path, user)
+			return true
+		}
+		if user != "" {
+			log.Debugf("%s is not allowed to access %s", user, r.URL.Path)
+		}
+	}
+	return false
+}
@@ -0,0 +1,63 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2018 Datadog, Inc.
+
+package util
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type fakeAuthenticator struct {
+	token string
+	path  string
+	err   error
+	calls int
+}
+
+func (a *fakeAuthenticator) Authenticate(token string, r *http.Request) (bool, string, error) {
+	a.calls++
+	if a.err != nil {
+		return false, "", a.err
+	}
+	if token != a.token {
+		return false, "", nil
+	}
+	return r.URL.Path == a.path, "system:serviceaccount:default:datadog-agent", nil
+}
+
+func validateDCARequest(token, path string) int {
+	r := httptest.NewRequest("GET", path, nil)
+	r.Header.Set("Authorization", "Bearer "+token)
+	w := httptest.NewRecorder()
+	if err := ValidateDCARequest(w, r); err != nil {
+		return w.Code
+	}
+	return http.StatusOK
+}
+
+func TestValidateDCARequestAuthenticators(t *testing.T) {
+	resetDCATokens(testTokenA)
+	defer resetDCATokens("")
+	defer func() { dcaAuthenticators = nil }()
+
+	failing := &fakeAuthenticator{err: fmt.Errorf("apiserver unreachable")}
+	serviceAccount := &fakeAuthenticator{token: "sa-token", path: "/api/v1/metadata/node/pod"}
+	RegisterDCAAuthenticator(failing)
+	RegisterDCAAuthenticator(serviceAccount)
+
+	// the session token doesn't need the authenticators
+	assert.Equal(t, http.StatusOK, validateDCARequest(testTokenA, "/api/v1/metadata/node/pod"))
+	assert.Equal(t, 0, serviceAccount.calls)
+
+	assert.Equal(t, http.StatusOK, validateDCARequest("sa-token", "/api/v1/metadata/node/pod"))
+	assert.Equal(t, http.StatusForbidden, validateDCARequest("sa-token", "/stop"))
+	assert.Equal(t, http.StatusForbidden, validateDCARequest("other-token", "/api/v1/metadata/node/pod"))
+	assert.Equal(t, 3, failing.calls)
+}
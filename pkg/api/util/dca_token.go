@@ -0,0 +1,219 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2018 Datadog, Inc.
+
+package util
+
+import (
+	"crypto/subtle"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	log "github.com/cihub/seelog"
+
+	"github.com/DataDog/datadog-agent/pkg/api/security"
+	"github.com/DataDog/datadog-agent/pkg/config"
+)
+
+// dcaTokenState holds the session token of the Cluster Agent. After a rotation,
+// the previous token stays valid until previousExpiry.
+type dcaTokenState struct {
+	sync.RWMutex
+	current        string
+	previous       string
+	previousExpiry time.Time
+}
+
+var dcaTokens dcaTokenState
+
+// RotateDCAAuthToken replaces the session token of the Cluster Agent. The replaced
+// token is still accepted during the overlap, so that the node agents have time
+// to pick up the new one.
+func RotateDCAAuthToken(newToken string, overlap time.Duration) {
+	dcaTokens.Lock()
+	defer dcaTokens.Unlock()
+
+	if newToken == dcaTokens.current {
+		return
+	}
+	if dcaTokens.current != "" && overlap > 0 {
+		dcaTokens.previous = dcaTokens.current
+		dcaTokens.previousExpiry = time.Now().Add(overlap)
+	} else {
+		dcaTokens.previous = ""
+	}
+	dcaTokens.current = newToken
+}
+
+// isValidDCAAuthToken returns whether the token is the current session token,
+// or the previous one during the rotation overlap
+func isValidDCAAuthToken(token string) bool {
+	dcaTokens.RLock()
+	defer dcaTokens.RUnlock()
+
+	if dcaTokens.current != "" && subtle.ConstantTimeCompare([]byte(token), []byte(dcaTokens.current)) == 1 {
+		return true
+	}
+	return dcaTokens.previous != "" &&
+		time.Now().Before(dcaTokens.previousExpiry) &&
+		subtle.ConstantTimeCompare([]byte(token), []byte(dcaTokens.previous)) == 1
+}
+
+// DCATokenStore persists the session token of the Cluster Agent where the
+// node agents can read it.
+type DCATokenStore interface {
+	// Load returns the stored token, or an empty string if none is stored yet
+	Load() (string, error)
+	// Save replaces the stored token
+	Save(token string) error
+}
+
+// DCATokenFileStore stores the token in the cluster_agent_auth_token file
+type DCATokenFileStore struct{}
+
+// Load reads the cluster_agent_auth_token file
+func (DCATokenFileStore) Load() (string, error) {
+	return security.GetClusterAgentAuthToken()
+}
+
+// Save overwrites the cluster_agent_auth_token file
+func (DCATokenFileStore) Save(token string) error {
+	return security.SaveClusterAgentAuthToken(token)
+}
+
+// DCATokenRotator periodically replaces the session token of the Cluster Agent
+// and publishes it in a DCATokenStore. When several Cluster Agents share the
+// same store, only the one for which isLeader returns true generates tokens,
+// the others adopt the stored one.
+type DCATokenRotator struct {
+	store    DCATokenStore
+	interval time.Duration
+	overlap  time.Duration
+	isLeader func() bool
+	stop     chan struct{}
+
+	// m serializes the rotations of the background loop and of RotateNow
+	m            sync.Mutex
+	lastRotation time.Time
+}
+
+// NewDCATokenRotator returns a rotator reading its schedule from the
+// "cluster_agent.auth_token_rotation_*" settings. isLeader can be nil
+// if the store isn't shared.
+func NewDCATokenRotator(store DCATokenStore, isLeader func() bool) *DCATokenRotator {
+	return &DCATokenRotator{
+		store:    store,
+		interval: time.Duration(config.Datadog.GetInt("cluster_agent.auth_token_rotation_interval")) * time.Second,
+		overlap:  time.Duration(config.Datadog.GetInt("cluster_agent.auth_token_rotation_overlap")) * time.Second,
+		isLeader: isLeader,
+		stop:     make(chan struct{}),
+	}
+}
+
+// Sync aligns the session token with the store: the stored token is adopted
+// if there is one, the current token is stored otherwise.
+func (r *DCATokenRotator) Sync() error {
+	stored, err := r.store.Load()
+	if err != nil {
+		return fmt.Errorf("could not load the cluster agent auth token: %s", err)
+	}
+	stored = strings.TrimSpace(stored)
+	if stored == "" {
+		return r.store.Save(GetDCAAuthToken())
+	}
+	if stored != GetDCAAuthToken() {
+		log.Infof("Using the cluster agent auth token from the store")
+		RotateDCAAuthToken(stored, r.overlap)
+	}
+	return nil
+}
+
+// Start runs the rotation in the background. Nothing is done if the
+// rotation interval is not set.
+func (r *DCATokenRotator) Start() {
+	if r.interval <= 0 {
+		return
+	}
+	if r.overlap >= r.interval {
+		log.Warnf("cluster_agent.auth_token_rotation_overlap (%s) should be lower than the rotation interval (%s)", r.overlap, r.interval)
+	}
+	r.m.Lock()
+	r.lastRotation = time.Now()
+	r.m.Unlock()
+
+	go func() {
+		ticker := time.NewTicker(r.checkPeriod())
+		defer ticker.Stop()
+		for {
+			select {
+			case <-r.stop:
+				return
+			case now := <-ticker.C:
+				if err := r.tick(now); err != nil {
+					log.Errorf("Could not rotate the cluster agent auth token: %s", err)
+				}
+			}
+		}
+	}()
+	log.Infof("Rotating the cluster agent auth token every %s", r.interval)
+}
+
+// Stop stops the rotation
+func (r *DCATokenRotator) Stop() {
+	close(r.stop)
+}
+
+// checkPeriod is short enough for the non-leaders to adopt a new
+// token before the previous one expires on the leader
+func (r *DCATokenRotator) checkPeriod() time.Duration {
+	period := r.interval
+	if r.overlap > 0 && r.overlap/2 < period {
+		period = r.overlap / 2
+	}
+	if period < time.Second {
+		period = time.Second
+	}
+	return period
+}
+
+// RotateNow replaces the token without waiting for the rotation interval,
+// e.g. after it leaked. It fails on the Cluster Agents which are not the
+// leader, as their token would be replaced by the stored one.
+func (r *DCATokenRotator) RotateNow() error {
+	if r.isLeader != nil && !r.isLeader() {
+		return fmt.Errorf("only the leader cluster agent can rotate the auth token")
+	}
+	r.m.Lock()
+	defer r.m.Unlock()
+	return r.rotate(time.Now())
+}
+
+func (r *DCATokenRotator) tick(now time.Time) error {
+	if r.isLeader != nil && !r.isLeader() {
+		return r.Sync()
+	}
+	r.m.Lock()
+	defer r.m.Unlock()
+	if now.Sub(r.lastRotation) < r.interval {
+		return nil
+	}
+	return r.rotate(now)
+}
+
+func (r *DCATokenRotator) rotate(now time.Time) error {
+	newToken, err := security.GenerateClusterAgentAuthToken()
+	if err != nil {
+		return err
+	}
+	// keep using the current token if the new one could not be published
+	if err := r.store.Save(newToken); err != nil {
+		return err
+	}
+	RotateDCAAuthToken(newToken, r.overlap)
+	r.lastRotation = now
+	log.Infof("Rotated the cluster agent auth token, the previous one is valid for %s", r.overlap)
+	return nil
+}
@@ -0,0 +1,153 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2018 Datadog, Inc.
+
+package util
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const (
+	testTokenA = "aaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaa"
+	testTokenB = "bbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbb"
+)
+
+type memoryTokenStore struct {
+	token   string
+	saveErr error
+}
+
+func (s *memoryTokenStore) Load() (string, error) {
+	return s.token, nil
+}
+
+func (s *memoryTokenStore) Save(token string) error {
+	if s.saveErr != nil {
+		return s.saveErr
+	}
+	s.token = token
+	return nil
+}
+
+func resetDCATokens(token string) {
+	dcaTokens.Lock()
+	defer dcaTokens.Unlock()
+	dcaTokens.current = token
+	dcaTokens.previous = ""
+}
+
+func TestRotateDCAAuthTokenOverlap(t *testing.T) {
+	resetDCATokens(testTokenA)
+	defer resetDCATokens("")
+
+	RotateDCAAuthToken(testTokenB, time.Minute)
+	assert.Equal(t, testTokenB, GetDCAAuthToken())
+	assert.True(t, isValidDCAAuthToken(testTokenA))
+	assert.True(t, isValidDCAAuthToken(testTokenB))
+	assert.False(t, isValidDCAAuthToken("unknown"))
+	assert.False(t, isValidDCAAuthToken(""))
+
+	// the previous token is rejected once the overlap is over
+	dcaTokens.previousExpiry = time.Now().Add(-time.Second)
+	assert.False(t, isValidDCAAuthToken(testTokenA))
+	assert.True(t, isValidDCAAuthToken(testTokenB))
+
+	// no overlap
+	RotateDCAAuthToken(testTokenA, 0)
+	assert.True(t, isValidDCAAuthToken(testTokenA))
+	assert.False(t, isValidDCAAuthToken(testTokenB))
+}
+
+func TestDCATokenRotatorSync(t *testing.T) {
+	resetDCATokens(testTokenA)
+	defer resetDCATokens("")
+
+	// the current token is published in an empty store
+	store := &memoryTokenStore{}
+	r := &DCATokenRotator{store: store, overlap: time.Minute}
+	require.Nil(t, r.Sync())
+	assert.Equal(t, testTokenA, store.token)
+
+	// the stored token is adopted
+	store.token = testTokenB + "\n"
+	require.Nil(t, r.Sync())
+	assert.Equal(t, testTokenB, GetDCAAuthToken())
+	assert.True(t, isValidDCAAuthToken(testTokenA))
+}
+
+func TestDCATokenRotatorTick(t *testing.T) {
+	resetDCATokens(testTokenA)
+	defer resetDCATokens("")
+
+	now := time.Now()
+	store := &memoryTokenStore{token: testTokenA}
+	leader := true
+	r := &DCATokenRotator{
+		store:        store,
+		interval:     time.Hour,
+		overlap:      time.Minute,
+		isLeader:     func() bool { return leader },
+		lastRotation: now,
+	}
+
+	// not yet time to rotate
+	require.Nil(t, r.tick(now.Add(time.Minute)))
+	assert.Equal(t, testTokenA, GetDCAAuthToken())
+
+	// the token is kept if it can't be published
+	store.saveErr = fmt.Errorf("forbidden")
+	assert.NotNil(t, r.tick(now.Add(time.Hour)))
+	assert.Equal(t, testTokenA, GetDCAAuthToken())
+
+	store.saveErr = nil
+	require.Nil(t, r.tick(now.Add(time.Hour)))
+	rotated := GetDCAAuthToken()
+	assert.NotEqual(t, testTokenA, rotated)
+	assert.Equal(t, rotated, store.token)
+	assert.True(t, isValidDCAAuthToken(testTokenA))
+
+	// followers adopt the token of the leader
+	leader = false
+	store.token = testTokenB
+	require.Nil(t, r.tick(now.Add(3*time.Hour)))
+	assert.Equal(t, testTokenB, GetDCAAuthToken())
+}
+
+func TestDCATokenRotatorRotateNow(t *testing.T) {
+	resetDCATokens(testTokenA)
+	defer resetDCATokens("")
+
+	store := &memoryTokenStore{token: testTokenA}
+	leader := false
+	r := &DCATokenRotator{
+		store:    store,
+		interval: time.Hour,
+		overlap:  time.Minute,
+		isLeader: func() bool { return leader },
+	}
+
+	assert.NotNil(t, r.RotateNow())
+	assert.Equal(t, testTokenA, GetDCAAuthToken())
+
+	leader = true
+	require.Nil(t, r.RotateNow())
+	rotated := GetDCAAuthToken()
+	assert.NotEqual(t, testTokenA, rotated)
+	assert.Equal(t, rotated, store.token)
+	assert.True(t, isValidDCAAuthToken(testTokenA))
+}
+
+func TestDCATokenRotatorCheckPeriod(t *testing.T) {
+	r := &DCATokenRotator{interval: time.Hour, overlap: 10 * time.Minute}
+	assert.Equal(t, 5*time.Minute, r.checkPeriod())
+
+	r = &DCATokenRotator{interval: time.Minute}
+	assert.Equal(t, time.Minute, r.checkPeriod())
+}
@@ -15,8 +15,7 @@ import (
 )
 
 var (
-	token    string
-	dcaToken string
+	token string
 )
 
 // SetAuthToken sets the session token
@@ -41,20 +40,25 @@ func GetAuthToken() string {
 // SetDCAAuthToken sets the session token for the Cluster Agent
 // Requires that the config has been set up before calling
 func SetDCAAuthToken() error {
+	dcaTokens.Lock()
+	defer dcaTokens.Unlock()
+
 	// Noop if dcaToken is already set
-	if dcaToken != "" {
+	if dcaTokens.current != "" {
 		return nil
 	}
 
-	// dcaToken is only set once, no need to mutex protect
+	// the token can be replaced afterwards by RotateDCAAuthToken
 	var err error
-	dcaToken, err = security.GetClusterAgentAuthToken()
+	dcaTokens.current, err = security.GetClusterAgentAuthToken()
 	return err
 }
 
 // GetDCAAuthToken gets the session token
 func GetDCAAuthToken() string {
-	return dcaToken
+	dcaTokens.RLock()
+	defer dcaTokens.RUnlock()
+	return dcaTokens.current
 }
 
 // Validate validates an http request
@@ -85,7 +89,9 @@ func Validate(w http.ResponseWriter, r *http.Request) error {
 }
 
 // ValidateDCARequest is used for the exposed endpoints of the DCA.
-// It is different from Validate as we want to have different validations.
+// It is different from Validate as we want to have different validations:
+// besides the session token of the DCA, the tokens accepted by an authenticator
+// registered with RegisterDCAAuthenticator are valid.
 func ValidateDCARequest(w http.ResponseWriter, r *http.Request) error {
 	var err error
 	auth := r.Header.Get("Authorization")
@@ -103,12 +109,22 @@ func ValidateDCARequest(w http.ResponseWriter, r *http.Request) error {
 		http.Error(w, err.Error(), 401)
 		return err
 	}
-	dcaToken := config.Datadog.GetString("cluster_agent.auth_token")
-	if dcaToken == "" {
-		dcaToken = GetDCAAuthToken()
+	// a token set in the configuration is static and can't be rotated
+	valid := false
+	if len(tok) == 2 {
+		if dcaToken := config.Datadog.GetString("cluster_agent.auth_token"); dcaToken != "" {
+			valid = tok[1] == dcaToken
+		} else {
+			valid = isValidDCAAuthToken(tok[1])
+		}
+		// other tokens, like the ones of the service accounts, are checked
+		// by the registered authenticators
+		if !valid {
+			valid = authenticateDCARequest(tok[1], r)
+		}
 	}
 
-	if len(tok) != 2 || tok[1] != dcaToken {
+	if !valid {
 		err = fmt.Errorf("invalid session token")
 		http.Error(w, err.Error(), 403)
 	}
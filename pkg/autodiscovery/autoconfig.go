@@ -200,29 +200,7 @@ func (ac *AutoConfig) GetAllConfigs() []check.Config {
 		cfgs, _ := pd.provider.Collect()
 
 		if fileConfPd, ok := pd.provider.(*providers.FileConfigProvider); ok {
-			var goodConfs []check.Config
-			for _, cfg := range cfgs {
-				// JMX checks can have 2 YAML files: one containing the metrics to collect, one containing the
-				// instance configuration
-				// If the file provider finds any of these metric YAMLs, we store them in a map for future access
-				if cfg.MetricConfig != nil {
-					ac.name2jmxmetrics[cfg.Name] = cfg.MetricConfig
-					// We don't want to save metric files, it's enough to store them in the map
-					continue
-				}
-
-				goodConfs = append(goodConfs, cfg)
-
-				// Clear any old errors if a valid config file is found
-				errorStats.removeConfigError(cfg.Name)
-			}
-
-			// Grab any errors that occurred when reading the YAML file
-			for name, e := range fileConfPd.Errors {
-				errorStats.setConfigError(name, e)
-			}
-
-			cfgs = goodConfs
+			cfgs = ac.processFileConfigs(fileConfPd, cfgs)
 		}
 		// Store all raw configs in the provider
 		pd.configs = cfgs
@@ -238,6 +216,34 @@ func (ac *AutoConfig) GetAllConfigs() []check.Config {
 	return resolvedConfigs
 }
 
+// processFileConfigs stores the JMX metric configurations found by the file
+// provider and the errors of the files, and returns the other configurations
+func (ac *AutoConfig) processFileConfigs(fileConfPd *providers.FileConfigProvider, cfgs []check.Config) []check.Config {
+	var goodConfs []check.Config
+	for _, cfg := range cfgs {
+		// JMX checks can have 2 YAML files: one containing the metrics to collect, one containing the
+		// instance configuration
+		// If the file provider finds any of these metric YAMLs, we store them in a map for future access
+		if cfg.MetricConfig != nil {
+			ac.name2jmxmetrics[cfg.Name] = cfg.MetricConfig
+			// We don't want to save metric files, it's enough to store them in the map
+			continue
+		}
+
+		goodConfs = append(goodConfs, cfg)
+
+		// Clear any old errors if a valid config file is found
+		errorStats.removeConfigError(cfg.Name)
+	}
+
+	// Grab any errors that occurred when reading the YAML file
+	for name, e := range fileConfPd.Errors {
+		errorStats.setConfigError(name, e)
+	}
+
+	return goodConfs
+}
+
 // getChecksFromConfigs gets all the check instances for given configurations
 // optionally can populate ac cache config2checks
 func (ac *AutoConfig) getChecksFromConfigs(configs []check.Config, populateCache bool) []check.Check {
@@ -385,53 +391,7 @@ func (ac *AutoConfig) pollConfigs() {
 					// retrieve the list of newly added configurations as well
 					// as removed configurations
 					newConfigs, removedConfigs := ac.collect(pd)
-
-					// Process removed configs first to handle the case where a
-					// container churn would result in the same configuration hash.
-					for _, config := range removedConfigs {
-						// unschedule all the checks corresponding to this config
-						digest := config.Digest()
-						ids := ac.config2checks[digest]
-						stopped := map[check.ID]struct{}{}
-						for _, id := range ids {
-							// `StopCheck` might time out so we don't risk to block
-							// the polling loop forever
-							err := ac.collector.StopCheck(id)
-							if err != nil {
-								log.Errorf("Error stopping check %s: %s", id, err)
-								errorStats.setRunError(id, err.Error())
-							} else {
-								stopped[id] = struct{}{}
-							}
-						}
-
-						// remove the entry from `config2checks`
-						if len(stopped) == len(ac.config2checks[digest]) {
-							// we managed to stop all the checks for this config
-							delete(ac.config2checks, digest)
-							delete(ac.configResolver.config2Service, digest)
-						} else {
-							// keep the checks we failed to stop in `config2checks`
-							dangling := []check.ID{}
-							for _, id := range ac.config2checks[digest] {
-								if _, found := stopped[id]; !found {
-									dangling = append(dangling, id)
-								}
-							}
-							ac.config2checks[digest] = dangling
-						}
-
-						// if the config is a template, remove it from the cache
-						if config.IsTemplate() {
-							ac.templateCache.Del(config)
-						}
-					}
-					for _, config := range newConfigs {
-						config.Provider = pd.provider.String()
-						resolvedConfigs := ac.resolve(config)
-						checks := ac.getChecksFromConfigs(resolvedConfigs, true)
-						ac.schedule(checks)
-					}
+					ac.applyChanges(pd, newConfigs, removedConfigs)
 				}
 				ac.m.RUnlock()
 			}
@@ -439,6 +399,74 @@ func (ac *AutoConfig) pollConfigs() {
 	}()
 }
 
+// applyChanges unschedules the checks of the removed configurations of a
+// provider, then schedules the checks of the new ones
+func (ac *AutoConfig) applyChanges(pd *providerDescriptor, newConfigs, removedConfigs []check.Config) {
+	// Process removed configs first to handle the case where a
+	// container churn would result in the same configuration hash.
+	for _, config := range removedConfigs {
+		// unschedule all the checks corresponding to this config
+		digest := config.Digest()
+		ids := ac.config2checks[digest]
+		stopped := map[check.ID]struct{}{}
+		for _, id := range ids {
+			// `StopCheck` might time out so we don't risk to block
+			// the polling loop forever
+			err := ac.collector.StopCheck(id)
+			if err != nil {
+				log.Errorf("Error stopping check %s: %s", id, err)
+				errorStats.setRunError(id, err.Error())
+			} else {
+				stopped[id] = struct{}{}
+			}
+		}
+
+		// remove the entry from `config2checks`
+		if len(stopped) == len(ac.config2checks[digest]) {
+			// we managed to stop all the checks for this config
+			delete(ac.config2checks, digest)
+			delete(ac.configResolver.config2Service, digest)
+		} else {
+			// keep the checks we failed to stop in `config2checks`
+			dangling := []check.ID{}
+			for _, id := range ac.config2checks[digest] {
+				if _, found := stopped[id]; !found {
+					dangling = append(dangling, id)
+				}
+			}
+			ac.config2checks[digest] = dangling
+		}
+
+		// if the config is a template, remove it from the cache
+		if config.IsTemplate() {
+			ac.templateCache.Del(config)
+		}
+	}
+	for _, config := range newConfigs {
+		config.Provider = pd.provider.String()
+		resolvedConfigs := ac.resolve(config)
+		checks := ac.getChecksFromConfigs(resolvedConfigs, true)
+		ac.schedule(checks)
+	}
+}
+
+// Reload collects the configurations of all the providers, including the
+// ones that are not polled like the configuration files, and applies the
+// changes since they were last collected. It returns the number of new and
+// removed configurations.
+func (ac *AutoConfig) Reload() (added, removed int) {
+	ac.m.Lock()
+	defer ac.m.Unlock()
+
+	for _, pd := range ac.providers {
+		newConfigs, removedConfigs := ac.collect(pd)
+		ac.applyChanges(pd, newConfigs, removedConfigs)
+		added += len(newConfigs)
+		removed += len(removedConfigs)
+	}
+	return added, removed
+}
+
 // collect is just a convenient wrapper to fetch configurations from a provider and
 // see what changed from the last time we called Collect().
 func (ac *AutoConfig) collect(pd *providerDescriptor) (new, removed []check.Config) {
@@ -450,6 +478,9 @@ func (ac *AutoConfig) collect(pd *providerDescriptor) (new, removed []check.Conf
 		log.Errorf("Unable to collect configurations from provider %s: %s", pd.provider, err)
 		return
 	}
+	if fileConfPd, ok := pd.provider.(*providers.FileConfigProvider); ok {
+		fetched = ac.processFileConfigs(fileConfPd, fetched)
+	}
 
 	for _, c := range fetched {
 		if !pd.contains(&c) {
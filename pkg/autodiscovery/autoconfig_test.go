@@ -100,3 +100,32 @@ func TestStop(t *testing.T) {
 	assert.True(t, ml.stopReceived)
 	assert.True(t, ml.stopReceived)
 }
+
+type MockStaticProvider struct {
+	MockProvider
+	configs []check.Config
+}
+
+func (p *MockStaticProvider) Collect() ([]check.Config, error) {
+	return p.configs, nil
+}
+
+func TestReload(t *testing.T) {
+	ac := NewAutoConfig(nil)
+	ac.AddLoader(&MockLoader{})
+
+	mp := &MockStaticProvider{configs: []check.Config{{Name: "foo"}}}
+	ac.AddProvider(mp, false)
+	ac.LoadAndRun()
+
+	// nothing changed since the configs were loaded
+	added, removed := ac.Reload()
+	assert.Equal(t, 0, added)
+	assert.Equal(t, 0, removed)
+
+	mp.configs = []check.Config{{Name: "bar"}}
+	added, removed = ac.Reload()
+	assert.Equal(t, 1, added)
+	assert.Equal(t, 1, removed)
+	assert.Equal(t, []check.Config{{Name: "bar"}}, ac.providers[0].configs)
+}
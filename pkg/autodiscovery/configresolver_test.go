@@ -125,6 +125,7 @@ func TestResolve(t *testing.T) {
 			out: check.Config{
 				Name:          "cpu",
 				ADIdentifiers: []string{"redis"},
+				Entity:        "a5901276aed1",
 				Instances:     []check.ConfigData{check.ConfigData("host: 127.0.0.1")},
 			},
 		},
@@ -143,6 +144,7 @@ func TestResolve(t *testing.T) {
 			out: check.Config{
 				Name:          "cpu",
 				ADIdentifiers: []string{"redis"},
+				Entity:        "a5901276aed1",
 				Instances:     []check.ConfigData{check.ConfigData("host: 127.0.0.2")},
 			},
 		},
@@ -161,6 +163,7 @@ func TestResolve(t *testing.T) {
 			out: check.Config{
 				Name:          "cpu",
 				ADIdentifiers: []string{"redis"},
+				Entity:        "a5901276aed1",
 				Instances:     []check.ConfigData{check.ConfigData("host: 127.0.0.2")},
 			},
 		},
@@ -179,6 +182,7 @@ func TestResolve(t *testing.T) {
 			out: check.Config{
 				Name:          "cpu",
 				ADIdentifiers: []string{"redis"},
+				Entity:        "a5901276aed1",
 				Instances:     []check.ConfigData{check.ConfigData("host: 127.0.0.3")},
 			},
 		},
@@ -197,6 +201,7 @@ func TestResolve(t *testing.T) {
 			out: check.Config{
 				Name:          "cpu",
 				ADIdentifiers: []string{"redis"},
+				Entity:        "a5901276aed1",
 				Instances:     []check.ConfigData{check.ConfigData("host: 127.0.0.3")},
 			},
 		},
@@ -230,6 +235,7 @@ func TestResolve(t *testing.T) {
 			out: check.Config{
 				Name:          "cpu",
 				ADIdentifiers: []string{"redis"},
+				Entity:        "a5901276aed1",
 				Instances:     []check.ConfigData{check.ConfigData("port: 3")},
 			},
 		},
@@ -248,6 +254,7 @@ func TestResolve(t *testing.T) {
 			out: check.Config{
 				Name:          "cpu",
 				ADIdentifiers: []string{"redis"},
+				Entity:        "a5901276aed1",
 				Instances:     []check.ConfigData{check.ConfigData("port: 1")},
 			},
 		},
@@ -309,6 +316,7 @@ func TestResolve(t *testing.T) {
 			out: check.Config{
 				Name:          "cpu",
 				ADIdentifiers: []string{"redis"},
+				Entity:        "a5901276aed1",
 				Instances:     []check.ConfigData{check.ConfigData("test: test_value")},
 			},
 		},
@@ -355,6 +363,7 @@ func TestResolve(t *testing.T) {
 			out: check.Config{
 				Name:          "cpu",
 				ADIdentifiers: []string{"redis"},
+				Entity:        "a5901276aed1",
 				Instances:     []check.ConfigData{check.ConfigData("pid: 1337\ntags:\n- foo\n")},
 			},
 		},
@@ -0,0 +1,229 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2018 Datadog, Inc.
+
+package listeners
+
+import (
+	"fmt"
+	"net"
+	"strings"
+	"sync"
+	"time"
+
+	log "github.com/cihub/seelog"
+
+	"github.com/DataDog/datadog-agent/pkg/config"
+	"github.com/DataDog/datadog-agent/pkg/status/health"
+)
+
+// MDNSListener implements the ServiceListener interface for services
+// advertised on the local network through mDNS/DNS-SD.
+// It browses the configured service types periodically and checks for
+// new instances to monitor, and old instances to stop monitoring
+type MDNSListener struct {
+	serviceTypes  []string
+	domain        string
+	iface         *net.Interface
+	browseTimeout time.Duration
+	expiry        time.Duration
+	services      map[string]*MDNSService // maps instance names to services
+	lastSeen      map[string]time.Time
+	newService    chan<- Service
+	delService    chan<- Service
+	stop          chan bool
+	t             *time.Ticker
+	health        *health.Handle
+	m             sync.RWMutex
+}
+
+// MDNSService implements and store results from the Service interface for the mDNS listener
+type MDNSService struct {
+	ID            ID
+	ADIdentifiers []string
+	Hosts         map[string]string
+	Ports         []int
+	Tags          []string
+}
+
+func init() {
+	Register("mdns", NewMDNSListener)
+}
+
+// NewMDNSListener creates a MDNSListener
+func NewMDNSListener() (ServiceListener, error) {
+	serviceTypes := config.Datadog.GetStringSlice("mdns_listener.service_types")
+	if len(serviceTypes) == 0 {
+		return nil, fmt.Errorf("no service type to browse, please set mdns_listener.service_types")
+	}
+
+	var iface *net.Interface
+	if name := config.Datadog.GetString("mdns_listener.interface"); name != "" {
+		var err error
+		iface, err = net.InterfaceByName(name)
+		if err != nil {
+			return nil, fmt.Errorf("unable to use network interface %s: %s", name, err)
+		}
+	}
+
+	interval := time.Duration(config.Datadog.GetInt("mdns_listener.browse_interval")) * time.Second
+	if interval <= 0 {
+		return nil, fmt.Errorf("invalid mdns_listener.browse_interval: %v", interval)
+	}
+
+	return &MDNSListener{
+		serviceTypes:  serviceTypes,
+		domain:        config.Datadog.GetString("mdns_listener.domain"),
+		iface:         iface,
+		browseTimeout: time.Duration(config.Datadog.GetInt("mdns_listener.browse_timeout")) * time.Second,
+		// Instances that didn't answer 3 browses in a row are considered gone
+		expiry:   3 * interval,
+		services: make(map[string]*MDNSService),
+		lastSeen: make(map[string]time.Time),
+		stop:     make(chan bool),
+		t:        time.NewTicker(interval),
+		health:   health.Register("ad-mdnslistener"),
+	}, nil
+}
+
+// Listen browses the configured service types on a regular basis and reports
+// the advertised instances as Services.
+func (l *MDNSListener) Listen(newSvc chan<- Service, delSvc chan<- Service) {
+	// setup the I/O channels
+	l.newService = newSvc
+	l.delService = delSvc
+
+	go func() {
+		l.refreshServices()
+		for {
+			select {
+			case <-l.stop:
+				l.health.Deregister()
+				return
+			case <-l.health.C:
+			case <-l.t.C:
+				l.refreshServices()
+			}
+		}
+	}()
+}
+
+// Stop queues a shutdown of MDNSListener
+func (l *MDNSListener) Stop() {
+	l.t.Stop()
+	l.stop <- true
+}
+
+// refreshServices sends a single batched query for all the service types,
+// compares the advertised instances to the local cache and sends new/expired
+// services over newService and delService accordingly
+func (l *MDNSListener) refreshServices() {
+	entries, err := browseMDNS(l.iface, l.serviceTypes, l.domain, l.browseTimeout)
+	if err != nil {
+		log.Errorf("failed to browse mDNS services, not refreshing services - %s", err)
+		return
+	}
+
+	now := time.Now()
+	for _, e := range entries {
+		l.lastSeen[e.instance] = now
+		l.m.RLock()
+		_, found := l.services[e.instance]
+		l.m.RUnlock()
+		if found {
+			continue
+		}
+
+		svc := newMDNSService(e)
+		if len(svc.Hosts) == 0 {
+			log.Debugf("no address advertised for mDNS instance %s - skipping", e.instance)
+			delete(l.lastSeen, e.instance)
+			continue
+		}
+		l.m.Lock()
+		l.services[e.instance] = svc
+		l.m.Unlock()
+		l.newService <- svc
+	}
+
+	for instance, seen := range l.lastSeen {
+		if now.Sub(seen) < l.expiry {
+			continue
+		}
+		l.m.Lock()
+		svc := l.services[instance]
+		delete(l.services, instance)
+		l.m.Unlock()
+		delete(l.lastSeen, instance)
+		if svc != nil {
+			l.delService <- svc
+		}
+	}
+}
+
+// newMDNSService builds a service out of a resolved DNS-SD instance
+func newMDNSService(e mdnsEntry) *MDNSService {
+	svc := &MDNSService{
+		ID: ID("mdns://" + e.instance),
+		// Templates can target every instance of a service type, e.g. `_http._tcp`,
+		// or a single instance with its full name
+		ADIdentifiers: []string{e.serviceType, strings.TrimSuffix(e.instance, ".")},
+		Hosts:         make(map[string]string),
+		Tags: []string{
+			fmt.Sprintf("mdns_service:%s", e.serviceType),
+			fmt.Sprintf("mdns_instance:%s", instanceLabel(e.instance, e.serviceType)),
+			fmt.Sprintf("mdns_host:%s", strings.TrimSuffix(e.target, ".")),
+		},
+	}
+	if e.ipv4 != nil {
+		svc.Hosts["ipv4"] = e.ipv4.String()
+	}
+	if e.ipv6 != nil {
+		svc.Hosts["ipv6"] = e.ipv6.String()
+	}
+	if e.port > 0 {
+		svc.Ports = []int{e.port}
+	}
+	return svc
+}
+
+// instanceLabel extracts the user friendly instance label from a full DNS-SD
+// instance name, e.g. `printer` from `printer._ipp._tcp.local.`
+func instanceLabel(instance, serviceType string) string {
+	idx := strings.Index(strings.ToLower(instance), "."+strings.ToLower(strings.Trim(serviceType, "."))+".")
+	if idx <= 0 {
+		return strings.TrimSuffix(instance, ".")
+	}
+	return instance[:idx]
+}
+
+// GetID returns the service ID
+func (s *MDNSService) GetID() ID {
+	return s.ID
+}
+
+// GetADIdentifiers returns the service type and the full instance name
+func (s *MDNSService) GetADIdentifiers() ([]string, error) {
+	return s.ADIdentifiers, nil
+}
+
+// GetHosts returns the addresses advertised for the instance
+func (s *MDNSService) GetHosts() (map[string]string, error) {
+	return s.Hosts, nil
+}
+
+// GetPorts returns the port advertised in the SRV record of the instance
+func (s *MDNSService) GetPorts() ([]int, error) {
+	return s.Ports, nil
+}
+
+// GetTags returns the tags describing the instance
+func (s *MDNSService) GetTags() ([]string, error) {
+	return s.Tags, nil
+}
+
+// GetPid returns nil and an error because pids are not advertised over mDNS
+func (s *MDNSService) GetPid() (int, error) {
+	return -1, ErrNotSupported
+}
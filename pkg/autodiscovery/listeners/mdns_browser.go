@@ -0,0 +1,191 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2018 Datadog, Inc.
+
+package listeners
+
+import (
+	"fmt"
+	"net"
+	"strings"
+	"time"
+
+	"golang.org/x/net/dns/dnsmessage"
+)
+
+// mDNS well-known multicast group and port, see RFC 6762
+var mdnsGroupAddr = &net.UDPAddr{IP: net.IPv4(224, 0, 0, 251), Port: 5353}
+
+const mdnsMaxPacketSize = 9000
+
+// mdnsEntry is a DNS-SD service instance resolved from mDNS answers
+type mdnsEntry struct {
+	instance    string // full instance name, e.g. `printer._ipp._tcp.local.`
+	serviceType string // service type without domain, e.g. `_ipp._tcp`
+	target      string // host name the instance runs on
+	port        int
+	ipv4        net.IP
+	ipv6        net.IP
+}
+
+// buildMDNSQuery returns a single mDNS query packet asking for the PTR
+// records of every given service type, so that all of them are browsed
+// with one round trip
+func buildMDNSQuery(serviceTypes []string, domain string) ([]byte, error) {
+	msg := dnsmessage.Message{}
+	for _, st := range serviceTypes {
+		name, err := dnsmessage.NewName(serviceFQDN(st, domain))
+		if err != nil {
+			return nil, fmt.Errorf("invalid service type %q: %s", st, err)
+		}
+		msg.Questions = append(msg.Questions, dnsmessage.Question{
+			Name:  name,
+			Type:  dnsmessage.TypePTR,
+			Class: dnsmessage.ClassINET,
+		})
+	}
+	return msg.Pack()
+}
+
+// serviceFQDN returns the fully qualified name of a service type in a domain
+func serviceFQDN(serviceType, domain string) string {
+	return strings.Trim(serviceType, ".") + "." + strings.Trim(domain, ".") + "."
+}
+
+// mdnsResponse accumulates the records of several mDNS responses until the
+// service instances they describe can be resolved
+type mdnsResponse struct {
+	serviceTypes map[string]string // service FQDN -> service type
+	instances    map[string]string // instance FQDN -> service type
+	srv          map[string]dnsmessage.SRVResource
+	ipv4         map[string]net.IP
+	ipv6         map[string]net.IP
+}
+
+func newMDNSResponse(serviceTypes []string, domain string) *mdnsResponse {
+	r := &mdnsResponse{
+		serviceTypes: make(map[string]string),
+		instances:    make(map[string]string),
+		srv:          make(map[string]dnsmessage.SRVResource),
+		ipv4:         make(map[string]net.IP),
+		ipv6:         make(map[string]net.IP),
+	}
+	for _, st := range serviceTypes {
+		r.serviceTypes[strings.ToLower(serviceFQDN(st, domain))] = st
+	}
+	return r
+}
+
+// add parses a mDNS packet and records its answers and additional records
+func (r *mdnsResponse) add(packet []byte) error {
+	var msg dnsmessage.Message
+	if err := msg.Unpack(packet); err != nil {
+		return err
+	}
+	if !msg.Header.Response {
+		return nil
+	}
+
+	records := append(msg.Answers, msg.Additionals...)
+	for _, rr := range records {
+		name := strings.ToLower(rr.Header.Name.String())
+		switch body := rr.Body.(type) {
+		case *dnsmessage.PTRResource:
+			if st, found := r.serviceTypes[name]; found {
+				r.instances[strings.ToLower(body.PTR.String())] = st
+			}
+		case *dnsmessage.SRVResource:
+			r.srv[name] = *body
+		case *dnsmessage.AResource:
+			r.ipv4[name] = net.IP(body.A[:])
+		case *dnsmessage.AAAAResource:
+			r.ipv6[name] = net.IP(body.AAAA[:])
+		}
+	}
+	return nil
+}
+
+// entries returns the service instances for which a SRV record was received
+func (r *mdnsResponse) entries() []mdnsEntry {
+	var entries []mdnsEntry
+	for instance, st := range r.instances {
+		srv, found := r.srv[instance]
+		if !found {
+			continue
+		}
+		target := strings.ToLower(srv.Target.String())
+		entries = append(entries, mdnsEntry{
+			instance:    instance,
+			serviceType: st,
+			target:      target,
+			port:        int(srv.Port),
+			ipv4:        r.ipv4[target],
+			ipv6:        r.ipv6[target],
+		})
+	}
+	return entries
+}
+
+// browseMDNS sends a batched PTR query for the given service types and
+// collects the answers received before the timeout expires
+func browseMDNS(iface *net.Interface, serviceTypes []string, domain string, timeout time.Duration) ([]mdnsEntry, error) {
+	query, err := buildMDNSQuery(serviceTypes, domain)
+	if err != nil {
+		return nil, err
+	}
+
+	// Responders answer one-shot queries sent from a port other than 5353
+	// directly to the source address, no need to join the multicast group
+	laddr := &net.UDPAddr{IP: net.IPv4zero, Port: 0}
+	if iface != nil {
+		ip := interfaceIPv4(iface)
+		if ip == nil {
+			return nil, fmt.Errorf("no IPv4 address found on interface %s", iface.Name)
+		}
+		laddr.IP = ip
+	}
+	conn, err := net.ListenUDP("udp4", laddr)
+	if err != nil {
+		return nil, fmt.Errorf("unable to open mDNS socket: %s", err)
+	}
+	defer conn.Close()
+
+	if _, err := conn.WriteToUDP(query, mdnsGroupAddr); err != nil {
+		return nil, fmt.Errorf("unable to send mDNS query: %s", err)
+	}
+
+	response := newMDNSResponse(serviceTypes, domain)
+	buf := make([]byte, mdnsMaxPacketSize)
+	conn.SetReadDeadline(time.Now().Add(timeout))
+	for {
+		n, _, err := conn.ReadFromUDP(buf)
+		if err != nil {
+			if netErr, ok := err.(net.Error); ok && netErr.Timeout() {
+				break
+			}
+			return nil, fmt.Errorf("error while reading mDNS responses: %s", err)
+		}
+		if err := response.add(buf[:n]); err != nil {
+			// Malformed packets are common on busy networks, skip them
+			continue
+		}
+	}
+	return response.entries(), nil
+}
+
+// interfaceIPv4 returns the first IPv4 address of a network interface
+func interfaceIPv4(iface *net.Interface) net.IP {
+	addrs, err := iface.Addrs()
+	if err != nil {
+		return nil
+	}
+	for _, addr := range addrs {
+		if ipNet, ok := addr.(*net.IPNet); ok {
+			if ip := ipNet.IP.To4(); ip != nil {
+				return ip
+			}
+		}
+	}
+	return nil
+}
@@ -0,0 +1,109 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2018 Datadog, Inc.
+
+package listeners
+
+import (
+	"net"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/net/dns/dnsmessage"
+)
+
+func mustName(t *testing.T, name string) dnsmessage.Name {
+	n, err := dnsmessage.NewName(name)
+	require.NoError(t, err)
+	return n
+}
+
+func TestBuildMDNSQuery(t *testing.T) {
+	packet, err := buildMDNSQuery([]string{"_http._tcp", "_ipp._tcp."}, "local.")
+	require.NoError(t, err)
+
+	var msg dnsmessage.Message
+	require.NoError(t, msg.Unpack(packet))
+	require.Len(t, msg.Questions, 2)
+	assert.Equal(t, "_http._tcp.local.", msg.Questions[0].Name.String())
+	assert.Equal(t, dnsmessage.TypePTR, msg.Questions[0].Type)
+	assert.Equal(t, "_ipp._tcp.local.", msg.Questions[1].Name.String())
+}
+
+func TestMDNSResponse(t *testing.T) {
+	header := func(name string, typ dnsmessage.Type) dnsmessage.ResourceHeader {
+		return dnsmessage.ResourceHeader{Name: mustName(t, name), Type: typ, Class: dnsmessage.ClassINET, TTL: 120}
+	}
+	msg := dnsmessage.Message{
+		Header: dnsmessage.Header{Response: true, Authoritative: true},
+		Answers: []dnsmessage.Resource{
+			{
+				Header: header("_http._tcp.local.", dnsmessage.TypePTR),
+				Body:   &dnsmessage.PTRResource{PTR: mustName(t, "Web Server._http._tcp.local.")},
+			},
+			// Not browsed
+			{
+				Header: header("_ssh._tcp.local.", dnsmessage.TypePTR),
+				Body:   &dnsmessage.PTRResource{PTR: mustName(t, "box._ssh._tcp.local.")},
+			},
+			// No SRV record
+			{
+				Header: header("_http._tcp.local.", dnsmessage.TypePTR),
+				Body:   &dnsmessage.PTRResource{PTR: mustName(t, "other._http._tcp.local.")},
+			},
+		},
+		Additionals: []dnsmessage.Resource{
+			{
+				Header: header("Web Server._http._tcp.local.", dnsmessage.TypeSRV),
+				Body:   &dnsmessage.SRVResource{Target: mustName(t, "webhost.local."), Port: 8080},
+			},
+			{
+				Header: header("box._ssh._tcp.local.", dnsmessage.TypeSRV),
+				Body:   &dnsmessage.SRVResource{Target: mustName(t, "box.local."), Port: 22},
+			},
+			{
+				Header: header("webhost.local.", dnsmessage.TypeA),
+				Body:   &dnsmessage.AResource{A: [4]byte{192, 168, 1, 10}},
+			},
+		},
+	}
+	packet, err := msg.Pack()
+	require.NoError(t, err)
+
+	r := newMDNSResponse([]string{"_http._tcp"}, "local")
+	require.NoError(t, r.add(packet))
+	assert.Error(t, r.add([]byte{0x01, 0x02}))
+
+	entries := r.entries()
+	require.Len(t, entries, 1)
+	assert.Equal(t, "web server._http._tcp.local.", entries[0].instance)
+	assert.Equal(t, "_http._tcp", entries[0].serviceType)
+	assert.Equal(t, "webhost.local.", entries[0].target)
+	assert.Equal(t, 8080, entries[0].port)
+	assert.Equal(t, "192.168.1.10", entries[0].ipv4.String())
+	assert.Nil(t, entries[0].ipv6)
+}
+
+func TestNewMDNSService(t *testing.T) {
+	svc := newMDNSService(mdnsEntry{
+		instance:    "web server._http._tcp.local.",
+		serviceType: "_http._tcp",
+		target:      "webhost.local.",
+		port:        8080,
+		ipv4:        net.IPv4(192, 168, 1, 10),
+	})
+
+	assert.Equal(t, ID("mdns://web server._http._tcp.local."), svc.GetID())
+	ids, _ := svc.GetADIdentifiers()
+	assert.Equal(t, []string{"_http._tcp", "web server._http._tcp.local"}, ids)
+	hosts, _ := svc.GetHosts()
+	assert.Equal(t, map[string]string{"ipv4": "192.168.1.10"}, hosts)
+	ports, _ := svc.GetPorts()
+	assert.Equal(t, []int{8080}, ports)
+	tags, _ := svc.GetTags()
+	assert.Equal(t, []string{"mdns_service:_http._tcp", "mdns_instance:web server", "mdns_host:webhost.local"}, tags)
+	_, err := svc.GetPid()
+	assert.Equal(t, ErrNotSupported, err)
+}
@@ -26,3 +26,25 @@ type Check interface {
 	GetWarnings() []error                          // return the last warning registered by the check
 	GetMetricStats() (map[string]int64, error)     // get metric stats from the sender
 }
+
+// ResultsCacher is implemented by the checks whose results can be cached: if
+// a run fails, the gauges of their last successful run are submitted again,
+// tagged with `stale:true`, for up to ResultsTTL after that run
+type ResultsCacher interface {
+	ResultsTTL() time.Duration // 0 disables the cache
+}
+
+// RunTimeouter is implemented by the checks whose runs can time out: a run
+// lasting more than RunTimeout is reported as failed, and the check is not
+// scheduled again until that run returns
+type RunTimeouter interface {
+	RunTimeout() time.Duration // 0 disables the timeout
+	RunningStack() string      // the stack of the ongoing run, for the error
+}
+
+// Profiler is implemented by the checks whose runs can be profiled
+type Profiler interface {
+	// RunProfiled runs the check like Run, and also returns the report of
+	// where the run spent its time and allocated its memory
+	RunProfiled() (string, error)
+}
@@ -35,6 +35,7 @@ type Config struct {
 	LogsConfig    ConfigData   `json:"log_config"`     // the logs config in Yaml (logs-agent only)
 	ADIdentifiers []string     `json:"ad_identifiers"` // the list of AutoDiscovery identifiers (optional)
 	Provider      string       `json:"provider"`       // the provider that issued the config
+	Entity        string       `json:"entity"`         // the id of the service the template was resolved against (optional)
 }
 
 // Equal determines whether the passed config is the same
@@ -0,0 +1,60 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2018 Datadog, Inc.
+
+package check
+
+import (
+	"encoding/json"
+	"fmt"
+	"hash/fnv"
+	"regexp"
+	"strings"
+)
+
+// volatileErrorParts matches the parts of the error messages which change from
+// one run to the next for the same error: addresses, numbers and durations
+var volatileErrorParts = regexp.MustCompile(`0x[0-9a-fA-F]+|[0-9]+(\.[0-9]+)?`)
+
+// ErrorFingerprint identifies the error of a check run regardless of its
+// details which change from one run to the next. The errors of the python
+// checks are identified by their exception type and the frames of their
+// traceback, the other ones by their message stripped of its numbers.
+func ErrorFingerprint(errMsg string) string {
+	h := fnv.New64a()
+	if frames, ok := pythonTracebackFrames(errMsg); ok {
+		h.Write([]byte(frames))
+	} else {
+		h.Write(volatileErrorParts.ReplaceAll([]byte(errMsg), []byte("?")))
+	}
+	return fmt.Sprintf("%016x", h.Sum64())
+}
+
+// pythonTracebackFrames returns the frames and the exception type of the
+// traceback of an error sent by `AgentCheck.run`
+func pythonTracebackFrames(errMsg string) (string, bool) {
+	var pyErrors []map[string]string
+	if err := json.Unmarshal([]byte(errMsg), &pyErrors); err != nil || len(pyErrors) == 0 {
+		return "", false
+	}
+	traceback := strings.TrimRight(pyErrors[0]["traceback"], "\n")
+	if traceback == "" {
+		return "", false
+	}
+
+	var frames []string
+	lines := strings.Split(traceback, "\n")
+	for _, line := range lines {
+		// `  File "/path/to/check.py", line 12, in check`
+		if strings.HasPrefix(line, "  File ") {
+			frames = append(frames, line)
+		}
+	}
+	// the last line is the exception, `ValueError: invalid literal...`
+	exception := lines[len(lines)-1]
+	if i := strings.Index(exception, ":"); i >= 0 {
+		exception = exception[:i]
+	}
+	return strings.Join(append(frames, exception), "\n"), true
+}
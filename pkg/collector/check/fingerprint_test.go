@@ -0,0 +1,52 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2018 Datadog, Inc.
+
+package check
+
+import (
+	"encoding/json"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func pythonError(message, traceback string) string {
+	j, _ := json.Marshal([]map[string]string{{"message": message, "traceback": traceback}})
+	return string(j)
+}
+
+func TestErrorFingerprint(t *testing.T) {
+	tb := "Traceback (most recent call last):\n  File \"/checks/foo.py\", line 12, in check\n    int(value)\nValueError: invalid literal for int() with base 10: '%s'\n"
+	first := pythonError("invalid literal for int() with base 10: 'abc'", tb[:len(tb)-5]+"abc'\n")
+	second := pythonError("invalid literal for int() with base 10: 'def'", tb[:len(tb)-5]+"def'\n")
+	assert.Equal(t, ErrorFingerprint(first), ErrorFingerprint(second))
+
+	other := pythonError("division by zero", "Traceback (most recent call last):\n  File \"/checks/foo.py\", line 14, in check\n    1 / 0\nZeroDivisionError: division by zero\n")
+	assert.NotEqual(t, ErrorFingerprint(first), ErrorFingerprint(other))
+
+	// the other errors are identified by their message without the numbers
+	assert.Equal(t, ErrorFingerprint("connection to 10.0.0.1:8080 timed out after 2.5s"), ErrorFingerprint("connection to 10.0.0.2:8080 timed out after 3s"))
+	assert.NotEqual(t, ErrorFingerprint("connection refused"), ErrorFingerprint("connection reset"))
+}
+
+func TestStatsErrorCount(t *testing.T) {
+	s := &Stats{}
+	s.Add(time.Millisecond, errors.New("timed out after 2s"), nil, nil)
+	s.Add(time.Millisecond, errors.New("timed out after 3s"), nil, nil)
+	assert.Equal(t, uint64(2), s.ErrorCount())
+	firstSeen := s.LastErrorFirstSeen
+	assert.NotZero(t, firstSeen)
+
+	s.Add(time.Millisecond, errors.New("connection refused"), nil, nil)
+	assert.Equal(t, uint64(1), s.ErrorCount())
+
+	s.Add(time.Millisecond, nil, nil, nil)
+	assert.Equal(t, uint64(0), s.ErrorCount())
+	assert.Empty(t, s.LastErrorFingerprint)
+	assert.Zero(t, s.LastErrorFirstSeen)
+	assert.Equal(t, uint64(3), s.TotalErrors)
+}
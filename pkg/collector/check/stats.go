@@ -23,10 +23,17 @@ type Stats struct {
 	TotalMetrics         int64
 	TotalEvents          int64
 	TotalServiceChecks   int64
+	TimestampsTooOld     int64     // points of the last run with a timestamp too far in the past
+	TimestampsTooNew     int64     // points of the last run with a timestamp too far in the future
+	TimestampsClamped    int64     // out of range points of the last run that were clamped instead of dropped
+	TotalOutOfRange      int64     // points with an out of range timestamp since the check started
 	ExecutionTimes       [32]int64 // circular buffer of recent run durations, most recent at [(TotalRuns+31) % 32]
 	AverageExecutionTime int64     // average run duration
 	LastExecutionTime    int64     // most recent run duration, provided for convenience
 	LastError            string    // error that occurred in the last run, if any
+	LastErrorFingerprint string    // identifies the error of the last run, see ErrorFingerprint
+	LastErrorCount       uint64    // consecutive runs which failed with the error of the last run
+	LastErrorFirstSeen   int64     // first of these runs, unix timestamp in seconds
 	LastWarnings         []string  // warnings that occurred in the last run, if any
 	UpdateTimestamp      int64     // latest update to this instance, unix timestamp in seconds
 	m                    sync.Mutex
@@ -40,6 +47,41 @@ func NewStats(c Check) *Stats {
 	}
 }
 
+// ErrorCount returns the number of consecutive runs which failed with the
+// error of the last run, 0 if it succeeded
+func (cs *Stats) ErrorCount() uint64 {
+	cs.m.Lock()
+	defer cs.m.Unlock()
+	return cs.LastErrorCount
+}
+
+// LastRun returns the results of the last run of the check instance, with
+// the counts since it started, as exposed to the python checks
+func (cs *Stats) LastRun() map[string]interface{} {
+	cs.m.Lock()
+	defer cs.m.Unlock()
+
+	warnings := make([]interface{}, 0, len(cs.LastWarnings))
+	for _, w := range cs.LastWarnings {
+		warnings = append(warnings, w)
+	}
+	return map[string]interface{}{
+		"check_id":               string(cs.CheckID),
+		"total_runs":             int64(cs.TotalRuns),
+		"total_errors":           int64(cs.TotalErrors),
+		"total_warnings":         int64(cs.TotalWarnings),
+		"metrics":                cs.Metrics,
+		"events":                 cs.Events,
+		"service_checks":         cs.ServiceChecks,
+		"execution_time":         cs.LastExecutionTime,
+		"average_execution_time": cs.AverageExecutionTime,
+		"last_error":             cs.LastError,
+		"last_error_count":       int64(cs.LastErrorCount),
+		"last_warnings":          warnings,
+		"update_timestamp":       cs.UpdateTimestamp,
+	}
+}
+
 // Add tracks a new execution time
 func (cs *Stats) Add(t time.Duration, err error, warnings []error, metricStats map[string]int64) {
 	cs.m.Lock()
@@ -62,8 +104,19 @@ func (cs *Stats) Add(t time.Duration, err error, warnings []error, metricStats m
 	if err != nil {
 		cs.TotalErrors++
 		cs.LastError = err.Error()
+		// aggregate the runs failing with the same error
+		fingerprint := ErrorFingerprint(cs.LastError)
+		if fingerprint != cs.LastErrorFingerprint || cs.LastErrorCount == 0 {
+			cs.LastErrorFingerprint = fingerprint
+			cs.LastErrorCount = 0
+			cs.LastErrorFirstSeen = time.Now().Unix()
+		}
+		cs.LastErrorCount++
 	} else {
 		cs.LastError = ""
+		cs.LastErrorFingerprint = ""
+		cs.LastErrorCount = 0
+		cs.LastErrorFirstSeen = 0
 	}
 	cs.LastWarnings = []string{}
 	if len(warnings) != 0 {
@@ -92,4 +145,10 @@ func (cs *Stats) Add(t time.Duration, err error, warnings []error, metricStats m
 			cs.TotalServiceChecks += sc
 		}
 	}
+	cs.TimestampsTooOld = metricStats["TimestampsTooOld"]
+	cs.TimestampsTooNew = metricStats["TimestampsTooNew"]
+	cs.TimestampsClamped = metricStats["TimestampsClamped"]
+	if cs.TotalOutOfRange <= 1000001 {
+		cs.TotalOutOfRange += cs.TimestampsTooOld + cs.TimestampsTooNew
+	}
 }
@@ -13,6 +13,7 @@ import (
 	"github.com/DataDog/datadog-agent/pkg/collector/check"
 	"github.com/DataDog/datadog-agent/pkg/collector/runner"
 	"github.com/DataDog/datadog-agent/pkg/collector/scheduler"
+	"github.com/DataDog/datadog-agent/pkg/metadata/inventories"
 	log "github.com/cihub/seelog"
 )
 
@@ -174,8 +175,9 @@ func (c *Collector) StopCheck(id check.ID) error {
 		return fmt.Errorf("an error occurred while stopping the check: %s", err)
 	}
 
-	// remove the check from the stats map
+	// remove the check from the stats map and its metadata
 	runner.RemoveCheckStats(id)
+	inventories.RemoveCheckMetadata(string(id))
 
 	// vaporize the check
 	c.delete(id)
@@ -10,6 +10,7 @@ package cluster
 import (
 	"errors"
 	"fmt"
+	"time"
 
 	"github.com/DataDog/datadog-agent/pkg/aggregator"
 	"github.com/DataDog/datadog-agent/pkg/collector/check"
@@ -19,6 +20,7 @@ import (
 	"github.com/DataDog/datadog-agent/pkg/config"
 	"github.com/DataDog/datadog-agent/pkg/metrics"
 	"github.com/DataDog/datadog-agent/pkg/util/kubernetes/apiserver"
+	"github.com/DataDog/datadog-agent/pkg/util/ratelimit"
 	log "github.com/cihub/seelog"
 	"github.com/ericchiang/k8s/api/v1"
 	yaml "gopkg.in/yaml.v2"
@@ -36,6 +38,22 @@ type KubeASConfig struct {
 	Tags              []string `yaml:"tags"`
 	CollectEvent      bool     `yaml:"collect_events"`
 	FilteredEventType []string `yaml:"filtered_event_types"`
+	// MaxEventsPerMinute caps the number of Datadog events submitted over any
+	// minute, 0 means no limit
+	MaxEventsPerMinute int `yaml:"max_events_per_minute"`
+	// EventWorkloadTags resolves the owner chain of the objects involved in
+	// the events (pod -> replicaset -> deployment) to tag the events with
+	// their workload, e.g. kube_deployment
+	EventWorkloadTags bool `yaml:"event_workload_tags"`
+	// EventWorkloadNamespaces restricts the resolution of the owner chain to
+	// these namespaces, all namespaces are resolved when empty
+	EventWorkloadNamespaces []string `yaml:"event_workload_namespaces"`
+}
+
+// ownerResolver resolves the owner chain of a Kubernetes object, it is
+// implemented by the apiserver client
+type ownerResolver interface {
+	OwnerChain(namespace, kind, name string) ([]apiserver.Owner, error)
 }
 
 // KubeASCheck grabs metrics and events from the API server.
@@ -46,6 +64,8 @@ type KubeASCheck struct {
 	latestEventToken      string
 	configMapAvailable    bool
 	ac                    *apiserver.APIClient
+	eventLimiter          ratelimit.Limiter
+	owners                ownerResolver
 }
 
 func (c *KubeASConfig) parse(data []byte) error {
@@ -63,6 +83,9 @@ func (k *KubeASCheck) Configure(config, initConfig check.ConfigData) error {
 		log.Error("could not parse the config for the API server")
 		return err
 	}
+	if k.instance.MaxEventsPerMinute > 0 {
+		k.eventLimiter = ratelimit.NewSlidingWindow("kubernetes_events", k.instance.MaxEventsPerMinute, time.Minute)
+	}
 
 	log.Debugf("Running config %s", config)
 	return nil
@@ -97,6 +120,7 @@ func (k *KubeASCheck) Run() error {
 			k.Warn("Could not connect to apiserver: %s", err)
 			return err
 		}
+		k.owners = k.ac
 	}
 
 	// Running the Control Plane status check.
@@ -272,6 +296,7 @@ func (k *KubeASCheck) parseComponentStatus(sender aggregator.Sender, componentsS
 func (k *KubeASCheck) processEvents(sender aggregator.Sender, events []*v1.Event, modified bool) error {
 	eventsByObject := make(map[string]*kubernetesEventBundle)
 	filteredByType := make(map[string]int)
+	throttled := 0
 
 	// Only process the events which actions aren't part of the FilteredEventType list in the yaml config.
 ITER_EVENTS:
@@ -302,12 +327,79 @@ ITER_EVENTS:
 			k.Warnf("Error while formatting bundled events, %s. Not submitting", err.Error())
 			continue
 		}
+		if k.eventLimiter != nil && !k.eventLimiter.Allow() {
+			throttled++
+			continue
+		}
 		datadogEv.Tags = append(datadogEv.Tags, k.instance.Tags...)
+		datadogEv.Tags = append(datadogEv.Tags, k.workloadTags(bundle)...)
 		sender.Event(datadogEv)
 	}
+	if throttled > 0 {
+		k.Warnf("Dropped %d events over the limit of %d events per minute", throttled, k.instance.MaxEventsPerMinute)
+	}
 	return nil
 }
 
+// workloadTags returns the tags of the workloads owning the object involved
+// in the bundled events, so that the monitors scoped on them catch the events
+func (k *KubeASCheck) workloadTags(bundle *kubernetesEventBundle) []string {
+	if !k.instance.EventWorkloadTags || k.owners == nil || !k.resolveWorkloadIn(bundle.namespace) {
+		return nil
+	}
+
+	var tags []string
+	if tag := workloadTag(bundle.kind, bundle.name); tag != "" {
+		tags = append(tags, tag)
+	}
+	chain, err := k.owners.OwnerChain(bundle.namespace, bundle.kind, bundle.name)
+	if err != nil {
+		// the object can be gone already, events outlive it
+		log.Debugf("Could not resolve the owners of the %s %s/%s: %s", bundle.kind, bundle.namespace, bundle.name, err)
+		return tags
+	}
+	for _, owner := range chain {
+		if tag := workloadTag(owner.Kind, owner.Name); tag != "" {
+			tags = append(tags, tag)
+		}
+	}
+	return tags
+}
+
+func (k *KubeASCheck) resolveWorkloadIn(namespace string) bool {
+	if len(k.instance.EventWorkloadNamespaces) == 0 {
+		return true
+	}
+	for _, ns := range k.instance.EventWorkloadNamespaces {
+		if ns == namespace {
+			return true
+		}
+	}
+	return false
+}
+
+// workloadTag returns the tag of a workload, with the same names as the
+// kubelet tag collector, or an empty string for the other kinds
+func workloadTag(kind, name string) string {
+	switch kind {
+	case "Deployment":
+		return fmt.Sprintf("kube_deployment:%s", name)
+	case "ReplicaSet":
+		return fmt.Sprintf("kube_replica_set:%s", name)
+	case "DaemonSet":
+		return fmt.Sprintf("kube_daemon_set:%s", name)
+	case "StatefulSet":
+		return fmt.Sprintf("kube_stateful_set:%s", name)
+	case "ReplicationController":
+		return fmt.Sprintf("kube_replication_controller:%s", name)
+	case "Job":
+		return fmt.Sprintf("kube_job:%s", name)
+	case "CronJob":
+		return fmt.Sprintf("kube_cronjob:%s", name)
+	}
+	return ""
+}
+
 func init() {
 	core.RegisterCheck(kubernetesAPIServerCheckName, KubernetesASFactory)
 }
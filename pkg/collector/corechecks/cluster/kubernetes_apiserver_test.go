@@ -7,6 +7,7 @@
 package cluster
 
 import (
+	"errors"
 	"testing"
 
 	"time"
@@ -15,10 +16,12 @@ import (
 	obj "github.com/ericchiang/k8s/apis/meta/v1"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
 
 	"github.com/DataDog/datadog-agent/pkg/aggregator/mocksender"
 	core "github.com/DataDog/datadog-agent/pkg/collector/corechecks"
 	"github.com/DataDog/datadog-agent/pkg/metrics"
+	"github.com/DataDog/datadog-agent/pkg/util/kubernetes/apiserver"
 )
 
 func toStr(str string) *string {
@@ -261,3 +264,71 @@ func TestProcessEvent(t *testing.T) {
 	mocked.AssertNotCalled(t, "Event")
 	mocked.AssertExpectations(t)
 }
+
+type fakeOwnerResolver map[string][]apiserver.Owner
+
+func (f fakeOwnerResolver) OwnerChain(namespace, kind, name string) ([]apiserver.Owner, error) {
+	chain, found := f[namespace+"/"+kind+"/"+name]
+	if !found {
+		return nil, errors.New("not found")
+	}
+	return chain, nil
+}
+
+func TestProcessEventsWorkloadTags(t *testing.T) {
+	ev1 := createEvent(2, "default", "dca-789976f5d7-2ljx6", "Pod", "e6417a7f-f566-11e7-9749-0e4863e1cbf4", "default-scheduler", "Scheduled", "Successfully assigned dca-789976f5d7-2ljx6 to ip-10-0-0-54", 709662600)
+	ev2 := createEvent(1, "kube-system", "dns-5d7f9b-xk2p", "Pod", "a1b2c3d4-f566-11e7-9749-0e4863e1cbf4", "default-scheduler", "Scheduled", "Successfully assigned dns-5d7f9b-xk2p to ip-10-0-0-54", 709662600)
+	ev3 := createEvent(1, "default", "gone-pod", "Pod", "b1b2c3d4-f566-11e7-9749-0e4863e1cbf4", "kubelet", "Killing", "Killing container", 709662600)
+
+	kubeASCheck := &KubeASCheck{
+		instance: &KubeASConfig{
+			EventWorkloadTags:       true,
+			EventWorkloadNamespaces: []string{"default"},
+		},
+		CheckBase:             core.NewCheckBase(kubernetesAPIServerCheckName),
+		KubeAPIServerHostname: "hostname",
+		owners: fakeOwnerResolver{
+			"default/Pod/dca-789976f5d7-2ljx6": {
+				{Kind: "ReplicaSet", Name: "dca-789976f5d7"},
+				{Kind: "Deployment", Name: "dca"},
+			},
+			"kube-system/Pod/dns-5d7f9b-xk2p": {
+				{Kind: "ReplicaSet", Name: "dns-5d7f9b"},
+				{Kind: "Deployment", Name: "dns"},
+			},
+		},
+	}
+
+	tagsByTitle := func(events []*v1.Event) map[string][]string {
+		mocked := mocksender.NewMockSender(kubeASCheck.ID())
+		mocked.On("Event", mock.AnythingOfType("metrics.Event"))
+		kubeASCheck.processEvents(mocked, events, false)
+		tags := make(map[string][]string)
+		for _, call := range mocked.Calls {
+			ev := call.Arguments.Get(0).(metrics.Event)
+			tags[ev.Title] = ev.Tags
+		}
+		return tags
+	}
+
+	tags := tagsByTitle([]*v1.Event{ev1, ev2, ev3})
+	require.Len(t, tags, 3)
+	assert.Contains(t, tags["Events from the dca-789976f5d7-2ljx6 Pod"], "kube_replica_set:dca-789976f5d7")
+	assert.Contains(t, tags["Events from the dca-789976f5d7-2ljx6 Pod"], "kube_deployment:dca")
+	// namespace not resolved
+	assert.Len(t, tags["Events from the dns-5d7f9b-xk2p Pod"], 2)
+	// owners can't be resolved, the event is still submitted
+	assert.Len(t, tags["Events from the gone-pod Pod"], 2)
+
+	// disabled
+	kubeASCheck.instance.EventWorkloadTags = false
+	tags = tagsByTitle([]*v1.Event{ev1})
+	assert.NotContains(t, tags["Events from the dca-789976f5d7-2ljx6 Pod"], "kube_deployment:dca")
+}
+
+func TestWorkloadTag(t *testing.T) {
+	assert.Equal(t, "kube_deployment:dca", workloadTag("Deployment", "dca"))
+	assert.Equal(t, "kube_daemon_set:agent", workloadTag("DaemonSet", "agent"))
+	assert.Equal(t, "kube_cronjob:backup", workloadTag("CronJob", "backup"))
+	assert.Equal(t, "", workloadTag("Node", "localhost"))
+}
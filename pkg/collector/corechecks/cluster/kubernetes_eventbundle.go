@@ -20,6 +20,8 @@ import (
 type kubernetesEventBundle struct {
 	objUid        string         // Unique object Identifier used as the Aggregation key
 	namespace     string         // namespace of the bundle
+	kind          string         // kind of the object involved in the events
+	name          string         // name of the object involved in the events
 	readableKey   string         // Formated key used in the Title in the events
 	component     string         // Used to identify the Kubernetes component which generated the event
 	events        []*v1.Event    // List of events in the bundle
@@ -50,6 +52,8 @@ func (k *kubernetesEventBundle) addEvent(event *v1.Event) error {
 
 	k.events = append(k.events, event)
 	k.namespace = *event.InvolvedObject.Namespace
+	k.kind = *event.InvolvedObject.Kind
+	k.name = *event.InvolvedObject.Name
 	k.timeStamp = math.Max(k.timeStamp, float64(*event.Metadata.CreationTimestamp.Seconds))
 	k.lastTimestamp = math.Max(k.timeStamp, float64(*event.LastTimestamp.Seconds))
 
@@ -17,6 +17,7 @@ import (
 	"github.com/DataDog/datadog-agent/pkg/aggregator"
 	"github.com/DataDog/datadog-agent/pkg/collector/check"
 	"github.com/DataDog/datadog-agent/pkg/metrics"
+	"github.com/DataDog/datadog-agent/pkg/util/dnscache"
 	yaml "gopkg.in/yaml.v2"
 )
 
@@ -40,6 +41,7 @@ type ntpInstanceConfig struct {
 	Port            string `yaml:"port"`
 	Timeout         int    `yaml:"timeout"`
 	Version         int    `yaml:"version"`
+	DNSCache        *bool  `yaml:"dns_cache"`
 }
 
 type ntpInitConfig struct{}
@@ -117,7 +119,15 @@ func (c *NTPCheck) Run() error {
 	serviceCheckMessage := ""
 	offsetThreshold := c.cfg.instance.OffsetThreshold
 
-	response, err := ntpQuery(c.cfg.instance.Host, c.cfg.instance.Version)
+	host := c.cfg.instance.Host
+	if dnscache.Enabled(c.cfg.instance.DNSCache) {
+		host, err = dnscache.Default().Resolve(host)
+	}
+
+	var response *ntp.Response
+	if err == nil {
+		response, err = ntpQuery(host, c.cfg.instance.Version)
+	}
 	if err != nil {
 		log.Infof("There was an error querying the ntp host: %s", err)
 		serviceCheckStatus = metrics.ServiceCheckUnknown
@@ -0,0 +1,43 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2018 Datadog, Inc.
+
+package network
+
+import (
+	"fmt"
+	"math"
+	"math/rand"
+
+	log "github.com/cihub/seelog"
+
+	"github.com/DataDog/datadog-agent/pkg/diagnose/diagnosis"
+)
+
+// maxDiagnosisOffset is the clock offset, in seconds, above which the
+// intake may reject the submitted points
+const maxDiagnosisOffset = 60
+
+func init() {
+	diagnosis.Register("NTP offset", diagnoseNTP)
+}
+
+// diagnoseNTP checks that the host clock is in sync
+func diagnoseNTP() error {
+	host := fmt.Sprintf("%d.datadog.pool.ntp.org", rand.Intn(3))
+	response, err := ntpQuery(host, 3)
+	if err != nil {
+		log.Errorf("could not query %s: %s", host, err)
+		return diagnosis.WithRemediation(err, "make sure outbound UDP traffic on port 123 is allowed to check the clock offset")
+	}
+
+	offset := response.ClockOffset.Seconds()
+	if math.Abs(offset) > maxDiagnosisOffset {
+		err := fmt.Errorf("clock offset is %.3fs, higher than %ds", offset, maxDiagnosisOffset)
+		log.Error(err)
+		return diagnosis.WithRemediation(err, "synchronize the host clock with an NTP server, metrics with a skewed timestamp may be dropped")
+	}
+	log.Infof("clock offset is %.3fs", offset)
+	return nil
+}
@@ -11,10 +11,13 @@ import (
 	"time"
 
 	"github.com/beevik/ntp"
+	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
 
 	"github.com/DataDog/datadog-agent/pkg/aggregator/mocksender"
+	"github.com/DataDog/datadog-agent/pkg/diagnose/diagnosis"
 	"github.com/DataDog/datadog-agent/pkg/metrics"
+	"github.com/DataDog/datadog-agent/pkg/util/dnscache"
 )
 
 var (
@@ -124,3 +127,51 @@ func TestNTPError(t *testing.T) {
 	mockSender.AssertNumberOfCalls(t, "ServiceCheck", 1)
 	mockSender.AssertNumberOfCalls(t, "Commit", 1)
 }
+
+func TestNTPDNSCache(t *testing.T) {
+	var ntpCfg = []byte(ntpCfgString + "host: ntp.test\ndns_cache: true\n")
+	var ntpInitCfg = []byte("")
+
+	var queriedHost string
+	ntpQuery = func(host string, version int) (*ntp.Response, error) {
+		queriedHost = host
+		return testNTPQuery(host, version)
+	}
+	defer func() { ntpQuery = ntp.Query }()
+	defer dnscache.Default().ClearOverrides()
+
+	ntpCheck := new(NTPCheck)
+	ntpCheck.Configure(ntpCfg, ntpInitCfg)
+
+	mockSender := mocksender.NewMockSender(ntpCheck.ID())
+	mockSender.SetupAcceptAll()
+
+	dnscache.Default().SetStatic("ntp.test", "10.0.0.1")
+	ntpCheck.Run()
+	assert.Equal(t, "10.0.0.1", queriedHost)
+
+	// a resolution failure is reported like a query failure
+	queriedHost = ""
+	dnscache.Default().InjectFailure("ntp.test", fmt.Errorf("injected failure"))
+	ntpCheck.Run()
+	assert.Equal(t, "", queriedHost)
+	mockSender.AssertCalled(t, "ServiceCheck", "ntp.in_sync", metrics.ServiceCheckUnknown, "", []string(nil), "")
+}
+
+func TestDiagnoseNTP(t *testing.T) {
+	ntpQuery = testNTPQuery
+	defer func() { ntpQuery = ntp.Query }()
+
+	offset = 21
+	assert.NoError(t, diagnoseNTP())
+
+	offset = -100
+	err := diagnoseNTP()
+	assert.Error(t, err)
+	assert.Contains(t, diagnosis.Remediation(err), "synchronize")
+
+	ntpQuery = testNTPQueryError
+	err = diagnoseNTP()
+	assert.Error(t, err)
+	assert.Contains(t, diagnosis.Remediation(err), "port 123")
+}
@@ -35,6 +35,7 @@ import (
 	"github.com/DataDog/datadog-agent/pkg/collector/check"
 	core "github.com/DataDog/datadog-agent/pkg/collector/corechecks"
 	"github.com/DataDog/datadog-agent/pkg/util"
+	"github.com/DataDog/datadog-agent/pkg/util/dnscache"
 
 	log "github.com/cihub/seelog"
 	"github.com/k-sone/snmpgo"
@@ -85,6 +86,7 @@ type snmpInstanceCfg struct {
 	Retries         uint                    `yaml:"retries,omitempty"`
 	Metrics         []metric                `yaml:"metrics,omitempty"`
 	Tags            []string                `yaml:"tags,omitempty"`
+	DNSCache        *bool                   `yaml:"dns_cache,omitempty"`
 	OIDTranslator   *util.BiMap             `yaml:",omitempty"` //will not be in yaml
 	NameLookup      map[string]string       `yaml:",omitempty"` //will not be in yaml
 	MetricMap       map[string]*metric      `yaml:",omitempty"` //will not be in yaml
@@ -675,9 +677,18 @@ func (c *SNMPCheck) Configure(data check.ConfigData, initConfig check.ConfigData
 		}
 	}
 
+	// the address is resolved once, snmpgo keeps it for the check lifetime
+	host := c.cfg.instance.Host
+	if dnscache.Enabled(c.cfg.instance.DNSCache) {
+		if host, err = dnscache.Default().Resolve(host); err != nil {
+			log.Warnf("Error resolving SNMP host: %s (%v) - skipping", c.cfg.instance.Host, err)
+			return err
+		}
+	}
+
 	c.cfg.instance.snmp, err = snmpgo.NewSNMP(snmpgo.SNMPArguments{
 		Version:         snmpver,
-		Address:         net.JoinHostPort(c.cfg.instance.Host, strconv.Itoa(int(c.cfg.instance.Port))),
+		Address:         net.JoinHostPort(host, strconv.Itoa(int(c.cfg.instance.Port))),
 		Retries:         c.cfg.instance.Retries,
 		Timeout:         time.Duration(c.cfg.instance.Timeout) * time.Second,
 		UserName:        c.cfg.instance.User,
@@ -9,6 +9,7 @@ package collector
 
 import (
 	"github.com/DataDog/datadog-agent/pkg/collector/py"
+	"github.com/DataDog/datadog-agent/pkg/collector/runner"
 	python "github.com/sbinet/go-python"
 )
 
@@ -16,10 +17,13 @@ var pyState *python.PyThreadState
 
 func pySetup(paths ...string) (pythonVersion, pythonHome, pythonPath string) {
 	pyState = py.Initialize(paths...)
+	py.StartInterpreterTelemetry()
+	py.CheckStatsGetter = runner.GetCheckStatsByName
 	return py.PythonVersion, py.PythonHome, py.PythonPath
 }
 
 func pyTeardown() {
+	py.StopInterpreterTelemetry()
 	python.PyEval_RestoreThread(pyState)
 	pyState = nil
 }
@@ -13,6 +13,7 @@ import (
 	"github.com/DataDog/datadog-agent/pkg/metadata/common"
 	"github.com/DataDog/datadog-agent/pkg/metadata/externalhost"
 	"github.com/DataDog/datadog-agent/pkg/metadata/host"
+	"github.com/DataDog/datadog-agent/pkg/metadata/inventories"
 	"github.com/DataDog/datadog-agent/pkg/util"
 
 	log "github.com/cihub/seelog"
@@ -62,11 +63,13 @@ func GetPayload() *Payload {
 	metaPayload.Hostname = hostname
 	cp := common.GetPayload(hostname)
 	ehp := externalhost.GetPayload()
+	cmp := inventories.GetPayload()
 	payload := &Payload{
 		CommonPayload{*cp},
 		MetaPayload{*metaPayload},
 		agentChecksPayload,
 		ExternalHostPayload{*ehp},
+		CheckMetadataPayload{*cmp},
 	}
 
 	return payload
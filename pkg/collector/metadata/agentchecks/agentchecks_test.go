@@ -9,6 +9,7 @@ import (
 	"testing"
 
 	"github.com/DataDog/datadog-agent/pkg/metadata/externalhost"
+	"github.com/DataDog/datadog-agent/pkg/metadata/inventories"
 	"github.com/stretchr/testify/assert"
 )
 
@@ -36,3 +37,13 @@ func TestExternalHostTags(t *testing.T) {
 		}
 	}
 }
+
+func TestCheckMetadata(t *testing.T) {
+	defer inventories.RemoveCheckMetadata("postgres:1")
+	inventories.SetCheckMetadata("postgres", "postgres:1", "version", "10.5")
+
+	pl := GetPayload()
+	cmp := pl.CheckMetadataPayload.Payload
+	assert.Len(t, cmp["postgres"], 1)
+	assert.Equal(t, "10.5", cmp["postgres"][0]["version"])
+}
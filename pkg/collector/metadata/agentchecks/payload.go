@@ -12,6 +12,7 @@ import (
 	"github.com/DataDog/datadog-agent/pkg/metadata/common"
 	"github.com/DataDog/datadog-agent/pkg/metadata/externalhost"
 	"github.com/DataDog/datadog-agent/pkg/metadata/host"
+	"github.com/DataDog/datadog-agent/pkg/metadata/inventories"
 	"github.com/DataDog/datadog-agent/pkg/serializer/marshaler"
 )
 
@@ -21,6 +22,7 @@ type Payload struct {
 	MetaPayload
 	ACPayload
 	ExternalHostPayload
+	CheckMetadataPayload
 }
 
 // MetaPayload wraps Meta from the host package (this is cached)
@@ -43,6 +45,11 @@ type ExternalHostPayload struct {
 	externalhost.Payload `json:"external_host_tags"`
 }
 
+// CheckMetadataPayload wraps Payload from the `inventories` package
+type CheckMetadataPayload struct {
+	inventories.Payload `json:"check_metadata"`
+}
+
 // MarshalJSON serialization a Payload to JSON
 func (p *Payload) MarshalJSON() ([]byte, error) {
 	// use an alias to avoid infinite recursion while serializing
@@ -11,6 +11,7 @@ import (
 	"errors"
 	"fmt"
 	"runtime"
+	"sync/atomic"
 	"time"
 
 	"gopkg.in/yaml.v2"
@@ -28,12 +29,18 @@ import "C"
 
 // PythonCheck represents a Python check, implements `Check` interface
 type PythonCheck struct {
+	// ident of the python thread of the ongoing run, 0 if not running. First
+	// field to be 64-bit aligned for the atomic operations
+	runningThread int64
+
 	id           check.ID
 	instance     *python.PyObject
 	class        *python.PyObject
 	ModuleName   string
 	config       *python.PyObject
 	interval     time.Duration
+	resultsTTL   time.Duration
+	runTimeout   time.Duration
 	lastWarnings []error
 }
 
@@ -58,6 +65,40 @@ func (c *PythonCheck) Run() error {
 	gstate := newStickyLock()
 	defer gstate.unlock()
 
+	return c.run(gstate)
+}
+
+// RunProfiled runs the Python check with the CPU and memory profilers
+// enabled, and returns their report along with the error of the run
+func (c *PythonCheck) RunProfiled() (string, error) {
+	// The profilers are enabled on the thread running the check: the GIL
+	// has to be kept locked, on the same thread, for the whole run
+	gstate := newStickyLock()
+	defer gstate.unlock()
+
+	profiler, err := startProfiler(gstate)
+	if err != nil {
+		log.Warnf("Could not profile check %s: %s", c.id, err)
+		return fmt.Sprintf("The profiling could not be started: %s\n", err), c.run(gstate)
+	}
+	defer profiler.DecRef()
+
+	runErr := c.run(gstate)
+
+	report, err := stopProfiler(gstate, profiler)
+	if err != nil {
+		log.Warnf("Could not profile check %s: %s", c.id, err)
+		return fmt.Sprintf("The profiling could not be completed: %s\n", err), runErr
+	}
+	return report, runErr
+}
+
+// run the Python check, the `stickyLock` must be locked
+func (c *PythonCheck) run(gstate *stickyLock) error {
+	// keep track of the thread running the check to get its stack on timeout
+	atomic.StoreInt64(&c.runningThread, int64(C.PyThread_get_thread_ident()))
+	defer atomic.StoreInt64(&c.runningThread, 0)
+
 	// call run function, it takes no args so we pass an empty tuple
 	log.Debugf("Running python check %s %s", c.ModuleName, c.id)
 	emptyTuple := python.PyTuple_New(0)
@@ -127,9 +168,10 @@ func (c *PythonCheck) String() string {
 	return c.ModuleName
 }
 
-// GetWarnings grabs the last warnings from the struct
+// GetWarnings grabs the last warnings from the struct, and the ones submitted
+// with `datadog_agent.warning`, even if the run failed
 func (c *PythonCheck) GetWarnings() []error {
-	warnings := c.lastWarnings
+	warnings := append(c.lastWarnings, popCheckWarnings(c.id)...)
 	c.lastWarnings = []error{}
 	return warnings
 }
@@ -223,6 +265,20 @@ func (c *PythonCheck) Configure(data check.ConfigData, initConfig check.ConfigDa
 		}
 	}
 
+	// See if the results should be re-submitted when a run fails
+	if x, ok := rawInstances["cache_results_ttl"]; ok {
+		if ttl, ok := x.(int); ok {
+			c.resultsTTL = time.Duration(ttl) * time.Second
+		}
+	}
+
+	// See if the runs should time out
+	if x, ok := rawInstances["run_timeout"]; ok {
+		if timeout, ok := x.(int); ok {
+			c.runTimeout = time.Duration(timeout) * time.Second
+		}
+	}
+
 	// To be retrocompatible with the Python code, still use an `instance` dictionary
 	// to contain the (now) unique instance for the check
 	conf := make(check.ConfigRawMap)
@@ -296,6 +352,37 @@ func (c *PythonCheck) Interval() time.Duration {
 	return c.interval
 }
 
+// ResultsTTL returns how long the results of the last successful run are
+// re-submitted when the check fails, set by `cache_results_ttl`
+func (c *PythonCheck) ResultsTTL() time.Duration {
+	return c.resultsTTL
+}
+
+// RunTimeout returns how long a run can last before being reported as failed,
+// set by `run_timeout`
+func (c *PythonCheck) RunTimeout() time.Duration {
+	return c.runTimeout
+}
+
+// RunningStack returns the python stack of the ongoing run of the check, or
+// an empty string if it's not running
+func (c *PythonCheck) RunningStack() string {
+	threadID := atomic.LoadInt64(&c.runningThread)
+	if threadID == 0 {
+		return ""
+	}
+
+	gstate := newStickyLock()
+	defer gstate.unlock()
+
+	stack, err := gstate.getThreadStack(threadID)
+	if err != nil {
+		log.Debugf("Could not get the stack of check %s: %s", c.id, err)
+		return ""
+	}
+	return stack
+}
+
 // ID returns the ID of the check
 func (c *PythonCheck) ID() check.ID {
 	return c.id
@@ -316,5 +403,6 @@ func pythonCheckFinalizer(c *PythonCheck) {
 		if c.config != nil {
 			c.config.DecRef()
 		}
+		popCheckWarnings(c.id)
 	}(c)
 }
@@ -80,6 +80,17 @@ func TestWarning(t *testing.T) {
 	err := check.Run()
 	assert.Nil(t, err)
 
+	warnings := check.GetWarnings()
+	require.Len(t, warnings, 1)
+	assert.Equal(t, "The cake is a lie", warnings[0].Error())
+	assert.Empty(t, check.GetWarnings())
+}
+
+func TestWarningFailedRun(t *testing.T) {
+	check, _ := getCheckInstance("testwarnings", "FailingCheck")
+	err := check.Run()
+	assert.NotNil(t, err)
+
 	warnings := check.GetWarnings()
 	require.Len(t, warnings, 1)
 	assert.Equal(t, "The cake is a lie", warnings[0].Error())
@@ -100,6 +111,40 @@ func TestInterval(t *testing.T) {
 	assert.Equal(t, time.Duration(1)*time.Second, c.Interval())
 }
 
+func TestRunTimeout(t *testing.T) {
+	c, _ := getCheckInstance("testcheck", "TestCheck")
+	assert.Equal(t, time.Duration(0), c.RunTimeout())
+	c.Configure([]byte("run_timeout: 30"), []byte("foo: bar"))
+	assert.Equal(t, 30*time.Second, c.RunTimeout())
+}
+
+func TestRunningStack(t *testing.T) {
+	c, _ := getCheckInstance("testhanging", "TestCheck")
+	assert.Empty(t, c.RunningStack())
+
+	done := make(chan error)
+	go func() {
+		done <- c.Run()
+	}()
+	time.Sleep(300 * time.Millisecond)
+
+	stack := c.RunningStack()
+	assert.Contains(t, stack, "testhanging.py")
+	assert.Contains(t, stack, "wait_for_the_cake")
+
+	assert.Nil(t, <-done)
+	assert.Empty(t, c.RunningStack())
+}
+
+func TestRunProfiled(t *testing.T) {
+	c, _ := getCheckInstance("testhanging", "TestCheck")
+	profile, err := c.RunProfiled()
+	assert.Nil(t, err)
+	assert.Contains(t, profile, "top 20 functions by cumulative time")
+	assert.Contains(t, profile, "wait_for_the_cake")
+	assert.Contains(t, profile, "top 20 allocations by line")
+}
+
 func TestInitKwargsCheck(t *testing.T) {
 	_, err := getCheckInstance("kwargs_init_signature", "TestCheck")
 	assert.Nil(t, err)
@@ -19,13 +19,24 @@
 PyObject* GetVersion(PyObject *self, PyObject *args);
 PyObject* Headers(PyObject *self, PyObject *args);
 PyObject* GetHostname(PyObject *self, PyObject *args);
+PyObject* GetClusterName(PyObject *self, PyObject *args);
+PyObject* GetOrchestratorContext(PyObject *self, PyObject *args);
 PyObject* LogMessage(char *message, int logLevel);
 PyObject* GetConfig(char *key);
-PyObject* GetSubprocessOutput(char **args, int argc, int raise);
+PyObject* GetSubprocessOutput(char **args, int argc, int raise, double timeout);
 PyObject* SetExternalTags(const char *hostname, const char *source_type, char **tags, int tags_s);
+PyObject* SetCheckMetadata(char *check_id, char *name, char *value);
+PyObject* SubmitWarning(char *check_id, char *message);
+PyObject* SendLog(char *line, char *attributes);
+PyObject* WritePersistentCache(char *key, char *value);
+PyObject* ReadPersistentCache(char *key);
+PyObject* ObfuscateSQL(char *query);
+PyObject* Scrub(char *text);
+PyObject* GetCheckStats(char *check_name);
 
 // Exceptions
 PyObject* SubprocessOutputEmptyError;
+PyObject* SubprocessOutputTimeoutError;
 
 static PyObject *get_config(PyObject *self, PyObject *args) {
     char *key;
@@ -59,9 +70,165 @@ static PyObject *log_message(PyObject *self, PyObject *args) {
     return LogMessage(message, log_level);
 }
 
+static PyObject *set_check_metadata(PyObject *self, PyObject *args) {
+    char *check_id, *name, *value;
+
+    PyGILState_STATE gstate;
+    gstate = PyGILState_Ensure();
+
+    // datadog_agent.set_check_metadata(check_id, name, value)
+    if (!PyArg_ParseTuple(args, "sss", &check_id, &name, &value)) {
+      PyGILState_Release(gstate);
+      return NULL;
+    }
+
+    PyGILState_Release(gstate);
+    return SetCheckMetadata(check_id, name, value);
+}
+
+static PyObject *warning(PyObject *self, PyObject *args) {
+    char *check_id, *message;
+
+    PyGILState_STATE gstate;
+    gstate = PyGILState_Ensure();
+
+    // datadog_agent.warning(check_id, message)
+    if (!PyArg_ParseTuple(args, "ss", &check_id, &message)) {
+      PyGILState_Release(gstate);
+      return NULL;
+    }
+
+    PyGILState_Release(gstate);
+    return SubmitWarning(check_id, message);
+}
+
+static PyObject *send_log(PyObject *self, PyObject *args) {
+    char *line;
+    PyObject *attributes = NULL, *json = NULL, *dumped = NULL, *py_result;
+
+    PyGILState_STATE gstate;
+    gstate = PyGILState_Ensure();
+
+    // datadog_agent.send_log(log_line, attributes=None)
+    if (!PyArg_ParseTuple(args, "s|O", &line, &attributes)) {
+      PyGILState_Release(gstate);
+      return NULL;
+    }
+
+    if (attributes != NULL && attributes != Py_None && !PyDict_Check(attributes)) {
+      PyErr_SetString(PyExc_TypeError, "the attributes must be a dict");
+      PyGILState_Release(gstate);
+      return NULL;
+    }
+
+    // the attributes are sent JSON encoded to the Go function, they can hold
+    // any serializable value
+    if (attributes == NULL || attributes == Py_None) {
+      dumped = PyString_FromString("{}");
+    } else if ((json = PyImport_ImportModule("json")) != NULL) {
+      dumped = PyObject_CallMethod(json, "dumps", "O", attributes);
+      Py_DECREF(json);
+    }
+    if (dumped == NULL) {
+      PyGILState_Release(gstate);
+      return NULL;
+    }
+
+    PyGILState_Release(gstate);
+    // `dumped` is only released once the Go function returned, it owns the buffer
+    py_result = SendLog(line, PyString_AsString(dumped));
+
+    gstate = PyGILState_Ensure();
+    Py_DECREF(dumped);
+    PyGILState_Release(gstate);
+
+    return py_result;
+}
+
+static PyObject *write_persistent_cache(PyObject *self, PyObject *args) {
+    char *key, *value;
+
+    PyGILState_STATE gstate;
+    gstate = PyGILState_Ensure();
+
+    // datadog_agent.write_persistent_cache(key, value)
+    if (!PyArg_ParseTuple(args, "ss", &key, &value)) {
+      PyGILState_Release(gstate);
+      return NULL;
+    }
+
+    PyGILState_Release(gstate);
+    return WritePersistentCache(key, value);
+}
+
+static PyObject *read_persistent_cache(PyObject *self, PyObject *args) {
+    char *key;
+
+    PyGILState_STATE gstate;
+    gstate = PyGILState_Ensure();
+
+    // datadog_agent.read_persistent_cache(key)
+    if (!PyArg_ParseTuple(args, "s", &key)) {
+      PyGILState_Release(gstate);
+      return NULL;
+    }
+
+    PyGILState_Release(gstate);
+    return ReadPersistentCache(key);
+}
+
+static PyObject *obfuscate_sql(PyObject *self, PyObject *args) {
+    char *query;
+
+    PyGILState_STATE gstate;
+    gstate = PyGILState_Ensure();
+
+    // datadog_agent.obfuscate_sql(query)
+    if (!PyArg_ParseTuple(args, "s", &query)) {
+      PyGILState_Release(gstate);
+      return NULL;
+    }
+
+    PyGILState_Release(gstate);
+    return ObfuscateSQL(query);
+}
+
+static PyObject *scrub(PyObject *self, PyObject *args) {
+    char *text;
+
+    PyGILState_STATE gstate;
+    gstate = PyGILState_Ensure();
+
+    // datadog_agent.scrub(text)
+    if (!PyArg_ParseTuple(args, "s", &text)) {
+      PyGILState_Release(gstate);
+      return NULL;
+    }
+
+    PyGILState_Release(gstate);
+    return Scrub(text);
+}
+
+static PyObject *get_check_stats(PyObject *self, PyObject *args) {
+    char *check_name;
+
+    PyGILState_STATE gstate;
+    gstate = PyGILState_Ensure();
+
+    // datadog_agent.get_check_stats(check_name)
+    if (!PyArg_ParseTuple(args, "s", &check_name)) {
+      PyGILState_Release(gstate);
+      return NULL;
+    }
+
+    PyGILState_Release(gstate);
+    return GetCheckStats(check_name);
+}
+
 static PyObject *get_subprocess_output(PyObject *self, PyObject *args) {
     PyObject *cmd_args, *cmd_raise_on_empty;
     int raise = 1, i=0;
+    double timeout = 0;
     int subprocess_args_sz;
     char ** subprocess_args, * subprocess_arg;
     PyObject *py_result;
@@ -69,7 +236,8 @@ static PyObject *get_subprocess_output(PyObject *self, PyObject *args) {
     PyGILState_STATE gstate = PyGILState_Ensure();
 
     cmd_raise_on_empty = NULL;
-    if (!PyArg_ParseTuple(args, "O|O:get_subprocess_output", &cmd_args, &cmd_raise_on_empty)) {
+    // _util.get_subprocess_output(cmd_args, raise_on_empty=True, timeout=0)
+    if (!PyArg_ParseTuple(args, "O|Od:get_subprocess_output", &cmd_args, &cmd_raise_on_empty, &timeout)) {
         PyGILState_Release(gstate);
         return NULL;
     }
@@ -109,17 +277,90 @@ static PyObject *get_subprocess_output(PyObject *self, PyObject *args) {
     }
 
     PyGILState_Release(gstate);
-    py_result = GetSubprocessOutput(subprocess_args, subprocess_args_sz, raise);
+    py_result = GetSubprocessOutput(subprocess_args, subprocess_args_sz, raise, timeout);
     free(subprocess_args);
 
     return py_result;
 }
 
+// submit_external_tags sends the list of tags of a host to the Go function,
+// it returns -1 with an exception set on error. The GIL must be held.
+static int submit_external_tags(const char *hostname, const char *source_type, PyObject *tag_list) {
+    if (!PyList_Check(tag_list)) {
+        PyErr_SetString(PyExc_TypeError, "the tags must be a list");
+        return -1;
+    }
+
+    // allocate an array of char* to store the tags we'll send to the Go function
+    char **tags;
+    int tags_len = PyList_Size(tag_list);
+    if(!(tags = (char **)malloc(sizeof(char *)*tags_len))) {
+        PyErr_SetString(PyExc_MemoryError, "unable to allocate memory, bailing out");
+        return -1;
+    }
+
+    // copy the list of tags into an array of char*
+    int j, actual_size = 0;
+    for (j=0; j<tags_len; j++) {
+        PyObject *s = PyList_GetItem(tag_list, j);
+        if (s == NULL) {
+            continue;
+        }
+
+        char *tag = PyString_AsString(s);
+        if (tag == NULL) {
+            continue;
+        }
+
+        int len = PyString_Size(s) + 1;
+        tags[actual_size] = (char*)malloc(sizeof(char)*len);
+        if (!tags[actual_size]) {
+            // cleanup
+            int k;
+            for (k=0; k<actual_size; k++) {
+                free(tags[k]);
+            }
+            free(tags);
+            // raise an exception
+            PyErr_SetString(PyExc_MemoryError, "unable to allocate memory, bailing out");
+            return -1;
+        }
+        strncpy(tags[actual_size], tag, len);
+        actual_size++;
+    }
+
+    // finally, invoke the Go function
+    SetExternalTags(hostname, source_type, tags, actual_size);
+
+    // cleanup
+    for (j=0; j<actual_size; j++) {
+        free(tags[j]);
+    }
+    free(tags);
+    return 0;
+}
+
 static PyObject *set_external_tags(PyObject *self, PyObject *args) {
     PyObject *input_list = NULL;
     PyGILState_STATE gstate = PyGILState_Ensure();
 
-    // function expects only one positional arg containing a list
+    // datadog_agent.set_external_tags(hostname, source_type, tags)
+    if (PyTuple_Size(args) == 3) {
+        const char *hostname, *source_type;
+        PyObject *tag_list = NULL;
+        if (!PyArg_ParseTuple(args, "ssO", &hostname, &source_type, &tag_list)) {
+            PyGILState_Release(gstate);
+            return NULL;
+        }
+        if (submit_external_tags(hostname, source_type, tag_list) < 0) {
+            PyGILState_Release(gstate);
+            return NULL;
+        }
+        PyGILState_Release(gstate);
+        Py_RETURN_NONE;
+    }
+
+    // datadog_agent.set_external_tags([('hostname', {'source_type': ['tag1', 'tag2']})])
     if (!PyArg_ParseTuple(args, "O", &input_list)) {
         PyGILState_Release(gstate);
         return NULL;
@@ -169,60 +410,10 @@ static PyObject *set_external_tags(PyObject *self, PyObject *args) {
 
         // key is the source type (e.g. 'vsphere') value is the list of tags
         const char *source_type = PyString_AsString(key);
-        if (!PyList_Check(value)) {
-            PyErr_SetString(PyExc_TypeError, "dict value must be a list of tags ");
+        if (submit_external_tags(hostname, source_type, value) < 0) {
             PyGILState_Release(gstate);
             return NULL;
         }
-
-        // allocate an array of char* to store the tags we'll send to the Go function
-        char **tags;
-        int tags_len = PyList_Size(value);
-        if(!(tags = (char **)malloc(sizeof(char *)*tags_len))) {
-            PyErr_SetString(PyExc_MemoryError, "unable to allocate memory, bailing out");
-            PyGILState_Release(gstate);
-            return NULL;
-        }
-
-        // copy the list of tags into an array of char*
-        int j, actual_size = 0;
-        for (j=0; j<tags_len; j++) {
-            PyObject *s = PyList_GetItem(value, j);
-            if (s == NULL) {
-                continue;
-            }
-
-            char *tag = PyString_AsString(s);
-            if (tag == NULL) {
-                continue;
-            }
-
-            int len = PyString_Size(s) + 1;
-            tags[actual_size] = (char*)malloc(sizeof(char)*len);
-            if (!tags[actual_size]) {
-                // cleanup
-                int k;
-                for (k=0; k<actual_size; k++) {
-                    free(tags[k]);
-                }
-                free(tags);
-                // raise an exception
-                PyErr_SetString(PyExc_MemoryError, "unable to allocate memory, bailing out");
-                PyGILState_Release(gstate);
-                return NULL;
-            }
-            strncpy(tags[actual_size], tag, len);
-            actual_size++;
-        }
-
-        // finally, invoke the Go function
-        SetExternalTags(hostname, source_type, tags, actual_size);
-
-        // cleanup
-        for (j=0; j<actual_size; j++) {
-            free(tags[j]);
-        }
-        free(tags);
     }
 
     PyGILState_Release(gstate);
@@ -234,8 +425,18 @@ static PyMethodDef datadogAgentMethods[] = {
   {"get_config", get_config, METH_VARARGS, "Get value from the agent configuration."},
   {"headers", Headers, METH_VARARGS | METH_KEYWORDS, "Get basic HTTP headers with the right UserAgent."},
   {"get_hostname", GetHostname, METH_VARARGS, "Get the agent hostname."},
+  {"get_clustername", GetClusterName, METH_VARARGS, "Get the name of the cluster of the agent."},
+  {"get_orchestrator_context", GetOrchestratorContext, METH_VARARGS, "Get the cluster name, the orchestrator and the node name of the agent."},
   {"log", log_message, METH_VARARGS, "Log a message through the agent logger."},
   {"set_external_tags", set_external_tags, METH_VARARGS, "Send external host tags."},
+  {"set_check_metadata", set_check_metadata, METH_VARARGS, "Report a metadata value of a check instance."},
+  {"warning", warning, METH_VARARGS, "Submit a warning of a check, shown in the agent status."},
+  {"send_log", send_log, METH_VARARGS, "Send a log line, with its attributes, to the logs-agent."},
+  {"write_persistent_cache", write_persistent_cache, METH_VARARGS, "Store a value, persisted across the agent restarts."},
+  {"read_persistent_cache", read_persistent_cache, METH_VARARGS, "Get a value stored with write_persistent_cache."},
+  {"obfuscate_sql", obfuscate_sql, METH_VARARGS, "Replace the literals of a SQL query by placeholders."},
+  {"scrub", scrub, METH_VARARGS, "Redact the credentials of a text."},
+  {"get_check_stats", get_check_stats, METH_VARARGS, "Get the results of the last run of the instances of a check."},
   {NULL, NULL}
 };
 
@@ -253,7 +454,7 @@ static PyMethodDef utilMethods[] = {
  */
 static PyMethodDef _utilMethods[] = {
   {"get_subprocess_output", (PyCFunction)get_subprocess_output,
-      METH_VARARGS, "Run subprocess and return its output. "
+      METH_VARARGS, "Run subprocess, killed after timeout seconds if positive, and return its output. "
                     "This is a private method and should not be called directly. "
                     "Please use the utils.subprocess_output.get_subprocess_output wrapper."},
   {NULL, NULL}
@@ -272,5 +473,9 @@ void initdatadogagent()
   Py_INCREF(SubprocessOutputEmptyError);
   PyModule_AddObject(_util, "SubprocessOutputEmptyError", SubprocessOutputEmptyError);
 
+  SubprocessOutputTimeoutError = PyErr_NewException("_util.SubprocessOutputTimeoutError", NULL, NULL);
+  Py_INCREF(SubprocessOutputTimeoutError);
+  PyModule_AddObject(_util, "SubprocessOutputTimeoutError", SubprocessOutputTimeoutError);
+
   PyGILState_Release(gstate);
 }
@@ -8,19 +8,27 @@
 package py
 
 import (
+	"bytes"
+	"context"
+	"encoding/json"
 	"fmt"
-	"io/ioutil"
 	"os/exec"
-	"sync"
 	"syscall"
+	"time"
 	"unsafe"
 
 	"github.com/DataDog/datadog-agent/pkg/metadata/externalhost"
+	"github.com/DataDog/datadog-agent/pkg/metadata/inventories"
 
 	log "github.com/cihub/seelog"
 
+	"github.com/DataDog/datadog-agent/pkg/collector/check"
 	"github.com/DataDog/datadog-agent/pkg/config"
+	"github.com/DataDog/datadog-agent/pkg/logs"
 	"github.com/DataDog/datadog-agent/pkg/util"
+	"github.com/DataDog/datadog-agent/pkg/util/obfuscate"
+	"github.com/DataDog/datadog-agent/pkg/util/orchestrator"
+	"github.com/DataDog/datadog-agent/pkg/util/scrubber"
 	"github.com/DataDog/datadog-agent/pkg/version"
 )
 
@@ -60,6 +68,43 @@ func GetHostname(self *C.PyObject, args *C.PyObject) *C.PyObject {
 	return pyStr
 }
 
+// GetClusterName exposes the name of the cluster of the agent, set in
+// `cluster_name`, to Python checks.
+// Used as a PyCFunction of type METH_VARARGS mapped to `datadog_agent.get_clustername`.
+// `self` is the module object.
+//export GetClusterName
+func GetClusterName(self *C.PyObject, args *C.PyObject) *C.PyObject {
+	cStr := C.CString(orchestrator.GetClusterName())
+	pyStr := C.PyString_FromString(cStr)
+	C.free(unsafe.Pointer(cStr))
+	return pyStr
+}
+
+// GetOrchestratorContext exposes the cluster name, the orchestrator and the
+// node name of the agent to Python checks, as a dict. The values are empty
+// strings when they are unknown.
+// Used as a PyCFunction of type METH_VARARGS mapped to `datadog_agent.get_orchestrator_context`.
+// `self` is the module object.
+//export GetOrchestratorContext
+func GetOrchestratorContext(self *C.PyObject, args *C.PyObject) *C.PyObject {
+	ctx, err := orchestrator.GetContext()
+	if err != nil {
+		log.Warnf("Error getting the orchestrator context: %s", err)
+	}
+
+	pyCtx, err := ToPython(map[string]interface{}{
+		"cluster_name": ctx.ClusterName,
+		"orchestrator": ctx.Orchestrator,
+		"node_name":    ctx.NodeName,
+	})
+	if err != nil {
+		log.Errorf("datadog_agent: could not convert the orchestrator context to python types: %s", err)
+		return C._none()
+	}
+	// converting type *python.C.struct__object to *C.struct__object
+	return (*C.PyObject)(unsafe.Pointer(pyCtx.GetCPointer()))
+}
+
 // Headers returns a basic set of HTTP headers that can be used by clients in Python checks.
 // Used as a PyCFunction of type METH_KEYWORDS mapped to `datadog_agent.headers`.
 // `self` is the module object.
@@ -144,10 +189,13 @@ func LogMessage(message *C.char, logLevel C.int) *C.PyObject {
 	return C._none()
 }
 
-// GetSubprocessOutput runs the subprocess and returns the output
+// GetSubprocessOutput runs the subprocess and returns the output, its standard
+// error and its exit code. The subprocess is killed after timeout seconds if
+// timeout is positive. The GIL is released while it runs, so that the other
+// checks aren't blocked.
 // Indirectly used by the C function `get_subprocess_output` that's mapped to `_util.get_subprocess_output`.
 //export GetSubprocessOutput
-func GetSubprocessOutput(argv **C.char, argc, raise int) *C.PyObject {
+func GetSubprocessOutput(argv **C.char, argc, raise int, timeout C.double) *C.PyObject {
 
 	// IMPORTANT: this is (probably) running in a go routine already locked to
 	//            a thread. No need to do it again, and definitely no need to
@@ -161,85 +209,45 @@ func GetSubprocessOutput(argv **C.char, argc, raise int) *C.PyObject {
 	for i := 1; i < length; i++ {
 		subprocessArgs[i-1] = C.GoString(cmdSlice[i])
 	}
-	cmd := exec.Command(subprocessCmd, subprocessArgs...)
 
-	glock := C.PyGILState_Ensure()
-	defer C.PyGILState_Release(glock)
-
-	stdout, err := cmd.StdoutPipe()
-	if err != nil {
-		cErr := C.CString(fmt.Sprintf("internal error creating stdout pipe: %v", err))
-		C.PyErr_SetString(C.PyExc_Exception, cErr)
-		C.free(unsafe.Pointer(cErr))
-		return nil
+	ctx := context.Background()
+	if timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, time.Duration(float64(timeout)*float64(time.Second)))
+		defer cancel()
 	}
+	cmd := exec.CommandContext(ctx, subprocessCmd, subprocessArgs...)
 
-	var wg sync.WaitGroup
-	var output []byte
-	wg.Add(1)
-	go func() {
-		defer wg.Done()
-		output, _ = ioutil.ReadAll(stdout)
-	}()
-
-	stderr, err := cmd.StderrPipe()
-	if err != nil {
-		cErr := C.CString(fmt.Sprintf("internal error creating stderr pipe: %v", err))
-		C.PyErr_SetString(C.PyExc_Exception, cErr)
-		C.free(unsafe.Pointer(cErr))
-		return nil
-	}
-
-	var outputErr []byte
-	wg.Add(1)
-	go func() {
-		defer wg.Done()
-		outputErr, _ = ioutil.ReadAll(stderr)
-	}()
-
-	cmd.Start()
-
-	// Wait for the pipes to be closed *before* waiting for the cmd to exit, as per os.exec docs
-	wg.Wait()
+	var output, outputErr bytes.Buffer
+	cmd.Stdout = &output
+	cmd.Stderr = &outputErr
 
 	retCode := 0
-	err = cmd.Wait()
+	err := cmd.Run()
 	if exiterr, ok := err.(*exec.ExitError); ok {
 		if status, ok := exiterr.Sys().(syscall.WaitStatus); ok {
 			retCode = status.ExitStatus()
 		}
 	}
 
+	glock := C.PyGILState_Ensure()
+	defer C.PyGILState_Release(glock)
+
+	if ctx.Err() == context.DeadlineExceeded {
+		return raiseUtilError("SubprocessOutputTimeoutError", fmt.Sprintf("get_subprocess_output: %s timed out after %gs.", subprocessCmd, float64(timeout)))
+	}
+
 	if raise > 0 {
 		// raise on error
-		if len(output) == 0 {
-			cModuleName := C.CString("_util")
-			utilModule := C.PyImport_ImportModule(cModuleName)
-			C.free(unsafe.Pointer(cModuleName))
-			if utilModule == nil {
-				return nil
-			}
-			defer C.Py_DecRef(utilModule)
-
-			cExcName := C.CString("SubprocessOutputEmptyError")
-			excClass := C.PyObject_GetAttrString(utilModule, cExcName)
-			C.free(unsafe.Pointer(cExcName))
-			if excClass == nil {
-				return nil
-			}
-			defer C.Py_DecRef(excClass)
-
-			cErr := C.CString("get_subprocess_output expected output but had none.")
-			C.PyErr_SetString((*C.PyObject)(unsafe.Pointer(excClass)), cErr)
-			C.free(unsafe.Pointer(cErr))
-			return nil
+		if output.Len() == 0 {
+			return raiseUtilError("SubprocessOutputEmptyError", "get_subprocess_output expected output but had none.")
 		}
 	}
 
-	cOutput := C.CString(string(output[:]))
+	cOutput := C.CString(output.String())
 	pyOutput := C.PyString_FromString(cOutput)
 	C.free(unsafe.Pointer(cOutput))
-	cOutputErr := C.CString(string(outputErr[:]))
+	cOutputErr := C.CString(outputErr.String())
 	pyOutputErr := C.PyString_FromString(cOutputErr)
 	C.free(unsafe.Pointer(cOutputErr))
 	pyRetCode := C.PyInt_FromLong(C.long(retCode))
@@ -252,6 +260,31 @@ func GetSubprocessOutput(argv **C.char, argc, raise int) *C.PyObject {
 	return pyResult
 }
 
+// raiseUtilError raises the exception `excName` of the `_util` module, the
+// GIL must be held
+func raiseUtilError(excName, message string) *C.PyObject {
+	cModuleName := C.CString("_util")
+	utilModule := C.PyImport_ImportModule(cModuleName)
+	C.free(unsafe.Pointer(cModuleName))
+	if utilModule == nil {
+		return nil
+	}
+	defer C.Py_DecRef(utilModule)
+
+	cExcName := C.CString(excName)
+	excClass := C.PyObject_GetAttrString(utilModule, cExcName)
+	C.free(unsafe.Pointer(cExcName))
+	if excClass == nil {
+		return nil
+	}
+	defer C.Py_DecRef(excClass)
+
+	cErr := C.CString(message)
+	C.PyErr_SetString((*C.PyObject)(unsafe.Pointer(excClass)), cErr)
+	C.free(unsafe.Pointer(cErr))
+	return nil
+}
+
 // SetExternalTags adds a set of tags for a given hostnane to the External Host
 // Tags metadata provider cache.
 // Indirectly used by the C function `set_external_tags` that's mapped to `datadog_agent.set_external_tags`.
@@ -272,6 +305,157 @@ func SetExternalTags(hostname, sourceType *C.char, tags **C.char, tagsLen C.int)
 	return C._none()
 }
 
+// SetCheckMetadata stores a metadata value of a check instance, like the
+// version of the software it monitors, sent with the host metadata.
+// Indirectly used by the C function `set_check_metadata` that's mapped to `datadog_agent.set_check_metadata`.
+//export SetCheckMetadata
+func SetCheckMetadata(checkID, name, value *C.char) *C.PyObject {
+	id := check.ID(C.GoString(checkID))
+	inventories.SetCheckMetadata(check.IDToCheckName(id), string(id), C.GoString(name), C.GoString(value))
+	return C._none()
+}
+
+// SubmitWarning stores a warning of a check, to be shown in the agent status
+// with the warnings of its next run.
+// Indirectly used by the C function `warning` that's mapped to `datadog_agent.warning`.
+//export SubmitWarning
+func SubmitWarning(checkID, message *C.char) *C.PyObject {
+	addCheckWarning(check.ID(C.GoString(checkID)), C.GoString(message))
+	return C._none()
+}
+
+// SendLog forwards a log line sent by a check, with its JSON encoded
+// attributes, to the logs-agent. The log is dropped if the logs-agent is not
+// running, a ValueError is raised if the attributes are invalid.
+// Indirectly used by the C function `send_log` that's mapped to `datadog_agent.send_log`.
+//export SendLog
+func SendLog(line, attributes *C.char) *C.PyObject {
+	attrs := make(map[string]interface{})
+	err := json.Unmarshal([]byte(C.GoString(attributes)), &attrs)
+	if err == nil {
+		err = logs.SendIntegrationLog(C.GoString(line), attrs)
+	}
+
+	switch err {
+	case nil:
+	case logs.ErrNotRunning:
+		log.Debugf("Dropping the log sent by a check: %s", err)
+	default:
+		glock := C.PyGILState_Ensure()
+		defer C.PyGILState_Release(glock)
+
+		cErr := C.CString(fmt.Sprintf("invalid log: %v", err))
+		C.PyErr_SetString(C.PyExc_ValueError, cErr)
+		C.free(unsafe.Pointer(cErr))
+		return nil
+	}
+	return C._none()
+}
+
+// WritePersistentCache stores a value in the persistent cache of the checks,
+// kept across the agent restarts.
+// Indirectly used by the C function `write_persistent_cache` that's mapped to `datadog_agent.write_persistent_cache`.
+//export WritePersistentCache
+func WritePersistentCache(key, value *C.char) *C.PyObject {
+	if err := writePersistentCache(C.GoString(key), C.GoString(value)); err != nil {
+		return raisePersistentCacheError(err)
+	}
+	return C._none()
+}
+
+// ReadPersistentCache returns a value of the persistent cache of the checks,
+// an empty string if the key was never written.
+// Indirectly used by the C function `read_persistent_cache` that's mapped to `datadog_agent.read_persistent_cache`.
+//export ReadPersistentCache
+func ReadPersistentCache(key *C.char) *C.PyObject {
+	value, err := readPersistentCache(C.GoString(key))
+	if err != nil {
+		return raisePersistentCacheError(err)
+	}
+
+	glock := C.PyGILState_Ensure()
+	defer C.PyGILState_Release(glock)
+
+	cValue := C.CString(value)
+	pyValue := C.PyString_FromString(cValue)
+	C.free(unsafe.Pointer(cValue))
+	return pyValue
+}
+
+func raisePersistentCacheError(err error) *C.PyObject {
+	glock := C.PyGILState_Ensure()
+	defer C.PyGILState_Release(glock)
+
+	cErr := C.CString(fmt.Sprintf("persistent cache error: %v", err))
+	C.PyErr_SetString(C.PyExc_IOError, cErr)
+	C.free(unsafe.Pointer(cErr))
+	return nil
+}
+
+// ObfuscateSQL returns the query with its literals replaced by `?`, the same
+// way for every check.
+// Indirectly used by the C function `obfuscate_sql` that's mapped to `datadog_agent.obfuscate_sql`.
+//export ObfuscateSQL
+func ObfuscateSQL(query *C.char) *C.PyObject {
+	obfuscated, err := obfuscate.ObfuscateSQL(C.GoString(query))
+
+	glock := C.PyGILState_Ensure()
+	defer C.PyGILState_Release(glock)
+
+	if err != nil {
+		cErr := C.CString(fmt.Sprintf("could not obfuscate the query: %v", err))
+		C.PyErr_SetString(C.PyExc_ValueError, cErr)
+		C.free(unsafe.Pointer(cErr))
+		return nil
+	}
+
+	cObfuscated := C.CString(obfuscated)
+	pyObfuscated := C.PyString_FromString(cObfuscated)
+	C.free(unsafe.Pointer(cObfuscated))
+	return pyObfuscated
+}
+
+// Scrub returns the text with its credentials redacted, like in the flares.
+// Indirectly used by the C function `scrub` that's mapped to `datadog_agent.scrub`.
+//export Scrub
+func Scrub(text *C.char) *C.PyObject {
+	scrubbed := scrubber.ScrubString(C.GoString(text))
+
+	glock := C.PyGILState_Ensure()
+	defer C.PyGILState_Release(glock)
+
+	cScrubbed := C.CString(scrubbed)
+	pyScrubbed := C.PyString_FromString(cScrubbed)
+	C.free(unsafe.Pointer(cScrubbed))
+	return pyScrubbed
+}
+
+// CheckStatsGetter returns the stats of the instances of a check by its name.
+// It's set by the collector, the runner keeping these stats can't be imported
+// from here.
+var CheckStatsGetter func(checkName string) []*check.Stats
+
+// GetCheckStats returns the results of the last run of the instances of a
+// check, a list of dicts, empty if the check isn't running.
+// Indirectly used by the C function `get_check_stats` that's mapped to `datadog_agent.get_check_stats`.
+//export GetCheckStats
+func GetCheckStats(checkName *C.char) *C.PyObject {
+	stats := []interface{}{}
+	if CheckStatsGetter != nil {
+		for _, s := range CheckStatsGetter(C.GoString(checkName)) {
+			stats = append(stats, s.LastRun())
+		}
+	}
+
+	pyStats, err := ToPython(stats)
+	if err != nil {
+		log.Errorf("datadog_agent: could not convert the stats of the check %s to python types: %s", C.GoString(checkName), err)
+		return C._none()
+	}
+	// converting type *python.C.struct__object to *C.struct__object
+	return (*C.PyObject)(unsafe.Pointer(pyStats.GetCPointer()))
+}
+
 func initDatadogAgent() {
 	C.initdatadogagent()
 }
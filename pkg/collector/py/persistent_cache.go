@@ -0,0 +1,76 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2018 Datadog, Inc.
+
+// +build cpython
+
+package py
+
+import (
+	"fmt"
+	"io/ioutil"
+	"net/url"
+	"os"
+	"path/filepath"
+
+	"github.com/DataDog/datadog-agent/pkg/config"
+)
+
+// persistentCacheDir is the directory of the persistent cache, under the run path
+const persistentCacheDir = "checks_cache"
+
+// persistentCachePath returns the path of the file storing the value of a key
+func persistentCachePath(key string) (string, error) {
+	runPath := config.Datadog.GetString("logs_config.run_path")
+	if runPath == "" {
+		return "", fmt.Errorf("no run path is set, the persistent cache is not available")
+	}
+	if key == "" || key == "." || key == ".." {
+		return "", fmt.Errorf("invalid persistent cache key: %q", key)
+	}
+	// escape the path separators so that the key is always a file of the cache
+	return filepath.Join(runPath, persistentCacheDir, url.PathEscape(key)), nil
+}
+
+// writePersistentCache stores the value of a key in the persistent cache,
+// replacing the previous one atomically
+func writePersistentCache(key, value string) error {
+	path, err := persistentCachePath(key)
+	if err != nil {
+		return err
+	}
+	if err = os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+		return err
+	}
+
+	tmp, err := ioutil.TempFile(filepath.Dir(path), ".tmp")
+	if err != nil {
+		return err
+	}
+	_, err = tmp.WriteString(value)
+	if closeErr := tmp.Close(); err == nil {
+		err = closeErr
+	}
+	if err == nil {
+		err = os.Rename(tmp.Name(), path)
+	}
+	if err != nil {
+		os.Remove(tmp.Name())
+	}
+	return err
+}
+
+// readPersistentCache returns the value of a key in the persistent cache,
+// an empty string if the key was never written
+func readPersistentCache(key string) (string, error) {
+	path, err := persistentCachePath(key)
+	if err != nil {
+		return "", err
+	}
+	value, err := ioutil.ReadFile(path)
+	if os.IsNotExist(err) {
+		return "", nil
+	}
+	return string(value), err
+}
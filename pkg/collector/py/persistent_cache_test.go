@@ -0,0 +1,70 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2018 Datadog, Inc.
+
+// +build cpython
+
+package py
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	python "github.com/sbinet/go-python"
+
+	"github.com/DataDog/datadog-agent/pkg/config"
+)
+
+func TestPersistentCache(t *testing.T) {
+	runPath, err := ioutil.TempDir("", "persistent-cache")
+	require.NoError(t, err)
+	defer os.RemoveAll(runPath)
+	previous := config.Datadog.GetString("logs_config.run_path")
+	config.Datadog.Set("logs_config.run_path", runPath)
+	defer config.Datadog.Set("logs_config.run_path", previous)
+
+	value, err := readPersistentCache("my_check:last_event")
+	require.NoError(t, err)
+	assert.Equal(t, "", value)
+
+	require.NoError(t, writePersistentCache("my_check:last_event", "1528364000"))
+	require.NoError(t, writePersistentCache("my_check:last_event", "1528364060"))
+	value, err = readPersistentCache("my_check:last_event")
+	require.NoError(t, err)
+	assert.Equal(t, "1528364060", value)
+
+	// the keys can't escape the cache directory
+	require.NoError(t, writePersistentCache("../escaped", "value"))
+	_, err = os.Stat(filepath.Join(runPath, "escaped"))
+	assert.True(t, os.IsNotExist(err))
+	value, err = readPersistentCache("../escaped")
+	require.NoError(t, err)
+	assert.Equal(t, "value", value)
+	assert.Error(t, writePersistentCache("..", "value"))
+}
+
+func TestPersistentCacheBindings(t *testing.T) {
+	runPath, err := ioutil.TempDir("", "persistent-cache")
+	require.NoError(t, err)
+	defer os.RemoveAll(runPath)
+	previous := config.Datadog.GetString("logs_config.run_path")
+	config.Datadog.Set("logs_config.run_path", runPath)
+	defer config.Datadog.Set("logs_config.run_path", previous)
+
+	gstate := newStickyLock()
+	defer gstate.unlock()
+
+	module := python.PyImport_ImportModule("persistent_cache")
+	require.NotNil(t, module)
+	f := module.GetAttrString("test")
+	require.NotNil(t, f)
+	res := f.Call(python.PyList_New(0), python.PyDict_New())
+	require.NotNil(t, res)
+	assert.Equal(t, "1528364060", python.PyString_AsString(res))
+}
@@ -10,24 +10,49 @@
 // Functions
 PyObject* GetTags(char *id, int highCard);
 
+// tagger.get_tags(entity, cardinality): the cardinality is either "low" or
+// "high", or a boolean set for the high cardinality tags. Without it, the
+// full_cardinality_tagging option of the agent is used.
 static PyObject *get_tags(PyObject *self, PyObject *args) {
     char *entity;
-    int  high_card;
+    PyObject *cardinality = NULL;
+    int  high_card = -1;
 
     PyGILState_STATE gstate;
     gstate = PyGILState_Ensure();
 
-    if (!PyArg_ParseTuple(args, "si", &entity, &high_card)) {
+    if (!PyArg_ParseTuple(args, "s|O", &entity, &cardinality)) {
       PyGILState_Release(gstate);
       return NULL;
     }
 
+    if (cardinality != NULL && cardinality != Py_None) {
+      if (PyString_Check(cardinality)) {
+        char *card = PyString_AsString(cardinality);
+        if (strcmp(card, "high") == 0) {
+          high_card = 1;
+        } else if (strcmp(card, "low") == 0) {
+          high_card = 0;
+        } else {
+          PyErr_SetString(PyExc_ValueError, "cardinality must be \"low\" or \"high\"");
+          PyGILState_Release(gstate);
+          return NULL;
+        }
+      } else {
+        high_card = PyObject_IsTrue(cardinality);
+        if (high_card < 0) {
+          PyGILState_Release(gstate);
+          return NULL;
+        }
+      }
+    }
+
     PyGILState_Release(gstate);
     return GetTags(entity, high_card);
 }
 
 static PyMethodDef taggerMethods[] = {
-  {"get_tags", get_tags, METH_VARARGS, "Get tags for an entity."},
+  {"get_tags", get_tags, METH_VARARGS, "Get tags for an entity, or a container ID."},
   {NULL, NULL}
 };
 
@@ -12,20 +12,30 @@ package py
 // #include "tagger.h"
 import "C"
 import (
+	"regexp"
 	"unsafe"
 
 	"github.com/DataDog/datadog-agent/pkg/tagger"
+	"github.com/DataDog/datadog-agent/pkg/util/docker"
 )
 
+// containerIDPattern matches the container IDs passed without the prefix of
+// the entity name
+var containerIDPattern = regexp.MustCompile("^[0-9a-f]{64}$")
+
 // GetTags queries the agent6 tagger and returns a string array containing
 // tags for the entity. If entity not found, or tagging error, the returned
-// array is empty but valid.
+// array is empty but valid. A container ID can be given instead of the entity
+// name. A negative highCard means the full_cardinality_tagging option is used.
 //export GetTags
 func GetTags(id *C.char, highCard int) *C.PyObject {
 	goID := C.GoString(id)
-	var highCardBool bool
-	if highCard > 0 {
-		highCardBool = true
+	if containerIDPattern.MatchString(goID) {
+		goID = docker.ContainerIDToEntityName(goID)
+	}
+	highCardBool := highCard > 0
+	if highCard < 0 {
+		highCardBool = tagger.IsFullCardinality()
 	}
 
 	tags, _ := tagger.Tag(goID, highCardBool)
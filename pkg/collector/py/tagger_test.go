@@ -60,4 +60,8 @@ func TestGetTags(t *testing.T) {
 	mockSender.AssertMetricTaggedWith(t, "Gauge", "metric.low_card", []string{"test_entity:low"})
 	mockSender.AssertMetricTaggedWith(t, "Gauge", "metric.high_card", []string{"test_entity:low", "test_entity:high", "other_tag:high"})
 	mockSender.AssertMetricTaggedWith(t, "Gauge", "metric.unknown", []string{})
+	containerEntity := "docker://ada5d83e6c2d3dfaaf7dd9ff83e735915da1174dc56880c06a6c99a9a58d5c73"
+	mockSender.AssertMetricTaggedWith(t, "Gauge", "metric.container", []string{containerEntity + ":low", containerEntity + ":high", "other_tag:high"})
+	// full_cardinality_tagging is disabled by default
+	mockSender.AssertMetricTaggedWith(t, "Gauge", "metric.default_card", []string{"test_entity:low"})
 }
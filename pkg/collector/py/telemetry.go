@@ -0,0 +1,156 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2018 Datadog, Inc.
+
+// +build cpython
+
+package py
+
+import (
+	"errors"
+	"expvar"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/sbinet/go-python"
+
+	log "github.com/cihub/seelog"
+
+	"github.com/DataDog/datadog-agent/pkg/aggregator"
+)
+
+const (
+	pyStatsModule         = "utils.py_stats"
+	pyInterpreterStatsFun = "get_interpreter_stats"
+	// interpreterTelemetryInterval is the interval between two collections
+	// of the stats of the interpreter
+	interpreterTelemetryInterval = time.Minute
+	// interpreterMetricPrefix prefixes the names of the metrics of the stats
+	interpreterMetricPrefix = "datadog.agent.python."
+)
+
+// interpreterTelemetry holds the last stats collected by the telemetry loop,
+// exposed in the status without locking the GIL
+var interpreterTelemetry = struct {
+	sync.RWMutex
+	stats map[string]float64
+	stop  chan struct{}
+}{}
+
+func init() {
+	expvar.Publish("pythonInterpreter", expvar.Func(func() interface{} {
+		interpreterTelemetry.RLock()
+		defer interpreterTelemetry.RUnlock()
+		return interpreterTelemetry.stats
+	}))
+}
+
+// GetPythonInterpreterStats collects the stats of the python interpreter: the
+// objects tracked by the garbage collector and their size, the state of the
+// collector, and the number of modules and threads
+func GetPythonInterpreterStats() (map[string]float64, error) {
+	glock := newStickyLock()
+	defer glock.unlock()
+
+	statsModule := python.PyImport_ImportModule(pyStatsModule)
+	if statsModule == nil {
+		python.PyErr_Clear()
+		return nil, fmt.Errorf("Unable to import Python module: %s", pyStatsModule)
+	}
+	defer statsModule.DecRef()
+
+	statter := statsModule.GetAttrString(pyInterpreterStatsFun)
+	if statter == nil {
+		pyErr, err := glock.getPythonError()
+		if err != nil {
+			return nil, fmt.Errorf("An error occurred while grabbing the python interpreter statter: %v", err)
+		}
+		return nil, errors.New(pyErr)
+	}
+	defer statter.DecRef()
+
+	pyStats := statter.CallFunction()
+	if pyStats == nil {
+		pyErr, err := glock.getPythonError()
+		if err != nil {
+			return nil, fmt.Errorf("An error occurred collecting the python interpreter stats: %v", err)
+		}
+		return nil, errors.New(pyErr)
+	}
+	defer pyStats.DecRef()
+
+	keys := python.PyDict_Keys(pyStats)
+	if keys == nil {
+		return nil, fmt.Errorf("the python interpreter stats are not a dict")
+	}
+	defer keys.DecRef()
+
+	stats := make(map[string]float64)
+	for i := 0; i < python.PyList_Size(keys); i++ {
+		key := python.PyList_GetItem(keys, i)        // borrowed ref
+		value := python.PyDict_GetItem(pyStats, key) // borrowed ref
+		stats[python.PyString_AsString(key)] = python.PyFloat_AsDouble(value)
+	}
+	return stats, nil
+}
+
+// StartInterpreterTelemetry collects the stats of the python interpreter
+// every minute, and submits them as `datadog.agent.python.*` gauges
+func StartInterpreterTelemetry() {
+	interpreterTelemetry.Lock()
+	defer interpreterTelemetry.Unlock()
+	if interpreterTelemetry.stop != nil {
+		return
+	}
+	stop := make(chan struct{})
+	interpreterTelemetry.stop = stop
+
+	go func() {
+		ticker := time.NewTicker(interpreterTelemetryInterval)
+		defer ticker.Stop()
+		for {
+			submitInterpreterStats()
+			select {
+			case <-ticker.C:
+			case <-stop:
+				return
+			}
+		}
+	}()
+}
+
+// StopInterpreterTelemetry stops the collection of the stats of the interpreter
+func StopInterpreterTelemetry() {
+	interpreterTelemetry.Lock()
+	defer interpreterTelemetry.Unlock()
+	if interpreterTelemetry.stop != nil {
+		close(interpreterTelemetry.stop)
+		interpreterTelemetry.stop = nil
+	}
+}
+
+// submitInterpreterStats collects the stats of the interpreter, keeps them
+// for the status and submits them
+func submitInterpreterStats() {
+	stats, err := GetPythonInterpreterStats()
+	if err != nil {
+		log.Warnf("Could not collect the stats of the python interpreter: %s", err)
+		return
+	}
+
+	interpreterTelemetry.Lock()
+	interpreterTelemetry.stats = stats
+	interpreterTelemetry.Unlock()
+
+	sender, err := aggregator.GetDefaultSender()
+	if err != nil {
+		log.Debugf("Could not submit the stats of the python interpreter: %s", err)
+		return
+	}
+	for name, value := range stats {
+		sender.Gauge(interpreterMetricPrefix+name, value, "", nil)
+	}
+	sender.Commit()
+}
@@ -0,0 +1,35 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2018 Datadog, Inc.
+
+// +build cpython
+
+package py
+
+import (
+	"encoding/json"
+	"expvar"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGetPythonInterpreterStats(t *testing.T) {
+	stats, err := GetPythonInterpreterStats()
+	require.NoError(t, err)
+	assert.True(t, stats["objects"] > 0)
+	assert.True(t, stats["objects.size"] > 0)
+	assert.True(t, stats["modules"] > 0)
+	assert.Contains(t, stats, "gc.pending.gen0")
+	assert.Contains(t, stats, "gc.garbage")
+}
+
+func TestSubmitInterpreterStats(t *testing.T) {
+	submitInterpreterStats()
+
+	var stats map[string]float64
+	require.NoError(t, json.Unmarshal([]byte(expvar.Get("pythonInterpreter").String()), &stats))
+	assert.True(t, stats["objects"] > 0)
+}
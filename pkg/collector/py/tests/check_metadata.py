@@ -0,0 +1,11 @@
+# Unless explicitly stated otherwise all files in this repository are licensed
+# under the Apache License Version 2.0.
+# This product includes software developed at Datadog (https://www.datadoghq.com/).
+# Copyright 2018 Datadog, Inc.
+
+from checks import AgentCheck
+
+class TestCheck(AgentCheck):
+    def check(self, instance):
+        self.set_metadata("version", "10.5")
+        self.set_metadata("max_connections", 100)
@@ -0,0 +1,12 @@
+import datadog_agent
+
+
+def test():
+    if datadog_agent.get_check_stats("not_running") != []:
+        return "unexpected stats"
+    stats = datadog_agent.get_check_stats("testcheck")
+    if len(stats) != 1:
+        return "unexpected number of instances"
+    return "{} {} {} {}".format(
+        stats[0]["check_id"], stats[0]["total_runs"], stats[0]["metrics"], stats[0]["last_error"],
+    )
@@ -15,3 +15,9 @@ tags = [
 
 def test():
     datadog_agent.set_external_tags(tags)
+
+def test_host():
+    datadog_agent.set_external_tags('test-py-remote', 'test-source-type', ['tag4', 'tag5'])
+
+def test_invalid_tags():
+    datadog_agent.set_external_tags('test-py-remote', 'test-source-type', 'tag4')
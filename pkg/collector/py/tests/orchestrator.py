@@ -0,0 +1,8 @@
+import datadog_agent
+
+
+def test():
+    context = datadog_agent.get_orchestrator_context()
+    return "{} {} {}".format(
+        datadog_agent.get_clustername(), context["cluster_name"], sorted(context.keys()),
+    )
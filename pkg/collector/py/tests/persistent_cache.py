@@ -0,0 +1,7 @@
+import datadog_agent
+
+
+def test():
+    assert datadog_agent.read_persistent_cache("my_check:last_event") == ""
+    datadog_agent.write_persistent_cache("my_check:last_event", "1528364060")
+    return datadog_agent.read_persistent_cache("my_check:last_event")
@@ -0,0 +1,15 @@
+import datadog_agent
+
+
+def test():
+    try:
+        datadog_agent.send_log("jane deleted the bucket", ["not", "a", "dict"])
+        return "no error raised"
+    except TypeError:
+        pass
+    # the logs-agent isn't running, the logs are dropped
+    if datadog_agent.send_log("jane deleted the bucket", {"ddsource": "vendor", "user": "jane"}) is not None:
+        return "unexpected result"
+    if datadog_agent.send_log("jane deleted the bucket") is not None:
+        return "unexpected result"
+    return "ok"
@@ -0,0 +1,16 @@
+# Unless explicitly stated otherwise all files in this repository are licensed
+# under the Apache License Version 2.0.
+# This product includes software developed at Datadog (https://www.datadoghq.com/).
+# Copyright 2018 Datadog, Inc.
+
+import time
+
+from checks import AgentCheck
+
+class TestCheck(AgentCheck):
+    def check(self, instance):
+        self.wait_for_the_cake()
+
+    def wait_for_the_cake(self):
+        for _ in range(10):
+            time.sleep(0.1)
@@ -0,0 +1,2 @@
+# reloaded by TestReloadCheckModule, which changes VALUE before
+VALUE = "initial"
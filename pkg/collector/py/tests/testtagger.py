@@ -17,3 +17,9 @@ class TestCheck(AgentCheck):
 
         notags = get_tags("404", True)
         self.gauge("metric.unknown", 1, tags=notags)
+
+        containertags = get_tags("ada5d83e6c2d3dfaaf7dd9ff83e735915da1174dc56880c06a6c99a9a58d5c73", "high")
+        self.gauge("metric.container", 1, tags=containertags)
+
+        defaulttags = get_tags("test_entity")
+        self.gauge("metric.default_card", 1, tags=defaulttags)
@@ -8,3 +8,8 @@ from checks import AgentCheck
 class TestCheck(AgentCheck):
     def check(self, instance):
         self.warning("The cake is a lie")
+
+class FailingCheck(AgentCheck):
+    def check(self, instance):
+        self.warning("The cake is a lie")
+        raise Exception("no cake")
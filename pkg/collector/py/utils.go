@@ -11,6 +11,7 @@ import (
 	"errors"
 	"fmt"
 	"runtime"
+	"sort"
 	"strings"
 	"sync/atomic"
 
@@ -48,6 +49,8 @@ const (
 	pyMemSummaryFunc      = "get_mem_stats"
 	pyPkgModule           = "utils.py_packages"
 	pyIntegrationListFunc = "get_datadog_wheels"
+	pyProfileModule       = "utils.py_profile"
+	pyProfilerClass       = "CheckProfiler"
 )
 
 // newStickyLock register the current thread with the interpreter and locks
@@ -138,6 +141,62 @@ func (sl *stickyLock) getPythonError() (string, error) {
 	return "", fmt.Errorf("unknown error")
 }
 
+// getThreadStack returns the formatted stack of a python thread, from its ident
+// Notice: the `stickyLock` must be locked.
+func (sl *stickyLock) getThreadStack(threadID int64) (string, error) {
+	if atomic.LoadUint32(&sl.locked) != 1 {
+		return "", fmt.Errorf("the stickyLock is unlocked, can't interact with python interpreter")
+	}
+
+	sys := python.PyImport_ImportModule("sys")
+	if sys == nil {
+		python.PyErr_Clear()
+		return "", fmt.Errorf("can't import the sys module")
+	}
+	defer sys.DecRef()
+
+	frames := sys.CallMethod("_current_frames")
+	if frames == nil {
+		python.PyErr_Clear()
+		return "", fmt.Errorf("can't list the frames of the threads")
+	}
+	defer frames.DecRef()
+
+	key := python.PyInt_FromLong(int(threadID))
+	defer key.DecRef()
+	frame := python.PyDict_GetItem(frames, key) // borrowed ref, no decref needed
+	if frame == nil {
+		return "", fmt.Errorf("thread %d is not running", threadID)
+	}
+
+	traceback := python.PyImport_ImportModule("traceback")
+	if traceback == nil {
+		python.PyErr_Clear()
+		return "", fmt.Errorf("can't import the traceback module")
+	}
+	defer traceback.DecRef()
+
+	formatStackFn := traceback.GetAttrString("format_stack")
+	if formatStackFn == nil {
+		python.PyErr_Clear()
+		return "", fmt.Errorf("can't find traceback.format_stack")
+	}
+	defer formatStackFn.DecRef()
+
+	lines := formatStackFn.CallFunction(frame)
+	if lines == nil {
+		python.PyErr_Clear()
+		return "", fmt.Errorf("can't format the stack of thread %d", threadID)
+	}
+	defer lines.DecRef()
+
+	stack := ""
+	for i := 0; i < python.PyList_Size(lines); i++ {
+		stack += python.PyString_AsString(python.PyList_GetItem(lines, i)) // borrowed ref
+	}
+	return stack, nil
+}
+
 // Search in module for a class deriving from baseClass and return the first match if any.
 // Notice: classes that have been derived will be ignored, i.e. this function only
 // returns leaves of the hierarchy tree.
@@ -403,6 +462,66 @@ func GetPythonInterpreterMemoryUsage() ([]*PythonStats, error) {
 	return myPythonStats, nil
 }
 
+// startProfiler creates a python profiler, and starts it on the current thread
+// Notice: the `stickyLock` must be locked.
+func startProfiler(gstate *stickyLock) (*python.PyObject, error) {
+	profileModule := python.PyImport_ImportModule(pyProfileModule)
+	if profileModule == nil {
+		python.PyErr_Clear()
+		return nil, fmt.Errorf("Unable to import Python module: %s", pyProfileModule)
+	}
+	defer profileModule.DecRef()
+
+	profilerClass := profileModule.GetAttrString(pyProfilerClass)
+	if profilerClass == nil {
+		pyErr, err := gstate.getPythonError()
+		if err != nil {
+			return nil, fmt.Errorf("An error occurred while grabbing the python profiler: %v", err)
+		}
+		return nil, errors.New(pyErr)
+	}
+	defer profilerClass.DecRef()
+
+	profiler := profilerClass.CallFunction()
+	if profiler == nil {
+		pyErr, err := gstate.getPythonError()
+		if err != nil {
+			return nil, fmt.Errorf("An error occurred while creating the python profiler: %v", err)
+		}
+		return nil, errors.New(pyErr)
+	}
+
+	res := profiler.CallMethod("start")
+	if res == nil {
+		profiler.DecRef()
+		pyErr, err := gstate.getPythonError()
+		if err != nil {
+			return nil, fmt.Errorf("An error occurred while starting the python profiler: %v", err)
+		}
+		return nil, errors.New(pyErr)
+	}
+	res.DecRef()
+
+	return profiler, nil
+}
+
+// stopProfiler stops a python profiler started by startProfiler, and returns
+// its report
+// Notice: the `stickyLock` must be locked, on the thread that started it.
+func stopProfiler(gstate *stickyLock, profiler *python.PyObject) (string, error) {
+	report := profiler.CallMethod("stop")
+	if report == nil {
+		pyErr, err := gstate.getPythonError()
+		if err != nil {
+			return "", fmt.Errorf("An error occurred while stopping the python profiler: %v", err)
+		}
+		return "", errors.New(pyErr)
+	}
+	defer report.DecRef()
+
+	return python.PyString_AsString(report), nil
+}
+
 // GetPythonIntegrationList collects python datadog installed integrations list
 func GetPythonIntegrationList() ([]string, error) {
 	glock := newStickyLock()
@@ -452,3 +571,58 @@ func GetPythonIntegrationList() ([]string, error) {
 
 	return ddPythonPackages, nil
 }
+
+// ReloadCheckModule reloads the python modules of a check already imported by
+// the interpreter, its wheel (`datadog_checks.<name>` and its submodules) or
+// its module from `checks.d`, so that the instances loaded next run its
+// current code. On error, like a syntax error, the modules keep their code.
+func ReloadCheckModule(name string) error {
+	glock := newStickyLock()
+	defer glock.unlock()
+
+	modules := python.PyImport_GetModuleDict() // borrowed reference
+	keys := python.PyDict_Keys(modules)
+	if keys == nil {
+		pyErr, err := glock.getPythonError()
+
+		if err != nil {
+			return fmt.Errorf("An error occurred listing the python modules: %v", err)
+		}
+		return errors.New(pyErr)
+	}
+	defer keys.DecRef()
+
+	whlModuleName := fmt.Sprintf("datadog_checks.%s", name)
+	toReload := []string{}
+	for i := 0; i < python.PyList_Size(keys); i++ {
+		moduleName := python.PyString_AsString(python.PyList_GetItem(keys, i))
+		if moduleName == name || moduleName == whlModuleName || strings.HasPrefix(moduleName, whlModuleName+".") {
+			toReload = append(toReload, moduleName)
+		}
+	}
+
+	// reload the submodules before their package, which imports the check
+	// class from them
+	sort.Sort(sort.Reverse(sort.StringSlice(toReload)))
+	for _, moduleName := range toReload {
+		module := python.PyDict_GetItemString(modules, moduleName) // borrowed reference
+		// python 2 keeps `None` entries for the failed relative imports
+		if module == nil || !python.PyModule_Check(module) {
+			continue
+		}
+
+		reloaded := python.PyImport_ReloadModule(module)
+		if reloaded == nil {
+			pyErr, err := glock.getPythonError()
+
+			if err != nil {
+				return fmt.Errorf("An error occurred reloading the python module %s: %v", moduleName, err)
+			}
+			return fmt.Errorf("could not reload the python module %s: %s", moduleName, pyErr)
+		}
+		reloaded.DecRef()
+		log.Debugf("Reloaded the python module %s", moduleName)
+	}
+
+	return nil
+}
@@ -18,3 +18,8 @@ var ErrNotCompiled = errors.New("cpython is not compiled in")
 func GetPythonInterpreterMemoryUsage() ([]*PythonStats, error) {
 	return nil, ErrNotCompiled
 }
+
+// ReloadCheckModule stub when cpython is not compiled in
+func ReloadCheckModule(name string) error {
+	return ErrNotCompiled
+}
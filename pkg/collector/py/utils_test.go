@@ -150,6 +150,44 @@ func TestSubprocessBindings(t *testing.T) {
 	}
 }
 
+func TestSubprocessBindingsTimeout(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("no sleep command on windows")
+	}
+	gstate := newStickyLock()
+	defer gstate.unlock()
+
+	utilModule := python.PyImport_ImportModuleNoBlock("_util")
+	require.NotNil(t, utilModule)
+	defer utilModule.DecRef()
+
+	getSubprocessOutput := utilModule.GetAttrString("get_subprocess_output")
+	require.NotNil(t, getSubprocessOutput)
+	defer getSubprocessOutput.DecRef()
+
+	cmdList := python.PyList_New(0)
+	python.PyList_Append(cmdList, python.PyString_FromString("sleep"))
+	python.PyList_Append(cmdList, python.PyString_FromString("5"))
+	args := python.PyTuple_New(3)
+	defer args.DecRef()
+	kwargs := python.PyDict_New()
+	defer kwargs.DecRef()
+	python.PyTuple_SetItem(args, 0, cmdList)
+	python.PyTuple_SetItem(args, 1, python.PyBool_FromLong(0))
+	python.PyTuple_SetItem(args, 2, python.PyFloat_FromDouble(0.1))
+
+	t0 := time.Now()
+	res := getSubprocessOutput.Call(args, kwargs)
+	assert.Nil(t, res)
+	assert.True(t, time.Since(t0) < 5*time.Second)
+
+	timeoutError := utilModule.GetAttrString("SubprocessOutputTimeoutError")
+	require.NotNil(t, timeoutError)
+	defer timeoutError.DecRef()
+	assert.True(t, python.PyErr_ExceptionMatches(timeoutError))
+	python.PyErr_Clear()
+}
+
 func TestGetModuleName(t *testing.T) {
 	name := getModuleName("foo.bar.baz")
 	if name != "baz" {
@@ -166,3 +204,26 @@ func TestGetModuleName(t *testing.T) {
 		t.Fatalf("Expected empty string, found: %s", name)
 	}
 }
+
+func TestReloadCheckModule(t *testing.T) {
+	// a check which wasn't imported has nothing to reload
+	require.NoError(t, ReloadCheckModule("not_imported"))
+
+	glock := newStickyLock()
+	module := python.PyImport_ImportModule("testreload")
+	require.NotNil(t, module)
+	defer module.DecRef()
+	changed := python.PyString_FromString("changed")
+	module.SetAttrString("VALUE", changed)
+	changed.DecRef()
+	glock.unlock()
+
+	require.NoError(t, ReloadCheckModule("testreload"))
+
+	glock = newStickyLock()
+	defer glock.unlock()
+	value := module.GetAttrString("VALUE")
+	require.NotNil(t, value)
+	defer value.DecRef()
+	assert.Equal(t, "initial", python.PyString_AsString(value))
+}
@@ -0,0 +1,50 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2018 Datadog, Inc.
+
+// +build cpython
+
+package py
+
+import (
+	"errors"
+	"sync"
+
+	"github.com/DataDog/datadog-agent/pkg/collector/check"
+
+	log "github.com/cihub/seelog"
+)
+
+// maxCheckWarnings bounds the number of warnings kept for a check between
+// two calls to its GetWarnings
+const maxCheckWarnings = 100
+
+// checkWarnings holds the warnings submitted by the python checks through
+// `datadog_agent.warning`, by check ID
+var checkWarnings = struct {
+	sync.Mutex
+	byID map[check.ID][]error
+}{byID: make(map[check.ID][]error)}
+
+// addCheckWarning stores a warning of the check until its next GetWarnings
+func addCheckWarning(id check.ID, message string) {
+	checkWarnings.Lock()
+	defer checkWarnings.Unlock()
+
+	if len(checkWarnings.byID[id]) >= maxCheckWarnings {
+		log.Debugf("Dropping warning of check %s, it already has %d pending warnings: %s", id, maxCheckWarnings, message)
+		return
+	}
+	checkWarnings.byID[id] = append(checkWarnings.byID[id], errors.New(message))
+}
+
+// popCheckWarnings returns the warnings stored for the check and forgets them
+func popCheckWarnings(id check.ID) []error {
+	checkWarnings.Lock()
+	defer checkWarnings.Unlock()
+
+	warnings := checkWarnings.byID[id]
+	delete(checkWarnings.byID, id)
+	return warnings
+}
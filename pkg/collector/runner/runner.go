@@ -8,7 +8,7 @@ package runner
 import (
 	"expvar"
 	"fmt"
-
+	"sort"
 	"strconv"
 	"sync"
 	"sync/atomic"
@@ -19,6 +19,7 @@ import (
 	"github.com/DataDog/datadog-agent/pkg/config"
 	"github.com/DataDog/datadog-agent/pkg/metrics"
 	"github.com/DataDog/datadog-agent/pkg/util"
+	"github.com/DataDog/datadog-agent/pkg/util/tracing"
 	log "github.com/cihub/seelog"
 )
 
@@ -29,6 +30,8 @@ const (
 	stopAllChecksTimeout time.Duration = 2 * time.Second
 	// How long is the first series of check runs we want to log
 	firstRunSeries uint64 = 5
+	// Time to wait for the stack of a check that timed out
+	runningStackTimeout time.Duration = time.Second
 )
 
 var (
@@ -62,6 +65,8 @@ type Runner struct {
 	m                sync.Mutex               // To control races on runningChecks
 	running          uint32                   // Flag to see if the Runner is, well, running
 	staticNumWorkers bool                     // Flag indicating if numWorkers is dynamically updated
+	dedicatedChecks  map[string]bool          // The names of the checks run by dedicated workers
+	dedicatedWorkers map[check.ID]chan check.Check
 }
 
 // NewRunner takes the number of desired goroutines processing incoming checks.
@@ -78,6 +83,11 @@ func NewRunner() *Runner {
 		runningChecks:    make(map[check.ID]check.Check),
 		running:          1,
 		staticNumWorkers: numWorkers != 0,
+		dedicatedChecks:  make(map[string]bool),
+		dedicatedWorkers: make(map[check.ID]chan check.Check),
+	}
+	for _, name := range config.Datadog.GetStringSlice("dedicated_check_runners") {
+		r.dedicatedChecks[name] = true
 	}
 
 	if !r.staticNumWorkers {
@@ -151,8 +161,12 @@ func (r *Runner) Stop() {
 	close(r.pending)
 	atomic.StoreUint32(&r.running, 0)
 
-	// stop checks that are still running
+	// stop checks that are still running, and the dedicated workers
 	r.m.Lock()
+	for id, worker := range r.dedicatedWorkers {
+		close(worker)
+		delete(r.dedicatedWorkers, id)
+	}
 	globalDone := make(chan struct{})
 	wg := sync.WaitGroup{}
 	for _, c := range r.runningChecks {
@@ -203,6 +217,12 @@ func (r *Runner) StopCheck(id check.ID) error {
 	r.m.Lock()
 	defer r.m.Unlock()
 
+	// the dedicated worker of the check is started again on its next run
+	if worker, found := r.dedicatedWorkers[id]; found {
+		close(worker)
+		delete(r.dedicatedWorkers, id)
+	}
+
 	if c, isRunning := r.runningChecks[id]; isRunning {
 		log.Debugf("Stopping check %s", c)
 		go func() {
@@ -228,89 +248,255 @@ func (r *Runner) work() {
 	defer runnerStats.Add("Workers", -1)
 
 	for check := range r.pending {
-		// see if the check is already running
-		r.m.Lock()
-		if _, isRunning := r.runningChecks[check.ID()]; isRunning {
-			log.Debugf("Check %s is already running, skip execution...", check)
-			r.m.Unlock()
+		if r.dedicatedChecks[check.String()] {
+			r.dispatchDedicated(check)
 			continue
-		} else {
-			r.runningChecks[check.ID()] = check
-			runnerStats.Add("RunningChecks", 1)
 		}
-		r.m.Unlock()
+		if r.process(check) {
+			return
+		}
+	}
 
-		doLog, lastLog := shouldLog(check.ID())
+	log.Debug("Finished processing checks.")
+}
 
-		if doLog {
-			log.Infof("Running check %s", check)
-		} else {
-			log.Debugf("Running check %s", check)
-		}
+// dispatchDedicated hands the check over to its dedicated worker, started on
+// its first run. The check is skipped if its worker is still busy.
+func (r *Runner) dispatchDedicated(c check.Check) {
+	r.m.Lock()
+	defer r.m.Unlock()
 
-		// run the check
-		var err error
-		t0 := time.Now()
+	// the dedicated workers are stopped with the runner
+	if atomic.LoadUint32(&r.running) == 0 {
+		return
+	}
 
-		err = check.Run()
+	worker, found := r.dedicatedWorkers[c.ID()]
+	if !found {
+		worker = make(chan check.Check)
+		r.dedicatedWorkers[c.ID()] = worker
+		runnerStats.Add("DedicatedWorkers", 1)
+		go r.dedicatedWork(c, worker)
+		return
+	}
 
-		warnings := check.GetWarnings()
+	select {
+	case worker <- c:
+	default:
+		log.Debugf("Check %s is already running on its dedicated worker, skip execution...", c)
+	}
+}
 
-		// use the default sender for the service checks
-		sender, e := aggregator.GetDefaultSender()
-		if e != nil {
-			log.Errorf("Error getting default sender: %v. Not sending status check for %s", e, check)
+// dedicatedWork runs the instances of a check with a dedicated worker, so that
+// long runs don't delay the other checks
+func (r *Runner) dedicatedWork(first check.Check, checks chan check.Check) {
+	log.Debugf("Ready to process check %s on its dedicated worker...", first.ID())
+	defer runnerStats.Add("DedicatedWorkers", -1)
+
+	check := first
+	for {
+		if r.process(check) {
+			r.m.Lock()
+			if r.dedicatedWorkers[check.ID()] == checks {
+				delete(r.dedicatedWorkers, check.ID())
+			}
+			r.m.Unlock()
+			return
+		}
+
+		var ok bool
+		if check, ok = <-checks; !ok {
+			break
 		}
-		serviceCheckTags := []string{fmt.Sprintf("check:%s", check.String())}
-		serviceCheckStatus := metrics.ServiceCheckOK
+	}
+
+	log.Debugf("Finished processing check %s on its dedicated worker.", first.ID())
+}
+
+// process runs a check, unless it's already running, and returns whether it
+// was a one-time check which is done
+func (r *Runner) process(check check.Check) bool {
+	// see if the check is already running
+	r.m.Lock()
+	if _, isRunning := r.runningChecks[check.ID()]; isRunning {
+		log.Debugf("Check %s is already running, skip execution...", check)
+		r.m.Unlock()
+		return false
+	} else {
+		r.runningChecks[check.ID()] = check
+		runnerStats.Add("RunningChecks", 1)
+	}
+	r.m.Unlock()
+
+	doLog, lastLog := shouldLog(check.ID())
 
-		hostname := getHostname()
+	if doLog {
+		log.Infof("Running check %s", check)
+	} else {
+		log.Debugf("Running check %s", check)
+	}
 
-		if len(warnings) != 0 {
-			// len returns int, and this expect int64, so it has to be converted
-			runnerStats.Add("Warnings", int64(len(warnings)))
-			serviceCheckStatus = metrics.ServiceCheckWarning
+	// run the check
+	var err error
+	t0 := time.Now()
+
+	span := tracing.StartSpan("check.run", check.String())
+	span.SetTag("check.id", string(check.ID()))
+	cacheResults := enableResultsCache(check)
+	hungRun, err := runCheck(check)
+	span.Finish(err)
+	if cacheResults {
+		if stale := aggregator.CompleteCheckRun(check.ID(), err == nil); stale > 0 {
+			log.Warnf("Check %s failed, submitted the %d metrics of its last successful run tagged with stale:true", check, stale)
+			runnerStats.Add("StaleResults", int64(stale))
 		}
+	}
 
-		if err != nil {
+	// the warnings of a hung run are collected after its next run
+	var warnings []error
+	if hungRun == nil {
+		warnings = check.GetWarnings()
+	}
+
+	// use the default sender for the service checks
+	sender, e := aggregator.GetDefaultSender()
+	if e != nil {
+		log.Errorf("Error getting default sender: %v. Not sending status check for %s", e, check)
+	}
+	serviceCheckTags := []string{fmt.Sprintf("check:%s", check.String())}
+	serviceCheckStatus := metrics.ServiceCheckOK
+	serviceCheckMessage := ""
+
+	hostname := getHostname()
+
+	if len(warnings) != 0 {
+		// len returns int, and this expect int64, so it has to be converted
+		runnerStats.Add("Warnings", int64(len(warnings)))
+		serviceCheckStatus = metrics.ServiceCheckWarning
+	}
+
+	if err != nil {
+		runnerStats.Add("Errors", 1)
+		serviceCheckStatus = metrics.ServiceCheckCritical
+	}
+	if hungRun != nil {
+		runnerStats.Add("TimedOut", 1)
+		serviceCheckMessage = "the check run timed out, the check is skipped until it returns"
+	}
+
+	if sender != nil {
+		sender.ServiceCheck("datadog.agent.check_status", serviceCheckStatus, hostname, serviceCheckTags, serviceCheckMessage)
+		sender.Commit()
+	}
+
+	// remove the check from the running list, once its run returns: the
+	// check is skipped until then
+	if hungRun != nil {
+		go r.forgetRunningCheck(check, hungRun)
+	} else {
+		r.forgetRunningCheck(check, nil)
+	}
+
+	// publish statistics about this run
+	runnerStats.Add("Runs", 1)
+	mStats, _ := check.GetMetricStats()
+	errorCount := addWorkStats(check, time.Since(t0), err, warnings, mStats)
+
+	// the runs failing with the same error are aggregated in the stats, only
+	// log the first one and then every logging_frequency of them
+	if err != nil {
+		loggingFrequency := uint64(config.Datadog.GetInt64("logging_frequency"))
+		switch {
+		case errorCount <= 1:
 			log.Errorf("Error running check %s: %s", check, err)
-			runnerStats.Add("Errors", 1)
-			serviceCheckStatus = metrics.ServiceCheckCritical
+		case loggingFrequency > 0 && errorCount%loggingFrequency == 0:
+			log.Errorf("Error running check %s, it failed with the same error in the last %d runs: %s", check, errorCount, err)
+		default:
+			log.Debugf("Error running check %s, it failed with the same error in the last %d runs: %s", check, errorCount, err)
 		}
+	}
 
-		if sender != nil {
-			sender.ServiceCheck("datadog.agent.check_status", serviceCheckStatus, hostname, serviceCheckTags, "")
-			sender.Commit()
+	l := "Done running check %s"
+	if doLog {
+		if lastLog {
+			l = l + fmt.Sprintf(", next runs will be logged every %v runs", config.Datadog.GetInt64("logging_frequency"))
 		}
+		log.Infof(l, check)
+	} else {
+		log.Debugf(l, check)
+	}
 
-		// remove the check from the running list
-		r.m.Lock()
-		delete(r.runningChecks, check.ID())
-		r.m.Unlock()
+	if check.Interval() == 0 {
+		log.Infof("Check %v one-time's execution has finished", check)
+		return true
+	}
+	return false
+}
 
-		// publish statistics about this run
-		runnerStats.Add("RunningChecks", -1)
-		runnerStats.Add("Runs", 1)
-		mStats, _ := check.GetMetricStats()
-		addWorkStats(check, time.Since(t0), err, warnings, mStats)
+// runCheck runs the check, and waits for it for up to its run timeout, if it
+// has one. If the run times out, runCheck returns a channel closed when the
+// run eventually returns, and an error with the stack of the run.
+func runCheck(c check.Check) (<-chan struct{}, error) {
+	timeouter, ok := c.(check.RunTimeouter)
+	if !ok || timeouter.RunTimeout() <= 0 {
+		return nil, c.Run()
+	}
 
-		l := "Done running check %s"
-		if doLog {
-			if lastLog {
-				l = l + fmt.Sprintf(", next runs will be logged every %v runs", config.Datadog.GetInt64("logging_frequency"))
-			}
-			log.Infof(l, check)
-		} else {
-			log.Debugf(l, check)
-		}
+	var err error
+	done := make(chan struct{})
+	go func() {
+		err = c.Run()
+		close(done)
+	}()
 
-		if check.Interval() == 0 {
-			log.Infof("Check %v one-time's execution has finished", check)
-			return
+	select {
+	case <-done:
+		return nil, err
+	case <-time.After(timeouter.RunTimeout()):
+	}
+
+	// getting the stack can hang as well, don't wait for it forever
+	stack := make(chan string, 1)
+	go func() {
+		stack <- timeouter.RunningStack()
+	}()
+	select {
+	case s := <-stack:
+		if s != "" {
+			return done, fmt.Errorf("the run timed out after %v, in:\n%s", timeouter.RunTimeout(), s)
 		}
+	case <-time.After(runningStackTimeout):
+		log.Debugf("Could not get the stack of check %s after %v", c, runningStackTimeout)
 	}
+	return done, fmt.Errorf("the run timed out after %v", timeouter.RunTimeout())
+}
 
-	log.Debug("Finished processing checks.")
+// forgetRunningCheck removes the check from the running list, after waiting
+// for its run to return if it hung
+func (r *Runner) forgetRunningCheck(c check.Check, hungRun <-chan struct{}) {
+	if hungRun != nil {
+		<-hungRun
+		log.Infof("The run of check %s that timed out returned, the check is scheduled again", c)
+	}
+
+	r.m.Lock()
+	delete(r.runningChecks, c.ID())
+	r.m.Unlock()
+	runnerStats.Add("RunningChecks", -1)
+}
+
+// enableResultsCache enables the caching of the results of the check, if it
+// is configured to, and returns whether they are cached
+func enableResultsCache(c check.Check) bool {
+	cacher, ok := c.(check.ResultsCacher)
+	if !ok || cacher.ResultsTTL() <= 0 {
+		return false
+	}
+	if err := aggregator.EnableResultsCache(c.ID(), cacher.ResultsTTL()); err != nil {
+		log.Warnf("Could not cache the results of check %s: %s", c, err)
+		return false
+	}
+	return true
 }
 
 func shouldLog(id check.ID) (doLog bool, lastLog bool) {
@@ -334,7 +520,9 @@ func shouldLog(id check.ID) (doLog bool, lastLog bool) {
 	return
 }
 
-func addWorkStats(c check.Check, execTime time.Duration, err error, warnings []error, mStats map[string]int64) {
+// addWorkStats adds a run to the stats of the check, and returns the number of
+// consecutive runs which failed with the same error
+func addWorkStats(c check.Check, execTime time.Duration, err error, warnings []error, mStats map[string]int64) uint64 {
 	var s *check.Stats
 	var found bool
 
@@ -347,6 +535,7 @@ func addWorkStats(c check.Check, execTime time.Duration, err error, warnings []e
 	checkStats.M.Unlock()
 
 	s.Add(execTime, err, warnings, mStats)
+	return s.ErrorCount()
 }
 
 func expCheckStats() interface{} {
@@ -364,6 +553,22 @@ func GetCheckStats() map[check.ID]*check.Stats {
 	return checkStats.Stats
 }
 
+// GetCheckStatsByName returns the stats of the instances of a check, by the
+// name of the check
+func GetCheckStatsByName(name string) []*check.Stats {
+	checkStats.M.RLock()
+	defer checkStats.M.RUnlock()
+
+	stats := []*check.Stats{}
+	for _, s := range checkStats.Stats {
+		if s.CheckName == name {
+			stats = append(stats, s)
+		}
+	}
+	sort.Slice(stats, func(i, j int) bool { return stats[i].CheckID < stats[j].CheckID })
+	return stats
+}
+
 // RemoveCheckStats removes a check from the check stats map
 func RemoveCheckStats(checkID check.ID) {
 	checkStats.M.RLock()
@@ -11,7 +11,9 @@ import (
 	"time"
 
 	"github.com/DataDog/datadog-agent/pkg/collector/check"
+	"github.com/DataDog/datadog-agent/pkg/config"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
 // FIXTURE
@@ -111,6 +113,28 @@ func TestLogging(t *testing.T) {
 	r.Stop()
 }
 
+func TestGetCheckStatsByName(t *testing.T) {
+	c := &TestCheck{}
+	defer RemoveCheckStats(c.ID())
+
+	assert.Empty(t, GetCheckStatsByName(c.String()))
+
+	addWorkStats(c, 5*time.Millisecond, errors.New("A tremendous error occurred."), nil, map[string]int64{"Metrics": 3})
+	addWorkStats(c, 5*time.Millisecond, errors.New("A tremendous error occurred."), nil, map[string]int64{"Metrics": 2})
+
+	stats := GetCheckStatsByName(c.String())
+	require.Len(t, stats, 1)
+	lastRun := stats[0].LastRun()
+	assert.Equal(t, "TestCheck", lastRun["check_id"])
+	assert.Equal(t, int64(2), lastRun["total_runs"])
+	assert.Equal(t, int64(2), lastRun["metrics"])
+	assert.Equal(t, int64(5), lastRun["execution_time"])
+	assert.Equal(t, "A tremendous error occurred.", lastRun["last_error"])
+	assert.Equal(t, int64(2), lastRun["last_error_count"])
+
+	assert.Empty(t, GetCheckStatsByName("OtherCheck"))
+}
+
 type TimingoutCheck struct {
 	TestCheck
 }
@@ -135,3 +159,104 @@ func TestStopCheck(t *testing.T) {
 	err = r.StopCheck(c2.ID())
 	assert.Equal(t, "timeout during stop operation on check id TestCheck", err.Error())
 }
+
+type HangingCheck struct {
+	TestCheck
+	release chan struct{}
+}
+
+func (c *HangingCheck) Run() error {
+	<-c.release
+	return nil
+}
+func (c *HangingCheck) RunTimeout() time.Duration { return 10 * time.Millisecond }
+func (c *HangingCheck) RunningStack() string      { return "File \"hanging.py\", line 1, in run" }
+
+func TestRunCheckTimeout(t *testing.T) {
+	c1 := &TestCheck{}
+	hungRun, err := runCheck(c1)
+	assert.Nil(t, hungRun)
+	assert.Nil(t, err)
+	assert.True(t, c1.hasRun)
+
+	c2 := &HangingCheck{release: make(chan struct{})}
+	hungRun, err = runCheck(c2)
+	assert.NotNil(t, hungRun)
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "timed out after 10ms")
+	assert.Contains(t, err.Error(), "hanging.py")
+
+	close(c2.release)
+	select {
+	case <-hungRun:
+	case <-time.After(time.Second):
+		assert.Fail(t, "the hung run should be done once released")
+	}
+}
+
+func TestWorkTimeout(t *testing.T) {
+	r := NewRunner()
+	c := &HangingCheck{release: make(chan struct{})}
+	r.pending <- c
+	time.Sleep(100 * time.Millisecond)
+
+	// the worker is free, the check is skipped until its run returns
+	r.m.Lock()
+	_, isRunning := r.runningChecks[c.ID()]
+	r.m.Unlock()
+	assert.True(t, isRunning)
+
+	close(c.release)
+	time.Sleep(100 * time.Millisecond)
+	r.m.Lock()
+	_, isRunning = r.runningChecks[c.ID()]
+	r.m.Unlock()
+	assert.False(t, isRunning)
+	r.Stop()
+}
+
+type SlowCheck struct {
+	TestCheck
+	release chan struct{}
+}
+
+func (c *SlowCheck) String() string { return "SlowCheck" }
+func (c *SlowCheck) ID() check.ID   { return check.ID(c.String()) }
+func (c *SlowCheck) Run() error {
+	<-c.release
+	c.hasRun = true
+	return nil
+}
+
+func TestWorkDedicated(t *testing.T) {
+	config.Datadog.Set("dedicated_check_runners", []string{"SlowCheck"})
+	defer config.Datadog.Set("dedicated_check_runners", []string{})
+	config.Datadog.Set("check_runners", 1)
+	defer config.Datadog.Set("check_runners", int64(1))
+
+	r := NewRunner()
+	slow := &SlowCheck{release: make(chan struct{})}
+	fast := &TestCheck{}
+	r.pending <- slow
+	r.pending <- fast
+	time.Sleep(100 * time.Millisecond)
+
+	// the slow check runs on its dedicated worker, without delaying the other one
+	assert.True(t, fast.hasRun)
+	r.m.Lock()
+	assert.Len(t, r.dedicatedWorkers, 1)
+	assert.Contains(t, r.dedicatedWorkers, slow.ID())
+	r.m.Unlock()
+
+	// the check is skipped while its dedicated worker is busy
+	r.pending <- slow
+	close(slow.release)
+	time.Sleep(100 * time.Millisecond)
+	assert.True(t, slow.hasRun)
+
+	require.NoError(t, r.StopCheck(slow.ID()))
+	r.m.Lock()
+	assert.Len(t, r.dedicatedWorkers, 0)
+	r.m.Unlock()
+	r.Stop()
+}
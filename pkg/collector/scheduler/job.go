@@ -16,6 +16,11 @@ import (
 	"github.com/DataDog/datadog-agent/pkg/status/health"
 )
 
+// queueDeadline is how long a queue can be stuck sending checks to the
+// runners before failing the liveness of the agent, checks can legitimately
+// keep the runners busy for a while
+const queueDeadline = 5 * time.Minute
+
 // jobQueue contains a list of checks (called jobs) that need to be
 // scheduled at a certain interval.
 type jobQueue struct {
@@ -36,7 +41,7 @@ func newJobQueue(interval time.Duration) *jobQueue {
 		ticker:   time.NewTicker(time.Duration(interval)),
 		stop:     make(chan bool),
 		stopped:  make(chan bool),
-		health:   health.Register("collector-queue"),
+		health:   health.RegisterWithDeadline("collector-queue", queueDeadline),
 	}
 
 }
@@ -52,11 +52,28 @@ type Listeners struct {
 	Name string `mapstructure:"name"`
 }
 
+// DerivedMetric helps unmarshalling `derived_metrics` config param
+type DerivedMetric struct {
+	Name       string `mapstructure:"name"`
+	Expression string `mapstructure:"expression"`
+}
+
+// FlareScrubbingRule helps unmarshalling `flare_scrubbing_rules` config param
+type FlareScrubbingRule struct {
+	Name        string `mapstructure:"name"`
+	Pattern     string `mapstructure:"pattern"`
+	Replacement string `mapstructure:"replacement"`
+}
+
 // Proxy represents the configuration for proxies in the agent
 type Proxy struct {
 	HTTP    string   `mapstructure:"http"`
 	HTTPS   string   `mapstructure:"https"`
 	NoProxy []string `mapstructure:"no_proxy"`
+	// HTTPAuth and HTTPSAuth are the authentication methods of the proxies:
+	// basic (default), ntlm or negotiate
+	HTTPAuth  string `mapstructure:"http_auth"`
+	HTTPSAuth string `mapstructure:"https_auth"`
 }
 
 func init() {
@@ -78,6 +95,7 @@ func init() {
 	Datadog.SetDefault("skip_ssl_validation", false)
 	Datadog.SetDefault("hostname", "")
 	Datadog.SetDefault("tags", []string{})
+	BindEnvAndSetDefault("cluster_name", "")
 	Datadog.SetDefault("conf_path", ".")
 	Datadog.SetDefault("confd_path", defaultConfdPath)
 	Datadog.SetDefault("confd_dca_path", defaultDCAConfdPath)
@@ -100,7 +118,12 @@ func init() {
 	Datadog.SetDefault("enable_metadata_collection", true)
 	Datadog.SetDefault("enable_gohai", true)
 	Datadog.SetDefault("check_runners", int64(1))
+	Datadog.SetDefault("dedicated_check_runners", []string{})
+	BindEnvAndSetDefault("check_dns_cache.enabled", false)
+	BindEnvAndSetDefault("check_dns_cache.ttl", 60)         // in seconds
+	BindEnvAndSetDefault("check_dns_cache.negative_ttl", 5) // in seconds
 	Datadog.SetDefault("expvar_port", "5000")
+	Datadog.SetDefault("health_port", int64(0))
 	Datadog.SetDefault("auth_token_file_path", "")
 	Datadog.SetDefault("bind_host", "localhost")
 
@@ -122,6 +145,8 @@ func init() {
 
 	// Agent GUI access port
 	Datadog.SetDefault("GUI_port", defaultGuiPort)
+	// Additional redaction rules applied to the flare content
+	Datadog.SetDefault("flare_scrubbing_rules", []FlareScrubbingRule{})
 	if IsContainerized() {
 		Datadog.SetDefault("container_proc_root", "/host/proc")
 		Datadog.SetDefault("procfs_path", "/host/proc")
@@ -140,6 +165,11 @@ func init() {
 	Datadog.SetDefault("proc_root", "/proc")
 	Datadog.SetDefault("histogram_aggregates", []string{"max", "median", "avg", "count"})
 	Datadog.SetDefault("histogram_percentiles", []string{"0.95"})
+	// Aggregator
+	Datadog.SetDefault("derived_metrics", []DerivedMetric{})
+	BindEnvAndSetDefault("metric_timestamp_max_past", 3600)  // in seconds, 0 disables the check
+	BindEnvAndSetDefault("metric_timestamp_max_future", 600) // in seconds, 0 disables the check
+	BindEnvAndSetDefault("metric_timestamp_policy", "drop")  // "drop" or "clamp"
 	// Serializer
 	Datadog.SetDefault("use_v2_api.series", false)
 	Datadog.SetDefault("use_v2_api.events", false)
@@ -147,26 +177,42 @@ func init() {
 	// Forwarder
 	Datadog.SetDefault("forwarder_timeout", 20)
 	Datadog.SetDefault("forwarder_retry_queue_max_size", 30)
+	BindEnvAndSetDefault("forwarder_retry_drain_rate", 0) // in transactions per second, 0 means unlimited
 	BindEnvAndSetDefault("forwarder_num_workers", 1)
+	BindEnvAndSetDefault("forwarder_stop_timeout", 2)
 	// Dogstatsd
 	Datadog.SetDefault("use_dogstatsd", true)
 	Datadog.SetDefault("dogstatsd_port", 8125)          // Notice: 0 means UDP port closed
 	Datadog.SetDefault("dogstatsd_buffer_size", 1024*8) // 8KB buffer
+	BindEnvAndSetDefault("dogstatsd_workers", 0)        // Notice: 0 means the number of cores minus 2, at least 2
 	Datadog.SetDefault("dogstatsd_non_local_traffic", false)
-	Datadog.SetDefault("dogstatsd_socket", "") // Notice: empty means feature disabled
+	BindEnvAndSetDefault("dogstatsd_allowed_sources", []string{}) // Notice: empty means all the sources are allowed
+	Datadog.SetDefault("dogstatsd_socket", "")                    // Notice: empty means feature disabled
+	BindEnvAndSetDefault("dogstatsd_pipe_name", "")               // Notice: empty means feature disabled, Windows only
 	Datadog.SetDefault("dogstatsd_stats_port", 5000)
 	Datadog.SetDefault("dogstatsd_stats_enable", false)
 	Datadog.SetDefault("dogstatsd_stats_buffer", 10)
+	BindEnvAndSetDefault("dogstatsd_telemetry_enabled", false)
 	Datadog.SetDefault("dogstatsd_expiry_seconds", 300)
-	Datadog.SetDefault("dogstatsd_origin_detection", false) // Only supported for socket traffic
+	Datadog.SetDefault("dogstatsd_origin_detection", false) // Only supported for socket and local UDP traffic
+	Datadog.SetDefault("dogstatsd_capture_path", "")        // Notice: empty means under logs_config.run_path
 	Datadog.SetDefault("statsd_forward_host", "")
 	Datadog.SetDefault("statsd_forward_port", 0)
 	BindEnvAndSetDefault("statsd_metric_namespace", "")
+	BindEnvAndSetDefault("dogstatsd_mapper_profiles", []map[string]interface{}{})
+	BindEnvAndSetDefault("dogstatsd_mapper_cache_size", 1000)
+	BindEnvAndSetDefault("dogstatsd_metric_blocklist", []string{})
+	BindEnvAndSetDefault("dogstatsd_tag_cardinality_limit", 0) // Notice: 0 means no limit
 	// Autoconfig
 	Datadog.SetDefault("autoconf_template_dir", "/datadog/check_configs")
 	Datadog.SetDefault("exclude_pause_container", true)
 	Datadog.SetDefault("ac_include", []string{})
 	Datadog.SetDefault("ac_exclude", []string{})
+	BindEnvAndSetDefault("mdns_listener.service_types", []string{})
+	BindEnvAndSetDefault("mdns_listener.domain", "local")
+	BindEnvAndSetDefault("mdns_listener.interface", "")
+	BindEnvAndSetDefault("mdns_listener.browse_interval", 60)
+	BindEnvAndSetDefault("mdns_listener.browse_timeout", 2)
 
 	// Docker
 	BindEnvAndSetDefault("docker_query_timeout", int64(5))
@@ -195,12 +241,23 @@ func init() {
 	Datadog.SetDefault("leader_lease_duration", "60")
 	Datadog.SetDefault("leader_election", false)
 	Datadog.SetDefault("kube_resources_namespace", "")
+	BindEnvAndSetDefault("kubernetes_apiserver_client_qps", 20.0)
+	BindEnvAndSetDefault("kubernetes_apiserver_client_burst", 30)
+	BindEnvAndSetDefault("kubernetes_apiserver_partial_rbac", true)
+	BindEnvAndSetDefault("kubernetes_metadata_mapping_strategy", "auto")
 
 	// Datadog cluster agent
 	Datadog.SetDefault("cluster_agent", false)
 	Datadog.SetDefault("cluster_agent.auth_token", "")
 	Datadog.SetDefault("cluster_agent.url", "")
 	Datadog.SetDefault("cluster_agent.kubernetes_service_name", "dca")
+	BindEnvAndSetDefault("cluster_agent.auth_token_length", 32)            // bytes of entropy of generated tokens
+	BindEnvAndSetDefault("cluster_agent.auth_token_rotation_interval", 0)  // in seconds, 0 disables the rotation
+	BindEnvAndSetDefault("cluster_agent.auth_token_rotation_overlap", 300) // in seconds
+	BindEnvAndSetDefault("cluster_agent.auth_token_file_path", "")
+	BindEnvAndSetDefault("cluster_agent.auth_token_secret_name", "")
+	BindEnvAndSetDefault("cluster_agent.token_review.enabled", false)
+	BindEnvAndSetDefault("cluster_agent.use_service_account_token", false)
 
 	// ECS
 	Datadog.SetDefault("ecs_agent_url", "") // Will be autodetected
@@ -220,6 +277,17 @@ func init() {
 	// Trace agent
 	Datadog.SetDefault("apm_config.enabled", true)
 
+	// Internal tracing of the agent operations
+	BindEnvAndSetDefault("internal_tracing.enabled", false)
+	BindEnvAndSetDefault("internal_tracing.agent_url", "http://localhost:8126")
+	BindEnvAndSetDefault("internal_tracing.sample_rate", 1.0)
+
+	// Memory budget of the components buffering data
+	BindEnvAndSetDefault("memory_budget.limit_mb", 0) // 0 means unbounded
+	BindEnvAndSetDefault("memory_budget.logs_share", 0.3)
+	BindEnvAndSetDefault("memory_budget.aggregator_share", 0.5)
+	BindEnvAndSetDefault("memory_budget.cache_share", 0.2)
+
 	// Logs Agent
 	BindEnvAndSetDefault("logs_enabled", false)
 	BindEnvAndSetDefault("log_enabled", false) // deprecated, use logs_enabled instead
@@ -227,10 +295,26 @@ func init() {
 
 	BindEnvAndSetDefault("logs_config.dd_url", "agent-intake.logs.datadoghq.com")
 	BindEnvAndSetDefault("logs_config.dd_port", 10516)
+	BindEnvAndSetDefault("logs_config.use_http", false)
+	BindEnvAndSetDefault("logs_config.http_dd_url", "agent-http-intake.logs.datadoghq.com")
+	BindEnvAndSetDefault("logs_config.http_dd_port", 443)
+	BindEnvAndSetDefault("logs_config.use_compression", true)
+	BindEnvAndSetDefault("logs_config.batch_wait", 5)
+	BindEnvAndSetDefault("logs_config.use_disk_buffer", false)
+	BindEnvAndSetDefault("logs_config.disk_buffer_path", "") // Notice: empty means in run_path
+	BindEnvAndSetDefault("logs_config.disk_buffer_max_size", 100*1024*1024)
 	BindEnvAndSetDefault("logs_config.dev_mode_use_proto", true)
 	BindEnvAndSetDefault("logs_config.run_path", defaultRunPath)
 	BindEnvAndSetDefault("logs_config.open_files_limit", 100)
+	BindEnvAndSetDefault("logs_config.file_scan_period", 10)
+	BindEnvAndSetDefault("logs_config.auto_multi_line_detection", false)
+	BindEnvAndSetDefault("logs_config.auto_json_detection", false)
+	BindEnvAndSetDefault("cri_socket_path", "") // Notice: empty means the socket of containerd or CRI-O, whichever exists
 	BindEnvAndSetDefault("logs_config.container_collect_all", false)
+	BindEnvAndSetDefault("logs_config.processing_rules", []map[string]interface{}{})
+	BindEnvAndSetDefault("logs_config.scrubbing_patterns", []string{})
+	BindEnvAndSetDefault("logs_config.scrubbing_rules", []map[string]interface{}{})
+	BindEnvAndSetDefault("logs_config.additional_endpoints", []map[string]interface{}{})
 
 	// Tagger full cardinality mode
 	// Undocumented opt-in feature for now
@@ -243,6 +327,7 @@ func init() {
 	Datadog.BindEnv("hostname")
 	Datadog.BindEnv("tags")
 	Datadog.BindEnv("cmd_port")
+	Datadog.BindEnv("health_port")
 	Datadog.BindEnv("conf_path")
 	Datadog.BindEnv("enable_metadata_collection")
 	Datadog.BindEnv("enable_gohai")
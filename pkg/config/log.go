@@ -16,6 +16,7 @@ import (
 	"path/filepath"
 	"strconv"
 	"strings"
+	"sync"
 
 	log "github.com/cihub/seelog"
 )
@@ -25,8 +26,52 @@ const logDateFormat = "2006-01-02 15:04:05 MST" // see time.Format for format sy
 
 var logCertPool *x509.CertPool
 
+// loggerSetup keeps the parameters of the last SetupLogger call, to rebuild
+// the logger when its level is changed at runtime
+var loggerSetup struct {
+	sync.Mutex
+	logFile      string
+	uri          string
+	rfc          bool
+	tls          bool
+	logToConsole bool
+	jsonFormat   bool
+}
+
 // SetupLogger sets up the default logger
 func SetupLogger(logLevel, logFile, uri string, rfc, tls bool, pem string, logToConsole bool, jsonFormat bool) error {
+	loggerSetup.Lock()
+	defer loggerSetup.Unlock()
+	loggerSetup.logFile, loggerSetup.uri = logFile, uri
+	loggerSetup.rfc, loggerSetup.tls = rfc, tls
+	loggerSetup.logToConsole, loggerSetup.jsonFormat = logToConsole, jsonFormat
+
+	return setupLogger(logLevel, logFile, uri, rfc, tls, pem, logToConsole, jsonFormat)
+}
+
+// ChangeLogLevel replaces the default logger by one logging at the given
+// level to the outputs of the last SetupLogger call
+func ChangeLogLevel(logLevel string) error {
+	seelogLogLevel := strings.ToLower(logLevel)
+	if seelogLogLevel == "warning" {
+		seelogLogLevel = "warn"
+	}
+	if _, found := log.LogLevelFromString(seelogLogLevel); !found {
+		return fmt.Errorf("unknown log level: %s", logLevel)
+	}
+
+	loggerSetup.Lock()
+	defer loggerSetup.Unlock()
+	// the certificates of the pem are already in logCertPool
+	err := setupLogger(seelogLogLevel, loggerSetup.logFile, loggerSetup.uri, loggerSetup.rfc, loggerSetup.tls, "", loggerSetup.logToConsole, loggerSetup.jsonFormat)
+	if err != nil {
+		return err
+	}
+	SetRuntime("log_level", seelogLogLevel)
+	return nil
+}
+
+func setupLogger(logLevel, logFile, uri string, rfc, tls bool, pem string, logToConsole bool, jsonFormat bool) error {
 	var syslog bool
 
 	if uri != "" { // non-blank uri enables syslog
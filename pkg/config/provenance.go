@@ -0,0 +1,105 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2018 Datadog, Inc.
+
+package config
+
+import (
+	"fmt"
+	"os"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/spf13/viper"
+)
+
+// The sources a setting value can come from, by increasing precedence
+const (
+	SourceDefault = "default"
+	SourceFile    = "file"
+	SourceEnvVar  = "env_var"
+	SourceRuntime = "runtime"
+)
+
+const scrubbedValue = "********"
+
+var (
+	runtimeOverrides      = make(map[string]bool)
+	runtimeOverridesMutex sync.RWMutex
+
+	// the keys holding credentials, their values are never reported
+	secretKeyRegex   = regexp.MustCompile(`(api_key|app_key|pass(word)?|token|community_string|authkey|privkey)`)
+	uriPasswordRegex = regexp.MustCompile(`\:\/\/([A-Za-z0-9_]+)\:(.+)\@`)
+)
+
+// SettingValue is the current value of a setting and where it comes from
+type SettingValue struct {
+	Key    string `json:"key"`
+	Value  string `json:"value"`
+	Source string `json:"source"`
+}
+
+// SetRuntime changes the value of a setting on the running agent, the
+// setting is then reported as coming from a runtime override
+func SetRuntime(key string, value interface{}) {
+	Datadog.Set(key, value)
+
+	runtimeOverridesMutex.Lock()
+	defer runtimeOverridesMutex.Unlock()
+	runtimeOverrides[strings.ToLower(key)] = true
+}
+
+// Provenance returns the current value of the settings, sorted by key, with
+// the source of each value. The credentials are scrubbed from the values.
+func Provenance() ([]SettingValue, error) {
+	fileConfig := viper.New()
+	if path := Datadog.ConfigFileUsed(); path != "" {
+		// the file is read again, viper doesn't tell which nested keys
+		// were found in the configuration file
+		fileConfig.SetConfigFile(path)
+		if err := fileConfig.ReadInConfig(); err != nil {
+			return nil, fmt.Errorf("unable to read the configuration file %s: %s", path, err)
+		}
+	}
+	return provenanceOf(Datadog, fileConfig), nil
+}
+
+func provenanceOf(config, fileConfig *viper.Viper) []SettingValue {
+	keys := config.AllKeys()
+	sort.Strings(keys)
+
+	runtimeOverridesMutex.RLock()
+	defer runtimeOverridesMutex.RUnlock()
+
+	values := make([]SettingValue, 0, len(keys))
+	for _, key := range keys {
+		source := SourceDefault
+		if runtimeOverrides[key] {
+			source = SourceRuntime
+		} else if _, found := os.LookupEnv(envVarName(key)); found {
+			// only the bound keys are read from the environment, which
+			// are most of them
+			source = SourceEnvVar
+		} else if fileConfig.IsSet(key) {
+			source = SourceFile
+		}
+		values = append(values, SettingValue{
+			Key:    key,
+			Value:  scrubValue(key, fmt.Sprintf("%v", config.Get(key))),
+			Source: source,
+		})
+	}
+	return values
+}
+
+// scrubValue hides the value of the credential settings and the passwords
+// of the URLs
+func scrubValue(key, value string) string {
+	if value != "" && secretKeyRegex.MatchString(strings.ToLower(key)) {
+		return scrubbedValue
+	}
+	return uriPasswordRegex.ReplaceAllString(value, "://$1:"+scrubbedValue+"@")
+}
@@ -0,0 +1,104 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2018 Datadog, Inc.
+
+package config
+
+import (
+	"os"
+	"reflect"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/spf13/viper"
+)
+
+// deprecatedSettings maps the deprecated settings to the ones replacing them
+var deprecatedSettings = map[string]string{
+	"log_enabled": "logs_enabled",
+}
+
+// SettingSchema describes a setting registered by the agent
+type SettingSchema struct {
+	Key        string      `json:"key"`
+	Type       string      `json:"type"`
+	Default    interface{} `json:"default"`
+	EnvVar     string      `json:"env_var"`
+	Deprecated bool        `json:"deprecated"`
+	ReplacedBy string      `json:"replaced_by,omitempty"`
+}
+
+// Schema returns the settings registered on the Datadog config, sorted by key.
+// It must be called before the configuration file is loaded, the settings
+// would otherwise include the keys set in the file. The environment variables
+// are ignored so that the defaults are reported.
+func Schema() []SettingSchema {
+	return schemaOf(Datadog)
+}
+
+func schemaOf(config *viper.Viper) []SettingSchema {
+	keys := config.AllKeys()
+	sort.Strings(keys)
+
+	schema := make([]SettingSchema, 0, len(keys))
+	for _, key := range keys {
+		def := defaultValue(config, key)
+		replacement, deprecated := deprecatedSettings[key]
+		schema = append(schema, SettingSchema{
+			Key:        key,
+			Type:       typeName(def),
+			Default:    def,
+			EnvVar:     envVarName(key),
+			Deprecated: deprecated,
+			ReplacedBy: replacement,
+		})
+	}
+	return schema
+}
+
+// defaultValue returns the value of a key with its environment variable
+// unset, viper doesn't expose the defaults otherwise
+func defaultValue(config *viper.Viper, key string) interface{} {
+	env := envVarName(key)
+	if value, found := os.LookupEnv(env); found {
+		os.Unsetenv(env)
+		defer os.Setenv(env, value)
+	}
+	return config.Get(key)
+}
+
+// envVarName follows the naming of the env bindings: DD_ prefix, upper
+// case and '.' replaced with '_'
+func envVarName(key string) string {
+	return "DD_" + strings.Replace(strings.ToUpper(key), ".", "_", -1)
+}
+
+// typeName returns the type of a setting from its default value, the
+// settings without default are typed "any"
+func typeName(value interface{}) string {
+	if value == nil {
+		return "any"
+	}
+	if _, ok := value.(time.Duration); ok {
+		return "duration"
+	}
+
+	switch reflect.TypeOf(value).Kind() {
+	case reflect.String:
+		return "string"
+	case reflect.Bool:
+		return "bool"
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return "int"
+	case reflect.Float32, reflect.Float64:
+		return "float"
+	case reflect.Slice, reflect.Array:
+		return "list"
+	case reflect.Map:
+		return "map"
+	}
+	return "any"
+}
@@ -0,0 +1,81 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2018 Datadog, Inc.
+
+package config
+
+import (
+	"os"
+	"testing"
+	"time"
+
+	"github.com/spf13/viper"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSchemaTypes(t *testing.T) {
+	conf := viper.New()
+	conf.SetDefault("str", "foo")
+	conf.SetDefault("flag", true)
+	conf.SetDefault("port", 5000)
+	conf.SetDefault("rate", 1.0)
+	conf.SetDefault("timeout", 5*time.Second)
+	conf.SetDefault("tags", []string{})
+	conf.SetDefault("nested.key", "bar")
+	conf.BindEnv("api_key")
+
+	schema := schemaOf(conf)
+	types := make(map[string]string)
+	for _, s := range schema {
+		types[s.Key] = s.Type
+	}
+
+	assert.Equal(t, map[string]string{
+		"api_key":    "any",
+		"flag":       "bool",
+		"nested.key": "string",
+		"port":       "int",
+		"rate":       "float",
+		"str":        "string",
+		"tags":       "list",
+		"timeout":    "duration",
+	}, types)
+
+	// sorted by key
+	assert.Equal(t, "api_key", schema[0].Key)
+	assert.Equal(t, "DD_API_KEY", schema[0].EnvVar)
+}
+
+func TestSchemaIgnoresEnv(t *testing.T) {
+	conf := viper.New()
+	conf.SetEnvPrefix("DD")
+	conf.SetDefault("logs_config.run_path", "/opt/run")
+	conf.BindEnv("logs_config.run_path", "DD_LOGS_CONFIG_RUN_PATH")
+
+	os.Setenv("DD_LOGS_CONFIG_RUN_PATH", "/tmp")
+	defer os.Unsetenv("DD_LOGS_CONFIG_RUN_PATH")
+
+	schema := schemaOf(conf)
+	require.Len(t, schema, 1)
+	assert.Equal(t, "/opt/run", schema[0].Default)
+	assert.Equal(t, "DD_LOGS_CONFIG_RUN_PATH", schema[0].EnvVar)
+	assert.Equal(t, "/tmp", os.Getenv("DD_LOGS_CONFIG_RUN_PATH"))
+}
+
+func TestSchemaDeprecated(t *testing.T) {
+	var found bool
+	for _, s := range Schema() {
+		if s.Key == "log_enabled" {
+			found = true
+			assert.True(t, s.Deprecated)
+			assert.Equal(t, "logs_enabled", s.ReplacedBy)
+		}
+		if s.Key == "logs_enabled" {
+			assert.False(t, s.Deprecated)
+			assert.Equal(t, "bool", s.Type)
+		}
+	}
+	assert.True(t, found)
+}
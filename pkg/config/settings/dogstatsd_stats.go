@@ -0,0 +1,51 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2018 Datadog, Inc.
+
+package settings
+
+import (
+	"strconv"
+)
+
+// statsToggler is implemented by the dogstatsd server
+type statsToggler interface {
+	StatsEnabled() bool
+	SetStatsEnabled(enabled bool) error
+}
+
+// DogstatsdStatsRuntimeSetting enables or disables the packet statistics of
+// the dogstatsd server
+type DogstatsdStatsRuntimeSetting struct {
+	server statsToggler
+}
+
+// NewDogstatsdStatsRuntimeSetting returns the setting for the given dogstatsd server
+func NewDogstatsdStatsRuntimeSetting(server statsToggler) *DogstatsdStatsRuntimeSetting {
+	return &DogstatsdStatsRuntimeSetting{server: server}
+}
+
+// Name returns the name of the setting
+func (s *DogstatsdStatsRuntimeSetting) Name() string {
+	return "dogstatsd_stats"
+}
+
+// Description returns the description of the setting
+func (s *DogstatsdStatsRuntimeSetting) Description() string {
+	return "Enable/disable the statistics of the packets received by dogstatsd, published as the pktsec expvar"
+}
+
+// Get returns whether the statistics are enabled
+func (s *DogstatsdStatsRuntimeSetting) Get() (interface{}, error) {
+	return s.server.StatsEnabled(), nil
+}
+
+// Set enables or disables the statistics
+func (s *DogstatsdStatsRuntimeSetting) Set(value string) error {
+	enabled, err := strconv.ParseBool(value)
+	if err != nil {
+		return err
+	}
+	return s.server.SetStatsEnabled(enabled)
+}
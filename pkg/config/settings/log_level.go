@@ -0,0 +1,39 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2018 Datadog, Inc.
+
+package settings
+
+import (
+	log "github.com/cihub/seelog"
+
+	"github.com/DataDog/datadog-agent/pkg/config"
+)
+
+// LogLevelRuntimeSetting changes the level of the agent logger
+type LogLevelRuntimeSetting struct{}
+
+// Name returns the name of the setting
+func (l LogLevelRuntimeSetting) Name() string {
+	return "log_level"
+}
+
+// Description returns the description of the setting
+func (l LogLevelRuntimeSetting) Description() string {
+	return "Set/get the log level, valid values are: trace, debug, info, warn, error, critical and off"
+}
+
+// Get returns the current log level
+func (l LogLevelRuntimeSetting) Get() (interface{}, error) {
+	return config.Datadog.GetString("log_level"), nil
+}
+
+// Set changes the log level
+func (l LogLevelRuntimeSetting) Set(value string) error {
+	if err := config.ChangeLogLevel(value); err != nil {
+		return err
+	}
+	log.Infof("Log level changed to %s", config.Datadog.GetString("log_level"))
+	return nil
+}
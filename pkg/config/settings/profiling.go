@@ -0,0 +1,91 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2018 Datadog, Inc.
+
+package settings
+
+import (
+	"fmt"
+	"runtime"
+	"strconv"
+	"sync"
+)
+
+// BlockProfileRateRuntimeSetting sets the rate of the block profile served
+// by the pprof endpoints of the expvar server
+type BlockProfileRateRuntimeSetting struct {
+	m    sync.Mutex
+	rate int
+}
+
+// Name returns the name of the setting
+func (s *BlockProfileRateRuntimeSetting) Name() string {
+	return "runtime_block_profile_rate"
+}
+
+// Description returns the description of the setting
+func (s *BlockProfileRateRuntimeSetting) Description() string {
+	return "Set/get the rate of the block profile, in nanoseconds blocked per sample: 0 disables it, 1 samples every blocking event"
+}
+
+// Get returns the current rate
+func (s *BlockProfileRateRuntimeSetting) Get() (interface{}, error) {
+	s.m.Lock()
+	defer s.m.Unlock()
+	return s.rate, nil
+}
+
+// Set changes the rate
+func (s *BlockProfileRateRuntimeSetting) Set(value string) error {
+	rate, err := parseProfileRate(value)
+	if err != nil {
+		return err
+	}
+	s.m.Lock()
+	defer s.m.Unlock()
+	runtime.SetBlockProfileRate(rate)
+	s.rate = rate
+	return nil
+}
+
+// MutexProfileFractionRuntimeSetting sets the fraction of the mutex
+// contention events reported in the mutex profile
+type MutexProfileFractionRuntimeSetting struct{}
+
+// Name returns the name of the setting
+func (s MutexProfileFractionRuntimeSetting) Name() string {
+	return "runtime_mutex_profile_fraction"
+}
+
+// Description returns the description of the setting
+func (s MutexProfileFractionRuntimeSetting) Description() string {
+	return "Set/get the fraction of the mutex contention events in the mutex profile: 0 disables it, n samples 1/n of the events"
+}
+
+// Get returns the current fraction
+func (s MutexProfileFractionRuntimeSetting) Get() (interface{}, error) {
+	// a negative rate reads the current one without changing it
+	return runtime.SetMutexProfileFraction(-1), nil
+}
+
+// Set changes the fraction
+func (s MutexProfileFractionRuntimeSetting) Set(value string) error {
+	fraction, err := parseProfileRate(value)
+	if err != nil {
+		return err
+	}
+	runtime.SetMutexProfileFraction(fraction)
+	return nil
+}
+
+func parseProfileRate(value string) (int, error) {
+	rate, err := strconv.Atoi(value)
+	if err != nil {
+		return 0, err
+	}
+	if rate < 0 {
+		return 0, fmt.Errorf("the rate can't be negative: %d", rate)
+	}
+	return rate, nil
+}
@@ -0,0 +1,102 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2018 Datadog, Inc.
+
+// Package settings implements the settings that can be changed on a running
+// agent, through the `agent config` command.
+package settings
+
+import (
+	"fmt"
+	"sort"
+	"sync"
+)
+
+// RuntimeSetting represents a setting that can be changed at runtime
+type RuntimeSetting interface {
+	Name() string
+	Description() string
+	Get() (interface{}, error)
+	Set(value string) error
+}
+
+var (
+	runtimeSettings      = make(map[string]RuntimeSetting)
+	runtimeSettingsMutex sync.RWMutex
+)
+
+// SettingNotFoundError is returned for a setting that is not registered
+type SettingNotFoundError struct {
+	name string
+}
+
+func (e *SettingNotFoundError) Error() string {
+	return fmt.Sprintf("setting %s not found", e.name)
+}
+
+// RegisterRuntimeSetting makes a setting available to change at runtime
+func RegisterRuntimeSetting(setting RuntimeSetting) error {
+	runtimeSettingsMutex.Lock()
+	defer runtimeSettingsMutex.Unlock()
+
+	if _, found := runtimeSettings[setting.Name()]; found {
+		return fmt.Errorf("duplicated settings detected: %s", setting.Name())
+	}
+	runtimeSettings[setting.Name()] = setting
+	return nil
+}
+
+// RuntimeSettings returns the descriptions of the registered settings, by name
+func RuntimeSettings() map[string]string {
+	runtimeSettingsMutex.RLock()
+	defer runtimeSettingsMutex.RUnlock()
+
+	descriptions := make(map[string]string, len(runtimeSettings))
+	for name, setting := range runtimeSettings {
+		descriptions[name] = setting.Description()
+	}
+	return descriptions
+}
+
+// RuntimeSettingNames returns the sorted names of the registered settings
+func RuntimeSettingNames() []string {
+	runtimeSettingsMutex.RLock()
+	defer runtimeSettingsMutex.RUnlock()
+
+	names := make([]string, 0, len(runtimeSettings))
+	for name := range runtimeSettings {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// GetRuntimeSetting returns the current value of a setting
+func GetRuntimeSetting(name string) (interface{}, error) {
+	setting, err := getSetting(name)
+	if err != nil {
+		return nil, err
+	}
+	return setting.Get()
+}
+
+// SetRuntimeSetting changes the value of a setting
+func SetRuntimeSetting(name string, value string) error {
+	setting, err := getSetting(name)
+	if err != nil {
+		return err
+	}
+	return setting.Set(value)
+}
+
+func getSetting(name string) (RuntimeSetting, error) {
+	runtimeSettingsMutex.RLock()
+	defer runtimeSettingsMutex.RUnlock()
+
+	setting, found := runtimeSettings[name]
+	if !found {
+		return nil, &SettingNotFoundError{name: name}
+	}
+	return setting, nil
+}
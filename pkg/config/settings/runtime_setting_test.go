@@ -0,0 +1,72 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2018 Datadog, Inc.
+
+package settings
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type fakeStatsToggler struct {
+	enabled bool
+}
+
+func (f *fakeStatsToggler) StatsEnabled() bool {
+	return f.enabled
+}
+
+func (f *fakeStatsToggler) SetStatsEnabled(enabled bool) error {
+	f.enabled = enabled
+	return nil
+}
+
+func TestRuntimeSettings(t *testing.T) {
+	toggler := &fakeStatsToggler{}
+	require.NoError(t, RegisterRuntimeSetting(NewDogstatsdStatsRuntimeSetting(toggler)))
+	require.NoError(t, RegisterRuntimeSetting(&BlockProfileRateRuntimeSetting{}))
+	defer func() {
+		runtimeSettingsMutex.Lock()
+		runtimeSettings = make(map[string]RuntimeSetting)
+		runtimeSettingsMutex.Unlock()
+	}()
+
+	err := RegisterRuntimeSetting(NewDogstatsdStatsRuntimeSetting(toggler))
+	assert.Error(t, err, "a setting can't be registered twice")
+
+	assert.Equal(t, []string{"dogstatsd_stats", "runtime_block_profile_rate"}, RuntimeSettingNames())
+	assert.Len(t, RuntimeSettings(), 2)
+
+	require.NoError(t, SetRuntimeSetting("dogstatsd_stats", "true"))
+	assert.True(t, toggler.enabled)
+	value, err := GetRuntimeSetting("dogstatsd_stats")
+	require.NoError(t, err)
+	assert.Equal(t, true, value)
+	assert.Error(t, SetRuntimeSetting("dogstatsd_stats", "maybe"))
+
+	require.NoError(t, SetRuntimeSetting("runtime_block_profile_rate", "0"))
+	value, err = GetRuntimeSetting("runtime_block_profile_rate")
+	require.NoError(t, err)
+	assert.Equal(t, 0, value)
+	assert.Error(t, SetRuntimeSetting("runtime_block_profile_rate", "-1"))
+
+	_, err = GetRuntimeSetting("unknown")
+	assert.IsType(t, &SettingNotFoundError{}, err)
+	err = SetRuntimeSetting("unknown", "1")
+	assert.IsType(t, &SettingNotFoundError{}, err)
+}
+
+func TestMutexProfileFraction(t *testing.T) {
+	setting := MutexProfileFractionRuntimeSetting{}
+	require.NoError(t, setting.Set("5"))
+	defer setting.Set("0")
+
+	value, err := setting.Get()
+	require.NoError(t, err)
+	assert.Equal(t, 5, value)
+	assert.Error(t, setting.Set("five"))
+}
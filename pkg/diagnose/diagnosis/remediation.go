@@ -0,0 +1,35 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2018 Datadog, Inc.
+
+package diagnosis
+
+// remediationError wraps a diagnosis error with a hint telling the user how
+// to fix the issue
+type remediationError struct {
+	err  error
+	hint string
+}
+
+func (e *remediationError) Error() string {
+	return e.err.Error()
+}
+
+// WithRemediation attaches a remediation hint to an error returned by a
+// diagnosis, the hint is printed by the runner when the diagnosis fails.
+// A nil error is returned as is.
+func WithRemediation(err error, hint string) error {
+	if err == nil {
+		return nil
+	}
+	return &remediationError{err: err, hint: hint}
+}
+
+// Remediation returns the remediation hint attached to an error, if any
+func Remediation(err error) string {
+	if e, ok := err.(*remediationError); ok {
+		return e.hint
+	}
+	return ""
+}
@@ -8,6 +8,7 @@ package diagnose
 import (
 	"fmt"
 	"io"
+	"sort"
 
 	"github.com/DataDog/datadog-agent/pkg/diagnose/diagnosis"
 	"github.com/cihub/seelog"
@@ -31,9 +32,16 @@ func RunAll(w io.Writer) (int, error) {
 	seelog.UseLogger(customLogger)
 	defer seelog.ReplaceLogger(oldLogger)
 
-	for name, f := range diagnosis.DefaultCatalog {
+	// Run the diagnosis in a stable order to make the output easier to compare
+	names := make([]string, 0, len(diagnosis.DefaultCatalog))
+	for name := range diagnosis.DefaultCatalog {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
 		fmt.Fprintln(w, fmt.Sprintf("=== Running %s diagnosis ===", color.BlueString(name)))
-		err := f()
+		err := diagnosis.DefaultCatalog[name]()
 		if err != nil {
 			errorCounter++
 		}
@@ -41,7 +49,11 @@ func RunAll(w io.Writer) (int, error) {
 		if err != nil {
 			statusString = color.RedString("FAIL")
 		}
-		fmt.Fprintln(w, fmt.Sprintf("===> %s\n", statusString))
+		fmt.Fprintln(w, fmt.Sprintf("===> %s", statusString))
+		if hint := diagnosis.Remediation(err); hint != "" {
+			fmt.Fprintln(w, fmt.Sprintf("%s %s", color.YellowString("Remediation:"), hint))
+		}
+		fmt.Fprintln(w)
 	}
 
 	return errorCounter, nil
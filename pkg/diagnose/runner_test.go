@@ -8,6 +8,7 @@ package diagnose
 import (
 	"bytes"
 	"errors"
+	"strings"
 	"testing"
 
 	"github.com/DataDog/datadog-agent/pkg/diagnose/diagnosis"
@@ -19,6 +20,9 @@ func TestRunAll(t *testing.T) {
 
 	diagnosis.Register("failing", func() error { return errors.New("fail") })
 	diagnosis.Register("succeeding", func() error { return nil })
+	diagnosis.Register("remediable", func() error {
+		return diagnosis.WithRemediation(errors.New("fail"), "fix it")
+	})
 
 	w := &bytes.Buffer{}
 	RunAll(w)
@@ -26,4 +30,17 @@ func TestRunAll(t *testing.T) {
 	result := w.String()
 	assert.Contains(t, result, "=== Running failing diagnosis ===\n===> FAIL")
 	assert.Contains(t, result, "=== Running succeeding diagnosis ===\n===> PASS")
+	assert.Contains(t, result, "=== Running remediable diagnosis ===\n===> FAIL\nRemediation: fix it\n")
+	assert.NotContains(t, result, "PASS\nRemediation")
+}
+
+func TestRunAllOrder(t *testing.T) {
+	diagnosis.Register("b", func() error { return nil })
+	diagnosis.Register("a", func() error { return nil })
+
+	w := &bytes.Buffer{}
+	RunAll(w)
+
+	result := w.String()
+	assert.True(t, strings.Index(result, "Running a diagnosis") < strings.Index(result, "Running b diagnosis"))
 }
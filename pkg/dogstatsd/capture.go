@@ -0,0 +1,134 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2018 Datadog, Inc.
+
+package dogstatsd
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	log "github.com/cihub/seelog"
+
+	"github.com/DataDog/datadog-agent/pkg/config"
+	"github.com/DataDog/datadog-agent/pkg/dogstatsd/listeners"
+	"github.com/DataDog/datadog-agent/pkg/dogstatsd/replay"
+)
+
+// MaxCaptureDuration is the longest capture of the traffic accepted
+const MaxCaptureDuration = time.Hour
+
+// capture records the packets received by the server in a capture file
+type capture struct {
+	// active is 1 while capturing, read without lock by the workers on each packet
+	active  int32
+	mu      sync.Mutex
+	file    *os.File
+	writer  *replay.Writer
+	packets int
+	timer   *time.Timer
+}
+
+// StartCapture records the packets received during the duration in a new
+// capture file, whose path is returned. Only one capture can run at a time.
+func (s *Server) StartCapture(duration time.Duration) (string, error) {
+	if duration <= 0 || duration > MaxCaptureDuration {
+		return "", fmt.Errorf("the capture duration must be between 0 and %s", MaxCaptureDuration)
+	}
+
+	c := &s.capture
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.file != nil {
+		return "", fmt.Errorf("a capture is already running in %s", c.file.Name())
+	}
+
+	dir := captureDir()
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		return "", fmt.Errorf("could not create the capture directory: %s", err)
+	}
+	path := filepath.Join(dir, fmt.Sprintf("dogstatsd-capture-%s.bin", time.Now().UTC().Format("20060102-150405.000000")))
+	f, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0600)
+	if err != nil {
+		return "", err
+	}
+	w, err := replay.NewWriter(f)
+	if err != nil {
+		f.Close()
+		os.Remove(path)
+		return "", err
+	}
+
+	c.file = f
+	c.writer = w
+	c.packets = 0
+	c.timer = time.AfterFunc(duration, s.stopCapture)
+	atomic.StoreInt32(&c.active, 1)
+	log.Infof("Dogstatsd: capturing the traffic for %s in %s", duration, path)
+	return path, nil
+}
+
+// capturePacket records the packet if a capture is running. It must be
+// called before the packet is parsed.
+func (s *Server) capturePacket(packet *listeners.Packet) {
+	c := &s.capture
+	if atomic.LoadInt32(&c.active) == 0 {
+		return
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.writer == nil {
+		return
+	}
+	err := c.writer.Write(replay.Record{
+		Timestamp: time.Now(),
+		Origin:    packet.Origin,
+		Contents:  packet.Contents,
+	})
+	if err != nil {
+		log.Errorf("Dogstatsd: stopping the capture, could not write to %s: %s", c.file.Name(), err)
+		c.closeLocked()
+		return
+	}
+	c.packets++
+}
+
+// stopCapture ends the running capture, if any
+func (s *Server) stopCapture() {
+	c := &s.capture
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.file == nil {
+		return
+	}
+	log.Infof("Dogstatsd: captured %d packets in %s", c.packets, c.file.Name())
+	c.closeLocked()
+}
+
+func (c *capture) closeLocked() {
+	atomic.StoreInt32(&c.active, 0)
+	c.timer.Stop()
+	if err := c.writer.Flush(); err != nil {
+		log.Errorf("Dogstatsd: could not write the capture file %s: %s", c.file.Name(), err)
+	}
+	c.file.Close()
+	c.file = nil
+	c.writer = nil
+}
+
+// captureDir returns the directory of the capture files
+func captureDir() string {
+	if dir := config.Datadog.GetString("dogstatsd_capture_path"); dir != "" {
+		return dir
+	}
+	if runPath := config.Datadog.GetString("logs_config.run_path"); runPath != "" {
+		return filepath.Join(runPath, "dsd_capture")
+	}
+	return filepath.Join(os.TempDir(), "dsd_capture")
+}
@@ -0,0 +1,89 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2018 Datadog, Inc.
+
+package dogstatsd
+
+import (
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/DataDog/datadog-agent/pkg/config"
+	"github.com/DataDog/datadog-agent/pkg/dogstatsd/listeners"
+	"github.com/DataDog/datadog-agent/pkg/dogstatsd/replay"
+)
+
+func TestCapture(t *testing.T) {
+	dir, err := ioutil.TempDir("", "dsd-capture")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+	config.Datadog.Set("dogstatsd_capture_path", dir)
+	defer config.Datadog.Set("dogstatsd_capture_path", "")
+
+	s := &Server{}
+	_, err = s.StartCapture(0)
+	assert.Error(t, err)
+	_, err = s.StartCapture(2 * MaxCaptureDuration)
+	assert.Error(t, err)
+
+	// packets received outside of a capture are ignored
+	s.capturePacket(&listeners.Packet{Contents: []byte("before:1|c")})
+
+	path, err := s.StartCapture(time.Hour)
+	require.NoError(t, err)
+	assert.Equal(t, dir, filepath.Dir(path))
+	_, err = s.StartCapture(time.Hour)
+	assert.Error(t, err, "a single capture can run at a time")
+
+	s.capturePacket(&listeners.Packet{Contents: []byte("foo:1|c\nbar:2|g")})
+	s.capturePacket(&listeners.Packet{Contents: []byte("baz:1|c"), Origin: "docker://abcdef"})
+	s.stopCapture()
+	s.capturePacket(&listeners.Packet{Contents: []byte("after:1|c")})
+
+	f, err := os.Open(path)
+	require.NoError(t, err)
+	defer f.Close()
+	r, err := replay.NewReader(f)
+	require.NoError(t, err)
+
+	record, err := r.Next()
+	require.NoError(t, err)
+	assert.Equal(t, []byte("foo:1|c\nbar:2|g"), record.Contents)
+	record, err = r.Next()
+	require.NoError(t, err)
+	assert.Equal(t, []byte("baz:1|c"), record.Contents)
+	assert.Equal(t, "docker://abcdef", record.Origin)
+	_, err = r.Next()
+	assert.Equal(t, io.EOF, err)
+}
+
+func TestCaptureStopsAfterDuration(t *testing.T) {
+	dir, err := ioutil.TempDir("", "dsd-capture")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+	config.Datadog.Set("dogstatsd_capture_path", dir)
+	defer config.Datadog.Set("dogstatsd_capture_path", "")
+
+	s := &Server{}
+	_, err = s.StartCapture(10 * time.Millisecond)
+	require.NoError(t, err)
+
+	for i := 0; i < 100; i++ {
+		s.capture.mu.Lock()
+		running := s.capture.file != nil
+		s.capture.mu.Unlock()
+		if !running {
+			return
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	assert.FailNow(t, "the capture should have stopped")
+}
@@ -0,0 +1,118 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2018 Datadog, Inc.
+
+package dogstatsd
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+
+	log "github.com/cihub/seelog"
+)
+
+// othersTagValue replaces the values of a tag above the cardinality limit
+const othersTagValue = "others"
+
+// cardinalityResetInterval is how often the tag values seen are forgotten,
+// giving a chance to the new ones once the old ones are not sent anymore
+const cardinalityResetInterval = time.Hour
+
+// metricFilter drops the metrics whose name matches a pattern of the
+// blocklist and limits the number of values of each tag of a metric, the
+// values above the limit are replaced by "others"
+type metricFilter struct {
+	blocklist *regexp.Regexp
+	limit     int
+
+	mu sync.Mutex
+	// values holds the values seen of the tags of the metrics, by metric
+	// name and tag key
+	values map[string]map[string]map[string]struct{}
+	// limited holds the metric name and tag key pairs above the limit
+	limited map[string]struct{}
+	resetAt time.Time
+}
+
+// newMetricFilter returns a filter of the metrics matching the patterns of
+// blocklist, where "*" matches any characters, with up to limit values per
+// tag, 0 meaning no limit. It returns nil when there is nothing to filter.
+func newMetricFilter(blocklist []string, limit int) (*metricFilter, error) {
+	if len(blocklist) == 0 && limit <= 0 {
+		return nil, nil
+	}
+	filter := &metricFilter{limit: limit}
+	if len(blocklist) > 0 {
+		patterns := make([]string, 0, len(blocklist))
+		for _, pattern := range blocklist {
+			patterns = append(patterns, strings.Replace(regexp.QuoteMeta(pattern), `\*`, `.*`, -1))
+		}
+		regex, err := regexp.Compile("^(?:" + strings.Join(patterns, "|") + ")$")
+		if err != nil {
+			return nil, fmt.Errorf("invalid metric blocklist: %s", err)
+		}
+		filter.blocklist = regex
+	}
+	filter.reset(time.Now())
+	return filter, nil
+}
+
+// blocked returns whether the metric must be dropped
+func (f *metricFilter) blocked(name string) bool {
+	return f.blocklist != nil && f.blocklist.MatchString(name)
+}
+
+// limitTags replaces the values of the tags of a metric above the limit
+func (f *metricFilter) limitTags(name string, tags []string) {
+	if f.limit <= 0 || len(tags) == 0 {
+		return
+	}
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if now := time.Now(); now.After(f.resetAt) {
+		f.reset(now)
+	}
+	keys, found := f.values[name]
+	if !found {
+		keys = make(map[string]map[string]struct{})
+		f.values[name] = keys
+	}
+	for i, tag := range tags {
+		sep := strings.IndexByte(tag, ':')
+		if sep < 0 {
+			continue
+		}
+		key, value := tag[:sep], tag[sep+1:]
+		values, found := keys[key]
+		if !found {
+			values = make(map[string]struct{})
+			keys[key] = values
+		}
+		if _, seen := values[value]; seen {
+			continue
+		}
+		if len(values) < f.limit {
+			values[value] = struct{}{}
+			continue
+		}
+		tags[i] = key + ":" + othersTagValue
+		dogstatsdExpvar.Add("TagValuesLimited", 1)
+		if _, warned := f.limited[name+"|"+key]; !warned {
+			f.limited[name+"|"+key] = struct{}{}
+			log.Warnf("Dogstatsd: the tag %s of the metric %s has more than %d values, the next ones are replaced by %q", key, name, f.limit, othersTagValue)
+		}
+	}
+}
+
+// reset forgets the tag values seen, it must be called with the lock held
+// once the filter is shared
+func (f *metricFilter) reset(now time.Time) {
+	f.values = make(map[string]map[string]map[string]struct{})
+	f.limited = make(map[string]struct{})
+	f.resetAt = now.Add(cardinalityResetInterval)
+}
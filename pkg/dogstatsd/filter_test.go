@@ -0,0 +1,58 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2018 Datadog, Inc.
+
+package dogstatsd
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNoMetricFilter(t *testing.T) {
+	filter, err := newMetricFilter(nil, 0)
+	assert.NoError(t, err)
+	assert.Nil(t, filter)
+}
+
+func TestMetricBlocklist(t *testing.T) {
+	filter, err := newMetricFilter([]string{"myapp.debug.*", "other.metric"}, 0)
+	require.NoError(t, err)
+
+	assert.True(t, filter.blocked("myapp.debug.requests"))
+	assert.True(t, filter.blocked("other.metric"))
+	assert.False(t, filter.blocked("myapp.requests"))
+	assert.False(t, filter.blocked("other.metric.count"))
+	// the dots are not wildcards
+	assert.False(t, filter.blocked("otherXmetric"))
+}
+
+func TestTagCardinalityLimit(t *testing.T) {
+	filter, err := newMetricFilter(nil, 2)
+	require.NoError(t, err)
+
+	for _, user := range []string{"a", "b", "a"} {
+		tags := []string{"user:" + user, "env:prod", "bare"}
+		filter.limitTags("requests", tags)
+		assert.Equal(t, []string{"user:" + user, "env:prod", "bare"}, tags)
+	}
+
+	tags := []string{"user:c", "env:prod", "bare"}
+	filter.limitTags("requests", tags)
+	assert.Equal(t, []string{"user:others", "env:prod", "bare"}, tags)
+
+	// the limit is per metric
+	tags = []string{"user:c"}
+	filter.limitTags("errors", tags)
+	assert.Equal(t, []string{"user:c"}, tags)
+
+	// the values are eventually forgotten
+	filter.resetAt = time.Now().Add(-time.Second)
+	tags = []string{"user:c"}
+	filter.limitTags("requests", tags)
+	assert.Equal(t, []string{"user:c"}, tags)
+}
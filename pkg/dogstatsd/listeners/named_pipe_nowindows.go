@@ -0,0 +1,26 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2018 Datadog, Inc.
+
+// +build !windows
+
+package listeners
+
+import (
+	"fmt"
+)
+
+// NamedPipeListener is only implemented on Windows hosts
+type NamedPipeListener struct{}
+
+// NewNamedPipeListener returns a "not implemented" error on non-windows hosts
+func NewNamedPipeListener(packetOut chan Packets, packetPool *PacketPool) (*NamedPipeListener, error) {
+	return nil, fmt.Errorf("named pipes are only implemented on Windows hosts")
+}
+
+// Listen does nothing on non-windows hosts
+func (l *NamedPipeListener) Listen() {}
+
+// Stop does nothing on non-windows hosts
+func (l *NamedPipeListener) Stop() {}
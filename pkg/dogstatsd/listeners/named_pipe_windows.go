@@ -0,0 +1,122 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2018 Datadog, Inc.
+
+package listeners
+
+import (
+	"fmt"
+	"io"
+	"net"
+	"sync"
+
+	"github.com/Microsoft/go-winio"
+	log "github.com/cihub/seelog"
+
+	"github.com/DataDog/datadog-agent/pkg/config"
+)
+
+var (
+	namedPipeExpvar = newTransportStats("named-pipe")
+)
+
+// namedPipeSecurityDescriptor lets all the local processes and containers
+// write to the pipe, like the socket of the UDSListener
+const namedPipeSecurityDescriptor = "D:AI(A;;GA;;;WD)"
+
+// NamedPipeListener implements the StatsdListener interface for Windows
+// named pipes. The clients connect to the pipe and write each of their
+// packets as a message, which are read from a goroutine per client.
+// Origin detection is not implemented for named pipes.
+type NamedPipeListener struct {
+	pipe        net.Listener
+	packetOut   chan Packets
+	packetPool  *PacketPool
+	connections map[net.Conn]struct{}
+	mu          sync.Mutex
+}
+
+// NewNamedPipeListener returns an idle named pipe Statsd listener
+func NewNamedPipeListener(packetOut chan Packets, packetPool *PacketPool) (*NamedPipeListener, error) {
+	path := `\\.\pipe\` + config.Datadog.GetString("dogstatsd_pipe_name")
+	bufferSize := int32(config.Datadog.GetInt("dogstatsd_buffer_size"))
+	pipe, err := winio.ListenPipe(path, &winio.PipeConfig{
+		SecurityDescriptor: namedPipeSecurityDescriptor,
+		MessageMode:        true,
+		InputBufferSize:    bufferSize,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("can't listen: %s", err)
+	}
+
+	listener := &NamedPipeListener{
+		pipe:        pipe,
+		packetOut:   packetOut,
+		packetPool:  packetPool,
+		connections: make(map[net.Conn]struct{}),
+	}
+	log.Debugf("dogstatsd-named-pipe: %s successfully initialized", path)
+	return listener, nil
+}
+
+// Listen runs the intake loop. Should be called in its own goroutine
+func (l *NamedPipeListener) Listen() {
+	log.Infof("dogstatsd-named-pipe: starting to listen on %s", l.pipe.Addr())
+	for {
+		conn, err := l.pipe.Accept()
+		if err == winio.ErrPipeListenerClosed {
+			return
+		}
+		if err != nil {
+			log.Errorf("dogstatsd-named-pipe: error accepting client: %v", err)
+			namedPipeExpvar.Add("ConnectionErrors", 1)
+			continue
+		}
+		l.mu.Lock()
+		l.connections[conn] = struct{}{}
+		l.mu.Unlock()
+		go l.listenConnection(conn)
+	}
+}
+
+// listenConnection reads the packets of a client until it disconnects
+func (l *NamedPipeListener) listenConnection(conn net.Conn) {
+	defer func() {
+		l.mu.Lock()
+		delete(l.connections, conn)
+		l.mu.Unlock()
+		conn.Close()
+	}()
+	for {
+		packet := l.packetPool.Get()
+		n, err := conn.Read(packet.buffer)
+		if err != nil {
+			// Return the packet back to the pool for reuse
+			l.packetPool.Put(packet)
+
+			// the client disconnected or the pipe has been closed
+			if err == io.EOF || err == winio.ErrFileClosed {
+				return
+			}
+
+			log.Errorf("dogstatsd-named-pipe: error reading packet: %v", err)
+			namedPipeExpvar.Add("PacketReadingErrors", 1)
+			return
+		}
+
+		packet.Contents = packet.buffer[:n]
+		countPacket(packet, namedPipeExpvar)
+		sendPackets(l.packetOut, Packets{packet}, namedPipeExpvar)
+	}
+}
+
+// Stop closes the named pipe and the connections of its clients
+func (l *NamedPipeListener) Stop() {
+	l.pipe.Close()
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	for conn := range l.connections {
+		conn.Close()
+	}
+}
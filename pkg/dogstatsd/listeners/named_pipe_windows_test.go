@@ -0,0 +1,49 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2018 Datadog, Inc.
+
+package listeners
+
+import (
+	"testing"
+	"time"
+
+	"github.com/Microsoft/go-winio"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/DataDog/datadog-agent/pkg/config"
+)
+
+func TestNamedPipeReceive(t *testing.T) {
+	var contents = []byte("daemon:666|g|#sometag1:somevalue1,sometag2:somevalue2")
+
+	config.Datadog.Set("dogstatsd_pipe_name", "dsd-test")
+	defer config.Datadog.Set("dogstatsd_pipe_name", "")
+
+	packetPool := NewPacketPool(config.Datadog.GetInt("dogstatsd_buffer_size"))
+	packetChannel := make(chan Packets)
+	s, err := NewNamedPipeListener(packetChannel, packetPool)
+	require.NoError(t, err)
+
+	go s.Listen()
+	defer s.Stop()
+	conn, err := winio.DialPipe(`\\.\pipe\dsd-test`, nil)
+	require.NoError(t, err)
+	defer conn.Close()
+
+	// each write is a message, read as a packet
+	for i := 0; i < 2; i++ {
+		conn.Write(contents)
+		select {
+		case packets := <-packetChannel:
+			require.Len(t, packets, 1)
+			assert.Equal(t, contents, packets[0].Contents)
+			assert.Equal(t, "", packets[0].Origin)
+			packetPool.Put(packets[0])
+		case <-time.After(2 * time.Second):
+			assert.FailNow(t, "Timeout on receive channel")
+		}
+	}
+}
@@ -0,0 +1,66 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2018 Datadog, Inc.
+
+package listeners
+
+import (
+	"expvar"
+	"sync"
+)
+
+var (
+	transportStatsMutex sync.Mutex
+	// transportStats are the stats of the listeners by transport
+	transportStats = make(map[string]*expvar.Map)
+)
+
+// newTransportStats publishes the stats of the listeners of a transport as
+// the dogstatsd-<transport> expvar
+func newTransportStats(transport string) *expvar.Map {
+	transportStatsMutex.Lock()
+	defer transportStatsMutex.Unlock()
+	stats := expvar.NewMap("dogstatsd-" + transport)
+	transportStats[transport] = stats
+	return stats
+}
+
+// TransportStats returns the counters of the listeners by transport: the
+// packets and bytes received, the messages which could not be parsed, the
+// times the queue of the workers was full, and the errors
+func TransportStats() map[string]map[string]int64 {
+	transportStatsMutex.Lock()
+	defer transportStatsMutex.Unlock()
+	all := make(map[string]map[string]int64, len(transportStats))
+	for transport, stats := range transportStats {
+		counters := make(map[string]int64)
+		stats.Do(func(kv expvar.KeyValue) {
+			if counter, ok := kv.Value.(*expvar.Int); ok {
+				counters[kv.Key] = counter.Value()
+			}
+		})
+		all[transport] = counters
+	}
+	return all
+}
+
+// countPacket counts a packet read by a listener in its stats, which the
+// parse errors of the packet are counted in as well
+func countPacket(packet *Packet, stats *expvar.Map) {
+	packet.stats = stats
+	stats.Add("Packets", 1)
+	stats.Add("Bytes", int64(len(packet.Contents)))
+}
+
+// sendPackets sends the packets read by a listener to the workers. When
+// their queue is full the listener waits for them, this is counted in the
+// QueueFull stat as the kernel can drop the datagrams received meanwhile.
+func sendPackets(packetOut chan Packets, packets Packets, stats *expvar.Map) {
+	select {
+	case packetOut <- packets:
+	default:
+		stats.Add("QueueFull", 1)
+		packetOut <- packets
+	}
+}
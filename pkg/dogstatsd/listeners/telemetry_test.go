@@ -0,0 +1,36 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2018 Datadog, Inc.
+
+package listeners
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTransportStats(t *testing.T) {
+	before := TransportStats()["udp"]
+
+	packet := &Packet{Contents: []byte("daemon:666|g")}
+	countPacket(packet, udpExpvar)
+	packet.CountParseError()
+
+	// the queue of the workers is full at the second send
+	packetOut := make(chan Packets, 1)
+	sendPackets(packetOut, Packets{packet}, udpExpvar)
+	go func() { <-packetOut }()
+	sendPackets(packetOut, Packets{packet}, udpExpvar)
+
+	after := TransportStats()["udp"]
+	assert.Equal(t, int64(1), after["Packets"]-before["Packets"])
+	assert.Equal(t, int64(12), after["Bytes"]-before["Bytes"])
+	assert.Equal(t, int64(1), after["ParseErrors"]-before["ParseErrors"])
+	assert.Equal(t, int64(1), after["QueueFull"]-before["QueueFull"])
+	assert.Contains(t, TransportStats(), "uds")
+
+	// the packets not read by a listener are not counted
+	(&Packet{}).CountParseError()
+}
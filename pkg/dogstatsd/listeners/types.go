@@ -5,6 +5,8 @@
 
 package listeners
 
+import "expvar"
+
 // Packet represents a statsd packet ready to process,
 // with its origin metadata if applicable.
 //
@@ -12,11 +14,24 @@ package listeners
 // underlying buffer reference to avoid re-sizing the slice
 // before reading
 type Packet struct {
-	Contents []byte // Contents, might contain several messages
-	buffer   []byte // Underlying buffer for data read
-	Origin   string // Origin container if identified
+	Contents []byte      // Contents, might contain several messages
+	buffer   []byte      // Underlying buffer for data read
+	Origin   string      // Origin container if identified
+	stats    *expvar.Map // Stats of the listener which read the packet
+}
+
+// CountParseError counts a message of the packet which could not be parsed
+// in the ParseErrors stat of the listener which read it
+func (p *Packet) CountParseError() {
+	if p.stats != nil {
+		p.stats.Add("ParseErrors", 1)
+	}
 }
 
+// Packets is a batch of packets read together, the listeners send them by
+// batch not to pay the cost of a channel operation for every packet
+type Packets []*Packet
+
 // StatsdListener opens a communication channel to get statsd packets in.
 type StatsdListener interface {
 	Listen()
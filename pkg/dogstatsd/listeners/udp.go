@@ -6,7 +6,6 @@
 package listeners
 
 import (
-	"expvar"
 	"fmt"
 	"net"
 	"strings"
@@ -14,35 +13,61 @@ import (
 	log "github.com/cihub/seelog"
 
 	"github.com/DataDog/datadog-agent/pkg/config"
+	"github.com/DataDog/datadog-agent/pkg/util/allowlist"
 )
 
 var (
-	udpExpvar = expvar.NewMap("dogstatsd-udp")
+	udpExpvar = newTransportStats("udp")
 )
 
+// udpBatchSize is the maximum number of packets read at once
+const udpBatchSize = 32
+
+// packetReader reads datagrams in the buffers of packets, setting their
+// contents, and their senders in addrs. It returns the number of packets
+// read, at least one.
+type packetReader interface {
+	ReadPackets(packets []*Packet, addrs []net.Addr) (int, error)
+}
+
 // UDPListener implements the StatsdListener interface for UDP protocol.
 // It listens to a given UDP address and sends back packets ready to be
 // processed.
-// Origin detection is not implemented for UDP.
+// When origin detection is enabled and the non local traffic is not, the
+// container of the process which sent a packet is its origin.
+// The packets are read and sent by batch, with a single system call on
+// Linux, the datagrams dropped by the kernel when the buffer of the socket
+// is full are counted in the PacketsDropped stat.
 type UDPListener struct {
-	conn       net.PacketConn
-	packetPool *PacketPool
-	packetOut  chan *Packet
+	conn            net.PacketConn
+	reader          packetReader
+	packetPool      *PacketPool
+	packetOut       chan Packets
+	allowlist       *allowlist.Allowlist
+	OriginDetection bool
 }
 
 // NewUDPListener returns an idle UDP Statsd listener
-func NewUDPListener(packetOut chan *Packet, packetPool *PacketPool) (*UDPListener, error) {
+func NewUDPListener(packetOut chan Packets, packetPool *PacketPool) (*UDPListener, error) {
 	var conn net.PacketConn
 	var err error
 	var url string
+	// the senders of the local traffic can be found on the host
+	originDetection := config.Datadog.GetBool("dogstatsd_origin_detection")
 
 	if config.Datadog.GetBool("dogstatsd_non_local_traffic") == true {
+		originDetection = false
 		// Listen to all network interfaces
 		url = fmt.Sprintf(":%d", config.Datadog.GetInt("dogstatsd_port"))
 	} else {
 		url = net.JoinHostPort(config.Datadog.GetString("bind_host"), config.Datadog.GetString("dogstatsd_port"))
 	}
 
+	sources, err := allowlist.New("dogstatsd-udp", config.Datadog.GetStringSlice("dogstatsd_allowed_sources"))
+	if err != nil {
+		return nil, fmt.Errorf("invalid dogstatsd_allowed_sources: %s", err)
+	}
+
 	conn, err = net.ListenPacket("udp", url)
 
 	if err != nil {
@@ -50,9 +75,12 @@ func NewUDPListener(packetOut chan *Packet, packetPool *PacketPool) (*UDPListene
 	}
 
 	listener := &UDPListener{
-		packetOut:  packetOut,
-		packetPool: packetPool,
-		conn:       conn,
+		packetOut:       packetOut,
+		packetPool:      packetPool,
+		conn:            conn,
+		reader:          newPacketReader(conn, udpBatchSize),
+		allowlist:       sources,
+		OriginDetection: originDetection,
 	}
 	log.Debugf("dogstatsd-udp: %s successfully initialized", conn.LocalAddr())
 	return listener, nil
@@ -61,12 +89,20 @@ func NewUDPListener(packetOut chan *Packet, packetPool *PacketPool) (*UDPListene
 // Listen runs the intake loop. Should be called in its own goroutine
 func (l *UDPListener) Listen() {
 	log.Infof("dogstatsd-udp: starting to listen on %s", l.conn.LocalAddr())
+	// the packets not read or rejected are reused by the next read
+	batch := make([]*Packet, udpBatchSize)
+	addrs := make([]net.Addr, udpBatchSize)
+	for i := range batch {
+		batch[i] = l.packetPool.Get()
+	}
 	for {
-		packet := l.packetPool.Get()
-		n, _, err := l.conn.ReadFrom(packet.buffer)
+		n, err := l.reader.ReadPackets(batch, addrs)
 		if err != nil {
 			// connection has been closed
 			if strings.HasSuffix(err.Error(), " use of closed network connection") {
+				for _, packet := range batch {
+					l.packetPool.Put(packet)
+				}
 				return
 			}
 
@@ -75,8 +111,29 @@ func (l *UDPListener) Listen() {
 			continue
 		}
 
-		packet.Contents = packet.buffer[:n]
-		l.packetOut <- packet
+		packets := make(Packets, 0, n)
+		for i := 0; i < n; i++ {
+			if !l.allowlist.Allowed(addrs[i]) {
+				udpExpvar.Add("PacketsRejected", 1)
+				continue
+			}
+			packet := batch[i]
+			countPacket(packet, udpExpvar)
+			if l.OriginDetection {
+				container, originErr := processUDPOrigin(addrs[i])
+				if originErr != nil {
+					log.Debugf("dogstatsd-udp: error processing origin of %s, data will not be tagged : %v", addrs[i], originErr)
+					udpExpvar.Add("OriginDetectionErrors", 1)
+				} else {
+					packet.Origin = container
+				}
+			}
+			packets = append(packets, packet)
+			batch[i] = l.packetPool.Get()
+		}
+		if len(packets) > 0 {
+			sendPackets(l.packetOut, packets, udpExpvar)
+		}
 	}
 }
 
@@ -0,0 +1,109 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2018 Datadog, Inc.
+
+package listeners
+
+import (
+	"encoding/binary"
+	"fmt"
+	"net"
+
+	log "github.com/cihub/seelog"
+	"golang.org/x/net/ipv4"
+	"golang.org/x/sys/unix"
+)
+
+// batchReader reads the datagrams by batch with recvmmsg, and counts the
+// ones dropped by the kernel with the SO_RXQ_OVFL counter of the socket
+type batchReader struct {
+	conn     *ipv4.PacketConn
+	messages []ipv4.Message
+	dropped  uint32
+}
+
+// newPacketReader returns a reader of batchSize datagrams at most
+func newPacketReader(conn net.PacketConn, batchSize int) packetReader {
+	countDrops := true
+	if err := enableUDPDropCounter(conn); err != nil {
+		log.Warnf("dogstatsd-udp: can't count the packets dropped by the kernel: %s", err)
+		countDrops = false
+	}
+	messages := make([]ipv4.Message, batchSize)
+	for i := range messages {
+		messages[i].Buffers = make([][]byte, 1)
+		if countDrops {
+			messages[i].OOB = make([]byte, unix.CmsgSpace(4))
+		}
+	}
+	return &batchReader{
+		conn:     ipv4.NewPacketConn(conn),
+		messages: messages,
+	}
+}
+
+// enableUDPDropCounter makes the kernel add the number of datagrams it
+// dropped for the socket to the ancillary data of the ones read
+func enableUDPDropCounter(conn net.PacketConn) error {
+	udpConn, ok := conn.(*net.UDPConn)
+	if !ok {
+		return fmt.Errorf("not an UDP connection")
+	}
+	rawconn, err := udpConn.SyscallConn()
+	if err != nil {
+		return err
+	}
+	var sockErr error
+	err = rawconn.Control(func(fd uintptr) {
+		sockErr = unix.SetsockoptInt(int(fd), unix.SOL_SOCKET, unix.SO_RXQ_OVFL, 1)
+	})
+	if err != nil {
+		return err
+	}
+	return sockErr
+}
+
+// ReadPackets reads up to len(packets) datagrams with a single system call
+func (r *batchReader) ReadPackets(packets []*Packet, addrs []net.Addr) (int, error) {
+	messages := r.messages[:len(packets)]
+	for i, packet := range packets {
+		messages[i].Buffers[0] = packet.buffer
+	}
+	n, err := r.conn.ReadBatch(messages, 0)
+	if err != nil {
+		return 0, err
+	}
+	for i := 0; i < n; i++ {
+		packets[i].Contents = packets[i].buffer[:messages[i].N]
+		addrs[i] = messages[i].Addr
+	}
+	// the counter of the last datagram is the most recent
+	last := messages[n-1]
+	r.countDrops(last.OOB[:last.NN])
+	return n, nil
+}
+
+// countDrops adds the datagrams dropped since the last read to the
+// PacketsDropped stat, the counter of the kernel being cumulative. It is
+// in host byte order, little endian on the architectures the agent
+// supports.
+func (r *batchReader) countDrops(oob []byte) {
+	if len(oob) == 0 {
+		return
+	}
+	messages, err := unix.ParseSocketControlMessage(oob)
+	if err != nil {
+		return
+	}
+	for _, message := range messages {
+		if message.Header.Level != unix.SOL_SOCKET || message.Header.Type != unix.SO_RXQ_OVFL || len(message.Data) < 4 {
+			continue
+		}
+		dropped := binary.LittleEndian.Uint32(message.Data)
+		if dropped != r.dropped {
+			udpExpvar.Add("PacketsDropped", int64(dropped-r.dropped))
+			r.dropped = dropped
+		}
+	}
+}
@@ -0,0 +1,56 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2018 Datadog, Inc.
+
+package listeners
+
+import (
+	"expvar"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestBatchReader(t *testing.T) {
+	conn, err := net.ListenUDP("udp", &net.UDPAddr{IP: net.ParseIP("127.0.0.1")})
+	require.NoError(t, err)
+	defer conn.Close()
+	// the smallest buffer, the kernel drops most of the datagrams
+	require.NoError(t, conn.SetReadBuffer(1))
+	reader := newPacketReader(conn, 4)
+
+	sender, err := net.Dial("udp", conn.LocalAddr().String())
+	require.NoError(t, err)
+	defer sender.Close()
+	for i := 0; i < 1000; i++ {
+		sender.Write([]byte("daemon:1|c"))
+	}
+
+	pool := NewPacketPool(1024)
+	packets := []*Packet{pool.Get(), pool.Get(), pool.Get(), pool.Get()}
+	addrs := make([]net.Addr, len(packets))
+	n, err := reader.ReadPackets(packets, addrs)
+	require.NoError(t, err)
+	assert.True(t, n > 0)
+	assert.Equal(t, []byte("daemon:1|c"), packets[0].Contents)
+	assert.Equal(t, sender.LocalAddr().String(), addrs[0].String())
+
+	// the drops are counted with the datagrams received after them
+	conn.SetReadDeadline(time.Now().Add(100 * time.Millisecond))
+	for err == nil {
+		_, err = reader.ReadPackets(packets, addrs)
+	}
+	conn.SetReadDeadline(time.Time{})
+	sender.Write([]byte("daemon:2|c"))
+	n, err = reader.ReadPackets(packets, addrs)
+	require.NoError(t, err)
+	assert.Equal(t, 1, n)
+	assert.Equal(t, []byte("daemon:2|c"), packets[0].Contents)
+	dropped, ok := udpExpvar.Get("PacketsDropped").(*expvar.Int)
+	require.True(t, ok)
+	assert.True(t, dropped.Value() > 0)
+}
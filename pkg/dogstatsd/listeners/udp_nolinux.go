@@ -0,0 +1,34 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2018 Datadog, Inc.
+
+// +build !linux
+
+package listeners
+
+import (
+	"net"
+)
+
+// singleReader reads the datagrams one at a time, batching is only
+// implemented on Linux hosts
+type singleReader struct {
+	conn net.PacketConn
+}
+
+// newPacketReader returns a reader of one datagram at a time
+func newPacketReader(conn net.PacketConn, batchSize int) packetReader {
+	return &singleReader{conn: conn}
+}
+
+// ReadPackets reads a single datagram in the first packet
+func (r *singleReader) ReadPackets(packets []*Packet, addrs []net.Addr) (int, error) {
+	n, addr, err := r.conn.ReadFrom(packets[0].buffer)
+	if err != nil {
+		return 0, err
+	}
+	packets[0].Contents = packets[0].buffer[:n]
+	addrs[0] = addr
+	return 1, nil
+}
@@ -0,0 +1,198 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2018 Datadog, Inc.
+
+package listeners
+
+import (
+	"bufio"
+	"encoding/binary"
+	"encoding/hex"
+	"fmt"
+	"io/ioutil"
+	"net"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/DataDog/datadog-agent/pkg/config"
+	"github.com/DataDog/datadog-agent/pkg/util/cache"
+)
+
+const (
+	// UDPAddrToContainerKeyPrefix holds the name prefix for cache keys
+	UDPAddrToContainerKeyPrefix = "udp_addr_to_container"
+	// udpOriginCacheDuration is how long the origin of an address is kept,
+	// the ports of the sockets closed are eventually reused
+	udpOriginCacheDuration = time.Minute
+)
+
+// udpSocket is a socket of /proc/<pid>/net/udp
+type udpSocket struct {
+	ip    net.IP
+	port  int
+	inode string
+}
+
+// processUDPOrigin returns a string identifying the container which sent a
+// packet from addr, finding the process which owns the socket bound to addr
+// in the network namespaces of the host. The result is cached as walking
+// /proc is expensive, the failures as well not to walk it for every packet.
+func processUDPOrigin(addr net.Addr) (string, error) {
+	udpAddr, ok := addr.(*net.UDPAddr)
+	if !ok {
+		return NoOrigin, fmt.Errorf("not an UDP address: %s", addr)
+	}
+	key := cache.BuildAgentKey(UDPAddrToContainerKeyPrefix, udpAddr.String())
+	if x, found := cache.Cache.Get(key); found {
+		return x.(string), nil
+	}
+	procRoot := config.Datadog.GetString("container_proc_root")
+	origin := NoOrigin
+	pid, err := findUDPSocketOwner(procRoot, udpAddr)
+	if err == nil {
+		origin, err = getContainerForPID(int32(pid))
+	}
+	cache.Cache.Set(key, origin, udpOriginCacheDuration)
+	return origin, err
+}
+
+// findUDPSocketOwner returns the pid of a process owning the socket bound to
+// addr. The sockets are listed once per network namespace, the ones bound to
+// the address of addr are preferred to the ones bound to all the addresses,
+// which also match when they are in another namespace.
+func findUDPSocketOwner(procRoot string, addr *net.UDPAddr) (int, error) {
+	pids, err := listPIDs(procRoot)
+	if err != nil {
+		return 0, err
+	}
+	pidsByNamespace := make(map[string][]int)
+	var namespaces []string
+	for _, pid := range pids {
+		namespace, err := os.Readlink(filepath.Join(procRoot, strconv.Itoa(pid), "ns", "net"))
+		if err != nil {
+			// the process exited or can't be inspected
+			continue
+		}
+		if _, seen := pidsByNamespace[namespace]; !seen {
+			namespaces = append(namespaces, namespace)
+		}
+		pidsByNamespace[namespace] = append(pidsByNamespace[namespace], pid)
+	}
+
+	var wildcardInode string
+	var wildcardPIDs []int
+	for _, namespace := range namespaces {
+		nsPIDs := pidsByNamespace[namespace]
+		for _, file := range []string{"udp", "udp6"} {
+			sockets, err := readUDPSockets(filepath.Join(procRoot, strconv.Itoa(nsPIDs[0]), "net", file))
+			if err != nil {
+				continue
+			}
+			for _, socket := range sockets {
+				if socket.port != addr.Port {
+					continue
+				}
+				if socket.ip.Equal(addr.IP) {
+					return findInodeOwner(procRoot, nsPIDs, socket.inode)
+				}
+				if socket.ip.IsUnspecified() && wildcardInode == "" {
+					wildcardInode, wildcardPIDs = socket.inode, nsPIDs
+				}
+			}
+		}
+	}
+	if wildcardInode != "" {
+		return findInodeOwner(procRoot, wildcardPIDs, wildcardInode)
+	}
+	return 0, fmt.Errorf("no socket bound to %s", addr)
+}
+
+// listPIDs returns the pids of the processes of procRoot
+func listPIDs(procRoot string) ([]int, error) {
+	entries, err := ioutil.ReadDir(procRoot)
+	if err != nil {
+		return nil, err
+	}
+	var pids []int
+	for _, entry := range entries {
+		if pid, err := strconv.Atoi(entry.Name()); err == nil {
+			pids = append(pids, pid)
+		}
+	}
+	return pids, nil
+}
+
+// readUDPSockets returns the sockets listed in a /proc/<pid>/net/udp or udp6
+// file, formatted like:
+//   sl  local_address rem_address   st tx_queue rx_queue tr tm->when retrnsmt   uid  timeout inode
+//  123: 0100007F:A1B2 00000000:0000 07 00000000:00000000 00:00000000 00000000     0        0 12345
+func readUDPSockets(path string) ([]udpSocket, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	var sockets []udpSocket
+	scanner := bufio.NewScanner(file)
+	// skip the header
+	scanner.Scan()
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) < 10 {
+			continue
+		}
+		ip, port, err := parseProcAddress(fields[1])
+		if err != nil {
+			continue
+		}
+		sockets = append(sockets, udpSocket{ip: ip, port: port, inode: fields[9]})
+	}
+	return sockets, scanner.Err()
+}
+
+// parseProcAddress parses an address of /proc/net, the IP is hex encoded
+// as 32-bit words in host byte order, little endian on the architectures
+// the agent supports, the port as a big endian 16-bit word
+func parseProcAddress(address string) (net.IP, int, error) {
+	parts := strings.Split(address, ":")
+	if len(parts) != 2 {
+		return nil, 0, fmt.Errorf("invalid address %q", address)
+	}
+	raw, err := hex.DecodeString(parts[0])
+	if err != nil || (len(raw) != net.IPv4len && len(raw) != net.IPv6len) {
+		return nil, 0, fmt.Errorf("invalid address %q", address)
+	}
+	ip := make(net.IP, len(raw))
+	for i := 0; i < len(raw); i += 4 {
+		binary.LittleEndian.PutUint32(ip[i:], binary.BigEndian.Uint32(raw[i:]))
+	}
+	port, err := strconv.ParseUint(parts[1], 16, 16)
+	if err != nil {
+		return nil, 0, fmt.Errorf("invalid address %q", address)
+	}
+	return ip, int(port), nil
+}
+
+// findInodeOwner returns the first of pids with a file descriptor on the
+// socket inode
+func findInodeOwner(procRoot string, pids []int, inode string) (int, error) {
+	target := "socket:[" + inode + "]"
+	for _, pid := range pids {
+		fdDir := filepath.Join(procRoot, strconv.Itoa(pid), "fd")
+		fds, err := ioutil.ReadDir(fdDir)
+		if err != nil {
+			continue
+		}
+		for _, fd := range fds {
+			if link, err := os.Readlink(filepath.Join(fdDir, fd.Name())); err == nil && link == target {
+				return pid, nil
+			}
+		}
+	}
+	return 0, fmt.Errorf("no process owns socket %s", inode)
+}
@@ -0,0 +1,92 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2018 Datadog, Inc.
+
+package listeners
+
+import (
+	"io/ioutil"
+	"net"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/DataDog/datadog-agent/pkg/config"
+	"github.com/DataDog/datadog-agent/pkg/util/cache"
+)
+
+func TestParseProcAddress(t *testing.T) {
+	ip, port, err := parseProcAddress("0100007F:1FBD")
+	require.NoError(t, err)
+	assert.True(t, ip.Equal(net.ParseIP("127.0.0.1")))
+	assert.Equal(t, 8125, port)
+
+	ip, port, err = parseProcAddress("0000000000000000FFFF00000100007F:A1B2")
+	require.NoError(t, err)
+	assert.True(t, ip.Equal(net.ParseIP("127.0.0.1")))
+	assert.Equal(t, 0xA1B2, port)
+
+	_, _, err = parseProcAddress("0100007F")
+	assert.Error(t, err)
+}
+
+func TestFindUDPSocketOwner(t *testing.T) {
+	conn, err := net.ListenUDP("udp", &net.UDPAddr{IP: net.ParseIP("127.0.0.1")})
+	require.NoError(t, err)
+	defer conn.Close()
+
+	pid, err := findUDPSocketOwner("/proc", conn.LocalAddr().(*net.UDPAddr))
+	require.NoError(t, err)
+	assert.Equal(t, os.Getpid(), pid)
+}
+
+func TestProcessUDPOrigin(t *testing.T) {
+	procRoot, err := ioutil.TempDir("", "dd-test-")
+	require.NoError(t, err)
+	defer os.RemoveAll(procRoot)
+	containerID := "3726184226f5d3147c25fdeab5b60097e378e8a720503a5e19ecfdf29f869860"
+
+	// the host sends from 127.0.0.1:41394, the container from the address
+	// of its own namespace, 10.0.0.2:41394
+	for _, proc := range []struct {
+		pid, namespace, udp, inode, cgroup string
+	}{
+		{"1", "net:[1]", "0100007F:A1B2", "100", "1:name=systemd:/\n"},
+		{"42", "net:[2]", "0200000A:A1B2", "200", "1:name=systemd:/docker/" + containerID + "\n"},
+	} {
+		dir := filepath.Join(procRoot, proc.pid)
+		require.NoError(t, os.MkdirAll(filepath.Join(dir, "ns"), 0755))
+		require.NoError(t, os.MkdirAll(filepath.Join(dir, "net"), 0755))
+		require.NoError(t, os.MkdirAll(filepath.Join(dir, "fd"), 0755))
+		require.NoError(t, os.Symlink(proc.namespace, filepath.Join(dir, "ns", "net")))
+		require.NoError(t, os.Symlink("socket:["+proc.inode+"]", filepath.Join(dir, "fd", "3")))
+		udp := "  sl  local_address rem_address   st tx_queue rx_queue tr tm->when retrnsmt   uid  timeout inode ref pointer drops\n" +
+			"   1: " + proc.udp + " 00000000:0000 07 00000000:00000000 00:00000000 00000000     0        0 " + proc.inode + " 2 0000000000000000 0\n"
+		require.NoError(t, ioutil.WriteFile(filepath.Join(dir, "net", "udp"), []byte(udp), 0644))
+		require.NoError(t, ioutil.WriteFile(filepath.Join(dir, "cgroup"), []byte(proc.cgroup), 0644))
+	}
+	for _, addr := range []string{"10.0.0.2:41394", "127.0.0.1:41394", "10.0.0.3:41394"} {
+		cache.Cache.Delete(cache.BuildAgentKey(UDPAddrToContainerKeyPrefix, addr))
+	}
+	defer config.Datadog.SetDefault("container_proc_root", config.Datadog.GetString("container_proc_root"))
+	config.Datadog.SetDefault("container_proc_root", procRoot)
+
+	origin, err := processUDPOrigin(&net.UDPAddr{IP: net.ParseIP("10.0.0.2"), Port: 41394})
+	assert.NoError(t, err)
+	assert.Equal(t, "docker://"+containerID, origin)
+
+	origin, err = processUDPOrigin(&net.UDPAddr{IP: net.ParseIP("127.0.0.1"), Port: 41394})
+	assert.NoError(t, err)
+	assert.Equal(t, NoOrigin, origin)
+
+	// the failures are cached not to walk /proc for every packet
+	_, err = processUDPOrigin(&net.UDPAddr{IP: net.ParseIP("10.0.0.3"), Port: 41394})
+	assert.Error(t, err)
+	origin, err = processUDPOrigin(&net.UDPAddr{IP: net.ParseIP("10.0.0.3"), Port: 41394})
+	assert.NoError(t, err)
+	assert.Equal(t, NoOrigin, origin)
+}
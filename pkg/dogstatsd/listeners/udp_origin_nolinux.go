@@ -0,0 +1,18 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2018 Datadog, Inc.
+
+// +build !linux
+
+package listeners
+
+import (
+	"fmt"
+	"net"
+)
+
+// processUDPOrigin returns a "not implemented" error on non-linux hosts
+func processUDPOrigin(addr net.Addr) (string, error) {
+	return "", fmt.Errorf("only implemented on Linux hosts")
+}
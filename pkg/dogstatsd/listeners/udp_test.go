@@ -117,7 +117,7 @@ func TestUDPReceive(t *testing.T) {
 	require.Nil(t, err)
 	config.Datadog.SetDefault("dogstatsd_port", port)
 
-	packetChannel := make(chan *Packet)
+	packetChannel := make(chan Packets)
 	s, err := NewUDPListener(packetChannel, packetPoolUDP)
 	require.NotNil(t, s)
 	assert.Nil(t, err)
@@ -131,7 +131,9 @@ func TestUDPReceive(t *testing.T) {
 	conn.Write(contents)
 
 	select {
-	case packet := <-packetChannel:
+	case packets := <-packetChannel:
+		require.Len(t, packets, 1)
+		packet := packets[0]
 		assert.NotNil(t, packet)
 		assert.Equal(t, contents, packet.Contents)
 		assert.Equal(t, "", packet.Origin)
@@ -140,6 +142,43 @@ func TestUDPReceive(t *testing.T) {
 	}
 }
 
+func BenchmarkUDPListener(b *testing.B) {
+	port, err := getAvailableUDPPort()
+	require.NoError(b, err)
+	config.Datadog.SetDefault("dogstatsd_port", port)
+
+	packetChannel := make(chan Packets, 100)
+	s, err := NewUDPListener(packetChannel, packetPoolUDP)
+	require.NoError(b, err)
+
+	go s.Listen()
+	defer s.Stop()
+	conn, err := net.Dial("udp", fmt.Sprintf("127.0.0.1:%d", port))
+	require.NoError(b, err)
+	defer conn.Close()
+	contents := []byte("daemon:666|g|#sometag1:somevalue1,sometag2:somevalue2")
+
+	b.ReportAllocs()
+	b.ResetTimer()
+	go func() {
+		for i := 0; i < b.N; i++ {
+			conn.Write(contents)
+		}
+	}()
+	// the packets dropped by the kernel are not waited for
+	for received := 0; received < b.N; {
+		select {
+		case packets := <-packetChannel:
+			received += len(packets)
+			for _, packet := range packets {
+				packetPoolUDP.Put(packet)
+			}
+		case <-time.After(100 * time.Millisecond):
+			return
+		}
+	}
+}
+
 // getAvailableUDPPort requests a random port number and makes sure it is available
 func getAvailableUDPPort() (int, error) {
 	conn, err := net.ListenPacket("udp", ":0")
@@ -6,7 +6,6 @@
 package listeners
 
 import (
-	"expvar"
 	"fmt"
 	"net"
 	"os"
@@ -19,23 +18,23 @@ import (
 )
 
 var (
-	socketExpvar = expvar.NewMap("dogstatsd-uds")
+	socketExpvar = newTransportStats("uds")
 )
 
 // UDSListener implements the StatsdListener interface for Unix Domain
 // Socket datagram protocol. It listens to a given socket path and sends
-// back packets ready to be processed.
-// Origin detection will be implemented for UDS.
+// back packets ready to be processed, with the container of their sender
+// as origin when origin detection is enabled.
 type UDSListener struct {
 	conn            *net.UnixConn
-	packetOut       chan *Packet
+	packetOut       chan Packets
 	packetPool      *PacketPool
 	oobPool         *sync.Pool // For origin detection ancilary data
 	OriginDetection bool
 }
 
 // NewUDSListener returns an idle UDS Statsd listener
-func NewUDSListener(packetOut chan *Packet, packetPool *PacketPool) (*UDSListener, error) {
+func NewUDSListener(packetOut chan Packets, packetPool *PacketPool) (*UDSListener, error) {
 	socketPath := config.Datadog.GetString("dogstatsd_socket")
 	originDection := config.Datadog.GetBool("dogstatsd_origin_detection")
 
@@ -97,13 +96,16 @@ func (l *UDSListener) Listen() {
 			var oobn int
 			n, oobn, _, _, err = l.conn.ReadMsgUnix(packet.buffer, oob)
 
-			// Extract container id from credentials
-			container, err := processUDSOrigin(oob[:oobn])
-			if err != nil {
-				log.Warnf("dogstatsd-uds: error processing origin, data will not be tagged : %v", err)
-				socketExpvar.Add("OriginDetectionErrors", 1)
-			} else {
-				packet.Origin = container
+			// Extract container id from credentials, there are none
+			// when the read failed
+			if err == nil {
+				container, originErr := processUDSOrigin(oob[:oobn])
+				if originErr != nil {
+					log.Warnf("dogstatsd-uds: error processing origin, data will not be tagged : %v", originErr)
+					socketExpvar.Add("OriginDetectionErrors", 1)
+				} else {
+					packet.Origin = container
+				}
 			}
 			// Return the buffer back to the pool for reuse
 			l.oobPool.Put(oob)
@@ -113,6 +115,9 @@ func (l *UDSListener) Listen() {
 		}
 
 		if err != nil {
+			// Return the packet back to the pool for reuse
+			l.packetPool.Put(packet)
+
 			// connection has been closed
 			if strings.HasSuffix(err.Error(), " use of closed network connection") {
 				return
@@ -124,7 +129,9 @@ func (l *UDSListener) Listen() {
 		}
 
 		packet.Contents = packet.buffer[:n]
-		l.packetOut <- packet
+		countPacket(packet, socketExpvar)
+		// the credentials are read with each datagram, there is no batch
+		sendPackets(l.packetOut, Packets{packet}, socketExpvar)
 	}
 }
 
@@ -20,6 +20,7 @@ import (
 	"github.com/stretchr/testify/assert"
 
 	"github.com/DataDog/datadog-agent/pkg/config"
+	"github.com/DataDog/datadog-agent/pkg/util/docker"
 	"golang.org/x/sys/unix"
 )
 
@@ -48,3 +49,22 @@ func TestUDSPassCred(t *testing.T) {
 	assert.Nil(t, err)
 	assert.Equal(t, enabled, 1)
 }
+
+func TestProcessUDSOrigin(t *testing.T) {
+	// the credentials are the ones the kernel adds with SO_PASSCRED
+	ancillary := unix.UnixCredentials(&unix.Ucred{Pid: int32(os.Getpid()), Uid: uint32(os.Getuid()), Gid: uint32(os.Getgid())})
+	origin, err := processUDSOrigin(ancillary)
+	assert.Nil(t, err)
+
+	// the origin of the agent is its own container, if any
+	id, err := docker.ContainerIDForPID(os.Getpid())
+	assert.Nil(t, err)
+	if id == "" {
+		assert.Equal(t, NoOrigin, origin)
+	} else {
+		assert.Equal(t, "docker://"+id, origin)
+	}
+
+	_, err = processUDSOrigin(nil)
+	assert.NotNil(t, err)
+}
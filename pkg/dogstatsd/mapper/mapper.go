@@ -0,0 +1,164 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2018 Datadog, Inc.
+
+package mapper
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// Match types of the mappings
+const (
+	WildcardMatchType = "wildcard"
+	RegexMatchType    = "regex"
+)
+
+// allowedWildcardMatch is what a wildcard match can be made of, the dots
+// separate the parts of the names and the stars match one part
+var allowedWildcardMatch = regexp.MustCompile(`^[a-zA-Z0-9\-_*.]+$`)
+
+// MappingProfile groups the mappings of the metrics starting with a prefix,
+// "*" matching all of them
+type MappingProfile struct {
+	Name     string          `mapstructure:"name" json:"name"`
+	Prefix   string          `mapstructure:"prefix" json:"prefix"`
+	Mappings []MetricMapping `mapstructure:"mappings" json:"mappings"`
+}
+
+// MetricMapping renames the metrics matching a wildcard or a regex match,
+// the name and the values of the tags can refer to the groups of the match
+// like $1, the stars of a wildcard match being its groups
+type MetricMapping struct {
+	Match     string            `mapstructure:"match" json:"match"`
+	MatchType string            `mapstructure:"match_type" json:"match_type"`
+	Name      string            `mapstructure:"name" json:"name"`
+	Tags      map[string]string `mapstructure:"tags" json:"tags"`
+}
+
+// MapResult is the name and the tags, sorted, of a mapped metric
+type MapResult struct {
+	Name string
+	Tags []string
+}
+
+type mapping struct {
+	regex *regexp.Regexp
+	name  string
+	tags  map[string]string
+}
+
+type profile struct {
+	prefix   string
+	mappings []mapping
+}
+
+// MetricMapper maps the names of the metrics to new names and tags with the
+// first matching mapping of the first matching profile, the results are
+// cached
+type MetricMapper struct {
+	profiles  []profile
+	cacheSize int
+	cache     map[string]*MapResult
+	mu        sync.Mutex
+}
+
+// NewMetricMapper returns a MetricMapper applying profiles, it caches the
+// results of cacheSize names at most
+func NewMetricMapper(profiles []MappingProfile, cacheSize int) (*MetricMapper, error) {
+	mapper := &MetricMapper{
+		cacheSize: cacheSize,
+		cache:     make(map[string]*MapResult),
+	}
+	for _, p := range profiles {
+		if p.Prefix == "" {
+			return nil, fmt.Errorf("missing prefix for mapping profile %q", p.Name)
+		}
+		compiled := profile{prefix: p.Prefix}
+		for _, m := range p.Mappings {
+			if m.Name == "" {
+				return nil, fmt.Errorf("missing name for the mapping of %q in profile %q", m.Match, p.Name)
+			}
+			regex, err := compileMatch(m)
+			if err != nil {
+				return nil, fmt.Errorf("invalid mapping of %q in profile %q: %s", m.Match, p.Name, err)
+			}
+			compiled.mappings = append(compiled.mappings, mapping{regex: regex, name: m.Name, tags: m.Tags})
+		}
+		mapper.profiles = append(mapper.profiles, compiled)
+	}
+	return mapper, nil
+}
+
+// compileMatch returns the regex matching the names of a mapping
+func compileMatch(m MetricMapping) (*regexp.Regexp, error) {
+	switch m.MatchType {
+	case "", WildcardMatchType:
+		if !allowedWildcardMatch.MatchString(m.Match) {
+			return nil, fmt.Errorf("wildcard match must be made of letters, digits, '-', '_', '.' and '*'")
+		}
+		if strings.Contains(m.Match, "**") {
+			return nil, fmt.Errorf("wildcard match can't contain consecutive '*'")
+		}
+		pattern := strings.Replace(regexp.QuoteMeta(m.Match), `\*`, `([^.]*)`, -1)
+		return regexp.Compile("^" + pattern + "$")
+	case RegexMatchType:
+		return regexp.Compile("^(?:" + m.Match + ")$")
+	default:
+		return nil, fmt.Errorf("unknown match type %q, expected %s or %s", m.MatchType, WildcardMatchType, RegexMatchType)
+	}
+}
+
+// Map returns the new name and tags of a metric, nil when no mapping matches
+// its name
+func (m *MetricMapper) Map(name string) *MapResult {
+	m.mu.Lock()
+	result, found := m.cache[name]
+	m.mu.Unlock()
+	if found {
+		return result
+	}
+
+	result = m.match(name)
+
+	m.mu.Lock()
+	if len(m.cache) >= m.cacheSize {
+		// the names are not tracked by use, the cache is emptied when full
+		m.cache = make(map[string]*MapResult)
+	}
+	if m.cacheSize > 0 {
+		m.cache[name] = result
+	}
+	m.mu.Unlock()
+	return result
+}
+
+// match applies the first mapping matching name
+func (m *MetricMapper) match(name string) *MapResult {
+	for _, p := range m.profiles {
+		if p.prefix != "*" && !strings.HasPrefix(name, p.prefix) {
+			continue
+		}
+		for _, mapping := range p.mappings {
+			groups := mapping.regex.FindStringSubmatchIndex(name)
+			if groups == nil {
+				continue
+			}
+			result := &MapResult{
+				Name: string(mapping.regex.ExpandString(nil, mapping.name, name, groups)),
+			}
+			for key, value := range mapping.tags {
+				expanded := mapping.regex.ExpandString(nil, value, name, groups)
+				result.Tags = append(result.Tags, key+":"+string(expanded))
+			}
+			sort.Strings(result.Tags)
+			return result
+		}
+	}
+	return nil
+}
@@ -0,0 +1,114 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2018 Datadog, Inc.
+
+package mapper
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWildcardMapping(t *testing.T) {
+	mapper, err := NewMetricMapper([]MappingProfile{{
+		Name:   "airflow",
+		Prefix: "airflow.",
+		Mappings: []MetricMapping{{
+			Match: "airflow.job.*.duration",
+			Name:  "airflow.job.duration",
+			Tags:  map[string]string{"dag": "$1"},
+		}, {
+			Match: "airflow.operator.*.*",
+			Name:  "airflow.operator.${2}",
+			Tags:  map[string]string{"operator": "$1", "job": "airflow"},
+		}},
+	}}, 10)
+	require.NoError(t, err)
+
+	result := mapper.Map("airflow.job.my_dag.duration")
+	require.NotNil(t, result)
+	assert.Equal(t, "airflow.job.duration", result.Name)
+	assert.Equal(t, []string{"dag:my_dag"}, result.Tags)
+
+	result = mapper.Map("airflow.operator.bash.failures")
+	require.NotNil(t, result)
+	assert.Equal(t, "airflow.operator.failures", result.Name)
+	assert.Equal(t, []string{"job:airflow", "operator:bash"}, result.Tags)
+
+	// a star matches a single part of the names
+	assert.Nil(t, mapper.Map("airflow.job.my.dag.duration"))
+	assert.Nil(t, mapper.Map("other.job.my_dag.duration"))
+}
+
+func TestRegexMapping(t *testing.T) {
+	mapper, err := NewMetricMapper([]MappingProfile{{
+		Name:   "test",
+		Prefix: "*",
+		Mappings: []MetricMapping{{
+			Match:     `test\.(\w+)\.([a-z.]+)`,
+			MatchType: RegexMatchType,
+			Name:      "test.$2",
+			Tags:      map[string]string{"env": "$1"},
+		}},
+	}}, 10)
+	require.NoError(t, err)
+
+	result := mapper.Map("test.prod.request.count")
+	require.NotNil(t, result)
+	assert.Equal(t, "test.request.count", result.Name)
+	assert.Equal(t, []string{"env:prod"}, result.Tags)
+
+	// the regex must match the whole name
+	assert.Nil(t, mapper.Map("other.test.prod.count"))
+}
+
+func TestFirstMappingWins(t *testing.T) {
+	mapper, err := NewMetricMapper([]MappingProfile{{
+		Name:     "first",
+		Prefix:   "app.",
+		Mappings: []MetricMapping{{Match: "app.*.hits", Name: "app.hits", Tags: map[string]string{"page": "$1"}}},
+	}, {
+		Name:     "second",
+		Prefix:   "app.",
+		Mappings: []MetricMapping{{Match: "app.*.*", Name: "app.$2"}},
+	}}, 10)
+	require.NoError(t, err)
+
+	assert.Equal(t, &MapResult{Name: "app.hits", Tags: []string{"page:home"}}, mapper.Map("app.home.hits"))
+	assert.Equal(t, &MapResult{Name: "app.misses"}, mapper.Map("app.home.misses"))
+}
+
+func TestMapperCache(t *testing.T) {
+	mapper, err := NewMetricMapper([]MappingProfile{{
+		Name:     "app",
+		Prefix:   "app.",
+		Mappings: []MetricMapping{{Match: "app.*", Name: "app"}},
+	}}, 2)
+	require.NoError(t, err)
+
+	mapper.Map("app.a")
+	mapper.Map("other")
+	assert.Len(t, mapper.cache, 2)
+	assert.Nil(t, mapper.cache["other"])
+
+	// the cache is emptied when full
+	assert.Equal(t, "app", mapper.Map("app.b").Name)
+	assert.Len(t, mapper.cache, 1)
+}
+
+func TestInvalidMappings(t *testing.T) {
+	for _, profile := range []MappingProfile{
+		{Name: "no prefix", Mappings: []MetricMapping{{Match: "a.*", Name: "a"}}},
+		{Name: "no name", Prefix: "a.", Mappings: []MetricMapping{{Match: "a.*"}}},
+		{Name: "invalid wildcard", Prefix: "a.", Mappings: []MetricMapping{{Match: "a.(b)", Name: "a"}}},
+		{Name: "consecutive stars", Prefix: "a.", Mappings: []MetricMapping{{Match: "a.**", Name: "a"}}},
+		{Name: "invalid regex", Prefix: "a.", Mappings: []MetricMapping{{Match: "a.(", MatchType: RegexMatchType, Name: "a"}}},
+		{Name: "unknown match type", Prefix: "a.", Mappings: []MetricMapping{{Match: "a.*", MatchType: "glob", Name: "a"}}},
+	} {
+		_, err := NewMetricMapper([]MappingProfile{profile}, 10)
+		assert.Error(t, err, profile.Name)
+	}
+}
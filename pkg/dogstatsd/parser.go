@@ -28,22 +28,31 @@ var metricTypes = map[string]metrics.MetricType{
 	"d":  metrics.DistributionType,
 }
 
+// packedMetricTypes are the metric types whose messages can pack several
+// values, all of them being kept
+var packedMetricTypes = map[metrics.MetricType]bool{
+	metrics.HistogramType:    true,
+	metrics.DistributionType: true,
+}
+
 var tagSeparator = []byte(",")
 var fieldSeparator = []byte("|")
 var valueSeparator = []byte(":")
 
+// nextMessage returns the next line of a packet, skipping the empty ones
 func nextMessage(packet *[]byte) (message []byte) {
-	if len(*packet) == 0 {
-		return nil
-	}
+	for len(*packet) > 0 {
+		advance, message, err := bufio.ScanLines(*packet, true)
+		if err != nil {
+			return nil
+		}
 
-	advance, message, err := bufio.ScanLines(*packet, true)
-	if err != nil || len(message) == 0 {
-		return nil
+		*packet = (*packet)[advance:]
+		if len(message) > 0 {
+			return message
+		}
 	}
-
-	*packet = (*packet)[advance:]
-	return message
+	return nil
 }
 
 // nextField returns the data found before the given separator and
@@ -231,9 +240,25 @@ func parseEventMessage(message []byte) (*metrics.Event, error) {
 	return &event, nil
 }
 
+// parseMetricMessage parses a metric message of a single value
 func parseMetricMessage(message []byte, namespace string) (*metrics.MetricSample, error) {
+	samples, err := parseMetricMessages(message, namespace)
+	if err != nil {
+		return nil, err
+	}
+	if len(samples) != 1 {
+		return nil, fmt.Errorf("too many values for %q", message)
+	}
+	return samples[0], nil
+}
+
+// parseMetricMessages parses a metric message, the histograms, timers and
+// distributions can pack several values separated by colons, which are
+// returned as distinct samples
+func parseMetricMessages(message []byte, namespace string) ([]*metrics.MetricSample, error) {
 	// daemon:666|g|#sometag1:somevalue1,sometag2:somevalue2
 	// daemon:666|g|@0.1|#sometag:somevalue"
+	// daemon:666:667:668|h|#sometag:somevalue
 
 	separatorCount := bytes.Count(message, fieldSeparator)
 	if separatorCount < 1 || separatorCount > 3 {
@@ -287,25 +312,42 @@ func parseMetricMessage(message []byte, namespace string) (*metrics.MetricSample
 		return nil, fmt.Errorf("invalid metric type for %q", message)
 	}
 
-	sample := &metrics.MetricSample{
-		Name:       metricName,
-		Mtype:      metricType,
-		Tags:       metricTags,
-		Host:       host,
-		SampleRate: sampleRate,
-		Timestamp:  0,
+	rawValues := [][]byte{rawValue}
+	if packedMetricTypes[metricType] {
+		rawValues = bytes.Split(rawValue, valueSeparator)
 	}
 
-	if metricType == metrics.SetType {
-		sample.RawValue = string(rawValue)
-	} else {
-		metricValue, err := strconv.ParseFloat(string(rawValue), 64)
-		if err != nil {
-			return nil, fmt.Errorf("invalid metric value for %q", message)
+	samples := make([]*metrics.MetricSample, 0, len(rawValues))
+	for i, rawValue := range rawValues {
+		if len(rawValue) == 0 {
+			return nil, fmt.Errorf("invalid metric message format: empty value in %q", message)
+		}
+		tags := metricTags
+		if i > 0 {
+			// the tags of the samples are appended to by the server
+			tags = append([]string(nil), metricTags...)
+		}
+		sample := &metrics.MetricSample{
+			Name:       metricName,
+			Mtype:      metricType,
+			Tags:       tags,
+			Host:       host,
+			SampleRate: sampleRate,
+			Timestamp:  0,
+		}
+
+		if metricType == metrics.SetType {
+			sample.RawValue = string(rawValue)
+		} else {
+			metricValue, err := strconv.ParseFloat(string(rawValue), 64)
+			if err != nil {
+				return nil, fmt.Errorf("invalid metric value for %q", message)
+			}
+			sample.RawValue = string(rawValue)
+			sample.Value = metricValue
 		}
-		sample.RawValue = string(rawValue)
-		sample.Value = metricValue
+		samples = append(samples, sample)
 	}
 
-	return sample, nil
+	return samples, nil
 }
@@ -60,6 +60,21 @@ func TestParseMultipleLineDatagram(t *testing.T) {
 	assert.Equal(t, 0, len(datagram))
 }
 
+func TestParseDatagramWithEmptyLines(t *testing.T) {
+	datagram := []byte("daemon:666|g\n\r\n\ndaemon:667|g\n\n")
+
+	pkt := nextMessage(&datagram)
+	assert.Equal(t, []byte("daemon:666|g"), pkt)
+
+	// the empty lines are skipped
+	pkt = nextMessage(&datagram)
+	assert.Equal(t, []byte("daemon:667|g"), pkt)
+
+	pkt = nextMessage(&datagram)
+	assert.Nil(t, pkt)
+	assert.Equal(t, 0, len(datagram))
+}
+
 func TestGaugePacketCounter(t *testing.T) {
 	assert.Equal(t, 1, 1)
 }
@@ -245,6 +260,17 @@ func TestParseMetricError(t *testing.T) {
 	_, err = parseMetricMessage([]byte("daemon:666:777|g"), "")
 	assert.Error(t, err)
 
+	_, err = parseMetricMessage([]byte("daemon:666:777|h"), "")
+	assert.Error(t, err)
+
+	// empty packed value
+	_, err = parseMetricMessages([]byte("daemon:666::777|h"), "")
+	assert.Error(t, err)
+
+	// invalid packed value
+	_, err = parseMetricMessages([]byte("daemon:666:abc|d"), "")
+	assert.Error(t, err)
+
 	// unknown metadata prefix
 	_, err = parseMetricMessage([]byte("daemon:666|g|m:test"), "")
 	assert.NoError(t, err)
@@ -262,6 +288,37 @@ func TestParseMetricError(t *testing.T) {
 	assert.Error(t, err)
 }
 
+func TestParsePackedValues(t *testing.T) {
+	samples, err := parseMetricMessages([]byte("daemon:666:1.5:-2|h|@0.5|#sometag:somevalue"), "")
+	require.NoError(t, err)
+	require.Len(t, samples, 3)
+	for i, value := range []float64{666, 1.5, -2} {
+		assert.Equal(t, "daemon", samples[i].Name)
+		assert.Equal(t, value, samples[i].Value)
+		assert.Equal(t, metrics.HistogramType, samples[i].Mtype)
+		assert.Equal(t, 0.5, samples[i].SampleRate)
+		assert.Equal(t, []string{"sometag:somevalue"}, samples[i].Tags)
+	}
+	// the samples don't share their tags
+	samples[0].Tags = append(samples[0].Tags, "origin:a")
+	samples[1].Tags = append(samples[1].Tags, "origin:b")
+	assert.Equal(t, []string{"sometag:somevalue", "origin:a"}, samples[0].Tags)
+
+	samples, err = parseMetricMessages([]byte("daemon:1:2|ms"), "")
+	require.NoError(t, err)
+	assert.Len(t, samples, 2)
+
+	samples, err = parseMetricMessages([]byte("daemon:1:2|d"), "")
+	require.NoError(t, err)
+	assert.Len(t, samples, 2)
+
+	// the values of the sets can contain colons
+	samples, err = parseMetricMessages([]byte("daemon:a:b|s"), "")
+	require.NoError(t, err)
+	require.Len(t, samples, 1)
+	assert.Equal(t, "a:b", samples[0].RawValue)
+}
+
 func TestParseMonokeyBatching(t *testing.T) {
 	// parsed, err := parseMetricMessage([]byte("test_gauge:1.5|g|#tag1:one,tag2:two:2.3|g|#tag3:three:3|g"))
 
@@ -0,0 +1,132 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2018 Datadog, Inc.
+
+// Package replay reads and writes the dogstatsd capture files.
+//
+// A capture file starts with fileHeader, followed by one record per packet:
+// the reception time in nanoseconds since epoch (int64), the length of the
+// origin (uint32), the origin, the length of the contents (uint32) and the
+// contents of the packet. Integers are little-endian.
+package replay
+
+import (
+	"bufio"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"time"
+)
+
+const (
+	fileHeader = "DSDCAP01"
+	// maxRecordFieldSize protects the reader against corrupted files,
+	// dogstatsd packets are way smaller
+	maxRecordFieldSize = 16 * 1024 * 1024
+)
+
+// Record is a packet received by dogstatsd
+type Record struct {
+	Timestamp time.Time
+	Origin    string
+	Contents  []byte
+}
+
+// Writer writes records to a capture file
+type Writer struct {
+	w   *bufio.Writer
+	buf [8]byte
+}
+
+// NewWriter writes the header of a capture file and returns a Writer
+func NewWriter(w io.Writer) (*Writer, error) {
+	bw := bufio.NewWriter(w)
+	if _, err := bw.WriteString(fileHeader); err != nil {
+		return nil, err
+	}
+	return &Writer{w: bw}, nil
+}
+
+// Write appends a record, the Writer must be flushed before closing the file
+func (w *Writer) Write(r Record) error {
+	binary.LittleEndian.PutUint64(w.buf[:], uint64(r.Timestamp.UnixNano()))
+	if _, err := w.w.Write(w.buf[:8]); err != nil {
+		return err
+	}
+	if err := w.writeField([]byte(r.Origin)); err != nil {
+		return err
+	}
+	return w.writeField(r.Contents)
+}
+
+func (w *Writer) writeField(field []byte) error {
+	binary.LittleEndian.PutUint32(w.buf[:], uint32(len(field)))
+	if _, err := w.w.Write(w.buf[:4]); err != nil {
+		return err
+	}
+	_, err := w.w.Write(field)
+	return err
+}
+
+// Flush writes the buffered records
+func (w *Writer) Flush() error {
+	return w.w.Flush()
+}
+
+// Reader reads the records of a capture file
+type Reader struct {
+	r   *bufio.Reader
+	buf [8]byte
+}
+
+// NewReader checks the header of a capture file and returns a Reader
+func NewReader(r io.Reader) (*Reader, error) {
+	br := bufio.NewReader(r)
+	header := make([]byte, len(fileHeader))
+	if _, err := io.ReadFull(br, header); err != nil || string(header) != fileHeader {
+		return nil, fmt.Errorf("not a dogstatsd capture file")
+	}
+	return &Reader{r: br}, nil
+}
+
+// Next returns the next record, or io.EOF at the end of the file
+func (r *Reader) Next() (Record, error) {
+	var record Record
+	if _, err := io.ReadFull(r.r, r.buf[:8]); err != nil {
+		// a clean end of file happens between two records
+		return record, err
+	}
+	record.Timestamp = time.Unix(0, int64(binary.LittleEndian.Uint64(r.buf[:8])))
+
+	origin, err := r.readField()
+	if err != nil {
+		return record, err
+	}
+	record.Origin = string(origin)
+	record.Contents, err = r.readField()
+	return record, err
+}
+
+func (r *Reader) readField() ([]byte, error) {
+	if _, err := io.ReadFull(r.r, r.buf[:4]); err != nil {
+		return nil, truncated(err)
+	}
+	size := binary.LittleEndian.Uint32(r.buf[:4])
+	if size > maxRecordFieldSize {
+		return nil, fmt.Errorf("corrupted capture file: record of %d bytes", size)
+	}
+	field := make([]byte, size)
+	if _, err := io.ReadFull(r.r, field); err != nil {
+		return nil, truncated(err)
+	}
+	return field, nil
+}
+
+// truncated reports an end of file in the middle of a record
+func truncated(err error) error {
+	if err == io.EOF {
+		return io.ErrUnexpectedEOF
+	}
+	return err
+}
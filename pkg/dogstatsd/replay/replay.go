@@ -0,0 +1,45 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2018 Datadog, Inc.
+
+package replay
+
+import (
+	"io"
+	"time"
+)
+
+// Replay sends the contents of the records to w, one Write call per packet so
+// that each packet is sent as a single datagram. If realTime is set, the delays
+// between the packets of the capture are reproduced.
+// It returns the number of packets sent.
+func Replay(r *Reader, w io.Writer, realTime bool) (int, error) {
+	return replay(r, w, realTime, time.Sleep)
+}
+
+func replay(r *Reader, w io.Writer, realTime bool, sleep func(time.Duration)) (int, error) {
+	var previous time.Time
+	sent := 0
+	for {
+		record, err := r.Next()
+		if err == io.EOF {
+			return sent, nil
+		}
+		if err != nil {
+			return sent, err
+		}
+
+		if realTime && !previous.IsZero() {
+			if delay := record.Timestamp.Sub(previous); delay > 0 {
+				sleep(delay)
+			}
+		}
+		previous = record.Timestamp
+
+		if _, err := w.Write(record.Contents); err != nil {
+			return sent, err
+		}
+		sent++
+	}
+}
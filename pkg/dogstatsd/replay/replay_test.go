@@ -0,0 +1,101 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2018 Datadog, Inc.
+
+package replay
+
+import (
+	"bytes"
+	"io"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type packetRecorder struct {
+	packets []string
+}
+
+func (p *packetRecorder) Write(b []byte) (int, error) {
+	p.packets = append(p.packets, string(b))
+	return len(b), nil
+}
+
+func writeTestCapture(t *testing.T, records []Record) *bytes.Buffer {
+	var buf bytes.Buffer
+	w, err := NewWriter(&buf)
+	require.Nil(t, err)
+	for _, r := range records {
+		require.Nil(t, w.Write(r))
+	}
+	require.Nil(t, w.Flush())
+	return &buf
+}
+
+func TestWriteRead(t *testing.T) {
+	start := time.Unix(1500000000, 42)
+	records := []Record{
+		{Timestamp: start, Contents: []byte("foo:1|c\nbar:2|g")},
+		{Timestamp: start.Add(time.Second), Origin: "docker://abcdef", Contents: []byte("_e{5,4}:title|text")},
+		{Timestamp: start.Add(2 * time.Second), Contents: []byte{}},
+	}
+	buf := writeTestCapture(t, records)
+
+	r, err := NewReader(buf)
+	require.Nil(t, err)
+	for _, expected := range records {
+		record, err := r.Next()
+		require.Nil(t, err)
+		assert.True(t, expected.Timestamp.Equal(record.Timestamp))
+		assert.Equal(t, expected.Origin, record.Origin)
+		assert.Equal(t, expected.Contents, record.Contents)
+	}
+	_, err = r.Next()
+	assert.Equal(t, io.EOF, err)
+}
+
+func TestReadInvalid(t *testing.T) {
+	_, err := NewReader(bytes.NewBufferString("foo:1|c"))
+	assert.NotNil(t, err)
+
+	// truncated record
+	buf := writeTestCapture(t, []Record{{Timestamp: time.Now(), Contents: []byte("foo:1|c")}})
+	r, err := NewReader(bytes.NewReader(buf.Bytes()[:buf.Len()-2]))
+	require.Nil(t, err)
+	_, err = r.Next()
+	assert.Equal(t, io.ErrUnexpectedEOF, err)
+}
+
+func TestReplay(t *testing.T) {
+	start := time.Now()
+	buf := writeTestCapture(t, []Record{
+		{Timestamp: start, Contents: []byte("foo:1|c")},
+		{Timestamp: start.Add(3 * time.Second), Contents: []byte("bar:1|c")},
+		{Timestamp: start.Add(4 * time.Second), Contents: []byte("baz:1|c")},
+	})
+	captured := buf.Bytes()
+
+	var delays []time.Duration
+	sleep := func(d time.Duration) { delays = append(delays, d) }
+
+	r, err := NewReader(bytes.NewReader(captured))
+	require.Nil(t, err)
+	out := &packetRecorder{}
+	sent, err := replay(r, out, true, sleep)
+	require.Nil(t, err)
+	assert.Equal(t, 3, sent)
+	assert.Equal(t, []string{"foo:1|c", "bar:1|c", "baz:1|c"}, out.packets)
+	assert.Equal(t, []time.Duration{3 * time.Second, time.Second}, delays)
+
+	// as fast as possible
+	delays = nil
+	r, err = NewReader(bytes.NewReader(captured))
+	require.Nil(t, err)
+	sent, err = replay(r, &packetRecorder{}, false, sleep)
+	require.Nil(t, err)
+	assert.Equal(t, 3, sent)
+	assert.Empty(t, delays)
+}
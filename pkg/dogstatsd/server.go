@@ -7,16 +7,20 @@ package dogstatsd
 
 import (
 	"bytes"
+	"encoding/json"
 	"expvar"
 	"fmt"
 	"net"
 	"runtime"
 	"strings"
+	"sync"
+	"sync/atomic"
 
 	log "github.com/cihub/seelog"
 
 	"github.com/DataDog/datadog-agent/pkg/config"
 	"github.com/DataDog/datadog-agent/pkg/dogstatsd/listeners"
+	"github.com/DataDog/datadog-agent/pkg/dogstatsd/mapper"
 	"github.com/DataDog/datadog-agent/pkg/metrics"
 	"github.com/DataDog/datadog-agent/pkg/status/health"
 	"github.com/DataDog/datadog-agent/pkg/tagger"
@@ -30,13 +34,18 @@ var (
 // Server represent a Dogstatsd server
 type Server struct {
 	listeners    []listeners.StatsdListener
-	packetIn     chan *listeners.Packet
+	packetIn     chan listeners.Packets
 	Statistics   *util.Stats
+	statsEnabled uint32
+	statsMutex   sync.Mutex
 	Started      bool
 	packetPool   *listeners.PacketPool
 	stopChan     chan bool
 	health       *health.Handle
 	metricPrefix string
+	mapper       *mapper.MetricMapper
+	filter       *metricFilter
+	capture      capture
 }
 
 // NewServer returns a running Dogstatsd server
@@ -51,9 +60,18 @@ func NewServer(metricOut chan<- *metrics.MetricSample, eventOut chan<- metrics.E
 		stats = s
 	}
 
-	packetChannel := make(chan *listeners.Packet, 100)
+	metricMapper, err := newMetricMapper()
+	if err != nil {
+		return nil, err
+	}
+	filter, err := newMetricFilter(config.Datadog.GetStringSlice("dogstatsd_metric_blocklist"), config.Datadog.GetInt("dogstatsd_tag_cardinality_limit"))
+	if err != nil {
+		return nil, err
+	}
+
+	packetChannel := make(chan listeners.Packets, 100)
 	packetPool := listeners.NewPacketPool(config.Datadog.GetInt("dogstatsd_buffer_size"))
-	tmpListeners := make([]listeners.StatsdListener, 0, 2)
+	tmpListeners := make([]listeners.StatsdListener, 0, 3)
 
 	socketPath := config.Datadog.GetString("dogstatsd_socket")
 	if len(socketPath) > 0 {
@@ -64,6 +82,14 @@ func NewServer(metricOut chan<- *metrics.MetricSample, eventOut chan<- metrics.E
 			tmpListeners = append(tmpListeners, unixListener)
 		}
 	}
+	if len(config.Datadog.GetString("dogstatsd_pipe_name")) > 0 {
+		namedPipeListener, err := listeners.NewNamedPipeListener(packetChannel, packetPool)
+		if err != nil {
+			log.Errorf(err.Error())
+		} else {
+			tmpListeners = append(tmpListeners, namedPipeListener)
+		}
+	}
 	if config.Datadog.GetInt("dogstatsd_port") > 0 {
 		udpListener, err := listeners.NewUDPListener(packetChannel, packetPool)
 		if err != nil {
@@ -74,7 +100,7 @@ func NewServer(metricOut chan<- *metrics.MetricSample, eventOut chan<- metrics.E
 	}
 
 	if len(tmpListeners) == 0 {
-		return nil, fmt.Errorf("listening on neither udp nor socket, please check your configuration")
+		return nil, fmt.Errorf("listening on neither udp nor socket nor named pipe, please check your configuration")
 	}
 
 	// check configuration for custom namespace
@@ -86,12 +112,15 @@ func NewServer(metricOut chan<- *metrics.MetricSample, eventOut chan<- metrics.E
 	s := &Server{
 		Started:      true,
 		Statistics:   stats,
+		statsEnabled: boolToUint32(stats != nil),
 		packetIn:     packetChannel,
 		listeners:    tmpListeners,
 		packetPool:   packetPool,
 		stopChan:     make(chan bool),
 		health:       health.Register("dogstatsd-main"),
 		metricPrefix: metricPrefix,
+		mapper:       metricMapper,
+		filter:       filter,
 	}
 
 	forwardHost := config.Datadog.GetString("statsd_forward_host")
@@ -106,7 +135,7 @@ func NewServer(metricOut chan<- *metrics.MetricSample, eventOut chan<- metrics.E
 		if err != nil {
 			log.Warnf("Could not connect to statsd forward host : %s", err)
 		} else {
-			s.packetIn = make(chan *listeners.Packet, 100)
+			s.packetIn = make(chan listeners.Packets, 100)
 			go s.forwarder(con, packetChannel)
 		}
 	}
@@ -125,31 +154,40 @@ func (s *Server) handleMessages(metricOut chan<- *metrics.MetricSample, eventOut
 		go l.Listen()
 	}
 
-	// Run min(2, GoMaxProcs-2) workers, we dedicate a core to the
-	// listener goroutine and another to aggregator + forwarder
-	workers := runtime.GOMAXPROCS(-1) - 2
-	if workers < 2 {
-		workers = 2
+	// Run min(2, GoMaxProcs-2) workers by default, we dedicate a core to
+	// the listener goroutine and another to aggregator + forwarder
+	workers := config.Datadog.GetInt("dogstatsd_workers")
+	if workers <= 0 {
+		workers = runtime.GOMAXPROCS(-1) - 2
+		if workers < 2 {
+			workers = 2
+		}
 	}
 
 	for i := 0; i < workers; i++ {
 		go s.worker(metricOut, eventOut, serviceCheckOut)
 	}
+
+	if config.Datadog.GetBool("dogstatsd_telemetry_enabled") {
+		go s.submitTelemetry(metricOut)
+	}
 }
 
-func (s *Server) forwarder(fcon net.Conn, packetChannel chan *listeners.Packet) {
+func (s *Server) forwarder(fcon net.Conn, packetChannel chan listeners.Packets) {
 	for {
 		select {
 		case <-s.stopChan:
 			return
-		case packet := <-packetChannel:
-			_, err := fcon.Write(packet.Contents)
+		case packets := <-packetChannel:
+			for _, packet := range packets {
+				_, err := fcon.Write(packet.Contents)
 
-			if err != nil {
-				log.Warnf("Forwarding packet failed : %s", err)
+				if err != nil {
+					log.Warnf("Forwarding packet failed : %s", err)
+				}
 			}
 
-			s.packetIn <- packet
+			s.packetIn <- packets
 		}
 	}
 }
@@ -160,84 +198,180 @@ func (s *Server) worker(metricOut chan<- *metrics.MetricSample, eventOut chan<-
 		case <-s.stopChan:
 			return
 		case <-s.health.C:
-		case packet := <-s.packetIn:
-			var originTags []string
-
-			if packet.Origin != listeners.NoOrigin {
-				var err error
-				log.Tracef("Dogstatsd receive from %s: %s", packet.Origin, packet.Contents)
-				originTags, err = tagger.Tag(packet.Origin, tagger.IsFullCardinality())
-				if err != nil {
-					log.Errorf(err.Error())
-				}
-				log.Tracef("Tags for %s: %s", packet.Origin, originTags)
-			} else {
-				log.Tracef("Dogstatsd receive: %s", packet.Contents)
+		case packets := <-s.packetIn:
+			for _, packet := range packets {
+				s.processPacket(packet, metricOut, eventOut, serviceCheckOut)
+				// Return the packet object back to the object pool for reuse
+				s.packetPool.Put(packet)
 			}
+		}
+	}
+}
 
-			for {
-				message := nextMessage(&packet.Contents)
-				if message == nil {
-					break
-				}
+// processPacket parses the messages of a packet and sends them to the
+// aggregator with the tags of its origin
+func (s *Server) processPacket(packet *listeners.Packet, metricOut chan<- *metrics.MetricSample, eventOut chan<- metrics.Event, serviceCheckOut chan<- metrics.ServiceCheck) {
+	s.capturePacket(packet)
 
-				if s.Statistics != nil {
-					s.Statistics.StatEvent(1)
-				}
+	var originTags []string
 
-				if bytes.HasPrefix(message, []byte("_sc")) {
-					serviceCheck, err := parseServiceCheckMessage(message)
-					if err != nil {
-						log.Errorf("Dogstatsd: error parsing service check: %s", err)
-						dogstatsdExpvar.Add("ServiceCheckParseErrors", 1)
-						continue
-					}
-					if len(originTags) > 0 {
-						serviceCheck.Tags = append(serviceCheck.Tags, originTags...)
-					}
-					dogstatsdExpvar.Add("ServiceCheckPackets", 1)
-					serviceCheckOut <- *serviceCheck
-				} else if bytes.HasPrefix(message, []byte("_e")) {
-					event, err := parseEventMessage(message)
-					if err != nil {
-						log.Errorf("Dogstatsd: error parsing event: %s", err)
-						dogstatsdExpvar.Add("EventParseErrors", 1)
-						continue
-					}
-					if len(originTags) > 0 {
-						event.Tags = append(event.Tags, originTags...)
-					}
-					dogstatsdExpvar.Add("EventPackets", 1)
-					eventOut <- *event
-				} else {
-					sample, err := parseMetricMessage(message, s.metricPrefix)
-					if err != nil {
-						log.Errorf("Dogstatsd: error parsing metrics: %s", err)
-						dogstatsdExpvar.Add("MetricParseErrors", 1)
+	if packet.Origin != listeners.NoOrigin {
+		var err error
+		log.Tracef("Dogstatsd receive from %s: %s", packet.Origin, packet.Contents)
+		originTags, err = tagger.Tag(packet.Origin, tagger.IsFullCardinality())
+		if err != nil {
+			log.Errorf(err.Error())
+		}
+		log.Tracef("Tags for %s: %s", packet.Origin, originTags)
+	} else {
+		log.Tracef("Dogstatsd receive: %s", packet.Contents)
+	}
+
+	for {
+		message := nextMessage(&packet.Contents)
+		if message == nil {
+			break
+		}
+
+		if atomic.LoadUint32(&s.statsEnabled) == 1 {
+			s.Statistics.StatEvent(1)
+		}
+
+		if bytes.HasPrefix(message, []byte("_sc")) {
+			serviceCheck, err := parseServiceCheckMessage(message)
+			if err != nil {
+				log.Errorf("Dogstatsd: error parsing service check: %s", err)
+				dogstatsdExpvar.Add("ServiceCheckParseErrors", 1)
+				packet.CountParseError()
+				continue
+			}
+			if len(originTags) > 0 {
+				serviceCheck.Tags = append(serviceCheck.Tags, originTags...)
+			}
+			dogstatsdExpvar.Add("ServiceCheckPackets", 1)
+			serviceCheckOut <- *serviceCheck
+		} else if bytes.HasPrefix(message, []byte("_e")) {
+			event, err := parseEventMessage(message)
+			if err != nil {
+				log.Errorf("Dogstatsd: error parsing event: %s", err)
+				dogstatsdExpvar.Add("EventParseErrors", 1)
+				packet.CountParseError()
+				continue
+			}
+			if len(originTags) > 0 {
+				event.Tags = append(event.Tags, originTags...)
+			}
+			dogstatsdExpvar.Add("EventPackets", 1)
+			eventOut <- *event
+		} else {
+			samples, err := parseMetricMessages(message, s.metricPrefix)
+			if err != nil {
+				log.Errorf("Dogstatsd: error parsing metrics: %s", err)
+				dogstatsdExpvar.Add("MetricParseErrors", 1)
+				packet.CountParseError()
+				continue
+			}
+			for _, sample := range samples {
+				if s.mapper != nil {
+					s.mapMetric(sample)
+				}
+				if s.filter != nil {
+					if s.filter.blocked(sample.Name) {
+						dogstatsdExpvar.Add("MetricsBlocked", 1)
 						continue
 					}
-					if len(originTags) > 0 {
-						sample.Tags = append(sample.Tags, originTags...)
-					}
-					dogstatsdExpvar.Add("MetricPackets", 1)
-					metricOut <- sample
+					s.filter.limitTags(sample.Name, sample.Tags)
+				}
+				if len(originTags) > 0 {
+					sample.Tags = append(sample.Tags, originTags...)
 				}
+				dogstatsdExpvar.Add("MetricPackets", 1)
+				metricOut <- sample
 			}
-			// Return the packet object back to the object pool for reuse
-			s.packetPool.Put(packet)
 		}
 	}
 }
 
+// newMetricMapper returns the mapper of the profiles of
+// dogstatsd_mapper_profiles, nil when there is none
+func newMetricMapper() (*mapper.MetricMapper, error) {
+	var profiles []mapper.MappingProfile
+	var err error
+	// the profiles set with an environment variable are a JSON array
+	if raw, isString := config.Datadog.Get("dogstatsd_mapper_profiles").(string); isString {
+		if raw != "" {
+			err = json.Unmarshal([]byte(raw), &profiles)
+		}
+	} else {
+		err = config.Datadog.UnmarshalKey("dogstatsd_mapper_profiles", &profiles)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("could not parse dogstatsd_mapper_profiles: %s", err)
+	}
+	if len(profiles) == 0 {
+		return nil, nil
+	}
+	metricMapper, err := mapper.NewMetricMapper(profiles, config.Datadog.GetInt("dogstatsd_mapper_cache_size"))
+	if err != nil {
+		return nil, fmt.Errorf("dogstatsd misconfigured: %s", err)
+	}
+	return metricMapper, nil
+}
+
+// mapMetric renames a sample and adds the tags of the mapping matching its
+// name, the mappings match the names as sent, without the namespace
+func (s *Server) mapMetric(sample *metrics.MetricSample) {
+	result := s.mapper.Map(strings.TrimPrefix(sample.Name, s.metricPrefix))
+	if result == nil {
+		return
+	}
+	sample.Name = s.metricPrefix + result.Name
+	sample.Tags = append(sample.Tags, result.Tags...)
+}
+
 // Stop stops a running Dogstatsd server
 func (s *Server) Stop() {
 	close(s.stopChan)
 	for _, l := range s.listeners {
 		l.Stop()
 	}
+	s.stopCapture()
+	s.statsMutex.Lock()
 	if s.Statistics != nil {
 		s.Statistics.Stop()
 	}
+	s.statsMutex.Unlock()
 	s.health.Deregister()
 	s.Started = false
 }
+
+// StatsEnabled returns whether the server counts the packets it processes
+func (s *Server) StatsEnabled() bool {
+	return atomic.LoadUint32(&s.statsEnabled) == 1
+}
+
+// SetStatsEnabled starts or stops counting the processed packets. The
+// statistics are created on first use and kept when disabled, as their expvar
+// can only be published once.
+func (s *Server) SetStatsEnabled(enabled bool) error {
+	s.statsMutex.Lock()
+	defer s.statsMutex.Unlock()
+
+	if enabled && s.Statistics == nil {
+		stats, err := util.NewStats(uint32(config.Datadog.GetInt("dogstatsd_stats_buffer")))
+		if err != nil {
+			return fmt.Errorf("unable to start statistics facilities: %s", err)
+		}
+		s.Statistics = stats
+		go s.Statistics.Process()
+	}
+	atomic.StoreUint32(&s.statsEnabled, boolToUint32(enabled))
+	return nil
+}
+
+func boolToUint32(b bool) uint32 {
+	if b {
+		return 1
+	}
+	return 0
+}
@@ -16,6 +16,8 @@ import (
 	"github.com/stretchr/testify/require"
 
 	"github.com/DataDog/datadog-agent/pkg/config"
+	"github.com/DataDog/datadog-agent/pkg/dogstatsd/listeners"
+	"github.com/DataDog/datadog-agent/pkg/dogstatsd/mapper"
 	"github.com/DataDog/datadog-agent/pkg/metrics"
 )
 
@@ -244,3 +246,48 @@ func TestUDPForward(t *testing.T) {
 
 	assert.Equal(t, message, buffer)
 }
+
+func TestMapMetric(t *testing.T) {
+	metricMapper, err := mapper.NewMetricMapper([]mapper.MappingProfile{{
+		Name:   "airflow",
+		Prefix: "airflow.",
+		Mappings: []mapper.MetricMapping{{
+			Match: "airflow.job.*.duration",
+			Name:  "airflow.job.duration",
+			Tags:  map[string]string{"dag": "$1"},
+		}},
+	}}, 10)
+	require.NoError(t, err)
+	s := &Server{metricPrefix: "ns.", mapper: metricMapper}
+
+	sample, err := parseMetricMessage([]byte("airflow.job.my_dag.duration:12|ms|#env:prod"), s.metricPrefix)
+	require.NoError(t, err)
+	s.mapMetric(sample)
+	assert.Equal(t, "ns.airflow.job.duration", sample.Name)
+	assert.Equal(t, []string{"env:prod", "dag:my_dag"}, sample.Tags)
+
+	sample, err = parseMetricMessage([]byte("airflow.other:12|ms|#env:prod"), s.metricPrefix)
+	require.NoError(t, err)
+	s.mapMetric(sample)
+	assert.Equal(t, "ns.airflow.other", sample.Name)
+	assert.Equal(t, []string{"env:prod"}, sample.Tags)
+}
+
+func BenchmarkProcessPacket(b *testing.B) {
+	s := &Server{}
+	metricOut := make(chan *metrics.MetricSample, 100)
+	go func() {
+		for range metricOut {
+		}
+	}()
+	defer close(metricOut)
+	contents := []byte("daemon:666|g|#sometag1:somevalue1,sometag2:somevalue2\ndaemon:666|c|@0.5|#sometag1:somevalue1")
+	packet := &listeners.Packet{}
+
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		packet.Contents = contents
+		s.processPacket(packet, metricOut, nil, nil)
+	}
+}
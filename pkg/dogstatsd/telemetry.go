@@ -0,0 +1,89 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2018 Datadog, Inc.
+
+package dogstatsd
+
+import (
+	"sort"
+	"time"
+	"unicode"
+
+	"github.com/DataDog/datadog-agent/pkg/dogstatsd/listeners"
+	"github.com/DataDog/datadog-agent/pkg/metrics"
+)
+
+const (
+	// telemetryInterval is the interval between two submissions of the
+	// telemetry, the one of the buckets of the aggregator
+	telemetryInterval = 10 * time.Second
+	// telemetryMetricPrefix prefixes the names of the metrics of the telemetry
+	telemetryMetricPrefix = "datadog.dogstatsd."
+)
+
+// submitTelemetry submits the increase of the counters of the listeners
+// every telemetryInterval until the server stops
+func (s *Server) submitTelemetry(metricOut chan<- *metrics.MetricSample) {
+	ticker := time.NewTicker(telemetryInterval)
+	defer ticker.Stop()
+	previous := listeners.TransportStats()
+	for {
+		select {
+		case <-s.stopChan:
+			return
+		case <-ticker.C:
+			current := listeners.TransportStats()
+			for _, sample := range telemetrySamples(previous, current) {
+				metricOut <- sample
+			}
+			previous = current
+		}
+	}
+}
+
+// telemetrySamples returns the increase of the counters of the listeners as
+// `datadog.dogstatsd.*` counters tagged with their transport, e.g.
+// the ParseErrors of the UDP listener as datadog.dogstatsd.parse_errors
+// tagged with transport:udp
+func telemetrySamples(previous, current map[string]map[string]int64) []*metrics.MetricSample {
+	var samples []*metrics.MetricSample
+	for transport, counters := range current {
+		for name, value := range counters {
+			increase := value - previous[transport][name]
+			if increase < 0 {
+				continue
+			}
+			samples = append(samples, &metrics.MetricSample{
+				Name:       telemetryMetricPrefix + toSnakeCase(name),
+				Value:      float64(increase),
+				Mtype:      metrics.CounterType,
+				Tags:       []string{"transport:" + transport},
+				SampleRate: 1,
+			})
+		}
+	}
+	sort.Slice(samples, func(i, j int) bool {
+		if samples[i].Name != samples[j].Name {
+			return samples[i].Name < samples[j].Name
+		}
+		return samples[i].Tags[0] < samples[j].Tags[0]
+	})
+	return samples
+}
+
+// toSnakeCase converts the name of a counter, e.g. PacketsDropped to
+// packets_dropped
+func toSnakeCase(name string) string {
+	snake := make([]rune, 0, len(name)+4)
+	for i, r := range name {
+		if unicode.IsUpper(r) {
+			if i > 0 {
+				snake = append(snake, '_')
+			}
+			r = unicode.ToLower(r)
+		}
+		snake = append(snake, r)
+	}
+	return string(snake)
+}
@@ -0,0 +1,49 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2018 Datadog, Inc.
+
+package dogstatsd
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/DataDog/datadog-agent/pkg/metrics"
+)
+
+func TestTelemetrySamples(t *testing.T) {
+	previous := map[string]map[string]int64{
+		"udp": {"Packets": 10, "PacketsDropped": 2},
+	}
+	current := map[string]map[string]int64{
+		"udp": {"Packets": 15, "PacketsDropped": 2, "ParseErrors": 1},
+		"uds": {"Packets": 3},
+	}
+
+	samples := telemetrySamples(previous, current)
+	require.Len(t, samples, 4)
+	expected := []struct {
+		name      string
+		value     float64
+		transport string
+	}{
+		{"datadog.dogstatsd.packets", 5, "transport:udp"},
+		{"datadog.dogstatsd.packets", 3, "transport:uds"},
+		{"datadog.dogstatsd.packets_dropped", 0, "transport:udp"},
+		{"datadog.dogstatsd.parse_errors", 1, "transport:udp"},
+	}
+	for i, e := range expected {
+		assert.Equal(t, e.name, samples[i].Name)
+		assert.Equal(t, e.value, samples[i].Value)
+		assert.Equal(t, []string{e.transport}, samples[i].Tags)
+		assert.Equal(t, metrics.CounterType, samples[i].Mtype)
+	}
+}
+
+func TestToSnakeCase(t *testing.T) {
+	assert.Equal(t, "packets", toSnakeCase("Packets"))
+	assert.Equal(t, "packet_reading_errors", toSnakeCase("PacketReadingErrors"))
+}
@@ -15,6 +15,7 @@ import (
 	"io/ioutil"
 	"os"
 	"path/filepath"
+	"runtime/pprof"
 	"sort"
 	"strings"
 	"time"
@@ -33,18 +34,21 @@ import (
 // SearchPaths is just an alias for a map of strings
 type SearchPaths map[string]string
 
-// CreateArchive packages up the files
-func CreateArchive(local bool, distPath, pyChecksPath, logFilePath string) (string, error) {
+// CreateArchive packages up the files of the given sections, a nil
+// Sections packages up everything
+func CreateArchive(local bool, distPath, pyChecksPath, logFilePath string, sections Sections) (string, error) {
 	zipFilePath := getArchivePath()
 	confSearchPaths := SearchPaths{
 		"":        config.Datadog.GetString("confd_path"),
 		"dist":    filepath.Join(distPath, "conf.d"),
 		"checksd": pyChecksPath,
 	}
-	return createArchive(zipFilePath, local, confSearchPaths, logFilePath)
+	return createArchive(zipFilePath, local, confSearchPaths, logFilePath, sections)
 }
 
-func createArchive(zipFilePath string, local bool, confSearchPaths SearchPaths, logFilePath string) (string, error) {
+func createArchive(zipFilePath string, local bool, confSearchPaths SearchPaths, logFilePath string, sections Sections) (string, error) {
+	loadScrubbingRules()
+
 	b := make([]byte, 10)
 	_, err := rand.Read(b)
 	if err != nil {
@@ -81,62 +85,89 @@ func createArchive(zipFilePath string, local bool, confSearchPaths SearchPaths,
 	} else {
 		// Status informations will be unavailable unless the agent is running.
 		// Only zip them up if the agent is running
-		err = zipStatusFile(tempDir, hostname)
-		if err != nil {
-			log.Errorf("Could not zip status: %s", err)
+		if sections.has(SectionStatus) {
+			err = zipStatusFile(tempDir, hostname)
+			if err != nil {
+				log.Errorf("Could not zip status: %s", err)
+			}
 		}
 
-		err = zipConfigCheck(tempDir, hostname)
-		if err != nil {
-			log.Errorf("Could not zip config check: %s", err)
+		if sections.has(SectionConfigCheck) {
+			err = zipConfigCheck(tempDir, hostname)
+			if err != nil {
+				log.Errorf("Could not zip config check: %s", err)
+			}
+		}
+
+		// The goroutines are only relevant when dumped by the agent process
+		if sections.has(SectionGoroutines) {
+			err = zipGoroutines(tempDir, hostname)
+			if err != nil {
+				log.Errorf("Could not zip goroutines: %s", err)
+			}
 		}
 	}
 
-	err = zipConfigFiles(tempDir, hostname, confSearchPaths)
-	if err != nil {
-		log.Errorf("Could not zip config: %s", err)
+	if sections.has(SectionConfig) {
+		err = zipConfigFiles(tempDir, hostname, confSearchPaths)
+		if err != nil {
+			log.Errorf("Could not zip config: %s", err)
+		}
 	}
 
-	err = zipExpVar(tempDir, hostname)
-	if err != nil {
-		log.Errorf("Could not zip exp var: %s", err)
+	if sections.has(SectionExpvar) {
+		err = zipExpVar(tempDir, hostname)
+		if err != nil {
+			log.Errorf("Could not zip exp var: %s", err)
+		}
 	}
 
-	err = zipDiagnose(tempDir, hostname)
-	if err != nil {
-		log.Errorf("Could not zip diagnose: %s", err)
+	if sections.has(SectionDiagnose) {
+		err = zipDiagnose(tempDir, hostname)
+		if err != nil {
+			log.Errorf("Could not zip diagnose: %s", err)
+		}
 	}
 
-	err = zipEnvvars(tempDir, hostname)
-	if err != nil {
-		log.Errorf("Could not zip env vars: %s", err)
+	if sections.has(SectionEnvvars) {
+		err = zipEnvvars(tempDir, hostname)
+		if err != nil {
+			log.Errorf("Could not zip env vars: %s", err)
+		}
 	}
 
-	err = zipHealth(tempDir, hostname)
-	if err != nil {
-		log.Errorf("Could not zip health check: %s", err)
+	if sections.has(SectionHealth) {
+		err = zipHealth(tempDir, hostname)
+		if err != nil {
+			log.Errorf("Could not zip health check: %s", err)
+		}
 	}
 
-	if config.IsContainerized() {
+	if sections.has(SectionDocker) && config.IsContainerized() {
 		err = zipDockerSelfInspect(tempDir, hostname)
 		if err != nil {
 			log.Errorf("Could not zip docker inspect: %s", err)
 		}
 	}
 
-	err = zipTypeperfData(tempDir, hostname)
-	if err != nil {
-		log.Errorf("Could not write typeperf data: %s", err)
-	}
-	err = zipCounterStrings(tempDir, hostname)
-	if err != nil {
-		log.Errorf("Could not write counter strings: %s", err)
+	if sections.has(SectionPerf) {
+		err = zipTypeperfData(tempDir, hostname)
+		if err != nil {
+			log.Errorf("Could not write typeperf data: %s", err)
+		}
+		err = zipCounterStrings(tempDir, hostname)
+		if err != nil {
+			log.Errorf("Could not write counter strings: %s", err)
+		}
 	}
-	// force a log flush before zipping them
-	log.Flush()
-	err = zipLogFiles(tempDir, hostname, logFilePath)
-	if err != nil {
-		log.Errorf("Could not zip logs: %s", err)
+
+	if sections.has(SectionLogs) {
+		// force a log flush before zipping them
+		log.Flush()
+		err = zipLogFiles(tempDir, hostname, logFilePath)
+		if err != nil {
+			log.Errorf("Could not zip logs: %s", err)
+		}
 	}
 
 	err = archiver.Zip.Make(zipFilePath, []string{filepath.Join(tempDir, hostname)})
@@ -339,6 +370,24 @@ func zipConfigCheck(tempDir, hostname string) error {
 	return nil
 }
 
+func zipGoroutines(tempDir, hostname string) error {
+	var b bytes.Buffer
+
+	err := pprof.Lookup("goroutine").WriteTo(&b, 2)
+	if err != nil {
+		return err
+	}
+
+	f := filepath.Join(tempDir, hostname, "goroutines.log")
+
+	err = ensureParentDirsExist(f)
+	if err != nil {
+		return err
+	}
+
+	return ioutil.WriteFile(f, b.Bytes(), os.ModePerm)
+}
+
 func zipHealth(tempDir, hostname string) error {
 	s := health.GetStatus()
 	sort.Strings(s.Healthy)
@@ -0,0 +1,70 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2018 Datadog, Inc.
+
+// +build kubeapiserver
+
+package flare
+
+import (
+	"time"
+
+	"github.com/DataDog/datadog-agent/pkg/util/kubernetes/apiserver"
+)
+
+// apiserverLatencySamples is the number of requests timed to sample the
+// latency of the apiserver
+const apiserverLatencySamples = 5
+
+// troubleshootAPIServer connects to the apiserver, reviews the resources the
+// agent is allowed to list, and samples the latency of the apiserver
+func troubleshootAPIServer() map[string]interface{} {
+	result := make(map[string]interface{})
+
+	start := time.Now()
+	client, err := apiserver.GetAPIClient()
+	result["connection_time"] = time.Since(start).String()
+	if err != nil {
+		// with the strict RBAC, the forbidden resources fail the connection
+		result["error"] = err.Error()
+		return result
+	}
+	result["rbac"] = map[string]interface{}{
+		"permissions":     apiserver.GetResourcesPermissions(),
+		"metadata_mapper": apiserver.GetMetadataMapperStatus(),
+	}
+
+	latencies := make([]string, 0, apiserverLatencySamples)
+	for i := 0; i < apiserverLatencySamples; i++ {
+		start = time.Now()
+		if _, err = client.ComponentStatuses(); err != nil {
+			result["latency_error"] = err.Error()
+			break
+		}
+		latencies = append(latencies, time.Since(start).String())
+	}
+	result["latency_samples"] = latencies
+	return result
+}
+
+// troubleshootNodeMetadata maps the services of the cluster, like the
+// metadata mapper of the agent, and returns the bundle of the node
+func troubleshootNodeMetadata(nodeName string) map[string]interface{} {
+	result := make(map[string]interface{})
+	client, err := apiserver.GetAPIClient()
+	if err != nil {
+		result["error"] = err.Error()
+		return result
+	}
+	if err = client.ClusterMetadataMapping(); err != nil {
+		result["error"] = err.Error()
+		return result
+	}
+	bundle, err := apiserver.GetMetadataMapBundleOnNode(nodeName)
+	if err != nil {
+		result["error"] = err.Error()
+	}
+	result["bundle"] = bundle
+	return result
+}
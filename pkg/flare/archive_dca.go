@@ -33,6 +33,8 @@ func CreateDCAArchive(local bool, distPath, logFilePath string) (string, error)
 }
 
 func createDCAArchive(zipFilePath string, local bool, confSearchPaths SearchPaths, logFilePath string) (string, error) {
+	loadScrubbingRules()
+
 	b := make([]byte, 10)
 	_, err := rand.Read(b)
 	if err != nil {
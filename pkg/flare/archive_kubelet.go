@@ -0,0 +1,52 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2018 Datadog, Inc.
+
+// +build kubelet
+
+package flare
+
+import (
+	"time"
+
+	"github.com/DataDog/datadog-agent/pkg/util/kubernetes/kubelet"
+)
+
+// troubleshootKubelet checks the connection to the kubelet, and returns the
+// name of the node and of its pods
+func troubleshootKubelet() (map[string]interface{}, string, []string) {
+	result := make(map[string]interface{})
+
+	start := time.Now()
+	ku, err := kubelet.GetKubeUtil()
+	result["connection_time"] = time.Since(start).String()
+	if err != nil {
+		result["error"] = err.Error()
+		return result, "", nil
+	}
+	// the other connection settings hold credentials
+	connectionInfo := ku.GetRawConnectionInfo()
+	result["url"] = connectionInfo["url"]
+	result["verify_tls"] = connectionInfo["verify_tls"]
+
+	start = time.Now()
+	pods, err := ku.ForceGetLocalPodList()
+	result["pod_list_latency"] = time.Since(start).String()
+	if err != nil {
+		result["error"] = err.Error()
+		return result, "", nil
+	}
+	result["pods"] = len(pods)
+
+	var nodeName string
+	podNames := make([]string, 0, len(pods))
+	for _, pod := range pods {
+		if nodeName == "" && pod.Spec.NodeName != "" {
+			nodeName = pod.Spec.NodeName
+		}
+		podNames = append(podNames, pod.Metadata.Name)
+	}
+	result["node_name"] = nodeName
+	return result, nodeName, podNames
+}
@@ -0,0 +1,159 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2018 Datadog, Inc.
+
+package flare
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"time"
+
+	log "github.com/cihub/seelog"
+	"github.com/mholt/archiver"
+
+	"github.com/DataDog/datadog-agent/pkg/config"
+	"github.com/DataDog/datadog-agent/pkg/status"
+	"github.com/DataDog/datadog-agent/pkg/util"
+	"github.com/DataDog/datadog-agent/pkg/util/clusteragent"
+)
+
+// maxClusterAgentPods bounds the number of pods of the node whose metadata is
+// queried from the cluster agent
+const maxClusterAgentPods = 20
+
+// CreateKubernetesArchive checks the connections of the agent to the
+// Kubernetes components, and packages up the results in an archive for the
+// support: the connection to the kubelet, the RBAC review and the latency of
+// the apiserver, the metadata of the node, the connection to the cluster
+// agent and the state of the leader election.
+func CreateKubernetesArchive() (string, error) {
+	return createKubernetesArchive(getArchivePath())
+}
+
+func createKubernetesArchive(zipFilePath string) (string, error) {
+	loadScrubbingRules()
+
+	b := make([]byte, 10)
+	_, err := rand.Read(b)
+	if err != nil {
+		return "", err
+	}
+
+	dirName := hex.EncodeToString([]byte(b))
+	tempDir, err := ioutil.TempDir("", dirName)
+	if err != nil {
+		return "", err
+	}
+
+	defer os.RemoveAll(tempDir)
+
+	// Get hostname, if there's an error in getting the hostname,
+	// set the hostname to unknown
+	hostname, err := util.GetHostname()
+	if err != nil {
+		hostname = "unknown"
+	}
+
+	kubeletResult, nodeName, podNames := troubleshootKubelet()
+	results := map[string]map[string]interface{}{
+		"kubelet":   kubeletResult,
+		"apiserver": troubleshootAPIServer(),
+	}
+
+	if config.Datadog.GetBool("cluster_agent") {
+		// the node agent gets the metadata from the cluster agent
+		results["cluster_agent"] = troubleshootClusterAgent(nodeName, podNames)
+	} else if nodeName == "" {
+		results["node_metadata"] = map[string]interface{}{"error": "the name of the node is unknown, the kubelet is not available"}
+	} else {
+		results["node_metadata"] = troubleshootNodeMetadata(nodeName)
+	}
+
+	if config.Datadog.GetBool("leader_election") {
+		leaderElection := make(map[string]interface{})
+		for k, v := range status.GetLeaderElectionDetails() {
+			leaderElection[k] = v
+		}
+		results["leader_election"] = leaderElection
+	} else {
+		results["leader_election"] = map[string]interface{}{"status": "Disabled"}
+	}
+
+	for name, result := range results {
+		err = zipTroubleshootResult(tempDir, hostname, name, result)
+		if err != nil {
+			log.Errorf("Could not zip the %s troubleshooting: %s", name, err)
+		}
+	}
+
+	err = archiver.Zip.Make(zipFilePath, []string{filepath.Join(tempDir, hostname)})
+	if err != nil {
+		return "", err
+	}
+
+	return zipFilePath, nil
+}
+
+// troubleshootClusterAgent checks the connection to the cluster agent, and
+// gets the metadata of the pods of the node from it
+func troubleshootClusterAgent(nodeName string, podNames []string) map[string]interface{} {
+	result := make(map[string]interface{})
+
+	start := time.Now()
+	dca, err := clusteragent.GetClusterAgentClient()
+	result["connection_time"] = time.Since(start).String()
+	if err != nil {
+		result["error"] = err.Error()
+		return result
+	}
+	if nodeName == "" {
+		result["error"] = "the name of the node is unknown, the kubelet is not available"
+		return result
+	}
+
+	if len(podNames) > maxClusterAgentPods {
+		podNames = podNames[:maxClusterAgentPods]
+	}
+	metadata := make(map[string]interface{}, len(podNames))
+	latencies := make([]string, 0, len(podNames))
+	for _, podName := range podNames {
+		start = time.Now()
+		names, err := dca.GetKubernetesMetadataNames(nodeName, podName)
+		latencies = append(latencies, time.Since(start).String())
+		if err != nil {
+			metadata[podName] = fmt.Sprintf("error: %s", err)
+			continue
+		}
+		metadata[podName] = names
+	}
+	result["latency_samples"] = latencies
+	result["node_metadata"] = metadata
+	return result
+}
+
+func zipTroubleshootResult(tempDir, hostname, name string, result map[string]interface{}) error {
+	serialized, err := json.MarshalIndent(result, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	cleaned, err := credentialsCleanerBytes(serialized)
+	if err != nil {
+		return err
+	}
+
+	f := filepath.Join(tempDir, hostname, "kubernetes", name+".json")
+	err = ensureParentDirsExist(f)
+	if err != nil {
+		return err
+	}
+
+	return ioutil.WriteFile(f, cleaned, os.ModePerm)
+}
@@ -0,0 +1,20 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2018 Datadog, Inc.
+
+// +build !kubeapiserver
+
+package flare
+
+import (
+	"github.com/DataDog/datadog-agent/pkg/util/kubernetes/apiserver"
+)
+
+func troubleshootAPIServer() map[string]interface{} {
+	return map[string]interface{}{"error": apiserver.ErrNotCompiled.Error()}
+}
+
+func troubleshootNodeMetadata(nodeName string) map[string]interface{} {
+	return map[string]interface{}{"error": apiserver.ErrNotCompiled.Error()}
+}
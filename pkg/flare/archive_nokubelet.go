@@ -0,0 +1,12 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2018 Datadog, Inc.
+
+// +build !kubelet
+
+package flare
+
+func troubleshootKubelet() (map[string]interface{}, string, []string) {
+	return map[string]interface{}{"error": "kubelet support not compiled in"}, "", nil
+}
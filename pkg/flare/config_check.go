@@ -14,6 +14,7 @@ import (
 	"github.com/DataDog/datadog-agent/cmd/agent/api/response"
 	"github.com/DataDog/datadog-agent/pkg/api/util"
 	"github.com/DataDog/datadog-agent/pkg/config"
+	"github.com/DataDog/datadog-agent/pkg/util/output"
 	"github.com/fatih/color"
 )
 
@@ -22,30 +23,38 @@ var ConfigCheckURL = fmt.Sprintf("https://localhost:%v/agent/config-check", conf
 
 // GetConfigCheck dump all loaded configurations to the writer
 func GetConfigCheck(w io.Writer, withDebug bool) error {
-	if w != color.Output {
-		color.NoColor = true
+	payload, err := QueryConfigCheck(w)
+	if err != nil {
+		return err
 	}
+	return RenderConfigCheck(w, payload, withDebug)
+}
 
-	c := util.GetClient(false) // FIX: get certificates right then make this true
-
-	// Set session token
-	err := util.SetAuthToken()
+// WriteConfigCheck dumps all loaded configurations to the writer in the output
+// format, the wide format implies withDebug
+func WriteConfigCheck(w io.Writer, format string, withDebug bool) error {
+	writer, err := output.NewWriter(format, w)
 	if err != nil {
 		return err
 	}
-
-	r, err := util.DoGet(c, ConfigCheckURL)
+	payload, err := QueryConfigCheck(w)
 	if err != nil {
-		if r != nil && string(r) != "" {
-			fmt.Fprintln(w, fmt.Sprintf("The agent ran into an error while checking config: %s", string(r)))
-		} else {
-			fmt.Fprintln(w, fmt.Sprintf("Failed to query the agent (running?): %s", err))
-		}
+		return err
+	}
+	return writer.Write(payload, func(w io.Writer, payload []byte, wide bool) error {
+		return RenderConfigCheck(w, payload, withDebug || wide)
+	})
+}
+
+// RenderConfigCheck writes the loaded configurations returned by the agent API
+// in a human readable way
+func RenderConfigCheck(w io.Writer, payload []byte, withDebug bool) error {
+	if w != color.Output {
+		color.NoColor = true
 	}
 
 	cr := response.ConfigCheckResponse{}
-	err = json.Unmarshal(r, &cr)
-	if err != nil {
+	if err := json.Unmarshal(payload, &cr); err != nil {
 		return err
 	}
 
@@ -86,6 +95,9 @@ func GetConfigCheck(w io.Writer, withDebug bool) error {
 				fmt.Fprintln(w, fmt.Sprintf("* %s", color.CyanString(id)))
 			}
 		}
+		if len(c.Entity) > 0 {
+			fmt.Fprintln(w, fmt.Sprintf("%s: %s", color.BlueString("Matched service"), color.CyanString(c.Entity)))
+		}
 		fmt.Fprintln(w, "===")
 	}
 
@@ -111,3 +123,26 @@ func GetConfigCheck(w io.Writer, withDebug bool) error {
 
 	return nil
 }
+
+// QueryConfigCheck queries the running agent for its loaded configurations,
+// returned as a JSON payload. Errors are also reported to w.
+func QueryConfigCheck(w io.Writer) ([]byte, error) {
+	c := util.GetClient(false) // FIX: get certificates right then make this true
+
+	// Set session token
+	err := util.SetAuthToken()
+	if err != nil {
+		return nil, err
+	}
+
+	r, err := util.DoGet(c, ConfigCheckURL)
+	if err != nil {
+		if r != nil && string(r) != "" {
+			fmt.Fprintln(w, fmt.Sprintf("The agent ran into an error while checking config: %s", string(r)))
+		} else {
+			fmt.Fprintln(w, fmt.Sprintf("Failed to query the agent (running?): %s", err))
+		}
+		return nil, err
+	}
+	return r, nil
+}
@@ -0,0 +1,97 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2018 Datadog, Inc.
+
+package flare
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// Sections of a flare archive that can be included or excluded
+const (
+	SectionConfig      = "config"
+	SectionConfigCheck = "configcheck"
+	SectionDiagnose    = "diagnose"
+	SectionDocker      = "docker"
+	SectionEnvvars     = "envvars"
+	SectionExpvar      = "expvar"
+	SectionGoroutines  = "goroutines"
+	SectionHealth      = "health"
+	SectionLogs        = "logs"
+	SectionPerf        = "perf"
+	SectionStatus      = "status"
+)
+
+var allSections = []string{
+	SectionConfig,
+	SectionConfigCheck,
+	SectionDiagnose,
+	SectionDocker,
+	SectionEnvvars,
+	SectionExpvar,
+	SectionGoroutines,
+	SectionHealth,
+	SectionLogs,
+	SectionPerf,
+	SectionStatus,
+}
+
+// Sections is the set of sections collected in a flare archive,
+// a nil Sections collects everything
+type Sections map[string]bool
+
+// NewSections returns the sections to collect: all the included sections,
+// or all the sections when include is empty, minus the excluded ones.
+func NewSections(include, exclude []string) (Sections, error) {
+	if len(include) == 0 {
+		include = allSections
+	}
+	sections := make(Sections)
+	for _, name := range include {
+		if err := checkSection(name); err != nil {
+			return nil, err
+		}
+		sections[name] = true
+	}
+	for _, name := range exclude {
+		if err := checkSection(name); err != nil {
+			return nil, err
+		}
+		delete(sections, name)
+	}
+	if len(sections) == 0 {
+		return nil, fmt.Errorf("no flare section selected")
+	}
+	return sections, nil
+}
+
+func checkSection(name string) error {
+	for _, s := range allSections {
+		if s == name {
+			return nil
+		}
+	}
+	return fmt.Errorf("unknown flare section %q, valid sections are: %s", name, strings.Join(allSections, ", "))
+}
+
+// List returns the sorted names of the sections
+func (s Sections) List() []string {
+	if s == nil {
+		return append([]string{}, allSections...)
+	}
+	names := make([]string, 0, len(s))
+	for name := range s {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// has returns whether a section must be collected
+func (s Sections) has(name string) bool {
+	return s == nil || s[name]
+}
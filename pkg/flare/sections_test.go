@@ -0,0 +1,41 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2018 Datadog, Inc.
+
+package flare
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewSections(t *testing.T) {
+	sections, err := NewSections(nil, nil)
+	require.NoError(t, err)
+	assert.Equal(t, allSections, sections.List())
+
+	sections, err = NewSections(nil, []string{SectionLogs, SectionGoroutines})
+	require.NoError(t, err)
+	assert.True(t, sections.has(SectionConfig))
+	assert.False(t, sections.has(SectionLogs))
+	assert.False(t, sections.has(SectionGoroutines))
+
+	sections, err = NewSections([]string{SectionStatus, SectionConfig}, []string{SectionStatus})
+	require.NoError(t, err)
+	assert.Equal(t, []string{SectionConfig}, sections.List())
+
+	_, err = NewSections([]string{"foo"}, nil)
+	assert.Error(t, err)
+	_, err = NewSections(nil, []string{"foo"})
+	assert.Error(t, err)
+	_, err = NewSections([]string{SectionLogs}, []string{SectionLogs})
+	assert.Error(t, err)
+
+	// a nil Sections collects everything
+	var all Sections
+	assert.True(t, all.has(SectionLogs))
+	assert.Equal(t, allSections, all.List())
+}
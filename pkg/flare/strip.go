@@ -8,54 +8,58 @@ package flare
 import (
 	"bufio"
 	"bytes"
-	"fmt"
 	"io"
 	"os"
 	"regexp"
-)
+	"sync"
 
-type replacer struct {
-	regex    *regexp.Regexp
-	repl     []byte
-	replFunc func(b []byte) []byte
-}
+	log "github.com/cihub/seelog"
+
+	"github.com/DataDog/datadog-agent/pkg/config"
+	"github.com/DataDog/datadog-agent/pkg/util/scrubber"
+)
 
-var apiKeyReplacer, dockerAPIKeyReplacer, uriPasswordReplacer, passwordReplacer, tokenReplacer, snmpReplacer replacer
 var commentRegex = regexp.MustCompile(`^\s*#.*$`)
 var blankRegex = regexp.MustCompile(`^\s*$`)
 
-var replacers []replacer
+// customReplacers are built from the flare_scrubbing_rules setting
+var (
+	customReplacers   []scrubber.Replacer
+	customReplacersMu sync.RWMutex
+)
 
-func init() {
-	apiKeyReplacer := replacer{
-		regex: regexp.MustCompile(`[a-fA-F0-9]{27}([a-fA-F0-9]{5})`),
-		repl:  []byte(`***************************$1`),
-	}
-	uriPasswordReplacer = replacer{
-		regex: regexp.MustCompile(`\:\/\/([A-Za-z0-9_]+)\:(.+)\@`),
-		repl:  []byte(`://$1:********@`),
-	}
-	passwordReplacer = replacer{
-		regex: matchYAMLKeyPart(`pass(word)?`),
-		repl:  []byte(`$1 ********`),
-	}
-	tokenReplacer = replacer{
-		regex: matchYAMLKeyPart(`token`),
-		repl:  []byte(`$1 ********`),
-	}
-	snmpReplacer = replacer{
-		regex: matchYAMLKey(`(community_string|authKey|privKey)`),
-		repl:  []byte(`$1 ********`),
+// defaultScrubbingReplacement replaces the matches of a scrubbing rule
+// without replacement
+const defaultScrubbingReplacement = "********"
+
+// loadScrubbingRules builds the custom replacers from the configuration,
+// invalid rules are skipped
+func loadScrubbingRules() {
+	var rules []config.FlareScrubbingRule
+	if err := config.Datadog.UnmarshalKey("flare_scrubbing_rules", &rules); err != nil {
+		log.Errorf("Could not load the flare scrubbing rules: %s", err)
 	}
-	replacers = []replacer{apiKeyReplacer, uriPasswordReplacer, passwordReplacer, tokenReplacer, snmpReplacer}
+	setScrubbingRules(rules)
 }
 
-func matchYAMLKeyPart(part string) *regexp.Regexp {
-	return regexp.MustCompile(fmt.Sprintf(`(\s*(\w|_)*%s(\w|_)*\s*:).+`, part))
-}
+func setScrubbingRules(rules []config.FlareScrubbingRule) {
+	custom := make([]scrubber.Replacer, 0, len(rules))
+	for _, rule := range rules {
+		regex, err := regexp.Compile(rule.Pattern)
+		if err != nil {
+			log.Errorf("Invalid flare scrubbing rule %q: %s", rule.Name, err)
+			continue
+		}
+		repl := rule.Replacement
+		if repl == "" {
+			repl = defaultScrubbingReplacement
+		}
+		custom = append(custom, scrubber.Replacer{Regex: regex, Repl: []byte(repl)})
+	}
 
-func matchYAMLKey(key string) *regexp.Regexp {
-	return regexp.MustCompile(fmt.Sprintf(`(\s*%s\s*:).+`, key))
+	customReplacersMu.Lock()
+	defer customReplacersMu.Unlock()
+	customReplacers = custom
 }
 
 func credentialsCleanerFile(filePath string) ([]byte, error) {
@@ -75,18 +79,16 @@ func credentialsCleanerBytes(file []byte) ([]byte, error) {
 func credentialsCleaner(file io.Reader) ([]byte, error) {
 	var finalFile string
 
+	customReplacersMu.RLock()
+	allReplacers := append(append([]scrubber.Replacer{}, scrubber.DefaultReplacers...), customReplacers...)
+	customReplacersMu.RUnlock()
+
 	scanner := bufio.NewScanner(file)
 
 	for scanner.Scan() {
 		b := scanner.Bytes()
 		if !commentRegex.Match(b) && !blankRegex.Match(b) && string(b) != "" {
-			for _, repl := range replacers {
-				if repl.replFunc != nil {
-					b = repl.regex.ReplaceAllFunc(b, repl.replFunc)
-				} else {
-					b = repl.regex.ReplaceAll(b, repl.repl)
-				}
-			}
+			b = scrubber.Apply(b, allReplacers)
 			finalFile += string(b) + "\n"
 		}
 	}
@@ -12,6 +12,8 @@ import (
 	"testing"
 
 	"github.com/stretchr/testify/assert"
+
+	"github.com/DataDog/datadog-agent/pkg/config"
 )
 
 func TestConfigStripApiKey(t *testing.T) {
@@ -194,6 +196,26 @@ func TestSNMPConfig(t *testing.T) {
 		`   community_string: ********`)
 }
 
+func TestCustomScrubbingRules(t *testing.T) {
+	defer setScrubbingRules(nil)
+	setScrubbingRules([]config.FlareScrubbingRule{
+		{Name: "hosts", Pattern: `[a-z0-9-]+\.corp\.example\.com`, Replacement: "<internal_host>"},
+		{Name: "ssn", Pattern: `\d{3}-\d{2}-\d{4}`},
+		{Name: "invalid", Pattern: `(`},
+	})
+
+	assertClean(t,
+		`host: db-1.corp.example.com`,
+		`host: <internal_host>`)
+	assertClean(t,
+		`user_id: 123-45-6789`,
+		`user_id: ********`)
+	// built-in rules still apply
+	assertClean(t,
+		`password: db-1.corp.example.com`,
+		`password: ********`)
+}
+
 func assertClean(t *testing.T, contents, cleanContents string) {
 	cleaned, err := credentialsCleanerBytes([]byte(contents))
 	assert.Nil(t, err)
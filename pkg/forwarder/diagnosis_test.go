@@ -0,0 +1,65 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2018 Datadog, Inc.
+
+package forwarder
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/DataDog/datadog-agent/pkg/diagnose/diagnosis"
+)
+
+func TestCheckIntake(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, v1ValidateEndpoint, r.URL.Path)
+		switch r.URL.Query().Get("api_key") {
+		case "valid_key":
+			w.WriteHeader(http.StatusOK)
+		case "invalid_key":
+			w.WriteHeader(http.StatusForbidden)
+		default:
+			w.WriteHeader(http.StatusInternalServerError)
+		}
+	}))
+	defer ts.Close()
+
+	assert.NoError(t, checkIntake(ts.Client(), ts.URL, "valid_key"))
+
+	err := checkIntake(ts.Client(), ts.URL, "invalid_key")
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "_key was rejected")
+	assert.Contains(t, diagnosis.Remediation(err), "api_key")
+
+	err = checkIntake(ts.Client(), ts.URL, "other")
+	assert.Contains(t, err.Error(), "unexpected response code 500")
+	assert.NotEmpty(t, diagnosis.Remediation(err))
+}
+
+func TestCheckIntakeUnreachable(t *testing.T) {
+	ts := httptest.NewServer(http.NotFoundHandler())
+	ts.Close()
+
+	err := checkIntake(ts.Client(), ts.URL, "secret_api_key")
+	assert.Error(t, err)
+	assert.NotContains(t, err.Error(), "secret_api_key")
+	assert.Contains(t, diagnosis.Remediation(err), "proxy")
+}
+
+func TestCheckProxy(t *testing.T) {
+	ts := httptest.NewServer(http.NotFoundHandler())
+	defer ts.Close()
+	assert.NoError(t, checkProxy(ts.URL))
+
+	err := checkProxy("not a url")
+	assert.Error(t, err)
+	assert.NotEmpty(t, diagnosis.Remediation(err))
+
+	ts.Close()
+	assert.Error(t, checkProxy(ts.URL))
+}
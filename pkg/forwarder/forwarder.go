@@ -81,11 +81,46 @@ const (
 // Payloads is a slice of pointers to byte arrays, an alias for the slices of payloads we pass into the forwarder
 type Payloads []*[]byte
 
+// TransactionPriority defines the order in which the transactions of the
+// retry queue are sent back once the endpoints recover
+type TransactionPriority int
+
+const (
+	// TransactionPriorityLow is used for the metrics series and sketches,
+	// which are stale by the time they are retried
+	TransactionPriorityLow TransactionPriority = iota
+	// TransactionPriorityNormal is the default priority
+	TransactionPriorityNormal
+	// TransactionPriorityHigh is used for the events and service checks
+	TransactionPriorityHigh
+)
+
+// endpointPriorities gives the priority of the transactions per endpoint,
+// the endpoints not listed use TransactionPriorityNormal
+var endpointPriorities = map[string]TransactionPriority{
+	v1SeriesEndpoint:       TransactionPriorityLow,
+	seriesEndpoint:         TransactionPriorityLow,
+	v1SketchSeriesEndpoint: TransactionPriorityLow,
+	sketchSeriesEndpoint:   TransactionPriorityLow,
+	v1CheckRunsEndpoint:    TransactionPriorityHigh,
+	v1IntakeEndpoint:       TransactionPriorityHigh,
+	eventsEndpoint:         TransactionPriorityHigh,
+	serviceChecksEndpoint:  TransactionPriorityHigh,
+}
+
+func priorityOf(endpoint string) TransactionPriority {
+	if priority, found := endpointPriorities[endpoint]; found {
+		return priority
+	}
+	return TransactionPriorityNormal
+}
+
 // Transaction represents the task to process for a Worker.
 type Transaction interface {
 	Process(ctx context.Context, client *http.Client) error
 	GetCreatedAt() time.Time
 	GetTarget() string
+	GetPriority() TransactionPriority
 }
 
 // Forwarder implements basic interface - useful for testing
@@ -117,6 +152,7 @@ type DefaultForwarder struct {
 	m                   sync.Mutex // To control Start/Stop races
 	health              *health.Handle
 	retryQueueLimit     int
+	retryDrainRate      float64 // in transactions per second, 0 means unlimited
 
 	// NumberOfWorkers Number of concurrent HTTP request made by the DefaultForwarder (default 4).
 	NumberOfWorkers int
@@ -131,24 +167,49 @@ func NewDefaultForwarder(KeysPerDomains map[string][]string) *DefaultForwarder {
 		KeysPerDomains:  KeysPerDomains,
 		internalState:   Stopped,
 		retryQueueLimit: config.Datadog.GetInt("forwarder_retry_queue_max_size"),
+		retryDrainRate:  config.Datadog.GetFloat64("forwarder_retry_drain_rate"),
 	}
 }
 
-type byCreatedTime []Transaction
+// byPriority sorts the transactions by decreasing priority, then from the
+// newest to the oldest
+type byPriority []Transaction
+
+func (v byPriority) Len() int      { return len(v) }
+func (v byPriority) Swap(i, j int) { v[i], v[j] = v[j], v[i] }
+func (v byPriority) Less(i, j int) bool {
+	if pi, pj := v[i].GetPriority(), v[j].GetPriority(); pi != pj {
+		return pi > pj
+	}
+	return v[i].GetCreatedAt().After(v[j].GetCreatedAt())
+}
 
-func (v byCreatedTime) Len() int           { return len(v) }
-func (v byCreatedTime) Swap(i, j int)      { v[i], v[j] = v[j], v[i] }
-func (v byCreatedTime) Less(i, j int) bool { return v[i].GetCreatedAt().After(v[j].GetCreatedAt()) }
+// retryBudget returns the number of transactions that can be retried in a
+// flush interval, or -1 if the drain rate is unlimited
+func (f *DefaultForwarder) retryBudget() int {
+	if f.retryDrainRate <= 0 {
+		return -1
+	}
+	budget := int(f.retryDrainRate * flushInterval.Seconds())
+	if budget < 1 {
+		budget = 1
+	}
+	return budget
+}
 
 func (f *DefaultForwarder) retryTransactions(retryBefore time.Time) {
 	newQueue := []Transaction{}
 	droppedRetryQueueFull := 0
 	droppedWorkerBusy := 0
+	budget := f.retryBudget()
 
-	sort.Sort(byCreatedTime(f.retryQueue))
+	sort.Stable(byPriority(f.retryQueue))
 
 	for _, t := range f.retryQueue {
-		if !f.blockedList.isBlock(t.GetTarget()) {
+		// once the budget is spent, the remaining transactions stay in the
+		// queue until the next attempt
+		if budget != 0 && !f.blockedList.isBlock(t.GetTarget()) {
+			budget--
 			select {
 			case f.lowPrio <- t:
 				transactionsExpvar.Add("Retried", 1)
@@ -248,7 +309,9 @@ func (f *DefaultForwarder) State() uint32 {
 	return f.internalState
 }
 
-// Stop stops a DefaultForwarder, all transactions not yet flushed will be lost.
+// Stop stops a DefaultForwarder. The workers are given up to
+// `forwarder_stop_timeout` seconds to send the pending transactions, the
+// transactions not yet flushed after that will be lost.
 func (f *DefaultForwarder) Stop() {
 	// Lock so we can't start a DefaultForwarder while is stopping
 	f.m.Lock()
@@ -262,6 +325,7 @@ func (f *DefaultForwarder) Stop() {
 	f.health.Deregister()
 	f.internalState = Stopped
 
+	f.waitForEmptyQueues(config.Datadog.GetDuration("forwarder_stop_timeout") * time.Second)
 	f.stopRetry <- true
 	for _, w := range f.workers {
 		w.Stop()
@@ -275,6 +339,19 @@ func (f *DefaultForwarder) Stop() {
 	log.Info("DefaultForwarder stopped")
 }
 
+// waitForEmptyQueues waits until the workers picked all the queued
+// transactions, or until the timeout expires
+func (f *DefaultForwarder) waitForEmptyQueues(timeout time.Duration) {
+	deadline := time.Now().Add(timeout)
+	for len(f.highPrio)+len(f.lowPrio) > 0 {
+		if time.Now().After(deadline) {
+			log.Warnf("Stopping the forwarder with %d transactions still queued", len(f.highPrio)+len(f.lowPrio))
+			return
+		}
+		time.Sleep(50 * time.Millisecond)
+	}
+}
+
 func (f *DefaultForwarder) hasValidAPIKey(timeout time.Duration) (bool, error) {
 	// Since timeout is the maximum duration we can wait, we need to divide it
 	// by the total number of api keys to obtain the max duration for each key
@@ -358,6 +435,7 @@ func (f *DefaultForwarder) createHTTPTransactions(endpoint string, payloads Payl
 				t.Domain = domain
 				t.Endpoint = transactionEndpoint
 				t.Payload = payload
+				t.Priority = priorityOf(endpoint)
 				t.Headers.Set(apiHTTPHeaderKey, apiKey)
 				t.Headers.Set(versionHTTPHeaderKey, version.AgentVersion)
 
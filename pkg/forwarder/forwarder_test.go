@@ -76,6 +76,32 @@ func TestStop(t *testing.T) {
 	assert.Equal(t, Stopped, forwarder.State())
 }
 
+func TestWaitForEmptyQueues(t *testing.T) {
+	forwarder := NewDefaultForwarder(nil)
+	forwarder.init()
+
+	// empty queues don't wait
+	start := time.Now()
+	forwarder.waitForEmptyQueues(time.Second)
+	assert.True(t, time.Since(start) < 100*time.Millisecond)
+
+	// nothing picks the queued transaction, wait until the timeout
+	forwarder.lowPrio <- NewHTTPTransaction()
+	start = time.Now()
+	forwarder.waitForEmptyQueues(200 * time.Millisecond)
+	assert.True(t, time.Since(start) >= 200*time.Millisecond)
+
+	// the queue is emptied before the timeout
+	go func() {
+		time.Sleep(100 * time.Millisecond)
+		<-forwarder.lowPrio
+	}()
+	start = time.Now()
+	forwarder.waitForEmptyQueues(5 * time.Second)
+	assert.True(t, time.Since(start) < 5*time.Second)
+	assert.Len(t, forwarder.lowPrio, 0)
+}
+
 func TestSubmitIfStopped(t *testing.T) {
 	forwarder := NewDefaultForwarder(nil)
 
@@ -274,3 +300,70 @@ func TestForwarderRetryLimitQueue(t *testing.T) {
 	// assert that the oldest transaction was dropped
 	assert.Equal(t, transaction2, forwarder.retryQueue[0])
 }
+
+func TestTransactionPriority(t *testing.T) {
+	forwarder := NewDefaultForwarder(map[string][]string{"domain": {"key"}})
+	payloads := Payloads{&[]byte{}}
+
+	series := forwarder.createHTTPTransactions(seriesEndpoint, payloads, false, nil)
+	events := forwarder.createHTTPTransactions(eventsEndpoint, payloads, false, nil)
+	metadata := forwarder.createHTTPTransactions(hostMetadataEndpoint, payloads, false, nil)
+
+	assert.Equal(t, TransactionPriorityLow, series[0].GetPriority())
+	assert.Equal(t, TransactionPriorityHigh, events[0].GetPriority())
+	assert.Equal(t, TransactionPriorityNormal, metadata[0].GetPriority())
+}
+
+func TestForwarderRetryPriority(t *testing.T) {
+	forwarder := NewDefaultForwarder(nil)
+	forwarder.init()
+
+	series := newTestTransaction()
+	series.priority = TransactionPriorityLow
+	serviceCheck := newTestTransaction()
+	serviceCheck.priority = TransactionPriorityHigh
+
+	// the series is newer but has a lower priority
+	series.On("GetCreatedAt").Return(time.Now().Add(1 * time.Minute))
+	series.On("GetTarget").Return("")
+	serviceCheck.On("GetCreatedAt").Return(time.Now())
+	serviceCheck.On("GetTarget").Return("")
+
+	forwarder.requeueTransaction(series)
+	forwarder.requeueTransaction(serviceCheck)
+	forwarder.retryTransactions(time.Now())
+
+	assert.Equal(t, serviceCheck, <-forwarder.lowPrio)
+	assert.Equal(t, series, <-forwarder.lowPrio)
+	assert.Len(t, forwarder.retryQueue, 0)
+}
+
+func TestForwarderRetryDrainRate(t *testing.T) {
+	forwarder := NewDefaultForwarder(nil)
+	forwarder.init()
+	forwarder.retryQueueLimit = 10
+	forwarder.retryDrainRate = 0.2 // one transaction per flush interval
+
+	transaction1 := newTestTransaction()
+	transaction2 := newTestTransaction()
+
+	transaction1.On("GetCreatedAt").Return(time.Now())
+	transaction1.On("GetTarget").Return("")
+	transaction2.On("GetCreatedAt").Return(time.Now().Add(1 * time.Minute))
+	transaction2.On("GetTarget").Return("")
+
+	forwarder.requeueTransaction(transaction1)
+	forwarder.requeueTransaction(transaction2)
+
+	forwarder.retryTransactions(time.Now())
+	require.Len(t, forwarder.lowPrio, 1)
+	assert.Equal(t, transaction2, <-forwarder.lowPrio)
+	// the other one is kept for the next attempt
+	require.Len(t, forwarder.retryQueue, 1)
+	assert.Equal(t, transaction1, forwarder.retryQueue[0])
+
+	forwarder.retryTransactions(time.Now())
+	require.Len(t, forwarder.lowPrio, 1)
+	assert.Equal(t, transaction1, <-forwarder.lowPrio)
+	assert.Len(t, forwarder.retryQueue, 0)
+}
@@ -16,6 +16,7 @@ import (
 type testTransaction struct {
 	mock.Mock
 	processed chan bool
+	priority  TransactionPriority
 }
 
 func newTestTransaction() *testTransaction {
@@ -28,6 +29,12 @@ func (t *testTransaction) GetCreatedAt() time.Time {
 	return t.Called().Get(0).(time.Time)
 }
 
+// GetPriority is not mocked to not break the expectations of the tests
+// checking the calls done while sorting the retry queue
+func (t *testTransaction) GetPriority() TransactionPriority {
+	return t.priority
+}
+
 func (t *testTransaction) Process(ctx context.Context, client *http.Client) error {
 	defer func() { t.processed <- true }()
 	return t.Called(client).Error(0) // we ignore the context to ease mocking
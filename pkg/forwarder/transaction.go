@@ -30,6 +30,8 @@ type HTTPTransaction struct {
 	Payload *[]byte
 	// ErrorCount is the number of times this HTTPTransaction failed to be processed.
 	ErrorCount int
+	// Priority is the priority of the HTTPTransaction in the retry queue.
+	Priority TransactionPriority
 
 	createdAt time.Time
 }
@@ -39,6 +41,7 @@ func NewHTTPTransaction() *HTTPTransaction {
 	return &HTTPTransaction{
 		createdAt:  time.Now(),
 		ErrorCount: 0,
+		Priority:   TransactionPriorityNormal,
 		Headers:    make(http.Header),
 	}
 }
@@ -48,6 +51,11 @@ func (t *HTTPTransaction) GetCreatedAt() time.Time {
 	return t.createdAt
 }
 
+// GetPriority returns the priority of the HTTPTransaction in the retry queue.
+func (t *HTTPTransaction) GetPriority() TransactionPriority {
+	return t.Priority
+}
+
 // GetTarget return the url used by the transaction
 func (t *HTTPTransaction) GetTarget() string {
 	url := t.Domain + t.Endpoint
@@ -6,15 +6,24 @@
 package logs
 
 import (
+	"path/filepath"
+	"time"
+
 	"github.com/DataDog/datadog-agent/pkg/logs/auditor"
 	"github.com/DataDog/datadog-agent/pkg/logs/config"
 	"github.com/DataDog/datadog-agent/pkg/logs/input/container"
+	"github.com/DataDog/datadog-agent/pkg/logs/input/cri"
+	"github.com/DataDog/datadog-agent/pkg/logs/input/integration"
+	"github.com/DataDog/datadog-agent/pkg/logs/input/kubeaudit"
+	"github.com/DataDog/datadog-agent/pkg/logs/input/kubernetes"
 	"github.com/DataDog/datadog-agent/pkg/logs/input/listener"
 	"github.com/DataDog/datadog-agent/pkg/logs/input/tailer"
+	"github.com/DataDog/datadog-agent/pkg/logs/input/windowsevent"
 	"github.com/DataDog/datadog-agent/pkg/logs/message"
 	"github.com/DataDog/datadog-agent/pkg/logs/pipeline"
 	"github.com/DataDog/datadog-agent/pkg/logs/restart"
 	"github.com/DataDog/datadog-agent/pkg/logs/sender"
+	"github.com/DataDog/datadog-agent/pkg/util/scrubber"
 )
 
 // Agent represents the data pipeline that collects, decodes,
@@ -25,38 +34,80 @@ import (
 // |                                                        |
 // + ------------------------------------------------------ +
 type Agent struct {
-	auditor           *auditor.Auditor
-	containersScanner *container.Scanner
-	filesScanner      *tailer.Scanner
-	networkListener   *listener.Listener
-	pipelineProvider  pipeline.Provider
+	auditor             *auditor.Auditor
+	containersScanner   *container.Scanner
+	filesScanner        *tailer.Scanner
+	podsLauncher        *kubernetes.Launcher
+	criLauncher         *cri.Launcher
+	networkListener     *listener.Listener
+	auditWebhooks       *kubeaudit.Launcher
+	integrationReceiver *integration.Receiver
+	windowsEvents       *windowsevent.Launcher
+	pipelineProvider    pipeline.Provider
 }
 
-// NewAgent returns a new Agent
-func NewAgent(sources *config.LogSources) *Agent {
+// NewAgent returns a new Agent, the processingRules and the scrubbers are
+// applied to the logs of all the sources, which are sent to the
+// additionalEndpoints as well
+func NewAgent(sources *config.LogSources, processingRules []config.LogsProcessingRule, scrubbers []scrubber.Replacer, additionalEndpoints []config.Endpoint) *Agent {
 	// setup the auditor
 	messageChan := make(chan message.Message, config.ChanSize)
 	auditor := auditor.New(messageChan, config.LogsAgent.GetString("logs_config.run_path"))
 
 	// setup the pipeline provider that provides pairs of processor and sender
-	connectionManager := sender.NewConnectionManager(
-		config.LogsAgent.GetString("logs_config.dd_url"),
-		config.LogsAgent.GetInt("logs_config.dd_port"),
-		config.LogsAgent.GetBool("logs_config.dev_mode_no_ssl"),
-	)
-	pipelineProvider := pipeline.NewProvider(config.NumberOfPipelines, connectionManager, messageChan)
+	var connectionManager *sender.ConnectionManager
+	var httpDestination *sender.HTTPDestination
+	if config.LogsAgent.GetBool("logs_config.use_http") {
+		httpDestination = sender.NewHTTPDestination(
+			config.LogsAgent.GetString("logs_config.http_dd_url"),
+			config.LogsAgent.GetInt("logs_config.http_dd_port"),
+			!config.LogsAgent.GetBool("logs_config.dev_mode_no_ssl"),
+			config.LogsAgent.GetString("api_key"),
+			config.LogsAgent.GetBool("logs_config.use_compression"),
+		)
+	} else {
+		connectionManager = sender.NewConnectionManager(
+			config.LogsAgent.GetString("logs_config.dd_url"),
+			config.LogsAgent.GetInt("logs_config.dd_port"),
+			config.LogsAgent.GetBool("logs_config.dev_mode_no_ssl"),
+		)
+	}
+	// the messages are spilled to disk while the intake is unreachable
+	var diskBufferPath string
+	if config.LogsAgent.GetBool("logs_config.use_disk_buffer") {
+		diskBufferPath = config.LogsAgent.GetString("logs_config.disk_buffer_path")
+		if diskBufferPath == "" {
+			diskBufferPath = filepath.Join(config.LogsAgent.GetString("logs_config.run_path"), "buffer")
+		}
+	}
+	pipelineProvider := pipeline.NewProvider(config.NumberOfPipelines, connectionManager, httpDestination, additionalEndpoints, diskBufferPath, auditor, processingRules, scrubbers, messageChan)
 
 	// setup the collectors
 	containersScanner := container.New(sources.GetValidSources(), pipelineProvider, auditor)
 	networkListeners := listener.New(sources.GetValidSources(), pipelineProvider)
-	filesScanner := tailer.New(sources.GetValidSources(), config.LogsAgent.GetInt("logs_config.open_files_limit"), pipelineProvider, auditor, tailer.DefaultSleepDuration)
+	auditWebhooks := kubeaudit.New(sources.GetValidSources(), pipelineProvider)
+	integrationReceiver := integration.New(pipelineProvider)
+	windowsEvents := windowsevent.New(sources.GetValidSources(), pipelineProvider, auditor)
+	scanPeriod := time.Duration(config.LogsAgent.GetInt("logs_config.file_scan_period")) * time.Second
+	if scanPeriod <= 0 {
+		scanPeriod = tailer.DefaultScanPeriod
+	}
+	filesScanner := tailer.New(sources.GetValidSources(), config.LogsAgent.GetInt("logs_config.open_files_limit"), pipelineProvider, auditor, tailer.DefaultSleepDuration, scanPeriod)
+	// the logs files of the pods are tailed by the files scanner
+	podsLauncher := kubernetes.New(sources.GetValidSources(), filesScanner)
+	criLauncher := cri.New(sources.GetValidSources(), filesScanner)
 
 	return &Agent{
-		auditor:           auditor,
-		containersScanner: containersScanner,
-		filesScanner:      filesScanner,
-		networkListener:   networkListeners,
-		pipelineProvider:  pipelineProvider,
+		auditor:             auditor,
+		containersScanner:   containersScanner,
+		filesScanner:        filesScanner,
+		podsLauncher:        podsLauncher,
+		criLauncher:         criLauncher,
+		networkListener:     networkListeners,
+		auditWebhooks:       auditWebhooks,
+		integrationReceiver: integrationReceiver,
+		windowsEvents:       windowsEvents,
+		pipelineProvider:    pipelineProvider,
 	}
 }
 
@@ -67,7 +118,12 @@ func (a *Agent) Start() {
 		a.auditor,
 		a.pipelineProvider,
 		a.filesScanner,
+		a.podsLauncher,
+		a.criLauncher,
 		a.networkListener,
+		a.auditWebhooks,
+		a.integrationReceiver,
+		a.windowsEvents,
 		a.containersScanner,
 	)
 }
@@ -78,7 +134,12 @@ func (a *Agent) Stop() {
 	stopper := restart.NewSerialStopper(
 		restart.NewParallelStopper(
 			a.filesScanner,
+			a.podsLauncher,
+			a.criLauncher,
 			a.networkListener,
+			a.auditWebhooks,
+			a.integrationReceiver,
+			a.windowsEvents,
 			a.containersScanner,
 		),
 		a.pipelineProvider,
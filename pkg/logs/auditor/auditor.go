@@ -9,22 +9,38 @@ import (
 	"encoding/json"
 	"fmt"
 	"io/ioutil"
+	"os"
 	"path/filepath"
+	"strings"
 	"sync"
 	"time"
 
 	log "github.com/cihub/seelog"
 
 	"github.com/DataDog/datadog-agent/pkg/logs/message"
+	"github.com/DataDog/datadog-agent/pkg/status/health"
 )
 
 const defaultFlushPeriod = 1 * time.Second
 const defaultCleanupPeriod = 300 * time.Second
-const defaultTTL = 23 * time.Hour
+
+// DefaultTTL is the time after which the entries of the registry which are
+// not updated expire
+const DefaultTTL = 23 * time.Hour
 
 // latest version of the API used by the auditor to retrieve the registry from disk.
 const registryAPIVersion = 2
 
+// registryFileName is the name of the registry file, in the run path
+const registryFileName = "registry.json"
+
+// corruptedSuffix is appended to the name of a registry which can't be read,
+// it is kept aside for investigation and the auditor starts from scratch
+const corruptedSuffix = ".corrupted"
+
+// fileIdentifierPrefix prefixes the identifiers of the offsets of the files
+const fileIdentifierPrefix = "file:"
+
 // A RegistryEntry represents an entry in the registry where we keep track
 // of current offsets
 type RegistryEntry struct {
@@ -45,6 +61,7 @@ type Auditor struct {
 	registryPath string
 	mu           sync.Mutex
 	entryTTL     time.Duration
+	health       *health.Handle
 	done         chan struct{}
 }
 
@@ -52,8 +69,8 @@ type Auditor struct {
 func New(inputChan chan message.Message, runPath string) *Auditor {
 	return &Auditor{
 		inputChan:    inputChan,
-		registryPath: filepath.Join(runPath, "registry.json"),
-		entryTTL:     defaultTTL,
+		registryPath: RegistryPath(runPath),
+		entryTTL:     DefaultTTL,
 		done:         make(chan struct{}),
 	}
 }
@@ -62,6 +79,7 @@ func New(inputChan chan message.Message, runPath string) *Auditor {
 func (a *Auditor) Start() {
 	a.registry = a.recoverRegistry()
 	a.cleanupRegistry()
+	a.health = health.Register("logs-auditor")
 	go a.run()
 }
 
@@ -69,6 +87,7 @@ func (a *Auditor) Start() {
 func (a *Auditor) Stop() {
 	close(a.inputChan)
 	<-a.done
+	a.health.Deregister()
 	a.cleanupRegistry()
 	err := a.flushRegistry()
 	if err != nil {
@@ -107,6 +126,14 @@ func (a *Auditor) run() {
 			}
 			// update the registry with new entry
 			a.updateRegistry(msg.GetOrigin().Identifier, msg.GetOrigin().Offset)
+			// the messages replayed from a disk buffer have no source, they
+			// were counted when spilled
+			if source := msg.GetOrigin().LogSource; source != nil {
+				source.Metrics.AddMessagesSent(1)
+			}
+			// the message went through the whole pipeline, it can be reused
+			message.Release(msg)
+		case <-a.health.C:
 		case <-cleanUpTicker.C:
 			// remove expired offsets from registry
 			a.cleanupRegistry()
@@ -120,29 +147,37 @@ func (a *Auditor) run() {
 	}
 }
 
-// recoverRegistry rebuilds the registry from the state file found at path
+// recoverRegistry rebuilds the registry from the state file found at path,
+// a corrupted state file is moved aside not to be overwritten
 func (a *Auditor) recoverRegistry() map[string]*RegistryEntry {
 	mr, err := ioutil.ReadFile(a.registryPath)
 	if err != nil {
-		log.Error(err)
+		if !os.IsNotExist(err) {
+			log.Error(err)
+		}
 		return make(map[string]*RegistryEntry)
 	}
-	r, err := a.unmarshalRegistry(mr)
+	r, err := unmarshalRegistry(mr)
 	if err != nil {
-		log.Error(err)
+		corruptedPath := a.registryPath + corruptedSuffix
+		log.Errorf("The registry %s is corrupted, the logs are tailed from scratch and it is moved to %s: %v", a.registryPath, corruptedPath, err)
+		if err := os.Rename(a.registryPath, corruptedPath); err != nil {
+			log.Warn(err)
+		}
 		return make(map[string]*RegistryEntry)
 	}
 	return r
 }
 
-// cleanupRegistry removes expired entries from the registry
+// cleanupRegistry removes from the registry the expired entries and the
+// ones of the files which no longer exist
 func (a *Auditor) cleanupRegistry() {
 	a.mu.Lock()
 	defer a.mu.Unlock()
 	expireBefore := time.Now().UTC().Add(-a.entryTTL)
-	for path, entry := range a.registry {
-		if entry.LastUpdated.Before(expireBefore) {
-			delete(a.registry, path)
+	for identifier, entry := range a.registry {
+		if isPrunable(identifier, *entry, expireBefore) {
+			delete(a.registry, identifier)
 		}
 	}
 }
@@ -176,16 +211,11 @@ func (a *Auditor) readOnlyRegistryCopy() map[string]RegistryEntry {
 
 // flushRegistry writes on disk the registry at the given path
 func (a *Auditor) flushRegistry() error {
-	r := a.readOnlyRegistryCopy()
-	mr, err := a.marshalRegistry(r)
-	if err != nil {
-		return err
-	}
-	return ioutil.WriteFile(a.registryPath, mr, 0644)
+	return WriteRegistry(a.registryPath, a.readOnlyRegistryCopy())
 }
 
-// marshalRegistry marshals a registry
-func (a *Auditor) marshalRegistry(registry map[string]RegistryEntry) ([]byte, error) {
+// MarshalRegistry marshals a registry in the latest format
+func MarshalRegistry(registry map[string]RegistryEntry) ([]byte, error) {
 	r := JSONRegistry{
 		Version:  registryAPIVersion,
 		Registry: registry,
@@ -194,7 +224,7 @@ func (a *Auditor) marshalRegistry(registry map[string]RegistryEntry) ([]byte, er
 }
 
 // unmarshalRegistry unmarshals a registry
-func (a *Auditor) unmarshalRegistry(b []byte) (map[string]*RegistryEntry, error) {
+func unmarshalRegistry(b []byte) (map[string]*RegistryEntry, error) {
 	var r map[string]interface{}
 	err := json.Unmarshal(b, &r)
 	if err != nil {
@@ -216,3 +246,87 @@ func (a *Auditor) unmarshalRegistry(b []byte) (map[string]*RegistryEntry, error)
 		return nil, fmt.Errorf("invalid registry version number")
 	}
 }
+
+// ReadRegistry returns the registry stored at path, in any of the formats
+// the auditor can recover
+func ReadRegistry(path string) (map[string]RegistryEntry, error) {
+	b, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	r, err := unmarshalRegistry(b)
+	if err != nil {
+		return nil, fmt.Errorf("invalid registry %s: %v", path, err)
+	}
+	registry := make(map[string]RegistryEntry, len(r))
+	for identifier, entry := range r {
+		registry[identifier] = *entry
+	}
+	return registry, nil
+}
+
+// WriteRegistry writes a registry in the latest format at path, atomically:
+// it is written to a temporary file first, then renamed, so that the
+// registry is never left half written when the agent is killed or the disk
+// is full
+func WriteRegistry(path string, registry map[string]RegistryEntry) error {
+	mr, err := MarshalRegistry(registry)
+	if err != nil {
+		return err
+	}
+	f, err := ioutil.TempFile(filepath.Dir(path), filepath.Base(path)+".tmp")
+	if err != nil {
+		return err
+	}
+	tmpPath := f.Name()
+	_, err = f.Write(mr)
+	if err == nil {
+		err = f.Sync()
+	}
+	if closeErr := f.Close(); err == nil {
+		err = closeErr
+	}
+	if err == nil {
+		err = os.Chmod(tmpPath, 0644)
+	}
+	if err == nil {
+		err = os.Rename(tmpPath, path)
+	}
+	if err != nil {
+		os.Remove(tmpPath)
+		return err
+	}
+	return nil
+}
+
+// PruneRegistry removes from a registry the entries last updated before
+// expireBefore and the ones of the files which no longer exist, it returns
+// the identifiers of the entries removed
+func PruneRegistry(registry map[string]RegistryEntry, expireBefore time.Time) []string {
+	var pruned []string
+	for identifier, entry := range registry {
+		if isPrunable(identifier, entry, expireBefore) {
+			delete(registry, identifier)
+			pruned = append(pruned, identifier)
+		}
+	}
+	return pruned
+}
+
+// isPrunable returns true if the entry has expired or if it is the one of
+// a file which no longer exists
+func isPrunable(identifier string, entry RegistryEntry, expireBefore time.Time) bool {
+	if entry.LastUpdated.Before(expireBefore) {
+		return true
+	}
+	if strings.HasPrefix(identifier, fileIdentifierPrefix) {
+		_, err := os.Stat(strings.TrimPrefix(identifier, fileIdentifierPrefix))
+		return os.IsNotExist(err)
+	}
+	return false
+}
+
+// RegistryPath returns the path of the registry of the auditor using runPath
+func RegistryPath(runPath string) string {
+	return filepath.Join(runPath, registryFileName)
+}
@@ -9,11 +9,13 @@ import (
 	"fmt"
 	"io/ioutil"
 	"os"
+	"sort"
 	"testing"
 	"time"
 
 	"github.com/DataDog/datadog-agent/pkg/logs/config"
 	"github.com/DataDog/datadog-agent/pkg/logs/message"
+	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/suite"
 )
 
@@ -76,6 +78,26 @@ func (suite *AuditorTestSuite) TestAuditorFlushesAndRecoversRegistry() {
 	suite.Equal("42", suite.a.registry[suite.source.Config.Path].Offset)
 }
 
+func (suite *AuditorTestSuite) TestWriteAndReadRegistry() {
+	registry := map[string]RegistryEntry{
+		"file:/var/log/app.log": {LastUpdated: time.Date(2006, time.January, 12, 1, 1, 1, 1, time.UTC), Offset: "42"},
+	}
+	suite.Nil(WriteRegistry(suite.testPath, registry))
+	r, err := ReadRegistry(suite.testPath)
+	suite.Nil(err)
+	suite.Equal(registry, r)
+
+	// the registries of the previous versions can be read as well
+	suite.Nil(ioutil.WriteFile(suite.testPath, []byte(`{"Version":0,"Registry":{"testpath":{"Path":"testpath","Timestamp":"2006-01-12T01:01:01.000000001Z","Offset":42}}}`), 0644))
+	r, err = ReadRegistry(suite.testPath)
+	suite.Nil(err)
+	suite.Equal("42", r["file:testpath"].Offset)
+
+	suite.Nil(ioutil.WriteFile(suite.testPath, []byte("{}"), 0644))
+	_, err = ReadRegistry(suite.testPath)
+	suite.NotNil(err)
+}
+
 func (suite *AuditorTestSuite) TestAuditorRecoversRegistryForOffset() {
 	suite.a.registry = make(map[string]*RegistryEntry)
 	suite.a.registry[suite.source.Config.Path] = &RegistryEntry{
@@ -110,6 +132,52 @@ func (suite *AuditorTestSuite) TestAuditorCleansupRegistry() {
 	suite.Equal("43", suite.a.registry[otherpath].Offset)
 }
 
+func (suite *AuditorTestSuite) TestAuditorMovesCorruptedRegistryAside() {
+	suite.Nil(ioutil.WriteFile(suite.testPath, []byte(`{"Version":2,"Regis`), 0644))
+	defer os.Remove(suite.testPath + corruptedSuffix)
+
+	suite.Equal(0, len(suite.a.recoverRegistry()))
+	_, err := os.Stat(suite.testPath)
+	suite.True(os.IsNotExist(err))
+	_, err = os.Stat(suite.testPath + corruptedSuffix)
+	suite.Nil(err)
+}
+
+func (suite *AuditorTestSuite) TestAuditorPrunesMissingFiles() {
+	suite.a.registry = make(map[string]*RegistryEntry)
+	suite.a.registry["file:"+suite.testPath] = &RegistryEntry{LastUpdated: time.Now().UTC(), Offset: "42"}
+	suite.a.registry["file:"+suite.testDir+"/missing.log"] = &RegistryEntry{LastUpdated: time.Now().UTC(), Offset: "43"}
+	suite.a.registry["docker:abc"] = &RegistryEntry{LastUpdated: time.Now().UTC(), Offset: "44"}
+
+	suite.a.cleanupRegistry()
+	suite.Equal(2, len(suite.a.registry))
+	suite.Equal("42", suite.a.registry["file:"+suite.testPath].Offset)
+	suite.Equal("44", suite.a.registry["docker:abc"].Offset)
+}
+
+func (suite *AuditorTestSuite) TestWriteRegistryIsAtomic() {
+	suite.Nil(WriteRegistry(suite.testPath, map[string]RegistryEntry{"testpath": {Offset: "42"}}))
+	files, err := ioutil.ReadDir(suite.testDir)
+	suite.Nil(err)
+	for _, file := range files {
+		// no temporary file is left behind
+		suite.Equal("auditor.json", file.Name())
+	}
+}
+
+func TestPruneRegistry(t *testing.T) {
+	registry := map[string]RegistryEntry{
+		"expired":              {LastUpdated: time.Date(2006, time.January, 12, 1, 1, 1, 1, time.UTC), Offset: "42"},
+		"file:/does/not/exist": {LastUpdated: time.Now().UTC(), Offset: "43"},
+		"docker:abc":           {LastUpdated: time.Now().UTC(), Offset: "44"},
+	}
+	pruned := PruneRegistry(registry, time.Now().UTC().Add(-DefaultTTL))
+	sort.Strings(pruned)
+	assert.Equal(t, []string{"expired", "file:/does/not/exist"}, pruned)
+	assert.Equal(t, 1, len(registry))
+	assert.Equal(t, "44", registry["docker:abc"].Offset)
+}
+
 func TestScannerTestSuite(t *testing.T) {
 	suite.Run(t, new(AuditorTestSuite))
 }
@@ -6,7 +6,9 @@
 package config
 
 import (
+	"encoding/json"
 	"fmt"
+
 	"github.com/DataDog/datadog-agent/pkg/config"
 )
 
@@ -22,6 +24,66 @@ func Build() (*LogSources, error) {
 	return sources, nil
 }
 
+// GlobalProcessingRules returns the processing rules of logs_config, applied
+// to the logs of all the sources before their own rules
+func GlobalProcessingRules() ([]LogsProcessingRule, error) {
+	var rules []LogsProcessingRule
+	var err error
+	// the rules set with an environment variable are a JSON array
+	if raw, isString := LogsAgent.Get("logs_config.processing_rules").(string); isString {
+		if raw != "" {
+			err = json.Unmarshal([]byte(raw), &rules)
+		}
+	} else {
+		err = LogsAgent.UnmarshalKey("logs_config.processing_rules", &rules)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("could not parse the global log processing rules: %s", err)
+	}
+	for _, rule := range rules {
+		if rule.Type == MultiLine {
+			return nil, fmt.Errorf("LogsAgent misconfigured: type %s is unsupported for global log processing rule `%s`", rule.Type, rule.Name)
+		}
+	}
+	return validateProcessingRules(rules)
+}
+
+// Endpoint is an intake the logs are sent to along with the main one, with
+// its own API key, over the same protocol, on the port of the main intake
+// when Port is not set
+type Endpoint struct {
+	APIKey string `mapstructure:"api_key" json:"api_key"`
+	Host   string `mapstructure:"host" json:"host"`
+	Port   int    `mapstructure:"port" json:"port"`
+}
+
+// AdditionalEndpoints returns the endpoints of logs_config, the logs are
+// sent to each of them as well as to the main intake
+func AdditionalEndpoints() ([]Endpoint, error) {
+	var endpoints []Endpoint
+	var err error
+	// the endpoints set with an environment variable are a JSON array
+	if raw, isString := LogsAgent.Get("logs_config.additional_endpoints").(string); isString {
+		if raw != "" {
+			err = json.Unmarshal([]byte(raw), &endpoints)
+		}
+	} else {
+		err = LogsAgent.UnmarshalKey("logs_config.additional_endpoints", &endpoints)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("could not parse the additional logs endpoints: %s", err)
+	}
+	for _, endpoint := range endpoints {
+		if endpoint.Host == "" || endpoint.APIKey == "" {
+			return nil, fmt.Errorf("LogsAgent misconfigured: host and api_key must be set for all additional endpoints")
+		}
+		if endpoint.Port < 0 {
+			return nil, fmt.Errorf("LogsAgent misconfigured: invalid port %d for additional endpoint %s", endpoint.Port, endpoint.Host)
+		}
+	}
+	return endpoints, nil
+}
+
 // buildLogSources returns all the logs sources computed from logs configuration files and environment variables
 func buildLogSources(ddconfdPath string, collectAllLogsFromContainers bool) (*LogSources, error) {
 	var sources []*LogSource
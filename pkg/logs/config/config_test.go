@@ -10,6 +10,8 @@ import (
 	"testing"
 
 	"github.com/stretchr/testify/assert"
+
+	"github.com/DataDog/datadog-agent/pkg/util/scrubber"
 )
 
 func TestDefaultDatadogConfig(t *testing.T) {
@@ -54,3 +56,108 @@ func TestBuildLogsSources(t *testing.T) {
 	assert.Equal(t, "docker", source.Config.Service)
 	assert.Equal(t, "docker", source.Config.Source)
 }
+
+func TestGlobalProcessingRules(t *testing.T) {
+	defer LogsAgent.Set("logs_config.processing_rules", []map[string]interface{}{})
+
+	rules, err := GlobalProcessingRules()
+	assert.Nil(t, err)
+	assert.Len(t, rules, 0)
+
+	LogsAgent.Set("logs_config.processing_rules", []map[string]interface{}{{
+		"type":                "mask_sequences",
+		"name":                "mask_passwords",
+		"replace_placeholder": "[password]",
+		"pattern":             "password=\\w+",
+	}})
+	rules, err = GlobalProcessingRules()
+	assert.Nil(t, err)
+	assert.Len(t, rules, 1)
+	assert.Equal(t, []byte("[password]"), rules[0].ReplacePlaceholderBytes)
+	assert.True(t, rules[0].Reg.MatchString("password=secret"))
+
+	// the rules set with an environment variable are a JSON array
+	LogsAgent.Set("logs_config.processing_rules", `[{"type":"exclude_at_match","name":"exclude_debug","pattern":"DEBUG"}]`)
+	rules, err = GlobalProcessingRules()
+	assert.Nil(t, err)
+	assert.Len(t, rules, 1)
+	assert.Equal(t, ExcludeAtMatch, rules[0].Type)
+
+	LogsAgent.Set("logs_config.processing_rules", []map[string]interface{}{{"type": "multi_line", "name": "multi", "pattern": "\\d"}})
+	_, err = GlobalProcessingRules()
+	assert.NotNil(t, err)
+
+	LogsAgent.Set("logs_config.processing_rules", []map[string]interface{}{{"type": "exclude_at_match", "name": "invalid", "pattern": "("}})
+	_, err = GlobalProcessingRules()
+	assert.NotNil(t, err)
+}
+
+func TestScrubbingReplacers(t *testing.T) {
+	defer LogsAgent.Set("logs_config.scrubbing_patterns", []string{})
+	defer LogsAgent.Set("logs_config.scrubbing_rules", []map[string]interface{}{})
+
+	replacers, err := ScrubbingReplacers()
+	assert.Nil(t, err)
+	assert.Len(t, replacers, 0)
+
+	LogsAgent.Set("logs_config.scrubbing_patterns", []string{CreditCardsPattern, APIKeysPattern, EmailsPattern})
+	replacers, err = ScrubbingReplacers()
+	assert.Nil(t, err)
+	assert.Equal(t, "card=[credit_card] key=***************************abcde app_key=***********************************fedcb from=[email]",
+		string(scrubber.Apply([]byte("card=4111-1111-1111-1111 key=aaaaaaaaaaaaaaaaaaaaaaaaaaaabcde app_key=aaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaafedcb from=john.doe@example.com"), replacers)))
+	assert.Equal(t, "order 1234 shipped", string(scrubber.Apply([]byte("order 1234 shipped"), replacers)))
+
+	LogsAgent.Set("logs_config.scrubbing_patterns", []string{"ssn"})
+	_, err = ScrubbingReplacers()
+	assert.NotNil(t, err)
+	LogsAgent.Set("logs_config.scrubbing_patterns", []string{})
+
+	LogsAgent.Set("logs_config.scrubbing_rules", []map[string]interface{}{{
+		"name":        "mask_passwords",
+		"pattern":     "(password=)\\w+",
+		"replacement": "${1}[password]",
+	}, {
+		"name":    "mask_tokens",
+		"pattern": "token=\\w+",
+	}})
+	replacers, err = ScrubbingReplacers()
+	assert.Nil(t, err)
+	assert.Equal(t, "password=[password] ********", string(scrubber.Apply([]byte("password=secret token=abc"), replacers)))
+
+	// the rules set with an environment variable are a JSON array
+	LogsAgent.Set("logs_config.scrubbing_rules", `[{"name":"mask_ips","pattern":"\\d+\\.\\d+\\.\\d+\\.\\d+","replacement":"[ip]"}]`)
+	replacers, err = ScrubbingReplacers()
+	assert.Nil(t, err)
+	assert.Equal(t, "from [ip]", string(scrubber.Apply([]byte("from 10.0.0.1"), replacers)))
+
+	LogsAgent.Set("logs_config.scrubbing_rules", []map[string]interface{}{{"name": "invalid", "pattern": "("}})
+	_, err = ScrubbingReplacers()
+	assert.NotNil(t, err)
+}
+
+func TestAdditionalEndpoints(t *testing.T) {
+	defer LogsAgent.Set("logs_config.additional_endpoints", []map[string]interface{}{})
+
+	endpoints, err := AdditionalEndpoints()
+	assert.Nil(t, err)
+	assert.Len(t, endpoints, 0)
+
+	LogsAgent.Set("logs_config.additional_endpoints", []map[string]interface{}{{
+		"api_key": "anotherkey",
+		"host":    "agent-intake.logs.datadoghq.eu",
+		"port":    443,
+	}})
+	endpoints, err = AdditionalEndpoints()
+	assert.Nil(t, err)
+	assert.Equal(t, []Endpoint{{APIKey: "anotherkey", Host: "agent-intake.logs.datadoghq.eu", Port: 443}}, endpoints)
+
+	// the endpoints set with an environment variable are a JSON array
+	LogsAgent.Set("logs_config.additional_endpoints", `[{"api_key":"anotherkey","host":"intake.example.com"}]`)
+	endpoints, err = AdditionalEndpoints()
+	assert.Nil(t, err)
+	assert.Equal(t, []Endpoint{{APIKey: "anotherkey", Host: "intake.example.com"}}, endpoints)
+
+	LogsAgent.Set("logs_config.additional_endpoints", []map[string]interface{}{{"host": "intake.example.com"}})
+	_, err = AdditionalEndpoints()
+	assert.NotNil(t, err)
+}
@@ -9,6 +9,10 @@ package config
 const (
 	ChanSize          = 100
 	NumberOfPipelines = 4
+	// RingSize is the number of messages buffered between the inputs and a processor
+	RingSize = 1024
+	// BatchSize is the maximum number of messages a processor takes at once
+	BatchSize = 64
 )
 
 const (
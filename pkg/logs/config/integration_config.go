@@ -6,23 +6,33 @@
 package config
 
 import (
+	"encoding/json"
 	"fmt"
 	"io/ioutil"
+	"math/rand"
 	"os"
 	"path/filepath"
 	"regexp"
+	"strconv"
 	"strings"
 
 	log "github.com/cihub/seelog"
 	"github.com/spf13/viper"
+
+	"github.com/DataDog/datadog-agent/pkg/util/allowlist"
 )
 
 // Logs source types
 const (
-	TCPType    = "tcp"
-	UDPType    = "udp"
-	FileType   = "file"
-	DockerType = "docker"
+	TCPType             = "tcp"
+	UDPType             = "udp"
+	FileType            = "file"
+	DockerType          = "docker"
+	KubernetesAuditType = "kubernetes_audit"
+	KubernetesType      = "kubernetes"
+	IntegrationType     = "integration"
+	WindowsEventType    = "windows_event"
+	CRIType             = "cri"
 )
 
 // Logs rule types
@@ -31,6 +41,28 @@ const (
 	IncludeAtMatch = "include_at_match"
 	MaskSequences  = "mask_sequences"
 	MultiLine      = "multi_line"
+	SampleAtMatch  = "sample_at_match"
+)
+
+// Logs formats
+const (
+	SyslogFormat  = "syslog"
+	CRIFormat     = "cri"
+	DatadogFormat = "datadog"
+)
+
+// Languages of the stack traces aggregated by auto_multi_line_detection
+const (
+	JavaLanguage   = "java"
+	PythonLanguage = "python"
+	CSharpLanguage = "csharp"
+)
+
+// Reserved attributes promoted from the logs formatted as JSON objects
+const (
+	StatusAttribute    = "status"
+	TimestampAttribute = "timestamp"
+	ServiceAttribute   = "service"
 )
 
 // Valid integration config extensions
@@ -40,16 +72,31 @@ const (
 	ymlExtension       = ".yml"
 )
 
-// LogsProcessingRule defines an exclusion or a masking rule to
+// LogsProcessingRule defines an exclusion, a masking or a sampling rule to
 // be applied on log lines
 type LogsProcessingRule struct {
 	Type               string
 	Name               string
-	ReplacePlaceholder string `mapstructure:"replace_placeholder"`
+	ReplacePlaceholder string `mapstructure:"replace_placeholder" json:"replace_placeholder"`
 	Pattern            string
+	// SampleRate is the fraction of the lines matching a sample_at_match
+	// rule which are kept, RateLimit the number of them kept per second on
+	// average, up to RateLimitBurst at once, either or both can be set
+	SampleRate     float64 `mapstructure:"sample_rate" json:"sample_rate"`
+	RateLimit      float64 `mapstructure:"rate_limit" json:"rate_limit"`
+	RateLimitBurst int     `mapstructure:"rate_limit_burst" json:"rate_limit_burst"`
 	// TODO: should be moved out
 	Reg                     *regexp.Regexp
 	ReplacePlaceholderBytes []byte
+	Limiter                 *RateLimiter
+}
+
+// Sample returns true when a line matching a sample_at_match rule is kept
+func (r LogsProcessingRule) Sample() bool {
+	if r.SampleRate > 0 && rand.Float64() >= r.SampleRate {
+		return false
+	}
+	return r.Limiter.Allow()
 }
 
 // LogsConfig represents a log source config, which can be for instance
@@ -58,17 +105,154 @@ type LogsConfig struct {
 	Type string
 
 	Port int    // Network
-	Path string // File
+	Path string // File, can be a pattern like /var/log/containers/**/*.log
+
+	// OpenFilesLimit is the maximum number of files matching the path of
+	// a file source tailed at the same time, no limit but the global one
+	// (logs_config.open_files_limit) when 0
+	OpenFilesLimit int `mapstructure:"open_files_limit"`
+
+	// AllowedSources are the networks, in CIDR notation, the network
+	// sources accept logs from, every network when empty
+	AllowedSources []string `mapstructure:"allowed_sources"`
 
-	Image string // Docker
+	// TLSCert and TLSKey are the PEM files of the certificate terminating
+	// the TLS connections of a tcp source, TLSCA the certificates of the
+	// authorities the clients must present a certificate of when it is set
+	TLSCert string `mapstructure:"tls_cert"`
+	TLSKey  string `mapstructure:"tls_key"`
+	TLSCA   string `mapstructure:"tls_ca"`
+
+	// Format is the format of the logs received by a network source, the
+	// syslog messages are parsed into structured logs and the logs sent by
+	// other agents are relayed with their hostname, service, source and
+	// tags, or of the logs of a file source, the lines of the CRI log files
+	// are parsed
+	Format string
+
+	// ChannelPath is the channel of the Windows Event Log collected by a
+	// windows_event source, Query the XPath query selecting its events,
+	// all of them when empty
+	ChannelPath string `mapstructure:"channel_path"`
+	Query       string
+
+	Image string // Docker, Kubernetes, CRI
 	Label string // Docker
-	Name  string // Docker
+	Name  string // Docker, Kubernetes, CRI
+
+	// Service, Source, SourceCategory and Tags are set on all the logs of
+	// the source, the service overrides the one of the logs
+	Service        string
+	Source         string
+	SourceCategory string `mapstructure:"source_category" json:"source_category"`
+	// LegacySourceCategory is the sourcecategory of the previous versions
+	// of the configuration, used when SourceCategory is not set
+	LegacySourceCategory string   `mapstructure:"sourcecategory" json:"sourcecategory"`
+	Tags                 []string `mapstructure:"tags" json:"tags"`
+
+	ProcessingRules []LogsProcessingRule `mapstructure:"log_processing_rules" json:"log_processing_rules"`
 
-	Service         string
-	Source          string
-	SourceCategory  string
-	Tags            []string
-	ProcessingRules []LogsProcessingRule `mapstructure:"log_processing_rules"`
+	// RateLimit is the number of messages per second the source can send
+	// on average, up to RateLimitBurst at once, no limit when 0. The
+	// messages over the limit are dropped.
+	RateLimit      float64 `mapstructure:"rate_limit"`
+	RateLimitBurst int     `mapstructure:"rate_limit_burst"`
+
+	// AutoMultiLineDetection aggregates the lines of the logs which don't
+	// start with the timestamp pattern detected in their first lines when
+	// true, or the lines of the stack traces of a language, java, python or
+	// csharp, when there is no multi_line rule. The global setting
+	// (logs_config.auto_multi_line_detection) is used when not set.
+	AutoMultiLineDetection string `mapstructure:"auto_multi_line_detection" json:"auto_multi_line_detection"`
+
+	// AutoJSONDetection promotes the status, the timestamp and the service
+	// of the logs formatted as JSON objects, unwrapped from the docker and
+	// CRI log formats, to reserved attributes. JSONAttributes are the paths
+	// of the attributes to promote, e.g. {"status": "log.level"}, the usual
+	// attributes are looked up when not set.
+	AutoJSONDetection bool              `mapstructure:"auto_json_detection"`
+	JSONAttributes    map[string]string `mapstructure:"json_attributes"`
+}
+
+// UnmarshalJSON decodes a config in JSON, like the ones of the container
+// labels, where the tags can also be a comma separated string and
+// auto_multi_line_detection a boolean as in the YAML configs
+func (c *LogsConfig) UnmarshalJSON(data []byte) error {
+	type logsConfig LogsConfig
+	raw := struct {
+		*logsConfig
+		Tags                   interface{} `json:"tags"`
+		AutoMultiLineDetection interface{} `json:"auto_multi_line_detection"`
+	}{
+		logsConfig: (*logsConfig)(c),
+	}
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return err
+	}
+	switch tags := raw.Tags.(type) {
+	case nil:
+		c.Tags = nil
+	case string:
+		c.Tags = nil
+		for _, tag := range strings.Split(tags, ",") {
+			if tag = strings.TrimSpace(tag); tag != "" {
+				c.Tags = append(c.Tags, tag)
+			}
+		}
+	case []interface{}:
+		c.Tags = make([]string, 0, len(tags))
+		for _, tag := range tags {
+			value, ok := tag.(string)
+			if !ok {
+				return fmt.Errorf("the tags must be strings (got %v)", tag)
+			}
+			c.Tags = append(c.Tags, value)
+		}
+	default:
+		return fmt.Errorf("the tags must be a list or a comma separated string (got %v)", tags)
+	}
+	switch detection := raw.AutoMultiLineDetection.(type) {
+	case nil:
+		c.AutoMultiLineDetection = ""
+	case bool:
+		c.AutoMultiLineDetection = strconv.FormatBool(detection)
+	case string:
+		c.AutoMultiLineDetection = detection
+	default:
+		return fmt.Errorf("auto_multi_line_detection must be a boolean or a language (got %v)", detection)
+	}
+	return nil
+}
+
+// IsValidAutoMultiLineDetection returns true if the value of
+// auto_multi_line_detection is empty, a boolean or a language
+func IsValidAutoMultiLineDetection(value string) bool {
+	switch strings.ToLower(value) {
+	case "", JavaLanguage, PythonLanguage, CSharpLanguage:
+		return true
+	}
+	_, err := strconv.ParseBool(value)
+	return err == nil
+}
+
+// ParseJSONConfig returns the first config of a JSON array of configs, like
+// the ones of the container labels and of the pod annotations, with its
+// processing rules compiled
+func ParseJSONConfig(data string) (*LogsConfig, error) {
+	var configs []LogsConfig
+	if err := json.Unmarshal([]byte(data), &configs); err != nil {
+		return nil, err
+	}
+	if len(configs) < 1 {
+		return nil, fmt.Errorf("no logs config in %s", data)
+	}
+	config := configs[0]
+	rules, err := validateProcessingRules(config.ProcessingRules)
+	if err != nil {
+		return nil, err
+	}
+	config.ProcessingRules = rules
+	return &config, nil
 }
 
 // IntegrationConfig represents a DataDog agent configuration file, which includes infra and logs parts.
@@ -190,7 +374,11 @@ func validateConfig(config LogsConfig) error {
 	case FileType,
 		DockerType,
 		TCPType,
-		UDPType:
+		UDPType,
+		KubernetesAuditType,
+		KubernetesType,
+		WindowsEventType,
+		CRIType:
 	default:
 		return fmt.Errorf("A source must have a valid type (got %s)", config.Type)
 	}
@@ -199,6 +387,14 @@ func validateConfig(config LogsConfig) error {
 		return fmt.Errorf("A file source must have a path")
 	}
 
+	if config.OpenFilesLimit < 0 {
+		return fmt.Errorf("A file source must have a positive open_files_limit")
+	}
+
+	if config.RateLimit < 0 || config.RateLimitBurst < 0 {
+		return fmt.Errorf("A source must have a positive rate_limit and rate_limit_burst")
+	}
+
 	if config.Type == TCPType && config.Port == 0 {
 		return fmt.Errorf("A tcp source must have a port")
 	}
@@ -207,6 +403,60 @@ func validateConfig(config LogsConfig) error {
 		return fmt.Errorf("A udp source must have a port")
 	}
 
+	if config.Type == WindowsEventType && config.ChannelPath == "" {
+		return fmt.Errorf("A windows_event source must have a channel_path")
+	}
+
+	if config.Type == KubernetesAuditType && (config.Path == "") == (config.Port == 0) {
+		return fmt.Errorf("A kubernetes_audit source must have either a path or a port")
+	}
+
+	if (config.TLSCert == "") != (config.TLSKey == "") {
+		return fmt.Errorf("A tls source must have both a tls_cert and a tls_key")
+	}
+
+	if (config.TLSCert != "" || config.TLSCA != "") && config.Type != TCPType {
+		return fmt.Errorf("Only a tcp source can terminate TLS connections")
+	}
+
+	if config.TLSCA != "" && config.TLSCert == "" {
+		return fmt.Errorf("A tls source with a tls_ca must have a tls_cert and a tls_key")
+	}
+
+	switch config.Format {
+	case "":
+	case SyslogFormat:
+		if config.Type != TCPType && config.Type != UDPType {
+			return fmt.Errorf("Only a tcp or udp source can have the %s format", config.Format)
+		}
+	case DatadogFormat:
+		if config.Type != TCPType {
+			return fmt.Errorf("Only a tcp source can have the %s format", config.Format)
+		}
+	case CRIFormat:
+		if config.Type != FileType {
+			return fmt.Errorf("Only a file source can have the %s format", config.Format)
+		}
+	default:
+		return fmt.Errorf("A source must have a valid format (got %s)", config.Format)
+	}
+
+	if !IsValidAutoMultiLineDetection(config.AutoMultiLineDetection) {
+		return fmt.Errorf("A source must have a boolean or a language, %s, %s or %s, as auto_multi_line_detection (got %s)", JavaLanguage, PythonLanguage, CSharpLanguage, config.AutoMultiLineDetection)
+	}
+
+	for attribute := range config.JSONAttributes {
+		switch attribute {
+		case StatusAttribute, TimestampAttribute, ServiceAttribute:
+		default:
+			return fmt.Errorf("A source can only promote the %s, %s and %s json_attributes (got %s)", StatusAttribute, TimestampAttribute, ServiceAttribute, attribute)
+		}
+	}
+
+	if _, err := allowlist.New("", config.AllowedSources); err != nil {
+		return fmt.Errorf("A network source must have valid allowed_sources: %s", err)
+	}
+
 	return nil
 }
 
@@ -216,22 +466,37 @@ func validateProcessingRules(rules []LogsProcessingRule) ([]LogsProcessingRule,
 		if rule.Name == "" {
 			return nil, fmt.Errorf("LogsAgent misconfigured: all log processing rules need a name")
 		}
+		pattern := rule.Pattern
 		switch rule.Type {
-		case ExcludeAtMatch:
-			rules[i].Reg = regexp.MustCompile(rule.Pattern)
-		case IncludeAtMatch:
-			rules[i].Reg = regexp.MustCompile(rule.Pattern)
+		case ExcludeAtMatch, IncludeAtMatch:
 		case MaskSequences:
-			rules[i].Reg = regexp.MustCompile(rule.Pattern)
 			rules[i].ReplacePlaceholderBytes = []byte(rule.ReplacePlaceholder)
 		case MultiLine:
-			rules[i].Reg = regexp.MustCompile("^" + rule.Pattern)
+			pattern = "^" + rule.Pattern
+		case SampleAtMatch:
+			if rule.SampleRate < 0 || rule.SampleRate > 1 {
+				return nil, fmt.Errorf("LogsAgent misconfigured: sample_rate must be between 0 and 1 for log processing rule `%s`", rule.Name)
+			}
+			if rule.RateLimit < 0 || rule.RateLimitBurst < 0 {
+				return nil, fmt.Errorf("LogsAgent misconfigured: rate_limit and rate_limit_burst can't be negative for log processing rule `%s`", rule.Name)
+			}
+			if rule.SampleRate == 0 && rule.RateLimit == 0 {
+				return nil, fmt.Errorf("LogsAgent misconfigured: sample_rate or rate_limit must be set for log processing rule `%s`", rule.Name)
+			}
+			if rule.RateLimit > 0 {
+				rules[i].Limiter = NewRateLimiter(rule.RateLimit, rule.RateLimitBurst)
+			}
 		default:
 			if rule.Type == "" {
 				return nil, fmt.Errorf("LogsAgent misconfigured: type must be set for log processing rule `%s`", rule.Name)
 			}
 			return nil, fmt.Errorf("LogsAgent misconfigured: type %s is unsupported for log processing rule `%s`", rule.Type, rule.Name)
 		}
+		reg, err := regexp.Compile(pattern)
+		if err != nil {
+			return nil, fmt.Errorf("LogsAgent misconfigured: invalid pattern for log processing rule `%s`: %s", rule.Name, err)
+		}
+		rules[i].Reg = reg
 	}
 	return rules, nil
 }
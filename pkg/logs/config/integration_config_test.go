@@ -8,6 +8,7 @@
 package config
 
 import (
+	"encoding/json"
 	"path/filepath"
 	"testing"
 
@@ -49,6 +50,8 @@ func TestBuildLogsAgentIntegrationsConfigs(t *testing.T) {
 
 	assert.Equal(t, []string{"env:prod", "foo:bar"}, sources[3].Config.Tags)
 	assert.Equal(t, []string{"env:prod", "foo:bar"}, sources[4].Config.Tags)
+	assert.Equal(t, "http_access", sources[3].Config.SourceCategory)
+	assert.Equal(t, "http_access", sources[4].Config.SourceCategory)
 
 	// processing
 	assert.Equal(t, 0, len(sources[0].Config.ProcessingRules))
@@ -125,3 +128,118 @@ func TestIntegrationName(t *testing.T) {
 	_, err = buildIntegrationName("foo.b/bar.yml")
 	assert.NotNil(t, err)
 }
+
+func TestValidateKubernetesAuditConfig(t *testing.T) {
+	assert.Nil(t, validateConfig(LogsConfig{Type: KubernetesAuditType, Path: "/var/log/kubernetes/audit.log"}))
+	assert.Nil(t, validateConfig(LogsConfig{Type: KubernetesAuditType, Port: 8125}))
+	assert.NotNil(t, validateConfig(LogsConfig{Type: KubernetesAuditType}))
+	assert.NotNil(t, validateConfig(LogsConfig{Type: KubernetesAuditType, Path: "/var/log/kubernetes/audit.log", Port: 8125}))
+}
+
+func TestValidateWindowsEventConfig(t *testing.T) {
+	assert.Nil(t, validateConfig(LogsConfig{Type: WindowsEventType, ChannelPath: "System"}))
+	assert.Nil(t, validateConfig(LogsConfig{Type: WindowsEventType, ChannelPath: "Security", Query: "*[System[(EventID=4625)]]"}))
+	assert.NotNil(t, validateConfig(LogsConfig{Type: WindowsEventType}))
+}
+
+func TestValidateRateLimit(t *testing.T) {
+	assert.Nil(t, validateConfig(LogsConfig{Type: FileType, Path: "/var/log/app.log", RateLimit: 0.5, RateLimitBurst: 10}))
+	assert.NotNil(t, validateConfig(LogsConfig{Type: FileType, Path: "/var/log/app.log", RateLimit: -1}))
+	assert.NotNil(t, validateConfig(LogsConfig{Type: FileType, Path: "/var/log/app.log", RateLimit: 1, RateLimitBurst: -1}))
+}
+
+func TestValidateSamplingRules(t *testing.T) {
+	rules, err := validateProcessingRules([]LogsProcessingRule{{Type: SampleAtMatch, Name: "sample_debug", Pattern: "DEBUG", SampleRate: 0.1}})
+	assert.Nil(t, err)
+	assert.Nil(t, rules[0].Limiter)
+	rules, err = validateProcessingRules([]LogsProcessingRule{{Type: SampleAtMatch, Name: "limit_debug", Pattern: "DEBUG", RateLimit: 10, RateLimitBurst: 100}})
+	assert.Nil(t, err)
+	assert.NotNil(t, rules[0].Limiter)
+
+	_, err = validateProcessingRules([]LogsProcessingRule{{Type: SampleAtMatch, Name: "sample_debug", Pattern: "DEBUG"}})
+	assert.NotNil(t, err)
+	_, err = validateProcessingRules([]LogsProcessingRule{{Type: SampleAtMatch, Name: "sample_debug", Pattern: "DEBUG", SampleRate: 1.5}})
+	assert.NotNil(t, err)
+	_, err = validateProcessingRules([]LogsProcessingRule{{Type: SampleAtMatch, Name: "limit_debug", Pattern: "DEBUG", RateLimit: -1}})
+	assert.NotNil(t, err)
+}
+
+func TestValidateAllowedSources(t *testing.T) {
+	assert.Nil(t, validateConfig(LogsConfig{Type: TCPType, Port: 10514, AllowedSources: []string{"10.0.0.0/8", "fd00::1"}}))
+	assert.Nil(t, validateConfig(LogsConfig{Type: UDPType, Port: 10514, AllowedSources: []string{}}))
+	assert.NotNil(t, validateConfig(LogsConfig{Type: UDPType, Port: 10514, AllowedSources: []string{"10.0.0.0/40"}}))
+}
+
+func TestValidateOpenFilesLimit(t *testing.T) {
+	assert.Nil(t, validateConfig(LogsConfig{Type: FileType, Path: "/var/log/containers/**/*.log", OpenFilesLimit: 20}))
+	assert.NotNil(t, validateConfig(LogsConfig{Type: FileType, Path: "/var/log/containers/**/*.log", OpenFilesLimit: -1}))
+}
+
+func TestValidateTLSConfig(t *testing.T) {
+	assert.Nil(t, validateConfig(LogsConfig{Type: TCPType, Port: 10514, TLSCert: "cert.pem", TLSKey: "key.pem"}))
+	assert.Nil(t, validateConfig(LogsConfig{Type: TCPType, Port: 10514, TLSCert: "cert.pem", TLSKey: "key.pem", TLSCA: "ca.pem"}))
+	assert.NotNil(t, validateConfig(LogsConfig{Type: TCPType, Port: 10514, TLSCert: "cert.pem"}))
+	assert.NotNil(t, validateConfig(LogsConfig{Type: TCPType, Port: 10514, TLSCA: "ca.pem"}))
+	assert.NotNil(t, validateConfig(LogsConfig{Type: UDPType, Port: 10514, TLSCert: "cert.pem", TLSKey: "key.pem"}))
+}
+
+func TestValidateFormat(t *testing.T) {
+	assert.Nil(t, validateConfig(LogsConfig{Type: TCPType, Port: 10514, Format: SyslogFormat}))
+	assert.Nil(t, validateConfig(LogsConfig{Type: UDPType, Port: 10514, Format: SyslogFormat}))
+	assert.NotNil(t, validateConfig(LogsConfig{Type: FileType, Path: "/var/log/syslog", Format: SyslogFormat}))
+	assert.NotNil(t, validateConfig(LogsConfig{Type: TCPType, Port: 10514, Format: "gelf"}))
+	assert.Nil(t, validateConfig(LogsConfig{Type: FileType, Path: "/var/log/pods/*/*/*.log", Format: CRIFormat}))
+	assert.NotNil(t, validateConfig(LogsConfig{Type: TCPType, Port: 10514, Format: CRIFormat}))
+	assert.Nil(t, validateConfig(LogsConfig{Type: TCPType, Port: 10516, Format: DatadogFormat}))
+	assert.NotNil(t, validateConfig(LogsConfig{Type: UDPType, Port: 10516, Format: DatadogFormat}))
+	assert.Nil(t, validateConfig(LogsConfig{Type: KubernetesType}))
+	assert.Nil(t, validateConfig(LogsConfig{Type: CRIType}))
+}
+
+func TestValidateAutoMultiLineDetection(t *testing.T) {
+	for _, detection := range []string{"", "true", "false", "1", JavaLanguage, PythonLanguage, CSharpLanguage, "Java"} {
+		assert.Nil(t, validateConfig(LogsConfig{Type: FileType, Path: "/var/log/app.log", AutoMultiLineDetection: detection}), detection)
+	}
+	assert.NotNil(t, validateConfig(LogsConfig{Type: FileType, Path: "/var/log/app.log", AutoMultiLineDetection: "cobol"}))
+}
+
+func TestUnmarshalJSONConfig(t *testing.T) {
+	var configs []LogsConfig
+	err := json.Unmarshal([]byte(`[
+		{"source":"nginx","service":"web","source_category":"http_access","tags":"env:prod, foo:bar"},
+		{"source":"redis","sourcecategory":"db","tags":["env:prod","team:cache"],"auto_multi_line_detection":true},
+		{"source":"java","auto_multi_line_detection":"java"}
+	]`), &configs)
+	assert.Nil(t, err)
+	assert.Equal(t, "web", configs[0].Service)
+	assert.Equal(t, "http_access", configs[0].SourceCategory)
+	assert.Equal(t, []string{"env:prod", "foo:bar"}, configs[0].Tags)
+	assert.Equal(t, []string{"env:prod", "team:cache"}, configs[1].Tags)
+	assert.Equal(t, "", configs[0].AutoMultiLineDetection)
+	assert.Equal(t, "true", configs[1].AutoMultiLineDetection)
+	assert.Equal(t, JavaLanguage, configs[2].AutoMultiLineDetection)
+
+	// the sourcecategory of the previous versions is still supported
+	source := NewLogSource("", &configs[1])
+	assert.Equal(t, "db", source.Config.SourceCategory)
+
+	assert.NotNil(t, json.Unmarshal([]byte(`{"tags":42}`), &LogsConfig{}))
+	assert.NotNil(t, json.Unmarshal([]byte(`{"auto_multi_line_detection":1}`), &LogsConfig{}))
+}
+
+func TestParseJSONConfig(t *testing.T) {
+	config, err := ParseJSONConfig(`[{"source":"redis","service":"cache","log_processing_rules":[{"type":"mask_sequences","name":"mask_passwords","replace_placeholder":"[password]","pattern":"password=\\w+"}]}]`)
+	assert.Nil(t, err)
+	assert.Equal(t, "redis", config.Source)
+	assert.Equal(t, "cache", config.Service)
+	assert.Len(t, config.ProcessingRules, 1)
+	assert.Equal(t, []byte("[password]"), config.ProcessingRules[0].ReplacePlaceholderBytes)
+	assert.True(t, config.ProcessingRules[0].Reg.MatchString("password=secret"))
+
+	_, err = ParseJSONConfig(`{"source":"redis"}`)
+	assert.NotNil(t, err)
+	_, err = ParseJSONConfig(`[]`)
+	assert.NotNil(t, err)
+	_, err = ParseJSONConfig(`[{"log_processing_rules":[{"type":"exclude_at_match","name":"invalid","pattern":"("}]}]`)
+	assert.NotNil(t, err)
+}
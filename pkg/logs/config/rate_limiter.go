@@ -0,0 +1,72 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2018 Datadog, Inc.
+
+package config
+
+import (
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// A RateLimiter lets through rate messages per second on average, and up to
+// burst messages at once, it counts the messages it drops. A nil RateLimiter
+// lets through all the messages.
+type RateLimiter struct {
+	mu      sync.Mutex
+	rate    float64
+	burst   float64
+	tokens  float64
+	last    time.Time
+	now     func() time.Time
+	dropped int64
+}
+
+// NewRateLimiter returns a RateLimiter, its bucket is full at first
+func NewRateLimiter(rate float64, burst int) *RateLimiter {
+	if burst < 1 {
+		burst = int(rate)
+		if burst < 1 {
+			burst = 1
+		}
+	}
+	return &RateLimiter{
+		rate:   rate,
+		burst:  float64(burst),
+		tokens: float64(burst),
+		last:   time.Now(),
+		now:    time.Now,
+	}
+}
+
+// Allow returns true when a message can be let through now, and counts it
+// as dropped otherwise
+func (l *RateLimiter) Allow() bool {
+	if l == nil {
+		return true
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	now := l.now()
+	l.tokens += now.Sub(l.last).Seconds() * l.rate
+	if l.tokens > l.burst {
+		l.tokens = l.burst
+	}
+	l.last = now
+	if l.tokens < 1 {
+		atomic.AddInt64(&l.dropped, 1)
+		return false
+	}
+	l.tokens--
+	return true
+}
+
+// Dropped returns the number of messages dropped so far
+func (l *RateLimiter) Dropped() int64 {
+	if l == nil {
+		return 0
+	}
+	return atomic.LoadInt64(&l.dropped)
+}
@@ -0,0 +1,46 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2018 Datadog, Inc.
+
+package config
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRateLimiter(t *testing.T) {
+	now := time.Now()
+	l := NewRateLimiter(2, 3)
+	l.last = now
+	l.now = func() time.Time { return now }
+
+	// the burst goes through at once
+	for i := 0; i < 3; i++ {
+		assert.True(t, l.Allow())
+	}
+	assert.False(t, l.Allow())
+	assert.Equal(t, int64(1), l.Dropped())
+
+	// then rate messages per second
+	now = now.Add(500 * time.Millisecond)
+	assert.True(t, l.Allow())
+	assert.False(t, l.Allow())
+
+	// the tokens don't pile up over the burst
+	now = now.Add(time.Minute)
+	for i := 0; i < 3; i++ {
+		assert.True(t, l.Allow())
+	}
+	assert.False(t, l.Allow())
+	assert.Equal(t, int64(3), l.Dropped())
+}
+
+func TestNilRateLimiter(t *testing.T) {
+	var l *RateLimiter
+	assert.True(t, l.Allow())
+	assert.Equal(t, int64(0), l.Dropped())
+}
@@ -0,0 +1,96 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2018 Datadog, Inc.
+
+package config
+
+import (
+	"encoding/json"
+	"fmt"
+	"regexp"
+
+	"github.com/DataDog/datadog-agent/pkg/util/scrubber"
+)
+
+// Built-in scrubbing patterns
+const (
+	CreditCardsPattern = "credit_cards"
+	APIKeysPattern     = "api_keys"
+	EmailsPattern      = "emails"
+)
+
+// defaultScrubbingReplacement replaces the matches of a scrubbing rule
+// without replacement
+const defaultScrubbingReplacement = "********"
+
+// ScrubbingRule replaces the matches of a pattern in the logs by a
+// replacement, which can refer to the groups of the pattern like $1
+type ScrubbingRule struct {
+	Name        string `mapstructure:"name" json:"name"`
+	Pattern     string `mapstructure:"pattern" json:"pattern"`
+	Replacement string `mapstructure:"replacement" json:"replacement"`
+}
+
+// builtinScrubbingReplacers are the replacers of the built-in patterns
+var builtinScrubbingReplacers = map[string][]scrubber.Replacer{
+	// the card numbers of the main networks, with or without separators
+	CreditCardsPattern: {{
+		Regex: regexp.MustCompile(`\b(?:4\d{3}|5[1-5]\d{2}|6(?:011|5\d{2})|3[47]\d{2})(?:[ -]?\d{4}){2}[ -]?\d{1,4}\b`),
+		Repl:  []byte("[credit_card]"),
+	}},
+	// the API and application keys, their last 5 characters are kept to
+	// tell them apart
+	APIKeysPattern: {{
+		Regex: regexp.MustCompile(`\b[a-fA-F0-9]{35}([a-fA-F0-9]{5})\b`),
+		Repl:  []byte("***********************************$1"),
+	}, {
+		Regex: regexp.MustCompile(`\b[a-fA-F0-9]{27}([a-fA-F0-9]{5})\b`),
+		Repl:  []byte("***************************$1"),
+	}},
+	EmailsPattern: {{
+		Regex: regexp.MustCompile(`[a-zA-Z0-9._%+-]+@[a-zA-Z0-9.-]+\.[a-zA-Z]{2,}`),
+		Repl:  []byte("[email]"),
+	}},
+}
+
+// ScrubbingReplacers returns the replacers of the built-in patterns of
+// logs_config.scrubbing_patterns followed by the ones of the rules of
+// logs_config.scrubbing_rules, applied to the logs of all the sources
+// before they leave the host
+func ScrubbingReplacers() ([]scrubber.Replacer, error) {
+	var replacers []scrubber.Replacer
+	for _, pattern := range LogsAgent.GetStringSlice("logs_config.scrubbing_patterns") {
+		builtin, exists := builtinScrubbingReplacers[pattern]
+		if !exists {
+			return nil, fmt.Errorf("LogsAgent misconfigured: unknown scrubbing pattern %s, expected %s, %s or %s", pattern, CreditCardsPattern, APIKeysPattern, EmailsPattern)
+		}
+		replacers = append(replacers, builtin...)
+	}
+
+	var rules []ScrubbingRule
+	var err error
+	// the rules set with an environment variable are a JSON array
+	if raw, isString := LogsAgent.Get("logs_config.scrubbing_rules").(string); isString {
+		if raw != "" {
+			err = json.Unmarshal([]byte(raw), &rules)
+		}
+	} else {
+		err = LogsAgent.UnmarshalKey("logs_config.scrubbing_rules", &rules)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("could not parse the log scrubbing rules: %s", err)
+	}
+	for _, rule := range rules {
+		regex, err := regexp.Compile(rule.Pattern)
+		if err != nil {
+			return nil, fmt.Errorf("LogsAgent misconfigured: invalid pattern for log scrubbing rule `%s`: %s", rule.Name, err)
+		}
+		replacement := rule.Replacement
+		if replacement == "" {
+			replacement = defaultScrubbingReplacement
+		}
+		replacers = append(replacers, scrubber.Replacer{Regex: regex, Repl: []byte(replacement)})
+	}
+	return replacers, nil
+}
@@ -5,7 +5,11 @@
 
 package config
 
-import "sync"
+import (
+	"sync"
+
+	"github.com/DataDog/datadog-agent/pkg/logs/metrics"
+)
 
 // LogSource holds a reference to and integration name and a log configuration, and allows to track errors and
 // successful operations on it. Both name and configuration are static for now and determined at creation time.
@@ -14,18 +18,32 @@ type LogSource struct {
 	Name   string
 	Config *LogsConfig
 	Status *LogStatus
-	inputs map[string]bool
-	lock   *sync.Mutex
+	// RateLimiter limits the messages of the source when it has a
+	// rate_limit, it is nil otherwise
+	RateLimiter *RateLimiter
+	// Metrics counts the logs of the source through the pipeline
+	Metrics *metrics.SourceMetrics
+	inputs  map[string]bool
+	lock    *sync.Mutex
 }
 
 // NewLogSource creates a new log source.
 func NewLogSource(name string, config *LogsConfig) *LogSource {
+	if config != nil && config.SourceCategory == "" {
+		config.SourceCategory = config.LegacySourceCategory
+	}
+	var rateLimiter *RateLimiter
+	if config != nil && config.RateLimit > 0 {
+		rateLimiter = NewRateLimiter(config.RateLimit, config.RateLimitBurst)
+	}
 	return &LogSource{
-		Name:   name,
-		Config: config,
-		Status: NewLogStatus(),
-		inputs: make(map[string]bool),
-		lock:   &sync.Mutex{},
+		Name:        name,
+		Config:      config,
+		Status:      NewLogStatus(),
+		RateLimiter: rateLimiter,
+		Metrics:     metrics.NewSourceMetrics(),
+		inputs:      make(map[string]bool),
+		lock:        &sync.Mutex{},
 	}
 }
 
@@ -0,0 +1,67 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2018 Datadog, Inc.
+
+package cri
+
+import (
+	"bytes"
+	"errors"
+
+	"github.com/DataDog/datadog-agent/pkg/logs/config"
+)
+
+// Tags of the lines, a log longer than the buffer of the runtime is split
+// into partial lines followed by a full one
+const (
+	partialTag = "P"
+	fullTag    = "F"
+)
+
+// Message is a line of a container log file written by a CRI runtime
+type Message struct {
+	Timestamp string
+	Severity  []byte
+	Partial   bool
+	Content   []byte
+}
+
+// ParseMessage parses a line of the log files written by the CRI runtimes,
+// like containerd and CRI-O, formatted as "<timestamp> <stream> <tag> <log>",
+// for instance "2018-10-16T12:00:00.000000000Z stdout F my message".
+// The logs of the older runtimes have no tag, they are always full lines.
+func ParseMessage(msg []byte) (*Message, error) {
+	fields := bytes.SplitN(msg, []byte{' '}, 4)
+	if len(fields) < 3 {
+		return nil, errors.New("Can't parse CRI message: expected a timestamp, a stream and a log")
+	}
+
+	var severity []byte
+	switch string(fields[1]) {
+	case "stdout":
+		severity = config.SevInfo
+	case "stderr":
+		severity = config.SevError
+	default:
+		return nil, errors.New("Can't parse CRI message: expected stdout or stderr as stream")
+	}
+
+	message := &Message{
+		Timestamp: string(fields[0]),
+		Severity:  severity,
+	}
+	switch tag := string(fields[2]); {
+	case len(fields) == 4 && (tag == partialTag || tag == fullTag):
+		message.Partial = tag == partialTag
+		message.Content = fields[3]
+	case len(fields) == 3 && (tag == partialTag || tag == fullTag):
+		// empty log
+		message.Partial = tag == partialTag
+		message.Content = []byte{}
+	default:
+		// no tag
+		message.Content = bytes.SplitN(msg, []byte{' '}, 3)[2]
+	}
+	return message, nil
+}
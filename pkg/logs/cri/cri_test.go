@@ -0,0 +1,49 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2018 Datadog, Inc.
+
+package cri
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/DataDog/datadog-agent/pkg/logs/config"
+)
+
+func TestParseMessage(t *testing.T) {
+	msg, err := ParseMessage([]byte("2018-10-16T12:00:00.000000000Z stdout F my message"))
+	require.NoError(t, err)
+	assert.Equal(t, "2018-10-16T12:00:00.000000000Z", msg.Timestamp)
+	assert.Equal(t, config.SevInfo, msg.Severity)
+	assert.False(t, msg.Partial)
+	assert.Equal(t, "my message", string(msg.Content))
+
+	msg, err = ParseMessage([]byte("2018-10-16T12:00:00.000000000Z stderr P my partial error "))
+	require.NoError(t, err)
+	assert.Equal(t, config.SevError, msg.Severity)
+	assert.True(t, msg.Partial)
+	assert.Equal(t, "my partial error ", string(msg.Content))
+
+	msg, err = ParseMessage([]byte("2018-10-16T12:00:00.000000000Z stdout F"))
+	require.NoError(t, err)
+	assert.Equal(t, "", string(msg.Content))
+}
+
+func TestParseMessageWithoutTag(t *testing.T) {
+	msg, err := ParseMessage([]byte("2018-10-16T12:00:00.000000000Z stdout my message"))
+	require.NoError(t, err)
+	assert.False(t, msg.Partial)
+	assert.Equal(t, "my message", string(msg.Content))
+}
+
+func TestParseInvalidMessage(t *testing.T) {
+	_, err := ParseMessage([]byte("my message"))
+	assert.Error(t, err)
+
+	_, err = ParseMessage([]byte("2018-10-16T12:00:00.000000000Z stdin F my message"))
+	assert.Error(t, err)
+}
@@ -0,0 +1,181 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2018 Datadog, Inc.
+
+package decoder
+
+import (
+	"regexp"
+	"time"
+
+	log "github.com/cihub/seelog"
+
+	"github.com/DataDog/datadog-agent/pkg/logs/config"
+)
+
+// defaultDetectionSampleSize is the number of lines sampled to detect the
+// pattern starting the new logs
+const defaultDetectionSampleSize = 500
+
+// defaultDetectionMatchThreshold is the minimum share of the sampled lines a
+// pattern must match to be used to aggregate the lines
+const defaultDetectionMatchThreshold = 0.75
+
+// timestampPatterns are the timestamps the logs commonly start with, the
+// lines which don't start with the one detected are appended to the log
+// before them, like the lines of a stack trace
+var timestampPatterns = []*regexp.Regexp{
+	// 2018-10-16T12:00:00, 2018-10-16 12:00:00,000
+	regexp.MustCompile(`^\[?\d{4}-\d{2}-\d{2}[T ]\d{2}:\d{2}:\d{2}`),
+	// 2018/10/16 12:00:00
+	regexp.MustCompile(`^\[?\d{4}/\d{2}/\d{2} \d{2}:\d{2}:\d{2}`),
+	// Oct 16 12:00:00
+	regexp.MustCompile(`^\[?[A-Z][a-z]{2} [ \d]\d \d{2}:\d{2}:\d{2}`),
+	// 16/Oct/2018:12:00:00
+	regexp.MustCompile(`^\[?\d{2}/[A-Z][a-z]{2}/\d{4}:\d{2}:\d{2}:\d{2}`),
+	// 16-Oct-2018 12:00:00
+	regexp.MustCompile(`^\[?\d{2}-[A-Z][a-z]{2}-\d{4} \d{2}:\d{2}:\d{2}`),
+	// 10/16/2018 12:00:00
+	regexp.MustCompile(`^\[?\d{1,2}/\d{1,2}/\d{4},? \d{1,2}:\d{2}:\d{2}`),
+	// I1016 12:00:00.000000
+	regexp.MustCompile(`^[IWEF]\d{4} \d{2}:\d{2}:\d{2}`),
+	// 12:00:00.000
+	regexp.MustCompile(`^\[?\d{2}:\d{2}:\d{2}[.,]\d+`),
+}
+
+// stackTracePatterns match the lines of the stack traces of the languages,
+// they are appended to the log before them
+var stackTracePatterns = map[string]*regexp.Regexp{
+	// java.lang.IllegalStateException: boom
+	// 	at com.example.App.main(App.java:10)
+	// 	... 5 more
+	// Caused by: java.io.IOException: boom
+	config.JavaLanguage: regexp.MustCompile(`^(\s+(at |\.\.\. \d+ |Suppressed: )|Caused by: |([a-zA-Z_$][\w$]*\.)+[\w$]*(Exception|Error|Throwable)(: |$))`),
+	// Traceback (most recent call last):
+	//   File "app.py", line 10, in <module>
+	//     main()
+	// ValueError: boom
+	config.PythonLanguage: regexp.MustCompile(`^(\s+\S|\s*$|Traceback \(most recent call last\):|During handling of the above exception|The above exception was the direct cause|([a-zA-Z_]\w*\.)*[A-Z]\w*(Error|Exception|Exit|Interrupt|Warning|Iteration)(: |$))`),
+	// System.InvalidOperationException: boom
+	//  ---> System.IO.IOException: boom
+	//    at App.Main() in C:\App\Program.cs:line 10
+	//    --- End of inner exception stack trace ---
+	config.CSharpLanguage: regexp.MustCompile(`^(\s+(at |---)|\s*---> |([a-zA-Z_]\w*\.)+[a-zA-Z_]\w*(Exception|Error)(: |$))`),
+}
+
+// AutoMultiLineHandler samples the first lines to detect the timestamp
+// pattern starting the logs. The sampled lines are sent as single lines,
+// the next ones are aggregated like with a multi_line rule of the detected
+// pattern, or sent as single lines when no pattern matches enough lines.
+type AutoMultiLineHandler struct {
+	lineChan       chan []byte
+	outputChan     chan *Output
+	lineUnwrapper  LineUnwrapper
+	flushTimeout   time.Duration
+	sampleSize     int
+	matchThreshold float64
+	candidates     []*regexp.Regexp
+	scores         []int
+	sampled        int
+	singleLine     *SingleLineHandler
+	multiLine      *MultiLineHandler
+}
+
+// NewAutoMultiLineHandler returns a new AutoMultiLineHandler
+func NewAutoMultiLineHandler(outputChan chan *Output, flushTimeout time.Duration, lineUnwrapper LineUnwrapper) *AutoMultiLineHandler {
+	return &AutoMultiLineHandler{
+		lineChan:       make(chan []byte),
+		outputChan:     outputChan,
+		lineUnwrapper:  lineUnwrapper,
+		flushTimeout:   flushTimeout,
+		sampleSize:     defaultDetectionSampleSize,
+		matchThreshold: defaultDetectionMatchThreshold,
+		candidates:     timestampPatterns,
+		scores:         make([]int, len(timestampPatterns)),
+		singleLine:     NewSingleLineHandler(outputChan),
+	}
+}
+
+// Handle forward lines to lineChan to process them
+func (h *AutoMultiLineHandler) Handle(content []byte) {
+	h.lineChan <- content
+}
+
+// Stop stops the lineHandler from processing lines
+func (h *AutoMultiLineHandler) Stop() {
+	close(h.lineChan)
+}
+
+// Start starts the handler
+func (h *AutoMultiLineHandler) Start() {
+	go h.run()
+}
+
+// run processes new lines from lineChan and flushes the aggregated lines when
+// the timeout expires
+func (h *AutoMultiLineHandler) run() {
+	flushTimer := time.NewTimer(h.flushTimeout)
+	defer func() {
+		flushTimer.Stop()
+		close(h.outputChan)
+	}()
+	for {
+		select {
+		case line, isOpen := <-h.lineChan:
+			if !isOpen {
+				// lineChan has been closed, no more lines are expected
+				return
+			}
+			// process the new line and restart the timeout
+			flushTimer.Stop()
+			h.process(line)
+			flushTimer.Reset(h.flushTimeout)
+		case <-flushTimer.C:
+			// the timout expired, the content is ready to be sent
+			if h.multiLine != nil {
+				h.multiLine.sendContent()
+			}
+		}
+	}
+}
+
+// process sends the line as a single line while the pattern is detected,
+// then hands it to the multi-line handler of the pattern detected
+func (h *AutoMultiLineHandler) process(line []byte) {
+	if h.multiLine != nil {
+		h.multiLine.process(line)
+		return
+	}
+	if h.sampled < h.sampleSize {
+		h.detect(h.lineUnwrapper.Unwrap(line))
+	}
+	h.singleLine.process(line)
+}
+
+// detect scores the patterns matching line, and picks the pattern matching
+// the most lines once sampleSize lines have been sampled
+func (h *AutoMultiLineHandler) detect(line []byte) {
+	h.sampled++
+	for i, re := range h.candidates {
+		if re.Match(line) {
+			h.scores[i]++
+		}
+	}
+	if h.sampled < h.sampleSize {
+		return
+	}
+
+	best := -1
+	for i, score := range h.scores {
+		if float64(score) >= h.matchThreshold*float64(h.sampled) && (best < 0 || score > h.scores[best]) {
+			best = i
+		}
+	}
+	if best < 0 {
+		log.Debugf("No pattern matches %.0f%% of the %d first lines, the logs are not aggregated", h.matchThreshold*100, h.sampled)
+		return
+	}
+	log.Infof("Detected the logs starting with the pattern %s, aggregating the lines not starting with it", h.candidates[best])
+	h.multiLine = NewMultiLineHandler(h.outputChan, h.candidates[best], h.flushTimeout, h.lineUnwrapper)
+}
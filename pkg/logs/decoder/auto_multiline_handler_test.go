@@ -0,0 +1,134 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2018 Datadog, Inc.
+
+package decoder
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/DataDog/datadog-agent/pkg/logs/config"
+)
+
+func TestAutoMultiLineHandlerDetectsPattern(t *testing.T) {
+	outputChan := make(chan *Output, 10)
+	h := NewAutoMultiLineHandler(outputChan, 10*time.Millisecond, NewUnwrapper())
+	h.sampleSize = 2
+	h.Start()
+
+	// the sampled lines are sent as single lines
+	h.Handle([]byte("2018-10-16 12:00:00 INFO starting"))
+	assert.Equal(t, "2018-10-16 12:00:00 INFO starting", string((<-outputChan).Content))
+	h.Handle([]byte("2018-10-16 12:00:01 INFO started"))
+	assert.Equal(t, "2018-10-16 12:00:01 INFO started", string((<-outputChan).Content))
+
+	// then the lines not starting with a timestamp are aggregated
+	h.Handle([]byte("2018-10-16 12:00:02 ERROR failed"))
+	h.Handle([]byte("Traceback (most recent call last):"))
+	h.Handle([]byte("  File \"foo.py\", line 1, in <module>"))
+	h.Handle([]byte("2018-10-16 12:00:03 INFO retrying"))
+	assert.Equal(t, "2018-10-16 12:00:02 ERROR failed"+"\\n"+"Traceback (most recent call last):"+"\\n"+"  File \"foo.py\", line 1, in <module>", string((<-outputChan).Content))
+	// the last log is flushed after the timeout
+	assert.Equal(t, "2018-10-16 12:00:03 INFO retrying", string((<-outputChan).Content))
+
+	h.Stop()
+}
+
+func TestAutoMultiLineHandlerWithoutPattern(t *testing.T) {
+	outputChan := make(chan *Output, 10)
+	h := NewAutoMultiLineHandler(outputChan, 10*time.Millisecond, NewUnwrapper())
+	h.sampleSize = 2
+	h.Start()
+
+	h.Handle([]byte("starting"))
+	h.Handle([]byte("2018-10-16 12:00:01 started"))
+	h.Handle([]byte("listening"))
+	h.Handle([]byte("  on port 8080"))
+	for _, line := range []string{"starting", "2018-10-16 12:00:01 started", "listening", "on port 8080"} {
+		assert.Equal(t, line, string((<-outputChan).Content))
+	}
+	assert.Nil(t, h.multiLine)
+
+	h.Stop()
+}
+
+func TestTimestampPatterns(t *testing.T) {
+	for _, line := range []string{
+		"2018-10-16T12:00:00.000Z INFO",
+		"[2018-10-16 12:00:00,000] INFO",
+		"2018/10/16 12:00:00 INFO",
+		"Oct 16 12:00:00 host app[1]:",
+		"Oct  6 12:00:00 host app[1]:",
+		"16/Oct/2018:12:00:00 +0000",
+		"16-Oct-2018 12:00:00.000 INFO",
+		"10/16/2018 12:00:00 PM",
+		"I1016 12:00:00.000000 1 main.go:1]",
+		"12:00:00.000 [main] INFO",
+	} {
+		matched := false
+		for _, re := range timestampPatterns {
+			matched = matched || re.MatchString(line)
+		}
+		assert.True(t, matched, line)
+	}
+	for _, line := range []string{
+		"\tat com.example.Main.main(Main.java:1)",
+		"Traceback (most recent call last):",
+		"2018 was a good year",
+	} {
+		for _, re := range timestampPatterns {
+			assert.False(t, re.MatchString(line), line)
+		}
+	}
+}
+
+func TestStackTraceHandler(t *testing.T) {
+	for language, logs := range map[string][][]string{
+		config.JavaLanguage: {{
+			"2018-10-16 12:00:00 ERROR request failed",
+			"java.lang.IllegalStateException: boom",
+			"\tat com.example.App.handle(App.java:10)",
+			"\t... 5 more",
+			"Caused by: java.io.IOException: broken pipe",
+			"\tat com.example.App.write(App.java:20)",
+		}, {
+			"2018-10-16 12:00:01 INFO retrying",
+		}},
+		config.PythonLanguage: {{
+			"ERROR:root:request failed",
+			"Traceback (most recent call last):",
+			"  File \"app.py\", line 10, in <module>",
+			"    main()",
+			"ValueError: boom",
+		}, {
+			"INFO:root:retrying",
+		}},
+		config.CSharpLanguage: {{
+			"2018-10-16 12:00:00 ERROR request failed",
+			"System.InvalidOperationException: boom ---> System.IO.IOException: broken pipe",
+			"   at App.Write() in C:\\App\\Program.cs:line 20",
+			"   --- End of inner exception stack trace ---",
+			"   at App.Main() in C:\\App\\Program.cs:line 10",
+		}, {
+			"2018-10-16 12:00:01 INFO retrying",
+		}},
+	} {
+		outputChan := make(chan *Output, 10)
+		h := NewStackTraceHandler(outputChan, stackTracePatterns[language], 10*time.Millisecond, NewUnwrapper())
+		h.Start()
+		for _, lines := range logs {
+			for _, line := range lines {
+				h.Handle([]byte(line))
+			}
+		}
+		for _, lines := range logs {
+			assert.Equal(t, strings.Join(lines, "\\n"), string((<-outputChan).Content), language)
+		}
+		h.Stop()
+	}
+}
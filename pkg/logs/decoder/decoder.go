@@ -7,8 +7,11 @@ package decoder
 
 import (
 	"bytes"
+	"strconv"
+	"strings"
 
 	"github.com/DataDog/datadog-agent/pkg/logs/config"
+	"github.com/DataDog/datadog-agent/pkg/logs/metrics"
 )
 
 // contentLenLimit represents the length limit above which we want to truncate the output content
@@ -45,6 +48,8 @@ type Decoder struct {
 
 	lineBuffer  *bytes.Buffer
 	lineHandler LineHandler
+	// metrics counts the bytes and the lines decoded for the source
+	metrics *metrics.SourceMetrics
 }
 
 // InitializeDecoder returns a properly initialized Decoder
@@ -56,22 +61,44 @@ func InitializeDecoder(source *config.LogSource) *Decoder {
 	for _, rule := range source.Config.ProcessingRules {
 		switch rule.Type {
 		case config.MultiLine:
-			var lineUnwrapper LineUnwrapper
-			switch source.Config.Type {
-			case config.DockerType:
-				lineUnwrapper = NewDockerUnwrapper()
-			default:
-				lineUnwrapper = NewUnwrapper()
-			}
-			lineHandler = NewMultiLineHandler(outputChan, rule.Reg, defaultFlushTimeout, lineUnwrapper)
+			lineHandler = NewMultiLineHandler(outputChan, rule.Reg, defaultFlushTimeout, newLineUnwrapper(source))
 			break
 		}
 	}
+	if lineHandler == nil {
+		detection := autoMultiLineDetection(source)
+		if continuationRe, isLanguage := stackTracePatterns[strings.ToLower(detection)]; isLanguage {
+			lineHandler = NewStackTraceHandler(outputChan, continuationRe, defaultFlushTimeout, newLineUnwrapper(source))
+		} else if enabled, _ := strconv.ParseBool(detection); enabled {
+			lineHandler = NewAutoMultiLineHandler(outputChan, defaultFlushTimeout, newLineUnwrapper(source))
+		}
+	}
 	if lineHandler == nil {
 		lineHandler = NewSingleLineHandler(outputChan)
 	}
 
-	return New(inputChan, outputChan, lineHandler)
+	decoder := New(inputChan, outputChan, lineHandler)
+	decoder.metrics = source.Metrics
+	return decoder
+}
+
+// autoMultiLineDetection returns the auto_multi_line_detection of source,
+// the global one when it has none
+func autoMultiLineDetection(source *config.LogSource) string {
+	if source.Config.AutoMultiLineDetection != "" {
+		return source.Config.AutoMultiLineDetection
+	}
+	return config.LogsAgent.GetString("logs_config.auto_multi_line_detection")
+}
+
+// newLineUnwrapper returns the LineUnwrapper of the lines of source
+func newLineUnwrapper(source *config.LogSource) LineUnwrapper {
+	switch source.Config.Type {
+	case config.DockerType:
+		return NewDockerUnwrapper()
+	default:
+		return NewUnwrapper()
+	}
 }
 
 // New returns an initialized Decoder
@@ -107,6 +134,7 @@ func (d *Decoder) run() {
 
 // decodeIncomingData splits raw data based on '\n', creates and processes new lines
 func (d *Decoder) decodeIncomingData(inBuf []byte) {
+	d.metrics.AddBytesRead(len(inBuf))
 	i, j := 0, 0
 	n := len(inBuf)
 	maxj := contentLenLimit - d.lineBuffer.Len()
@@ -133,5 +161,6 @@ func (d *Decoder) sendLine() {
 	content := make([]byte, d.lineBuffer.Len())
 	copy(content, d.lineBuffer.Bytes())
 	d.lineBuffer.Reset()
+	d.metrics.AddMessagesDecoded(1)
 	d.lineHandler.Handle(content)
 }
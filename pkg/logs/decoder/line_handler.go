@@ -98,14 +98,16 @@ func (h *SingleLineHandler) process(line []byte) {
 const defaultFlushTimeout = 1000 * time.Millisecond
 
 // MultiLineHandler reads lines from lineChan and uses lineBuffer to send them
-// when a new line matches with re or flushTimer is fired
+// when a new line matches with re, or doesn't match with continuationRe when
+// it is set, or flushTimer is fired
 type MultiLineHandler struct {
-	lineChan      chan []byte
-	outputChan    chan *Output
-	lineBuffer    *LineBuffer
-	lineUnwrapper LineUnwrapper
-	newContentRe  *regexp.Regexp
-	flushTimeout  time.Duration
+	lineChan       chan []byte
+	outputChan     chan *Output
+	lineBuffer     *LineBuffer
+	lineUnwrapper  LineUnwrapper
+	newContentRe   *regexp.Regexp
+	continuationRe *regexp.Regexp
+	flushTimeout   time.Duration
 }
 
 // NewMultiLineHandler returns a new MultiLineHandler
@@ -120,6 +122,20 @@ func NewMultiLineHandler(outputChan chan *Output, newContentRe *regexp.Regexp, f
 	}
 }
 
+// NewStackTraceHandler returns a new MultiLineHandler appending the lines
+// matching continuationRe, like the lines of a stack trace, to the content
+// before them
+func NewStackTraceHandler(outputChan chan *Output, continuationRe *regexp.Regexp, flushTimeout time.Duration, lineUnwrapper LineUnwrapper) *MultiLineHandler {
+	return &MultiLineHandler{
+		lineChan:       make(chan []byte),
+		outputChan:     outputChan,
+		lineBuffer:     NewLineBuffer(),
+		lineUnwrapper:  lineUnwrapper,
+		continuationRe: continuationRe,
+		flushTimeout:   flushTimeout,
+	}
+}
+
 // Handle forward lines to lineChan to process them
 func (h *MultiLineHandler) Handle(content []byte) {
 	h.lineChan <- content
@@ -164,7 +180,7 @@ func (h *MultiLineHandler) run() {
 // When lines are too long, they are truncated
 func (h *MultiLineHandler) process(line []byte) {
 	unwrappedLine := h.lineUnwrapper.Unwrap(line)
-	if h.newContentRe.Match(unwrappedLine) {
+	if h.isNewContent(unwrappedLine) {
 		// send content from lineBuffer
 		h.sendContent()
 	}
@@ -188,6 +204,14 @@ func (h *MultiLineHandler) process(line []byte) {
 	}
 }
 
+// isNewContent returns true if the line starts a new content
+func (h *MultiLineHandler) isNewContent(line []byte) bool {
+	if h.continuationRe != nil {
+		return !h.continuationRe.Match(line)
+	}
+	return h.newContentRe.Match(line)
+}
+
 // sendContent forwards the content from lineBuffer to outputChan
 func (h *MultiLineHandler) sendContent() {
 	defer h.lineBuffer.Reset()
@@ -0,0 +1,158 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2018 Datadog, Inc.
+
+package diagnostic
+
+import (
+	"sync"
+	"sync/atomic"
+
+	"github.com/DataDog/datadog-agent/pkg/logs/message"
+)
+
+// listenerBufferSize is the number of messages buffered per listener,
+// messages are dropped when a listener can't keep up
+const listenerBufferSize = 100
+
+// Filters selects the messages sent to a listener, empty fields match everything.
+type Filters struct {
+	Source  string
+	Service string
+}
+
+// Message is a processed log record, with the metadata helping to understand
+// what the pipeline did with it.
+type Message struct {
+	Integration  string   `json:"integration"`
+	Type         string   `json:"type"`
+	Source       string   `json:"source"`
+	Service      string   `json:"service"`
+	Tags         []string `json:"tags"`
+	AppliedRules []string `json:"applied_rules"`
+	Dropped      bool     `json:"dropped"`
+	Content      string   `json:"content"`
+}
+
+// globalReceiver receives the messages of all the pipelines
+var globalReceiver = NewReceiver()
+
+// Subscribe registers a listener on the messages of all the pipelines
+func Subscribe(filters Filters) (<-chan Message, func()) {
+	return globalReceiver.Subscribe(filters)
+}
+
+// IsEnabled returns whether someone listens to the messages of the pipelines
+func IsEnabled() bool {
+	return globalReceiver.IsEnabled()
+}
+
+// HandleMessage dispatches a message of a pipeline to the listeners
+func HandleMessage(msg message.Message, content []byte, appliedRules []string, dropped bool) {
+	globalReceiver.HandleMessage(msg, content, appliedRules, dropped)
+}
+
+type listener struct {
+	filters Filters
+	out     chan Message
+}
+
+func (l *listener) match(msg Message) bool {
+	return (l.filters.Source == "" || l.filters.Source == msg.Source) &&
+		(l.filters.Service == "" || l.filters.Service == msg.Service)
+}
+
+// Receiver dispatches the processed messages to the listeners.
+type Receiver struct {
+	mu        sync.RWMutex
+	listeners map[int]*listener
+	nextID    int
+	// enabled is 1 when there is at least one listener, read without lock
+	// by the processors on each message
+	enabled int32
+}
+
+// NewReceiver returns a new Receiver
+func NewReceiver() *Receiver {
+	return &Receiver{
+		listeners: make(map[int]*listener),
+	}
+}
+
+// Subscribe returns a channel of the messages matching the filters, and a
+// function to call to stop receiving them.
+func (r *Receiver) Subscribe(filters Filters) (<-chan Message, func()) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	id := r.nextID
+	r.nextID++
+	l := &listener{
+		filters: filters,
+		out:     make(chan Message, listenerBufferSize),
+	}
+	r.listeners[id] = l
+	atomic.StoreInt32(&r.enabled, 1)
+
+	var once sync.Once
+	unsubscribe := func() {
+		once.Do(func() {
+			r.mu.Lock()
+			defer r.mu.Unlock()
+			delete(r.listeners, id)
+			if len(r.listeners) == 0 {
+				atomic.StoreInt32(&r.enabled, 0)
+			}
+		})
+	}
+	return l.out, unsubscribe
+}
+
+// IsEnabled returns whether there is at least one listener
+func (r *Receiver) IsEnabled() bool {
+	return atomic.LoadInt32(&r.enabled) == 1
+}
+
+// HandleMessage dispatches a message to the listeners without blocking.
+// content is the message after the processing rules, or the original
+// content if the message was dropped.
+func (r *Receiver) HandleMessage(msg message.Message, content []byte, appliedRules []string, dropped bool) {
+	if !r.IsEnabled() {
+		return
+	}
+	m := newMessage(msg, content, appliedRules, dropped)
+
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	for _, l := range r.listeners {
+		if !l.match(m) {
+			continue
+		}
+		select {
+		case l.out <- m:
+		default:
+			// the listener is too slow, don't block the pipeline
+		}
+	}
+}
+
+func newMessage(msg message.Message, content []byte, appliedRules []string, dropped bool) Message {
+	m := Message{
+		AppliedRules: appliedRules,
+		Dropped:      dropped,
+		Content:      string(content),
+	}
+	origin := msg.GetOrigin()
+	if origin == nil || origin.LogSource == nil {
+		return m
+	}
+	m.Integration = origin.LogSource.Name
+	if cfg := origin.LogSource.Config; cfg != nil {
+		m.Type = cfg.Type
+		m.Source = cfg.Source
+		m.Service = cfg.Service
+		m.Tags = origin.Tags()
+	}
+	return m
+}
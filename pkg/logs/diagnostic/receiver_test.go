@@ -0,0 +1,73 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2018 Datadog, Inc.
+
+package diagnostic
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/DataDog/datadog-agent/pkg/logs/config"
+	"github.com/DataDog/datadog-agent/pkg/logs/message"
+)
+
+func newTestMessage(source, service, content string) message.Message {
+	logSource := config.NewLogSource("my-integration", &config.LogsConfig{
+		Type:    config.FileType,
+		Source:  source,
+		Service: service,
+		Tags:    []string{"env:test"},
+	})
+	return message.New([]byte(content), message.NewOrigin(logSource), nil)
+}
+
+func TestReceiverDisabledWithoutListener(t *testing.T) {
+	r := NewReceiver()
+	assert.False(t, r.IsEnabled())
+
+	_, unsubscribe := r.Subscribe(Filters{})
+	assert.True(t, r.IsEnabled())
+
+	unsubscribe()
+	unsubscribe()
+	assert.False(t, r.IsEnabled())
+}
+
+func TestReceiverFilters(t *testing.T) {
+	r := NewReceiver()
+	all, unsubscribeAll := r.Subscribe(Filters{})
+	defer unsubscribeAll()
+	nginx, unsubscribeNginx := r.Subscribe(Filters{Source: "nginx", Service: "web"})
+	defer unsubscribeNginx()
+
+	r.HandleMessage(newTestMessage("redis", "cache", "foo"), []byte("foo"), nil, false)
+	r.HandleMessage(newTestMessage("nginx", "web", "bar"), []byte("[masked]"), []string{"mask_sequences"}, false)
+
+	assert.Len(t, all, 2)
+	assert.Len(t, nginx, 1)
+
+	msg := <-nginx
+	assert.Equal(t, Message{
+		Integration:  "my-integration",
+		Type:         config.FileType,
+		Source:       "nginx",
+		Service:      "web",
+		Tags:         []string{"source:nginx", "env:test"},
+		AppliedRules: []string{"mask_sequences"},
+		Content:      "[masked]",
+	}, msg)
+}
+
+func TestReceiverDoesNotBlock(t *testing.T) {
+	r := NewReceiver()
+	out, unsubscribe := r.Subscribe(Filters{})
+	defer unsubscribe()
+
+	for i := 0; i < listenerBufferSize+10; i++ {
+		r.HandleMessage(newTestMessage("nginx", "web", "foo"), []byte("foo"), nil, false)
+	}
+	assert.Len(t, out, listenerBufferSize)
+}
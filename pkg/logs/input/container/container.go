@@ -8,7 +8,6 @@
 package container
 
 import (
-	"encoding/json"
 	"regexp"
 	"strings"
 
@@ -148,12 +147,10 @@ func (c *Container) parseConfig() *config.LogsConfig {
 	if !exists {
 		return nil
 	}
-	var configs []config.LogsConfig
-	err := json.Unmarshal([]byte(label), &configs)
-	if err != nil || len(configs) < 1 {
-		log.Warnf("Could not parse logs configs, %v is malformed", label)
+	cfg, err := config.ParseJSONConfig(label)
+	if err != nil {
+		log.Warnf("Could not parse logs configs, %v is malformed: %s", label, err)
 		return nil
 	}
-	config := configs[0]
-	return &config
+	return cfg
 }
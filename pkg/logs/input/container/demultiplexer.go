@@ -0,0 +1,127 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2018 Datadog, Inc.
+
+// +build !windows
+
+package container
+
+import (
+	"bytes"
+	"encoding/binary"
+	"io"
+)
+
+// Stream types of the frames of the multiplexed logs
+const (
+	stdoutStream = 1
+	stderrStream = 2
+)
+
+// frameHeaderLength is the length of the header of the frames,
+// [8]byte{STREAM_TYPE, 0, 0, 0, SIZE1, SIZE2, SIZE3, SIZE4}
+const frameHeaderLength = 8
+
+// demultiplexer reads the logs of a container from the docker API and
+// returns them line by line, each line starting with a header holding
+// only its stream type, which is what the docker parser expects.
+// The frames of the multiplexed stream are read using their size instead of
+// being split on new lines, as their size can contain a new line character,
+// the frames continuing a line split by docker, like the lines longer
+// than 16KB, are appended to it without their header and timestamp, and
+// the logs of the containers with a TTY, which are not multiplexed, are
+// sent as stdout.
+type demultiplexer struct {
+	reader  io.ReadCloser
+	tty     bool
+	pending []byte
+	// partial is true when the last content read doesn't end with a new line
+	partial bool
+}
+
+// newDemultiplexer returns a new demultiplexer
+func newDemultiplexer(reader io.ReadCloser, tty bool) *demultiplexer {
+	return &demultiplexer{
+		reader: reader,
+		tty:    tty,
+	}
+}
+
+// Read reads the demultiplexed logs
+func (d *demultiplexer) Read(p []byte) (int, error) {
+	if len(d.pending) == 0 {
+		var err error
+		if d.tty {
+			err = d.readRaw()
+		} else {
+			err = d.readFrame()
+		}
+		if err != nil {
+			return 0, err
+		}
+	}
+	n := copy(p, d.pending)
+	d.pending = d.pending[n:]
+	return n, nil
+}
+
+// Close closes the underlying reader
+func (d *demultiplexer) Close() error {
+	return d.reader.Close()
+}
+
+// readFrame reads the next non empty frame of the multiplexed stream
+func (d *demultiplexer) readFrame() error {
+	var header [frameHeaderLength]byte
+	var payload []byte
+	for len(payload) == 0 {
+		if _, err := io.ReadFull(d.reader, header[:]); err != nil {
+			return err
+		}
+		payload = make([]byte, binary.BigEndian.Uint32(header[4:]))
+		if _, err := io.ReadFull(d.reader, payload); err != nil {
+			return err
+		}
+	}
+	partial := payload[len(payload)-1] != '\n'
+	if d.partial {
+		// drop the timestamp of the frame continuing the line
+		if i := bytes.IndexByte(payload, ' '); i >= 0 {
+			payload = payload[i+1:]
+		}
+		d.pending = payload
+	} else {
+		d.pending = append(streamHeader(header[0]), payload...)
+	}
+	d.partial = partial
+	return nil
+}
+
+// readRaw reads the stream of a container with a TTY
+func (d *demultiplexer) readRaw() error {
+	buf := make([]byte, 4096)
+	n, err := d.reader.Read(buf)
+	if n == 0 {
+		return err
+	}
+	for _, line := range bytes.SplitAfter(buf[:n], []byte{'\n'}) {
+		if len(line) == 0 {
+			continue
+		}
+		if !d.partial {
+			d.pending = append(d.pending, streamHeader(stdoutStream)...)
+		}
+		d.pending = append(d.pending, line...)
+		d.partial = line[len(line)-1] != '\n'
+	}
+	return nil
+}
+
+// streamHeader returns the header of the lines of a stream
+func streamHeader(stream byte) []byte {
+	if stream != stderrStream {
+		stream = stdoutStream
+	}
+	return []byte{stream, 0, 0, 0, 0, 0, 0, 0}
+}
@@ -0,0 +1,62 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2018 Datadog, Inc.
+
+// +build !windows
+
+package container
+
+import (
+	"bytes"
+	"encoding/binary"
+	"io/ioutil"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// frame returns a frame of a multiplexed stream
+func frame(stream byte, payload string) []byte {
+	header := []byte{stream, 0, 0, 0, 0, 0, 0, 0}
+	binary.BigEndian.PutUint32(header[4:], uint32(len(payload)))
+	return append(header, payload...)
+}
+
+func TestDemultiplexerReadsFrames(t *testing.T) {
+	var stream []byte
+	// a payload of 10 bytes has a new line in its size
+	stream = append(stream, frame(stdoutStream, "ts1 12345\n")...)
+	stream = append(stream, frame(stderrStream, "ts2 error\n")...)
+	stream = append(stream, frame(stdoutStream, "")...)
+	// a line split in several frames by docker
+	stream = append(stream, frame(stdoutStream, "ts3 first part, ")...)
+	stream = append(stream, frame(stdoutStream, "ts3 second part\n")...)
+
+	content, err := ioutil.ReadAll(newDemultiplexer(ioutil.NopCloser(bytes.NewReader(stream)), false))
+	require.NoError(t, err)
+	assert.Equal(t, "\x01\x00\x00\x00\x00\x00\x00\x00ts1 12345\n"+
+		"\x02\x00\x00\x00\x00\x00\x00\x00ts2 error\n"+
+		"\x01\x00\x00\x00\x00\x00\x00\x00ts3 first part, second part\n", string(content))
+}
+
+func TestDemultiplexerReadsTruncatedFrame(t *testing.T) {
+	stream := frame(stdoutStream, "ts1 message\n")
+	_, err := ioutil.ReadAll(newDemultiplexer(ioutil.NopCloser(bytes.NewReader(stream[:len(stream)-2])), false))
+	assert.Error(t, err)
+}
+
+func TestDemultiplexerReadsTTYStream(t *testing.T) {
+	d := newDemultiplexer(ioutil.NopCloser(bytes.NewReader([]byte("ts1 first\nts2 sec"))), true)
+	buf := make([]byte, 4096)
+	n, err := d.Read(buf)
+	require.NoError(t, err)
+	assert.Equal(t, "\x01\x00\x00\x00\x00\x00\x00\x00ts1 first\n\x01\x00\x00\x00\x00\x00\x00\x00ts2 sec", string(buf[:n]))
+
+	// the end of the line doesn't get a header
+	d.reader = ioutil.NopCloser(bytes.NewReader([]byte("ond\n")))
+	n, err = d.Read(buf)
+	require.NoError(t, err)
+	assert.Equal(t, "ond\n", string(buf[:n]))
+}
@@ -16,6 +16,7 @@ import (
 
 	"github.com/DataDog/datadog-agent/pkg/tagger"
 	dockerutil "github.com/DataDog/datadog-agent/pkg/util/docker"
+	"github.com/DataDog/datadog-agent/pkg/util/ratelimit"
 	log "github.com/cihub/seelog"
 
 	"github.com/DataDog/datadog-agent/pkg/logs/auditor"
@@ -31,34 +32,44 @@ import (
 const defaultSleepDuration = 1 * time.Second
 const tagsUpdatePeriod = 10 * time.Second
 
+// parseErrorLogLimiter prevents a container writing unexpected content from
+// flooding the agent logs, shared by all the docker tailers
+var parseErrorLogLimiter = ratelimit.NewTokenBucket("logs_docker_parse_errors", 1, 10)
+
 // DockerTailer tails logs coming from stdout and stderr of a docker container
 // With docker api, there is no way to know if a log comes from strout or stderr
 // so if we want to capture the severity, we need to tail both in two goroutines
 type DockerTailer struct {
 	ContainerID   string
-	outputChan    chan message.Message
+	output        *message.Ring
 	decoder       *decoder.Decoder
 	reader        io.ReadCloser
 	cli           *client.Client
 	source        *config.LogSource
 	containerTags []string
+	// lastOffset is the timestamp of the last log forwarded
+	lastOffset string
 
 	sleepDuration time.Duration
 	shouldStop    bool
-	stop          chan struct{}
-	done          chan struct{}
+	// ended is closed once the logs stream ended and all its logs have
+	// been forwarded
+	ended chan struct{}
+	stop  chan struct{}
+	done  chan struct{}
 }
 
 // NewDockerTailer returns a new DockerTailer
-func NewDockerTailer(cli *client.Client, containerID string, source *config.LogSource, outputChan chan message.Message) *DockerTailer {
+func NewDockerTailer(cli *client.Client, containerID string, source *config.LogSource, output *message.Ring) *DockerTailer {
 	return &DockerTailer{
 		ContainerID: containerID,
-		outputChan:  outputChan,
+		output:      output,
 		decoder:     decoder.InitializeDecoder(source),
 		source:      source,
 		cli:         cli,
 
 		sleepDuration: defaultSleepDuration,
+		ended:         make(chan struct{}),
 		stop:          make(chan struct{}, 1),
 		done:          make(chan struct{}, 1),
 	}
@@ -80,6 +91,15 @@ func (dt *DockerTailer) Stop() {
 	<-dt.done
 }
 
+// waitForEnd waits up to timeout for the logs stream to end, which happens
+// once all the logs of an exited container have been read
+func (dt *DockerTailer) waitForEnd(timeout time.Duration) {
+	select {
+	case <-dt.ended:
+	case <-time.After(timeout):
+	}
+}
+
 // tailFromBeginning starts the tailing from the beginning
 // of the container logs
 func (dt *DockerTailer) tailFromBeginning() error {
@@ -117,6 +137,10 @@ func (dt *DockerTailer) nextLogSinceDate(lastTs string) string {
 // setupReader sets up the reader that reads the container's logs
 // with the proper configuration
 func (dt *DockerTailer) setupReader(from string) (io.ReadCloser, error) {
+	info, err := dt.cli.ContainerInspect(context.Background(), dt.ContainerID)
+	if err != nil {
+		return nil, err
+	}
 	options := types.ContainerLogsOptions{
 		ShowStdout: true,
 		ShowStderr: true,
@@ -125,7 +149,11 @@ func (dt *DockerTailer) setupReader(from string) (io.ReadCloser, error) {
 		Details:    false,
 		Since:      from,
 	}
-	return dt.cli.ContainerLogs(context.Background(), dt.ContainerID, options)
+	reader, err := dt.cli.ContainerLogs(context.Background(), dt.ContainerID, options)
+	if err != nil {
+		return nil, err
+	}
+	return newDemultiplexer(reader, info.Config != nil && info.Config.Tty), nil
 }
 
 // tailFrom sets up and starts the tailer
@@ -189,19 +217,23 @@ func (dt *DockerTailer) forwardMessages() {
 	defer func() {
 		// the decoder has successfully been flushed
 		dt.shouldStop = true
+		close(dt.ended)
 		dt.done <- struct{}{}
 	}()
 	for output := range dt.decoder.OutputChan {
 		ts, sev, content, err := parser.ParseMessage(output.Content)
 		if err != nil {
-			log.Warn(err)
+			if parseErrorLogLimiter.Allow() {
+				log.Warn(err)
+			}
 			continue
 		}
 		origin := message.NewOrigin(dt.source)
 		origin.Offset = ts
 		origin.Identifier = dt.Identifier()
 		origin.SetTags(dt.containerTags)
-		dt.outputChan <- message.New(content, origin, sev)
+		dt.lastOffset = ts
+		dt.output.Push(message.New(content, origin, sev))
 	}
 }
 
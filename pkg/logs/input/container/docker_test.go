@@ -10,6 +10,7 @@ package container
 import (
 	"errors"
 	"testing"
+	"time"
 
 	parser "github.com/DataDog/datadog-agent/pkg/logs/docker"
 	"github.com/stretchr/testify/suite"
@@ -84,6 +85,20 @@ func (suite *DockerTailerTestSuite) TestParseMessage() {
 
 }
 
+func (suite *DockerTailerTestSuite) TestDockerTailerWaitForEnd() {
+	suite.tailer.ended = make(chan struct{})
+	start := time.Now()
+	suite.tailer.waitForEnd(10 * time.Millisecond)
+	suite.True(time.Since(start) >= 10*time.Millisecond)
+
+	// the tailers of the exited containers are stopped as soon as their
+	// logs are forwarded
+	close(suite.tailer.ended)
+	start = time.Now()
+	suite.tailer.waitForEnd(time.Minute)
+	suite.True(time.Since(start) < time.Minute)
+}
+
 func TestDockerTailerTestSuite(t *testing.T) {
 	suite.Run(t, new(DockerTailerTestSuite))
 }
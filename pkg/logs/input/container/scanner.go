@@ -10,11 +10,14 @@ package container
 import (
 	"context"
 	"fmt"
+	"strconv"
 	"time"
 
 	"github.com/DataDog/datadog-agent/pkg/tagger"
 	log "github.com/cihub/seelog"
 	"github.com/docker/docker/api/types"
+	"github.com/docker/docker/api/types/events"
+	"github.com/docker/docker/api/types/filters"
 	"github.com/docker/docker/client"
 
 	"github.com/DataDog/datadog-agent/pkg/logs/auditor"
@@ -26,6 +29,10 @@ import (
 
 const scanPeriod = 10 * time.Second
 
+// exitedContainerTimeout is the time the tailers of the exited containers
+// are kept to forward the logs written before they exited
+const exitedContainerTimeout = 5 * time.Second
+
 // A Scanner listens for stdout and stderr of containers
 type Scanner struct {
 	pp        pipeline.Provider
@@ -83,15 +90,35 @@ func (s *Scanner) Stop() {
 	stopper.Stop()
 }
 
-// run checks periodically which docker containers are running until stop
+// run checks periodically which docker containers are running until stop,
+// and tails the containers as soon as they start not to miss the logs of
+// the short-lived ones
 func (s *Scanner) run() {
 	scanTicker := time.NewTicker(scanPeriod)
-	defer scanTicker.Stop()
+	ctx, cancel := context.WithCancel(context.Background())
+	defer func() {
+		scanTicker.Stop()
+		cancel()
+	}()
+	since := time.Now().Unix()
+	startEvents, errs := s.subscribeToStartEvents(ctx, since)
 	for {
 		select {
 		case <-scanTicker.C:
+			if startEvents == nil {
+				// the containers started meanwhile are replayed
+				startEvents, errs = s.subscribeToStartEvents(ctx, since)
+			}
 			// check all the containers running on the host and start new tailers if needed
 			s.scan(true)
+		case event := <-startEvents:
+			since = event.Time
+			s.tailStartedContainer(event.Actor.ID)
+		case err := <-errs:
+			// the next scan subscribes again, the connection may have
+			// been reset by the docker daemon
+			log.Debugf("Can't listen to the start of the containers: %s", err)
+			startEvents, errs = nil, nil
 		case <-s.stop:
 			// no docker container should be tailed anymore
 			return
@@ -99,6 +126,39 @@ func (s *Scanner) run() {
 	}
 }
 
+// subscribeToStartEvents returns the events of the containers started since
+// a time, in seconds since the epoch
+func (s *Scanner) subscribeToStartEvents(ctx context.Context, since int64) (<-chan events.Message, <-chan error) {
+	args := filters.NewArgs()
+	args.Add("type", "container")
+	args.Add("event", "start")
+	return s.cli.Events(ctx, types.EventsOptions{
+		Since:   strconv.FormatInt(since, 10),
+		Filters: args,
+	})
+}
+
+// tailStartedContainer tails a container which just started from the
+// beginning of its logs, the container may have exited already, its
+// tailer is then dismissed on the next scan once its logs are forwarded
+func (s *Scanner) tailStartedContainer(containerID string) {
+	if _, isTailed := s.tailers[containerID]; isTailed {
+		return
+	}
+	args := filters.NewArgs()
+	args.Add("id", containerID)
+	containers, err := s.cli.ContainerList(context.Background(), types.ContainerListOptions{All: true, Filters: args})
+	if err != nil || len(containers) == 0 {
+		// the next scan tails it if it's still running
+		return
+	}
+	source := NewContainer(containers[0]).findSource(s.sources)
+	if source == nil {
+		return
+	}
+	s.setupTailer(s.cli, containers[0], source, true, s.pp.NextPipelineInput())
+}
+
 // scan checks for new containers we're expected to
 // tail, as well as stopped containers or containers that
 // restarted
@@ -114,11 +174,18 @@ func (s *Scanner) scan(tailFromBeginning bool) {
 		}
 		tailer, isTailed := s.tailers[container.ID]
 		if isTailed && tailer.shouldStop {
-			continue
-		}
-		if !isTailed {
+			// the logs stream ended while the container is running, like when
+			// it restarted, the tailing resumes after the last log forwarded
+			tailer.Stop()
+			delete(s.tailers, container.ID)
+			succeeded := s.resumeTailer(s.cli, tailer, source, s.pp.NextPipelineInput())
+			if !succeeded {
+				// the setup failed, let's try to tail this container in the next scan
+				continue
+			}
+		} else if !isTailed {
 			// setup a new tailer
-			succeeded := s.setupTailer(s.cli, container, source, tailFromBeginning, s.pp.NextPipelineChan())
+			succeeded := s.setupTailer(s.cli, container, source, tailFromBeginning, s.pp.NextPipelineInput())
 			if !succeeded {
 				// the setup failed, let's try to tail this container in the next scan
 				continue
@@ -162,11 +229,13 @@ func (s *Scanner) setup() error {
 
 // setupTailer sets one tailer, making it tail from the beginning or the end,
 // returns true if the setup succeeded, false otherwise
-func (s *Scanner) setupTailer(cli *client.Client, container types.Container, source *config.LogSource, tailFromBeginning bool, outputChan chan message.Message) bool {
+func (s *Scanner) setupTailer(cli *client.Client, container types.Container, source *config.LogSource, tailFromBeginning bool, output *message.Ring) bool {
 	log.Info("Detected container ", container.Image, " - ", s.humanReadableContainerID(container.ID))
-	t := NewDockerTailer(cli, container.ID, source, outputChan)
+	t := NewDockerTailer(cli, container.ID, source, output)
 	var err error
-	if tailFromBeginning {
+	// the containers restarted after being stopped resume from their last
+	// log committed
+	if tailFromBeginning && s.auditor.GetLastCommittedOffset(t.Identifier()) == "" {
 		err = t.tailFromBeginning()
 	} else {
 		err = t.recoverTailing(s.auditor)
@@ -179,10 +248,34 @@ func (s *Scanner) setupTailer(cli *client.Client, container types.Container, sou
 	return true
 }
 
-// dismissTailer stops the tailer and removes it from the list of active tailers
+// resumeTailer replaces a tailer which stopped, starting the new one after
+// the last log forwarded by the previous one, or the last one committed,
+// returns true if the setup succeeded, false otherwise
+func (s *Scanner) resumeTailer(cli *client.Client, previous *DockerTailer, source *config.LogSource, output *message.Ring) bool {
+	log.Info("Resume tailing container ", s.humanReadableContainerID(previous.ContainerID))
+	t := NewDockerTailer(cli, previous.ContainerID, source, output)
+	var err error
+	if previous.lastOffset != "" {
+		err = t.tailFrom(t.nextLogSinceDate(previous.lastOffset))
+	} else {
+		err = t.recoverTailing(s.auditor)
+	}
+	if err != nil {
+		log.Warn(err)
+		return false
+	}
+	s.tailers[previous.ContainerID] = t
+	return true
+}
+
+// dismissTailer stops the tailer once the logs written before its container
+// exited have been forwarded, and removes it from the list of active tailers
 func (s *Scanner) dismissTailer(tailer *DockerTailer) {
 	// stop the tailer in another routine as we don't want to block here
-	go tailer.Stop()
+	go func() {
+		tailer.waitForEnd(exitedContainerTimeout)
+		tailer.Stop()
+	}()
 	delete(s.tailers, tailer.ContainerID)
 }
 
@@ -0,0 +1,102 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2018 Datadog, Inc.
+
+// +build cri
+
+package cri
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"os"
+	"time"
+
+	"google.golang.org/grpc"
+	runtimeapi "k8s.io/kubernetes/pkg/kubelet/apis/cri/runtime/v1alpha2"
+)
+
+// requestTimeout is the timeout of the connection to the runtime and of
+// its requests
+const requestTimeout = 5 * time.Second
+
+// defaultSocketPaths are the sockets of the runtime services of the common
+// CRI runtimes, tried in order when cri_socket_path is not set
+var defaultSocketPaths = []string{
+	"/var/run/containerd/containerd.sock",
+	"/run/containerd/containerd.sock",
+	"/var/run/crio/crio.sock",
+}
+
+// runtimeService lists the containers of a CRI runtime and returns their
+// status, which holds the path of their log file
+type runtimeService interface {
+	ListContainers() ([]*runtimeapi.Container, error)
+	ContainerStatus(containerID string) (*runtimeapi.ContainerStatus, error)
+}
+
+// client is a runtimeService using the gRPC API of a runtime
+type client struct {
+	runtime runtimeapi.RuntimeServiceClient
+}
+
+// newClient returns a client of the runtime service listening on the
+// socketPath, or on the first default socket found when empty
+func newClient(socketPath string) (*client, error) {
+	if socketPath == "" {
+		for _, path := range defaultSocketPaths {
+			if _, err := os.Stat(path); err == nil {
+				socketPath = path
+				break
+			}
+		}
+		if socketPath == "" {
+			return nil, fmt.Errorf("no CRI socket found in %v, set cri_socket_path", defaultSocketPaths)
+		}
+	}
+	conn, err := grpc.Dial(socketPath,
+		grpc.WithInsecure(),
+		grpc.WithBlock(),
+		grpc.WithTimeout(requestTimeout),
+		grpc.WithDialer(func(addr string, timeout time.Duration) (net.Conn, error) {
+			return net.DialTimeout("unix", addr, timeout)
+		}),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("can't connect to the CRI runtime on %s: %v", socketPath, err)
+	}
+	return &client{
+		runtime: runtimeapi.NewRuntimeServiceClient(conn),
+	}, nil
+}
+
+// ListContainers returns the running containers
+func (c *client) ListContainers() ([]*runtimeapi.Container, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), requestTimeout)
+	defer cancel()
+	response, err := c.runtime.ListContainers(ctx, &runtimeapi.ListContainersRequest{
+		Filter: &runtimeapi.ContainerFilter{
+			State: &runtimeapi.ContainerStateValue{State: runtimeapi.ContainerState_CONTAINER_RUNNING},
+		},
+	})
+	if err != nil {
+		return nil, err
+	}
+	return response.Containers, nil
+}
+
+// ContainerStatus returns the status of a container
+func (c *client) ContainerStatus(containerID string) (*runtimeapi.ContainerStatus, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), requestTimeout)
+	defer cancel()
+	response, err := c.runtime.ContainerStatus(ctx, &runtimeapi.ContainerStatusRequest{ContainerId: containerID})
+	if err != nil {
+		return nil, err
+	}
+	if response.Status == nil {
+		return nil, fmt.Errorf("no status for the container %s", containerID)
+	}
+	return response.Status, nil
+}
@@ -0,0 +1,227 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2018 Datadog, Inc.
+
+// +build cri
+
+package cri
+
+import (
+	"regexp"
+	"strings"
+	"time"
+
+	log "github.com/cihub/seelog"
+	runtimeapi "k8s.io/kubernetes/pkg/kubelet/apis/cri/runtime/v1alpha2"
+
+	"github.com/DataDog/datadog-agent/pkg/logs/config"
+)
+
+const scanPeriod = 10 * time.Second
+
+// The labels set by the kubelet on the containers of the pods
+const (
+	podNameLabel       = "io.kubernetes.pod.name"
+	podNamespaceLabel  = "io.kubernetes.pod.namespace"
+	containerNameLabel = "io.kubernetes.container.name"
+)
+
+// Launcher looks for the containers run by a CRI runtime, like containerd
+// or CRI-O, matching the cri sources, and has their log files tailed by the
+// registry, where the runtime reports it writes them. It does not need
+// docker nor the kubelet.
+type Launcher struct {
+	sources          []*config.LogSource
+	registry         SourceRegistry
+	runtime          runtimeService
+	containerSources map[string]*config.LogSource
+	isRunning        bool
+	stop             chan struct{}
+}
+
+// New returns a new Launcher
+func New(sources []*config.LogSource, registry SourceRegistry) *Launcher {
+	return &Launcher{
+		sources:          filterSources(sources),
+		registry:         registry,
+		containerSources: make(map[string]*config.LogSource),
+		stop:             make(chan struct{}),
+	}
+}
+
+// Start starts the Launcher
+func (l *Launcher) Start() {
+	if len(l.sources) == 0 {
+		return
+	}
+	runtime, err := newClient(config.LogsAgent.GetString("cri_socket_path"))
+	if err != nil {
+		log.Errorf("Can't tail the logs of the CRI containers: %s", err)
+		l.reportErrorToAllSources(err)
+		return
+	}
+	l.runtime = runtime
+	l.scan()
+	go l.run()
+	l.isRunning = true
+}
+
+// Stop stops the Launcher and removes the sources of the containers
+func (l *Launcher) Stop() {
+	if !l.isRunning {
+		return
+	}
+	l.stop <- struct{}{}
+	for containerID, source := range l.containerSources {
+		l.registry.RemoveSource(source)
+		delete(l.containerSources, containerID)
+	}
+}
+
+// run checks periodically which containers are running until stop
+func (l *Launcher) run() {
+	scanTicker := time.NewTicker(scanPeriod)
+	defer scanTicker.Stop()
+	for {
+		select {
+		case <-scanTicker.C:
+			l.scan()
+		case <-l.stop:
+			return
+		}
+	}
+}
+
+// scan adds the sources of the new containers and removes the ones of the
+// containers which are gone
+func (l *Launcher) scan() {
+	containers, err := l.runtime.ListContainers()
+	if err != nil {
+		log.Warnf("Can't list the CRI containers: %s", err)
+		l.reportErrorToAllSources(err)
+		return
+	}
+	for _, source := range l.sources {
+		source.Status.Success()
+	}
+
+	containersToTail := make(map[string]bool)
+	for _, container := range containers {
+		if _, isTailed := l.containerSources[container.Id]; isTailed {
+			containersToTail[container.Id] = true
+			continue
+		}
+		status, err := l.runtime.ContainerStatus(container.Id)
+		if err != nil {
+			log.Warnf("Can't get the status of the container %s: %s", container.Id, err)
+			continue
+		}
+		if status.LogPath == "" {
+			// the runtime does not write the logs of the container
+			continue
+		}
+		source := findSource(l.sources, containerName(status), containerImage(status))
+		if source == nil {
+			continue
+		}
+		containersToTail[container.Id] = true
+		containerSource := newContainerSource(source, status)
+		log.Infof("Tailing the logs of the container %s from %s", containerName(status), containerSource.Config.Path)
+		l.registry.AddSource(containerSource)
+		l.containerSources[container.Id] = containerSource
+	}
+
+	for containerID, source := range l.containerSources {
+		if !containersToTail[containerID] {
+			l.registry.RemoveSource(source)
+			delete(l.containerSources, containerID)
+		}
+	}
+}
+
+// reportErrorToAllSources changes the status of all sources to Error with err
+func (l *Launcher) reportErrorToAllSources(err error) {
+	for _, source := range l.sources {
+		source.Status.Error(err)
+	}
+}
+
+// containerName returns the name of the container in its pod when it is
+// run by the kubelet, its name in the runtime otherwise
+func containerName(status *runtimeapi.ContainerStatus) string {
+	if name, exists := status.Labels[containerNameLabel]; exists {
+		return name
+	}
+	if status.Metadata != nil {
+		return status.Metadata.Name
+	}
+	return ""
+}
+
+// containerImage returns the image of the container as it was requested
+func containerImage(status *runtimeapi.ContainerStatus) string {
+	if status.Image != nil && status.Image.Image != "" {
+		return status.Image.Image
+	}
+	return status.ImageRef
+}
+
+// findSource returns the source matching the container the most, by image
+// and name, nil when none matches
+func findSource(sources []*config.LogSource, name string, image string) *config.LogSource {
+	var candidate *config.LogSource
+	candidateScore := -1
+	for _, source := range sources {
+		score := 0
+		if source.Config.Image != "" {
+			if !isImageMatch(image, source.Config.Image) {
+				continue
+			}
+			score++
+		}
+		if source.Config.Name != "" {
+			if match, err := regexp.MatchString(source.Config.Name, name); err != nil || !match {
+				continue
+			}
+			score++
+		}
+		if score > candidateScore {
+			candidate, candidateScore = source, score
+		}
+	}
+	return candidate
+}
+
+// isImageMatch returns true if the image, '[<repository>/]image[:<tag>]',
+// matches the filter, '[<repository>/]image[:<tag>]', the tag of the image
+// is ignored when the filter has none
+func isImageMatch(image string, imageFilter string) bool {
+	image = strings.SplitN(image, "@sha256:", 2)[0]
+	if !strings.Contains(imageFilter, ":") {
+		image = strings.SplitN(image, ":", 2)[0]
+	}
+	repository := strings.TrimSuffix(image, imageFilter)
+	return repository != image && (len(repository) == 0 || strings.HasSuffix(repository, "/"))
+}
+
+// newContainerSource returns the file source of the logs of a container,
+// using the CRI format, tagged with its pod, namespace and name when it is
+// run by the kubelet, with its name otherwise
+func newContainerSource(source *config.LogSource, status *runtimeapi.ContainerStatus) *config.LogSource {
+	cfg := *source.Config
+	cfg.Type = config.FileType
+	cfg.Path = status.LogPath
+	cfg.Format = config.CRIFormat
+	cfg.Tags = append([]string{}, source.Config.Tags...)
+	if pod, exists := status.Labels[podNameLabel]; exists {
+		cfg.Tags = append(cfg.Tags,
+			"pod_name:"+pod,
+			"kube_namespace:"+status.Labels[podNamespaceLabel],
+			"kube_container_name:"+containerName(status),
+		)
+	} else {
+		cfg.Tags = append(cfg.Tags, "container_name:"+containerName(status))
+	}
+	return config.NewLogSource(source.Name, &cfg)
+}
@@ -0,0 +1,37 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2018 Datadog, Inc.
+
+// +build !cri
+
+package cri
+
+import (
+	"errors"
+
+	"github.com/DataDog/datadog-agent/pkg/logs/config"
+)
+
+// Launcher reports an error to the cri sources as the CRI support is not
+// compiled in
+type Launcher struct {
+	sources []*config.LogSource
+}
+
+// New returns a new Launcher
+func New(sources []*config.LogSource, registry SourceRegistry) *Launcher {
+	return &Launcher{
+		sources: filterSources(sources),
+	}
+}
+
+// Start reports an error to the cri sources
+func (l *Launcher) Start() {
+	for _, source := range l.sources {
+		source.Status.Error(errors.New("CRI support not compiled in"))
+	}
+}
+
+// Stop does nothing
+func (l *Launcher) Stop() {}
@@ -0,0 +1,116 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2018 Datadog, Inc.
+
+// +build cri
+
+package cri
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	runtimeapi "k8s.io/kubernetes/pkg/kubelet/apis/cri/runtime/v1alpha2"
+
+	"github.com/DataDog/datadog-agent/pkg/logs/config"
+)
+
+type fakeRuntime struct {
+	statuses []*runtimeapi.ContainerStatus
+}
+
+func (f *fakeRuntime) ListContainers() ([]*runtimeapi.Container, error) {
+	var containers []*runtimeapi.Container
+	for _, status := range f.statuses {
+		containers = append(containers, &runtimeapi.Container{Id: status.Id})
+	}
+	return containers, nil
+}
+
+func (f *fakeRuntime) ContainerStatus(containerID string) (*runtimeapi.ContainerStatus, error) {
+	for _, status := range f.statuses {
+		if status.Id == containerID {
+			return status, nil
+		}
+	}
+	return nil, nil
+}
+
+type fakeRegistry struct {
+	sources []*config.LogSource
+}
+
+func (r *fakeRegistry) AddSource(source *config.LogSource) {
+	r.sources = append(r.sources, source)
+}
+
+func (r *fakeRegistry) RemoveSource(source *config.LogSource) {
+	for i, s := range r.sources {
+		if s == source {
+			r.sources = append(r.sources[:i], r.sources[i+1:]...)
+			return
+		}
+	}
+}
+
+func newStatus(id string, name string, image string, logPath string, labels map[string]string) *runtimeapi.ContainerStatus {
+	return &runtimeapi.ContainerStatus{
+		Id:       id,
+		Metadata: &runtimeapi.ContainerMetadata{Name: name},
+		Image:    &runtimeapi.ImageSpec{Image: image},
+		LogPath:  logPath,
+		Labels:   labels,
+	}
+}
+
+func TestScan(t *testing.T) {
+	source := config.NewLogSource("cri", &config.LogsConfig{Type: config.CRIType, Image: "nginx", Service: "web", Tags: []string{"env:prod"}})
+	registry := &fakeRegistry{}
+	runtime := &fakeRuntime{}
+	launcher := New([]*config.LogSource{source}, registry)
+	launcher.runtime = runtime
+
+	runtime.statuses = []*runtimeapi.ContainerStatus{
+		newStatus("abc", "nginx", "docker.io/library/nginx:1.15", "/var/log/pods/6f2b1c3e/nginx/0.log", map[string]string{
+			podNameLabel:       "nginx-1234",
+			podNamespaceLabel:  "default",
+			containerNameLabel: "nginx",
+		}),
+		newStatus("def", "redis", "redis:4", "/var/log/redis.log", nil),
+		newStatus("ghi", "nginx-nolog", "nginx:1.15", "", nil),
+	}
+	launcher.scan()
+	require.Len(t, registry.sources, 1)
+	containerSource := registry.sources[0]
+	assert.Equal(t, config.FileType, containerSource.Config.Type)
+	assert.Equal(t, config.CRIFormat, containerSource.Config.Format)
+	assert.Equal(t, "web", containerSource.Config.Service)
+	assert.Equal(t, "/var/log/pods/6f2b1c3e/nginx/0.log", containerSource.Config.Path)
+	assert.Equal(t, []string{"env:prod", "pod_name:nginx-1234", "kube_namespace:default", "kube_container_name:nginx"}, containerSource.Config.Tags)
+	assert.Equal(t, []string{"env:prod"}, source.Config.Tags)
+
+	// the containers run outside of kubernetes are tagged with their name
+	runtime.statuses = append(runtime.statuses, newStatus("jkl", "proxy", "nginx:1.15", "/var/log/proxy.log", nil))
+	launcher.scan()
+	require.Len(t, registry.sources, 2)
+	assert.Equal(t, []string{"env:prod", "container_name:proxy"}, registry.sources[1].Config.Tags)
+
+	// the containers are gone
+	runtime.statuses = nil
+	launcher.scan()
+	assert.Len(t, registry.sources, 0)
+}
+
+func TestFindSource(t *testing.T) {
+	all := config.NewLogSource("", &config.LogsConfig{Type: config.CRIType})
+	redis := config.NewLogSource("", &config.LogsConfig{Type: config.CRIType, Image: "redis"})
+	redisCache := config.NewLogSource("", &config.LogsConfig{Type: config.CRIType, Image: "redis", Name: "^cache"})
+	sources := []*config.LogSource{all, redis, redisCache}
+
+	assert.Equal(t, all, findSource(sources, "nginx", "nginx:1.15"))
+	assert.Equal(t, redis, findSource(sources, "db", "docker.io/library/redis:4"))
+	assert.Equal(t, redisCache, findSource(sources, "cache", "redis@sha256:1234"))
+	assert.Nil(t, findSource([]*config.LogSource{redis}, "db", "myredis"))
+}
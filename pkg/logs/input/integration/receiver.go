@@ -0,0 +1,132 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2018 Datadog, Inc.
+
+package integration
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+
+	"github.com/DataDog/datadog-agent/pkg/logs/config"
+	"github.com/DataDog/datadog-agent/pkg/logs/message"
+	"github.com/DataDog/datadog-agent/pkg/logs/pipeline"
+)
+
+// Reserved attributes of the logs sent by the integrations, used to tag the
+// logs instead of being sent along with the message
+const (
+	sourceAttribute  = "ddsource"
+	serviceAttribute = "service"
+	tagsAttribute    = "ddtags"
+	statusAttribute  = "status"
+)
+
+// ErrStopped is returned for the logs sent while the receiver is stopped
+var ErrStopped = errors.New("the logs of the integrations are not forwarded")
+
+// Receiver forwards the logs sent by the integrations, for instance the
+// records of the audit trails scraped by the python checks, to a pipeline
+type Receiver struct {
+	mu      sync.Mutex
+	pp      pipeline.Provider
+	output  *message.Ring
+	sources map[string]*config.LogSource
+}
+
+// New returns an initialized Receiver
+func New(pp pipeline.Provider) *Receiver {
+	return &Receiver{
+		pp:      pp,
+		sources: make(map[string]*config.LogSource),
+	}
+}
+
+// Start starts forwarding the logs
+func (r *Receiver) Start() {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.output = r.pp.NextPipelineInput()
+}
+
+// Stop stops forwarding the logs, the logs sent afterwards are rejected
+func (r *Receiver) Stop() {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.output = nil
+}
+
+// Send forwards a log line of an integration to the pipeline, blocking while
+// the pipeline is full. The source, service, tags and status of the log are
+// taken from the reserved attributes, the other ones are sent with the message.
+func (r *Receiver) Send(line string, attributes map[string]interface{}) error {
+	content, severity, err := Format(line, attributes)
+	if err != nil {
+		return err
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.output == nil {
+		return ErrStopped
+	}
+	origin := message.NewOrigin(r.getSource(stringAttribute(attributes, sourceAttribute), stringAttribute(attributes, serviceAttribute)))
+	if tags := stringAttribute(attributes, tagsAttribute); tags != "" {
+		origin.SetTags(strings.Split(tags, ","))
+	}
+	r.output.Push(message.New(content, origin, severity))
+	return nil
+}
+
+// getSource returns the source of the logs with the given source and service,
+// the lock must be held
+func (r *Receiver) getSource(source, service string) *config.LogSource {
+	key := source + "/" + service
+	s, found := r.sources[key]
+	if !found {
+		s = config.NewLogSource(key, &config.LogsConfig{
+			Type:    config.IntegrationType,
+			Source:  source,
+			Service: service,
+		})
+		r.sources[key] = s
+	}
+	return s
+}
+
+// Format returns a log line sent by an integration as a JSON log, with the
+// line as message along with its attributes but the reserved ones, and its
+// severity set by the `status` attribute.
+func Format(line string, attributes map[string]interface{}) ([]byte, []byte, error) {
+	payload := map[string]interface{}{"message": line}
+	for name, value := range attributes {
+		switch name {
+		case sourceAttribute, serviceAttribute, tagsAttribute, statusAttribute:
+			continue
+		case "message":
+			return nil, nil, fmt.Errorf("the message attribute is reserved for the log line")
+		}
+		payload[name] = value
+	}
+	content, err := json.Marshal(payload)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	severity := config.SevInfo
+	switch strings.ToLower(stringAttribute(attributes, statusAttribute)) {
+	case "error", "critical", "emergency", "alert":
+		severity = config.SevError
+	}
+	return content, severity, nil
+}
+
+// stringAttribute returns the value of an attribute if it's a string
+func stringAttribute(attributes map[string]interface{}, name string) string {
+	value, _ := attributes[name].(string)
+	return value
+}
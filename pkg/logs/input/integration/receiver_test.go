@@ -0,0 +1,66 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2018 Datadog, Inc.
+
+package integration
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/DataDog/datadog-agent/pkg/logs/config"
+	"github.com/DataDog/datadog-agent/pkg/logs/pipeline/mock"
+)
+
+func TestFormat(t *testing.T) {
+	content, severity, err := Format("jane deleted the bucket", map[string]interface{}{
+		"ddsource": "vendor",
+		"service":  "storage",
+		"ddtags":   "env:prod",
+		"status":   "error",
+		"user":     "jane",
+		"count":    2,
+	})
+	require.NoError(t, err)
+	assert.Equal(t, config.SevError, severity)
+
+	var log map[string]interface{}
+	require.NoError(t, json.Unmarshal(content, &log))
+	assert.Equal(t, map[string]interface{}{"message": "jane deleted the bucket", "user": "jane", "count": float64(2)}, log)
+
+	content, severity, err = Format("foo", nil)
+	require.NoError(t, err)
+	assert.Equal(t, config.SevInfo, severity)
+	assert.Equal(t, `{"message":"foo"}`, string(content))
+
+	_, _, err = Format("foo", map[string]interface{}{"message": "bar"})
+	assert.Error(t, err)
+}
+
+func TestReceiverSend(t *testing.T) {
+	pp := mock.NewMockProvider()
+	r := New(pp)
+	assert.Equal(t, ErrStopped, r.Send("foo", nil))
+
+	r.Start()
+	require.NoError(t, r.Send("foo", map[string]interface{}{"ddsource": "vendor", "service": "storage", "ddtags": "env:prod,team:a"}))
+	msg := pp.NextPipelineInput().Pop()
+	assert.Equal(t, `{"message":"foo"}`, string(msg.Content()))
+	assert.Equal(t, config.IntegrationType, msg.GetOrigin().LogSource.Config.Type)
+	assert.Equal(t, "vendor", msg.GetOrigin().LogSource.Config.Source)
+	assert.Equal(t, "storage", msg.GetOrigin().LogSource.Config.Service)
+	assert.Equal(t, []string{"env:prod", "team:a", "source:vendor"}, msg.GetOrigin().Tags())
+
+	// the sources are reused from one log to the next
+	require.NoError(t, r.Send("bar", map[string]interface{}{"ddsource": "vendor", "service": "storage"}))
+	assert.Equal(t, msg.GetOrigin().LogSource, pp.NextPipelineInput().Pop().GetOrigin().LogSource)
+
+	assert.Error(t, r.Send("foo", map[string]interface{}{"message": "bar"}))
+
+	r.Stop()
+	assert.Equal(t, ErrStopped, r.Send("foo", nil))
+}
@@ -0,0 +1,115 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2018 Datadog, Inc.
+
+package kubeaudit
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/DataDog/datadog-agent/pkg/logs/config"
+)
+
+// event holds the attributes of a Kubernetes audit event (audit.k8s.io/v1beta1)
+// used to build the message of the log
+type event struct {
+	Verb string `json:"verb"`
+	User struct {
+		Username string `json:"username"`
+	} `json:"user"`
+	ObjectRef *struct {
+		Resource    string `json:"resource"`
+		Subresource string `json:"subresource"`
+		Namespace   string `json:"namespace"`
+		Name        string `json:"name"`
+	} `json:"objectRef"`
+	ResponseStatus *struct {
+		Code int `json:"code"`
+	} `json:"responseStatus"`
+	RequestURI string `json:"requestURI"`
+}
+
+// eventList is the payload sent by the audit webhook backend
+type eventList struct {
+	Items []json.RawMessage `json:"items"`
+}
+
+// summary returns a human readable description of the event,
+// for instance "system:admin delete pods default/nginx (200)"
+func (e *event) summary() string {
+	parts := []string{e.User.Username, e.Verb}
+	if e.ObjectRef != nil {
+		resource := e.ObjectRef.Resource
+		if e.ObjectRef.Subresource != "" {
+			resource += "/" + e.ObjectRef.Subresource
+		}
+		parts = append(parts, resource)
+		if e.ObjectRef.Namespace != "" {
+			parts = append(parts, e.ObjectRef.Namespace+"/"+e.ObjectRef.Name)
+		} else if e.ObjectRef.Name != "" {
+			parts = append(parts, e.ObjectRef.Name)
+		}
+	} else if e.RequestURI != "" {
+		parts = append(parts, e.RequestURI)
+	}
+	if e.ResponseStatus != nil {
+		parts = append(parts, fmt.Sprintf("(%d)", e.ResponseStatus.Code))
+	}
+	return strings.Join(parts, " ")
+}
+
+// severity returns the severity of the event: denied requests and
+// server errors are reported as errors
+func (e *event) severity() []byte {
+	if e.ResponseStatus == nil {
+		return config.SevInfo
+	}
+	switch code := e.ResponseStatus.Code; {
+	case code == 401, code == 403, code >= 500:
+		return config.SevError
+	default:
+		return config.SevInfo
+	}
+}
+
+// Format parses a Kubernetes audit event and returns it as a JSON log
+// keeping all its attributes (verb, user, objectRef...) with an additional
+// message summarizing it, along with its severity.
+func Format(content []byte) ([]byte, []byte, error) {
+	var attributes map[string]json.RawMessage
+	if err := json.Unmarshal(content, &attributes); err != nil {
+		return nil, nil, err
+	}
+	var e event
+	if err := json.Unmarshal(content, &e); err != nil {
+		return nil, nil, err
+	}
+	if e.Verb == "" {
+		return nil, nil, fmt.Errorf("not a kubernetes audit event")
+	}
+
+	if _, found := attributes["message"]; !found {
+		message, err := json.Marshal(e.summary())
+		if err != nil {
+			return nil, nil, err
+		}
+		attributes["message"] = message
+	}
+	formatted, err := json.Marshal(attributes)
+	if err != nil {
+		return nil, nil, err
+	}
+	return formatted, e.severity(), nil
+}
+
+// splitEventList returns the events of an EventList sent by the webhook
+func splitEventList(content []byte) ([]json.RawMessage, error) {
+	var list eventList
+	if err := json.Unmarshal(content, &list); err != nil {
+		return nil, err
+	}
+	return list.Items, nil
+}
@@ -0,0 +1,61 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2018 Datadog, Inc.
+
+package kubeaudit
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/DataDog/datadog-agent/pkg/logs/config"
+)
+
+const podDeletion = `{"kind":"Event","apiVersion":"audit.k8s.io/v1beta1","level":"Metadata","auditID":"a9f7b5c1","stage":"ResponseComplete","requestURI":"/api/v1/namespaces/default/pods/nginx","verb":"delete","user":{"username":"jane","groups":["system:authenticated"]},"sourceIPs":["10.0.0.1"],"objectRef":{"resource":"pods","namespace":"default","name":"nginx","apiVersion":"v1"},"responseStatus":{"metadata":{},"code":200}}`
+
+func TestFormat(t *testing.T) {
+	content, severity, err := Format([]byte(podDeletion))
+	require.NoError(t, err)
+	assert.Equal(t, config.SevInfo, severity)
+
+	var attributes map[string]interface{}
+	require.NoError(t, json.Unmarshal(content, &attributes))
+	assert.Equal(t, "jane delete pods default/nginx (200)", attributes["message"])
+	assert.Equal(t, "delete", attributes["verb"])
+	assert.Equal(t, "jane", attributes["user"].(map[string]interface{})["username"])
+	assert.Equal(t, "pods", attributes["objectRef"].(map[string]interface{})["resource"])
+	assert.Equal(t, "a9f7b5c1", attributes["auditID"])
+}
+
+func TestFormatSeverityAndSummary(t *testing.T) {
+	content, severity, err := Format([]byte(`{"verb":"get","user":{"username":"bob"},"objectRef":{"resource":"nodes","subresource":"proxy","name":"node-1"},"responseStatus":{"code":403}}`))
+	require.NoError(t, err)
+	assert.Equal(t, config.SevError, severity)
+	assert.Contains(t, string(content), `"message":"bob get nodes/proxy node-1 (403)"`)
+
+	content, severity, err = Format([]byte(`{"verb":"get","user":{"username":"bob"},"requestURI":"/healthz","stage":"RequestReceived"}`))
+	require.NoError(t, err)
+	assert.Equal(t, config.SevInfo, severity)
+	assert.Contains(t, string(content), `"message":"bob get /healthz"`)
+}
+
+func TestFormatInvalidEvent(t *testing.T) {
+	_, _, err := Format([]byte("I0101 00:00:00.000000 1 trace.go:76] not an audit event"))
+	assert.Error(t, err)
+
+	_, _, err = Format([]byte(`{"foo":"bar"}`))
+	assert.Error(t, err)
+}
+
+func TestSplitEventList(t *testing.T) {
+	events, err := splitEventList([]byte(`{"kind":"EventList","apiVersion":"audit.k8s.io/v1beta1","metadata":{},"items":[` + podDeletion + `,` + podDeletion + `]}`))
+	require.NoError(t, err)
+	assert.Len(t, events, 2)
+
+	_, err = splitEventList([]byte("foo"))
+	assert.Error(t, err)
+}
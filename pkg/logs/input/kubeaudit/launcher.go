@@ -0,0 +1,54 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2018 Datadog, Inc.
+
+package kubeaudit
+
+import (
+	log "github.com/cihub/seelog"
+
+	"github.com/DataDog/datadog-agent/pkg/logs/config"
+	"github.com/DataDog/datadog-agent/pkg/logs/pipeline"
+	"github.com/DataDog/datadog-agent/pkg/logs/restart"
+)
+
+// Launcher starts a webhook for every Kubernetes audit source configured
+// with a port, the sources configured with a path are tailed as files
+type Launcher struct {
+	pp       pipeline.Provider
+	sources  []*config.LogSource
+	webhooks []restart.Stoppable
+}
+
+// New returns an initialized Launcher
+func New(sources []*config.LogSource, pp pipeline.Provider) *Launcher {
+	return &Launcher{
+		pp:       pp,
+		sources:  sources,
+		webhooks: []restart.Stoppable{},
+	}
+}
+
+// Start starts the webhooks
+func (l *Launcher) Start() {
+	for _, source := range l.sources {
+		if source.Config.Type != config.KubernetesAuditType || source.Config.Port == 0 {
+			continue
+		}
+		webhook, err := NewWebhook(l.pp, source)
+		if err != nil {
+			log.Error("Can't start kubernetes_audit source: ", err)
+			continue
+		}
+		webhook.Start()
+		l.webhooks = append(l.webhooks, webhook)
+	}
+}
+
+// Stop stops all the webhooks
+func (l *Launcher) Stop() {
+	stopper := restart.NewParallelStopper(l.webhooks...)
+	stopper.Stop()
+	l.webhooks = l.webhooks[:0]
+}
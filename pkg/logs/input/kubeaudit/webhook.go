@@ -0,0 +1,99 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2018 Datadog, Inc.
+
+package kubeaudit
+
+import (
+	"fmt"
+	"io/ioutil"
+	"net"
+	"net/http"
+
+	log "github.com/cihub/seelog"
+
+	"github.com/DataDog/datadog-agent/pkg/logs/config"
+	"github.com/DataDog/datadog-agent/pkg/logs/message"
+	"github.com/DataDog/datadog-agent/pkg/logs/pipeline"
+)
+
+// maxPayloadSize is the maximum size of an EventList sent by the apiserver
+const maxPayloadSize = 10 * 1024 * 1024
+
+// Webhook receives the audit events sent by the apiserver audit webhook
+// backend and forwards them to a pipeline
+type Webhook struct {
+	source   *config.LogSource
+	listener net.Listener
+	server   *http.Server
+	output   *message.Ring
+	done     chan struct{}
+}
+
+// NewWebhook returns a new Webhook listening on the port of the source
+func NewWebhook(pp pipeline.Provider, source *config.LogSource) (*Webhook, error) {
+	listener, err := net.Listen("tcp", fmt.Sprintf(":%d", source.Config.Port))
+	if err != nil {
+		source.Status.Error(err)
+		return nil, err
+	}
+	source.Status.Success()
+	w := &Webhook{
+		source:   source,
+		listener: listener,
+		output:   pp.NextPipelineInput(),
+		done:     make(chan struct{}, 1),
+	}
+	w.server = &http.Server{Handler: w}
+	return w, nil
+}
+
+// Start starts serving the audit events
+func (w *Webhook) Start() {
+	log.Info("Starting Kubernetes audit webhook on port ", w.source.Config.Port)
+	go func() {
+		defer func() {
+			w.done <- struct{}{}
+		}()
+		if err := w.server.Serve(w.listener); err != nil && err != http.ErrServerClosed {
+			w.source.Status.Error(err)
+			log.Error("Can't serve the Kubernetes audit webhook: ", err)
+		}
+	}()
+}
+
+// Stop stops the server, the events already received are forwarded
+func (w *Webhook) Stop() {
+	log.Info("Stopping Kubernetes audit webhook on port ", w.source.Config.Port)
+	w.server.Close()
+	<-w.done
+}
+
+// ServeHTTP handles an EventList sent by the apiserver
+func (w *Webhook) ServeHTTP(rw http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(rw, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	body, err := ioutil.ReadAll(http.MaxBytesReader(rw, r.Body, maxPayloadSize))
+	if err != nil {
+		http.Error(rw, err.Error(), http.StatusRequestEntityTooLarge)
+		return
+	}
+	events, err := splitEventList(body)
+	if err != nil {
+		log.Warn("Couldn't decode Kubernetes audit events: ", err)
+		http.Error(rw, err.Error(), http.StatusBadRequest)
+		return
+	}
+	for _, e := range events {
+		content, severity, err := Format(e)
+		if err != nil {
+			log.Debug("Couldn't parse Kubernetes audit event: ", err)
+			continue
+		}
+		w.output.Push(message.New(content, message.NewOrigin(w.source), severity))
+	}
+	rw.WriteHeader(http.StatusOK)
+}
@@ -0,0 +1,43 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2018 Datadog, Inc.
+
+package kubeaudit
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/DataDog/datadog-agent/pkg/logs/config"
+	"github.com/DataDog/datadog-agent/pkg/logs/message"
+)
+
+func TestWebhookServeHTTP(t *testing.T) {
+	source := config.NewLogSource("", &config.LogsConfig{Type: config.KubernetesAuditType, Port: 8126})
+	output := message.NewRing(10)
+	w := &Webhook{source: source, output: output}
+
+	payload := `{"kind":"EventList","items":[` + podDeletion + `,{"foo":"bar"}]}`
+	rec := httptest.NewRecorder()
+	w.ServeHTTP(rec, httptest.NewRequest("POST", "/", strings.NewReader(payload)))
+	assert.Equal(t, http.StatusOK, rec.Code)
+
+	// the invalid event is dropped
+	assert.Equal(t, 1, output.Len())
+	msg := output.Pop()
+	assert.Contains(t, string(msg.Content()), `"message":"jane delete pods default/nginx (200)"`)
+	assert.Equal(t, source, msg.GetOrigin().LogSource)
+
+	rec = httptest.NewRecorder()
+	w.ServeHTTP(rec, httptest.NewRequest("POST", "/", strings.NewReader("foo")))
+	assert.Equal(t, http.StatusBadRequest, rec.Code)
+
+	rec = httptest.NewRecorder()
+	w.ServeHTTP(rec, httptest.NewRequest("GET", "/", nil))
+	assert.Equal(t, http.StatusMethodNotAllowed, rec.Code)
+}
@@ -0,0 +1,250 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2018 Datadog, Inc.
+
+// +build kubelet
+
+package kubernetes
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	log "github.com/cihub/seelog"
+
+	"github.com/DataDog/datadog-agent/pkg/logs/config"
+	"github.com/DataDog/datadog-agent/pkg/util/kubernetes/kubelet"
+)
+
+const scanPeriod = 10 * time.Second
+
+// dockerContainerPrefix is the prefix of the IDs of the containers run by
+// docker, their logs are collected by the docker sources
+const dockerContainerPrefix = "docker://"
+
+// annotationFormat is the format of the pod annotations holding the logs
+// config of a container, like the docker labels
+const annotationFormat = "ad.datadoghq.com/%s.logs"
+
+// podLogsBasePath is the directory the kubelet writes the logs of the pods in
+var podLogsBasePath = "/var/log/pods"
+
+// podLister lists the pods running on the node
+type podLister interface {
+	GetLocalPodList() ([]*kubelet.Pod, error)
+}
+
+// Launcher looks for the containers running on the node matching the
+// kubernetes sources, and has the logs files written by their runtime
+// tailed by the registry, tagged with their pod, namespace and name. The
+// logs config set in the annotations of a pod for a container overrides the
+// sources.
+type Launcher struct {
+	sources    []*config.LogSource
+	registry   SourceRegistry
+	pods       podLister
+	podSources map[string]*config.LogSource
+	isRunning  bool
+	stop       chan struct{}
+}
+
+// New returns a new Launcher
+func New(sources []*config.LogSource, registry SourceRegistry) *Launcher {
+	return &Launcher{
+		sources:    filterSources(sources),
+		registry:   registry,
+		podSources: make(map[string]*config.LogSource),
+		stop:       make(chan struct{}),
+	}
+}
+
+// Start starts the Launcher
+func (l *Launcher) Start() {
+	if len(l.sources) == 0 {
+		return
+	}
+	kubeutil, err := kubelet.GetKubeUtil()
+	if err != nil {
+		log.Errorf("Can't tail the logs of the pods: %s", err)
+		l.reportErrorToAllSources(err)
+		return
+	}
+	l.pods = kubeutil
+	l.scan()
+	go l.run()
+	l.isRunning = true
+}
+
+// Stop stops the Launcher and removes the sources of the containers
+func (l *Launcher) Stop() {
+	if !l.isRunning {
+		return
+	}
+	l.stop <- struct{}{}
+	for containerID, source := range l.podSources {
+		l.registry.RemoveSource(source)
+		delete(l.podSources, containerID)
+	}
+}
+
+// run checks periodically which containers are running until stop
+func (l *Launcher) run() {
+	scanTicker := time.NewTicker(scanPeriod)
+	defer scanTicker.Stop()
+	for {
+		select {
+		case <-scanTicker.C:
+			l.scan()
+		case <-l.stop:
+			return
+		}
+	}
+}
+
+// scan adds the sources of the new containers and removes the ones of the
+// containers which are gone, like the previous instance of a container
+// which restarted
+func (l *Launcher) scan() {
+	pods, err := l.pods.GetLocalPodList()
+	if err != nil {
+		log.Warnf("Can't list the pods: %s", err)
+		l.reportErrorToAllSources(err)
+		return
+	}
+	for _, source := range l.sources {
+		source.Status.Success()
+	}
+
+	containersToTail := make(map[string]bool)
+	for _, pod := range pods {
+		for _, container := range pod.Status.Containers {
+			if container.ID == "" || strings.HasPrefix(container.ID, dockerContainerPrefix) {
+				continue
+			}
+			if _, isTailed := l.podSources[container.ID]; isTailed {
+				containersToTail[container.ID] = true
+				continue
+			}
+			source := annotationSource(pod, container)
+			if source == nil {
+				source = findSource(l.sources, container)
+			}
+			if source == nil {
+				continue
+			}
+			containersToTail[container.ID] = true
+			podSource := newPodSource(source, pod, container)
+			log.Infof("Tailing the logs of the container %s of the pod %s/%s from %s", container.Name, pod.Metadata.Namespace, pod.Metadata.Name, podSource.Config.Path)
+			l.registry.AddSource(podSource)
+			l.podSources[container.ID] = podSource
+		}
+	}
+
+	for containerID, source := range l.podSources {
+		if !containersToTail[containerID] {
+			l.registry.RemoveSource(source)
+			delete(l.podSources, containerID)
+		}
+	}
+}
+
+// reportErrorToAllSources changes the status of all sources to Error with err
+func (l *Launcher) reportErrorToAllSources(err error) {
+	for _, source := range l.sources {
+		source.Status.Error(err)
+	}
+}
+
+// annotationSource returns the source of the logs config set in the
+// annotations of the pod for the container, nil when there is none or it is
+// malformed
+func annotationSource(pod *kubelet.Pod, container kubelet.ContainerStatus) *config.LogSource {
+	name := fmt.Sprintf(annotationFormat, container.Name)
+	annotation, exists := pod.Metadata.Annotations[name]
+	if !exists {
+		return nil
+	}
+	cfg, err := config.ParseJSONConfig(annotation)
+	if err != nil {
+		log.Warnf("Could not parse the logs config of the container %s of the pod %s/%s, %v is malformed: %s", container.Name, pod.Metadata.Namespace, pod.Metadata.Name, annotation, err)
+		return nil
+	}
+	return config.NewLogSource(name, cfg)
+}
+
+// findSource returns the source matching the container the most, by image
+// and name, nil when none matches
+func findSource(sources []*config.LogSource, container kubelet.ContainerStatus) *config.LogSource {
+	var candidate *config.LogSource
+	candidateScore := -1
+	for _, source := range sources {
+		score := 0
+		if source.Config.Image != "" {
+			if !isImageMatch(container.Image, source.Config.Image) {
+				continue
+			}
+			score++
+		}
+		if source.Config.Name != "" {
+			if match, err := regexp.MatchString(source.Config.Name, container.Name); err != nil || !match {
+				continue
+			}
+			score++
+		}
+		if score > candidateScore {
+			candidate, candidateScore = source, score
+		}
+	}
+	return candidate
+}
+
+// isImageMatch returns true if the image, '[<repository>/]image[:<tag>]',
+// matches the filter, '[<repository>/]image[:<tag>]', the tag of the image
+// is ignored when the filter has none
+func isImageMatch(image string, imageFilter string) bool {
+	image = strings.SplitN(image, "@sha256:", 2)[0]
+	if !strings.Contains(imageFilter, ":") {
+		image = strings.SplitN(image, ":", 2)[0]
+	}
+	repository := strings.TrimSuffix(image, imageFilter)
+	return repository != image && (len(repository) == 0 || strings.HasSuffix(repository, "/"))
+}
+
+// newPodSource returns the file source of the logs of a container, using
+// the CRI format, tagged with its pod, namespace and name
+func newPodSource(source *config.LogSource, pod *kubelet.Pod, container kubelet.ContainerStatus) *config.LogSource {
+	cfg := *source.Config
+	cfg.Type = config.FileType
+	cfg.Path = podLogPath(pod, container)
+	cfg.Format = config.CRIFormat
+	cfg.Tags = append(append([]string{}, source.Config.Tags...),
+		"pod_name:"+pod.Metadata.Name,
+		"kube_namespace:"+pod.Metadata.Namespace,
+		"kube_container_name:"+container.Name,
+	)
+	return config.NewLogSource(source.Name, &cfg)
+}
+
+// podLogPath returns the path of the log file of the current instance of a
+// container, depending on the layout of the kubelet version:
+// - <namespace>_<pod>_<uid>/<container>/<restarts>.log since 1.14,
+// - <uid>/<container>/<restarts>.log since 1.10,
+// - <uid>/<container>_<restarts>.log before.
+func podLogPath(pod *kubelet.Pod, container kubelet.ContainerStatus) string {
+	filename := strconv.Itoa(container.RestartCount) + ".log"
+	dir := filepath.Join(podLogsBasePath, fmt.Sprintf("%s_%s_%s", pod.Metadata.Namespace, pod.Metadata.Name, pod.Metadata.UID), container.Name)
+	if _, err := os.Stat(dir); err == nil {
+		return filepath.Join(dir, filename)
+	}
+	dir = filepath.Join(podLogsBasePath, pod.Metadata.UID, container.Name)
+	if _, err := os.Stat(dir); err == nil {
+		return filepath.Join(dir, filename)
+	}
+	return filepath.Join(podLogsBasePath, pod.Metadata.UID, container.Name+"_"+filename)
+}
@@ -0,0 +1,160 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2018 Datadog, Inc.
+
+// +build kubelet
+
+package kubernetes
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/DataDog/datadog-agent/pkg/logs/config"
+	"github.com/DataDog/datadog-agent/pkg/util/kubernetes/kubelet"
+)
+
+type fakePodLister struct {
+	pods []*kubelet.Pod
+}
+
+func (f *fakePodLister) GetLocalPodList() ([]*kubelet.Pod, error) {
+	return f.pods, nil
+}
+
+type fakeRegistry struct {
+	sources []*config.LogSource
+}
+
+func (r *fakeRegistry) AddSource(source *config.LogSource) {
+	r.sources = append(r.sources, source)
+}
+
+func (r *fakeRegistry) RemoveSource(source *config.LogSource) {
+	for i, s := range r.sources {
+		if s == source {
+			r.sources = append(r.sources[:i], r.sources[i+1:]...)
+			return
+		}
+	}
+}
+
+func newPod(containers ...kubelet.ContainerStatus) *kubelet.Pod {
+	pod := &kubelet.Pod{}
+	pod.Metadata.Name = "nginx-1234"
+	pod.Metadata.Namespace = "default"
+	pod.Metadata.UID = "6f2b1c3e"
+	pod.Status.Containers = containers
+	return pod
+}
+
+func TestScan(t *testing.T) {
+	source := config.NewLogSource("kubernetes", &config.LogsConfig{Type: config.KubernetesType, Service: "web", Tags: []string{"env:prod"}})
+	registry := &fakeRegistry{}
+	pods := &fakePodLister{}
+	launcher := New([]*config.LogSource{source}, registry)
+	launcher.pods = pods
+
+	pods.pods = []*kubelet.Pod{newPod(
+		kubelet.ContainerStatus{Name: "nginx", Image: "nginx:1.15", ID: "containerd://abc"},
+		kubelet.ContainerStatus{Name: "sidecar", Image: "sidecar:latest", ID: "docker://def"},
+		kubelet.ContainerStatus{Name: "pending"},
+	)}
+	launcher.scan()
+	require.Len(t, registry.sources, 1)
+	podSource := registry.sources[0]
+	assert.Equal(t, config.FileType, podSource.Config.Type)
+	assert.Equal(t, config.CRIFormat, podSource.Config.Format)
+	assert.Equal(t, "web", podSource.Config.Service)
+	assert.Equal(t, filepath.Join(podLogsBasePath, "6f2b1c3e", "nginx_0.log"), podSource.Config.Path)
+	assert.Equal(t, []string{"env:prod", "pod_name:nginx-1234", "kube_namespace:default", "kube_container_name:nginx"}, podSource.Config.Tags)
+	assert.Equal(t, []string{"env:prod"}, source.Config.Tags)
+
+	// the container restarted
+	pods.pods = []*kubelet.Pod{newPod(
+		kubelet.ContainerStatus{Name: "nginx", Image: "nginx:1.15", ID: "containerd://ghi", RestartCount: 1},
+	)}
+	launcher.scan()
+	require.Len(t, registry.sources, 1)
+	assert.Equal(t, filepath.Join(podLogsBasePath, "6f2b1c3e", "nginx_1.log"), registry.sources[0].Config.Path)
+
+	// the pod is gone
+	pods.pods = nil
+	launcher.scan()
+	assert.Len(t, registry.sources, 0)
+}
+
+func TestScanAnnotations(t *testing.T) {
+	source := config.NewLogSource("kubernetes", &config.LogsConfig{Type: config.KubernetesType, Image: "nginx", Service: "web"})
+	registry := &fakeRegistry{}
+	pods := &fakePodLister{}
+	launcher := New([]*config.LogSource{source}, registry)
+	launcher.pods = pods
+
+	pod := newPod(
+		kubelet.ContainerStatus{Name: "nginx", Image: "nginx:1.15", ID: "containerd://abc"},
+		kubelet.ContainerStatus{Name: "redis", Image: "redis:4", ID: "containerd://def"},
+		kubelet.ContainerStatus{Name: "proxy", Image: "nginx:1.15", ID: "containerd://ghi"},
+	)
+	pod.Metadata.Annotations = map[string]string{
+		"ad.datadoghq.com/redis.logs": `[{"source":"redis","service":"cache","tags":"team:storage","log_processing_rules":[{"type":"exclude_at_match","name":"exclude_debug","pattern":"DEBUG"}]}]`,
+		"ad.datadoghq.com/proxy.logs": `{"source":"nginx"}`,
+	}
+	pods.pods = []*kubelet.Pod{pod}
+	launcher.scan()
+	require.Len(t, registry.sources, 3)
+	sources := make(map[string]*config.LogSource)
+	for _, podSource := range registry.sources {
+		sources[podSource.Config.Path] = podSource
+	}
+
+	// the annotations override the sources
+	redis := sources[filepath.Join(podLogsBasePath, "6f2b1c3e", "redis_0.log")]
+	require.NotNil(t, redis)
+	assert.Equal(t, "redis", redis.Config.Source)
+	assert.Equal(t, "cache", redis.Config.Service)
+	assert.Equal(t, []string{"team:storage", "pod_name:nginx-1234", "kube_namespace:default", "kube_container_name:redis"}, redis.Config.Tags)
+	require.Len(t, redis.Config.ProcessingRules, 1)
+	assert.True(t, redis.Config.ProcessingRules[0].Reg.MatchString("DEBUG"))
+
+	// the malformed annotations are ignored
+	proxy := sources[filepath.Join(podLogsBasePath, "6f2b1c3e", "proxy_0.log")]
+	require.NotNil(t, proxy)
+	assert.Equal(t, "web", proxy.Config.Service)
+}
+
+func TestFindSource(t *testing.T) {
+	all := config.NewLogSource("", &config.LogsConfig{Type: config.KubernetesType})
+	redis := config.NewLogSource("", &config.LogsConfig{Type: config.KubernetesType, Image: "redis"})
+	redisCache := config.NewLogSource("", &config.LogsConfig{Type: config.KubernetesType, Image: "redis", Name: "^cache"})
+	sources := []*config.LogSource{all, redis, redisCache}
+
+	assert.Equal(t, all, findSource(sources, kubelet.ContainerStatus{Name: "nginx", Image: "nginx:1.15"}))
+	assert.Equal(t, redis, findSource(sources, kubelet.ContainerStatus{Name: "db", Image: "docker.io/library/redis:4"}))
+	assert.Equal(t, redisCache, findSource(sources, kubelet.ContainerStatus{Name: "cache", Image: "redis@sha256:1234"}))
+	assert.Nil(t, findSource([]*config.LogSource{redis}, kubelet.ContainerStatus{Name: "db", Image: "myredis"}))
+}
+
+func TestPodLogPath(t *testing.T) {
+	defer func(path string) { podLogsBasePath = path }(podLogsBasePath)
+	var err error
+	podLogsBasePath, err = ioutil.TempDir("", "pods")
+	require.NoError(t, err)
+	defer os.RemoveAll(podLogsBasePath)
+
+	pod := newPod()
+	container := kubelet.ContainerStatus{Name: "nginx", RestartCount: 2}
+	assert.Equal(t, filepath.Join(podLogsBasePath, "6f2b1c3e", "nginx_2.log"), podLogPath(pod, container))
+
+	require.NoError(t, os.MkdirAll(filepath.Join(podLogsBasePath, "6f2b1c3e", "nginx"), 0755))
+	assert.Equal(t, filepath.Join(podLogsBasePath, "6f2b1c3e", "nginx", "2.log"), podLogPath(pod, container))
+
+	require.NoError(t, os.MkdirAll(filepath.Join(podLogsBasePath, "default_nginx-1234_6f2b1c3e", "nginx"), 0755))
+	assert.Equal(t, filepath.Join(podLogsBasePath, "default_nginx-1234_6f2b1c3e", "nginx", "2.log"), podLogPath(pod, container))
+}
@@ -0,0 +1,28 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2018 Datadog, Inc.
+
+package kubernetes
+
+import (
+	"github.com/DataDog/datadog-agent/pkg/logs/config"
+)
+
+// SourceRegistry tails the files of the sources added until they are
+// removed, like the files scanner
+type SourceRegistry interface {
+	AddSource(source *config.LogSource)
+	RemoveSource(source *config.LogSource)
+}
+
+// filterSources returns the kubernetes sources
+func filterSources(sources []*config.LogSource) []*config.LogSource {
+	kubernetesSources := []*config.LogSource{}
+	for _, source := range sources {
+		if source.Config.Type == config.KubernetesType {
+			kubernetesSources = append(kubernetesSources, source)
+		}
+	}
+	return kubernetesSources
+}
@@ -86,7 +86,7 @@ func (h *ConnectionHandler) run() {
 
 // createWorker initializes and starts a new worker for conn
 func (h *ConnectionHandler) createWorker(conn net.Conn) {
-	worker := NewWorker(h.source, conn, h.pp.NextPipelineChan())
+	worker := NewWorker(h.source, conn, h.pp.NextPipelineInput())
 	worker.Start()
 	h.workers = append(h.workers, worker)
 }
@@ -0,0 +1,205 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2018 Datadog, Inc.
+
+package listener
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"net"
+	"strings"
+	"time"
+
+	log "github.com/cihub/seelog"
+
+	"github.com/DataDog/datadog-agent/pkg/logs/config"
+	"github.com/DataDog/datadog-agent/pkg/logs/input/syslog"
+	"github.com/DataDog/datadog-agent/pkg/logs/message"
+	"github.com/DataDog/datadog-agent/pkg/logs/pb"
+)
+
+// maxDatadogFrameLen is the length above which the frames sent by the
+// agents are rejected, the logs they hold are truncated way below
+const maxDatadogFrameLen = 1024 * 1024
+
+// readDatadogFrames reads the logs sent by other agents with the protocol
+// of the TCP intake, either length prefixed protocol buffers or lines, and
+// relays them until timeout or an error occurs
+func (w *Worker) readDatadogFrames() {
+	defer func() {
+		w.conn.Close()
+		w.shouldStop = true
+		w.done <- struct{}{}
+	}()
+	reader := bufio.NewReader(w.conn)
+	w.conn.SetReadDeadline(time.Now().Add(defaultTimeout))
+	first, err := reader.Peek(1)
+	if err != nil {
+		w.handleReadError(err)
+		return
+	}
+	// the length prefixing the protocol buffers starts with a null byte,
+	// the lines with the API key
+	useProto := first[0] == 0
+	nextFrame := newLineReader(reader)
+	if useProto {
+		nextFrame = newLengthPrefixReader(reader)
+	}
+	for {
+		select {
+		case <-w.stop:
+			// stop reading data from the connection
+			return
+		default:
+			w.conn.SetReadDeadline(time.Now().Add(defaultTimeout))
+			frame, err := nextFrame()
+			if err != nil {
+				w.handleReadError(err)
+				return
+			}
+			w.source.Metrics.AddBytesRead(len(frame))
+			msg, err := parseDatadogFrame(w.source, frame, useProto)
+			if err != nil {
+				log.Debugf("Couldn't parse log relayed from %s: %s", w.conn.RemoteAddr(), err)
+				continue
+			}
+			w.source.Metrics.AddMessagesDecoded(1)
+			w.output.Push(msg)
+		}
+	}
+}
+
+// handleReadError reports the errors of a connection, other than its end
+// or its timeout
+func (w *Worker) handleReadError(err error) {
+	if err == io.EOF {
+		return
+	}
+	if netErr, ok := err.(net.Error); ok && netErr.Timeout() {
+		return
+	}
+	w.source.Status.Error(err)
+	log.Warn("Couldn't read message from connection: ", err)
+}
+
+// newLengthPrefixReader returns a function reading the frames prefixed with
+// their length, as an unsigned 32-bit big-endian integer
+func newLengthPrefixReader(reader io.Reader) func() ([]byte, error) {
+	return func() ([]byte, error) {
+		var length uint32
+		if err := binary.Read(reader, binary.BigEndian, &length); err != nil {
+			return nil, err
+		}
+		if length > maxDatadogFrameLen {
+			return nil, fmt.Errorf("frame too long: %d bytes", length)
+		}
+		frame := make([]byte, length)
+		if _, err := io.ReadFull(reader, frame); err != nil {
+			return nil, err
+		}
+		return frame, nil
+	}
+}
+
+// newLineReader returns a function reading the frames delimited by a line
+// break
+func newLineReader(reader io.Reader) func() ([]byte, error) {
+	scanner := bufio.NewScanner(reader)
+	scanner.Buffer(make([]byte, 4096), maxDatadogFrameLen)
+	return func() ([]byte, error) {
+		if !scanner.Scan() {
+			if err := scanner.Err(); err != nil {
+				return nil, err
+			}
+			return nil, io.EOF
+		}
+		// the buffer of the scanner is reused by the next scan
+		return append([]byte(nil), scanner.Bytes()...), nil
+	}
+}
+
+// parseDatadogFrame returns the message of a log sent by an agent,
+// "<api_key>[/<logset>] <log>", the log being a protocol buffer or a
+// RFC5424 line. The message keeps the hostname, service, source and tags
+// of the log, the tags of the source are appended to the latter.
+func parseDatadogFrame(source *config.LogSource, frame []byte, useProto bool) (message.Message, error) {
+	end := bytes.IndexByte(frame, ' ')
+	if end <= 0 {
+		return nil, fmt.Errorf("missing API key")
+	}
+	content := frame[end+1:]
+	if useProto {
+		return parseProtoLog(source, content)
+	}
+	return parseRawLog(source, content)
+}
+
+// parseProtoLog returns the message of a log encoded as a protocol buffer
+func parseProtoLog(source *config.LogSource, content []byte) (message.Message, error) {
+	var relayed pb.Log
+	if err := relayed.Unmarshal(content); err != nil {
+		return nil, err
+	}
+	origin := message.NewOrigin(source)
+	origin.SetSource(relayed.Source)
+	// the source is added back to the tags by the origin
+	var tags []string
+	for _, tag := range relayed.Tags {
+		if !strings.HasPrefix(tag, "source:") {
+			tags = append(tags, tag)
+		}
+	}
+	origin.SetTags(tags)
+
+	severity := config.SevInfo
+	if relayed.Status == config.StatusError {
+		severity = config.SevError
+	}
+	msg := message.New([]byte(relayed.Message), origin, severity)
+	attributes := msg.GetAttributes()
+	attributes.Status = relayed.Status
+	if relayed.Timestamp > 0 {
+		attributes.Timestamp = time.Unix(0, relayed.Timestamp).UTC()
+	}
+	attributes.Hostname = relayed.Hostname
+	attributes.Service = relayed.Service
+	return msg, nil
+}
+
+// parseRawLog returns the message of a RFC5424 line, with the source and
+// the tags of the log in the "dd" structured data
+func parseRawLog(source *config.LogSource, content []byte) (message.Message, error) {
+	attrs, text, err := syslog.Parse(content)
+	if err != nil {
+		return nil, err
+	}
+	dd := attrs.StructuredData["dd"]
+	origin := message.NewOrigin(source)
+	origin.SetSource(dd["ddsource"])
+	var tags []string
+	if dd["ddtags"] != "" {
+		tags = strings.Split(dd["ddtags"], ",")
+	}
+	if dd["ddsourcecategory"] != "" {
+		tags = append(tags, "sourcecategory:"+dd["ddsourcecategory"])
+	}
+	origin.SetTags(tags)
+
+	severity := config.SevInfo
+	if attrs.Severity <= 3 {
+		severity = config.SevError
+	}
+	msg := message.New(text, origin, severity)
+	attributes := msg.GetAttributes()
+	if timestamp, err := time.Parse(time.RFC3339Nano, attrs.Timestamp); err == nil {
+		attributes.Timestamp = timestamp.UTC()
+	}
+	attributes.Hostname = attrs.Hostname
+	attributes.Service = attrs.AppName
+	return msg, nil
+}
@@ -0,0 +1,82 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2018 Datadog, Inc.
+
+package listener
+
+import (
+	"encoding/binary"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/DataDog/datadog-agent/pkg/logs/config"
+	"github.com/DataDog/datadog-agent/pkg/logs/message"
+	"github.com/DataDog/datadog-agent/pkg/logs/pb"
+)
+
+func TestRelayProtoLogs(t *testing.T) {
+	source := config.NewLogSource("", &config.LogsConfig{Type: config.TCPType, Port: port, Format: config.DatadogFormat, Tags: []string{"relay:eu"}})
+	msgs := message.NewRing(1)
+	r, w := net.Pipe()
+	worker := NewWorker(source, r, msgs)
+	worker.Start()
+	defer worker.Stop()
+
+	payload, err := (&pb.Log{
+		Message:   "disk full",
+		Status:    config.StatusError,
+		Timestamp: time.Date(2018, time.October, 16, 12, 0, 0, 0, time.UTC).UnixNano(),
+		Hostname:  "web-1",
+		Service:   "app",
+		Source:    "nginx",
+		Tags:      []string{"env:prod", "source:nginx"},
+	}).Marshal()
+	require.NoError(t, err)
+	frame := append([]byte("0123456789abcdef0123456789abcdef "), payload...)
+	length := make([]byte, 4)
+	binary.BigEndian.PutUint32(length, uint32(len(frame)))
+	go w.Write(append(length, frame...))
+
+	msg := msgs.Pop()
+	assert.Equal(t, "disk full", string(msg.Content()))
+	assert.Equal(t, config.SevError, msg.GetSeverity())
+	assert.Equal(t, config.StatusError, msg.GetAttributes().Status)
+	assert.Equal(t, time.Date(2018, time.October, 16, 12, 0, 0, 0, time.UTC), msg.GetAttributes().Timestamp)
+	assert.Equal(t, "web-1", msg.GetAttributes().Hostname)
+	assert.Equal(t, "app", msg.GetAttributes().Service)
+	assert.Equal(t, "nginx", msg.GetOrigin().Source())
+	assert.Equal(t, []string{"env:prod", "source:nginx", "relay:eu"}, msg.GetOrigin().Tags())
+}
+
+func TestRelayRawLogs(t *testing.T) {
+	source := config.NewLogSource("", &config.LogsConfig{Type: config.TCPType, Port: port, Format: config.DatadogFormat, Tags: []string{"relay:eu"}})
+	msgs := message.NewRing(1)
+	r, w := net.Pipe()
+	worker := NewWorker(source, r, msgs)
+	worker.Start()
+	defer worker.Stop()
+
+	go w.Write([]byte("0123456789abcdef0123456789abcdef <46>0 2018-10-16T12:00:00.000000000Z web-1 app - - [dd ddsource=\"nginx\"][dd ddsourcecategory=\"http\"][dd ddtags=\"env:prod\"] GET /\n" +
+		"not a datadog log\n" +
+		"0123456789abcdef0123456789abcdef/logset <43>0 2018-10-16T12:00:01.000000000Z web-1 - - - - disk full\n"))
+
+	msg := msgs.Pop()
+	assert.Equal(t, "GET /", string(msg.Content()))
+	assert.Equal(t, config.SevInfo, msg.GetSeverity())
+	assert.Equal(t, time.Date(2018, time.October, 16, 12, 0, 0, 0, time.UTC), msg.GetAttributes().Timestamp)
+	assert.Equal(t, "web-1", msg.GetAttributes().Hostname)
+	assert.Equal(t, "app", msg.GetAttributes().Service)
+	assert.Equal(t, []string{"env:prod", "sourcecategory:http", "source:nginx", "relay:eu"}, msg.GetOrigin().Tags())
+
+	// the lines which are not sent by an agent are dropped
+	msg = msgs.Pop()
+	assert.Equal(t, "disk full", string(msg.Content()))
+	assert.Equal(t, config.SevError, msg.GetSeverity())
+	assert.Equal(t, "", msg.GetAttributes().Service)
+	assert.Equal(t, []string{"relay:eu"}, msg.GetOrigin().Tags())
+}
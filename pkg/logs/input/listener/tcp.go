@@ -6,19 +6,24 @@
 package listener
 
 import (
+	"crypto/tls"
+	"crypto/x509"
 	"fmt"
+	"io/ioutil"
 	"net"
 
 	log "github.com/cihub/seelog"
 
 	"github.com/DataDog/datadog-agent/pkg/logs/config"
 	"github.com/DataDog/datadog-agent/pkg/logs/pipeline"
+	"github.com/DataDog/datadog-agent/pkg/util/allowlist"
 )
 
 // A TCPListener listens and accepts TCP connections and delegates the work to connHandler
 type TCPListener struct {
 	port        int
 	listener    net.Listener
+	allowlist   *allowlist.Allowlist
 	connHandler *ConnectionHandler
 	stop        chan struct{}
 	done        chan struct{}
@@ -26,22 +31,63 @@ type TCPListener struct {
 
 // NewTCPListener returns an initialized TCPListener
 func NewTCPListener(pp pipeline.Provider, source *config.LogSource) (*TCPListener, error) {
+	sources, err := allowlist.New(fmt.Sprintf("logs-tcp-%d", source.Config.Port), source.Config.AllowedSources)
+	if err != nil {
+		source.Status.Error(err)
+		return nil, err
+	}
 	listener, err := net.Listen("tcp", fmt.Sprintf(":%d", source.Config.Port))
 	if err != nil {
 		source.Status.Error(err)
 		return nil, err
 	}
+	if source.Config.TLSCert != "" {
+		tlsConfig, err := buildTLSConfig(source.Config)
+		if err != nil {
+			listener.Close()
+			source.Status.Error(err)
+			return nil, err
+		}
+		listener = tls.NewListener(listener, tlsConfig)
+	}
 	source.Status.Success()
 	connHandler := NewConnectionHandler(pp, source)
 	return &TCPListener{
 		port:        source.Config.Port,
 		listener:    listener,
+		allowlist:   sources,
 		connHandler: connHandler,
 		stop:        make(chan struct{}, 1),
 		done:        make(chan struct{}, 1),
 	}, nil
 }
 
+// buildTLSConfig returns the configuration terminating the TLS connections of
+// a source, which must present a certificate signed by tls_ca when it is set
+func buildTLSConfig(cfg *config.LogsConfig) (*tls.Config, error) {
+	cert, err := tls.LoadX509KeyPair(cfg.TLSCert, cfg.TLSKey)
+	if err != nil {
+		return nil, fmt.Errorf("could not load the TLS certificate of the source: %v", err)
+	}
+	tlsConfig := &tls.Config{
+		Certificates: []tls.Certificate{cert},
+		MinVersion:   tls.VersionTLS12,
+	}
+	if cfg.TLSCA != "" {
+		ca, err := ioutil.ReadFile(cfg.TLSCA)
+		if err != nil {
+			return nil, fmt.Errorf("could not read the TLS CA of the source: %v", err)
+		}
+		pool := x509.NewCertPool()
+		if !pool.AppendCertsFromPEM(ca) {
+			return nil, fmt.Errorf("no certificate found in the TLS CA of the source %s", cfg.TLSCA)
+		}
+		tlsConfig.ClientCAs = pool
+		tlsConfig.ClientAuth = tls.RequireAndVerifyClientCert
+	}
+	return tlsConfig, nil
+}
+
 // Start listens to TCP connections on another routine
 func (l *TCPListener) Start() {
 	log.Info("Starting TCP forwarder on port ", l.port)
@@ -79,6 +125,10 @@ func (l *TCPListener) run() {
 				return
 			}
 			l.connHandler.source.Status.Success()
+			if !l.allowlist.Allowed(conn.RemoteAddr()) {
+				conn.Close()
+				continue
+			}
 			go l.connHandler.HandleConnection(conn)
 		}
 	}
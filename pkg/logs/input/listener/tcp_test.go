@@ -6,31 +6,46 @@
 package listener
 
 import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
 	"fmt"
+	"io/ioutil"
+	"math/big"
 	"net"
+	"os"
+	"path/filepath"
 	"testing"
+	"time"
 
 	"github.com/DataDog/datadog-agent/pkg/logs/config"
 	"github.com/DataDog/datadog-agent/pkg/logs/message"
 	"github.com/DataDog/datadog-agent/pkg/logs/pipeline"
 	"github.com/DataDog/datadog-agent/pkg/logs/pipeline/mock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 	"github.com/stretchr/testify/suite"
 )
 
 const tcpTestPort = 10512
+const tcpTLSTestPort = 10514
 
 type TCPTestSuite struct {
 	suite.Suite
 
-	outputChan chan message.Message
-	pp         pipeline.Provider
-	source     *config.LogSource
-	tcpl       *TCPListener
+	output *message.Ring
+	pp     pipeline.Provider
+	source *config.LogSource
+	tcpl   *TCPListener
 }
 
 func (suite *TCPTestSuite) SetupTest() {
 	suite.pp = mock.NewMockProvider()
-	suite.outputChan = suite.pp.NextPipelineChan()
+	suite.output = suite.pp.NextPipelineInput()
 	suite.source = config.NewLogSource("", &config.LogsConfig{Type: config.TCPType, Port: tcpTestPort})
 	tcpl, err := NewTCPListener(suite.pp, suite.source)
 	suite.Nil(err)
@@ -44,10 +59,66 @@ func (suite *TCPTestSuite) TestTCPReceivesMessages() {
 
 	// should receive and decode message
 	fmt.Fprintf(conn, "hello world\n")
-	msg := <-suite.outputChan
+	msg := suite.output.Pop()
 	suite.Equal("hello world", string(msg.Content()))
 }
 
 func TestTCPTestSuite(t *testing.T) {
 	suite.Run(t, new(TCPTestSuite))
 }
+
+// writeTestCertificate writes a self-signed certificate of localhost and its
+// key to dir, and returns their paths
+func writeTestCertificate(t *testing.T, dir string) (string, string) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.Nil(t, err)
+	template := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: "localhost"},
+		DNSNames:              []string{"localhost"},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		KeyUsage:              x509.KeyUsageDigitalSignature | x509.KeyUsageCertSign,
+		ExtKeyUsage:           []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth, x509.ExtKeyUsageClientAuth},
+		BasicConstraintsValid: true,
+		IsCA:                  true,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	require.Nil(t, err)
+	keyDer, err := x509.MarshalECPrivateKey(key)
+	require.Nil(t, err)
+
+	certPath, keyPath := filepath.Join(dir, "cert.pem"), filepath.Join(dir, "key.pem")
+	require.Nil(t, ioutil.WriteFile(certPath, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}), 0600))
+	require.Nil(t, ioutil.WriteFile(keyPath, pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDer}), 0600))
+	return certPath, keyPath
+}
+
+func TestTCPListenerWithTLS(t *testing.T) {
+	dir, err := ioutil.TempDir("", "logs-tcp-tls-")
+	require.Nil(t, err)
+	defer os.RemoveAll(dir)
+	certPath, keyPath := writeTestCertificate(t, dir)
+
+	pp := mock.NewMockProvider()
+	output := pp.NextPipelineInput()
+	source := config.NewLogSource("", &config.LogsConfig{Type: config.TCPType, Port: tcpTLSTestPort, TLSCert: certPath, TLSKey: keyPath, TLSCA: certPath})
+	tcpl, err := NewTCPListener(pp, source)
+	require.Nil(t, err)
+	tcpl.Start()
+	defer tcpl.Stop()
+
+	cert, err := tls.LoadX509KeyPair(certPath, keyPath)
+	require.Nil(t, err)
+	roots := x509.NewCertPool()
+	leaf, err := x509.ParseCertificate(cert.Certificate[0])
+	require.Nil(t, err)
+	roots.AddCert(leaf)
+
+	// the clients must present a certificate signed by the CA
+	conn, err := tls.Dial("tcp", fmt.Sprintf("localhost:%d", tcpTLSTestPort), &tls.Config{RootCAs: roots, Certificates: []tls.Certificate{cert}})
+	require.Nil(t, err)
+	defer conn.Close()
+	fmt.Fprintf(conn, "hello world\n")
+	assert.Equal(t, "hello world", string(output.Pop().Content()))
+}
@@ -13,12 +13,13 @@ import (
 
 	"github.com/DataDog/datadog-agent/pkg/logs/config"
 	"github.com/DataDog/datadog-agent/pkg/logs/pipeline"
+	"github.com/DataDog/datadog-agent/pkg/util/allowlist"
 )
 
 // A UDPListener listens for UDP connections and delegates the work to connHandler
 type UDPListener struct {
 	port        int
-	conn        *net.UDPConn
+	conn        net.Conn
 	connHandler *ConnectionHandler
 	stop        chan struct{}
 	done        chan struct{}
@@ -26,6 +27,11 @@ type UDPListener struct {
 
 // NewUDPListener returns an initialized UDPListener
 func NewUDPListener(pp pipeline.Provider, source *config.LogSource) (*UDPListener, error) {
+	sources, err := allowlist.New(fmt.Sprintf("logs-udp-%d", source.Config.Port), source.Config.AllowedSources)
+	if err != nil {
+		source.Status.Error(err)
+		return nil, err
+	}
 	udpAddr, err := net.ResolveUDPAddr("udp", fmt.Sprintf(":%d", source.Config.Port))
 	if err != nil {
 		source.Status.Error(err)
@@ -38,9 +44,13 @@ func NewUDPListener(pp pipeline.Provider, source *config.LogSource) (*UDPListene
 	}
 	source.Status.Success()
 	connHandler := NewConnectionHandler(pp, source)
+	var filteredConn net.Conn = conn
+	if sources != nil {
+		filteredConn = &allowedUDPConn{UDPConn: conn, allowlist: sources}
+	}
 	return &UDPListener{
 		port:        source.Config.Port,
-		conn:        conn,
+		conn:        filteredConn,
 		connHandler: connHandler,
 		stop:        make(chan struct{}, 1),
 		done:        make(chan struct{}, 1),
@@ -69,3 +79,19 @@ func (l *UDPListener) run() {
 	l.connHandler.Stop()
 	l.done <- struct{}{}
 }
+
+// allowedUDPConn drops the datagrams of the sources not in its allowlist
+type allowedUDPConn struct {
+	*net.UDPConn
+	allowlist *allowlist.Allowlist
+}
+
+// Read reads the next datagram sent by an allowed source
+func (c *allowedUDPConn) Read(b []byte) (int, error) {
+	for {
+		n, addr, err := c.UDPConn.ReadFrom(b)
+		if err != nil || c.allowlist.Allowed(addr) {
+			return n, err
+		}
+	}
+}
@@ -9,11 +9,15 @@ import (
 	"fmt"
 	"net"
 	"testing"
+	"time"
 
 	"github.com/DataDog/datadog-agent/pkg/logs/config"
 	"github.com/DataDog/datadog-agent/pkg/logs/message"
 	"github.com/DataDog/datadog-agent/pkg/logs/pipeline"
 	"github.com/DataDog/datadog-agent/pkg/logs/pipeline/mock"
+	"github.com/DataDog/datadog-agent/pkg/util/allowlist"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 	"github.com/stretchr/testify/suite"
 )
 
@@ -22,15 +26,15 @@ const udpTestPort = 10513
 type UDPTestSuite struct {
 	suite.Suite
 
-	outputChan chan message.Message
-	pp         pipeline.Provider
-	source     *config.LogSource
-	udpl       *UDPListener
+	output *message.Ring
+	pp     pipeline.Provider
+	source *config.LogSource
+	udpl   *UDPListener
 }
 
 func (suite *UDPTestSuite) SetupTest() {
 	suite.pp = mock.NewMockProvider()
-	suite.outputChan = suite.pp.NextPipelineChan()
+	suite.output = suite.pp.NextPipelineInput()
 	suite.source = config.NewLogSource("", &config.LogsConfig{Type: config.UDPType, Port: udpTestPort})
 	udpl, err := NewUDPListener(suite.pp, suite.source)
 	suite.Nil(err)
@@ -44,10 +48,43 @@ func (suite *UDPTestSuite) TestUDPReceivesMessages() {
 
 	// should receive and decode message
 	fmt.Fprintf(conn, "hello world\n")
-	msg := <-suite.outputChan
+	msg := suite.output.Pop()
 	suite.Equal("hello world", string(msg.Content()))
 }
 
+func TestAllowedUDPConn(t *testing.T) {
+	for _, tc := range []struct {
+		allowedSources []string
+		received       bool
+	}{
+		{[]string{"127.0.0.0/8", "::1"}, true},
+		{[]string{"10.0.0.0/8"}, false},
+	} {
+		conn, err := net.ListenUDP("udp", &net.UDPAddr{IP: net.ParseIP("127.0.0.1")})
+		require.NoError(t, err)
+		sources, err := allowlist.New("test", tc.allowedSources)
+		require.NoError(t, err)
+		allowedConn := &allowedUDPConn{UDPConn: conn, allowlist: sources}
+
+		client, err := net.Dial("udp", conn.LocalAddr().String())
+		require.NoError(t, err)
+		fmt.Fprintf(client, "hello world\n")
+		client.Close()
+
+		allowedConn.SetReadDeadline(time.Now().Add(100 * time.Millisecond))
+		buf := make([]byte, 64)
+		n, err := allowedConn.Read(buf)
+		if tc.received {
+			assert.NoError(t, err)
+			assert.Equal(t, "hello world\n", string(buf[:n]))
+		} else {
+			assert.Error(t, err)
+			assert.Equal(t, int64(1), sources.Rejected())
+		}
+		conn.Close()
+	}
+}
+
 func TestUDPTestSuite(t *testing.T) {
 	suite.Run(t, new(UDPTestSuite))
 }
@@ -14,6 +14,7 @@ import (
 
 	"github.com/DataDog/datadog-agent/pkg/logs/config"
 	"github.com/DataDog/datadog-agent/pkg/logs/decoder"
+	"github.com/DataDog/datadog-agent/pkg/logs/input/syslog"
 	"github.com/DataDog/datadog-agent/pkg/logs/message"
 )
 
@@ -24,7 +25,7 @@ const defaultTimeout = 60000 * time.Millisecond
 type Worker struct {
 	source     *config.LogSource
 	conn       net.Conn
-	outputChan chan message.Message
+	output     *message.Ring
 	decoder    *decoder.Decoder
 	shouldStop bool
 	stop       chan struct{}
@@ -32,19 +33,24 @@ type Worker struct {
 }
 
 // NewWorker returns a new Worker
-func NewWorker(source *config.LogSource, conn net.Conn, outputChan chan message.Message) *Worker {
+func NewWorker(source *config.LogSource, conn net.Conn, output *message.Ring) *Worker {
 	return &Worker{
-		source:     source,
-		conn:       conn,
-		outputChan: outputChan,
-		decoder:    decoder.InitializeDecoder(source),
-		stop:       make(chan struct{}, 1),
-		done:       make(chan struct{}, 1),
+		source:  source,
+		conn:    conn,
+		output:  output,
+		decoder: decoder.InitializeDecoder(source),
+		stop:    make(chan struct{}, 1),
+		done:    make(chan struct{}, 1),
 	}
 }
 
 // Start prepares the worker to read and decode data from the connection
 func (w *Worker) Start() {
+	if w.source.Config.Format == config.DatadogFormat {
+		// the logs sent by the agents are already split and formatted
+		go w.readDatadogFrames()
+		return
+	}
 	go w.forwardMessages()
 	w.decoder.Start()
 	go w.readForever()
@@ -66,8 +72,23 @@ func (w *Worker) forwardMessages() {
 	}()
 	for output := range w.decoder.OutputChan {
 		origin := message.NewOrigin(w.source)
-		w.outputChan <- message.New(output.Content, origin, nil)
+		content, severity := output.Content, []byte(nil)
+		if w.source.Config.Format == config.SyslogFormat {
+			content, severity = w.formatSyslogMessage(content)
+		}
+		w.output.Push(message.New(content, origin, severity))
+	}
+}
+
+// formatSyslogMessage turns a syslog message into a structured log, the
+// message is sent as is when it can't be parsed
+func (w *Worker) formatSyslogMessage(content []byte) ([]byte, []byte) {
+	formatted, severity, err := syslog.Format(content)
+	if err != nil {
+		log.Debugf("Couldn't parse syslog message from port %d: %s", w.source.Config.Port, err)
+		return content, nil
 	}
+	return formatted, severity
 }
 
 // readForever reads the data from conn until timeout or an error occurs
@@ -21,19 +21,19 @@ const port = 10493
 type WorkerTestSuite struct {
 	suite.Suite
 
-	w       *Worker
-	conn    net.Conn
-	msgChan chan message.Message
+	w    *Worker
+	conn net.Conn
+	msgs *message.Ring
 }
 
 func (suite *WorkerTestSuite) SetupTest() {
 	source := config.NewLogSource("", &config.LogsConfig{Type: config.TCPType, Port: port})
-	msgChan := make(chan message.Message)
+	msgs := message.NewRing(1)
 	r, w := net.Pipe()
 
-	suite.w = NewWorker(source, r, msgChan)
+	suite.w = NewWorker(source, r, msgs)
 	suite.conn = w
-	suite.msgChan = msgChan
+	suite.msgs = msgs
 
 	suite.w.Start()
 }
@@ -47,14 +47,14 @@ func (suite *WorkerTestSuite) TestReadAndForward() {
 
 	// should receive and decode one message
 	suite.conn.Write([]byte("foo\n"))
-	msg = <-suite.msgChan
+	msg = suite.msgs.Pop()
 	suite.Equal("foo", string(msg.Content()))
 
 	// should receive and decode two messages
 	suite.conn.Write([]byte("bar\nboo\n"))
-	msg = <-suite.msgChan
+	msg = suite.msgs.Pop()
 	suite.Equal("bar", string(msg.Content()))
-	msg = <-suite.msgChan
+	msg = suite.msgs.Pop()
 	suite.Equal("boo", string(msg.Content()))
 }
 
@@ -74,3 +74,23 @@ func TestMustKeepConnAlive(t *testing.T) {
 	worker = NewWorker(source, nil, nil)
 	assert.True(t, worker.mustKeepConnAlive())
 }
+
+func TestReadAndFormatSyslogMessages(t *testing.T) {
+	source := config.NewLogSource("", &config.LogsConfig{Type: config.TCPType, Port: port, Format: config.SyslogFormat})
+	msgs := message.NewRing(1)
+	r, w := net.Pipe()
+	worker := NewWorker(source, r, msgs)
+	worker.Start()
+	defer worker.Stop()
+
+	w.Write([]byte("<11>1 - host app - - - disk full\n"))
+	msg := msgs.Pop()
+	assert.Equal(t, `{"message":"disk full","syslog":{"priority":11,"facility":1,"severity":3,"version":1,"hostname":"host","appname":"app"}}`, string(msg.Content()))
+	assert.Equal(t, config.SevError, msg.GetSeverity())
+
+	// the messages which can't be parsed are sent as is
+	w.Write([]byte("not a syslog message\n"))
+	msg = msgs.Pop()
+	assert.Equal(t, "not a syslog message", string(msg.Content()))
+	assert.Nil(t, msg.GetSeverity())
+}
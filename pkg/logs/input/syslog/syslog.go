@@ -0,0 +1,270 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2018 Datadog, Inc.
+
+package syslog
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/DataDog/datadog-agent/pkg/logs/config"
+)
+
+// nilValue is the value of the RFC5424 fields which are not set
+const nilValue = "-"
+
+// rfc3164TimestampLen is the length of the timestamps of the RFC3164
+// messages, like "Oct  6 12:00:00"
+const rfc3164TimestampLen = len(time.Stamp)
+
+// maxPriority is the highest priority, facility 23 (local7) with
+// severity 7 (debug)
+const maxPriority = 191
+
+// utf8BOM may start the MSG part of the RFC5424 messages
+var utf8BOM = []byte("\xef\xbb\xbf")
+
+// now returns the current time, used to pick the year of the RFC3164
+// timestamps which don't hold it
+var now = time.Now
+
+// Attributes holds the syslog attributes of a message
+type Attributes struct {
+	Priority       int                          `json:"priority"`
+	Facility       int                          `json:"facility"`
+	Severity       int                          `json:"severity"`
+	Version        int                          `json:"version,omitempty"`
+	Timestamp      string                       `json:"timestamp,omitempty"`
+	Hostname       string                       `json:"hostname,omitempty"`
+	AppName        string                       `json:"appname,omitempty"`
+	ProcID         string                       `json:"procid,omitempty"`
+	MsgID          string                       `json:"msgid,omitempty"`
+	StructuredData map[string]map[string]string `json:"structured_data,omitempty"`
+}
+
+// Format parses a RFC5424 or RFC3164 syslog message and returns it as a
+// JSON log, with the text of the message as message and the syslog fields
+// (priority, facility, hostname, structured data...) as syslog attributes,
+// along with its severity: the messages from the error severity to the
+// emergency one are reported as errors.
+func Format(content []byte) ([]byte, []byte, error) {
+	attrs, msg, err := Parse(content)
+	if err != nil {
+		return nil, nil, err
+	}
+	formatted, err := json.Marshal(map[string]interface{}{
+		"message": string(msg),
+		"syslog":  attrs,
+	})
+	if err != nil {
+		return nil, nil, err
+	}
+	severity := config.SevInfo
+	if attrs.Severity <= 3 {
+		severity = config.SevError
+	}
+	return formatted, severity, nil
+}
+
+// Parse returns the attributes and the text of a RFC5424 or RFC3164 syslog
+// message
+func Parse(content []byte) (*Attributes, []byte, error) {
+	priority, rest, err := parsePriority(content)
+	if err != nil {
+		return nil, nil, err
+	}
+	attrs := &Attributes{
+		Priority: priority,
+		Facility: priority / 8,
+		Severity: priority % 8,
+	}
+	// the agents send their logs with the version 0
+	if len(rest) > 1 && rest[0] >= '0' && rest[0] <= '9' && rest[1] == ' ' {
+		msg, err := parseRFC5424(attrs, rest)
+		return attrs, msg, err
+	}
+	return attrs, parseRFC3164(attrs, rest), nil
+}
+
+// parsePriority returns the priority of the message, "<PRI>", along with
+// the content following it
+func parsePriority(content []byte) (int, []byte, error) {
+	if len(content) == 0 || content[0] != '<' {
+		return 0, nil, fmt.Errorf("not a syslog message: missing priority")
+	}
+	end := bytes.IndexByte(content, '>')
+	if end < 2 || end > 4 {
+		return 0, nil, fmt.Errorf("not a syslog message: invalid priority")
+	}
+	priority, err := strconv.Atoi(string(content[1:end]))
+	if err != nil || priority < 0 || priority > maxPriority {
+		return 0, nil, fmt.Errorf("not a syslog message: invalid priority %q", content[1:end])
+	}
+	return priority, content[end+1:], nil
+}
+
+// parseRFC5424 parses "VERSION TIMESTAMP HOSTNAME APP-NAME PROCID MSGID
+// STRUCTURED-DATA [MSG]" and returns MSG
+func parseRFC5424(attrs *Attributes, content []byte) ([]byte, error) {
+	attrs.Version = int(content[0] - '0')
+	rest := content[2:]
+
+	var fields [5]string
+	for i := range fields {
+		var field []byte
+		field, rest = nextField(rest)
+		if len(field) == 0 {
+			return nil, fmt.Errorf("invalid RFC5424 message: missing header fields")
+		}
+		if string(field) != nilValue {
+			fields[i] = string(field)
+		}
+	}
+	if fields[0] != "" {
+		timestamp, err := time.Parse(time.RFC3339Nano, fields[0])
+		if err != nil {
+			return nil, fmt.Errorf("invalid RFC5424 message: %s", err)
+		}
+		attrs.Timestamp = timestamp.Format(time.RFC3339Nano)
+	}
+	attrs.Hostname, attrs.AppName, attrs.ProcID, attrs.MsgID = fields[1], fields[2], fields[3], fields[4]
+
+	structuredData, rest, err := parseStructuredData(rest)
+	if err != nil {
+		return nil, err
+	}
+	attrs.StructuredData = structuredData
+
+	if len(rest) > 0 && rest[0] == ' ' {
+		rest = rest[1:]
+	}
+	return bytes.TrimPrefix(rest, utf8BOM), nil
+}
+
+// parseStructuredData parses either "-" or a sequence of elements like
+// `[id name="value" ...]`, the values escaping `"`, `\` and `]` with a `\`
+func parseStructuredData(content []byte) (map[string]map[string]string, []byte, error) {
+	if len(content) > 0 && content[0] == '-' {
+		return nil, content[1:], nil
+	}
+	structuredData := make(map[string]map[string]string)
+	for len(content) > 0 && content[0] == '[' {
+		content = content[1:]
+		end := bytes.IndexAny(content, " ]")
+		if end <= 0 {
+			return nil, nil, fmt.Errorf("invalid RFC5424 message: invalid structured data")
+		}
+		// the parameters of the elements sharing an id are merged, like the
+		// ones of the "dd" elements of the agents
+		params, exists := structuredData[string(content[:end])]
+		if !exists {
+			params = make(map[string]string)
+			structuredData[string(content[:end])] = params
+		}
+		content = content[end:]
+		for len(content) > 0 && content[0] == ' ' {
+			content = content[1:]
+			eq := bytes.Index(content, []byte(`="`))
+			if eq <= 0 {
+				return nil, nil, fmt.Errorf("invalid RFC5424 message: invalid structured data parameter")
+			}
+			name := string(content[:eq])
+			value, rest, err := parseParamValue(content[eq+2:])
+			if err != nil {
+				return nil, nil, err
+			}
+			params[name] = value
+			content = rest
+		}
+		if len(content) == 0 || content[0] != ']' {
+			return nil, nil, fmt.Errorf("invalid RFC5424 message: unterminated structured data")
+		}
+		content = content[1:]
+	}
+	if len(structuredData) == 0 {
+		return nil, nil, fmt.Errorf("invalid RFC5424 message: missing structured data")
+	}
+	return structuredData, content, nil
+}
+
+// parseParamValue returns the unescaped value of a structured data
+// parameter up to its closing quote, along with the content following it
+func parseParamValue(content []byte) (string, []byte, error) {
+	var value []byte
+	for i := 0; i < len(content); i++ {
+		switch c := content[i]; c {
+		case '\\':
+			if i+1 < len(content) && (content[i+1] == '"' || content[i+1] == '\\' || content[i+1] == ']') {
+				i++
+				value = append(value, content[i])
+			} else {
+				value = append(value, c)
+			}
+		case '"':
+			return string(value), content[i+1:], nil
+		default:
+			value = append(value, c)
+		}
+	}
+	return "", nil, fmt.Errorf("invalid RFC5424 message: unterminated structured data parameter")
+}
+
+// parseRFC3164 parses "TIMESTAMP HOSTNAME TAG: MSG" and returns MSG, the
+// content which doesn't follow this format is returned as is like the
+// relays do
+func parseRFC3164(attrs *Attributes, content []byte) []byte {
+	if len(content) < rfc3164TimestampLen+1 || content[rfc3164TimestampLen] != ' ' {
+		return content
+	}
+	timestamp, err := time.ParseInLocation(time.Stamp, string(content[:rfc3164TimestampLen]), time.Local)
+	if err != nil {
+		return content
+	}
+	attrs.Timestamp = withCurrentYear(timestamp).Format(time.RFC3339)
+	rest := content[rfc3164TimestampLen+1:]
+
+	var hostname []byte
+	hostname, rest = nextField(rest)
+	attrs.Hostname = string(hostname)
+
+	// the TAG is the name of the program, possibly followed by its pid
+	// like in "sshd[1234]:"
+	end := bytes.IndexAny(rest, ": ")
+	if end <= 0 || rest[end] != ':' {
+		return rest
+	}
+	tag := rest[:end]
+	if open := bytes.IndexByte(tag, '['); open > 0 && tag[len(tag)-1] == ']' {
+		attrs.ProcID = string(tag[open+1 : len(tag)-1])
+		tag = tag[:open]
+	}
+	attrs.AppName = string(tag)
+	return bytes.TrimPrefix(rest[end+1:], []byte(" "))
+}
+
+// withCurrentYear sets the year of a RFC3164 timestamp to the current one,
+// or to the previous one when the timestamp would be in the future, like
+// for messages of December 31st received on January 1st
+func withCurrentYear(timestamp time.Time) time.Time {
+	current := now()
+	timestamp = timestamp.AddDate(current.Year(), 0, 0)
+	if timestamp.After(current.Add(24 * time.Hour)) {
+		timestamp = timestamp.AddDate(-1, 0, 0)
+	}
+	return timestamp
+}
+
+// nextField returns the content up to the next space, and the content
+// following this space
+func nextField(content []byte) ([]byte, []byte) {
+	end := bytes.IndexByte(content, ' ')
+	if end < 0 {
+		return content, nil
+	}
+	return content[:end], content[end+1:]
+}
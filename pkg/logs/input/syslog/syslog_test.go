@@ -0,0 +1,103 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2018 Datadog, Inc.
+
+package syslog
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/DataDog/datadog-agent/pkg/logs/config"
+)
+
+func TestFormatRFC5424(t *testing.T) {
+	content, severity, err := Format([]byte(`<165>1 2018-10-16T12:00:00.003Z mymachine.example.com evntslog 8710 ID47 [exampleSDID@32473 iut="3" eventSource="App\"lication\]"][origin ip="10.0.0.1"] ` + "\xef\xbb\xbf" + `An application event log entry...`))
+	require.NoError(t, err)
+	assert.Equal(t, config.SevInfo, severity)
+
+	var log struct {
+		Message string
+		Syslog  Attributes
+	}
+	require.NoError(t, json.Unmarshal(content, &log))
+	assert.Equal(t, "An application event log entry...", log.Message)
+	assert.Equal(t, Attributes{
+		Priority:  165,
+		Facility:  20,
+		Severity:  5,
+		Version:   1,
+		Timestamp: "2018-10-16T12:00:00.003Z",
+		Hostname:  "mymachine.example.com",
+		AppName:   "evntslog",
+		ProcID:    "8710",
+		MsgID:     "ID47",
+		StructuredData: map[string]map[string]string{
+			"exampleSDID@32473": {"iut": "3", "eventSource": `App"lication]`},
+			"origin":            {"ip": "10.0.0.1"},
+		},
+	}, log.Syslog)
+}
+
+func TestFormatRFC5424NilValues(t *testing.T) {
+	content, severity, err := Format([]byte(`<11>1 - - - - - -`))
+	require.NoError(t, err)
+	assert.Equal(t, config.SevError, severity)
+	assert.Equal(t, `{"message":"","syslog":{"priority":11,"facility":1,"severity":3,"version":1}}`, string(content))
+
+	_, _, err = Format([]byte(`<11>1 2018-10-16T12:00:00Z host app`))
+	assert.Error(t, err)
+
+	_, _, err = Format([]byte(`<11>1 2018-10-16T12:00:00Z host app - - [unterminated`))
+	assert.Error(t, err)
+}
+
+func TestParseRepeatedStructuredData(t *testing.T) {
+	attrs, msg, err := Parse([]byte(`<46>0 2018-10-16T12:00:00Z host app - - [dd ddsource="nginx"][dd ddtags="env:prod"] hello`))
+	require.NoError(t, err)
+	assert.Equal(t, "hello", string(msg))
+	assert.Equal(t, map[string]map[string]string{
+		"dd": {"ddsource": "nginx", "ddtags": "env:prod"},
+	}, attrs.StructuredData)
+}
+
+func TestFormatRFC3164(t *testing.T) {
+	defer func(n func() time.Time) { now = n }(now)
+	now = func() time.Time { return time.Date(2019, time.January, 1, 0, 0, 0, 0, time.Local) }
+
+	content, severity, err := Format([]byte(`<34>Dec 31 23:59:59 mymachine su[1234]: 'su root' failed for lonvick on /dev/pts/8`))
+	require.NoError(t, err)
+	assert.Equal(t, config.SevError, severity)
+
+	var log struct {
+		Message string
+		Syslog  Attributes
+	}
+	require.NoError(t, json.Unmarshal(content, &log))
+	assert.Equal(t, "'su root' failed for lonvick on /dev/pts/8", log.Message)
+	assert.Equal(t, 4, log.Syslog.Facility)
+	assert.Equal(t, 2, log.Syslog.Severity)
+	assert.Equal(t, time.Date(2018, time.December, 31, 23, 59, 59, 0, time.Local).Format(time.RFC3339), log.Syslog.Timestamp)
+	assert.Equal(t, "mymachine", log.Syslog.Hostname)
+	assert.Equal(t, "su", log.Syslog.AppName)
+	assert.Equal(t, "1234", log.Syslog.ProcID)
+}
+
+func TestFormatRFC3164WithoutHeader(t *testing.T) {
+	content, severity, err := Format([]byte(`<13>message without header`))
+	require.NoError(t, err)
+	assert.Equal(t, config.SevInfo, severity)
+	assert.Equal(t, `{"message":"message without header","syslog":{"priority":13,"facility":1,"severity":5}}`, string(content))
+}
+
+func TestFormatInvalidMessage(t *testing.T) {
+	for _, content := range []string{"", "no priority", "<>1 - - - - - -", "<192>too high", "<1a>not a number", "<1234>too long"} {
+		_, _, err := Format([]byte(content))
+		assert.Error(t, err, content)
+	}
+}
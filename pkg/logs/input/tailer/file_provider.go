@@ -9,13 +9,21 @@ import (
 	"fmt"
 	"os"
 	"path/filepath"
+	"sort"
 	"strings"
+	"sync"
+	"time"
 
 	log "github.com/cihub/seelog"
 
 	"github.com/DataDog/datadog-agent/pkg/logs/config"
+	"github.com/DataDog/datadog-agent/pkg/util/ratelimit"
 )
 
+// errorLogPeriod is the minimum period between two scans logging their errors,
+// errors are still reported in the sources status on every scan
+const errorLogPeriod = 10 * time.Minute
+
 // File represents a file to tail
 type File struct {
 	Path   string
@@ -33,8 +41,9 @@ func NewFile(path string, source *config.LogSource) *File {
 // FileProvider implements the logic to retrieve at most filesLimit Files defined in sources
 type FileProvider struct {
 	sources         []*config.LogSource
+	sourcesMutex    sync.Mutex
 	filesLimit      int
-	shouldLogErrors bool
+	errorLogLimiter ratelimit.Limiter
 }
 
 // NewFileProvider returns a new FileProvider
@@ -42,21 +51,25 @@ func NewFileProvider(sources []*config.LogSource, filesLimit int) *FileProvider
 	return &FileProvider{
 		sources:         sources,
 		filesLimit:      filesLimit,
-		shouldLogErrors: true,
+		errorLogLimiter: ratelimit.NewSlidingWindow("logs_file_provider_errors", 1, errorLogPeriod),
 	}
 }
 
 // FilesToTail returns all the Files matching paths in sources,
-// it cannot return more than filesLimit Files.
+// it cannot return more than filesLimit Files, nor more than the
+// open_files_limit of a source for the Files of this source.
 // For now, there is no way to prioritize specific Files over others,
 // they are just returned in alphabetical order
 func (p *FileProvider) FilesToTail() []*File {
 	filesToTail := []*File{}
-	shouldLogErrors := p.shouldLogErrors
-	p.shouldLogErrors = false // Let's log errors on first run only
+	shouldLogErrors := p.errorLogLimiter.Allow()
+
+	p.sourcesMutex.Lock()
+	sources := p.sources
+	p.sourcesMutex.Unlock()
 
-	for i := 0; i < len(p.sources) && len(filesToTail) < p.filesLimit; i++ {
-		source := p.sources[i]
+	for i := 0; i < len(sources) && len(filesToTail) < p.filesLimit; i++ {
+		source := sources[i]
 		sourcePath := source.Config.Path
 		if p.exists(sourcePath) {
 			// no need to traverse the file system here as we found a file
@@ -65,7 +78,7 @@ func (p *FileProvider) FilesToTail() []*File {
 		}
 		// search all files matching pattern and append them all until filesLimit is reached
 		pattern := sourcePath
-		paths, err := filepath.Glob(pattern)
+		paths, err := glob(pattern)
 		if err != nil {
 			err := fmt.Errorf("Malformed pattern, could not find any file: %s", pattern)
 			source.Status.Error(err)
@@ -76,7 +89,7 @@ func (p *FileProvider) FilesToTail() []*File {
 		}
 		if len(paths) == 0 {
 			// no file was found, its parent directories might have wrong permissions or it just does not exist
-			if p.containsWildcard(pattern) {
+			if containsWildcard(pattern) {
 				err := fmt.Errorf("Could not find any file matching pattern %s, check that all its subdirectories are exectutable", pattern)
 				source.Status.Error(err)
 				if shouldLogErrors {
@@ -91,7 +104,14 @@ func (p *FileProvider) FilesToTail() []*File {
 			}
 			continue
 		}
-		for j := 0; j < len(paths) && len(filesToTail) < p.filesLimit; j++ {
+		sourceLimit := len(paths)
+		if source.Config.OpenFilesLimit > 0 && source.Config.OpenFilesLimit < sourceLimit {
+			sourceLimit = source.Config.OpenFilesLimit
+			if shouldLogErrors {
+				log.Warnf("%d files match the pattern %s, only tailing the first %d of them", len(paths), pattern, sourceLimit)
+			}
+		}
+		for j := 0; j < sourceLimit && len(filesToTail) < p.filesLimit; j++ {
 			path := paths[j]
 			filesToTail = append(filesToTail, NewFile(path, source))
 		}
@@ -106,6 +126,27 @@ func (p *FileProvider) FilesToTail() []*File {
 	return filesToTail
 }
 
+// AddSource adds a source to the sources the files are looked for
+func (p *FileProvider) AddSource(source *config.LogSource) {
+	p.sourcesMutex.Lock()
+	defer p.sourcesMutex.Unlock()
+	sources := make([]*config.LogSource, 0, len(p.sources)+1)
+	p.sources = append(append(sources, p.sources...), source)
+}
+
+// RemoveSource removes a source from the sources the files are looked for
+func (p *FileProvider) RemoveSource(source *config.LogSource) {
+	p.sourcesMutex.Lock()
+	defer p.sourcesMutex.Unlock()
+	sources := make([]*config.LogSource, 0, len(p.sources))
+	for _, s := range p.sources {
+		if s != source {
+			sources = append(sources, s)
+		}
+	}
+	p.sources = sources
+}
+
 // exists returns true if the file at path filePath exists
 // Note: we can't rely on os.IsNotExist for windows, so we check error nullity.
 // As we're tailing with *, the error is related to the path being malformed.
@@ -117,6 +158,53 @@ func (p *FileProvider) exists(filePath string) bool {
 }
 
 // containsWildcard returns true if the path contains any wildcard character
-func (p *FileProvider) containsWildcard(path string) bool {
+func containsWildcard(path string) bool {
 	return strings.ContainsAny(path, "*?[")
 }
+
+// glob returns the files matching pattern in alphabetical order, like
+// filepath.Glob, a `**` component of pattern matching any number of
+// directories, e.g. /var/log/containers/**/*.log
+func glob(pattern string) ([]string, error) {
+	separator := string(filepath.Separator)
+	recursive := separator + "**" + separator
+	i := strings.Index(pattern, recursive)
+	if i < 0 {
+		return filepath.Glob(pattern)
+	}
+
+	roots, err := filepath.Glob(pattern[:i])
+	if err != nil {
+		return nil, err
+	}
+	rest := pattern[i+len(recursive):]
+	matches := make(map[string]bool)
+	for _, root := range roots {
+		err := filepath.Walk(root, func(path string, info os.FileInfo, err error) error {
+			if err != nil || !info.IsDir() {
+				// skip the unreadable directories, like filepath.Glob
+				return nil
+			}
+			paths, err := glob(filepath.Join(path, rest))
+			if err != nil {
+				return err
+			}
+			for _, path := range paths {
+				matches[path] = true
+			}
+			return nil
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	paths := make([]string, 0, len(matches))
+	for path := range matches {
+		if info, err := os.Stat(path); err == nil && !info.IsDir() {
+			paths = append(paths, path)
+		}
+	}
+	sort.Strings(paths)
+	return paths, nil
+}
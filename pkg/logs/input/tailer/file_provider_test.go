@@ -11,8 +11,11 @@ import (
 	"fmt"
 	"io/ioutil"
 	"os"
+	"path/filepath"
 	"testing"
 
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 	"github.com/stretchr/testify/suite"
 
 	"github.com/DataDog/datadog-agent/pkg/logs/config"
@@ -119,6 +122,60 @@ func (suite *FileProviderTestSuite) TestNumberOfFilesToTailDoesNotExceedLimit()
 	suite.Equal(suite.filesLimit, len(files))
 }
 
+func (suite *FileProviderTestSuite) TestFilesToTailReturnsAllFilesFromSubdirectories() {
+	path := fmt.Sprintf("%s/**/2.log", suite.testDir)
+	fileProvider := suite.newFileProvider(path)
+	files := fileProvider.FilesToTail()
+
+	suite.Equal(2, len(files))
+	suite.Equal(fmt.Sprintf("%s/1/2.log", suite.testDir), files[0].Path)
+	suite.Equal(fmt.Sprintf("%s/2/2.log", suite.testDir), files[1].Path)
+}
+
+func (suite *FileProviderTestSuite) TestNumberOfFilesToTailDoesNotExceedSourceLimit() {
+	sources := []*config.LogSource{
+		config.NewLogSource("", &config.LogsConfig{Type: config.FileType, Path: fmt.Sprintf("%s/1/*.log", suite.testDir), OpenFilesLimit: 1}),
+		config.NewLogSource("", &config.LogsConfig{Type: config.FileType, Path: fmt.Sprintf("%s/2/*.log", suite.testDir)}),
+	}
+	fileProvider := NewFileProvider(sources, suite.filesLimit)
+	files := fileProvider.FilesToTail()
+
+	suite.Equal(3, len(files))
+	suite.Equal(fmt.Sprintf("%s/1/1.log", suite.testDir), files[0].Path)
+	suite.Equal(fmt.Sprintf("%s/2/1.log", suite.testDir), files[1].Path)
+	suite.Equal(fmt.Sprintf("%s/2/2.log", suite.testDir), files[2].Path)
+}
+
+func TestGlobRecursive(t *testing.T) {
+	testDir, err := ioutil.TempDir("", "log-file_provider-test-")
+	require.Nil(t, err)
+	defer os.RemoveAll(testDir)
+
+	for _, dir := range []string{"a/b/c", "a/d"} {
+		require.Nil(t, os.MkdirAll(filepath.Join(testDir, dir), os.ModePerm))
+	}
+	for _, file := range []string{"a/0.log", "a/b/1.log", "a/b/c/2.log", "a/b/c/2.txt", "a/d/3.log"} {
+		_, err := os.Create(filepath.Join(testDir, file))
+		require.Nil(t, err)
+	}
+
+	paths, err := glob(filepath.Join(testDir, "a", "**", "*.log"))
+	require.Nil(t, err)
+	assert.Equal(t, []string{
+		filepath.Join(testDir, "a/0.log"),
+		filepath.Join(testDir, "a/b/1.log"),
+		filepath.Join(testDir, "a/b/c/2.log"),
+		filepath.Join(testDir, "a/d/3.log"),
+	}, paths)
+
+	paths, err = glob(filepath.Join(testDir, "*", "**", "c", "*"))
+	require.Nil(t, err)
+	assert.Equal(t, []string{
+		filepath.Join(testDir, "a/b/c/2.log"),
+		filepath.Join(testDir, "a/b/c/2.txt"),
+	}, paths)
+}
+
 func TestFileProviderTestSuite(t *testing.T) {
 	suite.Run(t, new(FileProviderTestSuite))
 }
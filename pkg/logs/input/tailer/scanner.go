@@ -18,8 +18,8 @@ import (
 	"github.com/DataDog/datadog-agent/pkg/logs/restart"
 )
 
-// scanPeriod represents the period of scanning
-const scanPeriod = 10 * time.Second
+// DefaultScanPeriod represents the default period of scanning
+const DefaultScanPeriod = 10 * time.Second
 
 // Scanner checks all files provided by fileProvider and create new tailers
 // or update the old ones if needed
@@ -30,16 +30,23 @@ type Scanner struct {
 	tailers             map[string]*Tailer
 	auditor             *auditor.Auditor
 	tailerSleepDuration time.Duration
+	scanPeriod          time.Duration
 	stop                chan struct{}
 }
 
-// New returns an initialized Scanner
-func New(sources []*config.LogSource, tailingLimit int, pp pipeline.Provider, auditor *auditor.Auditor, tailerSleepDuration time.Duration) *Scanner {
+// New returns an initialized Scanner, looking for new files matching the
+// paths of the sources every scanPeriod
+func New(sources []*config.LogSource, tailingLimit int, pp pipeline.Provider, auditor *auditor.Auditor, tailerSleepDuration time.Duration, scanPeriod time.Duration) *Scanner {
 	tailSources := []*config.LogSource{}
 	for _, source := range sources {
 		switch source.Config.Type {
 		case config.FileType:
 			tailSources = append(tailSources, source)
+		case config.KubernetesAuditType:
+			// audit logs written by the apiserver log backend
+			if source.Config.Path != "" {
+				tailSources = append(tailSources, source)
+			}
 		default:
 		}
 	}
@@ -50,6 +57,7 @@ func New(sources []*config.LogSource, tailingLimit int, pp pipeline.Provider, au
 		tailers:             make(map[string]*Tailer),
 		auditor:             auditor,
 		tailerSleepDuration: tailerSleepDuration,
+		scanPeriod:          scanPeriod,
 		stop:                make(chan struct{}),
 	}
 }
@@ -83,14 +91,14 @@ func (s *Scanner) cleanup() {
 }
 
 // createTailer returns a new initialized tailer
-func (s *Scanner) createTailer(file *File, outputChan chan message.Message) *Tailer {
-	return NewTailer(outputChan, file.Source, file.Path, s.tailerSleepDuration)
+func (s *Scanner) createTailer(file *File, output *message.Ring) *Tailer {
+	return NewTailer(output, file.Source, file.Path, s.tailerSleepDuration)
 }
 
 // startNewTailer creates a new tailer, making it tail from the last committed offset, the beginning or the end of the file,
 // returns true if the operation succeeded, false otherwise
 func (s *Scanner) startNewTailer(file *File, tailFromBeginning bool) bool {
-	tailer := s.createTailer(file, s.pp.NextPipelineChan())
+	tailer := s.createTailer(file, s.pp.NextPipelineInput())
 	offset := s.auditor.GetLastCommittedOffset(tailer.Identifier())
 	value, err := strconv.ParseInt(offset, 10, 64)
 	if err != nil {
@@ -111,6 +119,17 @@ func (s *Scanner) startNewTailer(file *File, tailFromBeginning bool) bool {
 	return true
 }
 
+// AddSource starts tailing the files of a source from the next scan, the
+// files are tailed from their beginning when no offset has been recorded
+func (s *Scanner) AddSource(source *config.LogSource) {
+	s.fileProvider.AddSource(source)
+}
+
+// RemoveSource stops tailing the files of a source from the next scan
+func (s *Scanner) RemoveSource(source *config.LogSource) {
+	s.fileProvider.RemoveSource(source)
+}
+
 // Start starts the Scanner
 func (s *Scanner) Start() {
 	s.setup()
@@ -126,7 +145,7 @@ func (s *Scanner) Stop() {
 
 // run checks periodically if there are new files to tail and the state of its tailers until stop
 func (s *Scanner) run() {
-	scanTicker := time.NewTicker(scanPeriod)
+	scanTicker := time.NewTicker(s.scanPeriod)
 	defer scanTicker.Stop()
 	for {
 		select {
@@ -187,6 +206,8 @@ func (s *Scanner) scan() {
 				// the setup failed, let's try to tail this file in the next scan
 				continue
 			}
+		} else {
+			tailer.updateLag()
 		}
 
 		filesTailed[file.Path] = true
@@ -212,7 +233,7 @@ func (s *Scanner) didFileRotate(file *File, tailer *Tailer) (bool, error) {
 func (s *Scanner) restartTailerAfterFileRotation(tailer *Tailer, file *File) bool {
 	log.Info("Log rotation happened to ", tailer.path)
 	tailer.StopAfterFileRotation()
-	tailer = s.createTailer(file, tailer.outputChan)
+	tailer = s.createTailer(file, tailer.output)
 	// force reading file from beginning since it has been log-rotated
 	err := tailer.tailFromBeginning()
 	if err != nil {
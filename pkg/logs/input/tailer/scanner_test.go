@@ -32,7 +32,7 @@ type ScannerTestSuite struct {
 	testRotatedPath string
 	testRotatedFile *os.File
 
-	outputChan     chan message.Message
+	output         *message.Ring
 	pp             pipeline.Provider
 	sources        []*config.LogSource
 	openFilesLimit int
@@ -41,7 +41,7 @@ type ScannerTestSuite struct {
 
 func (suite *ScannerTestSuite) SetupTest() {
 	suite.pp = mock.NewMockProvider()
-	suite.outputChan = suite.pp.NextPipelineChan()
+	suite.output = suite.pp.NextPipelineInput()
 
 	var err error
 	suite.testDir, err = ioutil.TempDir("", "log-scanner-test-")
@@ -60,7 +60,7 @@ func (suite *ScannerTestSuite) SetupTest() {
 	suite.openFilesLimit = 100
 	suite.sources = []*config.LogSource{config.NewLogSource("", &config.LogsConfig{Type: config.FileType, Path: suite.testPath})}
 	sleepDuration := 20 * time.Millisecond
-	suite.s = New(suite.sources, suite.openFilesLimit, suite.pp, auditor.New(nil, ""), sleepDuration)
+	suite.s = New(suite.sources, suite.openFilesLimit, suite.pp, auditor.New(nil, ""), sleepDuration, DefaultScanPeriod)
 	suite.s.setup()
 }
 
@@ -74,7 +74,7 @@ func (suite *ScannerTestSuite) TearDownTest() {
 func (suite *ScannerTestSuite) TestScannerStartsTailers() {
 	_, err := suite.testFile.WriteString("hello world\n")
 	suite.Nil(err)
-	msg := <-suite.outputChan
+	msg := suite.output.Pop()
 	suite.Equal("hello world", string(msg.Content()))
 }
 
@@ -90,7 +90,7 @@ func (suite *ScannerTestSuite) TestScannerScanWithoutLogRotation() {
 	tailer = s.tailers[sources[0].Config.Path]
 	_, err = suite.testFile.WriteString("hello world\n")
 	suite.Nil(err)
-	msg = <-suite.outputChan
+	msg = suite.output.Pop()
 	suite.Equal("hello world", string(msg.Content()))
 
 	s.scan()
@@ -100,7 +100,7 @@ func (suite *ScannerTestSuite) TestScannerScanWithoutLogRotation() {
 
 	_, err = suite.testFile.WriteString("hello again\n")
 	suite.Nil(err)
-	msg = <-suite.outputChan
+	msg = suite.output.Pop()
 	suite.Equal("hello again", string(msg.Content()))
 }
 
@@ -115,7 +115,7 @@ func (suite *ScannerTestSuite) TestScannerScanWithLogRotation() {
 
 	_, err = suite.testFile.WriteString("hello world\n")
 	suite.Nil(err)
-	msg = <-suite.outputChan
+	msg = suite.output.Pop()
 	suite.Equal("hello world", string(msg.Content()))
 
 	tailer = s.tailers[sources[0].Config.Path]
@@ -128,7 +128,7 @@ func (suite *ScannerTestSuite) TestScannerScanWithLogRotation() {
 
 	_, err = f.WriteString("hello again\n")
 	suite.Nil(err)
-	msg = <-suite.outputChan
+	msg = suite.output.Pop()
 	suite.Equal("hello again", string(msg.Content()))
 }
 
@@ -144,7 +144,7 @@ func (suite *ScannerTestSuite) TestScannerScanWithLogRotationCopyTruncate() {
 	tailer = s.tailers[sources[0].Config.Path]
 	_, err = suite.testFile.WriteString("hello world\n")
 	suite.Nil(err)
-	msg = <-suite.outputChan
+	msg = suite.output.Pop()
 	suite.Equal("hello world", string(msg.Content()))
 
 	suite.testFile.Truncate(0)
@@ -156,7 +156,7 @@ func (suite *ScannerTestSuite) TestScannerScanWithLogRotationCopyTruncate() {
 	newTailer = s.tailers[sources[0].Config.Path]
 	suite.True(tailer != newTailer)
 
-	msg = <-suite.outputChan
+	msg = suite.output.Pop()
 	suite.Equal("third", string(msg.Content()))
 }
 
@@ -208,7 +208,7 @@ func TestScannerScanStartNewTailer(t *testing.T) {
 	sources := []*config.LogSource{config.NewLogSource("", &config.LogsConfig{Type: config.FileType, Path: path})}
 	openFilesLimit := 2
 	sleepDuration := 20 * time.Millisecond
-	scanner := New(sources, openFilesLimit, mock.NewMockProvider(), auditor.New(nil, ""), sleepDuration)
+	scanner := New(sources, openFilesLimit, mock.NewMockProvider(), auditor.New(nil, ""), sleepDuration, DefaultScanPeriod)
 
 	// setup scanner
 	scanner.setup()
@@ -229,9 +229,9 @@ func TestScannerScanStartNewTailer(t *testing.T) {
 	scanner.scan()
 	assert.Equal(t, 1, len(scanner.tailers))
 	tailer = scanner.tailers[path]
-	msg = <-tailer.outputChan
+	msg = tailer.output.Pop()
 	assert.Equal(t, "hello", string(msg.Content()))
-	msg = <-tailer.outputChan
+	msg = tailer.output.Pop()
 	assert.Equal(t, "world", string(msg.Content()))
 }
 
@@ -260,7 +260,7 @@ func TestScannerScanWithTooManyFiles(t *testing.T) {
 	sources := []*config.LogSource{config.NewLogSource("", &config.LogsConfig{Type: config.FileType, Path: path})}
 	openFilesLimit := 2
 	sleepDuration := 20 * time.Millisecond
-	scanner := New(sources, openFilesLimit, mock.NewMockProvider(), auditor.New(nil, ""), sleepDuration)
+	scanner := New(sources, openFilesLimit, mock.NewMockProvider(), auditor.New(nil, ""), sleepDuration, DefaultScanPeriod)
 
 	// test at setup
 	scanner.setup()
@@ -280,3 +280,33 @@ func TestScannerScanWithTooManyFiles(t *testing.T) {
 	scanner.scan()
 	assert.Equal(t, 2, len(scanner.tailers))
 }
+
+func TestScannerAddAndRemoveSource(t *testing.T) {
+	testDir, err := ioutil.TempDir("", "log-scanner-test-")
+	assert.Nil(t, err)
+	defer os.RemoveAll(testDir)
+
+	path := fmt.Sprintf("%s/0.log", testDir)
+	file, err := os.Create(path)
+	assert.Nil(t, err)
+	defer file.Close()
+	_, err = file.WriteString("hello\n")
+	assert.Nil(t, err)
+
+	sleepDuration := 20 * time.Millisecond
+	scanner := New(nil, 2, mock.NewMockProvider(), auditor.New(nil, ""), sleepDuration, DefaultScanPeriod)
+	scanner.setup()
+	assert.Equal(t, 0, len(scanner.tailers))
+
+	// the files of the added source are tailed from their beginning
+	source := config.NewLogSource("", &config.LogsConfig{Type: config.FileType, Path: path})
+	scanner.AddSource(source)
+	scanner.scan()
+	assert.Equal(t, 1, len(scanner.tailers))
+	msg := scanner.tailers[path].output.Pop()
+	assert.Equal(t, "hello", string(msg.Content()))
+
+	scanner.RemoveSource(source)
+	scanner.scan()
+	assert.Equal(t, 0, len(scanner.tailers))
+}
@@ -8,6 +8,7 @@ package tailer
 import (
 	"fmt"
 	"os"
+	"path/filepath"
 	"strconv"
 	"sync/atomic"
 	"time"
@@ -17,8 +18,11 @@ import (
 	"io"
 
 	"github.com/DataDog/datadog-agent/pkg/logs/config"
+	"github.com/DataDog/datadog-agent/pkg/logs/cri"
 	"github.com/DataDog/datadog-agent/pkg/logs/decoder"
+	"github.com/DataDog/datadog-agent/pkg/logs/input/kubeaudit"
 	"github.com/DataDog/datadog-agent/pkg/logs/message"
+	"github.com/DataDog/datadog-agent/pkg/logs/metrics"
 )
 
 // DefaultSleepDuration represents the amount of time the tailer waits before reading new data when no data is received
@@ -26,6 +30,11 @@ const DefaultSleepDuration = 1 * time.Second
 
 const defaultCloseTimeout = 60 * time.Second
 
+// maxPartialContentLen is the length above which the partial lines of the CRI
+// log files are sent without waiting for the end of the log, like the decoder
+// truncates the lines
+const maxPartialContentLen = 256 * 1000
+
 // Tailer tails one file and sends messages to an output channel
 type Tailer struct {
 	path     string
@@ -35,10 +44,17 @@ type Tailer struct {
 
 	readOffset    int64
 	decodedOffset int64
+	// caughtUpAt is the time, in nanoseconds, the tailer last read its
+	// file up to its end
+	caughtUpAt int64
+
+	output  *message.Ring
+	decoder *decoder.Decoder
+	source  *config.LogSource
 
-	outputChan chan message.Message
-	decoder    *decoder.Decoder
-	source     *config.LogSource
+	// partialContent holds the partial lines of a CRI log file until the
+	// full line ending the log
+	partialContent []byte
 
 	sleepDuration time.Duration
 
@@ -50,10 +66,10 @@ type Tailer struct {
 }
 
 // NewTailer returns an initialized Tailer
-func NewTailer(outputChan chan message.Message, source *config.LogSource, path string, sleepDuration time.Duration) *Tailer {
+func NewTailer(output *message.Ring, source *config.LogSource, path string, sleepDuration time.Duration) *Tailer {
 	return &Tailer{
 		path:          path,
-		outputChan:    outputChan,
+		output:        output,
 		decoder:       decoder.InitializeDecoder(source),
 		source:        source,
 		readOffset:    0,
@@ -69,6 +85,16 @@ func (t *Tailer) Identifier() string {
 	return fmt.Sprintf("file:%s", t.path)
 }
 
+// buildTags returns the tags of the messages of the file: its name and, when
+// it matched a pattern, its path, to tell apart the files of the same name
+func (t *Tailer) buildTags() []string {
+	tags := []string{fmt.Sprintf("filename:%s", filepath.Base(t.path))}
+	if containsWildcard(t.source.Config.Path) {
+		tags = append(tags, fmt.Sprintf("filepath:%s", t.path))
+	}
+	return tags
+}
+
 // tailFromBeginning lets the tailer start tailing its file
 // from the beginning
 func (t *Tailer) tailFromBeginning() error {
@@ -115,6 +141,11 @@ func (t *Tailer) onStop() {
 	log.Info("Closing ", t.path)
 	t.file.Close()
 	t.decoder.Stop()
+	if !t.didFileRotate {
+		// the lag of the file is tracked by the tailer of its new version
+		// otherwise
+		metrics.RemoveLag(t.path)
+	}
 }
 
 // tailFrom let's the tailer open a file and tail from whence
@@ -126,6 +157,7 @@ func (t *Tailer) tailFrom(offset int64, whence int) error {
 	}
 	t.source.Status.Success()
 	t.source.AddInput(t.path)
+	t.markCaughtUp()
 
 	go t.forwardMessages()
 	t.decoder.Start()
@@ -153,8 +185,50 @@ func (t *Tailer) forwardMessages() {
 		origin.Identifier = identifier
 		origin.Offset = strconv.FormatInt(offset, 10)
 		origin.SetTags(t.tags)
-		t.outputChan <- message.New(output.Content, origin, nil)
+		content, severity := output.Content, []byte(nil)
+		switch {
+		case t.source.Config.Type == config.KubernetesAuditType:
+			content, severity = t.formatAuditEvent(content)
+		case t.source.Config.Format == config.CRIFormat:
+			var partial bool
+			content, severity, partial = t.formatCRIMessage(content)
+			if partial {
+				continue
+			}
+		}
+		t.output.Push(message.New(content, origin, severity))
+	}
+}
+
+// formatAuditEvent turns a line of a Kubernetes audit log into a
+// structured log, the line is sent as is when it can't be parsed
+func (t *Tailer) formatAuditEvent(content []byte) ([]byte, []byte) {
+	formatted, severity, err := kubeaudit.Format(content)
+	if err != nil {
+		log.Debugf("Couldn't parse Kubernetes audit event from %s: %s", t.path, err)
+		return content, nil
+	}
+	return formatted, severity
+}
+
+// formatCRIMessage returns the log of a line of a CRI log file along with
+// its severity, or true when the line is a partial one to join with the
+// next ones, the line is sent as is when it can't be parsed
+func (t *Tailer) formatCRIMessage(content []byte) ([]byte, []byte, bool) {
+	msg, err := cri.ParseMessage(content)
+	if err != nil {
+		log.Debugf("Couldn't parse CRI log line from %s: %s", t.path, err)
+		return content, nil, false
 	}
+	if len(t.partialContent) > 0 {
+		msg.Content = append(t.partialContent, msg.Content...)
+		t.partialContent = nil
+	}
+	if msg.Partial && len(msg.Content) < maxPartialContentLen {
+		t.partialContent = msg.Content
+		return nil, nil, true
+	}
+	return msg.Content, msg.Severity, false
 }
 
 func (t *Tailer) incrementReadOffset(n int) {
@@ -184,6 +258,28 @@ func (t *Tailer) SetDecodedOffset(off int64) {
 	atomic.StoreInt64(&t.decodedOffset, off)
 }
 
+// markCaughtUp records that the tailer read its file up to its end
+func (t *Tailer) markCaughtUp() {
+	atomic.StoreInt64(&t.caughtUpAt, time.Now().UnixNano())
+}
+
+// updateLag records how far behind the end of its file the tailer is, the
+// bytes not read yet and the time since it last read all of them
+func (t *Tailer) updateLag() {
+	info, err := os.Stat(t.path)
+	if err != nil {
+		return
+	}
+	bytesBehind := info.Size() - t.GetReadOffset()
+	var timeBehind time.Duration
+	if bytesBehind > 0 {
+		timeBehind = time.Since(time.Unix(0, atomic.LoadInt64(&t.caughtUpAt)))
+	} else {
+		bytesBehind = 0
+	}
+	metrics.SetLag(t.path, bytesBehind, timeBehind)
+}
+
 // shouldTrackOffset returns whether the tailer should track the file offset or not
 func (t *Tailer) shouldTrackOffset() bool {
 	if t.didFileRotate {
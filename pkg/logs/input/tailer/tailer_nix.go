@@ -15,8 +15,6 @@ import (
 
 	log "github.com/cihub/seelog"
 
-	"fmt"
-
 	"github.com/DataDog/datadog-agent/pkg/logs/decoder"
 )
 
@@ -26,7 +24,7 @@ func (t *Tailer) setup(offset int64, whence int) error {
 	if err != nil {
 		return err
 	}
-	t.tags = []string{fmt.Sprintf("filename:%s", filepath.Base(t.path))}
+	t.tags = t.buildTags()
 	log.Info("Opening ", t.path)
 	f, err := os.Open(fullpath)
 	if err != nil {
@@ -62,6 +60,7 @@ func (t *Tailer) readForever() {
 			}
 			if n == 0 {
 				// wait for new data to come
+				t.markCaughtUp()
 				t.wait()
 				continue
 			}
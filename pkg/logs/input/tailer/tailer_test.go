@@ -12,15 +12,19 @@ import (
 	"io/ioutil"
 	"os"
 	"strconv"
+	"sync/atomic"
 	"testing"
 	"time"
 
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 	"github.com/stretchr/testify/suite"
 
 	"path/filepath"
 
 	"github.com/DataDog/datadog-agent/pkg/logs/config"
 	"github.com/DataDog/datadog-agent/pkg/logs/message"
+	"github.com/DataDog/datadog-agent/pkg/logs/metrics"
 )
 
 var chanSize = 10
@@ -31,9 +35,9 @@ type TailerTestSuite struct {
 	testPath string
 	testFile *os.File
 
-	tl         *Tailer
-	outputChan chan message.Message
-	source     *config.LogSource
+	tl     *Tailer
+	output *message.Ring
+	source *config.LogSource
 }
 
 func (suite *TailerTestSuite) SetupTest() {
@@ -45,13 +49,13 @@ func (suite *TailerTestSuite) SetupTest() {
 	f, err := os.Create(suite.testPath)
 	suite.Nil(err)
 	suite.testFile = f
-	suite.outputChan = make(chan message.Message, chanSize)
+	suite.output = message.NewRing(chanSize)
 	suite.source = config.NewLogSource("", &config.LogsConfig{
 		Type: config.FileType,
 		Path: suite.testPath,
 	})
 	sleepDuration := 10 * time.Millisecond
-	suite.tl = NewTailer(suite.outputChan, suite.source, suite.testPath, sleepDuration)
+	suite.tl = NewTailer(suite.output, suite.source, suite.testPath, sleepDuration)
 }
 
 func (suite *TailerTestSuite) TearDownTest() {
@@ -78,15 +82,15 @@ func (suite *TailerTestSuite) TestTailFromBeginning() {
 	_, err = suite.testFile.WriteString(lines[2])
 	suite.Nil(err)
 
-	msg = <-suite.outputChan
+	msg = suite.output.Pop()
 	suite.Equal("hello world", string(msg.Content()))
 	suite.Equal(len(lines[0]), toInt(msg.GetOrigin().Offset))
 
-	msg = <-suite.outputChan
+	msg = suite.output.Pop()
 	suite.Equal("hello again", string(msg.Content()))
 	suite.Equal(len(lines[0])+len(lines[1]), toInt(msg.GetOrigin().Offset))
 
-	msg = <-suite.outputChan
+	msg = suite.output.Pop()
 	suite.Equal("good bye", string(msg.Content()))
 	suite.Equal(len(lines[0])+len(lines[1])+len(lines[2]), toInt(msg.GetOrigin().Offset))
 
@@ -111,11 +115,11 @@ func (suite *TailerTestSuite) TestTailFromEnd() {
 	_, err = suite.testFile.WriteString(lines[2])
 	suite.Nil(err)
 
-	msg = <-suite.outputChan
+	msg = suite.output.Pop()
 	suite.Equal("hello again", string(msg.Content()))
 	suite.Equal(len(lines[0])+len(lines[1]), toInt(msg.GetOrigin().Offset))
 
-	msg = <-suite.outputChan
+	msg = suite.output.Pop()
 	suite.Equal("good bye", string(msg.Content()))
 	suite.Equal(len(lines[0])+len(lines[1])+len(lines[2]), toInt(msg.GetOrigin().Offset))
 
@@ -142,11 +146,11 @@ func (suite *TailerTestSuite) TestRecoverTailing() {
 	_, err = suite.testFile.WriteString(lines[2])
 	suite.Nil(err)
 
-	msg = <-suite.outputChan
+	msg = suite.output.Pop()
 	suite.Equal("hello again", string(msg.Content()))
 	suite.Equal(len(lines[0])+len(lines[1]), toInt(msg.GetOrigin().Offset))
 
-	msg = <-suite.outputChan
+	msg = suite.output.Pop()
 	suite.Equal("good bye", string(msg.Content()))
 	suite.Equal(len(lines[0])+len(lines[1])+len(lines[2]), toInt(msg.GetOrigin().Offset))
 
@@ -165,13 +169,35 @@ func (suite *TailerTestSuite) TestOriginTagsWhenTailingFiles() {
 	_, err := suite.testFile.WriteString("foo\n")
 	suite.Nil(err)
 
-	msg := <-suite.outputChan
+	msg := suite.output.Pop()
 	tags := msg.GetOrigin().Tags()
 	suite.Equal(1, len(tags))
 	suite.Equal("filename:"+filepath.Base(suite.testFile.Name()), tags[0])
 
 }
 
+func (suite *TailerTestSuite) TestTailCRILogFile() {
+	suite.source.Config.Format = config.CRIFormat
+	suite.tl.tailFromBeginning()
+
+	_, err := suite.testFile.WriteString("2018-10-16T12:00:00.000000000Z stdout F hello\n" +
+		"2018-10-16T12:00:01.000000000Z stderr P a long err\n" +
+		"2018-10-16T12:00:01.000000000Z stderr F or message\n" +
+		"not a CRI line\n")
+	suite.Nil(err)
+
+	msg := suite.output.Pop()
+	suite.Equal("hello", string(msg.Content()))
+	suite.Equal(config.SevInfo, msg.GetSeverity())
+
+	msg = suite.output.Pop()
+	suite.Equal("a long error message", string(msg.Content()))
+	suite.Equal(config.SevError, msg.GetSeverity())
+
+	msg = suite.output.Pop()
+	suite.Equal("not a CRI line", string(msg.Content()))
+}
+
 func TestTailerTestSuite(t *testing.T) {
 	suite.Run(t, new(TailerTestSuite))
 }
@@ -182,3 +208,28 @@ func toInt(str string) int {
 	}
 	return 0
 }
+
+func TestUpdateLag(t *testing.T) {
+	testDir, err := ioutil.TempDir("", "log-tailer-test-")
+	require.Nil(t, err)
+	defer os.RemoveAll(testDir)
+	path := filepath.Join(testDir, "tailer.log")
+	require.Nil(t, ioutil.WriteFile(path, []byte("hello world\nhello again\n"), 0644))
+	defer metrics.RemoveLag(path)
+
+	source := config.NewLogSource("", &config.LogsConfig{Type: config.FileType, Path: path})
+	tailer := NewTailer(message.NewRing(chanSize), source, path, 10*time.Millisecond)
+	tailer.SetReadOffset(12)
+	atomic.StoreInt64(&tailer.caughtUpAt, time.Now().Add(-5*time.Second).UnixNano())
+	tailer.updateLag()
+	lag := metrics.GetLag(path)
+	require.NotNil(t, lag)
+	assert.Equal(t, int64(12), lag.BytesBehind)
+	assert.Equal(t, int64(5), lag.SecondsBehind)
+
+	// the tailer caught up
+	tailer.SetReadOffset(24)
+	tailer.updateLag()
+	assert.Equal(t, int64(0), metrics.GetLag(path).BytesBehind)
+	assert.Equal(t, int64(0), metrics.GetLag(path).SecondsBehind)
+}
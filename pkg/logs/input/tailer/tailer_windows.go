@@ -8,7 +8,6 @@
 package tailer
 
 import (
-	"fmt"
 	"io"
 	"os"
 	"path/filepath"
@@ -24,7 +23,7 @@ func (t *Tailer) setup(offset int64, whence int) error {
 	if err != nil {
 		return err
 	}
-	t.tags = []string{fmt.Sprintf("filename:%s", filepath.Base(t.path))}
+	t.tags = t.buildTags()
 	t.fullpath = path
 	t.readOffset = offset
 	t.decodedOffset = offset
@@ -64,6 +63,9 @@ func (t *Tailer) readAvailable() (err error) {
 		n, err := f.Read(inBuf)
 		if n == 0 || err != nil {
 			log.Debugf("Done reading")
+			if err == io.EOF {
+				t.markCaughtUp()
+			}
 			return err
 		}
 		log.Debugf("Sending %d bytes to input channel", n)
@@ -0,0 +1,148 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2018 Datadog, Inc.
+
+package windowsevent
+
+import (
+	"bytes"
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/DataDog/datadog-agent/pkg/logs/config"
+)
+
+// The levels of the events
+const (
+	levelCritical    = 1
+	levelError       = 2
+	levelWarning     = 3
+	levelInformation = 4
+	levelVerbose     = 5
+)
+
+// event holds the attributes of an event rendered as XML (EvtRenderEventXml)
+type event struct {
+	System struct {
+		Provider struct {
+			Name string `xml:"Name,attr"`
+		} `xml:"Provider"`
+		EventID     int    `xml:"EventID"`
+		Level       int    `xml:"Level"`
+		Task        int    `xml:"Task"`
+		Opcode      int    `xml:"Opcode"`
+		Keywords    string `xml:"Keywords"`
+		TimeCreated struct {
+			SystemTime string `xml:"SystemTime,attr"`
+		} `xml:"TimeCreated"`
+		EventRecordID int64  `xml:"EventRecordID"`
+		Channel       string `xml:"Channel"`
+		Computer      string `xml:"Computer"`
+		Security      struct {
+			UserID string `xml:"UserID,attr"`
+		} `xml:"Security"`
+	} `xml:"System"`
+	EventData struct {
+		Data []struct {
+			Name  string `xml:"Name,attr"`
+			Value string `xml:",chardata"`
+		} `xml:"Data"`
+	} `xml:"EventData"`
+}
+
+// levelName returns the name of the level of the event
+func (e *event) levelName() string {
+	switch e.System.Level {
+	case levelCritical:
+		return "Critical"
+	case levelError:
+		return "Error"
+	case levelWarning:
+		return "Warning"
+	case levelInformation, 0:
+		return "Information"
+	case levelVerbose:
+		return "Verbose"
+	default:
+		return strconv.Itoa(e.System.Level)
+	}
+}
+
+// severity returns the severity of the event: the critical events and
+// the errors are reported as errors
+func (e *event) severity() []byte {
+	switch e.System.Level {
+	case levelCritical, levelError:
+		return config.SevError
+	default:
+		return config.SevInfo
+	}
+}
+
+// eventData returns the data of the event by name, the unnamed ones are
+// named after their position
+func (e *event) eventData() map[string]string {
+	data := make(map[string]string, len(e.EventData.Data))
+	for i, d := range e.EventData.Data {
+		name := d.Name
+		if name == "" {
+			name = fmt.Sprintf("data_%d", i)
+		}
+		data[name] = strings.TrimSpace(d.Value)
+	}
+	return data
+}
+
+// parseEvent parses an event rendered as XML
+func parseEvent(content []byte) (*event, error) {
+	var e event
+	if err := xml.Unmarshal(content, &e); err != nil {
+		return nil, err
+	}
+	if e.System.Provider.Name == "" {
+		return nil, fmt.Errorf("not a windows event")
+	}
+	return &e, nil
+}
+
+// format returns the event as a JSON log with its attributes (event_id,
+// provider, channel, level...) and its data. The message is the description
+// of the event rendered by its provider, a summary of the event when empty.
+func (e *event) format(description string) ([]byte, error) {
+	message := strings.TrimSpace(description)
+	if message == "" {
+		message = fmt.Sprintf("%s event %d in %s", e.System.Provider.Name, e.System.EventID, e.System.Channel)
+	}
+	attributes := map[string]interface{}{
+		"message":   message,
+		"event_id":  e.System.EventID,
+		"provider":  e.System.Provider.Name,
+		"channel":   e.System.Channel,
+		"level":     e.levelName(),
+		"task":      e.System.Task,
+		"opcode":    e.System.Opcode,
+		"keywords":  e.System.Keywords,
+		"record_id": e.System.EventRecordID,
+		"computer":  e.System.Computer,
+		"timestamp": e.System.TimeCreated.SystemTime,
+	}
+	if e.System.Security.UserID != "" {
+		attributes["user_id"] = e.System.Security.UserID
+	}
+	if data := e.eventData(); len(data) > 0 {
+		attributes["event_data"] = data
+	}
+	return json.Marshal(attributes)
+}
+
+// bookmark returns the XML of the bookmark of the record recordID of a
+// channel, the events after it are collected when resuming
+func bookmark(channel string, recordID string) string {
+	var escaped bytes.Buffer
+	xml.EscapeText(&escaped, []byte(channel))
+	return fmt.Sprintf("<BookmarkList><Bookmark Channel='%s' RecordId='%s' IsCurrent='true'/></BookmarkList>", escaped.String(), recordID)
+}
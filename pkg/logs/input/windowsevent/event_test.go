@@ -0,0 +1,56 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2018 Datadog, Inc.
+
+package windowsevent
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/DataDog/datadog-agent/pkg/logs/config"
+)
+
+const serviceCrash = `<Event xmlns='http://schemas.microsoft.com/win/2004/08/events/event'><System><Provider Name='Service Control Manager' Guid='{555908d1-a6d7-4695-8e1e-26931d2012f4}' EventSourceName='Service Control Manager'/><EventID Qualifiers='49152'>7034</EventID><Version>0</Version><Level>2</Level><Task>0</Task><Opcode>0</Opcode><Keywords>0x8080000000000000</Keywords><TimeCreated SystemTime='2018-03-02T10:15:42.123456700Z'/><EventRecordID>4242</EventRecordID><Correlation/><Execution ProcessID='620' ThreadID='4580'/><Channel>System</Channel><Computer>WIN-HOST</Computer><Security/></System><EventData><Data Name='param1'>Spooler</Data><Data Name='param2'>1</Data></EventData></Event>`
+
+func TestParseAndFormatEvent(t *testing.T) {
+	e, err := parseEvent([]byte(serviceCrash))
+	require.NoError(t, err)
+	assert.Equal(t, int64(4242), e.System.EventRecordID)
+	assert.Equal(t, config.SevError, e.severity())
+
+	content, err := e.format("The Spooler service terminated unexpectedly.\r\n")
+	require.NoError(t, err)
+	var attributes map[string]interface{}
+	require.NoError(t, json.Unmarshal(content, &attributes))
+	assert.Equal(t, "The Spooler service terminated unexpectedly.", attributes["message"])
+	assert.Equal(t, float64(7034), attributes["event_id"])
+	assert.Equal(t, "Service Control Manager", attributes["provider"])
+	assert.Equal(t, "System", attributes["channel"])
+	assert.Equal(t, "Error", attributes["level"])
+	assert.Equal(t, "WIN-HOST", attributes["computer"])
+	assert.Equal(t, "2018-03-02T10:15:42.123456700Z", attributes["timestamp"])
+	assert.Equal(t, map[string]interface{}{"param1": "Spooler", "param2": "1"}, attributes["event_data"])
+
+	// the events without description are summarized
+	content, err = e.format("")
+	require.NoError(t, err)
+	assert.Contains(t, string(content), `"message":"Service Control Manager event 7034 in System"`)
+}
+
+func TestParseInvalidEvent(t *testing.T) {
+	_, err := parseEvent([]byte("foo"))
+	assert.Error(t, err)
+
+	_, err = parseEvent([]byte("<Event><System></System></Event>"))
+	assert.Error(t, err)
+}
+
+func TestBookmark(t *testing.T) {
+	assert.Equal(t, "<BookmarkList><Bookmark Channel='Microsoft-Windows-Sysmon/Operational' RecordId='42' IsCurrent='true'/></BookmarkList>", bookmark("Microsoft-Windows-Sysmon/Operational", "42"))
+	assert.Equal(t, "<BookmarkList><Bookmark Channel='a&#39;b' RecordId='42' IsCurrent='true'/></BookmarkList>", bookmark("a'b", "42"))
+}
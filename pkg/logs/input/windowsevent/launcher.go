@@ -0,0 +1,58 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2018 Datadog, Inc.
+
+package windowsevent
+
+import (
+	log "github.com/cihub/seelog"
+
+	"github.com/DataDog/datadog-agent/pkg/logs/auditor"
+	"github.com/DataDog/datadog-agent/pkg/logs/config"
+	"github.com/DataDog/datadog-agent/pkg/logs/pipeline"
+	"github.com/DataDog/datadog-agent/pkg/logs/restart"
+)
+
+// Launcher starts a tailer for every Windows Event Log source, resuming
+// from the last event sent
+type Launcher struct {
+	pp      pipeline.Provider
+	sources []*config.LogSource
+	auditor *auditor.Auditor
+	tailers []restart.Stoppable
+}
+
+// New returns an initialized Launcher
+func New(sources []*config.LogSource, pp pipeline.Provider, auditor *auditor.Auditor) *Launcher {
+	return &Launcher{
+		pp:      pp,
+		sources: sources,
+		auditor: auditor,
+		tailers: []restart.Stoppable{},
+	}
+}
+
+// Start starts the tailers
+func (l *Launcher) Start() {
+	for _, source := range l.sources {
+		if source.Config.Type != config.WindowsEventType {
+			continue
+		}
+		tailer := NewTailer(source, l.pp.NextPipelineInput())
+		if err := tailer.Start(l.auditor.GetLastCommittedOffset(tailer.Identifier())); err != nil {
+			source.Status.Error(err)
+			log.Error("Can't start windows_event source: ", err)
+			continue
+		}
+		source.Status.Success()
+		l.tailers = append(l.tailers, tailer)
+	}
+}
+
+// Stop stops all the tailers
+func (l *Launcher) Stop() {
+	stopper := restart.NewParallelStopper(l.tailers...)
+	stopper.Stop()
+	l.tailers = l.tailers[:0]
+}
@@ -0,0 +1,77 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2018 Datadog, Inc.
+
+package windowsevent
+
+import (
+	"strconv"
+
+	log "github.com/cihub/seelog"
+
+	"github.com/DataDog/datadog-agent/pkg/logs/config"
+	"github.com/DataDog/datadog-agent/pkg/logs/message"
+)
+
+// defaultQuery selects all the events of a channel
+const defaultQuery = "*"
+
+// A Tailer collects the events of a channel of the Windows Event Log
+// matching the query of its source
+type Tailer struct {
+	source *config.LogSource
+	output *message.Ring
+	// the handles of the subscription, its signal and the metadata of the
+	// providers used to render the descriptions of the events
+	subscription uintptr
+	signal       uintptr
+	publishers   map[string]uintptr
+	stop         chan struct{}
+	done         chan struct{}
+}
+
+// NewTailer returns an initialized Tailer
+func NewTailer(source *config.LogSource, output *message.Ring) *Tailer {
+	return &Tailer{
+		source:     source,
+		output:     output,
+		publishers: make(map[string]uintptr),
+		stop:       make(chan struct{}),
+		done:       make(chan struct{}),
+	}
+}
+
+// Identifier returns a string that uniquely identifies a source
+func (t *Tailer) Identifier() string {
+	return "windows_event:" + t.source.Config.ChannelPath + ":" + t.query()
+}
+
+// Stop stops the Tailer
+func (t *Tailer) Stop() {
+	close(t.stop)
+	<-t.done
+}
+
+// query returns the XPath query selecting the events of the channel
+func (t *Tailer) query() string {
+	if t.source.Config.Query == "" {
+		return defaultQuery
+	}
+	return t.source.Config.Query
+}
+
+// handleEvent forwards an event, its offset is its record id so that the
+// events after it are collected on restart
+func (t *Tailer) handleEvent(e *event, description string) {
+	formatted, err := e.format(description)
+	if err != nil {
+		log.Warnf("Can't format an event of %s: %s", t.source.Config.ChannelPath, err)
+		return
+	}
+	origin := message.NewOrigin(t.source)
+	origin.Identifier = t.Identifier()
+	origin.Offset = strconv.FormatInt(e.System.EventRecordID, 10)
+	origin.SetTags([]string{"channel:" + e.System.Channel})
+	t.output.Push(message.New(formatted, origin, e.severity()))
+}
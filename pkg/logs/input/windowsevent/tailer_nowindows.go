@@ -0,0 +1,17 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2018 Datadog, Inc.
+
+// +build !windows
+
+package windowsevent
+
+import (
+	"fmt"
+)
+
+// Start returns an error, the Windows Event Log is only available on Windows
+func (t *Tailer) Start(offset string) error {
+	return fmt.Errorf("the %s sources are only supported on Windows", t.source.Config.Type)
+}
@@ -0,0 +1,231 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2018 Datadog, Inc.
+
+// +build windows
+
+package windowsevent
+
+import (
+	"unsafe"
+
+	log "github.com/cihub/seelog"
+	"golang.org/x/sys/windows"
+)
+
+var (
+	modwevtapi = windows.NewLazySystemDLL("wevtapi.dll")
+
+	procEvtSubscribe             = modwevtapi.NewProc("EvtSubscribe")
+	procEvtNext                  = modwevtapi.NewProc("EvtNext")
+	procEvtRender                = modwevtapi.NewProc("EvtRender")
+	procEvtClose                 = modwevtapi.NewProc("EvtClose")
+	procEvtCreateBookmark        = modwevtapi.NewProc("EvtCreateBookmark")
+	procEvtOpenPublisherMetadata = modwevtapi.NewProc("EvtOpenPublisherMetadata")
+	procEvtFormatMessage         = modwevtapi.NewProc("EvtFormatMessage")
+)
+
+// Flags of the wevtapi functions, from winevt.h
+const (
+	evtSubscribeToFutureEvents     = 1
+	evtSubscribeStartAfterBookmark = 3
+	evtRenderEventXML              = 1
+	evtFormatMessageEvent          = 1
+)
+
+const (
+	// eventBatchSize is the maximum number of events read at once
+	eventBatchSize = 10
+	// waitTimeout is the time waited for new events before checking
+	// whether the tailer is stopped, in milliseconds
+	waitTimeout = 500
+)
+
+// Start subscribes to the events of the channel matching the query, from
+// the one after the record id offset when set, from now on otherwise
+func (t *Tailer) Start(offset string) error {
+	signal, err := windows.CreateEvent(nil, 1, 1, nil)
+	if err != nil {
+		return err
+	}
+	channelPath, err := windows.UTF16PtrFromString(t.source.Config.ChannelPath)
+	if err != nil {
+		windows.CloseHandle(signal)
+		return err
+	}
+	query, err := windows.UTF16PtrFromString(t.query())
+	if err != nil {
+		windows.CloseHandle(signal)
+		return err
+	}
+
+	flags := uintptr(evtSubscribeToFutureEvents)
+	var bookmarkHandle uintptr
+	if offset != "" {
+		bookmarkHandle, err = createBookmark(t.source.Config.ChannelPath, offset)
+		if err != nil {
+			log.Warnf("Can't resume the events of %s from %s, collecting the new ones: %s", t.source.Config.ChannelPath, offset, err)
+		} else {
+			flags = evtSubscribeStartAfterBookmark
+			defer evtClose(bookmarkHandle)
+		}
+	}
+
+	subscription, _, err := procEvtSubscribe.Call(
+		0,
+		uintptr(signal),
+		uintptr(unsafe.Pointer(channelPath)),
+		uintptr(unsafe.Pointer(query)),
+		bookmarkHandle,
+		0,
+		0,
+		flags,
+	)
+	if subscription == 0 {
+		windows.CloseHandle(signal)
+		return err
+	}
+	t.subscription = subscription
+	t.signal = uintptr(signal)
+	go t.run()
+	return nil
+}
+
+// run reads the events every time the subscription signals new ones
+func (t *Tailer) run() {
+	defer func() {
+		evtClose(t.subscription)
+		windows.CloseHandle(windows.Handle(t.signal))
+		for _, publisher := range t.publishers {
+			evtClose(publisher)
+		}
+		t.done <- struct{}{}
+	}()
+	for {
+		select {
+		case <-t.stop:
+			return
+		default:
+		}
+		event, err := windows.WaitForSingleObject(windows.Handle(t.signal), waitTimeout)
+		if err != nil {
+			log.Warnf("Can't wait for the events of %s: %s", t.source.Config.ChannelPath, err)
+			return
+		}
+		if event != windows.WAIT_OBJECT_0 {
+			continue
+		}
+		// reset the signal before reading not to miss the events
+		// published meanwhile
+		windows.ResetEvent(windows.Handle(t.signal))
+		t.readEvents()
+	}
+}
+
+// readEvents forwards all the events available
+func (t *Tailer) readEvents() {
+	events := make([]uintptr, eventBatchSize)
+	for {
+		var returned uint32
+		ok, _, err := procEvtNext.Call(
+			t.subscription,
+			eventBatchSize,
+			uintptr(unsafe.Pointer(&events[0])),
+			0,
+			0,
+			uintptr(unsafe.Pointer(&returned)),
+		)
+		if ok == 0 {
+			if err != windows.ERROR_NO_MORE_ITEMS {
+				log.Warnf("Can't read the events of %s: %s", t.source.Config.ChannelPath, err)
+			}
+			return
+		}
+		for _, event := range events[:returned] {
+			t.readEvent(event)
+			evtClose(event)
+		}
+	}
+}
+
+// readEvent renders an event and forwards it
+func (t *Tailer) readEvent(event uintptr) {
+	content, err := render(event)
+	if err != nil {
+		log.Warnf("Can't render an event of %s: %s", t.source.Config.ChannelPath, err)
+		return
+	}
+	e, err := parseEvent([]byte(content))
+	if err != nil {
+		log.Warnf("Can't parse an event of %s: %s", t.source.Config.ChannelPath, err)
+		return
+	}
+	t.handleEvent(e, t.description(event, e.System.Provider.Name))
+}
+
+// description returns the description of an event rendered by its
+// provider, empty when the provider can't render it
+func (t *Tailer) description(event uintptr, provider string) string {
+	publisher, found := t.publishers[provider]
+	if !found {
+		name, err := windows.UTF16PtrFromString(provider)
+		if err != nil {
+			return ""
+		}
+		publisher, _, _ = procEvtOpenPublisherMetadata.Call(0, uintptr(unsafe.Pointer(name)), 0, 0, 0)
+		// the providers without metadata are not looked up again
+		t.publishers[provider] = publisher
+	}
+	if publisher == 0 {
+		return ""
+	}
+
+	var used uint32
+	procEvtFormatMessage.Call(publisher, event, 0, 0, 0, evtFormatMessageEvent, 0, 0, uintptr(unsafe.Pointer(&used)))
+	if used == 0 {
+		return ""
+	}
+	buffer := make([]uint16, used)
+	ok, _, _ := procEvtFormatMessage.Call(publisher, event, 0, 0, 0, evtFormatMessageEvent, uintptr(used), uintptr(unsafe.Pointer(&buffer[0])), uintptr(unsafe.Pointer(&used)))
+	if ok == 0 {
+		return ""
+	}
+	return windows.UTF16ToString(buffer)
+}
+
+// render returns an event rendered as XML
+func render(event uintptr) (string, error) {
+	var used, count uint32
+	ok, _, err := procEvtRender.Call(0, event, evtRenderEventXML, 0, 0, uintptr(unsafe.Pointer(&used)), uintptr(unsafe.Pointer(&count)))
+	if ok == 0 && err != windows.ERROR_INSUFFICIENT_BUFFER {
+		return "", err
+	}
+	// used is a size in bytes
+	buffer := make([]uint16, used/2+1)
+	ok, _, err = procEvtRender.Call(0, event, evtRenderEventXML, uintptr(len(buffer)*2), uintptr(unsafe.Pointer(&buffer[0])), uintptr(unsafe.Pointer(&used)), uintptr(unsafe.Pointer(&count)))
+	if ok == 0 {
+		return "", err
+	}
+	return windows.UTF16ToString(buffer), nil
+}
+
+// createBookmark returns the handle of the bookmark of a record of a channel
+func createBookmark(channel string, recordID string) (uintptr, error) {
+	xml, err := windows.UTF16PtrFromString(bookmark(channel, recordID))
+	if err != nil {
+		return 0, err
+	}
+	handle, _, err := procEvtCreateBookmark.Call(uintptr(unsafe.Pointer(xml)))
+	if handle == 0 {
+		return 0, err
+	}
+	return handle, nil
+}
+
+// evtClose closes a handle of the wevtapi
+func evtClose(handle uintptr) {
+	if handle != 0 {
+		procEvtClose.Call(handle)
+	}
+}
@@ -6,12 +6,18 @@
 package logs
 
 import (
+	"errors"
+
 	log "github.com/cihub/seelog"
 
 	"github.com/DataDog/datadog-agent/pkg/logs/config"
+	"github.com/DataDog/datadog-agent/pkg/logs/input/integration"
 	"github.com/DataDog/datadog-agent/pkg/logs/status"
 )
 
+// ErrNotRunning is returned for the logs sent while the logs-agent is not running
+var ErrNotRunning = errors.New("the logs-agent is not running")
+
 var (
 	// isRunning indicates whether logs-agent is running or not
 	isRunning bool
@@ -26,10 +32,22 @@ func Start() error {
 		// could not parse the configuration
 		return err
 	}
+	processingRules, err := config.GlobalProcessingRules()
+	if err != nil {
+		return err
+	}
+	scrubbers, err := config.ScrubbingReplacers()
+	if err != nil {
+		return err
+	}
+	additionalEndpoints, err := config.AdditionalEndpoints()
+	if err != nil {
+		return err
+	}
 	log.Info("Starting logs-agent")
 
 	// setup and start the agent
-	agent = NewAgent(sources)
+	agent = NewAgent(sources, processingRules, scrubbers, additionalEndpoints)
 	agent.Start()
 
 	// setup the status
@@ -49,6 +67,54 @@ func Stop() {
 	}
 }
 
+// Reload restarts the logs-agent with the sources of the current
+// configuration files, the tailers resume from the offsets saved by the
+// auditor. It does nothing if the logs-agent is not running.
+func Reload() error {
+	if !isRunning {
+		return nil
+	}
+	sources, err := config.Build()
+	if err != nil {
+		// keep the current sources when the new ones can't be parsed
+		return err
+	}
+	processingRules, err := config.GlobalProcessingRules()
+	if err != nil {
+		return err
+	}
+	scrubbers, err := config.ScrubbingReplacers()
+	if err != nil {
+		return err
+	}
+	additionalEndpoints, err := config.AdditionalEndpoints()
+	if err != nil {
+		return err
+	}
+	log.Info("Reloading logs-agent")
+
+	agent.Stop()
+	agent = NewAgent(sources, processingRules, scrubbers, additionalEndpoints)
+	agent.Start()
+	status.Initialize(sources.GetSources())
+
+	return nil
+}
+
+// SendIntegrationLog forwards a log line sent by an integration, along with
+// its attributes, to the pipelines. It returns ErrNotRunning if the logs-agent
+// is not running.
+func SendIntegrationLog(line string, attributes map[string]interface{}) error {
+	if !isRunning {
+		return ErrNotRunning
+	}
+	err := agent.integrationReceiver.Send(line, attributes)
+	if err == integration.ErrStopped {
+		return ErrNotRunning
+	}
+	return err
+}
+
 // GetStatus returns logs-agent status
 func GetStatus() status.Status {
 	if !isRunning {
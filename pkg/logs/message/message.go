@@ -5,27 +5,63 @@
 
 package message
 
+import (
+	"sync"
+	"time"
+)
+
 // Message represents a log line sent to datadog, with its metadata
 type Message interface {
 	Content() []byte
 	SetContent([]byte)
 	GetOrigin() *Origin
 	GetSeverity() []byte
+	GetAttributes() *Attributes
+}
+
+// Attributes are the reserved attributes of a message promoted from its
+// content, they override the ones of its source when set
+type Attributes struct {
+	Status    string
+	Timestamp time.Time
+	Service   string
+	// Hostname is the one of the agent a relayed log was sent by
+	Hostname string
 }
 
 type message struct {
-	content  []byte
-	origin   *Origin
-	severity []byte
+	content    []byte
+	origin     *Origin
+	severity   []byte
+	attributes Attributes
+}
+
+// messagePool recycles the messages released once sent, to lower the
+// allocations of the pipelines under load
+var messagePool = sync.Pool{
+	New: func() interface{} {
+		return &message{}
+	},
 }
 
 // New returns a new Message
 func New(content []byte, origin *Origin, severity []byte) Message {
-	return &message{
-		content:  content,
-		origin:   origin,
-		severity: severity,
+	m := messagePool.Get().(*message)
+	m.content = content
+	m.origin = origin
+	m.severity = severity
+	return m
+}
+
+// Release gives back a message which won't be used anymore, to be reused
+// by New. The message must not be referenced after this call.
+func Release(msg Message) {
+	m, ok := msg.(*message)
+	if !ok {
+		return
 	}
+	*m = message{}
+	messagePool.Put(m)
 }
 
 // Content returns the content the message, the actual log line
@@ -47,3 +83,9 @@ func (m *message) GetOrigin() *Origin {
 func (m *message) GetSeverity() []byte {
 	return m.severity
 }
+
+// GetAttributes returns the reserved attributes of the message, to be
+// updated in place
+func (m *message) GetAttributes() *Attributes {
+	return &m.attributes
+}
@@ -21,3 +21,11 @@ func TestMessage(t *testing.T) {
 	assert.Nil(t, message.GetSeverity())
 
 }
+
+func TestRelease(t *testing.T) {
+	msg := New([]byte("hello"), &Origin{Identifier: "file:/var/log/hello.log"}, []byte("<46>"))
+	Release(msg)
+	assert.Nil(t, msg.Content())
+	assert.Nil(t, msg.GetOrigin())
+	assert.Nil(t, msg.GetSeverity())
+}
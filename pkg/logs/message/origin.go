@@ -16,6 +16,7 @@ type Origin struct {
 	Identifier string
 	LogSource  *config.LogSource
 	Offset     string
+	source     string
 	tags       []string
 }
 
@@ -29,8 +30,8 @@ func NewOrigin(source *config.LogSource) *Origin {
 // Tags returns the tags of the origin.
 func (o *Origin) Tags() []string {
 	tags := o.tags
-	if o.LogSource.Config.Source != "" {
-		tags = append(tags, "source:"+o.LogSource.Config.Source)
+	if source := o.Source(); source != "" {
+		tags = append(tags, "source:"+source)
 	}
 	if o.LogSource.Config.SourceCategory != "" {
 		tags = append(tags, "sourcecategory:"+o.LogSource.Config.SourceCategory)
@@ -43,8 +44,8 @@ func (o *Origin) Tags() []string {
 // TagsPayload returns the raw tag payload of the origin.
 func (o *Origin) TagsPayload() []byte {
 	var tagsPayload []byte
-	if o.LogSource.Config.Source != "" {
-		tagsPayload = append(tagsPayload, []byte("[dd ddsource=\""+o.LogSource.Config.Source+"\"]")...)
+	if source := o.Source(); source != "" {
+		tagsPayload = append(tagsPayload, []byte("[dd ddsource=\""+source+"\"]")...)
 	}
 	if o.LogSource.Config.SourceCategory != "" {
 		tagsPayload = append(tagsPayload, []byte("[dd ddsourcecategory=\""+o.LogSource.Config.SourceCategory+"\"]")...)
@@ -67,3 +68,18 @@ func (o *Origin) TagsPayload() []byte {
 func (o *Origin) SetTags(tags []string) {
 	o.tags = tags
 }
+
+// Source returns the source of the logs of the origin, the one of its log
+// source unless it is overridden.
+func (o *Origin) Source() string {
+	if o.source != "" {
+		return o.source
+	}
+	return o.LogSource.Config.Source
+}
+
+// SetSource overrides the source of the log source, like for the logs
+// relayed from other agents.
+func (o *Origin) SetSource(source string) {
+	o.source = source
+}
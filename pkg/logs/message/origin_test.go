@@ -57,3 +57,17 @@ func TestSetTagsWithConfigTags(t *testing.T) {
 	assert.Equal(t, []string{"foo:bar", "baz", "source:a", "sourcecategory:b", "c:d", "e"}, origin.Tags())
 	assert.Equal(t, "[dd ddsource=\"a\"][dd ddsourcecategory=\"b\"][dd ddtags=\"c:d,e,foo:bar,baz\"]", string(origin.TagsPayload()))
 }
+
+func TestSetSource(t *testing.T) {
+	cfg := &config.LogsConfig{
+		Source: "a",
+		Tags:   []string{"c:d"},
+	}
+	source := config.NewLogSource("", cfg)
+	origin := NewOrigin(source)
+	origin.SetSource("nginx")
+	assert.Equal(t, "nginx", origin.Source())
+	assert.Equal(t, []string{"source:nginx", "c:d"}, origin.Tags())
+	assert.Equal(t, "[dd ddsource=\"nginx\"][dd ddtags=\"c:d\"]", string(origin.TagsPayload()))
+	assert.Equal(t, "a", NewOrigin(source).Source())
+}
@@ -0,0 +1,188 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2018 Datadog, Inc.
+
+package message
+
+import (
+	"bytes"
+	"expvar"
+	"sync"
+	"time"
+
+	"github.com/DataDog/datadog-agent/pkg/logs/config"
+	"github.com/DataDog/datadog-agent/pkg/logs/metrics"
+	"github.com/DataDog/datadog-agent/pkg/util/membudget"
+)
+
+var (
+	ringExpvars = expvar.NewMap("logs-ring")
+	// rateLimitedExpvars counts the messages dropped by the rate limiters
+	// of the sources, by source name
+	rateLimitedExpvars = expvar.NewMap("logs-rate-limited")
+	// ringBudget is the memory budget shared by the rings of all the pipelines
+	ringBudget = membudget.Register("logs")
+)
+
+// A Ring is a bounded queue of messages, shared by the inputs pushing the
+// messages and the processor popping them in batches. A push blocks while
+// the ring is full, so that a slow pipeline slows down the inputs instead of
+// buffering without limit.
+type Ring struct {
+	mu       sync.Mutex
+	notEmpty *sync.Cond
+	notFull  *sync.Cond
+	buffer   []Message
+	head     int
+	length   int
+	closed   bool
+	// blockedPushes counts the pushes which waited for some room
+	blockedPushes int64
+	// budget accounts for the content of the messages in the ring
+	budget *membudget.Component
+}
+
+// NewRing returns a ring holding up to size messages
+func NewRing(size int) *Ring {
+	if size < 1 {
+		size = 1
+	}
+	r := &Ring{
+		buffer: make([]Message, size),
+		budget: ringBudget,
+	}
+	r.notEmpty = sync.NewCond(&r.mu)
+	r.notFull = sync.NewCond(&r.mu)
+	return r
+}
+
+// Push adds a message to the ring, blocking while the ring is full.
+// The message is dropped when its source is over its rate limit, when the
+// ring is closed, or when the rings are over their memory budget, the
+// messages which are not errors first.
+func (r *Ring) Push(msg Message) {
+	if origin := msg.GetOrigin(); origin != nil && origin.LogSource != nil && !origin.LogSource.RateLimiter.Allow() {
+		rateLimitedExpvars.Add(origin.LogSource.Name, 1)
+		origin.LogSource.Metrics.AddMessagesDropped(1)
+		return
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.length == len(r.buffer) && !r.closed {
+		r.blockedPushes++
+		ringExpvars.Add("BlockedPushes", 1)
+		start := time.Now()
+		for r.length == len(r.buffer) && !r.closed {
+			r.notFull.Wait()
+		}
+		ringExpvars.Add("BlockedPushNanoseconds", int64(time.Since(start)))
+	}
+	if r.closed {
+		ringExpvars.Add("DroppedOnClose", 1)
+		sourceMetrics(msg).AddMessagesDropped(1)
+		return
+	}
+	if !r.budget.Reserve(int64(len(msg.Content())), budgetPriority(msg)) {
+		ringExpvars.Add("DroppedOverBudget", 1)
+		sourceMetrics(msg).AddMessagesDropped(1)
+		return
+	}
+	r.buffer[(r.head+r.length)%len(r.buffer)] = msg
+	r.length++
+	r.notEmpty.Signal()
+}
+
+// PopBatch moves up to cap(batch) messages from the ring to batch, blocking
+// until there is at least one. The batch is reused from one call to the next
+// to avoid allocations. It returns an empty batch once the ring is closed
+// and drained.
+func (r *Ring) PopBatch(batch []Message) []Message {
+	batch = batch[:0]
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for r.length == 0 && !r.closed {
+		r.notEmpty.Wait()
+	}
+	for r.length > 0 && len(batch) < cap(batch) {
+		batch = append(batch, r.pop())
+	}
+	if len(batch) > 0 {
+		ringExpvars.Add("Batches", 1)
+		r.notFull.Broadcast()
+	}
+	return batch
+}
+
+// Pop removes the oldest message of the ring, blocking until there is one.
+// It returns nil once the ring is closed and drained.
+func (r *Ring) Pop() Message {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for r.length == 0 && !r.closed {
+		r.notEmpty.Wait()
+	}
+	if r.length == 0 {
+		return nil
+	}
+	msg := r.pop()
+	r.notFull.Broadcast()
+	return msg
+}
+
+// pop removes the oldest message, the ring must not be empty
+func (r *Ring) pop() Message {
+	msg := r.buffer[r.head]
+	// release the reference for the garbage collector
+	r.buffer[r.head] = nil
+	r.head = (r.head + 1) % len(r.buffer)
+	r.length--
+	r.budget.Release(int64(len(msg.Content())))
+	return msg
+}
+
+// budgetPriority returns the priority of a message in the memory budget of
+// the rings, the errors are kept the longest
+func budgetPriority(msg Message) membudget.Priority {
+	if bytes.Equal(msg.GetSeverity(), config.SevError) {
+		return membudget.PriorityHigh
+	}
+	return membudget.PriorityNormal
+}
+
+// Close wakes up the blocked callers, the messages left can still be popped
+func (r *Ring) Close() {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.closed = true
+	r.notEmpty.Broadcast()
+	r.notFull.Broadcast()
+}
+
+// Len returns the number of messages in the ring
+func (r *Ring) Len() int {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.length
+}
+
+// BlockedPushes returns the number of pushes which waited for the ring to
+// have some room, a sign of backpressure from the pipeline
+func (r *Ring) BlockedPushes() int64 {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.blockedPushes
+}
+
+// sourceMetrics returns the metrics of the source of a message, nil when
+// it has no source
+func sourceMetrics(msg Message) *metrics.SourceMetrics {
+	if origin := msg.GetOrigin(); origin != nil && origin.LogSource != nil {
+		return origin.LogSource.Metrics
+	}
+	return nil
+}
@@ -0,0 +1,134 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2018 Datadog, Inc.
+
+package message
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/DataDog/datadog-agent/pkg/logs/config"
+	"github.com/DataDog/datadog-agent/pkg/util/membudget"
+)
+
+func TestRingPopBatch(t *testing.T) {
+	ring := NewRing(4)
+	for i := 0; i < 3; i++ {
+		ring.Push(New([]byte(fmt.Sprintf("%d", i)), nil, nil))
+	}
+	assert.Equal(t, 3, ring.Len())
+
+	batch := ring.PopBatch(make([]Message, 0, 2))
+	require.Len(t, batch, 2)
+	assert.Equal(t, "0", string(batch[0].Content()))
+	assert.Equal(t, "1", string(batch[1].Content()))
+
+	// the ring wraps around its buffer
+	ring.Push(New([]byte("3"), nil, nil))
+	ring.Push(New([]byte("4"), nil, nil))
+	batch = ring.PopBatch(batch)
+	require.Len(t, batch, 2)
+	assert.Equal(t, "2", string(batch[0].Content()))
+	assert.Equal(t, "3", string(batch[1].Content()))
+	assert.Equal(t, "4", string(ring.Pop().Content()))
+	assert.Equal(t, 0, ring.Len())
+}
+
+func TestRingBackpressure(t *testing.T) {
+	ring := NewRing(1)
+	ring.Push(New([]byte("first"), nil, nil))
+
+	pushed := make(chan struct{})
+	go func() {
+		ring.Push(New([]byte("second"), nil, nil))
+		close(pushed)
+	}()
+
+	select {
+	case <-pushed:
+		assert.Fail(t, "the push should block while the ring is full")
+	case <-time.After(50 * time.Millisecond):
+	}
+
+	assert.Equal(t, "first", string(ring.Pop().Content()))
+	select {
+	case <-pushed:
+	case <-time.After(time.Second):
+		assert.Fail(t, "the push should be unblocked by the pop")
+	}
+	assert.Equal(t, "second", string(ring.Pop().Content()))
+	assert.Equal(t, int64(1), ring.BlockedPushes())
+}
+
+func TestRingClose(t *testing.T) {
+	ring := NewRing(2)
+	ring.Push(New([]byte("hello"), nil, nil))
+
+	popped := make(chan []Message)
+	go func() {
+		batch := make([]Message, 0, 2)
+		var all []Message
+		for {
+			batch = ring.PopBatch(batch)
+			if len(batch) == 0 {
+				popped <- all
+				return
+			}
+			all = append(all, batch...)
+		}
+	}()
+	ring.Close()
+
+	select {
+	case all := <-popped:
+		// the messages pushed before the close are still popped
+		require.Len(t, all, 1)
+		assert.Equal(t, "hello", string(all[0].Content()))
+	case <-time.After(time.Second):
+		assert.Fail(t, "the close should unblock the pop")
+	}
+
+	// the pushes after the close don't block
+	ring.Push(New([]byte("world"), nil, nil))
+	ring.Push(New([]byte("world"), nil, nil))
+	ring.Push(New([]byte("world"), nil, nil))
+	assert.Nil(t, ring.Pop())
+}
+
+func TestRingMemoryBudget(t *testing.T) {
+	ring := NewRing(4)
+	ring.budget = membudget.Register("test_logs")
+	ring.budget.SetLimit(10)
+	ring.Push(New([]byte("12345678"), nil, nil))
+	// over the share of the budget of the messages which are not errors
+	ring.Push(New([]byte("12"), nil, nil))
+	ring.Push(New([]byte("12"), nil, config.SevError))
+	assert.Equal(t, 2, ring.Len())
+	assert.Equal(t, int64(10), ring.budget.Used())
+
+	ring.Pop()
+	ring.Pop()
+	assert.Equal(t, int64(0), ring.budget.Used())
+	ring.Push(New([]byte("12"), nil, nil))
+	assert.Equal(t, 1, ring.Len())
+}
+
+func TestRingRateLimit(t *testing.T) {
+	ring := NewRing(4)
+	source := config.NewLogSource("chatty", &config.LogsConfig{RateLimit: 1, RateLimitBurst: 2})
+	for i := 0; i < 4; i++ {
+		ring.Push(New([]byte("hello"), NewOrigin(source), nil))
+	}
+	assert.Equal(t, 2, ring.Len())
+	assert.Equal(t, int64(2), source.RateLimiter.Dropped())
+
+	// the other sources are not limited
+	ring.Push(New([]byte("hello"), NewOrigin(config.NewLogSource("quiet", &config.LogsConfig{})), nil))
+	assert.Equal(t, 3, ring.Len())
+}
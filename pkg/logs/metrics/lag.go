@@ -0,0 +1,83 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2018 Datadog, Inc.
+
+package metrics
+
+import (
+	"sort"
+	"sync"
+	"time"
+)
+
+// lagGrowingChecks is the number of consecutive checks an input must fall
+// further behind at for its lag to be reported as growing
+const lagGrowingChecks = 3
+
+var (
+	lagsMutex sync.Mutex
+	lags      = make(map[string]*InputLag)
+)
+
+// InputLag is how far behind the end of a file its input is
+type InputLag struct {
+	Input         string `json:"input"`
+	BytesBehind   int64  `json:"bytes_behind"`
+	SecondsBehind int64  `json:"seconds_behind"`
+	// Growing is true when the input fell further behind at the last
+	// checks, it can't keep up with the volume of the logs
+	Growing       bool `json:"growing"`
+	growingChecks int
+}
+
+// SetLag records the lag of an input, bytesBehind bytes were not read yet
+// and it last caught up with the end of its file timeBehind ago
+func SetLag(input string, bytesBehind int64, timeBehind time.Duration) {
+	lagsMutex.Lock()
+	defer lagsMutex.Unlock()
+	lag, found := lags[input]
+	if !found {
+		lag = &InputLag{Input: input}
+		lags[input] = lag
+	}
+	if bytesBehind > lag.BytesBehind {
+		lag.growingChecks++
+	} else {
+		lag.growingChecks = 0
+	}
+	lag.BytesBehind = bytesBehind
+	lag.SecondsBehind = int64(timeBehind / time.Second)
+	lag.Growing = lag.growingChecks >= lagGrowingChecks
+}
+
+// RemoveLag forgets the lag of an input which is not read anymore
+func RemoveLag(input string) {
+	lagsMutex.Lock()
+	defer lagsMutex.Unlock()
+	delete(lags, input)
+}
+
+// GetLag returns the lag of an input, nil when it is unknown
+func GetLag(input string) *InputLag {
+	lagsMutex.Lock()
+	defer lagsMutex.Unlock()
+	lag, found := lags[input]
+	if !found {
+		return nil
+	}
+	snapshot := *lag
+	return &snapshot
+}
+
+// GetLags returns the lags of all the inputs sorted by input
+func GetLags() []InputLag {
+	lagsMutex.Lock()
+	defer lagsMutex.Unlock()
+	all := make([]InputLag, 0, len(lags))
+	for _, lag := range lags {
+		all = append(all, *lag)
+	}
+	sort.Slice(all, func(i, j int) bool { return all[i].Input < all[j].Input })
+	return all
+}
@@ -0,0 +1,187 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2018 Datadog, Inc.
+
+package metrics
+
+import (
+	"expvar"
+	"sort"
+	"sync"
+	"sync/atomic"
+)
+
+var (
+	// logsExpvars holds the totals of the counters of all the sources
+	logsExpvars = expvar.NewMap("logs-agent")
+
+	destinationsMutex sync.Mutex
+	destinations      = make(map[string]*DestinationMetrics)
+)
+
+func init() {
+	expvar.Publish("logs-destinations", expvar.Func(func() interface{} {
+		return GetDestinationStats()
+	}))
+	expvar.Publish("logs-lags", expvar.Func(func() interface{} {
+		return GetLags()
+	}))
+}
+
+// SourceMetrics counts the logs of a source through the pipeline, a nil
+// SourceMetrics counts nothing
+type SourceMetrics struct {
+	bytesRead       int64
+	messagesDecoded int64
+	messagesDropped int64
+	messagesSent    int64
+}
+
+// NewSourceMetrics returns a new SourceMetrics
+func NewSourceMetrics() *SourceMetrics {
+	return &SourceMetrics{}
+}
+
+// AddBytesRead counts the bytes read by an input
+func (m *SourceMetrics) AddBytesRead(n int) {
+	if m == nil {
+		return
+	}
+	atomic.AddInt64(&m.bytesRead, int64(n))
+	logsExpvars.Add("BytesRead", int64(n))
+}
+
+// AddMessagesDecoded counts the messages decoded from the bytes read
+func (m *SourceMetrics) AddMessagesDecoded(n int) {
+	if m == nil {
+		return
+	}
+	atomic.AddInt64(&m.messagesDecoded, int64(n))
+	logsExpvars.Add("MessagesDecoded", int64(n))
+}
+
+// AddMessagesDropped counts the messages dropped before being sent
+func (m *SourceMetrics) AddMessagesDropped(n int) {
+	if m == nil {
+		return
+	}
+	atomic.AddInt64(&m.messagesDropped, int64(n))
+	logsExpvars.Add("MessagesDropped", int64(n))
+}
+
+// AddMessagesSent counts the messages sent to the intake
+func (m *SourceMetrics) AddMessagesSent(n int) {
+	if m == nil {
+		return
+	}
+	atomic.AddInt64(&m.messagesSent, int64(n))
+	logsExpvars.Add("MessagesSent", int64(n))
+}
+
+// BytesRead returns the number of bytes read
+func (m *SourceMetrics) BytesRead() int64 {
+	if m == nil {
+		return 0
+	}
+	return atomic.LoadInt64(&m.bytesRead)
+}
+
+// MessagesDecoded returns the number of messages decoded
+func (m *SourceMetrics) MessagesDecoded() int64 {
+	if m == nil {
+		return 0
+	}
+	return atomic.LoadInt64(&m.messagesDecoded)
+}
+
+// MessagesDropped returns the number of messages dropped
+func (m *SourceMetrics) MessagesDropped() int64 {
+	if m == nil {
+		return 0
+	}
+	return atomic.LoadInt64(&m.messagesDropped)
+}
+
+// MessagesSent returns the number of messages sent
+func (m *SourceMetrics) MessagesSent() int64 {
+	if m == nil {
+		return 0
+	}
+	return atomic.LoadInt64(&m.messagesSent)
+}
+
+// DestinationMetrics counts the payloads sent to an intake, a nil
+// DestinationMetrics counts nothing
+type DestinationMetrics struct {
+	payloadsSent    int64
+	bytesSent       int64
+	retries         int64
+	payloadsDropped int64
+}
+
+// DestinationStats holds the counters of a destination
+type DestinationStats struct {
+	Name            string `json:"name"`
+	PayloadsSent    int64  `json:"payloads_sent"`
+	BytesSent       int64  `json:"bytes_sent"`
+	Retries         int64  `json:"retries"`
+	PayloadsDropped int64  `json:"payloads_dropped"`
+}
+
+// GetDestination returns the DestinationMetrics of the intake name,
+// shared by all the senders sending to it
+func GetDestination(name string) *DestinationMetrics {
+	destinationsMutex.Lock()
+	defer destinationsMutex.Unlock()
+	m, found := destinations[name]
+	if !found {
+		m = &DestinationMetrics{}
+		destinations[name] = m
+	}
+	return m
+}
+
+// GetDestinationStats returns the counters of all the destinations sorted
+// by name
+func GetDestinationStats() []DestinationStats {
+	destinationsMutex.Lock()
+	defer destinationsMutex.Unlock()
+	stats := make([]DestinationStats, 0, len(destinations))
+	for name, m := range destinations {
+		stats = append(stats, DestinationStats{
+			Name:            name,
+			PayloadsSent:    atomic.LoadInt64(&m.payloadsSent),
+			BytesSent:       atomic.LoadInt64(&m.bytesSent),
+			Retries:         atomic.LoadInt64(&m.retries),
+			PayloadsDropped: atomic.LoadInt64(&m.payloadsDropped),
+		})
+	}
+	sort.Slice(stats, func(i, j int) bool { return stats[i].Name < stats[j].Name })
+	return stats
+}
+
+// AddPayloadSent counts a payload of size bytes sent
+func (m *DestinationMetrics) AddPayloadSent(size int) {
+	if m == nil {
+		return
+	}
+	atomic.AddInt64(&m.payloadsSent, 1)
+	atomic.AddInt64(&m.bytesSent, int64(size))
+}
+
+// AddRetry counts a payload which could not be sent, to be sent again
+func (m *DestinationMetrics) AddRetry() {
+	if m == nil {
+		return
+	}
+	atomic.AddInt64(&m.retries, 1)
+}
+
+// AddPayloadDropped counts a payload rejected by the intake
+func (m *DestinationMetrics) AddPayloadDropped() {
+	if m == nil {
+		return
+	}
+	atomic.AddInt64(&m.payloadsDropped, 1)
+}
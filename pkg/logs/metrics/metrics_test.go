@@ -0,0 +1,66 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2018 Datadog, Inc.
+
+package metrics
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSourceMetrics(t *testing.T) {
+	m := NewSourceMetrics()
+	m.AddBytesRead(10)
+	m.AddBytesRead(5)
+	m.AddMessagesDecoded(2)
+	m.AddMessagesDropped(1)
+	m.AddMessagesSent(1)
+	assert.Equal(t, int64(15), m.BytesRead())
+	assert.Equal(t, int64(2), m.MessagesDecoded())
+	assert.Equal(t, int64(1), m.MessagesDropped())
+	assert.Equal(t, int64(1), m.MessagesSent())
+	assert.Equal(t, "15", logsExpvars.Get("BytesRead").String())
+
+	// a nil SourceMetrics counts nothing
+	var nilMetrics *SourceMetrics
+	nilMetrics.AddBytesRead(10)
+	assert.Equal(t, int64(0), nilMetrics.BytesRead())
+}
+
+func TestDestinationMetrics(t *testing.T) {
+	GetDestination("b:443").AddPayloadSent(100)
+	GetDestination("a:10516").AddRetry()
+	GetDestination("b:443").AddPayloadSent(50)
+	GetDestination("b:443").AddPayloadDropped()
+
+	assert.Equal(t, []DestinationStats{
+		{Name: "a:10516", Retries: 1},
+		{Name: "b:443", PayloadsSent: 2, BytesSent: 150, PayloadsDropped: 1},
+	}, GetDestinationStats())
+}
+
+func TestLags(t *testing.T) {
+	defer RemoveLag("/var/log/app.log")
+	assert.Nil(t, GetLag("/var/log/app.log"))
+
+	SetLag("/var/log/app.log", 100, 2*time.Second)
+	assert.Equal(t, &InputLag{Input: "/var/log/app.log", BytesBehind: 100, SecondsBehind: 2, growingChecks: 1}, GetLag("/var/log/app.log"))
+
+	// the lag grows at every check
+	SetLag("/var/log/app.log", 200, 12*time.Second)
+	SetLag("/var/log/app.log", 300, 22*time.Second)
+	assert.True(t, GetLag("/var/log/app.log").Growing)
+	assert.Equal(t, []InputLag{{Input: "/var/log/app.log", BytesBehind: 300, SecondsBehind: 22, Growing: true, growingChecks: 3}}, GetLags())
+
+	// the input caught up
+	SetLag("/var/log/app.log", 0, 0)
+	assert.False(t, GetLag("/var/log/app.log").Growing)
+
+	RemoveLag("/var/log/app.log")
+	assert.Nil(t, GetLag("/var/log/app.log"))
+	assert.Empty(t, GetLags())
+}
@@ -12,13 +12,13 @@ import (
 
 // mockProvider mocks pipeline providing logic
 type mockProvider struct {
-	msgChan chan message.Message
+	input *message.Ring
 }
 
 // NewMockProvider returns a new mockProvider
 func NewMockProvider() pipeline.Provider {
 	return &mockProvider{
-		msgChan: make(chan message.Message),
+		input: message.NewRing(1),
 	}
 }
 
@@ -28,7 +28,7 @@ func (p *mockProvider) Start() {}
 // Stop does nothing
 func (p *mockProvider) Stop() {}
 
-// NextPipelineChan returns the next pipeline
-func (p *mockProvider) NextPipelineChan() chan message.Message {
-	return p.msgChan
+// NextPipelineInput returns the next pipeline
+func (p *mockProvider) NextPipelineInput() *message.Ring {
+	return p.input
 }
@@ -6,54 +6,131 @@
 package pipeline
 
 import (
+	"fmt"
+	"time"
+
+	"github.com/DataDog/datadog-agent/pkg/logs/auditor"
 	"github.com/DataDog/datadog-agent/pkg/logs/config"
 	"github.com/DataDog/datadog-agent/pkg/logs/message"
 	"github.com/DataDog/datadog-agent/pkg/logs/processor"
+	"github.com/DataDog/datadog-agent/pkg/logs/restart"
 	"github.com/DataDog/datadog-agent/pkg/logs/sender"
+	"github.com/DataDog/datadog-agent/pkg/util/scrubber"
 )
 
 // Pipeline processes and sends messages to the backend
 type Pipeline struct {
-	InputChan chan message.Message
+	Input     *message.Ring
 	processor *processor.Processor
-	sender    *sender.Sender
+	buffer    *sender.DiskBuffer
+	sender    restart.Restartable
 }
 
-// NewPipeline returns a new Pipeline
-func NewPipeline(connManager *sender.ConnectionManager, outputChan chan message.Message) *Pipeline {
+// NewPipeline returns a new Pipeline sending the logs to the HTTP intake
+// when httpDestination is set, to the TCP one otherwise, and spilling them
+// to bufferPath while the intake is unreachable when bufferPath is set, the
+// logs are redacted by the scrubbers before they are sent, and copies of
+// them are sent to the additionalEndpoints over the same protocol
+func NewPipeline(connManager *sender.ConnectionManager, httpDestination *sender.HTTPDestination, additionalEndpoints []config.Endpoint, bufferPath string, auditor *auditor.Auditor, processingRules []config.LogsProcessingRule, scrubbers []scrubber.Replacer, outputChan chan message.Message) *Pipeline {
 
 	useProto := config.LogsAgent.GetBool("logs_config.dev_mode_use_proto")
 
-	// initialize the sender
 	senderChan := make(chan message.Message, config.ChanSize)
-	delimiter := sender.NewDelimiter(useProto)
-	sender := sender.New(senderChan, outputChan, connManager, delimiter)
+	var s restart.Restartable
+	var encoder processor.Encoder
+	var prefixer processor.Prefixer
+	if httpDestination != nil {
+		// the HTTP intake gets batches of JSON logs, authenticated by the API key header
+		batchWait := time.Duration(config.LogsAgent.GetInt("logs_config.batch_wait")) * time.Second
+		additionals := newAdditionalHTTPSenders(additionalEndpoints)
+		s = sender.NewBatchSender(senderChan, outputChan, httpDestination, additionals, batchWait)
+		encoder = processor.JSONEncoder
+		prefixer = processor.NoopPrefixer
+	} else {
+		delimiter := sender.NewDelimiter(useProto)
+		apikey := config.LogsAgent.GetString("api_key")
+		logset := config.LogsAgent.GetString("logset") // TODO Logset is deprecated and should be removed eventually.
+		additionals := newAdditionalTCPSenders(additionalEndpoints, delimiter, processor.APIKeyPrefix(apikey, logset))
+		s = sender.New(senderChan, outputChan, connManager, additionals, delimiter)
+		encoder = processor.NewEncoder(useProto)
+		prefixer = processor.NewAPIKeyPrefixer(apikey, logset)
+	}
+
+	// initialize the disk buffer between the processor and the sender
+	processorChan := senderChan
+	var buffer *sender.DiskBuffer
+	if bufferPath != "" {
+		processorChan = make(chan message.Message, config.ChanSize)
+		maxSize := config.LogsAgent.GetInt64("logs_config.disk_buffer_max_size")
+		buffer = sender.NewDiskBuffer(processorChan, senderChan, outputChan, bufferPath, maxSize, auditor)
+	}
 
-	// initialize the input chan
-	inputChan := make(chan message.Message, config.ChanSize)
+	// initialize the input ring
+	input := message.NewRing(config.RingSize)
 
 	// initialize the processor
-	encoder := processor.NewEncoder(useProto)
-	apikey := config.LogsAgent.GetString("api_key")
-	logset := config.LogsAgent.GetString("logset") // TODO Logset is deprecated and should be removed eventually.
-	prefixer := processor.NewAPIKeyPrefixer(apikey, logset)
-	processor := processor.New(inputChan, senderChan, encoder, prefixer)
+	processor := processor.New(input, processorChan, encoder, prefixer, processingRules, scrubbers)
 
 	return &Pipeline{
-		InputChan: inputChan,
+		Input:     input,
 		processor: processor,
-		sender:    sender,
+		buffer:    buffer,
+		sender:    s,
 	}
 }
 
 // Start launches the pipeline
 func (p *Pipeline) Start() {
 	p.sender.Start()
+	if p.buffer != nil {
+		p.buffer.Start()
+	}
 	p.processor.Start()
 }
 
 // Stop stops the pipeline
 func (p *Pipeline) Stop() {
 	p.processor.Stop()
+	if p.buffer != nil {
+		p.buffer.Stop()
+	}
 	p.sender.Stop()
 }
+
+// newAdditionalHTTPSenders returns the senders of the batches of logs to the
+// HTTP intakes of the endpoints, each has its own retries
+func newAdditionalHTTPSenders(endpoints []config.Endpoint) []*sender.AdditionalSender {
+	var additionals []*sender.AdditionalSender
+	for _, endpoint := range endpoints {
+		port := endpoint.Port
+		if port == 0 {
+			port = config.LogsAgent.GetInt("logs_config.http_dd_port")
+		}
+		destination := sender.NewHTTPDestination(
+			endpoint.Host,
+			port,
+			!config.LogsAgent.GetBool("logs_config.dev_mode_no_ssl"),
+			endpoint.APIKey,
+			config.LogsAgent.GetBool("logs_config.use_compression"),
+		)
+		additionals = append(additionals, sender.NewAdditionalSender(destination, fmt.Sprintf("%s:%d", endpoint.Host, port)))
+	}
+	return additionals
+}
+
+// newAdditionalTCPSenders returns the senders of the logs to the TCP intakes
+// of the endpoints, each has its own connection and retries, the logs are
+// prefixed with the API key of the endpoint instead of mainPrefix
+func newAdditionalTCPSenders(endpoints []config.Endpoint, delimiter sender.Delimiter, mainPrefix []byte) []*sender.AdditionalSender {
+	var additionals []*sender.AdditionalSender
+	for _, endpoint := range endpoints {
+		port := endpoint.Port
+		if port == 0 {
+			port = config.LogsAgent.GetInt("logs_config.dd_port")
+		}
+		connManager := sender.NewConnectionManager(endpoint.Host, port, config.LogsAgent.GetBool("logs_config.dev_mode_no_ssl"))
+		destination := sender.NewTCPDestination(connManager, delimiter, mainPrefix, processor.APIKeyPrefix(endpoint.APIKey, ""))
+		additionals = append(additionals, sender.NewAdditionalSender(destination, fmt.Sprintf("%s:%d", endpoint.Host, port)))
+	}
+	return additionals
+}
@@ -6,43 +6,68 @@
 package pipeline
 
 import (
+	"path/filepath"
+	"strconv"
 	"sync/atomic"
 
+	"github.com/DataDog/datadog-agent/pkg/logs/auditor"
+	"github.com/DataDog/datadog-agent/pkg/logs/config"
 	"github.com/DataDog/datadog-agent/pkg/logs/message"
 	"github.com/DataDog/datadog-agent/pkg/logs/restart"
 	"github.com/DataDog/datadog-agent/pkg/logs/sender"
+	"github.com/DataDog/datadog-agent/pkg/util/scrubber"
 )
 
-// Provider provides message channels
+// Provider provides the input rings of the pipelines
 type Provider interface {
 	Start()
 	Stop()
-	NextPipelineChan() chan message.Message
+	NextPipelineInput() *message.Ring
 }
 
 // provider implements providing logic
 type provider struct {
 	numberOfPipelines    int
 	connManager          *sender.ConnectionManager
+	httpDestination      *sender.HTTPDestination
+	additionalEndpoints  []config.Endpoint
+	diskBufferPath       string
+	auditor              *auditor.Auditor
+	processingRules      []config.LogsProcessingRule
+	scrubbers            []scrubber.Replacer
 	outputChan           chan message.Message
 	pipelines            []*Pipeline
 	currentPipelineIndex int32
 }
 
-// NewProvider returns a new Provider
-func NewProvider(numberOfPipelines int, connManager *sender.ConnectionManager, outputChan chan message.Message) Provider {
+// NewProvider returns a new Provider, its pipelines send the logs to the
+// HTTP intake when httpDestination is set, to the TCP one otherwise, and to
+// the additionalEndpoints, and each spill them to a directory of
+// diskBufferPath when it is set, the processingRules and the scrubbers are
+// applied to the logs of all the sources
+func NewProvider(numberOfPipelines int, connManager *sender.ConnectionManager, httpDestination *sender.HTTPDestination, additionalEndpoints []config.Endpoint, diskBufferPath string, auditor *auditor.Auditor, processingRules []config.LogsProcessingRule, scrubbers []scrubber.Replacer, outputChan chan message.Message) Provider {
 	return &provider{
-		numberOfPipelines: numberOfPipelines,
-		connManager:       connManager,
-		outputChan:        outputChan,
-		pipelines:         []*Pipeline{},
+		numberOfPipelines:   numberOfPipelines,
+		connManager:         connManager,
+		httpDestination:     httpDestination,
+		additionalEndpoints: additionalEndpoints,
+		diskBufferPath:      diskBufferPath,
+		auditor:             auditor,
+		processingRules:     processingRules,
+		scrubbers:           scrubbers,
+		outputChan:          outputChan,
+		pipelines:           []*Pipeline{},
 	}
 }
 
 // Start initializes the pipelines
 func (p *provider) Start() {
 	for i := 0; i < p.numberOfPipelines; i++ {
-		pipeline := NewPipeline(p.connManager, p.outputChan)
+		var bufferPath string
+		if p.diskBufferPath != "" {
+			bufferPath = filepath.Join(p.diskBufferPath, strconv.Itoa(i))
+		}
+		pipeline := NewPipeline(p.connManager, p.httpDestination, p.additionalEndpoints, bufferPath, p.auditor, p.processingRules, p.scrubbers, p.outputChan)
 		pipeline.Start()
 		p.pipelines = append(p.pipelines, pipeline)
 	}
@@ -59,8 +84,8 @@ func (p *provider) Stop() {
 	p.pipelines = p.pipelines[:0]
 }
 
-// NextPipelineChan returns the next pipeline input channel
-func (p *provider) NextPipelineChan() chan message.Message {
+// NextPipelineInput returns the next pipeline input ring
+func (p *provider) NextPipelineInput() *message.Ring {
 	pipelinesLen := len(p.pipelines)
 	if pipelinesLen == 0 {
 		return nil
@@ -68,5 +93,5 @@ func (p *provider) NextPipelineChan() chan message.Message {
 	index := int(p.currentPipelineIndex+1) % pipelinesLen
 	defer atomic.StoreInt32(&p.currentPipelineIndex, int32(index))
 	nextPipeline := p.pipelines[index]
-	return nextPipeline.InputChan
+	return nextPipeline.Input
 }
@@ -28,19 +28,19 @@ func (suite *ProviderTestSuite) TestProvider() {
 	suite.Equal(int32(0), suite.p.currentPipelineIndex)
 	suite.Equal(3, len(suite.p.pipelines))
 
-	c := suite.p.NextPipelineChan()
+	c := suite.p.NextPipelineInput()
 	suite.Equal(int32(1), suite.p.currentPipelineIndex)
 
-	suite.p.NextPipelineChan()
+	suite.p.NextPipelineInput()
 	suite.Equal(int32(2), suite.p.currentPipelineIndex)
 
-	suite.p.NextPipelineChan()
+	suite.p.NextPipelineInput()
 	suite.Equal(int32(0), suite.p.currentPipelineIndex)
-	suite.Equal(c, suite.p.NextPipelineChan())
+	suite.Equal(c, suite.p.NextPipelineInput())
 	suite.Equal(int32(1), suite.p.currentPipelineIndex)
 
 	suite.p.Stop()
-	suite.Nil(suite.p.NextPipelineChan())
+	suite.Nil(suite.p.NextPipelineInput())
 }
 
 func TestProviderTestSuite(t *testing.T) {
@@ -7,6 +7,8 @@ package processor
 
 import (
 	"bytes"
+	"encoding/json"
+	"strings"
 	"time"
 
 	"regexp"
@@ -28,6 +30,10 @@ var rawEncoder raw
 // Proto is an encoder implementation that writes messages as protocol buffers.
 var protoEncoder proto
 
+// JSONEncoder is an encoder implementation that writes messages as the JSON
+// objects of the HTTP intake.
+var JSONEncoder Encoder = &jsonEncoder{}
+
 // NewEncoder returns an encoder.
 func NewEncoder(useProto bool) Encoder {
 	if useProto {
@@ -61,14 +67,14 @@ func (r *raw) encode(msg message.Message, redactedMsg []byte) ([]byte, error) {
 		extraContent = append(extraContent, ' ')
 
 		// Timestamp
-		extraContent = time.Now().UTC().AppendFormat(extraContent, config.DateFormat)
+		extraContent = getTimestamp(msg).AppendFormat(extraContent, config.DateFormat)
 		extraContent = append(extraContent, ' ')
 
-		extraContent = append(extraContent, []byte(getHostname())...)
+		extraContent = append(extraContent, []byte(getHostname(msg))...)
 		extraContent = append(extraContent, ' ')
 
 		// Service
-		service := msg.GetOrigin().LogSource.Config.Service
+		service := getService(msg)
 		if service != "" {
 			extraContent = append(extraContent, []byte(service)...)
 		} else {
@@ -109,28 +115,79 @@ type proto struct{}
 
 func (p *proto) encode(msg message.Message, redactedMsg []byte) ([]byte, error) {
 
-	// TODO Remove occurrences of "severity" (it is now "status")
-	// Compute the status
-	var status string
-	if msg.GetSeverity() != nil && bytes.Equal(msg.GetSeverity(), config.SevError) {
-		status = config.StatusError
-	} else {
-		status = config.StatusInfo
-	}
-
 	return (&pb.Log{
 		Message:   string(redactedMsg),
-		Status:    status,
-		Timestamp: time.Now().UTC().UnixNano(),
-		Hostname:  getHostname(),
-		Service:   msg.GetOrigin().LogSource.Config.Service,
-		Source:    msg.GetOrigin().LogSource.Config.Source,
+		Status:    getStatus(msg),
+		Timestamp: getTimestamp(msg).UnixNano(),
+		Hostname:  getHostname(msg),
+		Service:   getService(msg),
+		Source:    msg.GetOrigin().Source(),
 		Tags:      msg.GetOrigin().Tags(),
 	}).Marshal()
 }
 
-// getHostname returns the hostname for the agent.
-func getHostname() string {
+type jsonEncoder struct{}
+
+// jsonPayload is the JSON object of a log sent to the HTTP intake
+type jsonPayload struct {
+	Message   string `json:"message"`
+	Status    string `json:"status"`
+	Timestamp int64  `json:"timestamp"`
+	Hostname  string `json:"hostname"`
+	Service   string `json:"service,omitempty"`
+	Source    string `json:"ddsource,omitempty"`
+	Tags      string `json:"ddtags,omitempty"`
+}
+
+func (j *jsonEncoder) encode(msg message.Message, redactedMsg []byte) ([]byte, error) {
+	return json.Marshal(jsonPayload{
+		Message:   string(redactedMsg),
+		Status:    getStatus(msg),
+		Timestamp: getTimestamp(msg).UnixNano() / int64(time.Millisecond),
+		Hostname:  getHostname(msg),
+		Service:   getService(msg),
+		Source:    msg.GetOrigin().Source(),
+		Tags:      strings.Join(msg.GetOrigin().Tags(), ","),
+	})
+}
+
+// getStatus returns the status promoted from the content of the message, or
+// the one of its severity
+func getStatus(msg message.Message) string {
+	if status := msg.GetAttributes().Status; status != "" {
+		return status
+	}
+	// TODO Remove occurrences of "severity" (it is now "status")
+	if bytes.Equal(msg.GetSeverity(), config.SevError) {
+		return config.StatusError
+	}
+	return config.StatusInfo
+}
+
+// getTimestamp returns the timestamp promoted from the content of the message,
+// or now
+func getTimestamp(msg message.Message) time.Time {
+	if timestamp := msg.GetAttributes().Timestamp; !timestamp.IsZero() {
+		return timestamp
+	}
+	return time.Now().UTC()
+}
+
+// getService returns the service promoted from the content of the message, or
+// the one of its source
+func getService(msg message.Message) string {
+	if service := msg.GetAttributes().Service; service != "" {
+		return service
+	}
+	return msg.GetOrigin().LogSource.Config.Service
+}
+
+// getHostname returns the hostname of the agent the message was relayed
+// from, or the one of this agent.
+func getHostname(msg message.Message) string {
+	if hostname := msg.GetAttributes().Hostname; hostname != "" {
+		return hostname
+	}
 	// Compute the hostname
 	hostname, err := util.GetHostname()
 	if err != nil {
@@ -6,6 +6,7 @@
 package processor
 
 import (
+	"encoding/json"
 	"testing"
 
 	"strings"
@@ -179,3 +180,61 @@ func TestProtoEncoderEmpty(t *testing.T) {
 	assert.NotEmpty(t, log.Timestamp)
 
 }
+
+func TestJSONEncoder(t *testing.T) {
+	logsConfig := &config.LogsConfig{
+		Service: "Service",
+		Source:  "Source",
+		Tags:    []string{"foo:bar"},
+	}
+	source := config.NewLogSource("", logsConfig)
+	message := newMessage([]byte("message"), source, config.SevError)
+	message.GetOrigin().SetTags([]string{"a"})
+
+	content, err := JSONEncoder.encode(message, []byte("redacted"))
+	assert.Nil(t, err)
+
+	var payload jsonPayload
+	assert.Nil(t, json.Unmarshal(content, &payload))
+	assert.Equal(t, "redacted", payload.Message)
+	assert.Equal(t, config.StatusError, payload.Status)
+	assert.NotEmpty(t, payload.Timestamp)
+	assert.NotEmpty(t, payload.Hostname)
+	assert.Equal(t, "Service", payload.Service)
+	assert.Equal(t, "Source", payload.Source)
+	assert.Equal(t, "a,source:Source,foo:bar", payload.Tags)
+}
+
+func TestJSONEncoderPromotedAttributes(t *testing.T) {
+	source := config.NewLogSource("", &config.LogsConfig{Service: "Service"})
+	message := newMessage([]byte("message"), source, config.SevError)
+	message.GetAttributes().Status = "warn"
+	message.GetAttributes().Timestamp = time.Date(2018, time.October, 16, 12, 0, 0, 0, time.UTC)
+	message.GetAttributes().Service = "app"
+
+	content, err := JSONEncoder.encode(message, []byte("redacted"))
+	assert.Nil(t, err)
+
+	var payload jsonPayload
+	assert.Nil(t, json.Unmarshal(content, &payload))
+	assert.Equal(t, "warn", payload.Status)
+	assert.Equal(t, int64(1539691200000), payload.Timestamp)
+	assert.Equal(t, "app", payload.Service)
+}
+
+func TestJSONEncoderRelayedLog(t *testing.T) {
+	source := config.NewLogSource("", &config.LogsConfig{Tags: []string{"relay:eu"}})
+	message := newMessage([]byte("message"), source, nil)
+	message.GetAttributes().Hostname = "web-1"
+	message.GetOrigin().SetSource("nginx")
+	message.GetOrigin().SetTags([]string{"env:prod"})
+
+	content, err := JSONEncoder.encode(message, []byte("redacted"))
+	assert.Nil(t, err)
+
+	var payload jsonPayload
+	assert.Nil(t, json.Unmarshal(content, &payload))
+	assert.Equal(t, "web-1", payload.Hostname)
+	assert.Equal(t, "nginx", payload.Source)
+	assert.Equal(t, "env:prod,source:nginx,relay:eu", payload.Tags)
+}
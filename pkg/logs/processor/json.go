@@ -0,0 +1,142 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2018 Datadog, Inc.
+
+package processor
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+	"time"
+
+	"github.com/DataDog/datadog-agent/pkg/logs/config"
+	"github.com/DataDog/datadog-agent/pkg/logs/cri"
+	"github.com/DataDog/datadog-agent/pkg/logs/message"
+)
+
+// defaultJSONAttributes are the attributes looked up, in order, to promote
+// the reserved attributes of the sources which don't set their own path
+var defaultJSONAttributes = map[string][]string{
+	config.StatusAttribute:    {"status", "level", "severity", "log.level"},
+	config.TimestampAttribute: {"timestamp", "@timestamp", "time", "date"},
+	config.ServiceAttribute:   {"service"},
+}
+
+// dockerLog is a line of the log files of the docker json-file driver
+type dockerLog struct {
+	Log    *string `json:"log"`
+	Stream string  `json:"stream"`
+	Time   string  `json:"time"`
+}
+
+// promoteJSONAttributes returns the content of a message unwrapped from the
+// docker and CRI log formats and, when it is a JSON object, promotes its
+// status, timestamp and service to the attributes of the message
+func promoteJSONAttributes(msg message.Message, content []byte) []byte {
+	content, wrapperTimestamp := unwrapContent(content)
+	attributes := msg.GetAttributes()
+	if timestamp, ok := parseTimestamp(wrapperTimestamp); ok {
+		attributes.Timestamp = timestamp
+	}
+	if !isJSONObject(content) {
+		return content
+	}
+	var object map[string]interface{}
+	if err := json.Unmarshal(content, &object); err != nil {
+		return content
+	}
+	paths := msg.GetOrigin().LogSource.Config.JSONAttributes
+	if status, ok := lookupAttribute(object, paths, config.StatusAttribute).(string); ok && status != "" {
+		attributes.Status = status
+	}
+	if timestamp, ok := parseTimestamp(lookupAttribute(object, paths, config.TimestampAttribute)); ok {
+		attributes.Timestamp = timestamp
+	}
+	if service, ok := lookupAttribute(object, paths, config.ServiceAttribute).(string); ok && service != "" {
+		attributes.Service = service
+	}
+	return content
+}
+
+// unwrapContent returns the log wrapped in a line of the docker json-file
+// driver or of a CRI runtime, along with the timestamp of the line, the
+// content is returned as is otherwise
+func unwrapContent(content []byte) ([]byte, interface{}) {
+	if isJSONObject(content) {
+		var line dockerLog
+		if err := json.Unmarshal(content, &line); err != nil || line.Log == nil || line.Stream == "" {
+			return content, nil
+		}
+		return []byte(strings.TrimSuffix(*line.Log, "\n")), line.Time
+	}
+	line, err := cri.ParseMessage(content)
+	if err != nil {
+		return content, nil
+	}
+	// the first field of any line with a stream as second one would parse,
+	// only the lines starting with a timestamp are unwrapped
+	if _, err := time.Parse(time.RFC3339Nano, line.Timestamp); err != nil {
+		return content, nil
+	}
+	return line.Content, line.Timestamp
+}
+
+// lookupAttribute returns the value of the path of an attribute set by the
+// source, or the one of the first default attribute found
+func lookupAttribute(object map[string]interface{}, paths map[string]string, attribute string) interface{} {
+	if path, exists := paths[attribute]; exists {
+		return lookupPath(object, path)
+	}
+	for _, path := range defaultJSONAttributes[attribute] {
+		if value := lookupPath(object, path); value != nil {
+			return value
+		}
+	}
+	return nil
+}
+
+// lookupPath returns the value of a dotted path in a JSON object, a key
+// containing dots matches as well, nil when not found
+func lookupPath(object map[string]interface{}, path string) interface{} {
+	if value, exists := object[path]; exists {
+		return value
+	}
+	parts := strings.SplitN(path, ".", 2)
+	if len(parts) < 2 {
+		return nil
+	}
+	child, ok := object[parts[0]].(map[string]interface{})
+	if !ok {
+		return nil
+	}
+	return lookupPath(child, parts[1])
+}
+
+// parseTimestamp returns the time of a RFC3339 date or of a number of
+// seconds or milliseconds since the epoch
+func parseTimestamp(value interface{}) (time.Time, bool) {
+	switch v := value.(type) {
+	case string:
+		timestamp, err := time.Parse(time.RFC3339Nano, v)
+		return timestamp.UTC(), err == nil
+	case float64:
+		if v <= 0 {
+			return time.Time{}, false
+		}
+		// the timestamps over 1e11 seconds, year 5138, are milliseconds
+		if v > 1e11 {
+			return time.Unix(0, int64(v*float64(time.Millisecond))).UTC(), true
+		}
+		return time.Unix(0, int64(v*float64(time.Second))).UTC(), true
+	default:
+		return time.Time{}, false
+	}
+}
+
+// isJSONObject returns true if the content looks like a JSON object
+func isJSONObject(content []byte) bool {
+	content = bytes.TrimSpace(content)
+	return len(content) > 1 && content[0] == '{' && content[len(content)-1] == '}'
+}
@@ -0,0 +1,74 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2018 Datadog, Inc.
+
+package processor
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/DataDog/datadog-agent/pkg/logs/config"
+)
+
+func TestPromoteJSONAttributes(t *testing.T) {
+	source := config.NewLogSource("", &config.LogsConfig{})
+	msg := newMessage(nil, source, nil)
+	content := promoteJSONAttributes(msg, []byte(`{"message":"hello","level":"warn","timestamp":"2018-10-16T12:00:00.5Z","service":"app"}`))
+	assert.Equal(t, `{"message":"hello","level":"warn","timestamp":"2018-10-16T12:00:00.5Z","service":"app"}`, string(content))
+	assert.Equal(t, "warn", msg.GetAttributes().Status)
+	assert.Equal(t, time.Date(2018, time.October, 16, 12, 0, 0, 5e8, time.UTC), msg.GetAttributes().Timestamp)
+	assert.Equal(t, "app", msg.GetAttributes().Service)
+
+	// the epoch timestamps are in seconds or milliseconds
+	msg = newMessage(nil, source, nil)
+	promoteJSONAttributes(msg, []byte(`{"time":1539691200}`))
+	assert.Equal(t, time.Date(2018, time.October, 16, 12, 0, 0, 0, time.UTC), msg.GetAttributes().Timestamp)
+	msg = newMessage(nil, source, nil)
+	promoteJSONAttributes(msg, []byte(`{"time":1539691200500}`))
+	assert.Equal(t, time.Date(2018, time.October, 16, 12, 0, 0, 5e8, time.UTC), msg.GetAttributes().Timestamp)
+
+	// the other logs are left as is
+	msg = newMessage(nil, source, nil)
+	assert.Equal(t, "level: error", string(promoteJSONAttributes(msg, []byte("level: error"))))
+	assert.Equal(t, "", msg.GetAttributes().Status)
+	assert.True(t, msg.GetAttributes().Timestamp.IsZero())
+}
+
+func TestPromoteJSONAttributesWithPaths(t *testing.T) {
+	source := config.NewLogSource("", &config.LogsConfig{
+		JSONAttributes: map[string]string{
+			config.StatusAttribute:  "log.severity",
+			config.ServiceAttribute: "app.name",
+		},
+	})
+	msg := newMessage(nil, source, nil)
+	promoteJSONAttributes(msg, []byte(`{"level":"info","log":{"severity":"error"},"app":{"name":"web"},"service":"app"}`))
+	assert.Equal(t, "error", msg.GetAttributes().Status)
+	assert.Equal(t, "web", msg.GetAttributes().Service)
+}
+
+func TestPromoteJSONAttributesUnwrapsContainerLogs(t *testing.T) {
+	source := config.NewLogSource("", &config.LogsConfig{})
+
+	// docker json-file driver
+	msg := newMessage(nil, source, nil)
+	content := promoteJSONAttributes(msg, []byte(`{"log":"{\"status\":\"error\",\"msg\":\"boom\"}\n","stream":"stderr","time":"2018-10-16T12:00:00Z"}`))
+	assert.Equal(t, `{"status":"error","msg":"boom"}`, string(content))
+	assert.Equal(t, "error", msg.GetAttributes().Status)
+	assert.Equal(t, time.Date(2018, time.October, 16, 12, 0, 0, 0, time.UTC), msg.GetAttributes().Timestamp)
+
+	// CRI runtimes
+	msg = newMessage(nil, source, nil)
+	content = promoteJSONAttributes(msg, []byte(`2018-10-16T12:00:00.000000001Z stdout F {"service":"api"}`))
+	assert.Equal(t, `{"service":"api"}`, string(content))
+	assert.Equal(t, "api", msg.GetAttributes().Service)
+	assert.Equal(t, time.Date(2018, time.October, 16, 12, 0, 0, 1, time.UTC), msg.GetAttributes().Timestamp)
+
+	// the lines which only look like CRI ones are left as is
+	msg = newMessage(nil, source, nil)
+	assert.Equal(t, "GET stdout F", string(promoteJSONAttributes(msg, []byte("GET stdout F"))))
+}
@@ -17,14 +17,29 @@ type apiKeyPrefixer struct {
 
 // NewAPIKeyPrefixer returns a prefixer that prepends the given API key to a message.
 func NewAPIKeyPrefixer(apikey, logset string) Prefixer {
+	return &apiKeyPrefixer{
+		key: APIKeyPrefix(apikey, logset),
+	}
+}
+
+// APIKeyPrefix returns the prefix of the messages sent with the given API key.
+func APIKeyPrefix(apikey, logset string) []byte {
 	if logset != "" {
 		apikey = fmt.Sprintf("%s/%s", apikey, logset)
 	}
-	return &apiKeyPrefixer{
-		key: append([]byte(apikey), ' '),
-	}
+	return append([]byte(apikey), ' ')
 }
 
 func (p *apiKeyPrefixer) prefix(content []byte) []byte {
 	return append(p.key, content...)
 }
+
+// NoopPrefixer leaves the messages as is, for the intakes getting the API
+// key another way, like the HTTP one from a header
+var NoopPrefixer Prefixer = &noopPrefixer{}
+
+type noopPrefixer struct{}
+
+func (p *noopPrefixer) prefix(content []byte) []byte {
+	return content
+}
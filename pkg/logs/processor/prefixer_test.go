@@ -24,3 +24,7 @@ func TestApiKeyPrefixerScope(t *testing.T) {
 	assert.Equal(t, []byte("foo/bar baz"), prefixer.prefix([]byte("baz")))
 
 }
+
+func TestNoopPrefixer(t *testing.T) {
+	assert.Equal(t, []byte("message"), NoopPrefixer.prefix([]byte("message")))
+}
@@ -9,27 +9,41 @@ import (
 	log "github.com/cihub/seelog"
 
 	"github.com/DataDog/datadog-agent/pkg/logs/config"
+	"github.com/DataDog/datadog-agent/pkg/logs/diagnostic"
 	"github.com/DataDog/datadog-agent/pkg/logs/message"
+	"github.com/DataDog/datadog-agent/pkg/util/scrubber"
 )
 
-// A Processor updates messages from an inputChan and pushes
+// A Processor updates messages from an input ring and pushes
 // in an outputChan.
 type Processor struct {
-	inputChan  chan message.Message
-	outputChan chan message.Message
-	encoder    Encoder
-	prefixer   Prefixer
-	done       chan struct{}
+	input           *message.Ring
+	outputChan      chan message.Message
+	encoder         Encoder
+	prefixer        Prefixer
+	processingRules []config.LogsProcessingRule
+	// scrubbers redact the sensitive data of the messages before they
+	// leave the host
+	scrubbers []scrubber.Replacer
+	// autoJSONDetection promotes the attributes of the JSON logs of all
+	// the sources
+	autoJSONDetection bool
+	done              chan struct{}
 }
 
-// New returns an initialized Processor.
-func New(inputChan, outputChan chan message.Message, encoder Encoder, prefixer Prefixer) *Processor {
+// New returns an initialized Processor, the processingRules are applied to
+// all the messages before the rules of their source, then the scrubbers to
+// their content and attributes.
+func New(input *message.Ring, outputChan chan message.Message, encoder Encoder, prefixer Prefixer, processingRules []config.LogsProcessingRule, scrubbers []scrubber.Replacer) *Processor {
 	return &Processor{
-		inputChan:  inputChan,
-		outputChan: outputChan,
-		encoder:    encoder,
-		prefixer:   prefixer,
-		done:       make(chan struct{}),
+		input:             input,
+		outputChan:        outputChan,
+		encoder:           encoder,
+		prefixer:          prefixer,
+		processingRules:   processingRules,
+		scrubbers:         scrubbers,
+		autoJSONDetection: config.LogsAgent.GetBool("logs_config.auto_json_detection"),
+		done:              make(chan struct{}),
 	}
 }
 
@@ -39,50 +53,134 @@ func (p *Processor) Start() {
 }
 
 // Stop stops the Processor,
-// this call blocks until inputChan is flushed
+// this call blocks until the input ring is flushed
 func (p *Processor) Stop() {
-	close(p.inputChan)
+	p.input.Close()
 	<-p.done
 }
 
-// run starts the processing of the inputChan
+// run processes the messages of the input ring by batches,
+// until the ring is closed and drained
 func (p *Processor) run() {
 	defer func() {
 		p.done <- struct{}{}
 	}()
-	for msg := range p.inputChan {
-		if shouldProcess, redactedMsg := applyRedactingRules(msg); shouldProcess {
-			// Encode the message to its final format
-			content, err := p.encoder.encode(msg, redactedMsg)
-			if err != nil {
-				log.Error("unable to encode msg ", err)
-				continue
-			}
-			// Prefix the message with the API key
-			content = p.prefixer.prefix(content)
-			msg.SetContent(content)
-			p.outputChan <- msg
+	batch := make([]message.Message, 0, config.BatchSize)
+	for {
+		batch = p.input.PopBatch(batch)
+		if len(batch) == 0 {
+			return
+		}
+		for i, msg := range batch {
+			p.process(msg)
+			batch[i] = nil
+		}
+	}
+}
+
+// process applies the processing rules to a message and forwards it to
+// the outputChan, the dropped messages are released
+func (p *Processor) process(msg message.Message) {
+	// only keep track of the applied rules when someone streams the logs
+	traceRules := diagnostic.IsEnabled()
+	shouldProcess, redactedMsg, appliedRules := applyProcessingRules(msg, p.processingRules, traceRules)
+	if traceRules {
+		content := redactedMsg
+		if !shouldProcess {
+			content = msg.Content()
 		}
+		diagnostic.HandleMessage(msg, content, appliedRules, !shouldProcess)
+	}
+	if !shouldProcess {
+		msg.GetOrigin().LogSource.Metrics.AddMessagesDropped(1)
+		message.Release(msg)
+		return
+	}
+	if p.autoJSONDetection || msg.GetOrigin().LogSource.Config.AutoJSONDetection {
+		redactedMsg = promoteJSONAttributes(msg, redactedMsg)
+	}
+	if len(p.scrubbers) > 0 {
+		redactedMsg = scrubMessage(msg, redactedMsg, p.scrubbers)
+	}
+	// Encode the message to its final format
+	content, err := p.encoder.encode(msg, redactedMsg)
+	if err != nil {
+		log.Error("unable to encode msg ", err)
+		msg.GetOrigin().LogSource.Metrics.AddMessagesDropped(1)
+		message.Release(msg)
+		return
+	}
+	// Prefix the message with the API key
+	content = p.prefixer.prefix(content)
+	msg.SetContent(content)
+	p.outputChan <- msg
+}
+
+// scrubMessage returns the content of a message with its sensitive data
+// redacted, the promoted attributes of the message are redacted as well
+func scrubMessage(msg message.Message, content []byte, scrubbers []scrubber.Replacer) []byte {
+	attributes := msg.GetAttributes()
+	if attributes.Status != "" {
+		attributes.Status = string(scrubber.Apply([]byte(attributes.Status), scrubbers))
+	}
+	if attributes.Service != "" {
+		attributes.Service = string(scrubber.Apply([]byte(attributes.Service), scrubbers))
 	}
+	return scrubber.Apply(content, scrubbers)
 }
 
 // applyRedactingRules returns given a message if we should process it or not,
 // and a copy of the message with some fields redacted, depending on config
 func applyRedactingRules(msg message.Message) (bool, []byte) {
+	shouldProcess, content, _ := applyProcessingRules(msg, nil, false)
+	return shouldProcess, content
+}
+
+// applyProcessingRules works like applyRedactingRules with the globalRules
+// applied before the rules of the source, and also returns the rules which
+// matched the message when traceRules is set
+func applyProcessingRules(msg message.Message, globalRules []config.LogsProcessingRule, traceRules bool) (bool, []byte, []string) {
+	var appliedRules []string
 	content := msg.Content()
-	for _, rule := range msg.GetOrigin().LogSource.Config.ProcessingRules {
+	rules := globalRules
+	if sourceRules := msg.GetOrigin().LogSource.Config.ProcessingRules; len(sourceRules) > 0 {
+		rules = append(append(make([]config.LogsProcessingRule, 0, len(globalRules)+len(sourceRules)), globalRules...), sourceRules...)
+	}
+	for _, rule := range rules {
 		switch rule.Type {
 		case config.ExcludeAtMatch:
 			if rule.Reg.Match(content) {
-				return false, nil
+				return false, nil, appendRule(appliedRules, traceRules, rule)
 			}
 		case config.IncludeAtMatch:
 			if !rule.Reg.Match(content) {
-				return false, nil
+				return false, nil, appendRule(appliedRules, traceRules, rule)
+			}
+			appliedRules = appendRule(appliedRules, traceRules, rule)
+		case config.SampleAtMatch:
+			if rule.Reg.Match(content) {
+				if !rule.Sample() {
+					return false, nil, appendRule(appliedRules, traceRules, rule)
+				}
+				appliedRules = appendRule(appliedRules, traceRules, rule)
 			}
 		case config.MaskSequences:
+			if traceRules && rule.Reg.Match(content) {
+				appliedRules = appendRule(appliedRules, traceRules, rule)
+			}
 			content = rule.Reg.ReplaceAllLiteral(content, rule.ReplacePlaceholderBytes)
 		}
 	}
-	return true, content
+	return true, content, appliedRules
+}
+
+// appendRule adds the type and the name of the rule to the applied rules
+func appendRule(appliedRules []string, traceRules bool, rule config.LogsProcessingRule) []string {
+	if !traceRules {
+		return appliedRules
+	}
+	if rule.Name == "" {
+		return append(appliedRules, rule.Type)
+	}
+	return append(appliedRules, rule.Type+":"+rule.Name)
 }
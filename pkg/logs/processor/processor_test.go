@@ -11,6 +11,7 @@ import (
 
 	"github.com/DataDog/datadog-agent/pkg/logs/config"
 	"github.com/DataDog/datadog-agent/pkg/logs/message"
+	"github.com/DataDog/datadog-agent/pkg/util/scrubber"
 	"github.com/stretchr/testify/assert"
 )
 
@@ -140,6 +141,35 @@ func TestMask(t *testing.T) {
 	assert.Equal(t, []byte("The credit card [masked_credit_card] was used to buy some time"), redactedMessage)
 }
 
+func TestSampling(t *testing.T) {
+	var shouldProcess bool
+
+	source := buildTestConfigLogSource("sample_at_match", "", "DEBUG")
+	source.Config.ProcessingRules[0].SampleRate = 1
+	shouldProcess, _ = applyRedactingRules(newMessage([]byte("DEBUG hello"), &source, nil))
+	assert.Equal(t, true, shouldProcess)
+
+	source.Config.ProcessingRules[0].Limiter = config.NewRateLimiter(1, 1)
+	shouldProcess, _ = applyRedactingRules(newMessage([]byte("DEBUG hello"), &source, nil))
+	assert.Equal(t, true, shouldProcess)
+	shouldProcess, _ = applyRedactingRules(newMessage([]byte("DEBUG world"), &source, nil))
+	assert.Equal(t, false, shouldProcess)
+	// the lines which don't match are never sampled
+	shouldProcess, _ = applyRedactingRules(newMessage([]byte("INFO world"), &source, nil))
+	assert.Equal(t, true, shouldProcess)
+
+	// about sample_rate of the matching lines are kept
+	source = buildTestConfigLogSource("sample_at_match", "", "DEBUG")
+	source.Config.ProcessingRules[0].SampleRate = 0.1
+	kept := 0
+	for i := 0; i < 10000; i++ {
+		if shouldProcess, _ = applyRedactingRules(newMessage([]byte("DEBUG hello"), &source, nil)); shouldProcess {
+			kept++
+		}
+	}
+	assert.InDelta(t, 1000, kept, 200)
+}
+
 func TestTruncate(t *testing.T) {
 
 	source := config.NewLogSource("", &config.LogsConfig{})
@@ -148,3 +178,96 @@ func TestTruncate(t *testing.T) {
 	_, redactedMessage = applyRedactingRules(newMessage([]byte("hello"), source, nil))
 	assert.Equal(t, []byte("hello"), redactedMessage)
 }
+
+func TestAppliedRules(t *testing.T) {
+	source := buildTestConfigLogSource("mask_sequences", "[masked]", "secret")
+	source.Config.ProcessingRules = append(source.Config.ProcessingRules, config.LogsProcessingRule{
+		Type: "exclude_at_match",
+		Reg:  regexp.MustCompile("debug"),
+	})
+
+	shouldProcess, redactedMessage, appliedRules := applyProcessingRules(newMessage([]byte("hello"), &source, nil), nil, true)
+	assert.Equal(t, true, shouldProcess)
+	assert.Equal(t, []byte("hello"), redactedMessage)
+	assert.Empty(t, appliedRules)
+
+	shouldProcess, redactedMessage, appliedRules = applyProcessingRules(newMessage([]byte("my secret"), &source, nil), nil, true)
+	assert.Equal(t, true, shouldProcess)
+	assert.Equal(t, []byte("my [masked]"), redactedMessage)
+	assert.Equal(t, []string{"mask_sequences:test"}, appliedRules)
+
+	shouldProcess, _, appliedRules = applyProcessingRules(newMessage([]byte("debug secret"), &source, nil), nil, true)
+	assert.Equal(t, false, shouldProcess)
+	assert.Equal(t, []string{"mask_sequences:test", "exclude_at_match"}, appliedRules)
+
+	// the rules are not tracked by default
+	_, _, appliedRules = applyProcessingRules(newMessage([]byte("debug secret"), &source, nil), nil, false)
+	assert.Nil(t, appliedRules)
+}
+
+func TestGlobalProcessingRules(t *testing.T) {
+	source := buildTestConfigLogSource("mask_sequences", "[masked]", "secret")
+	globalRules := []config.LogsProcessingRule{{
+		Type: "exclude_at_match",
+		Name: "global",
+		Reg:  regexp.MustCompile("debug"),
+	}, {
+		Type:                    "mask_sequences",
+		Name:                    "global",
+		ReplacePlaceholderBytes: []byte("[password]"),
+		Reg:                     regexp.MustCompile("password=\\w+"),
+	}}
+
+	shouldProcess, redactedMessage, appliedRules := applyProcessingRules(newMessage([]byte("debug"), &source, nil), globalRules, true)
+	assert.Equal(t, false, shouldProcess)
+	assert.Equal(t, []string{"exclude_at_match:global"}, appliedRules)
+
+	// the global rules are applied before the ones of the source
+	shouldProcess, redactedMessage, appliedRules = applyProcessingRules(newMessage([]byte("password=secret"), &source, nil), globalRules, true)
+	assert.Equal(t, true, shouldProcess)
+	assert.Equal(t, []byte("[password]"), redactedMessage)
+	assert.Equal(t, []string{"mask_sequences:global"}, appliedRules)
+
+	shouldProcess, redactedMessage, appliedRules = applyProcessingRules(newMessage([]byte("my secret"), &source, nil), globalRules, true)
+	assert.Equal(t, true, shouldProcess)
+	assert.Equal(t, []byte("my [masked]"), redactedMessage)
+	assert.Equal(t, []string{"mask_sequences:test"}, appliedRules)
+
+	// the rules of the source are left as is
+	assert.Len(t, source.Config.ProcessingRules, 1)
+}
+
+// redactedEncoder encodes a message to its redacted content
+type redactedEncoder struct{}
+
+func (e redactedEncoder) encode(msg message.Message, redactedMsg []byte) ([]byte, error) {
+	return redactedMsg, nil
+}
+
+func TestProcessorRun(t *testing.T) {
+	source := buildTestConfigLogSource("exclude_at_match", "", "debug")
+	input := message.NewRing(config.RingSize)
+	outputChan := make(chan message.Message, 10)
+	p := New(input, outputChan, redactedEncoder{}, NewAPIKeyPrefixer("key", ""), nil, nil)
+	p.Start()
+
+	for _, content := range []string{"hello", "debug", "world"} {
+		input.Push(newMessage([]byte(content), &source, nil))
+	}
+	// stopping the processor flushes the input ring
+	p.Stop()
+
+	assert.Len(t, outputChan, 2)
+	assert.Equal(t, "key hello", string((<-outputChan).Content()))
+	assert.Equal(t, "key world", string((<-outputChan).Content()))
+}
+
+func TestScrubMessage(t *testing.T) {
+	source := config.NewLogSource("", &config.LogsConfig{})
+	scrubbers := []scrubber.Replacer{{Regex: regexp.MustCompile(`secret`), Repl: []byte("[redacted]")}}
+	msg := newMessage([]byte("my secret"), source, nil)
+	msg.GetAttributes().Service = "secret-service"
+	assert.Equal(t, "my [redacted]", string(scrubMessage(msg, msg.Content(), scrubbers)))
+	assert.Equal(t, "[redacted]-service", msg.GetAttributes().Service)
+	assert.Equal(t, "", msg.GetAttributes().Status)
+}
@@ -16,3 +16,9 @@ func Start(components ...Startable) {
 		component.Start()
 	}
 }
+
+// Restartable represents a startable and stoppable object
+type Restartable interface {
+	Startable
+	Stoppable
+}
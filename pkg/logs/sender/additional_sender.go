@@ -0,0 +1,115 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2018 Datadog, Inc.
+
+package sender
+
+import (
+	"time"
+
+	log "github.com/cihub/seelog"
+
+	"github.com/DataDog/datadog-agent/pkg/logs/metrics"
+)
+
+// additionalBufferSize is the number of payloads an AdditionalSender holds
+// while its destination is unreachable, the next ones are dropped
+const additionalBufferSize = 100
+
+// An AdditionalSender sends copies of the payloads of a sender to an
+// additional destination, from its own goroutine and with its own retries
+// not to slow down the main destination, the payloads are dropped when it
+// falls behind
+type AdditionalSender struct {
+	name        string
+	destination destination
+	metrics     *metrics.DestinationMetrics
+	payloads    chan []byte
+	retries     int
+	stop        chan struct{}
+}
+
+// NewAdditionalSender returns an initialized AdditionalSender, name is the
+// address of the destination
+func NewAdditionalSender(destination destination, name string) *AdditionalSender {
+	return &AdditionalSender{
+		name:        name,
+		destination: destination,
+		metrics:     metrics.GetDestination(name),
+		payloads:    make(chan []byte, additionalBufferSize),
+		stop:        make(chan struct{}),
+	}
+}
+
+// Start starts the AdditionalSender
+func (s *AdditionalSender) Start() {
+	go s.run()
+}
+
+// Stop stops the AdditionalSender, the payloads not sent yet are dropped
+func (s *AdditionalSender) Stop() {
+	close(s.stop)
+}
+
+// Forward queues a copy of a payload, it is dropped when the queue is full,
+// the payload must not be modified afterwards
+func (s *AdditionalSender) Forward(payload []byte) {
+	select {
+	case s.payloads <- payload:
+	default:
+		s.metrics.AddPayloadDropped()
+	}
+}
+
+// run sends the payloads until stop
+func (s *AdditionalSender) run() {
+	for {
+		select {
+		case payload := <-s.payloads:
+			s.send(payload)
+		case <-s.stop:
+			return
+		}
+	}
+}
+
+// after is replaced in the tests not to wait between the retries
+var after = time.After
+
+// send sends a payload, retrying until it is accepted or rejected by the
+// intake, or until stop
+func (s *AdditionalSender) send(payload []byte) {
+	for {
+		err := s.destination.Send(payload)
+		if err == nil {
+			s.retries = 0
+			return
+		}
+		if _, ok := err.(*errClient); ok {
+			log.Errorf("Dropping logs sent to %s: %s", s.name, err)
+			return
+		}
+		s.retries++
+		log.Warnf("Can't send logs to %s, retrying: %s", s.name, err)
+		select {
+		case <-after(backoffDuration(s.retries)):
+		case <-s.stop:
+			return
+		}
+	}
+}
+
+// startAdditionalSenders starts all the additional senders
+func startAdditionalSenders(additionals []*AdditionalSender) {
+	for _, additional := range additionals {
+		additional.Start()
+	}
+}
+
+// stopAdditionalSenders stops all the additional senders
+func stopAdditionalSenders(additionals []*AdditionalSender) {
+	for _, additional := range additionals {
+		additional.Stop()
+	}
+}
@@ -0,0 +1,92 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2018 Datadog, Inc.
+
+package sender
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/DataDog/datadog-agent/pkg/logs/message"
+	"github.com/DataDog/datadog-agent/pkg/logs/metrics"
+)
+
+func TestAdditionalSenderRetries(t *testing.T) {
+	defer func(a func(time.Duration) <-chan time.Time) { after = a }(after)
+	after = func(time.Duration) <-chan time.Time { return time.After(0) }
+
+	d := &mockDestination{errs: []error{errors.New("unavailable")}}
+	s := NewAdditionalSender(d, "additional-retries:443")
+	s.send([]byte("a"))
+	assert.Equal(t, []string{"a"}, d.sent())
+
+	// the payloads rejected by the intake are not sent again
+	d.errs = []error{&errClient{statusCode: 403}}
+	s.send([]byte("b"))
+	assert.Equal(t, []string{"a"}, d.sent())
+
+	// the retries are interrupted by stop
+	d.errs = []error{errors.New("unavailable")}
+	after = func(time.Duration) <-chan time.Time { return nil }
+	s.Stop()
+	s.send([]byte("c"))
+	assert.Equal(t, []string{"a"}, d.sent())
+}
+
+func TestAdditionalSenderDropsPayloadsWhenFallingBehind(t *testing.T) {
+	d := &mockDestination{}
+	s := NewAdditionalSender(d, "additional-drops:443")
+	dropped := payloadsDropped("additional-drops:443")
+	for i := 0; i < additionalBufferSize+10; i++ {
+		s.Forward([]byte("{}"))
+	}
+	assert.Len(t, s.payloads, additionalBufferSize)
+	assert.Equal(t, dropped+10, payloadsDropped("additional-drops:443"))
+
+	// the payloads are sent once the destination is reachable
+	s.Start()
+	defer s.Stop()
+	for i := 0; i < 1000 && len(d.sent()) < additionalBufferSize; i++ {
+		time.Sleep(time.Millisecond)
+	}
+	assert.Len(t, d.sent(), additionalBufferSize)
+}
+
+func TestBatchSenderForwardsBatchesToAdditionalSenders(t *testing.T) {
+	main := &mockDestination{errs: []error{&errClient{statusCode: 400}}}
+	additional := &mockDestination{}
+	additionalSender := NewAdditionalSender(additional, "additional-batches:443")
+	input := make(chan message.Message, 2)
+	output := make(chan message.Message, 2)
+	s := NewBatchSender(input, output, main, []*AdditionalSender{additionalSender}, time.Hour)
+	s.batch = append(s.batch, message.New([]byte(`{"message":"a"}`), nil, nil))
+	s.sendBatch()
+
+	// the batches rejected by the main intake are sent anyway
+	assert.Len(t, main.sent(), 0)
+	assert.Equal(t, []string{`[{"message":"a"}]`}, drain(additionalSender))
+}
+
+// drain returns the payloads queued by an additional sender
+func drain(s *AdditionalSender) []string {
+	var payloads []string
+	for len(s.payloads) > 0 {
+		payloads = append(payloads, string(<-s.payloads))
+	}
+	return payloads
+}
+
+// payloadsDropped returns the number of payloads dropped for a destination
+func payloadsDropped(name string) int64 {
+	for _, stats := range metrics.GetDestinationStats() {
+		if stats.Name == name {
+			return stats.PayloadsDropped
+		}
+	}
+	return 0
+}
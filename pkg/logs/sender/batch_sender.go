@@ -0,0 +1,157 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2018 Datadog, Inc.
+
+package sender
+
+import (
+	"time"
+
+	log "github.com/cihub/seelog"
+
+	"github.com/DataDog/datadog-agent/pkg/logs/message"
+)
+
+// Limits of the batches of the HTTP intake
+const (
+	maxBatchSize        = 200
+	maxBatchContentSize = 1000000
+)
+
+// destination sends a payload to the intake
+type destination interface {
+	Send(payload []byte) error
+}
+
+// A BatchSender sends the messages from an inputChan to datadog's HTTP
+// intake by batches, sent when they are full or every batchWait, retrying
+// until they are accepted, copies of the batches are sent to the additional
+// destinations
+type BatchSender struct {
+	inputChan   chan message.Message
+	outputChan  chan message.Message
+	destination destination
+	additionals []*AdditionalSender
+	batchWait   time.Duration
+	batch       []message.Message
+	contentSize int
+	retries     int
+	done        chan struct{}
+}
+
+// NewBatchSender returns an initialized BatchSender
+func NewBatchSender(inputChan, outputChan chan message.Message, destination destination, additionals []*AdditionalSender, batchWait time.Duration) *BatchSender {
+	return &BatchSender{
+		inputChan:   inputChan,
+		outputChan:  outputChan,
+		destination: destination,
+		additionals: additionals,
+		batchWait:   batchWait,
+		done:        make(chan struct{}),
+	}
+}
+
+// Start starts the BatchSender
+func (s *BatchSender) Start() {
+	startAdditionalSenders(s.additionals)
+	go s.run()
+}
+
+// Stop stops the BatchSender,
+// this call blocks until inputChan is flushed
+func (s *BatchSender) Stop() {
+	close(s.inputChan)
+	<-s.done
+	stopAdditionalSenders(s.additionals)
+}
+
+// run adds the messages to the batch, and sends it when it's full, every
+// batchWait or once inputChan is closed
+func (s *BatchSender) run() {
+	flushTicker := time.NewTicker(s.batchWait)
+	defer func() {
+		flushTicker.Stop()
+		s.done <- struct{}{}
+	}()
+	for {
+		select {
+		case msg, isOpen := <-s.inputChan:
+			if !isOpen {
+				s.sendBatch()
+				return
+			}
+			if s.contentSize+len(msg.Content()) > maxBatchContentSize {
+				s.sendBatch()
+			}
+			s.batch = append(s.batch, msg)
+			s.contentSize += len(msg.Content())
+			if len(s.batch) >= maxBatchSize {
+				s.sendBatch()
+			}
+		case <-flushTicker.C:
+			s.sendBatch()
+		}
+	}
+}
+
+// sendBatch sends the batch as a JSON array of the messages, which are
+// already encoded as JSON objects, and forwards them once sent or rejected
+// by the intake
+func (s *BatchSender) sendBatch() {
+	if len(s.batch) == 0 {
+		return
+	}
+	payload := make([]byte, 0, s.contentSize+len(s.batch)+1)
+	payload = append(payload, '[')
+	for i, msg := range s.batch {
+		if i > 0 {
+			payload = append(payload, ',')
+		}
+		payload = append(payload, msg.Content()...)
+	}
+	payload = append(payload, ']')
+
+	for _, additional := range s.additionals {
+		additional.Forward(payload)
+	}
+	for {
+		err := s.destination.Send(payload)
+		if err == nil {
+			s.retries = 0
+			break
+		}
+		if _, ok := err.(*errClient); ok {
+			log.Errorf("Dropping %d logs: %s", len(s.batch), err)
+			break
+		}
+		s.retries++
+		log.Warnf("Can't send %d logs, retrying: %s", len(s.batch), err)
+		s.backoff()
+	}
+
+	for i, msg := range s.batch {
+		s.outputChan <- msg
+		s.batch[i] = nil
+	}
+	s.batch = s.batch[:0]
+	s.contentSize = 0
+}
+
+// sleep is replaced in the tests not to wait between the retries
+var sleep = time.Sleep
+
+// backoff lets the sender sleep a bit longer after each failure
+func (s *BatchSender) backoff() {
+	sleep(backoffDuration(s.retries))
+}
+
+// backoffDuration returns the time to wait after a number of failures in a
+// row, longer after each one
+func backoffDuration(retries int) time.Duration {
+	backoffDuration := backoffSleepTimeUnit * retries
+	if backoffDuration > maxBackoffSleepTime {
+		backoffDuration = maxBackoffSleepTime
+	}
+	return time.Second * time.Duration(backoffDuration)
+}
@@ -0,0 +1,119 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2018 Datadog, Inc.
+
+package sender
+
+import (
+	"errors"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/DataDog/datadog-agent/pkg/logs/message"
+)
+
+type mockDestination struct {
+	mutex    sync.Mutex
+	payloads []string
+	errs     []error
+}
+
+func (d *mockDestination) Send(payload []byte) error {
+	d.mutex.Lock()
+	defer d.mutex.Unlock()
+	if len(d.errs) > 0 {
+		err := d.errs[0]
+		d.errs = d.errs[1:]
+		return err
+	}
+	d.payloads = append(d.payloads, string(payload))
+	return nil
+}
+
+func (d *mockDestination) sent() []string {
+	d.mutex.Lock()
+	defer d.mutex.Unlock()
+	return append([]string{}, d.payloads...)
+}
+
+func newBatchSender(d destination, batchWait time.Duration) (*BatchSender, chan message.Message, chan message.Message) {
+	input := make(chan message.Message, maxBatchSize+1)
+	output := make(chan message.Message, maxBatchSize+1)
+	return NewBatchSender(input, output, d, nil, batchWait), input, output
+}
+
+func TestBatchSenderSendsBatchOnStop(t *testing.T) {
+	d := &mockDestination{}
+	s, input, output := newBatchSender(d, time.Hour)
+	s.Start()
+	input <- message.New([]byte(`{"message":"a"}`), nil, nil)
+	input <- message.New([]byte(`{"message":"b"}`), nil, nil)
+	s.Stop()
+
+	assert.Equal(t, []string{`[{"message":"a"},{"message":"b"}]`}, d.sent())
+	assert.Len(t, output, 2)
+}
+
+func TestBatchSenderSendsBatchEveryBatchWait(t *testing.T) {
+	d := &mockDestination{}
+	s, input, output := newBatchSender(d, 10*time.Millisecond)
+	s.Start()
+	defer s.Stop()
+	input <- message.New([]byte(`{"message":"a"}`), nil, nil)
+
+	msg := <-output
+	assert.Equal(t, `{"message":"a"}`, string(msg.Content()))
+	assert.Equal(t, []string{`[{"message":"a"}]`}, d.sent())
+}
+
+func TestBatchSenderSendsFullBatches(t *testing.T) {
+	d := &mockDestination{}
+	s, input, output := newBatchSender(d, time.Hour)
+	s.Start()
+	for i := 0; i < maxBatchSize+1; i++ {
+		input <- message.New([]byte("{}"), nil, nil)
+	}
+	for i := 0; i < maxBatchSize; i++ {
+		<-output
+	}
+	assert.Equal(t, []string{"[" + strings.Repeat("{},", maxBatchSize-1) + "{}]"}, d.sent())
+
+	s.Stop()
+	assert.Len(t, d.sent(), 2)
+
+	// the batches don't exceed maxBatchContentSize
+	d = &mockDestination{}
+	s, input, _ = newBatchSender(d, time.Hour)
+	s.Start()
+	input <- message.New(make([]byte, maxBatchContentSize-1), nil, nil)
+	input <- message.New(make([]byte, 2), nil, nil)
+	s.Stop()
+	assert.Len(t, d.sent(), 2)
+}
+
+func TestBatchSenderRetries(t *testing.T) {
+	defer func(s func(time.Duration)) { sleep = s }(sleep)
+	sleep = func(time.Duration) {}
+
+	d := &mockDestination{errs: []error{errors.New("unavailable"), errors.New("unavailable")}}
+	s, input, output := newBatchSender(d, time.Hour)
+	s.Start()
+	input <- message.New([]byte("{}"), nil, nil)
+	s.Stop()
+	assert.Equal(t, []string{"[{}]"}, d.sent())
+	assert.Len(t, output, 1)
+
+	// the payloads rejected by the intake are not sent again
+	d = &mockDestination{errs: []error{&errClient{statusCode: 400}}}
+	s, input, output = newBatchSender(d, time.Hour)
+	s.Start()
+	input <- message.New([]byte("{}"), nil, nil)
+	s.Stop()
+	assert.Len(t, d.sent(), 0)
+	assert.Len(t, output, 1)
+}
@@ -14,6 +14,8 @@ import (
 	"time"
 
 	log "github.com/cihub/seelog"
+
+	"github.com/DataDog/datadog-agent/pkg/logs/metrics"
 )
 
 const (
@@ -31,15 +33,20 @@ type ConnectionManager struct {
 	mutex   sync.Mutex
 	retries int
 
+	// metrics counts the payloads sent to the intake
+	metrics *metrics.DestinationMetrics
+
 	firstConn bool
 }
 
 // NewConnectionManager returns an initialized ConnectionManager
 func NewConnectionManager(serverName string, serverPort int, devModeNoSSL bool) *ConnectionManager {
+	connectionString := fmt.Sprintf("%s:%d", serverName, serverPort)
 	return &ConnectionManager{
-		connectionString: fmt.Sprintf("%s:%d", serverName, serverPort),
+		connectionString: connectionString,
 		serverName:       serverName,
 		devModeNoSSL:     devModeNoSSL,
+		metrics:          metrics.GetDestination(connectionString),
 
 		mutex: sync.Mutex{},
 
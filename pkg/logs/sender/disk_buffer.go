@@ -0,0 +1,151 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2018 Datadog, Inc.
+
+package sender
+
+import (
+	"time"
+
+	log "github.com/cihub/seelog"
+
+	"github.com/DataDog/datadog-agent/pkg/logs/message"
+)
+
+// prunePeriod is the period at which the segments already sent are deleted
+const prunePeriod = 10 * time.Second
+
+// registry returns the cursors committed once the messages have been sent
+type registry interface {
+	GetLastCommittedOffset(identifier string) string
+}
+
+// A DiskBuffer forwards the messages of an inputChan to a sender, and spills
+// them to disk while the sender can't keep up, e.g. when the intake is
+// unreachable. The spilled messages are acknowledged to the auditor once on
+// disk and replayed to the sender in order, at least once: the cursor of the
+// replayed messages is committed by the auditor once they have been sent, so
+// that the ones not sent yet are replayed again after a restart.
+type DiskBuffer struct {
+	inputChan  chan message.Message
+	senderChan chan message.Message
+	outputChan chan message.Message
+	queue      *diskQueue
+	registry   registry
+	identifier string
+	stop       chan struct{}
+	done       chan struct{}
+}
+
+// NewDiskBuffer returns a DiskBuffer storing at most maxSize bytes of
+// messages in path, between inputChan and the senderChan of a sender
+func NewDiskBuffer(inputChan, senderChan, outputChan chan message.Message, path string, maxSize int64, registry registry) *DiskBuffer {
+	return &DiskBuffer{
+		inputChan:  inputChan,
+		senderChan: senderChan,
+		outputChan: outputChan,
+		queue:      newDiskQueue(path, maxSize),
+		registry:   registry,
+		identifier: "disk_buffer:" + path,
+		stop:       make(chan struct{}),
+		done:       make(chan struct{}),
+	}
+}
+
+// Start starts the DiskBuffer, replaying the messages left on disk
+func (b *DiskBuffer) Start() {
+	if err := b.queue.open(b.registry.GetLastCommittedOffset(b.identifier)); err != nil {
+		log.Warnf("Can't open the disk buffer %s, the messages won't be spilled to disk: %s", b.queue.path, err)
+		go b.forward()
+		return
+	}
+	go b.replay()
+	go b.run()
+}
+
+// Stop stops the DiskBuffer,
+// this call blocks until inputChan is flushed, the messages not sent yet stay on disk
+func (b *DiskBuffer) Stop() {
+	close(b.inputChan)
+	<-b.done
+	close(b.stop)
+	<-b.done
+	b.queue.close()
+}
+
+// forward sends the messages straight to the sender
+func (b *DiskBuffer) forward() {
+	for msg := range b.inputChan {
+		b.senderChan <- msg
+	}
+	b.done <- struct{}{}
+	<-b.stop
+	b.done <- struct{}{}
+}
+
+// run sends the messages to the sender when it's ready and nothing is left
+// on disk, and spills them to disk otherwise
+func (b *DiskBuffer) run() {
+	defer func() {
+		b.done <- struct{}{}
+	}()
+	for msg := range b.inputChan {
+		if b.queue.empty() {
+			select {
+			case b.senderChan <- msg:
+				continue
+			default:
+			}
+		}
+		if err := b.queue.push(msg.Content()); err != nil {
+			// the disk is full, fall back to waiting for the sender
+			log.Debugf("Can't spill a message to %s: %s", b.queue.path, err)
+			b.senderChan <- msg
+			continue
+		}
+		// the message is safe on disk, its offset can be committed
+		b.outputChan <- msg
+	}
+}
+
+// replay sends the messages of the disk to the sender
+func (b *DiskBuffer) replay() {
+	pruneTicker := time.NewTicker(prunePeriod)
+	defer func() {
+		pruneTicker.Stop()
+		b.done <- struct{}{}
+	}()
+	for {
+		select {
+		case <-pruneTicker.C:
+			b.prune()
+		default:
+		}
+		content, cursor, ok := b.queue.pop()
+		if !ok {
+			select {
+			case <-b.queue.notify:
+			case <-pruneTicker.C:
+				b.prune()
+			case <-b.stop:
+				return
+			}
+			continue
+		}
+		origin := message.NewOrigin(nil)
+		origin.Identifier = b.identifier
+		origin.Offset = cursor
+		select {
+		case b.senderChan <- message.New(content, origin, nil):
+		case <-b.stop:
+			// the message is replayed again after a restart
+			return
+		}
+	}
+}
+
+// prune deletes the segments of the messages which have been sent
+func (b *DiskBuffer) prune() {
+	b.queue.prune(b.registry.GetLastCommittedOffset(b.identifier))
+}
@@ -0,0 +1,54 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2018 Datadog, Inc.
+
+package sender
+
+import (
+	"io/ioutil"
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/DataDog/datadog-agent/pkg/logs/message"
+)
+
+type mockRegistry struct {
+	offset string
+}
+
+func (r *mockRegistry) GetLastCommittedOffset(identifier string) string {
+	return r.offset
+}
+
+func TestDiskBufferSpillsAndReplays(t *testing.T) {
+	path, err := ioutil.TempDir("", "disk-buffer")
+	require.NoError(t, err)
+	defer os.RemoveAll(path)
+
+	inputChan := make(chan message.Message)
+	senderChan := make(chan message.Message)
+	outputChan := make(chan message.Message, 10)
+	b := NewDiskBuffer(inputChan, senderChan, outputChan, path, 1024, &mockRegistry{})
+	b.Start()
+
+	// nobody reads senderChan, the messages are spilled and acknowledged
+	inputChan <- message.New([]byte("a"), message.NewOrigin(nil), nil)
+	inputChan <- message.New([]byte("b"), message.NewOrigin(nil), nil)
+	assert.Equal(t, "a", string((<-outputChan).Content()))
+	assert.Equal(t, "b", string((<-outputChan).Content()))
+
+	// they are replayed in order once the sender is back
+	msg := <-senderChan
+	assert.Equal(t, "a", string(msg.Content()))
+	assert.Equal(t, "disk_buffer:"+path, msg.GetOrigin().Identifier)
+	assert.Equal(t, "0:5", msg.GetOrigin().Offset)
+	msg = <-senderChan
+	assert.Equal(t, "b", string(msg.Content()))
+	assert.Equal(t, "0:10", msg.GetOrigin().Offset)
+
+	b.Stop()
+}
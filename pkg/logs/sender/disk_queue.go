@@ -0,0 +1,265 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2018 Datadog, Inc.
+
+package sender
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+const (
+	// maxSegmentSize is the size from which a new segment file is started
+	maxSegmentSize = 10 * 1024 * 1024
+	segmentSuffix  = ".segment"
+	// a record is the length of the content, on 4 bytes, followed by the content
+	recordHeaderSize = 4
+)
+
+// errDiskQueueFull is returned when a content would exceed the maximum
+// size of the queue
+var errDiskQueueFull = errors.New("the disk buffer is full")
+
+// a segment is a file of the queue, the contents are appended to the last one
+type segment struct {
+	id   int
+	size int64
+}
+
+// diskQueue stores contents in segment files, read back in the order they
+// were written, the segments are deleted once the cursors committed after
+// reading them are past them
+type diskQueue struct {
+	path     string
+	maxSize  int64
+	mu       sync.Mutex
+	segments []*segment
+	size     int64
+	writer   *os.File
+	reader   *os.File
+	// the segments before readIndex have been read, they are kept until the
+	// cursors past them are committed
+	readIndex int
+	readPos   int64
+	// notify is signaled when a content is pushed
+	notify chan struct{}
+}
+
+// newDiskQueue returns a diskQueue storing at most maxSize bytes in path
+func newDiskQueue(path string, maxSize int64) *diskQueue {
+	return &diskQueue{
+		path:    path,
+		maxSize: maxSize,
+		notify:  make(chan struct{}, 1),
+	}
+}
+
+// open loads the segments left by a previous run, deleting the ones before
+// cursor which have already been sent, and starts a new segment to write to
+func (q *diskQueue) open(cursor string) error {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	if err := os.MkdirAll(q.path, 0755); err != nil {
+		return err
+	}
+	files, err := ioutil.ReadDir(q.path)
+	if err != nil {
+		return err
+	}
+	cursorID, cursorPos := parseCursor(cursor)
+	for _, file := range files {
+		if !strings.HasSuffix(file.Name(), segmentSuffix) {
+			continue
+		}
+		id, err := strconv.Atoi(strings.TrimSuffix(file.Name(), segmentSuffix))
+		if err != nil {
+			continue
+		}
+		if id < cursorID {
+			os.Remove(q.segmentPath(id))
+			continue
+		}
+		q.segments = append(q.segments, &segment{id: id, size: file.Size()})
+		q.size += file.Size()
+	}
+	sort.Slice(q.segments, func(i, j int) bool { return q.segments[i].id < q.segments[j].id })
+	if len(q.segments) > 0 && q.segments[0].id == cursorID {
+		q.readPos = cursorPos
+	}
+
+	nextID := 0
+	if len(q.segments) > 0 {
+		nextID = q.segments[len(q.segments)-1].id + 1
+	}
+	return q.startSegment(nextID)
+}
+
+// close closes the files of the queue, the unread contents stay on disk
+func (q *diskQueue) close() {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	if q.writer != nil {
+		q.writer.Close()
+		q.writer = nil
+	}
+	if q.reader != nil {
+		q.reader.Close()
+		q.reader = nil
+	}
+}
+
+// empty returns true when all the contents have been read
+func (q *diskQueue) empty() bool {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	return q.readIndex == len(q.segments)-1 && q.readPos >= q.segments[q.readIndex].size
+}
+
+// push appends a content to the last segment
+func (q *diskQueue) push(content []byte) error {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	recordSize := int64(recordHeaderSize + len(content))
+	if q.size+recordSize > q.maxSize {
+		return errDiskQueueFull
+	}
+	last := q.segments[len(q.segments)-1]
+	if last.size >= maxSegmentSize {
+		if err := q.startSegment(last.id + 1); err != nil {
+			return err
+		}
+		last = q.segments[len(q.segments)-1]
+	}
+	record := make([]byte, recordSize)
+	binary.BigEndian.PutUint32(record, uint32(len(content)))
+	copy(record[recordHeaderSize:], content)
+	if _, err := q.writer.Write(record); err != nil {
+		return err
+	}
+	last.size += recordSize
+	q.size += recordSize
+	select {
+	case q.notify <- struct{}{}:
+	default:
+	}
+	return nil
+}
+
+// pop returns the next content and the cursor to commit once it has been
+// sent, ok is false when all the contents have been read
+func (q *diskQueue) pop() (content []byte, cursor string, ok bool) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	for {
+		current := q.segments[q.readIndex]
+		if q.readPos >= current.size {
+			if q.readIndex == len(q.segments)-1 {
+				return nil, "", false
+			}
+			q.nextReadSegment()
+			continue
+		}
+		if q.reader == nil {
+			reader, err := os.Open(q.segmentPath(current.id))
+			if err != nil {
+				q.nextReadSegment()
+				continue
+			}
+			if _, err := reader.Seek(q.readPos, io.SeekStart); err != nil {
+				reader.Close()
+				q.nextReadSegment()
+				continue
+			}
+			q.reader = reader
+		}
+		header := make([]byte, recordHeaderSize)
+		if _, err := io.ReadFull(q.reader, header); err != nil {
+			// a record was partially written before a crash
+			q.readPos = current.size
+			continue
+		}
+		content = make([]byte, binary.BigEndian.Uint32(header))
+		if _, err := io.ReadFull(q.reader, content); err != nil {
+			q.readPos = current.size
+			continue
+		}
+		q.readPos += int64(recordHeaderSize + len(content))
+		return content, formatCursor(current.id, q.readPos), true
+	}
+}
+
+// prune deletes the segments entirely before cursor
+func (q *diskQueue) prune(cursor string) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	cursorID, _ := parseCursor(cursor)
+	for q.readIndex > 0 && q.segments[0].id < cursorID {
+		os.Remove(q.segmentPath(q.segments[0].id))
+		q.size -= q.segments[0].size
+		q.segments = q.segments[1:]
+		q.readIndex--
+	}
+}
+
+// nextReadSegment moves the reader to the next segment
+func (q *diskQueue) nextReadSegment() {
+	if q.reader != nil {
+		q.reader.Close()
+		q.reader = nil
+	}
+	q.readIndex++
+	q.readPos = 0
+}
+
+// startSegment creates a new segment to write to
+func (q *diskQueue) startSegment(id int) error {
+	writer, err := os.OpenFile(q.segmentPath(id), os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0600)
+	if err != nil {
+		return err
+	}
+	if q.writer != nil {
+		q.writer.Close()
+	}
+	q.writer = writer
+	q.segments = append(q.segments, &segment{id: id})
+	return nil
+}
+
+// segmentPath returns the path of the file of a segment
+func (q *diskQueue) segmentPath(id int) string {
+	return filepath.Join(q.path, fmt.Sprintf("%010d%s", id, segmentSuffix))
+}
+
+// formatCursor returns the position after a record
+func formatCursor(id int, pos int64) string {
+	return fmt.Sprintf("%d:%d", id, pos)
+}
+
+// parseCursor returns the segment and the position of a cursor, the
+// beginning of the queue when the cursor is empty or invalid
+func parseCursor(cursor string) (int, int64) {
+	parts := strings.Split(cursor, ":")
+	if len(parts) != 2 {
+		return 0, 0
+	}
+	id, err := strconv.Atoi(parts[0])
+	if err != nil {
+		return 0, 0
+	}
+	pos, err := strconv.ParseInt(parts[1], 10, 64)
+	if err != nil {
+		return 0, 0
+	}
+	return id, pos
+}
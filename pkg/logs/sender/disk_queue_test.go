@@ -0,0 +1,82 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2018 Datadog, Inc.
+
+package sender
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDiskQueuePushPop(t *testing.T) {
+	path, err := ioutil.TempDir("", "disk-queue")
+	require.NoError(t, err)
+	defer os.RemoveAll(path)
+
+	q := newDiskQueue(path, 1024)
+	require.NoError(t, q.open(""))
+	defer q.close()
+	assert.True(t, q.empty())
+
+	assert.NoError(t, q.push([]byte("a")))
+	assert.NoError(t, q.push([]byte("bb")))
+	assert.False(t, q.empty())
+
+	content, cursor, ok := q.pop()
+	assert.True(t, ok)
+	assert.Equal(t, "a", string(content))
+	assert.Equal(t, "0:5", cursor)
+	content, cursor, ok = q.pop()
+	assert.True(t, ok)
+	assert.Equal(t, "bb", string(content))
+	assert.Equal(t, "0:11", cursor)
+	_, _, ok = q.pop()
+	assert.False(t, ok)
+	assert.True(t, q.empty())
+
+	// the size of the queue is limited
+	assert.Equal(t, errDiskQueueFull, q.push(make([]byte, 1024)))
+}
+
+func TestDiskQueueResumesFromCursor(t *testing.T) {
+	path, err := ioutil.TempDir("", "disk-queue")
+	require.NoError(t, err)
+	defer os.RemoveAll(path)
+
+	q := newDiskQueue(path, 1024)
+	require.NoError(t, q.open(""))
+	assert.NoError(t, q.push([]byte("a")))
+	assert.NoError(t, q.push([]byte("b")))
+	q.close()
+
+	// the first message was sent before the restart
+	q = newDiskQueue(path, 1024)
+	require.NoError(t, q.open("0:5"))
+	defer q.close()
+	content, _, ok := q.pop()
+	assert.True(t, ok)
+	assert.Equal(t, "b", string(content))
+	_, _, ok = q.pop()
+	assert.False(t, ok)
+
+	// the new messages are written to a new segment
+	assert.NoError(t, q.push([]byte("c")))
+	content, cursor, ok := q.pop()
+	assert.True(t, ok)
+	assert.Equal(t, "c", string(content))
+	assert.Equal(t, "1:5", cursor)
+
+	// the segments are deleted once the cursors past them are committed
+	q.prune(cursor)
+	_, err = os.Stat(filepath.Join(path, "0000000000.segment"))
+	assert.True(t, os.IsNotExist(err))
+	_, err = os.Stat(filepath.Join(path, "0000000001.segment"))
+	assert.NoError(t, err)
+}
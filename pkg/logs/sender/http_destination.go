@@ -0,0 +1,110 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2018 Datadog, Inc.
+
+package sender
+
+import (
+	"bytes"
+	"compress/gzip"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+
+	"github.com/DataDog/datadog-agent/pkg/logs/metrics"
+	"github.com/DataDog/datadog-agent/pkg/util"
+)
+
+// errClient is returned when the intake rejects a payload, sending it
+// again would fail the same way
+type errClient struct {
+	statusCode int
+}
+
+func (e *errClient) Error() string {
+	return fmt.Sprintf("the payload was rejected by the intake: %d", e.statusCode)
+}
+
+// An HTTPDestination posts the batches of logs to the HTTP intake, through
+// the proxy of the agent when one is configured
+type HTTPDestination struct {
+	url         string
+	apiKey      string
+	compression bool
+	client      *http.Client
+	metrics     *metrics.DestinationMetrics
+}
+
+// NewHTTPDestination returns an initialized HTTPDestination
+func NewHTTPDestination(host string, port int, useSSL bool, apiKey string, compression bool) *HTTPDestination {
+	scheme := "https"
+	if !useSSL {
+		scheme = "http"
+	}
+	return &HTTPDestination{
+		url:         fmt.Sprintf("%s://%s:%d/v1/input", scheme, host, port),
+		metrics:     metrics.GetDestination(fmt.Sprintf("%s:%d", host, port)),
+		apiKey:      apiKey,
+		compression: compression,
+		client: &http.Client{
+			Transport: util.CreateHTTPTransport(),
+			Timeout:   timeout,
+		},
+	}
+}
+
+// Send posts a payload, a JSON array of logs, to the intake, the errors
+// but errClient are worth retrying
+func (d *HTTPDestination) Send(payload []byte) error {
+	body, err := d.encode(payload)
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequest("POST", d.url, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	// the API key is not part of the URL not to be logged with the errors
+	req.Header.Set("DD-API-KEY", d.apiKey)
+	req.Header.Set("Content-Type", "application/json")
+	if d.compression {
+		req.Header.Set("Content-Encoding", "gzip")
+	}
+
+	resp, err := d.client.Do(req)
+	if err != nil {
+		d.metrics.AddRetry()
+		return err
+	}
+	defer resp.Body.Close()
+	io.Copy(ioutil.Discard, resp.Body)
+
+	switch {
+	case resp.StatusCode >= 500, resp.StatusCode == http.StatusTooManyRequests:
+		d.metrics.AddRetry()
+		return fmt.Errorf("the intake is unavailable: %d", resp.StatusCode)
+	case resp.StatusCode >= 400:
+		d.metrics.AddPayloadDropped()
+		return &errClient{statusCode: resp.StatusCode}
+	}
+	d.metrics.AddPayloadSent(len(body))
+	return nil
+}
+
+// encode compresses the payload when the compression is enabled
+func (d *HTTPDestination) encode(payload []byte) ([]byte, error) {
+	if !d.compression {
+		return payload, nil
+	}
+	var buf bytes.Buffer
+	writer := gzip.NewWriter(&buf)
+	if _, err := writer.Write(payload); err != nil {
+		return nil, err
+	}
+	if err := writer.Close(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
@@ -0,0 +1,72 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2018 Datadog, Inc.
+
+package sender
+
+import (
+	"compress/gzip"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strconv"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newTestDestination(t *testing.T, handler http.HandlerFunc, compression bool) (*HTTPDestination, func()) {
+	server := httptest.NewServer(handler)
+	u, err := url.Parse(server.URL)
+	require.NoError(t, err)
+	port, err := strconv.Atoi(u.Port())
+	require.NoError(t, err)
+	return NewHTTPDestination(u.Hostname(), port, false, "myapikey", compression), server.Close
+}
+
+func TestHTTPDestinationSend(t *testing.T) {
+	var body string
+	var header http.Header
+	d, stop := newTestDestination(t, func(w http.ResponseWriter, r *http.Request) {
+		header = r.Header
+		assert.Equal(t, "/v1/input", r.URL.Path)
+		reader, err := gzip.NewReader(r.Body)
+		require.NoError(t, err)
+		content, err := ioutil.ReadAll(reader)
+		require.NoError(t, err)
+		body = string(content)
+	}, true)
+	defer stop()
+
+	assert.NoError(t, d.Send([]byte(`[{"message":"a"}]`)))
+	assert.Equal(t, `[{"message":"a"}]`, body)
+	assert.Equal(t, "myapikey", header.Get("DD-API-KEY"))
+	assert.Equal(t, "application/json", header.Get("Content-Type"))
+	assert.Equal(t, "gzip", header.Get("Content-Encoding"))
+}
+
+func TestHTTPDestinationErrors(t *testing.T) {
+	statusCode := http.StatusInternalServerError
+	d, stop := newTestDestination(t, func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(statusCode)
+	}, false)
+	defer stop()
+
+	err := d.Send([]byte("[]"))
+	assert.Error(t, err)
+	_, isClientError := err.(*errClient)
+	assert.False(t, isClientError)
+
+	statusCode = http.StatusTooManyRequests
+	err = d.Send([]byte("[]"))
+	_, isClientError = err.(*errClient)
+	assert.False(t, isClientError)
+
+	statusCode = http.StatusForbidden
+	err = d.Send([]byte("[]"))
+	_, isClientError = err.(*errClient)
+	assert.True(t, isClientError)
+}
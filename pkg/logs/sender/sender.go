@@ -13,22 +13,25 @@ import (
 )
 
 // A Sender sends messages from an inputChan to datadog's intake,
-// handling connections and retries.
+// handling connections and retries, copies of the messages are sent to the
+// additional destinations.
 type Sender struct {
 	inputChan   chan message.Message
 	outputChan  chan message.Message
 	connManager *ConnectionManager
+	additionals []*AdditionalSender
 	conn        net.Conn
 	delimiter   Delimiter
 	done        chan struct{}
 }
 
 // New returns an initialized Sender
-func New(inputChan, outputChan chan message.Message, connManager *ConnectionManager, delimiter Delimiter) *Sender {
+func New(inputChan, outputChan chan message.Message, connManager *ConnectionManager, additionals []*AdditionalSender, delimiter Delimiter) *Sender {
 	return &Sender{
 		inputChan:   inputChan,
 		outputChan:  outputChan,
 		connManager: connManager,
+		additionals: additionals,
 		delimiter:   delimiter,
 		done:        make(chan struct{}),
 	}
@@ -36,6 +39,7 @@ func New(inputChan, outputChan chan message.Message, connManager *ConnectionMana
 
 // Start starts the Sender
 func (s *Sender) Start() {
+	startAdditionalSenders(s.additionals)
 	go s.run()
 }
 
@@ -44,6 +48,7 @@ func (s *Sender) Start() {
 func (s *Sender) Stop() {
 	close(s.inputChan)
 	<-s.done
+	stopAdditionalSenders(s.additionals)
 }
 
 // run lets the sender wire messages
@@ -58,6 +63,9 @@ func (s *Sender) run() {
 
 // wireMessage lets the Sender send a message to datadog's intake
 func (s *Sender) wireMessage(payload message.Message) {
+	for _, additional := range s.additionals {
+		additional.Forward(payload.Content())
+	}
 	for {
 		if s.conn == nil {
 			s.conn = s.connManager.NewConnection() // blocks until a new conn is ready
@@ -69,10 +77,12 @@ func (s *Sender) wireMessage(payload message.Message) {
 		}
 		_, err = s.conn.Write(frame)
 		if err != nil {
+			s.connManager.metrics.AddRetry()
 			s.connManager.CloseConnection(s.conn)
 			s.conn = nil
 			continue
 		}
+		s.connManager.metrics.AddPayloadSent(len(frame))
 		s.outputChan <- payload
 		return
 	}
@@ -0,0 +1,59 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2018 Datadog, Inc.
+
+package sender
+
+import (
+	"bytes"
+	"net"
+
+	log "github.com/cihub/seelog"
+)
+
+// A TCPDestination sends the logs to a TCP intake over its own connection,
+// the API key of the main intake prefixing them is replaced by its own
+type TCPDestination struct {
+	connManager *ConnectionManager
+	delimiter   Delimiter
+	mainPrefix  []byte
+	prefix      []byte
+	conn        net.Conn
+}
+
+// NewTCPDestination returns an initialized TCPDestination, mainPrefix is
+// the prefix of the logs sent to the main intake, prefix the one of this
+// intake
+func NewTCPDestination(connManager *ConnectionManager, delimiter Delimiter, mainPrefix []byte, prefix []byte) *TCPDestination {
+	return &TCPDestination{
+		connManager: connManager,
+		delimiter:   delimiter,
+		mainPrefix:  mainPrefix,
+		prefix:      prefix,
+	}
+}
+
+// Send writes a log to the intake, it blocks until it is connected
+func (d *TCPDestination) Send(content []byte) error {
+	if d.conn == nil {
+		d.conn = d.connManager.NewConnection() // blocks until a new conn is ready
+	}
+	content = bytes.TrimPrefix(content, d.mainPrefix)
+	// the content is shared with the main sender, it is copied to be prefixed
+	prefixed := make([]byte, 0, len(d.prefix)+len(content)+1)
+	prefixed = append(append(prefixed, d.prefix...), content...)
+	frame, err := d.delimiter.delimit(prefixed)
+	if err != nil {
+		log.Error("can't send payload: ", err)
+		return nil
+	}
+	if _, err := d.conn.Write(frame); err != nil {
+		d.connManager.metrics.AddRetry()
+		d.connManager.CloseConnection(d.conn)
+		d.conn = nil
+		return err
+	}
+	d.connManager.metrics.AddPayloadSent(len(frame))
+	return nil
+}
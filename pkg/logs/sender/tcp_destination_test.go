@@ -0,0 +1,52 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2018 Datadog, Inc.
+
+package sender
+
+import (
+	"bufio"
+	"net"
+	"strconv"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTCPDestinationReplacesAPIKey(t *testing.T) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	defer listener.Close()
+	lines := make(chan string, 2)
+	go func() {
+		conn, err := listener.Accept()
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+		reader := bufio.NewReader(conn)
+		for {
+			line, err := reader.ReadString('\n')
+			if err != nil {
+				return
+			}
+			lines <- line
+		}
+	}()
+
+	host, port, err := net.SplitHostPort(listener.Addr().String())
+	require.NoError(t, err)
+	portNumber, err := strconv.Atoi(port)
+	require.NoError(t, err)
+	d := NewTCPDestination(NewConnectionManager(host, portNumber, true), NewDelimiter(false), []byte("mainkey "), []byte("anotherkey "))
+
+	content := []byte("mainkey hello")
+	assert.NoError(t, d.Send(content))
+	assert.Equal(t, "anotherkey hello\n", <-lines)
+	// the content shared with the main sender is left as is
+	assert.Equal(t, "mainkey hello", string(content))
+	assert.NoError(t, d.Send([]byte("world")))
+	assert.Equal(t, "anotherkey world\n", <-lines)
+}
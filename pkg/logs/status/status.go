@@ -6,7 +6,10 @@
 package status
 
 import (
+	"fmt"
+
 	"github.com/DataDog/datadog-agent/pkg/logs/config"
+	"github.com/DataDog/datadog-agent/pkg/logs/metrics"
 )
 
 var (
@@ -18,6 +21,17 @@ type Source struct {
 	Type   string   `json:"type"`
 	Status string   `json:"status"`
 	Inputs []string `json:"inputs"`
+	// RateLimited is the number of messages dropped by the rate limiter
+	RateLimited int64 `json:"rate_limited"`
+	// the counters of the logs of the source through the pipeline
+	BytesRead       int64 `json:"bytes_read"`
+	MessagesDecoded int64 `json:"messages_decoded"`
+	MessagesDropped int64 `json:"messages_dropped"`
+	MessagesSent    int64 `json:"messages_sent"`
+	// Lags are how far behind the end of their files the inputs are,
+	// Warnings report the ones which can't keep up
+	Lags     []metrics.InputLag `json:"lags"`
+	Warnings []string           `json:"warnings"`
 	// TCP, UDP
 	Port int `json:"port"`
 	// File
@@ -36,8 +50,9 @@ type Integration struct {
 
 // Status provides some information about logs-agent.
 type Status struct {
-	IsRunning    bool          `json:"is_running"`
-	Integrations []Integration `json:"integrations"`
+	IsRunning    bool                       `json:"is_running"`
+	Integrations []Integration              `json:"integrations"`
+	Destinations []metrics.DestinationStats `json:"destinations"`
 }
 
 // Builder is used to build the status.
@@ -75,15 +90,23 @@ func Get() Status {
 			} else if source.Status.IsError() {
 				status = source.Status.GetError()
 			}
+			lags, warnings := getLags(source)
 			sources = append(sources, Source{
-				Type:   source.Config.Type,
-				Status: status,
-				Inputs: source.GetInputs(),
-				Port:   source.Config.Port,
-				Path:   source.Config.Path,
-				Image:  source.Config.Image,
-				Label:  source.Config.Label,
-				Name:   source.Config.Name,
+				Type:            source.Config.Type,
+				Status:          status,
+				Inputs:          source.GetInputs(),
+				RateLimited:     source.RateLimiter.Dropped(),
+				BytesRead:       source.Metrics.BytesRead(),
+				MessagesDecoded: source.Metrics.MessagesDecoded(),
+				MessagesDropped: source.Metrics.MessagesDropped(),
+				MessagesSent:    source.Metrics.MessagesSent(),
+				Lags:            lags,
+				Warnings:        warnings,
+				Port:            source.Config.Port,
+				Path:            source.Config.Path,
+				Image:           source.Config.Image,
+				Label:           source.Config.Label,
+				Name:            source.Config.Name,
 			})
 		}
 		integrations = append(integrations, Integration{Name: name, Sources: sources})
@@ -91,5 +114,24 @@ func Get() Status {
 	return Status{
 		IsRunning:    true,
 		Integrations: integrations,
+		Destinations: metrics.GetDestinationStats(),
+	}
+}
+
+// getLags returns the lags of the inputs of a source, along with a warning
+// for each input falling further behind
+func getLags(source *config.LogSource) ([]metrics.InputLag, []string) {
+	var lags []metrics.InputLag
+	var warnings []string
+	for _, input := range source.GetInputs() {
+		lag := metrics.GetLag(input)
+		if lag == nil {
+			continue
+		}
+		lags = append(lags, *lag)
+		if lag.Growing {
+			warnings = append(warnings, fmt.Sprintf("The agent can't keep up with %s: %d bytes behind, for %ds", input, lag.BytesBehind, lag.SecondsBehind))
+		}
 	}
+	return lags, warnings
 }
@@ -8,9 +8,12 @@ package status
 import (
 	"fmt"
 	"testing"
+	"time"
 
 	"github.com/DataDog/datadog-agent/pkg/logs/config"
+	"github.com/DataDog/datadog-agent/pkg/logs/metrics"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
 func TestSourceAreGroupedByIntegrations(t *testing.T) {
@@ -35,3 +38,32 @@ func TestSourceAreGroupedByIntegrations(t *testing.T) {
 		}
 	}
 }
+
+func TestSourceMetricsAreReported(t *testing.T) {
+	source := config.NewLogSource("foo", &config.LogsConfig{})
+	source.Metrics.AddBytesRead(10)
+	source.Metrics.AddMessagesDecoded(2)
+	source.Metrics.AddMessagesDropped(1)
+	source.Metrics.AddMessagesSent(1)
+	Initialize([]*config.LogSource{source})
+	status := Get()
+	assert.Equal(t, 1, len(status.Integrations))
+	assert.Equal(t, int64(10), status.Integrations[0].Sources[0].BytesRead)
+	assert.Equal(t, int64(2), status.Integrations[0].Sources[0].MessagesDecoded)
+	assert.Equal(t, int64(1), status.Integrations[0].Sources[0].MessagesDropped)
+	assert.Equal(t, int64(1), status.Integrations[0].Sources[0].MessagesSent)
+}
+
+func TestSourceLagsAreReported(t *testing.T) {
+	source := config.NewLogSource("foo", &config.LogsConfig{})
+	source.AddInput("/var/log/foo.log")
+	defer metrics.RemoveLag("/var/log/foo.log")
+	for i := 1; i <= 3; i++ {
+		metrics.SetLag("/var/log/foo.log", int64(i*100), time.Duration(i)*time.Second)
+	}
+	Initialize([]*config.LogSource{source})
+	status := Get()
+	require.Equal(t, 1, len(status.Integrations[0].Sources[0].Lags))
+	assert.Equal(t, int64(300), status.Integrations[0].Sources[0].Lags[0].BytesBehind)
+	assert.Equal(t, []string{"The agent can't keep up with /var/log/foo.log: 300 bytes behind, for 3s"}, status.Integrations[0].Sources[0].Warnings)
+}
@@ -0,0 +1,15 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2018 Datadog, Inc.
+
+/*
+Package inventories implements the check metadata provider.
+
+The checks report metadata about the software they monitor, like the version
+or the flavor of a database, with `SetCheckMetadata`, or from python with
+`datadog_agent.set_check_metadata`. The provider keeps the last value of each
+key by check instance until the instance is unscheduled, and sends the whole
+inventory with the host metadata, grouped by check name.
+*/
+package inventories
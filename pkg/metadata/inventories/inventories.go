@@ -0,0 +1,71 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2018 Datadog, Inc.
+
+package inventories
+
+import (
+	"sync"
+	"time"
+)
+
+// checkMetadataCacheEntry holds the metadata reported by a check instance
+type checkMetadataCacheEntry struct {
+	CheckName   string
+	LastUpdated time.Time
+	Values      map[string]interface{}
+}
+
+var (
+	// checkMetadataCache maps check ID -> metadata
+	checkMetadataCache = make(map[string]*checkMetadataCacheEntry)
+	checkMetadataMutex = &sync.Mutex{}
+)
+
+// SetCheckMetadata stores a metadata value of a check instance, replacing the
+// previous value of the key
+func SetCheckMetadata(checkName, checkID, key string, value interface{}) {
+	checkMetadataMutex.Lock()
+	defer checkMetadataMutex.Unlock()
+
+	entry, found := checkMetadataCache[checkID]
+	if !found {
+		entry = &checkMetadataCacheEntry{
+			CheckName: checkName,
+			Values:    make(map[string]interface{}),
+		}
+		checkMetadataCache[checkID] = entry
+	}
+	entry.LastUpdated = time.Now()
+	entry.Values[key] = value
+}
+
+// RemoveCheckMetadata forgets the metadata of a check instance, once it is
+// unscheduled
+func RemoveCheckMetadata(checkID string) {
+	checkMetadataMutex.Lock()
+	defer checkMetadataMutex.Unlock()
+
+	delete(checkMetadataCache, checkID)
+}
+
+// GetPayload fills and returns the check metadata payload. Unlike the
+// external host tags, the cache is kept between the collections.
+func GetPayload() *Payload {
+	checkMetadataMutex.Lock()
+	defer checkMetadataMutex.Unlock()
+
+	payload := make(Payload)
+	for checkID, entry := range checkMetadataCache {
+		instance := CheckInstanceMetadata{
+			"config.hash":  checkID,
+			"last_updated": entry.LastUpdated.UnixNano(),
+		}
+		for key, value := range entry.Values {
+			instance[key] = value
+		}
+		payload[entry.CheckName] = append(payload[entry.CheckName], instance)
+	}
+	return &payload
+}
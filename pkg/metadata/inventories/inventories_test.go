@@ -0,0 +1,50 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2018 Datadog, Inc.
+
+package inventories
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGetPayload(t *testing.T) {
+	defer RemoveCheckMetadata("postgres:1")
+	defer RemoveCheckMetadata("postgres:2")
+
+	// empty cache, empty payload
+	assert.Len(t, *GetPayload(), 0)
+
+	SetCheckMetadata("postgres", "postgres:1", "version", "9.6.10")
+	SetCheckMetadata("postgres", "postgres:1", "version", "10.5")
+	SetCheckMetadata("postgres", "postgres:1", "flavor", "aurora")
+	SetCheckMetadata("postgres", "postgres:2", "version", "11.0")
+
+	p := *GetPayload()
+	require.Len(t, p["postgres"], 2)
+	for _, instance := range p["postgres"] {
+		assert.Contains(t, instance, "last_updated")
+		switch instance["config.hash"] {
+		case "postgres:1":
+			assert.Equal(t, "10.5", instance["version"])
+			assert.Equal(t, "aurora", instance["flavor"])
+		case "postgres:2":
+			assert.Equal(t, "11.0", instance["version"])
+			assert.NotContains(t, instance, "flavor")
+		default:
+			t.Errorf("unexpected instance %v", instance)
+		}
+	}
+
+	// the cache is kept between the collections
+	assert.Len(t, (*GetPayload())["postgres"], 2)
+
+	RemoveCheckMetadata("postgres:1")
+	p = *GetPayload()
+	require.Len(t, p["postgres"], 1)
+	assert.Equal(t, "postgres:2", p["postgres"][0]["config.hash"])
+}
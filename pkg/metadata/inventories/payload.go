@@ -0,0 +1,24 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2018 Datadog, Inc.
+
+package inventories
+
+/*
+The payload looks like this for two instances of the `postgres` check:
+
+check_metadata = {
+	"postgres": [
+		{"config.hash": "postgres:a3f5c7e9", "last_updated": 1539000000000000000, "version": "10.5"},
+		{"config.hash": "postgres:b8e2d4f1", "last_updated": 1539000000000000000, "version": "9.6.10"}
+	]
+}
+*/
+
+// CheckInstanceMetadata maps the metadata keys of a check instance to their
+// value, `config.hash` being the ID of the instance
+type CheckInstanceMetadata map[string]interface{}
+
+// Payload maps the check names to the metadata of their instances
+type Payload map[string][]CheckInstanceMetadata
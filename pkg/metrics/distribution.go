@@ -3,8 +3,6 @@
 // This product includes software developed at Datadog (https://www.datadoghq.com/).
 // Copyright 2018 Datadog, Inc.
 
-// NOTE: This file contains a feature in development that is NOT supported.
-
 package metrics
 
 import (
@@ -26,8 +24,16 @@ func NewDistribution() *Distribution {
 }
 
 func (d *Distribution) addSample(sample *MetricSample, timestamp float64) {
-	// Insert sample value into the sketch
-	d.sketch = d.sketch.Add(sample.Value)
+	rate := sample.SampleRate
+	if rate == 0 {
+		rate = 1
+	}
+
+	// Insert sample value into the sketch as many times as its weight, so
+	// that the sampled values count like the histogram ones
+	for i := int64(0); i < int64(1/rate); i++ {
+		d.sketch = d.sketch.Add(sample.Value)
+	}
 	d.count++
 }
 
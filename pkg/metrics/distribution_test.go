@@ -45,3 +45,25 @@ func TestDistributionSampling(t *testing.T) {
 	_, err = distro.flush(20)
 	assert.NotNil(t, err)
 }
+
+func TestDistributionSampleRate(t *testing.T) {
+	distro := NewDistribution()
+
+	// a sample sent at a rate of 0.5 weighs 2 samples
+	distro.addSample(&MetricSample{Value: 1, Mtype: DistributionType, SampleRate: 0.5}, 10)
+	distro.addSample(&MetricSample{Value: 10, Mtype: DistributionType, SampleRate: 1}, 11)
+
+	sketchSeries, err := distro.flush(15)
+	assert.Nil(t, err)
+
+	expectedSketch := percentile.NewGKArray()
+	expectedSketch = expectedSketch.Add(1)
+	expectedSketch = expectedSketch.Add(1)
+	expectedSketch = expectedSketch.Add(10)
+	expectedSeries := &percentile.SketchSeries{
+		Sketches: []percentile.Sketch{{Timestamp: int64(15), Sketch: expectedSketch}},
+	}
+
+	AssertSketchSeriesEqual(t, expectedSeries, sketchSeries)
+	assert.Equal(t, int64(3), sketchSeries.Sketches[0].Sketch.Count)
+}
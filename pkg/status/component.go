@@ -0,0 +1,87 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2018 Datadog, Inc.
+
+package status
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// components maps each component of the status to the keys of the status
+// payload it's built from
+var components = map[string][]string{
+	"autodiscovery": {"autoConfigStats"},
+	"collector":     {"runnerStats", "autoConfigStats"},
+	"dogstatsd":     {"aggregatorStats"},
+	"forwarder":     {"forwarderStats"},
+	"jmxfetch":      {"JMXStatus"},
+	"logs-agent":    {"logsStats"},
+}
+
+// ComponentNames returns the sorted names of the status components
+func ComponentNames() []string {
+	names := make([]string, 0, len(components))
+	for name := range components {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// GetComponent returns the part of a json status payload describing a
+// single component
+func GetComponent(data []byte, name string) ([]byte, error) {
+	keys, found := components[name]
+	if !found {
+		return nil, fmt.Errorf("unknown status component %q, valid components are: %s", name, strings.Join(ComponentNames(), ", "))
+	}
+
+	stats := make(map[string]json.RawMessage)
+	if err := json.Unmarshal(data, &stats); err != nil {
+		return nil, err
+	}
+	component := make(map[string]json.RawMessage, len(keys))
+	for _, key := range keys {
+		if value, found := stats[key]; found {
+			component[key] = value
+		}
+	}
+	return json.Marshal(component)
+}
+
+// FormatComponent takes a json status payload and prints out the formatted
+// status of a single component
+func FormatComponent(data []byte, name string) (string, error) {
+	if _, found := components[name]; !found {
+		return "", fmt.Errorf("unknown status component %q, valid components are: %s", name, strings.Join(ComponentNames(), ", "))
+	}
+
+	var b = new(bytes.Buffer)
+	stats := make(map[string]interface{})
+	if err := json.Unmarshal(data, &stats); err != nil {
+		return "", err
+	}
+
+	switch name {
+	case "autodiscovery":
+		renderAutodiscoveryStatus(b, stats["autoConfigStats"])
+	case "collector":
+		renderChecksStats(b, stats["runnerStats"], stats["autoConfigStats"], "")
+	case "dogstatsd":
+		renderDogstatsdStatus(b, stats["aggregatorStats"])
+	case "forwarder":
+		renderForwarderStatus(b, stats["forwarderStats"])
+	case "jmxfetch":
+		renderJMXFetchStatus(b, stats["JMXStatus"])
+	case "logs-agent":
+		renderLogsStatus(b, stats["logsStats"])
+	}
+
+	return b.String(), nil
+}
@@ -0,0 +1,39 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2018 Datadog, Inc.
+
+package status
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGetComponent(t *testing.T) {
+	data := []byte(`{"version":"6.2.0","forwarderStats":{"Transactions":{"Success":3}},"runnerStats":{"Runs":2},"autoConfigStats":{"ConfigErrors":{}}}`)
+
+	component, err := GetComponent(data, "forwarder")
+	require.NoError(t, err)
+	assert.JSONEq(t, `{"forwarderStats":{"Transactions":{"Success":3}}}`, string(component))
+
+	component, err = GetComponent(data, "collector")
+	require.NoError(t, err)
+	assert.JSONEq(t, `{"runnerStats":{"Runs":2},"autoConfigStats":{"ConfigErrors":{}}}`, string(component))
+
+	// missing keys are skipped
+	component, err = GetComponent(data, "logs-agent")
+	require.NoError(t, err)
+	assert.JSONEq(t, `{}`, string(component))
+
+	_, err = GetComponent(data, "foo")
+	assert.Error(t, err)
+	_, err = FormatComponent(data, "foo")
+	assert.Error(t, err)
+}
+
+func TestComponentNames(t *testing.T) {
+	assert.Equal(t, []string{"autodiscovery", "collector", "dogstatsd", "forwarder", "jmxfetch", "logs-agent"}, ComponentNames())
+}
@@ -5,11 +5,21 @@
 
 package health
 
+import (
+	"time"
+)
+
 var globalCatalog = newCatalog()
 
-// Register a component with the default 30 seconds timeout, returns a token
+// Register a component with the default deadline, returns a token
 func Register(name string) *Handle {
-	return globalCatalog.register(name)
+	return globalCatalog.register(name, DefaultDeadline)
+}
+
+// RegisterWithDeadline registers a component which can stall for up to
+// deadline before failing the liveness of the agent, returns a token
+func RegisterWithDeadline(name string, deadline time.Duration) *Handle {
+	return globalCatalog.register(name, deadline)
 }
 
 // Deregister a component from the healthcheck
@@ -14,6 +14,10 @@ import (
 var pingFrequency = 15 * time.Second
 var bufferSize = 2
 
+// DefaultDeadline is how long a component can stall before failing the
+// liveness of the agent, unless registered with another deadline
+const DefaultDeadline = 2 * time.Minute
+
 // Handle holds the token and the channel for components to use
 type Handle struct {
 	C <-chan struct{}
@@ -28,6 +32,10 @@ type component struct {
 	name       string
 	healthChan chan struct{}
 	healthy    bool
+	deadline   time.Duration
+	// latestPong is the latest ping found read by the component,
+	// or its registration time
+	latestPong time.Time
 }
 
 type catalog struct {
@@ -43,8 +51,8 @@ func newCatalog() *catalog {
 	}
 }
 
-// register a component with the default 30 seconds timeout, returns a token
-func (c *catalog) register(name string) *Handle {
+// register a component with the given deadline, returns a token
+func (c *catalog) register(name string, deadline time.Duration) *Handle {
 	c.Lock()
 	defer c.Unlock()
 
@@ -56,6 +64,8 @@ func (c *catalog) register(name string) *Handle {
 		name:       name,
 		healthChan: make(chan struct{}, bufferSize),
 		healthy:    false,
+		deadline:   deadline,
+		latestPong: time.Now(),
 	}
 	h := &Handle{
 		C: component.healthChan,
@@ -91,15 +101,17 @@ func (c *catalog) run() {
 // pingComponents is the actual pinging logic, separated for unit tests
 // lock is handled by the parent run method
 func (c *catalog) pingComponents() {
+	now := time.Now()
 	for _, component := range c.components {
 		select {
 		case component.healthChan <- struct{}{}:
 			component.healthy = true
+			component.latestPong = now
 		default:
 			component.healthy = false
 		}
 	}
-	c.latestRun = time.Now()
+	c.latestRun = now
 }
 
 // deregister a component from the healthcheck
@@ -119,6 +131,35 @@ func (c *catalog) deregister(handle *Handle) error {
 type Status struct {
 	Healthy   []string
 	Unhealthy []string
+	// Stalled is how long each unhealthy component hasn't read its channel
+	Stalled map[string]time.Duration `json:",omitempty" yaml:",omitempty"`
+	// Expired lists the unhealthy components stalled for longer than their
+	// deadline, failing the liveness of the agent
+	Expired []string `json:",omitempty" yaml:",omitempty"`
+}
+
+// Ready returns whether all the components are healthy
+func (s Status) Ready() bool {
+	return len(s.Unhealthy) == 0
+}
+
+// Live returns whether no component is stalled past its deadline
+func (s Status) Live() bool {
+	return len(s.Expired) == 0
+}
+
+func (s *Status) addUnhealthy(name string, stalled, deadline time.Duration) {
+	s.Unhealthy = append(s.Unhealthy, name)
+	if s.Stalled == nil {
+		s.Stalled = make(map[string]time.Duration)
+	}
+	// keep the longest stall of the components sharing a name
+	if stalled > s.Stalled[name] {
+		s.Stalled[name] = stalled
+	}
+	if stalled > deadline {
+		s.Expired = append(s.Expired, name)
+	}
 }
 
 // getStatus allows to query the health status of the agent
@@ -126,10 +167,11 @@ func (c *catalog) getStatus() Status {
 	status := Status{}
 	c.RLock()
 	defer c.RUnlock()
+	now := time.Now()
 
 	// Test the checker itself
-	if time.Now().After(c.latestRun.Add(2 * pingFrequency)) {
-		status.Unhealthy = append(status.Unhealthy, "healthcheck")
+	if now.After(c.latestRun.Add(2 * pingFrequency)) {
+		status.addUnhealthy("healthcheck", now.Sub(c.latestRun), DefaultDeadline)
 	} else {
 		status.Healthy = append(status.Healthy, "healthcheck")
 	}
@@ -139,7 +181,7 @@ func (c *catalog) getStatus() Status {
 		if component.healthy {
 			status.Healthy = append(status.Healthy, component.name)
 		} else {
-			status.Unhealthy = append(status.Unhealthy, component.name)
+			status.addUnhealthy(component.name, now.Sub(component.latestPong), component.deadline)
 		}
 	}
 	return status
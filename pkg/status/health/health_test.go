@@ -39,7 +39,7 @@ func TestCatalogGetsUnhealthyAndBack(t *testing.T) {
 
 func TestRegisterAndUnhealthy(t *testing.T) {
 	cat := newCatalog()
-	token := cat.register("test1")
+	token := cat.register("test1", DefaultDeadline)
 
 	_, found := cat.components[token]
 	require.True(t, found)
@@ -52,16 +52,16 @@ func TestRegisterAndUnhealthy(t *testing.T) {
 
 func TestRegisterTriplets(t *testing.T) {
 	cat := newCatalog()
-	cat.register("triplet")
-	cat.register("triplet")
-	cat.register("triplet")
+	cat.register("triplet", DefaultDeadline)
+	cat.register("triplet", DefaultDeadline)
+	cat.register("triplet", DefaultDeadline)
 	assert.Len(t, cat.components, 3)
 }
 
 func TestDeregister(t *testing.T) {
 	cat := newCatalog()
-	token1 := cat.register("test1")
-	token2 := cat.register("test2")
+	token1 := cat.register("test1", DefaultDeadline)
+	token2 := cat.register("test2", DefaultDeadline)
 
 	assert.Len(t, cat.components, 2)
 
@@ -73,7 +73,7 @@ func TestDeregister(t *testing.T) {
 
 func TestDeregisterBadToken(t *testing.T) {
 	cat := newCatalog()
-	token1 := cat.register("test1")
+	token1 := cat.register("test1", DefaultDeadline)
 
 	assert.Len(t, cat.components, 1)
 
@@ -85,7 +85,7 @@ func TestDeregisterBadToken(t *testing.T) {
 
 func TestGetHealthy(t *testing.T) {
 	cat := newCatalog()
-	token := cat.register("test1")
+	token := cat.register("test1", DefaultDeadline)
 
 	status := cat.getStatus()
 	assert.Len(t, status.Healthy, 1)
@@ -103,7 +103,7 @@ func TestGetHealthy(t *testing.T) {
 
 func TestUnhealthyAndBack(t *testing.T) {
 	cat := newCatalog()
-	token := cat.register("test1")
+	token := cat.register("test1", DefaultDeadline)
 
 	status := cat.getStatus()
 	assert.Len(t, status.Healthy, 1)
@@ -126,3 +126,32 @@ func TestUnhealthyAndBack(t *testing.T) {
 	assert.Len(t, status.Healthy, 2)
 	assert.Len(t, status.Unhealthy, 0)
 }
+
+func TestStalledAndExpired(t *testing.T) {
+	cat := newCatalog()
+	token1 := cat.register("test1", time.Minute)
+	token2 := cat.register("test2", time.Hour)
+
+	status := cat.getStatus()
+	assert.True(t, status.Live())
+	assert.False(t, status.Ready())
+	assert.Len(t, status.Stalled, 2)
+
+	// both components have been stalled since their registration
+	cat.components[token1].latestPong = time.Now().Add(-10 * time.Minute)
+	cat.components[token2].latestPong = time.Now().Add(-10 * time.Minute)
+	status = cat.getStatus()
+	assert.Equal(t, []string{"test1"}, status.Expired)
+	assert.False(t, status.Live())
+	assert.True(t, status.Stalled["test2"] >= 10*time.Minute)
+
+	// test1 reads its channel again
+	<-token1.C
+	<-token1.C
+	cat.pingComponents()
+	status = cat.getStatus()
+	assert.True(t, status.Live())
+	assert.Equal(t, []string{"test2"}, status.Unhealthy)
+	_, found := status.Stalled["test1"]
+	assert.False(t, found)
+}
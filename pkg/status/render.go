@@ -39,11 +39,16 @@ func FormatStatus(data []byte) (string, error) {
 	aggregatorStats := stats["aggregatorStats"]
 	jmxStats := stats["JMXStatus"]
 	logsStats := stats["logsStats"]
+	pythonInterpreterStats, hasPython := stats["pythonInterpreterStats"]
 	title := fmt.Sprintf("Agent (v%s)", stats["version"])
 	stats["title"] = title
 	renderHeader(b, stats)
 	renderChecksStats(b, runnerStats, autoConfigStats, "")
+	renderAutodiscoveryStatus(b, autoConfigStats)
 	renderJMXFetchStatus(b, jmxStats)
+	if hasPython {
+		renderPythonInterpreterStatus(b, pythonInterpreterStats)
+	}
 	renderForwarderStatus(b, forwarderStats)
 	renderLogsStatus(b, logsStats)
 	renderDogstatsdStatus(b, aggregatorStats)
@@ -64,6 +69,7 @@ func FormatDCAStatus(data []byte) (string, error) {
 	stats["title"] = title
 	renderHeader(b, stats)
 	renderChecksStats(b, runnerStats, autoConfigStats, "")
+	renderAutodiscoveryStatus(b, autoConfigStats)
 	renderForwarderStatus(b, forwarderStats)
 
 	return b.String(), nil
@@ -151,6 +157,24 @@ func renderLogsStatus(w io.Writer, logsStats interface{}) {
 	}
 }
 
+func renderPythonInterpreterStatus(w io.Writer, pythonInterpreterStats interface{}) {
+	t := template.Must(template.New("python.tmpl").Funcs(fmap).ParseFiles(filepath.Join(templateFolder, "python.tmpl")))
+	err := t.Execute(w, pythonInterpreterStats)
+	if err != nil {
+		fmt.Println(err)
+	}
+}
+
+func renderAutodiscoveryStatus(w io.Writer, autoConfigStats interface{}) {
+	stats := make(map[string]interface{})
+	stats["AutoConfigStats"] = autoConfigStats
+	t := template.Must(template.New("autodiscovery.tmpl").Funcs(fmap).ParseFiles(filepath.Join(templateFolder, "autodiscovery.tmpl")))
+	err := t.Execute(w, stats)
+	if err != nil {
+		fmt.Println(err)
+	}
+}
+
 func renderMetadataMapper(w io.Writer, metadataMapperStats interface{}) {
 	t := template.Must(template.New("metadatamapper.tmpl").Funcs(fmap).ParseFiles(filepath.Join(templateFolder, "metadatamapper.tmpl")))
 	err := t.Execute(w, metadataMapperStats)
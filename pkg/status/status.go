@@ -123,7 +123,8 @@ func GetDCAStatus() (map[string]interface{}, error) {
 	}
 	now := time.Now()
 	stats["time"] = now.Format(timeFormat)
-	stats["leaderelection"] = getLeaderElectionDetails()
+	stats["leaderelection"] = GetLeaderElectionDetails()
+	stats["metadataMapper"] = getMetadataMapperDetails()
 
 	return stats, nil
 }
@@ -189,6 +190,13 @@ func expvarStats(stats map[string]interface{}) (map[string]interface{}, error) {
 	json.Unmarshal(aggregatorStatsJSON, &aggregatorStats)
 	stats["aggregatorStats"] = aggregatorStats
 
+	// only published by the agents embedding python
+	if pythonInterpreter := expvar.Get("pythonInterpreter"); pythonInterpreter != nil {
+		pythonInterpreterStats := make(map[string]interface{})
+		json.Unmarshal([]byte(pythonInterpreter.String()), &pythonInterpreterStats)
+		stats["pythonInterpreterStats"] = pythonInterpreterStats
+	}
+
 	if expvar.Get("ntpOffset").String() != "" {
 		stats["ntpOffset"], err = strconv.ParseFloat(expvar.Get("ntpOffset").String(), 64)
 	}
@@ -11,10 +11,13 @@ import (
 	"fmt"
 	"time"
 
+	"github.com/DataDog/datadog-agent/pkg/util/kubernetes/apiserver"
 	"github.com/DataDog/datadog-agent/pkg/util/kubernetes/apiserver/leaderelection"
 )
 
-func getLeaderElectionDetails() map[string]string {
+// GetLeaderElectionDetails returns the state of the leader election, used by
+// the status and the leader commands of the Cluster Agent
+func GetLeaderElectionDetails() map[string]string {
 	leaderElectionStats := make(map[string]string)
 
 	leaderElectionDetails, err := leaderelection.GetLeaderDetails()
@@ -30,3 +33,7 @@ func getLeaderElectionDetails() map[string]string {
 	leaderElectionStats["status"] = "Running"
 	return leaderElectionStats
 }
+
+func getMetadataMapperDetails() map[string]interface{} {
+	return apiserver.GetMetadataMapperStatus()
+}
@@ -11,7 +11,12 @@ import (
 	log "github.com/cihub/seelog"
 )
 
-func getLeaderElectionDetails() map[string]string {
+// GetLeaderElectionDetails is not implemented without the kubeapiserver build tag
+func GetLeaderElectionDetails() map[string]string {
 	log.Info("Not implemented")
 	return nil
 }
+
+func getMetadataMapperDetails() map[string]interface{} {
+	return nil
+}
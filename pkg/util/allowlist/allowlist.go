@@ -0,0 +1,117 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2018 Datadog, Inc.
+
+// Package allowlist filters the traffic received by the agent listeners by
+// its source address.
+package allowlist
+
+import (
+	"expvar"
+	"fmt"
+	"net"
+	"strings"
+	"sync/atomic"
+
+	log "github.com/cihub/seelog"
+)
+
+var allowlistExpvars = expvar.NewMap("allowlists")
+
+// Allowlist accepts the IPv4 and IPv6 addresses of a set of networks. A nil
+// Allowlist accepts every address.
+type Allowlist struct {
+	name     string
+	networks []*net.IPNet
+	rejected int64 // accessed atomically
+}
+
+// New returns an Allowlist of the networks in CIDR notation, the single
+// addresses are accepted as well. The rejections are counted under name in
+// the `allowlists` expvar. An empty list of networks returns a nil Allowlist.
+func New(name string, cidrs []string) (*Allowlist, error) {
+	var networks []*net.IPNet
+	for _, cidr := range cidrs {
+		cidr = strings.TrimSpace(cidr)
+		if cidr == "" {
+			continue
+		}
+		if !strings.Contains(cidr, "/") {
+			ip := net.ParseIP(cidr)
+			if ip == nil {
+				return nil, fmt.Errorf("invalid address %q", cidr)
+			}
+			bits := 8 * net.IPv6len
+			if ip.To4() != nil {
+				ip, bits = ip.To4(), 8*net.IPv4len
+			}
+			networks = append(networks, &net.IPNet{IP: ip, Mask: net.CIDRMask(bits, bits)})
+			continue
+		}
+		_, network, err := net.ParseCIDR(cidr)
+		if err != nil {
+			return nil, fmt.Errorf("invalid network %q: %s", cidr, err)
+		}
+		networks = append(networks, network)
+	}
+	if len(networks) == 0 {
+		return nil, nil
+	}
+	return &Allowlist{name: name, networks: networks}, nil
+}
+
+// Allowed returns whether the traffic from addr is accepted, and counts it as
+// rejected if it is not. The addresses without IP, like the ones of the unix
+// sockets, are accepted.
+func (a *Allowlist) Allowed(addr net.Addr) bool {
+	if a == nil {
+		return true
+	}
+	var ip net.IP
+	switch addr := addr.(type) {
+	case *net.UDPAddr:
+		ip = addr.IP
+	case *net.TCPAddr:
+		ip = addr.IP
+	case *net.IPAddr:
+		ip = addr.IP
+	default:
+		return true
+	}
+	if a.Contains(ip) {
+		return true
+	}
+	if atomic.AddInt64(&a.rejected, 1) == 1 {
+		log.Warnf("%s: rejecting the traffic from %s, not in the allowed sources", a.name, ip)
+	} else {
+		log.Debugf("%s: rejecting the traffic from %s", a.name, ip)
+	}
+	allowlistExpvars.Add(a.name, 1)
+	return false
+}
+
+// Contains returns whether ip is in one of the networks of the Allowlist
+func (a *Allowlist) Contains(ip net.IP) bool {
+	if a == nil {
+		return true
+	}
+	// the IPv4 addresses mapped in IPv6 match the IPv4 networks
+	if ip4 := ip.To4(); ip4 != nil {
+		ip = ip4
+	}
+	for _, network := range a.networks {
+		if network.Contains(ip) {
+			return true
+		}
+	}
+	return false
+}
+
+// Rejected returns the number of times traffic was rejected
+func (a *Allowlist) Rejected() int64 {
+	if a == nil {
+		return 0
+	}
+	return atomic.LoadInt64(&a.rejected)
+}
@@ -0,0 +1,57 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2018 Datadog, Inc.
+
+package allowlist
+
+import (
+	"net"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNew(t *testing.T) {
+	a, err := New("test", nil)
+	require.NoError(t, err)
+	assert.Nil(t, a)
+
+	a, err = New("test", []string{"", " "})
+	require.NoError(t, err)
+	assert.Nil(t, a)
+
+	_, err = New("test", []string{"10.0.0.0/33"})
+	assert.Error(t, err)
+	_, err = New("test", []string{"not-an-ip"})
+	assert.Error(t, err)
+}
+
+func TestAllowed(t *testing.T) {
+	a, err := New("test", []string{"10.0.0.0/8", "192.168.1.1", "fd00::/8", "::1"})
+	require.NoError(t, err)
+
+	for _, tc := range []struct {
+		addr    net.Addr
+		allowed bool
+	}{
+		{&net.UDPAddr{IP: net.ParseIP("10.1.2.3"), Port: 8125}, true},
+		{&net.UDPAddr{IP: net.ParseIP("::ffff:10.1.2.3"), Port: 8125}, true},
+		{&net.TCPAddr{IP: net.ParseIP("192.168.1.1"), Port: 10514}, true},
+		{&net.TCPAddr{IP: net.ParseIP("192.168.1.2"), Port: 10514}, false},
+		{&net.UDPAddr{IP: net.ParseIP("fd12:3456::1")}, true},
+		{&net.UDPAddr{IP: net.ParseIP("::1")}, true},
+		{&net.UDPAddr{IP: net.ParseIP("2001:db8::1")}, false},
+		{&net.UnixAddr{Name: "/var/run/dogstatsd.sock", Net: "unixgram"}, true},
+	} {
+		assert.Equal(t, tc.allowed, a.Allowed(tc.addr), tc.addr.String())
+	}
+	assert.Equal(t, int64(2), a.Rejected())
+	assert.Equal(t, "2", allowlistExpvars.Get("test").String())
+
+	// a nil allowlist accepts everything
+	var none *Allowlist
+	assert.True(t, none.Allowed(&net.UDPAddr{IP: net.ParseIP("1.2.3.4")}))
+	assert.Equal(t, int64(0), none.Rejected())
+}
@@ -0,0 +1,54 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2018 Datadog, Inc.
+
+package cache
+
+import (
+	"sort"
+
+	log "github.com/cihub/seelog"
+	cache "github.com/patrickmn/go-cache"
+
+	"github.com/DataDog/datadog-agent/pkg/util/membudget"
+)
+
+// estimatedEntrySize is the memory accounted for an entry of the cache in
+// its memory budget, the size of the values stored can't be known
+const estimatedEntrySize = 1024
+
+func init() {
+	membudget.RegisterShedder("cache", func(limit int64) int64 {
+		return shed(Cache, limit)
+	})
+}
+
+// shed removes the entries of the cache closest to their expiration, the
+// ones without expiration last, until the cache fits in limit. It returns
+// the memory the cache is estimated to use.
+func shed(c *cache.Cache, limit int64) int64 {
+	c.DeleteExpired()
+	items := c.Items()
+	excess := len(items) - int(limit/estimatedEntrySize)
+	if excess <= 0 {
+		return int64(len(items)) * estimatedEntrySize
+	}
+
+	keys := make([]string, 0, len(items))
+	for k := range items {
+		keys = append(keys, k)
+	}
+	sort.Slice(keys, func(i, j int) bool {
+		ei, ej := items[keys[i]].Expiration, items[keys[j]].Expiration
+		if ei == 0 || ej == 0 {
+			return ej == 0 && ei != 0
+		}
+		return ei < ej
+	})
+	for _, k := range keys[:excess] {
+		c.Delete(k)
+	}
+	log.Debugf("Removed %d entries of the cache over its memory budget", excess)
+	return int64(c.ItemCount()) * estimatedEntrySize
+}
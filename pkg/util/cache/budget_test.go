@@ -0,0 +1,31 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2018 Datadog, Inc.
+
+package cache
+
+import (
+	"testing"
+	"time"
+
+	cache "github.com/patrickmn/go-cache"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestShed(t *testing.T) {
+	c := cache.New(time.Minute, time.Minute)
+	c.Set("forever", 1, NoExpiration)
+	c.Set("soon", 2, time.Second)
+	c.Set("later", 3, time.Hour)
+	c.Set("sooner", 4, time.Millisecond)
+
+	assert.Equal(t, int64(4*estimatedEntrySize), shed(c, 10*estimatedEntrySize))
+	assert.Equal(t, 4, c.ItemCount())
+
+	assert.Equal(t, int64(2*estimatedEntrySize), shed(c, 2*estimatedEntrySize))
+	_, found := c.Get("forever")
+	assert.True(t, found)
+	_, found = c.Get("later")
+	assert.True(t, found)
+}
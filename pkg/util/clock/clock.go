@@ -0,0 +1,81 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2018 Datadog, Inc.
+
+// Package clock abstracts the time source of the components measuring
+// durations, like the retries, the caches or the rate limiters, so that they
+// can be tested without sleeping.
+//
+// The times returned by the real clock carry a monotonic reading: the
+// durations computed between two of them with Sub or Since are not affected
+// by the changes of the wall clock. This reading is dropped by the
+// conversions, like Unix or Format, so the elapsed times should never be
+// computed from such values when both ends come from the clock.
+package clock
+
+import (
+	"sync"
+	"time"
+)
+
+// Clock tells the current time
+type Clock interface {
+	// Now returns the current time
+	Now() time.Time
+	// Since returns the time elapsed since t
+	Since(t time.Time) time.Duration
+}
+
+// New returns the Clock of the system
+func New() Clock {
+	return realClock{}
+}
+
+type realClock struct{}
+
+func (realClock) Now() time.Time {
+	return time.Now()
+}
+
+func (realClock) Since(t time.Time) time.Duration {
+	return time.Since(t)
+}
+
+// Mock is a Clock only moving when it is told to, for the tests
+type Mock struct {
+	m   sync.RWMutex
+	now time.Time
+}
+
+// NewMock returns a Mock set at now
+func NewMock(now time.Time) *Mock {
+	return &Mock{now: now}
+}
+
+// Now returns the time the Mock is set at
+func (c *Mock) Now() time.Time {
+	c.m.RLock()
+	defer c.m.RUnlock()
+	return c.now
+}
+
+// Since returns the time elapsed since t, according to the Mock
+func (c *Mock) Since(t time.Time) time.Duration {
+	return c.Now().Sub(t)
+}
+
+// Add moves the Mock forward by d, or backward if d is negative, like a wall
+// clock adjusted by NTP
+func (c *Mock) Add(d time.Duration) {
+	c.m.Lock()
+	defer c.m.Unlock()
+	c.now = c.now.Add(d)
+}
+
+// Set sets the Mock at now
+func (c *Mock) Set(now time.Time) {
+	c.m.Lock()
+	defer c.m.Unlock()
+	c.now = now
+}
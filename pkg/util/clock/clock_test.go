@@ -0,0 +1,37 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2018 Datadog, Inc.
+
+package clock
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRealClock(t *testing.T) {
+	c := New()
+	start := c.Now()
+	assert.True(t, c.Since(start) >= 0)
+	assert.WithinDuration(t, time.Now(), start, time.Second)
+}
+
+func TestMock(t *testing.T) {
+	start := time.Date(2018, 1, 1, 0, 0, 0, 0, time.UTC)
+	c := NewMock(start)
+	assert.Equal(t, start, c.Now())
+	assert.Equal(t, time.Duration(0), c.Since(start))
+
+	c.Add(time.Minute)
+	assert.Equal(t, start.Add(time.Minute), c.Now())
+	assert.Equal(t, time.Minute, c.Since(start))
+
+	c.Add(-2 * time.Minute)
+	assert.Equal(t, -time.Minute, c.Since(start))
+
+	c.Set(start)
+	assert.Equal(t, start, c.Now())
+}
@@ -14,6 +14,7 @@ import (
 	"os"
 
 	"strings"
+	"sync"
 	"time"
 
 	log "github.com/cihub/seelog"
@@ -29,7 +30,8 @@ Client to query the Datadog Cluster Agent (DCA) API.
 */
 
 const (
-	authorizationHeaderKey = "Authorization"
+	authorizationHeaderKey  = "Authorization"
+	serviceAccountTokenPath = "/var/run/secrets/kubernetes.io/serviceaccount/token"
 )
 
 var globalClusterAgentClient *DCAClient
@@ -44,6 +46,8 @@ type DCAClient struct {
 	clusterAgentAPIEndpoint       string // ${SCHEME}://${clusterAgentHost}:${PORT}
 	clusterAgentAPIClient         *http.Client
 	clusterAgentAPIRequestHeaders *http.Header
+	// headersMutex protects the headers, replaced when the auth token is rotated
+	headersMutex sync.RWMutex
 }
 
 // GetClusterAgentClient returns or init the DCAClient
@@ -74,14 +78,11 @@ func (c *DCAClient) init() error {
 		return err
 	}
 
-	authToken, err := security.GetClusterAgentAuthToken()
+	err = c.loadAuthToken()
 	if err != nil {
 		return err
 	}
 
-	c.clusterAgentAPIRequestHeaders = &http.Header{}
-	c.clusterAgentAPIRequestHeaders.Set(authorizationHeaderKey, fmt.Sprintf("Bearer %s", authToken))
-
 	// TODO remove insecure
 	c.clusterAgentAPIClient = util.GetClient(false)
 	c.clusterAgentAPIClient.Timeout = 2 * time.Second
@@ -89,10 +90,78 @@ func (c *DCAClient) init() error {
 	return nil
 }
 
+// loadAuthToken (re)loads the auth token shared with the cluster agent
+func (c *DCAClient) loadAuthToken() error {
+	var authToken string
+	var err error
+	if config.Datadog.GetBool("cluster_agent.use_service_account_token") {
+		// the token of the service account is rotated by the kubelet, it is
+		// read again along with the shared token when refused
+		authToken, err = readServiceAccountToken()
+	} else {
+		authToken, err = security.GetClusterAgentAuthToken()
+	}
+	if err != nil {
+		return err
+	}
+
+	headers := &http.Header{}
+	headers.Set(authorizationHeaderKey, fmt.Sprintf("Bearer %s", authToken))
+
+	c.headersMutex.Lock()
+	defer c.headersMutex.Unlock()
+	c.clusterAgentAPIRequestHeaders = headers
+	return nil
+}
+
+// readServiceAccountToken reads the token of the service account of the pod,
+// to be reviewed by the cluster agent
+func readServiceAccountToken() (string, error) {
+	b, err := ioutil.ReadFile(serviceAccountTokenPath)
+	if err != nil {
+		return "", fmt.Errorf("could not read the service account token: %s", err)
+	}
+	token := strings.TrimSpace(string(b))
+	if token == "" {
+		return "", fmt.Errorf("empty service account token in %s", serviceAccountTokenPath)
+	}
+	return token, nil
+}
+
+// doGet queries the cluster agent. If the auth token is refused, it is
+// reloaded, as the cluster agent may have rotated it, and the query retried once.
+func (c *DCAClient) doGet(rawURL string) (*http.Response, error) {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return nil, err
+	}
+
+	for attempt := 0; ; attempt++ {
+		c.headersMutex.RLock()
+		req := &http.Request{
+			Header: *c.clusterAgentAPIRequestHeaders,
+			URL:    u,
+		}
+		c.headersMutex.RUnlock()
+
+		resp, err := c.clusterAgentAPIClient.Do(req)
+		if err != nil || resp.StatusCode != http.StatusForbidden || attempt > 0 {
+			return resp, err
+		}
+		resp.Body.Close()
+
+		log.Debugf("The cluster agent refused the auth token, reloading it")
+		if err = c.loadAuthToken(); err != nil {
+			return nil, err
+		}
+	}
+}
+
 // getClusterAgentEndpoint provides a validated https endpoint from configuration keys in datadog.yaml:
 // 1st. configuration key "cluster_agent_url", add the https prefix if the scheme isn't specified
 // 2nd. environment variables associated with "cluster_agent_kubernetes_service_name"
-//      ${dcaServiceName}_SERVICE_HOST and ${dcaServiceName}_SERVICE_PORT
+//
+//	${dcaServiceName}_SERVICE_HOST and ${dcaServiceName}_SERVICE_PORT
 func getClusterAgentEndpoint() (string, error) {
 	const configDcaURL = "cluster_agent.url"
 	const configDcaSvcName = "cluster_agent.kubernetes_service_name"
@@ -160,20 +229,13 @@ func (c *DCAClient) GetKubernetesMetadataNames(nodeName, podName string) ([]stri
 	if c == nil {
 		return nil, fmt.Errorf("cluster agent's client is not properly initialized")
 	}
-	req := &http.Request{
-		Header: *c.clusterAgentAPIRequestHeaders,
-	}
 	// https://host:port /api/v1/metadata/ {nodeName}/ {pod-[0-9a-z]+}
 	rawURL := fmt.Sprintf("%s/%s/%s/%s", c.clusterAgentAPIEndpoint, dcaMetadataPath, nodeName, podName)
-	req.URL, err = url.Parse(rawURL)
-	if err != nil {
-		return metadataNames, err
-	}
-
-	resp, err := c.clusterAgentAPIClient.Do(req)
+	resp, err := c.doGet(rawURL)
 	if err != nil {
 		return metadataNames, err
 	}
+	defer resp.Body.Close()
 
 	if resp.StatusCode != http.StatusOK {
 		return metadataNames, fmt.Errorf("unexpected status code from cluster agent: %d", resp.StatusCode)
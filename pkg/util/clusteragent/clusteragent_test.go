@@ -52,7 +52,10 @@ func newDummyClusterAgent() (*dummyClusterAgent, error) {
 func (d *dummyClusterAgent) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 	log.Debugf("dummyDCA received %s on %s", r.Method, r.URL.Path)
 	token := r.Header.Get("Authorization")
-	if token != fmt.Sprintf("Bearer %s", d.token) {
+	d.RLock()
+	expected := fmt.Sprintf("Bearer %s", d.token)
+	d.RUnlock()
+	if token != expected {
 		log.Errorf("wrong token %s", token)
 		w.WriteHeader(403)
 		return
@@ -305,6 +308,41 @@ func (suite *clusterAgentSuite) TestGetKubernetesMetadataNames() {
 	}
 }
 
+func (suite *clusterAgentSuite) TestGetKubernetesMetadataNamesRotatedToken() {
+	const rotatedTokenValue = "abcdefabcdefabcdefabcdefabcdefabcdefabcdef"
+	config.Datadog.Set("cluster_agent.auth_token", "")
+	err := ioutil.WriteFile(suite.authTokenPath, []byte(clusterAgentTokenValue), os.ModePerm)
+	require.Nil(suite.T(), err, fmt.Sprintf("%v", err))
+
+	dca, err := newDummyClusterAgent()
+	require.Nil(suite.T(), err, fmt.Sprintf("%v", err))
+	dca.token = clusterAgentTokenValue
+
+	ts, p, err := dca.StartTLS()
+	defer ts.Close()
+	require.Nil(suite.T(), err, fmt.Sprintf("%v", err))
+
+	config.Datadog.Set("cluster_agent.url", fmt.Sprintf("https://127.0.0.1:%d", p))
+
+	ca := &DCAClient{}
+	err = ca.init()
+	require.Nil(suite.T(), err, fmt.Sprintf("%v", err))
+
+	_, err = ca.GetKubernetesMetadataNames("node1", "pod-00001")
+	require.Nil(suite.T(), err, fmt.Sprintf("%v", err))
+
+	// the cluster agent rotated the token and published it in the token file
+	dca.Lock()
+	dca.token = rotatedTokenValue
+	dca.Unlock()
+	err = ioutil.WriteFile(suite.authTokenPath, []byte(rotatedTokenValue), os.ModePerm)
+	require.Nil(suite.T(), err, fmt.Sprintf("%v", err))
+
+	svc, err := ca.GetKubernetesMetadataNames("node1", "pod-00001")
+	require.Nil(suite.T(), err, fmt.Sprintf("%v", err))
+	assert.Equal(suite.T(), []string{"kube_service:svc1"}, svc)
+}
+
 func TestClusterAgentSuite(t *testing.T) {
 	clusterAgentAuthTokenFilename := "cluster_agent_auth_token"
 
@@ -11,6 +11,7 @@ import (
 	"io"
 	"io/ioutil"
 	"math/rand"
+	"net"
 	"net/http"
 	"net/url"
 	"os"
@@ -21,6 +22,7 @@ import (
 
 	"github.com/DataDog/datadog-agent/pkg/collector/check"
 	"github.com/DataDog/datadog-agent/pkg/config"
+	"github.com/DataDog/datadog-agent/pkg/util/proxyauth"
 	"github.com/DataDog/datadog-agent/pkg/version"
 )
 
@@ -157,8 +159,15 @@ func GetProxyTransportFunc(p *config.Proxy) func(*http.Request) (*url.URL, error
 		confProxy := ""
 		if r.URL.Scheme == "http" {
 			confProxy = p.HTTP
+			if proxyauth.IsConnectionBased(p.HTTPAuth) {
+				// the connection is tunneled by the dialer of the transport
+				return nil, nil
+			}
 		} else if r.URL.Scheme == "https" {
 			confProxy = p.HTTPS
+			if proxyauth.IsConnectionBased(p.HTTPSAuth) {
+				return nil, nil
+			}
 		} else {
 			log.Warnf("Proxy configuration do not support scheme '%s'", r.URL.Scheme)
 		}
@@ -208,6 +217,7 @@ func CreateHTTPTransport() *http.Transport {
 			log.Errorf("Could not load the proxy configuration: %s", err)
 		} else {
 			transport.Proxy = GetProxyTransportFunc(proxies)
+			setProxyDialers(transport, proxies)
 		}
 	}
 
@@ -218,3 +228,47 @@ func CreateHTTPTransport() *http.Transport {
 
 	return transport
 }
+
+// setProxyDialers tunnels the connections of the transport through the proxies
+// using a connection-based authentication, not supported by http.Transport.
+// When a dialer can't be set up, the connections fail instead of bypassing
+// the proxy.
+func setProxyDialers(transport *http.Transport, p *config.Proxy) {
+	if proxyauth.IsConnectionBased(p.HTTPAuth) && p.HTTP != "" {
+		dialer, err := newProxyDialer(p.HTTP, p.HTTPAuth, p.NoProxy, transport.TLSClientConfig)
+		if err != nil {
+			log.Errorf("Could not set up the authentication of the proxy for scheme http: %s", err)
+			transport.Dial = failingDial(err)
+		} else {
+			transport.Dial = dialer.Dial
+		}
+	}
+	if proxyauth.IsConnectionBased(p.HTTPSAuth) && p.HTTPS != "" {
+		dialer, err := newProxyDialer(p.HTTPS, p.HTTPSAuth, p.NoProxy, transport.TLSClientConfig)
+		if err != nil {
+			log.Errorf("Could not set up the authentication of the proxy for scheme https: %s", err)
+			transport.DialTLS = failingDial(err)
+		} else {
+			transport.DialTLS = dialer.DialTLS(transport.TLSClientConfig)
+		}
+	}
+}
+
+func newProxyDialer(proxy, auth string, noProxy []string, tlsConfig *tls.Config) (*proxyauth.Dialer, error) {
+	proxyURL, err := url.Parse(proxy)
+	if err != nil {
+		return nil, err
+	}
+	dialer, err := proxyauth.NewDialer(proxyURL, auth, noProxy)
+	if err != nil {
+		return nil, err
+	}
+	dialer.TLSConfig = tlsConfig
+	return dialer, nil
+}
+
+func failingDial(err error) func(network, addr string) (net.Conn, error) {
+	return func(network, addr string) (net.Conn, error) {
+		return nil, fmt.Errorf("invalid proxy configuration: %s", err)
+	}
+}
@@ -157,3 +157,24 @@ func TestCreateHTTPTransport(t *testing.T) {
 	assert.True(t, transport.TLSClientConfig.InsecureSkipVerify)
 	assert.Equal(t, transport.TLSClientConfig.MinVersion, uint16(tls.VersionTLS12))
 }
+
+func TestConnectionBasedProxyAuth(t *testing.T) {
+	r1, _ := http.NewRequest("GET", "http://test_no_proxy.com/api/v1?arg=21", nil)
+	r2, _ := http.NewRequest("GET", "https://test_no_proxy.com/api/v1?arg=21", nil)
+
+	proxies := &config.Proxy{
+		HTTP:      "http://test_http:1234",
+		HTTPS:     "http://test_https:4321",
+		HTTPSAuth: "ntlm",
+	}
+	proxyFunc := GetProxyTransportFunc(proxies)
+
+	proxyURL, err := proxyFunc(r1)
+	assert.Nil(t, err)
+	assert.Equal(t, "http://test_http:1234", proxyURL.String())
+
+	// the https connections are tunneled by the dialer of the transport
+	proxyURL, err = proxyFunc(r2)
+	assert.Nil(t, err)
+	assert.Nil(t, proxyURL)
+}
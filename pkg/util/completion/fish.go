@@ -0,0 +1,72 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2018 Datadog, Inc.
+
+// Package completion generates the shell completion scripts of the commands
+// not supported by the cobra version in use.
+package completion
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+)
+
+// GenFishCompletion writes the fish completion script of the command tree of root
+func GenFishCompletion(root *cobra.Command, w io.Writer) error {
+	buf := new(bytes.Buffer)
+	name := root.Name()
+
+	fmt.Fprintf(buf, "# fish completion for %s\n\n", name)
+	writeFishFlags(buf, name, "", root.PersistentFlags())
+	writeFishCommands(buf, name, root)
+
+	_, err := buf.WriteTo(w)
+	return err
+}
+
+func writeFishCommands(buf *bytes.Buffer, name string, parent *cobra.Command) {
+	condition := "__fish_use_subcommand"
+	if parent.HasParent() {
+		condition = "__fish_seen_subcommand_from " + parent.Name()
+	}
+	for _, cmd := range parent.Commands() {
+		if cmd.Hidden || !cmd.IsAvailableCommand() {
+			continue
+		}
+		fmt.Fprintf(buf, "complete -c %s -n '%s' -f -a %s -d '%s'\n", name, condition, cmd.Name(), escapeFish(cmd.Short))
+		writeFishFlags(buf, name, "__fish_seen_subcommand_from "+cmd.Name(), cmd.LocalFlags())
+		writeFishCommands(buf, name, cmd)
+	}
+}
+
+func writeFishFlags(buf *bytes.Buffer, name, condition string, flags *pflag.FlagSet) {
+	flags.VisitAll(func(flag *pflag.Flag) {
+		if flag.Hidden || flag.Deprecated != "" {
+			return
+		}
+		fmt.Fprintf(buf, "complete -c %s", name)
+		if condition != "" {
+			fmt.Fprintf(buf, " -n '%s'", condition)
+		}
+		fmt.Fprintf(buf, " -l %s", flag.Name)
+		if flag.Shorthand != "" {
+			fmt.Fprintf(buf, " -s %s", flag.Shorthand)
+		}
+		if flag.Value.Type() != "bool" {
+			buf.WriteString(" -r")
+		}
+		fmt.Fprintf(buf, " -d '%s'\n", escapeFish(flag.Usage))
+	})
+}
+
+// escapeFish escapes a string quoted with single quotes in a fish script
+func escapeFish(s string) string {
+	s = strings.Replace(s, `\`, `\\`, -1)
+	return strings.Replace(s, `'`, `\'`, -1)
+}
@@ -0,0 +1,41 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2018 Datadog, Inc.
+
+package completion
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/spf13/cobra"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGenFishCompletion(t *testing.T) {
+	noop := func(cmd *cobra.Command, args []string) {}
+	root := &cobra.Command{Use: "agent [command]"}
+	root.PersistentFlags().StringP("cfgpath", "c", "", "path to directory containing datadog.yaml")
+
+	status := &cobra.Command{Use: "status", Short: "Print the agent's status", Run: noop}
+	status.Flags().BoolP("verbose", "v", false, "print out verbose status")
+	root.AddCommand(status)
+
+	config := &cobra.Command{Use: "config", Short: "Print or change the runtime settings", Run: noop}
+	config.AddCommand(&cobra.Command{Use: "get <setting>", Short: "Print a runtime setting", Run: noop})
+	root.AddCommand(config)
+
+	root.AddCommand(&cobra.Command{Use: "secret", Short: "Hidden command", Hidden: true, Run: noop})
+
+	buf := new(bytes.Buffer)
+	require.NoError(t, GenFishCompletion(root, buf))
+	script := buf.String()
+
+	assert.Contains(t, script, "complete -c agent -l cfgpath -s c -r -d 'path to directory containing datadog.yaml'\n")
+	assert.Contains(t, script, "complete -c agent -n '__fish_use_subcommand' -f -a status -d 'Print the agent\\'s status'\n")
+	assert.Contains(t, script, "complete -c agent -n '__fish_seen_subcommand_from status' -l verbose -s v -d 'print out verbose status'\n")
+	assert.Contains(t, script, "complete -c agent -n '__fish_seen_subcommand_from config' -f -a get -d 'Print a runtime setting'\n")
+	assert.NotContains(t, script, "secret")
+}
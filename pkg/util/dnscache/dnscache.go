@@ -0,0 +1,143 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2018 Datadog, Inc.
+
+/*
+Package dnscache caches the resolution of the hosts targeted by the core
+checks, to not query the resolver for every host at every check run.
+
+The answers can be overridden for tests: SetStatic and InjectFailure make
+the resolution of a host deterministic, without querying the resolver.
+*/
+package dnscache
+
+import (
+	"fmt"
+	"net"
+	"sync"
+	"time"
+
+	"github.com/DataDog/datadog-agent/pkg/config"
+)
+
+type entry struct {
+	addrs   []string
+	err     error
+	expires time.Time
+}
+
+// Cache resolves host names, caching the answers for ttl and the failures
+// for negativeTTL
+type Cache struct {
+	m           sync.Mutex
+	entries     map[string]entry
+	overrides   map[string]entry
+	ttl         time.Duration
+	negativeTTL time.Duration
+
+	// replaced in unit tests
+	lookupHost func(host string) ([]string, error)
+	now        func() time.Time
+}
+
+var (
+	defaultCache     *Cache
+	defaultCacheOnce sync.Once
+)
+
+// New returns an empty cache
+func New(ttl, negativeTTL time.Duration) *Cache {
+	return &Cache{
+		entries:     make(map[string]entry),
+		overrides:   make(map[string]entry),
+		ttl:         ttl,
+		negativeTTL: negativeTTL,
+		lookupHost:  net.LookupHost,
+		now:         time.Now,
+	}
+}
+
+// Default returns the cache shared by the checks, configured by the
+// check_dns_cache settings
+func Default() *Cache {
+	defaultCacheOnce.Do(func() {
+		defaultCache = New(
+			config.Datadog.GetDuration("check_dns_cache.ttl")*time.Second,
+			config.Datadog.GetDuration("check_dns_cache.negative_ttl")*time.Second,
+		)
+	})
+	return defaultCache
+}
+
+// Enabled returns whether a check should resolve its hosts with the cache,
+// the setting of the check instance, if any, overrides the agent-wide one
+func Enabled(instance *bool) bool {
+	if instance != nil {
+		return *instance
+	}
+	return config.Datadog.GetBool("check_dns_cache.enabled")
+}
+
+// LookupHost returns the addresses of a host, from the cache if they were
+// resolved recently
+func (c *Cache) LookupHost(host string) ([]string, error) {
+	if net.ParseIP(host) != nil {
+		return []string{host}, nil
+	}
+
+	c.m.Lock()
+	defer c.m.Unlock()
+
+	if e, found := c.overrides[host]; found {
+		return e.addrs, e.err
+	}
+
+	now := c.now()
+	if e, found := c.entries[host]; found && now.Before(e.expires) {
+		return e.addrs, e.err
+	}
+
+	// the lock is held during the lookup so that concurrent runs of the
+	// checks only query the resolver once per host
+	addrs, err := c.lookupHost(host)
+	if err != nil {
+		c.entries[host] = entry{err: err, expires: now.Add(c.negativeTTL)}
+		return nil, err
+	}
+	c.entries[host] = entry{addrs: addrs, expires: now.Add(c.ttl)}
+	return addrs, nil
+}
+
+// Resolve returns the first address of a host
+func (c *Cache) Resolve(host string) (string, error) {
+	addrs, err := c.LookupHost(host)
+	if err != nil {
+		return "", err
+	}
+	if len(addrs) == 0 {
+		return "", fmt.Errorf("no address found for %s", host)
+	}
+	return addrs[0], nil
+}
+
+// SetStatic makes a host resolve to the given addresses
+func (c *Cache) SetStatic(host string, addrs ...string) {
+	c.m.Lock()
+	defer c.m.Unlock()
+	c.overrides[host] = entry{addrs: addrs}
+}
+
+// InjectFailure makes the resolution of a host fail with the given error
+func (c *Cache) InjectFailure(host string, err error) {
+	c.m.Lock()
+	defer c.m.Unlock()
+	c.overrides[host] = entry{err: err}
+}
+
+// ClearOverrides removes the answers set by SetStatic and InjectFailure
+func (c *Cache) ClearOverrides() {
+	c.m.Lock()
+	defer c.m.Unlock()
+	c.overrides = make(map[string]entry)
+}
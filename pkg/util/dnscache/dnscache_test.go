@@ -0,0 +1,120 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2018 Datadog, Inc.
+
+package dnscache
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type fakeResolver struct {
+	lookups int
+	err     error
+}
+
+func (r *fakeResolver) lookupHost(host string) ([]string, error) {
+	r.lookups++
+	if r.err != nil {
+		return nil, r.err
+	}
+	return []string{"10.0.0.1", "10.0.0.2"}, nil
+}
+
+func newTestCache(r *fakeResolver, now *time.Time) *Cache {
+	c := New(time.Minute, 5*time.Second)
+	c.lookupHost = r.lookupHost
+	c.now = func() time.Time { return *now }
+	return c
+}
+
+func TestResolveCached(t *testing.T) {
+	r := &fakeResolver{}
+	now := time.Now()
+	c := newTestCache(r, &now)
+
+	addr, err := c.Resolve("example.com")
+	require.NoError(t, err)
+	assert.Equal(t, "10.0.0.1", addr)
+
+	now = now.Add(30 * time.Second)
+	addrs, err := c.LookupHost("example.com")
+	require.NoError(t, err)
+	assert.Equal(t, []string{"10.0.0.1", "10.0.0.2"}, addrs)
+	assert.Equal(t, 1, r.lookups)
+
+	// expired
+	now = now.Add(time.Minute)
+	_, err = c.Resolve("example.com")
+	require.NoError(t, err)
+	assert.Equal(t, 2, r.lookups)
+}
+
+func TestResolveFailureCached(t *testing.T) {
+	r := &fakeResolver{err: fmt.Errorf("no such host")}
+	now := time.Now()
+	c := newTestCache(r, &now)
+
+	_, err := c.Resolve("example.com")
+	assert.Error(t, err)
+	_, err = c.Resolve("example.com")
+	assert.Error(t, err)
+	assert.Equal(t, 1, r.lookups)
+
+	// the failures are retried sooner than the answers expire
+	r.err = nil
+	now = now.Add(10 * time.Second)
+	addr, err := c.Resolve("example.com")
+	require.NoError(t, err)
+	assert.Equal(t, "10.0.0.1", addr)
+	assert.Equal(t, 2, r.lookups)
+}
+
+func TestResolveIP(t *testing.T) {
+	r := &fakeResolver{}
+	now := time.Now()
+	c := newTestCache(r, &now)
+
+	for _, ip := range []string{"192.168.1.1", "::1"} {
+		addr, err := c.Resolve(ip)
+		require.NoError(t, err)
+		assert.Equal(t, ip, addr)
+	}
+	assert.Equal(t, 0, r.lookups)
+}
+
+func TestOverrides(t *testing.T) {
+	r := &fakeResolver{}
+	now := time.Now()
+	c := newTestCache(r, &now)
+
+	c.SetStatic("static.local", "127.0.0.2")
+	c.InjectFailure("failing.local", fmt.Errorf("injected failure"))
+
+	addr, err := c.Resolve("static.local")
+	require.NoError(t, err)
+	assert.Equal(t, "127.0.0.2", addr)
+
+	_, err = c.Resolve("failing.local")
+	assert.EqualError(t, err, "injected failure")
+	assert.Equal(t, 0, r.lookups)
+
+	c.ClearOverrides()
+	addr, err = c.Resolve("failing.local")
+	require.NoError(t, err)
+	assert.Equal(t, "10.0.0.1", addr)
+	assert.Equal(t, 1, r.lookups)
+}
+
+func TestEnabled(t *testing.T) {
+	enabled, disabled := true, false
+	assert.True(t, Enabled(&enabled))
+	assert.False(t, Enabled(&disabled))
+	assert.False(t, Enabled(nil))
+}
@@ -22,15 +22,15 @@ func diagnose() error {
 	_, err := GetDockerUtil()
 	if err != nil {
 		log.Error(err)
-	} else {
-		log.Info("successfully connected to docker")
+		return diagnosis.WithRemediation(err, "add the user running the agent to the docker group, or make sure the docker socket is mounted in the agent container")
 	}
+	log.Info("successfully connected to docker")
 
 	hostname, err := HostnameProvider("")
 	if err != nil {
 		log.Errorf("returned hostname %q with error: %s", hostname, err)
-	} else {
-		log.Infof("successfully got hostname %q from docker", hostname)
+		return diagnosis.WithRemediation(err, "set the hostname option in datadog.yaml if the docker hostname can't be used")
 	}
-	return err
+	log.Infof("successfully got hostname %q from docker", hostname)
+	return nil
 }
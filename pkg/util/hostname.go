@@ -81,6 +81,34 @@ func Fqdn(hostname string) string {
 	return hostname
 }
 
+// HostnameStep is the result of one of the providers tried to resolve the
+// hostname
+type HostnameStep struct {
+	Provider string `json:"provider"`
+	Hostname string `json:"hostname"`
+	Error    string `json:"error,omitempty"`
+}
+
+// HostnameResolution details how the hostname was resolved: the providers
+// tried, in order, and the one the hostname comes from
+type HostnameResolution struct {
+	Hostname string         `json:"hostname"`
+	Provider string         `json:"provider"`
+	Steps    []HostnameStep `json:"steps"`
+}
+
+// record adds the result of a provider, it does nothing on a nil resolution
+func (r *HostnameResolution) record(provider, name string, err error) {
+	if r == nil {
+		return
+	}
+	step := HostnameStep{Provider: provider, Hostname: name}
+	if err != nil {
+		step.Error = err.Error()
+	}
+	r.Steps = append(r.Steps, step)
+}
+
 // GetHostname retrieve the host name for the Agent, trying to query these
 // environments/api, in order:
 // * GCE
@@ -94,15 +122,33 @@ func GetHostname() (string, error) {
 		return cacheHostname.(string), nil
 	}
 
-	var hostName string
+	hostName, _, err := resolveHostname(nil)
+	cache.Cache.Set(cacheHostnameKey, hostName, cache.NoExpiration)
+	return hostName, err
+}
+
+// ResolveHostname resolves the hostname like GetHostname, without using
+// its cache, and returns the results of all the providers tried
+func ResolveHostname() (*HostnameResolution, error) {
+	r := &HostnameResolution{}
+	hostName, provider, err := resolveHostname(r)
+	r.Hostname = hostName
+	r.Provider = provider
+	return r, err
+}
+
+// resolveHostname returns the hostname and the provider it comes from, the
+// results of the providers are recorded in r if it's not nil
+func resolveHostname(r *HostnameResolution) (string, string, error) {
+	var hostName, provider string
 	var err error
 
 	// try the name provided in the configuration file
 	name := config.Datadog.GetString("hostname")
 	err = ValidHostname(name)
+	r.record("config", name, err)
 	if err == nil {
-		cache.Cache.Set(cacheHostnameKey, name, cache.NoExpiration)
-		return name, err
+		return name, "config", err
 	}
 
 	log.Debugf("Unable to get the hostname from the config file: %s", err)
@@ -110,32 +156,35 @@ func GetHostname() (string, error) {
 
 	// if fargate we strip the hostname
 	if ecs.IsFargateInstance() {
-		cache.Cache.Set(cacheHostnameKey, "", cache.NoExpiration)
-		return "", nil
+		r.record("fargate", "", nil)
+		return "", "fargate", nil
 	}
 
 	// GCE metadata
 	log.Debug("GetHostname trying GCE metadata...")
 	if getGCEHostname, found := hostname.ProviderCatalog["gce"]; found {
 		name, err = getGCEHostname(name)
+		r.record("gce", name, err)
 		if err == nil {
-			cache.Cache.Set(cacheHostnameKey, name, cache.NoExpiration)
-			return name, err
+			return name, "gce", err
 		}
 		log.Debug("Unable to get hostname from GCE: ", err)
 	}
 
-	isContainerized, name := getContainerHostname()
+	isContainerized, name, containerProvider := getContainerHostname(r)
 	if isContainerized && name != "" {
 		hostName = name
+		provider = containerProvider
 	}
 
 	if hostName == "" {
 		// os
 		log.Debug("GetHostname trying os...")
 		name, err = os.Hostname()
+		r.record("os", name, err)
 		if err == nil {
 			hostName = name
+			provider = "os"
 		} else {
 			log.Debug("Unable to get hostname from OS: ", err)
 		}
@@ -152,12 +201,14 @@ func GetHostname() (string, error) {
 			err = ValidHostname(instanceID)
 			if err == nil {
 				hostName = instanceID
+				provider = "ec2"
 			} else {
 				log.Debug("EC2 instance ID is not a valid hostname: ", err)
 			}
 		} else {
 			log.Debug("Unable to determine hostname from EC2: ", err)
 		}
+		r.record("ec2", instanceID, err)
 	}
 
 	// If at this point we don't have a name, bail out
@@ -168,6 +219,5 @@ func GetHostname() (string, error) {
 		err = nil
 	}
 
-	cache.Cache.Set(cacheHostnameKey, hostName, cache.NoExpiration)
-	return hostName, err
+	return hostName, provider, err
 }
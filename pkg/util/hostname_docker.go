@@ -16,32 +16,40 @@ import (
 	"github.com/DataDog/datadog-agent/pkg/util/hostname"
 )
 
-func getContainerHostname() (bool, string) {
+func getContainerHostname(r *HostnameResolution) (bool, string, string) {
 	var name string
 
 	if config.IsContainerized() == false {
-		return false, name
+		return false, name, ""
 	}
 
 	// Docker
 	log.Debug("GetHostname trying Docker API...")
 	if getDockerHostname, found := hostname.ProviderCatalog["docker"]; found {
 		name, err := getDockerHostname(name)
-		if err == nil && ValidHostname(name) == nil {
-			return true, name
+		if err == nil {
+			err = ValidHostname(name)
+		}
+		r.record("docker", name, err)
+		if err == nil {
+			return true, name, "docker"
 		}
 	}
 
 	if config.IsKubernetes() == false {
-		return false, name
+		return false, name, ""
 	}
 	// Kubernetes
 	log.Debug("GetHostname trying Kubernetes trough kubelet API...")
 	if getKubeletHostname, found := hostname.ProviderCatalog["kubelet"]; found {
 		name, err := getKubeletHostname(name)
-		if err == nil && ValidHostname(name) == nil {
-			return true, name
+		if err == nil {
+			err = ValidHostname(name)
+		}
+		r.record("kubelet", name, err)
+		if err == nil {
+			return true, name, "kubelet"
 		}
 	}
-	return false, name
+	return false, name, ""
 }
@@ -7,6 +7,6 @@
 
 package util
 
-func getContainerHostname() (bool, string) {
-	return false, ""
+func getContainerHostname(r *HostnameResolution) (bool, string, string) {
+	return false, "", ""
 }
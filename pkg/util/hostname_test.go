@@ -10,6 +10,10 @@ import (
 	"testing"
 
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/DataDog/datadog-agent/pkg/config"
+	"github.com/DataDog/datadog-agent/pkg/util/cache"
 )
 
 func TestIsLocal(t *testing.T) {
@@ -31,3 +35,31 @@ func TestValidHostname(t *testing.T) {
 	err = ValidHostname("data🐕hq.com")
 	assert.NotNil(t, err)
 }
+
+func TestResolveHostnameFromConfig(t *testing.T) {
+	config.Datadog.Set("hostname", "config-host")
+	defer config.Datadog.Set("hostname", "")
+
+	r, err := ResolveHostname()
+	require.NoError(t, err)
+	assert.Equal(t, "config-host", r.Hostname)
+	assert.Equal(t, "config", r.Provider)
+	assert.Equal(t, []HostnameStep{{Provider: "config", Hostname: "config-host"}}, r.Steps)
+
+	// the cache of GetHostname is not filled
+	_, found := cache.Cache.Get(cache.BuildAgentKey("hostname"))
+	assert.False(t, found)
+}
+
+func TestHostnameResolutionRecord(t *testing.T) {
+	var nilResolution *HostnameResolution
+	nilResolution.record("os", "host", nil) // must not panic
+
+	r := &HostnameResolution{}
+	r.record("config", "", ValidHostname(""))
+	r.record("os", "host", nil)
+	assert.Equal(t, []HostnameStep{
+		{Provider: "config", Error: "host name is empty"},
+		{Provider: "os", Hostname: "host"},
+	}, r.Steps)
+}
@@ -24,12 +24,20 @@ import (
 
 	"github.com/DataDog/datadog-agent/pkg/config"
 	"github.com/DataDog/datadog-agent/pkg/util/cache"
+	"github.com/DataDog/datadog-agent/pkg/util/clock"
+	"github.com/DataDog/datadog-agent/pkg/util/ratelimit"
 	"github.com/DataDog/datadog-agent/pkg/util/retry"
+	"github.com/DataDog/datadog-agent/pkg/util/tracing"
 )
 
 var (
 	globalAPIClient *APIClient
 
+	// lastMetadataExpiryRefresh is the last time the expiration of all
+	// the cached metadata bundles was refreshed
+	lastMetadataExpiryRefresh time.Time
+	metadataExpiryMutex       sync.Mutex
+
 	ErrNotFound  = errors.New("entity not found")
 	ErrOutdated  = errors.New("entity is outdated")
 	ErrNotLeader = errors.New("not Leader")
@@ -52,6 +60,14 @@ type APIClient struct {
 
 	client  *k8s.Client
 	timeout time.Duration
+	// clock ages the tokens stored in the ConfigMap
+	clock clock.Clock
+	// limiter caps the rate of the requests sent to the apiserver
+	limiter ratelimit.Limiter
+
+	// resources lists what the client is allowed to list, set on connection
+	resources      resourcesAuth
+	resourcesMutex sync.RWMutex
 }
 
 // GetAPIClient returns the shared ApiClient instance.
@@ -60,6 +76,12 @@ func GetAPIClient() (*APIClient, error) {
 		globalAPIClient = &APIClient{
 			// TODO: make it configurable if requested
 			timeout: 5 * time.Second,
+			clock:   clock.New(),
+			limiter: ratelimit.NewTokenBucket(
+				"apiserver_client",
+				config.Datadog.GetFloat64("kubernetes_apiserver_client_qps"),
+				config.Datadog.GetInt("kubernetes_apiserver_client_burst"),
+			),
 		}
 		globalAPIClient.initRetry.SetupRetrier(&retry.Config{
 			Name:          "apiserver",
@@ -77,6 +99,17 @@ func GetAPIClient() (*APIClient, error) {
 	return globalAPIClient, nil
 }
 
+// throttle blocks until the client is allowed to send a request to the apiserver
+func (c *APIClient) throttle(ctx context.Context) error {
+	if c.limiter == nil {
+		return nil
+	}
+	if err := c.limiter.Wait(ctx); err != nil {
+		return fmt.Errorf("throttled by the apiserver client rate limit: %s", err)
+	}
+	return nil
+}
+
 func (c *APIClient) connect() error {
 	if c.client == nil {
 		var err error
@@ -115,7 +148,11 @@ func (c *APIClient) connect() error {
 	if err != nil {
 		return err
 	}
-	log.Debug("Could successfully collect Pods, Nodes, Services and Events.")
+	if limitations := c.getResourcesAuth().limitations(); len(limitations) > 0 {
+		log.Warnf("Running with reduced features, the cluster role of the agent lacks permissions: %s", strings.Join(limitations, "; "))
+	} else {
+		log.Debug("Could successfully collect Pods, Nodes, Services and Events.")
+	}
 
 	useMetadataMapper := config.Datadog.GetBool("use_metadata_mapper")
 	if !useMetadataMapper {
@@ -132,6 +169,8 @@ func (c *APIClient) connect() error {
 type MetadataMapperBundle struct {
 	PodNameToService map[string][]string `json:"services,omitempty"`
 	m                sync.RWMutex
+	// checksum of PodNameToService, to only write the changed bundles to the cache
+	checksum uint64
 }
 
 func newMetadataMapperBundle() *MetadataMapperBundle {
@@ -143,9 +182,15 @@ func newMetadataMapperBundle() *MetadataMapperBundle {
 // NodeMetadataMapping only fetch the endpoints from Kubernetes apiserver and add the metadataMapper of the
 // node to the cache
 // Only called when the node agent computes the metadata mapper locally and does not rely on the DCA.
-func (c *APIClient) NodeMetadataMapping(nodeName string, podList *v1.PodList) error {
+func (c *APIClient) NodeMetadataMapping(nodeName string, podList *v1.PodList) (err error) {
+	span := tracing.StartSpan("apiserver.poll", "node_metadata_mapping")
+	defer func() { span.Finish(err) }()
+
 	ctx, cancel := context.WithTimeout(context.Background(), metadataPollIntl)
 	defer cancel()
+	if err := c.throttle(ctx); err != nil {
+		return err
+	}
 
 	endpointList, err := c.client.CoreV1().ListEndpoints(ctx, "")
 	if err != nil {
@@ -173,23 +218,26 @@ func (c *APIClient) NodeMetadataMapping(nodeName string, podList *v1.PodList) er
 // - all endpoints of all namespaces
 // - all pods of all namespaces
 // Then it stores in cache the MetadataMapperBundle of each node.
-func (c *APIClient) ClusterMetadataMapping() error {
+func (c *APIClient) ClusterMetadataMapping() (err error) {
+	span := tracing.StartSpan("apiserver.poll", "cluster_metadata_mapping")
+	defer func() { span.Finish(err) }()
+
 	// The timeout for the context is the same as the poll frequency.
 	// We use a new context at each run, to recover if we can't access the API server temporarily.
 	// A poll run should take less than the poll frequency.
 	ctx, cancel := context.WithTimeout(context.Background(), metadataPollIntl)
 	defer cancel()
 
-	// We fetch nodes to reliably use nodename as key in the cache.
-	// Avoiding to retrieve them from the endpoints/podList.
-	nodeList, err := c.client.CoreV1().ListNodes(ctx)
-	if err != nil {
-		log.Errorf("Could not collect nodes from the kube-apiserver: %q", err.Error())
-		return err
+	// Without the permission to list the nodes or the pods, the mapper runs in
+	// a reduced mode deducing them from the endpoints.
+	resources := c.getResourcesAuth()
+	if resources.metadataMapperMode() == MetadataMapperDisabled {
+		return fmt.Errorf("the metadata mapper is disabled, the endpoints can't be listed")
 	}
-	if nodeList.Items == nil {
-		log.Debug("No node collected from the kube-apiserver")
-		return nil
+
+	// A single token covers the three lists below, fetched as one poll run
+	if err := c.throttle(ctx); err != nil {
+		return err
 	}
 
 	endpointList, err := c.client.CoreV1().ListEndpoints(ctx, k8s.AllNamespaces)
@@ -202,10 +250,32 @@ func (c *APIClient) ClusterMetadataMapping() error {
 		return nil
 	}
 
-	podList, err := c.client.CoreV1().ListPods(ctx, k8s.AllNamespaces)
-	if err != nil {
-		log.Errorf("Could not collect pods from the kube-apiserver: %q", err.Error())
-		return err
+	// We fetch nodes to reliably use nodename as key in the cache.
+	// Avoiding to retrieve them from the endpoints/podList.
+	var nodeList *v1.NodeList
+	if resources.nodes {
+		nodeList, err = c.client.CoreV1().ListNodes(ctx)
+		if err != nil {
+			log.Errorf("Could not collect nodes from the kube-apiserver: %q", err.Error())
+			return err
+		}
+	} else {
+		nodeList = nodesFromEndpoints(endpointList)
+	}
+	if nodeList.Items == nil {
+		log.Debug("No node collected from the kube-apiserver")
+		return nil
+	}
+
+	var podList *v1.PodList
+	if resources.pods {
+		podList, err = c.client.CoreV1().ListPods(ctx, k8s.AllNamespaces)
+		if err != nil {
+			log.Errorf("Could not collect pods from the kube-apiserver: %q", err.Error())
+			return err
+		}
+	} else {
+		podList = podsFromEndpoints(endpointList)
 	}
 	if podList.Items == nil {
 		log.Debug("No pod collected from the kube-apiserver")
@@ -216,31 +286,56 @@ func (c *APIClient) ClusterMetadataMapping() error {
 	return nil
 }
 
-// processKubeServices adds services to the metadataMapper cache, pointer parameters must be non nil
+// processKubeServices adds services to the metadataMapper cache, pointer parameters must be non nil.
+// Only the new and changed bundles are written to the cache, the expiration of the
+// unchanged ones is refreshed all at once every half of metadataMapExpire.
 func processKubeServices(nodeList *v1.NodeList, podList *v1.PodList, endpointList *v1.EndpointsList) {
 	if nodeList.Items == nil || podList.Items == nil || endpointList.Items == nil {
 		return
 	}
 	log.Debugf("Identified: %d node, %d pod, %d endpoints", len(nodeList.Items), len(podList.Items), len(endpointList.Items))
+
+	metadataExpiryMutex.Lock()
+	defer metadataExpiryMutex.Unlock()
+	now := time.Now()
+	refreshExpiry := now.Sub(lastMetadataExpiryRefresh) >= metadataMapExpire/2
+
+	// the strategy is picked once for the whole cluster
+	strategy := mappingStrategy(*podList, *endpointList)
+	written, unchanged := 0, 0
 	for _, node := range nodeList.Items {
 		nodeName := *node.Metadata.Name
 		nodeNameCacheKey := cache.BuildAgentKey(metadataMapperCachePrefix, nodeName)
-		metaBundle, found := cache.Cache.Get(nodeNameCacheKey)
+		cached, found := cache.Cache.Get(nodeNameCacheKey)
 		if !found {
-			metaBundle = newMetadataMapperBundle()
+			cached = newMetadataMapperBundle()
 		}
-		err := metaBundle.(*MetadataMapperBundle).mapServices(nodeName, *podList, *endpointList)
+		metaBundle := cached.(*MetadataMapperBundle)
+		err := metaBundle.mapServicesWithStrategy(strategy, nodeName, *podList, *endpointList)
 		if err != nil {
 			log.Errorf("Could not map the services: %s on node %s", err.Error(), *node.Metadata.Name)
 			continue
 		}
+		if !metaBundle.updateChecksum() && found && !refreshExpiry {
+			unchanged++
+			continue
+		}
 		cache.Cache.Set(nodeNameCacheKey, metaBundle, metadataMapExpire)
+		written++
+	}
+	if refreshExpiry {
+		lastMetadataExpiryRefresh = now
 	}
+	log.Debugf("Metadata mapping: %d bundles written to the cache, %d unchanged", written, unchanged)
 }
 
 // StartMetadataMapping is only called once, when we have confirmed we could correctly connect to the API server.
 // The logic here is solely to retrieve Nodes, Pods and Endpoints. The processing part is in mapServices.
 func (c *APIClient) StartMetadataMapping() {
+	if c.getResourcesAuth().metadataMapperMode() == MetadataMapperDisabled {
+		log.Warnf("Not starting the metadata mapper, it is disabled or the endpoints can't be listed")
+		return
+	}
 	tickerSvcProcess := time.NewTicker(metadataPollIntl)
 	go func() {
 		for {
@@ -263,37 +358,54 @@ func aggregateCheckResourcesErrors(errorMessages []string) error {
 // Depending on the user's config we only trigger an error if necessary.
 // The Event check requires getting Events data.
 // The MetadataMapper case, requires access to Services, Nodes and Pods.
+// If kubernetes_apiserver_partial_rbac is set, the forbidden resources are recorded
+// and the features relying on them are degraded instead of failing the connection.
 func (c *APIClient) checkResourcesAuth() error {
 	ctx, cancel := context.WithTimeout(context.Background(), c.timeout)
 	defer cancel()
 	var errorMessages []string
+	// errors other than forbidden always fail the connection, so that it is retried
+	onlyForbidden := true
+	listed := func(resource string, err error) bool {
+		if err == nil {
+			return true
+		}
+		errorMessages = append(errorMessages, fmt.Sprintf("%s collection: %q", resource, err.Error()))
+		onlyForbidden = onlyForbidden && isForbidden(err)
+		return false
+	}
+	resources := resourcesAuth{checked: true}
 
 	// We always want to collect events
 	_, err := c.client.CoreV1().ListEvents(ctx, "")
-	if err != nil {
-		errorMessages = append(errorMessages, fmt.Sprintf("event collection: %q", err.Error()))
-	}
+	resources.events = listed("event", err)
 
-	if config.Datadog.GetBool("use_metadata_mapper") == false {
-		return aggregateCheckResourcesErrors(errorMessages)
-	}
-	_, err = c.client.CoreV1().ListServices(ctx, "")
-	if err != nil {
-		errorMessages = append(errorMessages, fmt.Sprintf("service collection: %q", err.Error()))
-	}
-	_, err = c.client.CoreV1().ListPods(ctx, "")
-	if err != nil {
-		errorMessages = append(errorMessages, fmt.Sprintf("pod collection: %q", err.Error()))
+	if config.Datadog.GetBool("use_metadata_mapper") {
+		_, err = c.client.CoreV1().ListServices(ctx, "")
+		resources.services = listed("service", err)
+		_, err = c.client.CoreV1().ListPods(ctx, "")
+		resources.pods = listed("pod", err)
+		_, err = c.client.CoreV1().ListNodes(ctx)
+		resources.nodes = listed("node", err)
+		_, err = c.client.CoreV1().ListEndpoints(ctx, "")
+		resources.endpoints = listed("endpoints", err)
 	}
-	_, err = c.client.CoreV1().ListNodes(ctx)
-	if err != nil {
-		errorMessages = append(errorMessages, fmt.Sprintf("node collection: %q", err.Error()))
-	}
-	_, err = c.client.CoreV1().ListEndpoints(ctx, "")
-	if err != nil {
-		errorMessages = append(errorMessages, fmt.Sprintf("endpoints collection: %q", err.Error()))
+
+	err = aggregateCheckResourcesErrors(errorMessages)
+	if err != nil && (!onlyForbidden || resources.noneAllowed() || !config.Datadog.GetBool("kubernetes_apiserver_partial_rbac")) {
+		return err
 	}
-	return aggregateCheckResourcesErrors(errorMessages)
+	c.resourcesMutex.Lock()
+	c.resources = resources
+	c.resourcesMutex.Unlock()
+	return nil
+}
+
+// getResourcesAuth returns the resources the client is allowed to list
+func (c *APIClient) getResourcesAuth() resourcesAuth {
+	c.resourcesMutex.RLock()
+	defer c.resourcesMutex.RUnlock()
+	return c.resources
 }
 
 // ParseKubeConfig reads and unmarshal a kubeconfig file
@@ -314,6 +426,9 @@ func ParseKubeConfig(fpath string) (*k8s.Config, error) {
 func (c *APIClient) ComponentStatuses() (*v1.ComponentStatusList, error) {
 	ctx, cancel := context.WithTimeout(context.Background(), c.timeout)
 	defer cancel()
+	if err := c.throttle(ctx); err != nil {
+		return nil, err
+	}
 	return c.client.CoreV1().ListComponentStatuses(ctx)
 }
 
@@ -321,6 +436,9 @@ func (c *APIClient) ComponentStatuses() (*v1.ComponentStatusList, error) {
 func (c *APIClient) GetTokenFromConfigmap(token string, tokenTimeout int64) (string, bool, error) {
 	ctx, cancel := context.WithTimeout(context.Background(), c.timeout)
 	defer cancel()
+	if err := c.throttle(ctx); err != nil {
+		return "", false, err
+	}
 	namespace := GetResourcesNamespace()
 	tokenConfigMap, err := c.client.CoreV1().GetConfigMap(ctx, configMapDCAToken, namespace)
 	if err != nil {
@@ -350,12 +468,20 @@ func (c *APIClient) GetTokenFromConfigmap(token string, tokenTimeout int64) (str
 	if err != nil {
 		return "", found, log.Errorf("could not convert the timestamp associated with %s from the ConfigMap %s", token, configMapDCAToken)
 	}
-	tokenAge := time.Now().Unix() - tokenTime.Unix()
+	// the timestamp was written by another process, possibly on another
+	// node: it can only be compared to the wall clock
+	tokenAge := c.clock.Since(tokenTime)
 
-	if tokenAge > tokenTimeout {
+	if tokenAge > time.Duration(tokenTimeout)*time.Second {
 		log.Debugf("The tokenValue %s is outdated, refreshing the state", token)
 		return tokenValue, found, ErrOutdated
 	}
+	if tokenAge < -time.Minute {
+		// RFC822 timestamps are truncated to the minute, more than that
+		// means the clocks disagree: don't trust the token
+		log.Debugf("The timestamp of the tokenValue %s is %s in the future, refreshing the state", token, -tokenAge)
+		return tokenValue, found, ErrOutdated
+	}
 	log.Debugf("Token %s was updated recently, using value to collect newer events.", token)
 	return tokenValue, found, nil
 }
@@ -365,6 +491,9 @@ func (c *APIClient) GetTokenFromConfigmap(token string, tokenTimeout int64) (str
 func (c *APIClient) UpdateTokenInConfigmap(token, tokenValue string) error {
 	ctx, cancel := context.WithTimeout(context.Background(), c.timeout)
 	defer cancel()
+	if err := c.throttle(ctx); err != nil {
+		return err
+	}
 	namespace := GetResourcesNamespace()
 	tokenConfigMap, err := c.client.CoreV1().GetConfigMap(ctx, configMapDCAToken, namespace)
 	if err != nil {
@@ -374,7 +503,7 @@ func (c *APIClient) UpdateTokenInConfigmap(token, tokenValue string) error {
 	eventTokenKey := fmt.Sprintf("%s.%s", token, tokenKey)
 	tokenConfigMap.Data[eventTokenKey] = tokenValue
 
-	now := time.Now()
+	now := c.clock.Now()
 	eventTokenTS := fmt.Sprintf("%s.%s", token, tokenTime)
 	tokenConfigMap.Data[eventTokenTS] = now.Format(time.RFC822) // Timestamps in the ConfigMap should all use the type int.
 
@@ -390,6 +519,9 @@ func (c *APIClient) UpdateTokenInConfigmap(token, tokenValue string) error {
 func (c *APIClient) NodeLabels(nodeName string) (map[string]string, error) {
 	ctx, cancel := context.WithTimeout(context.Background(), c.timeout)
 	defer cancel()
+	if err := c.throttle(ctx); err != nil {
+		return nil, err
+	}
 	node, err := c.client.CoreV1().GetNode(ctx, nodeName)
 	if err != nil {
 		return nil, err
@@ -459,6 +591,17 @@ func getNodeList() ([]*v1.Node, error) {
 		log.Errorf("Can't create client to query the API Server: %s", err.Error())
 		return nil, err
 	}
+	if err := cl.throttle(ctx); err != nil {
+		return nil, err
+	}
+	if !cl.getResourcesAuth().nodes {
+		endpointList, err := cl.client.CoreV1().ListEndpoints(ctx, k8s.AllNamespaces)
+		if err != nil {
+			log.Errorf("Can't list endpoints from the API server: %s", err.Error())
+			return nil, err
+		}
+		return nodesFromEndpoints(endpointList).Items, nil
+	}
 	nodes, err := cl.client.CoreV1().ListNodes(ctx)
 	if err != nil {
 		log.Errorf("Can't list nodes from the API server: %s", err.Error())
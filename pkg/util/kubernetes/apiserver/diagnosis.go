@@ -23,5 +23,5 @@ func diagnose() error {
 	if err != nil {
 		log.Error(err)
 	}
-	return err
+	return diagnosis.WithRemediation(err, "check the kubernetes_kubeconfig_path setting, or the RBAC permissions of the agent service account")
 }
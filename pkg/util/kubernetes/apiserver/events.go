@@ -54,6 +54,10 @@ func (c *APIClient) LatestEvents(since string) ([]*v1.Event, []*v1.Event, string
 	ctx, cancel := context.WithTimeout(context.Background(), c.timeout)
 	defer cancel()
 
+	if err := c.throttle(ctx); err != nil {
+		return addedEvents, modifiedEvents, since, err
+	}
+
 	watcher, err := c.client.CoreV1().WatchEvents(ctx, "", sinceOption)
 	if err != nil {
 		return addedEvents, modifiedEvents, since, err
@@ -0,0 +1,122 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2018 Datadog, Inc.
+
+// +build kubeapiserver
+
+package apiserver
+
+import (
+	"context"
+	"time"
+
+	metav1 "github.com/ericchiang/k8s/apis/meta/v1"
+
+	"github.com/DataDog/datadog-agent/pkg/util/cache"
+)
+
+const (
+	ownersCachePrefix = "KubernetesOwners"
+	ownersCacheExpire = 5 * time.Minute
+	// maxOwnerDepth bounds the walk of the owner chain, the usual chains
+	// are at most pod -> replicaset -> deployment or pod -> job -> cronjob
+	maxOwnerDepth = 4
+)
+
+// Owner is a link of the owner chain of a Kubernetes object
+type Owner struct {
+	Kind string
+	Name string
+}
+
+// OwnerChain returns the owners of an object, from its direct controller up
+// to the top-level workload, e.g. the replicaset then the deployment of a pod.
+// Only pods, replicasets and jobs are queried, the other kinds are considered
+// as top-level. The chains are cached for a few minutes.
+func (c *APIClient) OwnerChain(namespace, kind, name string) ([]Owner, error) {
+	cacheKey := cache.BuildAgentKey(ownersCachePrefix, namespace, kind, name)
+	if cached, found := cache.Cache.Get(cacheKey); found {
+		if chain, ok := cached.([]Owner); ok {
+			return chain, nil
+		}
+	}
+
+	var chain []Owner
+	for i := 0; i < maxOwnerDepth; i++ {
+		owner, err := c.controllerOf(namespace, kind, name)
+		if err != nil {
+			return nil, err
+		}
+		if owner == nil {
+			break
+		}
+		chain = append(chain, *owner)
+		kind, name = owner.Kind, owner.Name
+	}
+
+	cache.Cache.Set(cacheKey, chain, ownersCacheExpire)
+	return chain, nil
+}
+
+// controllerOf returns the controller owning an object, or nil if it has none
+// or if its kind is not resolved
+func (c *APIClient) controllerOf(namespace, kind, name string) (*Owner, error) {
+	var meta *metav1.ObjectMeta
+
+	ctx, cancel := context.WithTimeout(context.Background(), c.timeout)
+	defer cancel()
+
+	switch kind {
+	case "Pod":
+		if err := c.throttle(ctx); err != nil {
+			return nil, err
+		}
+		pod, err := c.client.CoreV1().GetPod(ctx, name, namespace)
+		if err != nil {
+			return nil, err
+		}
+		meta = pod.GetMetadata()
+	case "ReplicaSet":
+		if err := c.throttle(ctx); err != nil {
+			return nil, err
+		}
+		rs, err := c.client.ExtensionsV1Beta1().GetReplicaSet(ctx, name, namespace)
+		if err != nil {
+			return nil, err
+		}
+		meta = rs.GetMetadata()
+	case "Job":
+		if err := c.throttle(ctx); err != nil {
+			return nil, err
+		}
+		job, err := c.client.BatchV1().GetJob(ctx, name, namespace)
+		if err != nil {
+			return nil, err
+		}
+		meta = job.GetMetadata()
+	default:
+		return nil, nil
+	}
+
+	return controllerFromMeta(meta), nil
+}
+
+// controllerFromMeta returns the owner flagged as controller, falling back
+// to the first owner for objects created before the flag was introduced
+func controllerFromMeta(meta *metav1.ObjectMeta) *Owner {
+	var found *metav1.OwnerReference
+	for _, ref := range meta.GetOwnerReferences() {
+		if ref.GetController() {
+			found = ref
+			break
+		}
+		if found == nil {
+			found = ref
+		}
+	}
+	if found == nil || found.GetKind() == "" || found.GetName() == "" {
+		return nil
+	}
+	return &Owner{Kind: found.GetKind(), Name: found.GetName()}
+}
@@ -0,0 +1,46 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2018 Datadog, Inc.
+
+// +build kubeapiserver
+
+package apiserver
+
+import (
+	"testing"
+
+	metav1 "github.com/ericchiang/k8s/apis/meta/v1"
+	"github.com/stretchr/testify/assert"
+)
+
+func ownerRef(kind, name string, controller bool) *metav1.OwnerReference {
+	return &metav1.OwnerReference{
+		Kind:       &kind,
+		Name:       &name,
+		Controller: &controller,
+	}
+}
+
+func TestControllerFromMeta(t *testing.T) {
+	// no owner
+	assert.Nil(t, controllerFromMeta(&metav1.ObjectMeta{}))
+
+	// the controller is preferred
+	meta := &metav1.ObjectMeta{
+		OwnerReferences: []*metav1.OwnerReference{
+			ownerRef("ConfigMap", "settings", false),
+			ownerRef("ReplicaSet", "dca-789976f5d7", true),
+		},
+	}
+	assert.Equal(t, &Owner{Kind: "ReplicaSet", Name: "dca-789976f5d7"}, controllerFromMeta(meta))
+
+	// falls back to the first owner without controller flag
+	meta = &metav1.ObjectMeta{
+		OwnerReferences: []*metav1.OwnerReference{
+			{Kind: toPtr("Deployment"), Name: toPtr("dca")},
+			ownerRef("ConfigMap", "settings", false),
+		},
+	}
+	assert.Equal(t, &Owner{Kind: "Deployment", Name: "dca"}, controllerFromMeta(meta))
+}
@@ -0,0 +1,174 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2018 Datadog, Inc.
+
+// +build kubeapiserver
+
+package apiserver
+
+import (
+	"net/http"
+
+	"github.com/ericchiang/k8s"
+	"github.com/ericchiang/k8s/api/v1"
+	metav1 "github.com/ericchiang/k8s/apis/meta/v1"
+
+	"github.com/DataDog/datadog-agent/pkg/config"
+)
+
+// Modes of the metadata mapper, depending on the resources the client can list
+const (
+	MetadataMapperFull     = "full"
+	MetadataMapperReduced  = "reduced"
+	MetadataMapperDisabled = "disabled"
+)
+
+// resourcesAuth records which resources the client is allowed to list,
+// as checked by checkResourcesAuth.
+type resourcesAuth struct {
+	// checked is false until the client is connected
+	checked   bool
+	events    bool
+	services  bool
+	pods      bool
+	nodes     bool
+	endpoints bool
+}
+
+// noneAllowed returns whether none of the checked resources can be listed
+func (a resourcesAuth) noneAllowed() bool {
+	return !a.events && !a.services && !a.pods && !a.nodes && !a.endpoints
+}
+
+// metadataMapperMode returns how the metadata mapper runs with these permissions.
+// The endpoints are required, they carry the services of the pods; the pods and
+// the nodes can be deduced from them.
+func (a resourcesAuth) metadataMapperMode() string {
+	if !config.Datadog.GetBool("use_metadata_mapper") || !a.endpoints {
+		return MetadataMapperDisabled
+	}
+	if a.pods && a.nodes {
+		return MetadataMapperFull
+	}
+	return MetadataMapperReduced
+}
+
+// limitations describes the features degraded by the missing permissions
+func (a resourcesAuth) limitations() []string {
+	var limitations []string
+	if !a.events {
+		limitations = append(limitations, "events can't be listed, they are not collected")
+	}
+	if !config.Datadog.GetBool("use_metadata_mapper") {
+		return limitations
+	}
+	if !a.endpoints {
+		return append(limitations, "endpoints can't be listed, the metadata mapper is disabled")
+	}
+	if !a.services {
+		limitations = append(limitations, "services can't be listed, they are identified from the endpoints")
+	}
+	if !a.pods {
+		limitations = append(limitations, "pods can't be listed, only the pods targeted by an endpoint are mapped")
+	}
+	if !a.nodes {
+		limitations = append(limitations, "nodes can't be listed, only the nodes hosting an endpoint are mapped")
+	}
+	return limitations
+}
+
+// isForbidden returns whether the apiserver denied the request to the client
+func isForbidden(err error) bool {
+	apiErr, ok := err.(*k8s.APIError)
+	return ok && apiErr.Code == http.StatusForbidden
+}
+
+// nodesFromEndpoints lists the nodes hosting the addresses of the endpoints,
+// used instead of the node list when the client can't list the nodes.
+func nodesFromEndpoints(endpointList *v1.EndpointsList) *v1.NodeList {
+	nodeList := &v1.NodeList{}
+	seen := make(map[string]bool)
+	forEachAddress(endpointList, func(address *v1.EndpointAddress) {
+		if address.NodeName == nil || *address.NodeName == "" || seen[*address.NodeName] {
+			return
+		}
+		seen[*address.NodeName] = true
+		nodeName := *address.NodeName
+		nodeList.Items = append(nodeList.Items, &v1.Node{Metadata: &metav1.ObjectMeta{Name: &nodeName}})
+	})
+	return nodeList
+}
+
+// podsFromEndpoints lists the pods targeted by the addresses of the endpoints,
+// used instead of the pod list when the client can't list the pods.
+func podsFromEndpoints(endpointList *v1.EndpointsList) *v1.PodList {
+	podList := &v1.PodList{}
+	seen := make(map[string]bool)
+	forEachAddress(endpointList, func(address *v1.EndpointAddress) {
+		target := address.TargetRef
+		if target == nil || target.GetKind() != "Pod" || target.GetName() == "" || address.Ip == nil {
+			return
+		}
+		key := target.GetNamespace() + "/" + target.GetName()
+		if seen[key] {
+			return
+		}
+		seen[key] = true
+		name, ip := target.GetName(), *address.Ip
+		podList.Items = append(podList.Items, &v1.Pod{
+			Metadata: &metav1.ObjectMeta{Name: &name},
+			Status:   &v1.PodStatus{PodIP: &ip},
+		})
+	})
+	return podList
+}
+
+func forEachAddress(endpointList *v1.EndpointsList, f func(*v1.EndpointAddress)) {
+	for _, endpoints := range endpointList.Items {
+		for _, subset := range endpoints.GetSubsets() {
+			for _, address := range subset.GetAddresses() {
+				if address != nil {
+					f(address)
+				}
+			}
+		}
+	}
+}
+
+// GetMetadataMapperStatus returns the mode of the metadata mapper and the
+// features degraded by missing permissions, for the status page.
+func GetMetadataMapperStatus() map[string]interface{} {
+	if globalAPIClient == nil {
+		return nil
+	}
+	auth := globalAPIClient.getResourcesAuth()
+	if !auth.checked {
+		return nil
+	}
+	return map[string]interface{}{
+		"mode":        auth.metadataMapperMode(),
+		"limitations": auth.limitations(),
+	}
+}
+
+// GetResourcesPermissions returns whether the client is allowed to list each
+// of the resources the agent relies on, nil until the client is connected.
+// The resources only needed by the metadata mapper are not checked when it is
+// disabled.
+func GetResourcesPermissions() map[string]bool {
+	if globalAPIClient == nil {
+		return nil
+	}
+	auth := globalAPIClient.getResourcesAuth()
+	if !auth.checked {
+		return nil
+	}
+	return map[string]bool{
+		"events":    auth.events,
+		"services":  auth.services,
+		"pods":      auth.pods,
+		"nodes":     auth.nodes,
+		"endpoints": auth.endpoints,
+	}
+}
@@ -0,0 +1,110 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2018 Datadog, Inc.
+
+// +build kubeapiserver
+
+package apiserver
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/ericchiang/k8s"
+	"github.com/ericchiang/k8s/api/v1"
+	metav1 "github.com/ericchiang/k8s/apis/meta/v1"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/DataDog/datadog-agent/pkg/config"
+	"github.com/DataDog/datadog-agent/pkg/util/cache"
+)
+
+func createTargetedEndpoints(svcName, nodeName string, targets map[string]string) *v1.Endpoints {
+	subset := &v1.EndpointSubset{}
+	for podName, ip := range targets {
+		subset.Addresses = append(subset.Addresses, &v1.EndpointAddress{
+			Ip:        toPtr(ip),
+			NodeName:  toPtr(nodeName),
+			TargetRef: &v1.ObjectReference{Kind: toPtr("Pod"), Namespace: toPtr("default"), Name: toPtr(podName)},
+		})
+	}
+	return &v1.Endpoints{
+		Metadata: &metav1.ObjectMeta{Name: toPtr(svcName)},
+		Subsets:  []*v1.EndpointSubset{subset},
+	}
+}
+
+func TestReducedMetadataMapping(t *testing.T) {
+	endpointList := &v1.EndpointsList{Items: []*v1.Endpoints{
+		createTargetedEndpoints("svc1", "node1", map[string]string{"pod1": "10.0.0.1", "pod2": "10.0.0.2"}),
+		createTargetedEndpoints("svc2", "node1", map[string]string{"pod1": "10.0.0.1"}),
+		createTargetedEndpoints("svc3", "node2", map[string]string{"pod3": "10.0.1.1"}),
+		// addresses without a pod target are ignored
+		{
+			Metadata: &metav1.ObjectMeta{Name: toPtr("external")},
+			Subsets:  []*v1.EndpointSubset{{Addresses: []*v1.EndpointAddress{{Ip: toPtr("192.168.0.1")}}}},
+		},
+	}}
+
+	nodeList := nodesFromEndpoints(endpointList)
+	var nodeNames []string
+	for _, node := range nodeList.Items {
+		nodeNames = append(nodeNames, *node.Metadata.Name)
+	}
+	assert.ElementsMatch(t, []string{"node1", "node2"}, nodeNames)
+
+	podList := podsFromEndpoints(endpointList)
+	require.Len(t, podList.Items, 3)
+
+	processKubeServices(nodeList, podList, endpointList)
+
+	bundle, err := getMetadataMapBundle("node1")
+	require.NoError(t, err)
+	svcs, found := bundle.ServicesForPod("pod1")
+	assert.True(t, found)
+	assert.ElementsMatch(t, []string{"svc1", "svc2"}, svcs)
+	svcs, found = bundle.ServicesForPod("pod2")
+	assert.True(t, found)
+	assert.Equal(t, []string{"svc1"}, svcs)
+
+	bundle, err = getMetadataMapBundle("node2")
+	require.NoError(t, err)
+	svcs, found = bundle.ServicesForPod("pod3")
+	assert.True(t, found)
+	assert.Equal(t, []string{"svc3"}, svcs)
+
+	cache.Cache.Delete(cache.BuildAgentKey(metadataMapperCachePrefix, "node1"))
+	cache.Cache.Delete(cache.BuildAgentKey(metadataMapperCachePrefix, "node2"))
+}
+
+func TestResourcesAuth(t *testing.T) {
+	full := resourcesAuth{checked: true, events: true, services: true, pods: true, nodes: true, endpoints: true}
+	assert.Equal(t, MetadataMapperFull, full.metadataMapperMode())
+	assert.Empty(t, full.limitations())
+
+	noPods := full
+	noPods.pods = false
+	assert.Equal(t, MetadataMapperReduced, noPods.metadataMapperMode())
+	assert.Len(t, noPods.limitations(), 1)
+
+	noEndpoints := full
+	noEndpoints.endpoints = false
+	noEndpoints.events = false
+	assert.Equal(t, MetadataMapperDisabled, noEndpoints.metadataMapperMode())
+	assert.Len(t, noEndpoints.limitations(), 2)
+	assert.False(t, noEndpoints.noneAllowed())
+	assert.True(t, resourcesAuth{checked: true}.noneAllowed())
+
+	config.Datadog.Set("use_metadata_mapper", false)
+	defer config.Datadog.Set("use_metadata_mapper", true)
+	assert.Equal(t, MetadataMapperDisabled, full.metadataMapperMode())
+	assert.Len(t, noEndpoints.limitations(), 1, "only the events are reported without the metadata mapper")
+}
+
+func TestIsForbidden(t *testing.T) {
+	assert.True(t, isForbidden(&k8s.APIError{Code: 403}))
+	assert.False(t, isForbidden(&k8s.APIError{Code: 500}))
+	assert.False(t, isForbidden(fmt.Errorf("connection refused")))
+}
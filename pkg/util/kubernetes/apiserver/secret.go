@@ -0,0 +1,88 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2018 Datadog, Inc.
+
+// +build kubeapiserver
+
+package apiserver
+
+import (
+	log "github.com/cihub/seelog"
+	"k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	corev1 "k8s.io/client-go/kubernetes/typed/core/v1"
+)
+
+// secretTokenKey is the key of the token in the Secret, node agents
+// mount it as their cluster_agent_auth_token file
+const secretTokenKey = "token"
+
+// SecretTokenStore stores the Cluster Agent auth token in a Kubernetes Secret
+// of the resources namespace. Unlike a ConfigMap, the token isn't readable by
+// everyone allowed to read the configuration of the cluster.
+type SecretTokenStore struct {
+	client    corev1.SecretsGetter
+	name      string
+	namespace string
+}
+
+// NewSecretTokenStore returns a SecretTokenStore for the Secret `name`
+func NewSecretTokenStore(name string) (*SecretTokenStore, error) {
+	client, err := GetCoreV1Client()
+	if err != nil {
+		return nil, err
+	}
+	return &SecretTokenStore{
+		client:    client,
+		name:      name,
+		namespace: GetResourcesNamespace(),
+	}, nil
+}
+
+// Load returns the token stored in the Secret, or an empty string if the
+// Secret doesn't exist yet
+func (s *SecretTokenStore) Load() (string, error) {
+	secret, err := s.client.Secrets(s.namespace).Get(s.name, metav1.GetOptions{})
+	if errors.IsNotFound(err) {
+		log.Debugf("The secret %s/%s doesn't exist yet", s.namespace, s.name)
+		return "", nil
+	}
+	if err != nil {
+		return "", err
+	}
+	return string(secret.Data[secretTokenKey]), nil
+}
+
+// Save updates the token stored in the Secret, creating the Secret if needed
+func (s *SecretTokenStore) Save(token string) error {
+	secrets := s.client.Secrets(s.namespace)
+	secret, err := secrets.Get(s.name, metav1.GetOptions{})
+	if errors.IsNotFound(err) {
+		_, err = secrets.Create(&v1.Secret{
+			ObjectMeta: metav1.ObjectMeta{
+				Name: s.name,
+			},
+			Type: v1.SecretTypeOpaque,
+			Data: map[string][]byte{secretTokenKey: []byte(token)},
+		})
+		if err == nil {
+			log.Infof("Created the secret %s/%s", s.namespace, s.name)
+		}
+		return err
+	}
+	if err != nil {
+		return err
+	}
+
+	if secret.Data == nil {
+		secret.Data = make(map[string][]byte)
+	}
+	secret.Data[secretTokenKey] = []byte(token)
+	_, err = secrets.Update(secret)
+	if err == nil {
+		log.Debugf("Updated the token in the secret %s/%s", s.namespace, s.name)
+	}
+	return err
+}
@@ -9,32 +9,97 @@ package apiserver
 
 import (
 	"fmt"
+	"hash/fnv"
+	"sort"
+	"sync"
 
 	log "github.com/cihub/seelog"
 
 	"github.com/ericchiang/k8s/api/v1"
+
+	"github.com/DataDog/datadog-agent/pkg/config"
 )
 
-// mapServices maps each pod (endpoint) to the metadata associated with it.
-// It is on a per node basis to avoid mixing up the services pods are actually connected to if all pods of different nodes share a similar subnet, therefore sharing a similar IP.
-func (metaBundle *MetadataMapperBundle) mapServices(nodeName string, pods v1.PodList, endpointList v1.EndpointsList) error {
-	metaBundle.m.Lock()
-	defer metaBundle.m.Unlock()
-	ipToEndpoints := make(map[string][]string) // maps the IP address from an endpoint (pod) to associated services ex: "10.10.1.1" : ["service1","service2"]
-	podToIp := make(map[string]string)         // maps the pods of the currently evaluated node to their IP.
+// The strategies used to match the addresses of the endpoints to the pods,
+// set with the kubernetes_metadata_mapping_strategy option.
+const (
+	// MappingStrategyAuto picks the strategy for every mapping run, from the
+	// information exposed by the endpoints of the cluster
+	MappingStrategyAuto = "auto"
+	// MappingStrategyTargetRef matches the pod referenced by the address
+	MappingStrategyTargetRef = "target_ref"
+	// MappingStrategyPodIP matches the IP of the address with the IP of the pod
+	MappingStrategyPodIP = "pod_ip"
+	// MappingStrategyHostname matches the hostname of the address with the
+	// hostname of the pod, or its name if it has no hostname
+	MappingStrategyHostname = "hostname"
+)
 
-	if pods.Items == nil {
-		return fmt.Errorf("empty podlist received for nodeName %q", nodeName)
+var (
+	// lastDetectedStrategy is the last strategy picked by MappingStrategyAuto,
+	// to log only when it changes
+	lastDetectedStrategy   string
+	lastDetectedStrategyMu sync.Mutex
+)
+
+// mappingStrategy returns the strategy configured to map the endpoints to
+// the pods, detecting it from the endpoints if it is set to auto.
+func mappingStrategy(pods v1.PodList, endpointList v1.EndpointsList) string {
+	strategy := config.Datadog.GetString("kubernetes_metadata_mapping_strategy")
+	switch strategy {
+	case MappingStrategyTargetRef, MappingStrategyPodIP, MappingStrategyHostname:
+		return strategy
+	case MappingStrategyAuto, "":
+	default:
+		log.Warnf("Unknown metadata mapping strategy %q, detecting it from the endpoints", strategy)
 	}
-	if nodeName == "" {
-		log.Debugf("Service mapper was given an empty node name. Mapping might be incorrect.")
+
+	strategy = detectMappingStrategy(pods, endpointList)
+	lastDetectedStrategyMu.Lock()
+	if strategy != lastDetectedStrategy {
+		log.Infof("Mapping the endpoints to the pods with the %s strategy", strategy)
+		lastDetectedStrategy = strategy
 	}
+	lastDetectedStrategyMu.Unlock()
+	return strategy
+}
 
+// detectMappingStrategy picks target_ref if all the addresses reference a pod,
+// pod_ip if some of them have the IP of a pod, hostname otherwise: some CNIs
+// only expose the IPs, and the addresses of the services without selector
+// have neither a target reference nor the IP of a pod.
+func detectMappingStrategy(pods v1.PodList, endpointList v1.EndpointsList) string {
+	podIPs := make(map[string]bool)
 	for _, pod := range pods.Items {
-		if *pod.Status.PodIP != "" {
-			podToIp[*pod.Metadata.Name] = *pod.Status.PodIP
+		if ip := pod.GetStatus().GetPodIP(); ip != "" {
+			podIPs[ip] = true
+		}
+	}
+
+	addresses, withTargetRef, withPodIP := 0, 0, 0
+	forEachEndpointAddress(endpointList, func(_ string, address *v1.EndpointAddress) {
+		addresses++
+		if ref := address.GetTargetRef(); ref.GetKind() == "Pod" && ref.GetName() != "" {
+			withTargetRef++
 		}
+		if podIPs[address.GetIp()] {
+			withPodIP++
+		}
+	})
+
+	switch {
+	case addresses > 0 && withTargetRef == addresses:
+		return MappingStrategyTargetRef
+	case withPodIP > 0 || addresses == 0:
+		return MappingStrategyPodIP
+	default:
+		return MappingStrategyHostname
 	}
+}
+
+// forEachEndpointAddress calls f with the name of the service and every
+// address of its endpoints.
+func forEachEndpointAddress(endpointList v1.EndpointsList, f func(svcName string, address *v1.EndpointAddress)) {
 	for _, svc := range endpointList.Items {
 		for _, endpointsSubsets := range svc.Subsets {
 			if endpointsSubsets.Addresses == nil {
@@ -46,15 +111,84 @@ func (metaBundle *MetadataMapperBundle) mapServices(nodeName string, pods v1.Pod
 					log.Tracef("An endpoint from %s could not be evaluated", *svc.Metadata.Name)
 					continue
 				}
-				if edpt.NodeName != nil && *edpt.NodeName == nodeName {
-					ipToEndpoints[*edpt.Ip] = append(ipToEndpoints[*edpt.Ip], *svc.Metadata.Name)
-				}
+				f(*svc.Metadata.Name, edpt)
 			}
 		}
 	}
-	for name, ip := range podToIp {
-		if svc, found := ipToEndpoints[ip]; found {
+}
+
+// podKey returns the key matching a pod with the addresses of the endpoints,
+// depending on the mapping strategy.
+func podKey(strategy string, pod *v1.Pod) string {
+	switch strategy {
+	case MappingStrategyTargetRef:
+		return pod.GetMetadata().GetName()
+	case MappingStrategyHostname:
+		if hostname := pod.GetSpec().GetHostname(); hostname != "" {
+			return hostname
+		}
+		return pod.GetMetadata().GetName()
+	default:
+		return pod.GetStatus().GetPodIP()
+	}
+}
+
+// addressKey returns the key matching an address of the endpoints with a pod,
+// depending on the mapping strategy.
+func addressKey(strategy string, address *v1.EndpointAddress) string {
+	switch strategy {
+	case MappingStrategyTargetRef:
+		if ref := address.GetTargetRef(); ref.GetKind() == "Pod" {
+			return ref.GetName()
+		}
+		return ""
+	case MappingStrategyHostname:
+		return address.GetHostname()
+	default:
+		return address.GetIp()
+	}
+}
+
+// mapServices maps each pod (endpoint) to the metadata associated with it.
+// It is on a per node basis to avoid mixing up the services pods are actually connected to if all pods of different nodes share a similar subnet, therefore sharing a similar IP.
+// The pods are matched with the addresses of the endpoints with the strategy
+// set by kubernetes_metadata_mapping_strategy.
+func (metaBundle *MetadataMapperBundle) mapServices(nodeName string, pods v1.PodList, endpointList v1.EndpointsList) error {
+	return metaBundle.mapServicesWithStrategy(mappingStrategy(pods, endpointList), nodeName, pods, endpointList)
+}
+
+// mapServicesWithStrategy maps the pods of the node to their services, matching
+// them with the addresses of the endpoints with the given strategy.
+func (metaBundle *MetadataMapperBundle) mapServicesWithStrategy(strategy, nodeName string, pods v1.PodList, endpointList v1.EndpointsList) error {
+	metaBundle.m.Lock()
+	defer metaBundle.m.Unlock()
+	keyToEndpoints := make(map[string][]string) // maps the key of an endpoint (pod) to associated services ex: "10.10.1.1" : ["service1","service2"]
+	podToKey := make(map[string]string)         // maps the pods to their key, ex: their IP with the pod_ip strategy.
+
+	if pods.Items == nil {
+		return fmt.Errorf("empty podlist received for nodeName %q", nodeName)
+	}
+	if nodeName == "" {
+		log.Debugf("Service mapper was given an empty node name. Mapping might be incorrect.")
+	}
+
+	for _, pod := range pods.Items {
+		if key := podKey(strategy, pod); key != "" {
+			podToKey[pod.GetMetadata().GetName()] = key
+		}
+	}
+	forEachEndpointAddress(endpointList, func(svcName string, edpt *v1.EndpointAddress) {
+		if edpt.NodeName == nil || *edpt.NodeName != nodeName {
+			return
+		}
+		if key := addressKey(strategy, edpt); key != "" {
+			keyToEndpoints[key] = append(keyToEndpoints[key], svcName)
+		}
+	})
+	for name, key := range podToKey {
+		if svc, found := keyToEndpoints[key]; found {
 			metaBundle.PodNameToService[name] = svc
+			log.Tracef("Pod %s mapped to the services %q with the %s strategy", name, svc, strategy)
 		}
 	}
 	log.Tracef("The services matched %q", fmt.Sprintf("%s", metaBundle.PodNameToService))
@@ -69,3 +203,31 @@ func (metaBundle *MetadataMapperBundle) ServicesForPod(podName string) ([]string
 	metaBundle.m.RUnlock()
 	return svc, found
 }
+
+// updateChecksum computes the checksum of the mapping and returns whether it
+// changed since the previous call.
+func (metaBundle *MetadataMapperBundle) updateChecksum() bool {
+	metaBundle.m.Lock()
+	defer metaBundle.m.Unlock()
+
+	podNames := make([]string, 0, len(metaBundle.PodNameToService))
+	for name := range metaBundle.PodNameToService {
+		podNames = append(podNames, name)
+	}
+	sort.Strings(podNames)
+
+	h := fnv.New64a()
+	for _, name := range podNames {
+		h.Write([]byte(name))
+		for _, svc := range metaBundle.PodNameToService[name] {
+			h.Write([]byte{0})
+			h.Write([]byte(svc))
+		}
+		h.Write([]byte{1})
+	}
+	checksum := h.Sum64()
+
+	changed := checksum != metaBundle.checksum
+	metaBundle.checksum = checksum
+	return changed
+}
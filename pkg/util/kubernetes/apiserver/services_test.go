@@ -11,10 +11,13 @@ import (
 	"fmt"
 	"sync"
 	"testing"
+	"time"
 
 	"github.com/ericchiang/k8s/api/v1"
 	metav1 "github.com/ericchiang/k8s/apis/meta/v1"
 	"github.com/stretchr/testify/assert"
+
+	"github.com/DataDog/datadog-agent/pkg/util/cache"
 )
 
 type podTest struct {
@@ -173,3 +176,154 @@ func TestMapServices(t *testing.T) {
 	defer allBundleMu.RUnlock()
 	assert.Equal(t, expectedAllPodNameToService, allCasesBundle.PodNameToService)
 }
+
+// createAddressList returns the endpoints of a service on nodeName, with the
+// given addresses
+func createAddressList(nodeName string, svcName string, addresses ...*v1.EndpointAddress) v1.EndpointsList {
+	for _, address := range addresses {
+		address.NodeName = &nodeName
+	}
+	return v1.EndpointsList{
+		Items: []*v1.Endpoints{
+			{
+				Metadata: &metav1.ObjectMeta{Name: toPtr(svcName)},
+				Subsets:  []*v1.EndpointSubset{{Addresses: addresses}},
+			},
+		},
+	}
+}
+
+func TestMapServicesStrategies(t *testing.T) {
+	podList := createPodList([]podTest{
+		{ip: "1.1.1.1", name: "pod1_name"},
+		{ip: "2.2.2.2", name: "pod2_name"},
+	})
+	podList.Items[1].Spec = &v1.PodSpec{Hostname: toPtr("pod2-host")}
+
+	testCases := []struct {
+		strategy        string
+		address         *v1.EndpointAddress
+		expectedMapping map[string][]string
+	}{
+		{
+			strategy: MappingStrategyTargetRef,
+			address: &v1.EndpointAddress{
+				Ip:        toPtr("10.0.0.1"),
+				TargetRef: &v1.ObjectReference{Kind: toPtr("Pod"), Name: toPtr("pod1_name")},
+			},
+			expectedMapping: map[string][]string{"pod1_name": {"svc1"}},
+		},
+		{
+			strategy:        MappingStrategyPodIP,
+			address:         &v1.EndpointAddress{Ip: toPtr("2.2.2.2")},
+			expectedMapping: map[string][]string{"pod2_name": {"svc1"}},
+		},
+		{
+			strategy:        MappingStrategyHostname,
+			address:         &v1.EndpointAddress{Ip: toPtr("10.0.0.2"), Hostname: toPtr("pod2-host")},
+			expectedMapping: map[string][]string{"pod2_name": {"svc1"}},
+		},
+		{
+			// pods without hostname are matched by name
+			strategy:        MappingStrategyHostname,
+			address:         &v1.EndpointAddress{Ip: toPtr("10.0.0.3"), Hostname: toPtr("pod1_name")},
+			expectedMapping: map[string][]string{"pod1_name": {"svc1"}},
+		},
+		{
+			// a reference to another kind of object is not a pod
+			strategy: MappingStrategyTargetRef,
+			address: &v1.EndpointAddress{
+				Ip:        toPtr("1.1.1.1"),
+				TargetRef: &v1.ObjectReference{Kind: toPtr("Node"), Name: toPtr("pod1_name")},
+			},
+			expectedMapping: map[string][]string{},
+		},
+	}
+	for i, testCase := range testCases {
+		t.Run(fmt.Sprintf("#%d %s", i, testCase.strategy), func(t *testing.T) {
+			bundle := newMetadataMapperBundle()
+			epList := createAddressList("firstNode", "svc1", testCase.address)
+			err := bundle.mapServicesWithStrategy(testCase.strategy, "firstNode", podList, epList)
+			assert.NoError(t, err)
+			assert.Equal(t, testCase.expectedMapping, bundle.PodNameToService)
+		})
+	}
+}
+
+func TestDetectMappingStrategy(t *testing.T) {
+	podList := createPodList([]podTest{{ip: "1.1.1.1", name: "pod1_name"}})
+	podRef := &v1.ObjectReference{Kind: toPtr("Pod"), Name: toPtr("pod1_name")}
+
+	epList := createAddressList("firstNode", "svc1",
+		&v1.EndpointAddress{Ip: toPtr("1.1.1.1"), TargetRef: podRef},
+		&v1.EndpointAddress{Ip: toPtr("10.0.0.1"), TargetRef: podRef},
+	)
+	assert.Equal(t, MappingStrategyTargetRef, detectMappingStrategy(podList, epList))
+
+	epList = createAddressList("firstNode", "svc1",
+		&v1.EndpointAddress{Ip: toPtr("1.1.1.1"), TargetRef: podRef},
+		&v1.EndpointAddress{Ip: toPtr("10.0.0.1")},
+	)
+	assert.Equal(t, MappingStrategyPodIP, detectMappingStrategy(podList, epList))
+
+	epList = createAddressList("firstNode", "svc1",
+		&v1.EndpointAddress{Ip: toPtr("10.0.0.1"), Hostname: toPtr("pod1_name")},
+	)
+	assert.Equal(t, MappingStrategyHostname, detectMappingStrategy(podList, epList))
+
+	assert.Equal(t, MappingStrategyPodIP, detectMappingStrategy(podList, v1.EndpointsList{}))
+}
+
+func TestMetadataBundleChecksum(t *testing.T) {
+	bundle := newMetadataMapperBundle()
+	assert.True(t, bundle.updateChecksum())
+	assert.False(t, bundle.updateChecksum())
+
+	bundle.PodNameToService["pod1_name"] = []string{"svc1"}
+	assert.True(t, bundle.updateChecksum())
+	assert.False(t, bundle.updateChecksum())
+
+	bundle.PodNameToService["pod1_name"] = []string{"svc1", "svc2"}
+	assert.True(t, bundle.updateChecksum())
+
+	// the services are not concatenated in the checksum
+	bundle.PodNameToService["pod1_name"] = []string{"svc1svc2"}
+	assert.True(t, bundle.updateChecksum())
+}
+
+func TestProcessKubeServicesSkipsUnchanged(t *testing.T) {
+	node := createNode("skipNode")
+	nodeList := &v1.NodeList{Items: []*v1.Node{&node}}
+	podList := createPodList([]podTest{{ip: "1.1.1.1", name: "pod1_name"}})
+	epList := createSvcList("skipNode", []serviceTest{{svcName: "svc1", podIps: []string{"1.1.1.1"}}})
+	cacheKey := cache.BuildAgentKey(metadataMapperCachePrefix, "skipNode")
+	defer cache.Cache.Delete(cacheKey)
+
+	processKubeServices(nodeList, &podList, &epList)
+	_, firstExpiry, found := cache.Cache.GetWithExpiration(cacheKey)
+	assert.True(t, found)
+
+	// unchanged bundle, its expiration is not refreshed
+	time.Sleep(time.Millisecond)
+	processKubeServices(nodeList, &podList, &epList)
+	_, expiry, _ := cache.Cache.GetWithExpiration(cacheKey)
+	assert.Equal(t, firstExpiry, expiry)
+
+	// the expiration of all the bundles is refreshed periodically
+	metadataExpiryMutex.Lock()
+	lastMetadataExpiryRefresh = time.Time{}
+	metadataExpiryMutex.Unlock()
+	processKubeServices(nodeList, &podList, &epList)
+	_, expiry, _ = cache.Cache.GetWithExpiration(cacheKey)
+	assert.True(t, expiry.After(firstExpiry))
+
+	// changed bundle
+	secondExpiry := expiry
+	time.Sleep(time.Millisecond)
+	epList = createSvcList("skipNode", []serviceTest{{svcName: "svc2", podIps: []string{"1.1.1.1"}}})
+	processKubeServices(nodeList, &podList, &epList)
+	bundle, expiry, _ := cache.Cache.GetWithExpiration(cacheKey)
+	assert.True(t, expiry.After(secondExpiry))
+	services, _ := bundle.(*MetadataMapperBundle).ServicesForPod("pod1_name")
+	assert.Equal(t, []string{"svc2"}, services)
+}
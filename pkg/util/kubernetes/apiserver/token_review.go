@@ -0,0 +1,112 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2018 Datadog, Inc.
+
+// +build kubeapiserver
+
+package apiserver
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	authenticationv1 "github.com/ericchiang/k8s/apis/authentication/v1"
+	authorizationv1 "github.com/ericchiang/k8s/apis/authorization/v1"
+
+	"github.com/DataDog/datadog-agent/pkg/util/cache"
+)
+
+const (
+	tokenReviewCachePrefix = "TokenReview"
+	tokenReviewCacheExpire = time.Minute
+)
+
+// tokenReviewResult is the cached outcome of the review of a token for a
+// given request
+type tokenReviewResult struct {
+	allowed bool
+	user    string
+}
+
+// TokenReviewAuthenticator authenticates the requests to the Cluster Agent
+// bearing a Kubernetes token, a ServiceAccount token typically: the token is
+// checked with a TokenReview, then the access of its user to the path of the
+// request with a SubjectAccessReview, so that it is granted by the RBAC rules
+// on the nonResourceURLs of the Cluster Agent API.
+type TokenReviewAuthenticator struct {
+	client *APIClient
+}
+
+// NewTokenReviewAuthenticator returns a TokenReviewAuthenticator sending its
+// reviews through the given client
+func NewTokenReviewAuthenticator(client *APIClient) *TokenReviewAuthenticator {
+	return &TokenReviewAuthenticator{client: client}
+}
+
+// Authenticate implements the DCAAuthenticator interface, the reviews are
+// cached for a minute to spare the apiserver
+func (a *TokenReviewAuthenticator) Authenticate(token string, r *http.Request) (bool, string, error) {
+	verb := strings.ToLower(r.Method)
+	hash := sha256.Sum256([]byte(token))
+	cacheKey := cache.BuildAgentKey(tokenReviewCachePrefix, hex.EncodeToString(hash[:]), verb, r.URL.Path)
+	if cached, found := cache.Cache.Get(cacheKey); found {
+		if result, ok := cached.(tokenReviewResult); ok {
+			return result.allowed, result.user, nil
+		}
+	}
+
+	result, err := a.review(token, verb, r.URL.Path)
+	if err != nil {
+		return false, "", err
+	}
+	cache.Cache.Set(cacheKey, result, tokenReviewCacheExpire)
+	return result.allowed, result.user, nil
+}
+
+func (a *TokenReviewAuthenticator) review(token, verb, path string) (tokenReviewResult, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), a.client.timeout)
+	defer cancel()
+
+	if err := a.client.throttle(ctx); err != nil {
+		return tokenReviewResult{}, err
+	}
+	tokenReview, err := a.client.client.AuthenticationV1().CreateTokenReview(ctx, &authenticationv1.TokenReview{
+		Spec: &authenticationv1.TokenReviewSpec{Token: &token},
+	})
+	if err != nil {
+		return tokenReviewResult{}, fmt.Errorf("could not review the token: %s", err)
+	}
+	status := tokenReview.GetStatus()
+	if !status.GetAuthenticated() {
+		return tokenReviewResult{}, nil
+	}
+	user := status.GetUser()
+
+	if err := a.client.throttle(ctx); err != nil {
+		return tokenReviewResult{}, err
+	}
+	accessReview, err := a.client.client.AuthorizationV1().CreateSubjectAccessReview(ctx, &authorizationv1.SubjectAccessReview{
+		Spec: &authorizationv1.SubjectAccessReviewSpec{
+			User:   user.Username,
+			Groups: user.GetGroups(),
+			Uid:    user.Uid,
+			NonResourceAttributes: &authorizationv1.NonResourceAttributes{
+				Path: &path,
+				Verb: &verb,
+			},
+		},
+	})
+	if err != nil {
+		return tokenReviewResult{}, fmt.Errorf("could not review the access of %s to %s: %s", user.GetUsername(), path, err)
+	}
+	return tokenReviewResult{
+		allowed: accessReview.GetStatus().GetAllowed(),
+		user:    user.GetUsername(),
+	}, nil
+}
@@ -23,5 +23,5 @@ func diagnose() error {
 	if err != nil {
 		log.Error(err)
 	}
-	return err
+	return diagnosis.WithRemediation(err, "check the kubernetes_kubelet_host, kubernetes_http_kubelet_port and kubernetes_https_kubelet_port settings, and that the agent service account is allowed to access the kubelet API")
 }
@@ -0,0 +1,234 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2018 Datadog, Inc.
+
+/*
+Package membudget bounds the memory used by the agent components buffering
+data: the logs pipelines, the cache and the aggregator contexts.
+
+The memory_budget.limit_mb setting is split between the components by their
+memory_budget.<component>_share. A component reserves the estimated size of
+the data it buffers before keeping it, and releases it once the data is
+gone. The lower the priority of the data, the sooner it is refused as the
+component fills its budget, so that the least important data is dropped
+first. The components which can't account for every allocation register a
+shedder instead, called periodically to free memory while they are over
+budget.
+
+Until Start is called, or when memory_budget.limit_mb is 0, the components
+are unbounded.
+*/
+package membudget
+
+import (
+	"expvar"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	log "github.com/cihub/seelog"
+
+	"github.com/DataDog/datadog-agent/pkg/config"
+)
+
+// Priority is the priority of the data reserving some budget
+type Priority int
+
+// The data of low priority are refused once half of the budget is used, the
+// data of normal priority once 80% is used, the data of high priority once
+// the budget is full.
+const (
+	PriorityLow Priority = iota
+	PriorityNormal
+	PriorityHigh
+)
+
+// shedInterval is the interval between the calls to the shedders
+const shedInterval = 15 * time.Second
+
+var thresholds = map[Priority]float64{
+	PriorityLow:    0.5,
+	PriorityNormal: 0.8,
+	PriorityHigh:   1,
+}
+
+// Shedder frees some of the memory of a component over its budget, and
+// returns the estimated memory it still uses
+type Shedder func(limit int64) int64
+
+// Component is the share of the memory budget of an agent component
+type Component struct {
+	name string
+	// accessed atomically
+	limit   int64
+	used    int64
+	dropped int64
+
+	shedder Shedder
+}
+
+var (
+	componentsMu sync.RWMutex
+	components   = make(map[string]*Component)
+
+	stop chan struct{}
+	done chan struct{}
+)
+
+func init() {
+	expvar.Publish("memory_budget", expvar.Func(func() interface{} { return Stats() }))
+}
+
+// Register returns the budget of a component, registering it if needed
+func Register(name string) *Component {
+	componentsMu.Lock()
+	defer componentsMu.Unlock()
+	c, found := components[name]
+	if !found {
+		c = &Component{name: name}
+		components[name] = c
+	}
+	return c
+}
+
+// RegisterShedder returns the budget of a component which doesn't reserve
+// its memory, shed is called periodically while it runs over budget
+func RegisterShedder(name string, shed Shedder) *Component {
+	c := Register(name)
+	componentsMu.Lock()
+	c.shedder = shed
+	componentsMu.Unlock()
+	return c
+}
+
+// Start applies the memory_budget settings to the registered components and
+// starts calling their shedders
+func Start() {
+	limit := config.Datadog.GetInt64("memory_budget.limit_mb") * 1024 * 1024
+	if limit <= 0 {
+		return
+	}
+
+	componentsMu.Lock()
+	defer componentsMu.Unlock()
+	if stop != nil {
+		return
+	}
+	for name, c := range components {
+		share := config.Datadog.GetFloat64("memory_budget." + name + "_share")
+		atomic.StoreInt64(&c.limit, int64(share*float64(limit)))
+		log.Infof("Memory budget of %s: %d bytes", name, atomic.LoadInt64(&c.limit))
+	}
+	stop = make(chan struct{})
+	done = make(chan struct{})
+	go run(stop, done)
+}
+
+// Stop stops the shedders, the components become unbounded
+func Stop() {
+	componentsMu.Lock()
+	s, d := stop, done
+	stop, done = nil, nil
+	for _, c := range components {
+		atomic.StoreInt64(&c.limit, 0)
+	}
+	componentsMu.Unlock()
+
+	if s != nil {
+		close(s)
+		<-d
+	}
+}
+
+func run(stop, done chan struct{}) {
+	defer close(done)
+	ticker := time.NewTicker(shedInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+			shed()
+		case <-stop:
+			return
+		}
+	}
+}
+
+// shed calls the shedders of the components over budget
+func shed() {
+	componentsMu.RLock()
+	defer componentsMu.RUnlock()
+	for _, c := range components {
+		if c.shedder == nil {
+			continue
+		}
+		limit := atomic.LoadInt64(&c.limit)
+		if limit <= 0 {
+			continue
+		}
+		used := c.shedder(limit)
+		atomic.StoreInt64(&c.used, used)
+		if used > limit {
+			log.Warnf("The %s are still over their memory budget after shedding: %d bytes used, %d allowed", c.name, used, limit)
+		}
+	}
+}
+
+// Reserve reserves size bytes for some data of the given priority. It
+// returns false, and the data must be dropped, if the component would then
+// use more than the share of its budget allowed to this priority.
+func (c *Component) Reserve(size int64, priority Priority) bool {
+	limit := atomic.LoadInt64(&c.limit)
+	used := atomic.AddInt64(&c.used, size)
+	if limit <= 0 || float64(used) <= thresholds[priority]*float64(limit) {
+		return true
+	}
+	atomic.AddInt64(&c.used, -size)
+	if atomic.AddInt64(&c.dropped, 1) == 1 {
+		log.Warnf("The %s are over their memory budget of %d bytes, dropping data", c.name, limit)
+	}
+	return false
+}
+
+// Release gives back size bytes reserved by Reserve
+func (c *Component) Release(size int64) {
+	atomic.AddInt64(&c.used, -size)
+}
+
+// Used returns the estimated memory used by the component
+func (c *Component) Used() int64 {
+	return atomic.LoadInt64(&c.used)
+}
+
+// Limit returns the memory budget of the component, 0 if it is unbounded
+func (c *Component) Limit() int64 {
+	return atomic.LoadInt64(&c.limit)
+}
+
+// Dropped returns the number of reservations refused
+func (c *Component) Dropped() int64 {
+	return atomic.LoadInt64(&c.dropped)
+}
+
+// SetLimit overrides the budget of the component until the next Start or
+// Stop, a limit of 0 makes it unbounded
+func (c *Component) SetLimit(limit int64) {
+	atomic.StoreInt64(&c.limit, limit)
+}
+
+// Stats returns the usage of the budget of every component
+func Stats() map[string]map[string]int64 {
+	componentsMu.RLock()
+	defer componentsMu.RUnlock()
+
+	stats := make(map[string]map[string]int64, len(components))
+	for name, c := range components {
+		stats[name] = map[string]int64{
+			"Used":    c.Used(),
+			"Limit":   c.Limit(),
+			"Dropped": c.Dropped(),
+		}
+	}
+	return stats
+}
@@ -0,0 +1,58 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2018 Datadog, Inc.
+
+package membudget
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestReservePriorities(t *testing.T) {
+	c := Register("test_priorities")
+	c.SetLimit(100)
+	defer c.SetLimit(0)
+
+	assert.True(t, c.Reserve(40, PriorityLow))
+	assert.False(t, c.Reserve(20, PriorityLow))
+	assert.True(t, c.Reserve(40, PriorityNormal))
+	assert.False(t, c.Reserve(10, PriorityNormal))
+	assert.True(t, c.Reserve(20, PriorityHigh))
+	assert.False(t, c.Reserve(1, PriorityHigh))
+	assert.Equal(t, int64(100), c.Used())
+	assert.Equal(t, int64(3), c.Dropped())
+
+	c.Release(60)
+	assert.True(t, c.Reserve(10, PriorityLow))
+	assert.Equal(t, int64(50), c.Used())
+}
+
+func TestReserveUnbounded(t *testing.T) {
+	c := Register("test_unbounded")
+	assert.True(t, c.Reserve(1<<40, PriorityLow))
+	assert.Equal(t, int64(0), c.Dropped())
+	assert.Equal(t, int64(0), c.Limit())
+}
+
+func TestShed(t *testing.T) {
+	var limits []int64
+	c := RegisterShedder("test_shed", func(limit int64) int64 {
+		limits = append(limits, limit)
+		return limit / 2
+	})
+	shed()
+	assert.Empty(t, limits, "unbounded components are not shed")
+
+	c.SetLimit(100)
+	defer c.SetLimit(0)
+	shed()
+	assert.Equal(t, []int64{100}, limits)
+	assert.Equal(t, int64(50), c.Used())
+
+	stats := Stats()["test_shed"]
+	assert.Equal(t, int64(50), stats["Used"])
+	assert.Equal(t, int64(100), stats["Limit"])
+}
@@ -0,0 +1,229 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2018 Datadog, Inc.
+
+// Package obfuscate removes the sensitive values from the statements
+// collected by the checks, so that they can be submitted.
+package obfuscate
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+	"unicode"
+)
+
+// sqlPlaceholder replaces the literals of the obfuscated statements
+const sqlPlaceholder = "?"
+
+// sqlLiteralKeywords are the keywords replaced as literals
+var sqlLiteralKeywords = map[string]bool{
+	"TRUE":  true,
+	"FALSE": true,
+}
+
+// ObfuscateSQL replaces the literals of the SQL query, strings, numbers and
+// booleans, by a `?` placeholder, and removes its comments. The lists of
+// literals, as found in `IN (...)` or `VALUES (...)`, are collapsed to a
+// single placeholder, so that the queries only differing by the size of
+// their lists are obfuscated the same way. The tokens are separated by a
+// single space.
+func ObfuscateSQL(query string) (string, error) {
+	tokens, err := tokenizeSQL(query)
+	if err != nil {
+		return "", err
+	}
+	tokens = groupSQLLiterals(tokens)
+
+	var out bytes.Buffer
+	for i, tok := range tokens {
+		if i > 0 && tok != "," {
+			out.WriteByte(' ')
+		}
+		out.WriteString(tok)
+	}
+	return out.String(), nil
+}
+
+// tokenizeSQL splits the query in tokens, with its literals replaced by the
+// placeholder and without its comments
+func tokenizeSQL(query string) ([]string, error) {
+	var tokens []string
+	runes := []rune(query)
+	for i := 0; i < len(runes); {
+		r := runes[i]
+		switch {
+		case unicode.IsSpace(r):
+			i++
+		case r == '-' && next(runes, i) == '-':
+			// comment up to the end of the line
+			for i < len(runes) && runes[i] != '\n' {
+				i++
+			}
+		case r == '/' && next(runes, i) == '*':
+			start := i
+			for i += 2; i < len(runes) && !(runes[i] == '*' && next(runes, i) == '/'); i++ {
+			}
+			if i >= len(runes) {
+				return nil, fmt.Errorf("unterminated comment at offset %d", start)
+			}
+			i += 2
+		case r == '\'':
+			end, err := skipQuoted(runes, i)
+			if err != nil {
+				return nil, err
+			}
+			tokens = append(tokens, sqlPlaceholder)
+			i = end
+		case r == '"' || r == '`':
+			// quoted identifiers are kept
+			end, err := skipQuoted(runes, i)
+			if err != nil {
+				return nil, err
+			}
+			tokens = append(tokens, string(runes[i:end]))
+			i = end
+		case unicode.IsDigit(r) || (r == '.' && unicode.IsDigit(next(runes, i))):
+			i = skipNumber(runes, i)
+			tokens = append(tokens, sqlPlaceholder)
+		case isIdentifierRune(r) || r == '$' || r == '@' || (r == ':' && isIdentifierRune(next(runes, i))):
+			// keywords, identifiers and bind parameters, like $1, :name or @name
+			start := i
+			i++
+			for i < len(runes) && (isIdentifierRune(runes[i]) || unicode.IsDigit(runes[i]) || runes[i] == '$' || runes[i] == '.') {
+				i++
+			}
+			word := string(runes[start:i])
+			if sqlLiteralKeywords[strings.ToUpper(word)] {
+				word = sqlPlaceholder
+			}
+			tokens = append(tokens, word)
+		default:
+			// operators, the ones of two characters are kept together
+			if op := string(runes[i:min(i+2, len(runes))]); isSQLOperator(op) {
+				tokens = append(tokens, op)
+				i += 2
+			} else {
+				tokens = append(tokens, string(r))
+				i++
+			}
+		}
+	}
+	return tokens, nil
+}
+
+// groupSQLLiterals collapses the lists of placeholders between parentheses,
+// and the consecutive lists of values, to a single one
+func groupSQLLiterals(tokens []string) []string {
+	grouped := make([]string, 0, len(tokens))
+	for i := 0; i < len(tokens); i++ {
+		if tokens[i] == "(" {
+			if end := placeholderListEnd(tokens, i); end > 0 {
+				grouped = append(grouped, "(", sqlPlaceholder, ")")
+				i = end
+				// the other lists of VALUES (?), (?)
+				for i+2 < len(tokens) && tokens[i+1] == "," && tokens[i+2] == "(" {
+					end = placeholderListEnd(tokens, i+2)
+					if end < 0 {
+						break
+					}
+					i = end
+				}
+				continue
+			}
+		}
+		grouped = append(grouped, tokens[i])
+	}
+	return grouped
+}
+
+// placeholderListEnd returns the index of the parenthesis closing the list
+// of placeholders opened at start, -1 if it is not such a list
+func placeholderListEnd(tokens []string, start int) int {
+	i := start + 1
+	for ; i < len(tokens); i += 2 {
+		if tokens[i] != sqlPlaceholder || i+1 >= len(tokens) {
+			return -1
+		}
+		switch tokens[i+1] {
+		case ")":
+			return i + 1
+		case ",":
+		default:
+			return -1
+		}
+	}
+	return -1
+}
+
+// skipQuoted returns the index following the quoted string starting at
+// start, the quote is escaped by doubling it or by a backslash
+func skipQuoted(runes []rune, start int) (int, error) {
+	quote := runes[start]
+	for i := start + 1; i < len(runes); i++ {
+		switch runes[i] {
+		case '\\':
+			i++
+		case quote:
+			if next(runes, i) != quote {
+				return i + 1, nil
+			}
+			i++
+		}
+	}
+	return 0, fmt.Errorf("unterminated quoted string at offset %d", start)
+}
+
+// skipNumber returns the index following the number starting at start,
+// decimal, with an exponent, or hexadecimal
+func skipNumber(runes []rune, start int) int {
+	i := start
+	if runes[i] == '0' && (next(runes, i) == 'x' || next(runes, i) == 'X') {
+		i += 2
+		for i < len(runes) && strings.ContainsRune("0123456789abcdefABCDEF", runes[i]) {
+			i++
+		}
+		return i
+	}
+	for i < len(runes) && (unicode.IsDigit(runes[i]) || runes[i] == '.') {
+		i++
+	}
+	if i < len(runes) && (runes[i] == 'e' || runes[i] == 'E') {
+		j := i + 1
+		if j < len(runes) && (runes[j] == '+' || runes[j] == '-') {
+			j++
+		}
+		if j < len(runes) && unicode.IsDigit(runes[j]) {
+			for i = j; i < len(runes) && unicode.IsDigit(runes[i]); i++ {
+			}
+		}
+	}
+	return i
+}
+
+func isIdentifierRune(r rune) bool {
+	return unicode.IsLetter(r) || r == '_'
+}
+
+func isSQLOperator(op string) bool {
+	switch op {
+	case "<=", ">=", "<>", "!=", "||", "::", ":=", "<<", ">>":
+		return true
+	}
+	return false
+}
+
+func next(runes []rune, i int) rune {
+	if i+1 < len(runes) {
+		return runes[i+1]
+	}
+	return 0
+}
+
+func min(a, b int) int {
+	if a < b {
+		return a
+	}
+	return b
+}
@@ -0,0 +1,68 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2018 Datadog, Inc.
+
+package obfuscate
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestObfuscateSQL(t *testing.T) {
+	for _, tc := range []struct {
+		query    string
+		expected string
+	}{
+		{
+			query:    "SELECT * FROM users WHERE id = 42",
+			expected: "SELECT * FROM users WHERE id = ?",
+		},
+		{
+			query:    "SELECT name FROM users WHERE email = 'jane@example.com' AND password = 'it''s \\'secret'",
+			expected: "SELECT name FROM users WHERE email = ? AND password = ?",
+		},
+		{
+			query:    "SELECT * FROM orders WHERE total >= 12.5e3 AND flags <> 0xFF AND paid = TRUE AND deleted IS NULL",
+			expected: "SELECT * FROM orders WHERE total >= ? AND flags <> ? AND paid = ? AND deleted IS NULL",
+		},
+		{
+			query:    "SELECT * FROM users WHERE id IN (1, 2, 3)",
+			expected: "SELECT * FROM users WHERE id IN ( ? )",
+		},
+		{
+			query:    "INSERT INTO users (id, name) VALUES (1, 'jane'), (2, 'john')",
+			expected: "INSERT INTO users ( id, name ) VALUES ( ? )",
+		},
+		{
+			query:    "SELECT count(*) FROM \"Users\" u -- active ones\nWHERE u.active /* flag */ = 1",
+			expected: "SELECT count ( * ) FROM \"Users\" u WHERE u.active = ?",
+		},
+		{
+			query:    "SELECT * FROM users WHERE id = $1 AND name = :name AND created_at > '2018-01-01'::date",
+			expected: "SELECT * FROM users WHERE id = $1 AND name = :name AND created_at > ? :: date",
+		},
+		{
+			query:    "  UPDATE  table1\n\tSET  v = -1  ",
+			expected: "UPDATE table1 SET v = - ?",
+		},
+	} {
+		obfuscated, err := ObfuscateSQL(tc.query)
+		require.NoError(t, err, tc.query)
+		assert.Equal(t, tc.expected, obfuscated)
+	}
+}
+
+func TestObfuscateSQLErrors(t *testing.T) {
+	for _, query := range []string{
+		"SELECT * FROM users WHERE name = 'jane",
+		"SELECT * FROM users /* unterminated",
+		"SELECT * FROM \"users",
+	} {
+		_, err := ObfuscateSQL(query)
+		assert.Error(t, err, query)
+	}
+}
@@ -0,0 +1,75 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2018 Datadog, Inc.
+
+// Package orchestrator describes the cluster the agent runs in, so that the
+// checks can tag their data the same way as the agent.
+package orchestrator
+
+import (
+	"errors"
+
+	"github.com/DataDog/datadog-agent/pkg/config"
+	"github.com/DataDog/datadog-agent/pkg/util/ecs"
+	"github.com/DataDog/datadog-agent/pkg/util/hostname"
+)
+
+// The orchestrators the agent can detect
+const (
+	Kubernetes = "kubernetes"
+	ECS        = "ecs"
+	ECSFargate = "ecs_fargate"
+)
+
+// Context holds the orchestrator context of the agent, its fields are empty
+// when they are unknown
+type Context struct {
+	ClusterName  string
+	Orchestrator string
+	NodeName     string
+}
+
+// GetClusterName returns the name of the cluster set in `cluster_name`
+func GetClusterName() string {
+	return config.Datadog.GetString("cluster_name")
+}
+
+// GetOrchestrator returns the orchestrator detected, an empty string if the
+// agent doesn't run in one
+func GetOrchestrator() string {
+	if config.IsKubernetes() {
+		return Kubernetes
+	}
+	if ecs.IsFargateInstance() {
+		return ECSFargate
+	}
+	if _, err := ecs.GetUtil(); err == nil {
+		return ECS
+	}
+	return ""
+}
+
+// GetNodeName returns the name of the kubernetes node the agent runs on, from
+// the kubelet
+func GetNodeName() (string, error) {
+	if !config.IsKubernetes() {
+		return "", nil
+	}
+	getKubeletHostname, found := hostname.ProviderCatalog["kubelet"]
+	if !found {
+		return "", errors.New("the kubelet support is not compiled in")
+	}
+	return getKubeletHostname("")
+}
+
+// GetContext returns the orchestrator context of the agent
+func GetContext() (Context, error) {
+	ctx := Context{
+		ClusterName:  GetClusterName(),
+		Orchestrator: GetOrchestrator(),
+	}
+	nodeName, err := GetNodeName()
+	ctx.NodeName = nodeName
+	return ctx, err
+}
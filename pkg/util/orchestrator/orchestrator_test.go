@@ -0,0 +1,37 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2018 Datadog, Inc.
+
+package orchestrator
+
+import (
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/DataDog/datadog-agent/pkg/config"
+	"github.com/DataDog/datadog-agent/pkg/util/hostname"
+)
+
+func TestGetContextKubernetes(t *testing.T) {
+	config.Datadog.Set("cluster_name", "mycluster")
+	defer config.Datadog.Set("cluster_name", "")
+	os.Setenv("KUBERNETES", "yes")
+	defer os.Unsetenv("KUBERNETES")
+	getKubeletHostname, found := hostname.ProviderCatalog["kubelet"]
+	hostname.ProviderCatalog["kubelet"] = func(string) (string, error) { return "node-1", nil }
+	defer func() {
+		if found {
+			hostname.ProviderCatalog["kubelet"] = getKubeletHostname
+		} else {
+			delete(hostname.ProviderCatalog, "kubelet")
+		}
+	}()
+
+	ctx, err := GetContext()
+	require.NoError(t, err)
+	assert.Equal(t, Context{ClusterName: "mycluster", Orchestrator: Kubernetes, NodeName: "node-1"}, ctx)
+}
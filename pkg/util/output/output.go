@@ -0,0 +1,100 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2018 Datadog, Inc.
+
+// Package output writes the payloads returned by the agent API in the format
+// picked with the -o flag of the CLI commands, so that every command can be
+// read by humans and parsed by scripts the same way.
+package output
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+
+	"gopkg.in/yaml.v2"
+)
+
+// Output formats
+const (
+	// Table is the human readable format, the default
+	Table = "table"
+	// Wide is the human readable format with additional details
+	Wide = "wide"
+	// JSON is the indented JSON payload
+	JSON = "json"
+	// YAML is the payload converted to YAML
+	YAML = "yaml"
+)
+
+// Formats lists the supported output formats
+var Formats = []string{Table, Wide, JSON, YAML}
+
+// FlagUsage is the usage of the -o flag of the commands
+var FlagUsage = fmt.Sprintf("output format, one of: %s", strings.Join(Formats, ", "))
+
+// Renderer renders a JSON payload in the human readable formats,
+// with additional details if wide is set.
+type Renderer func(w io.Writer, payload []byte, wide bool) error
+
+// Writer writes JSON payloads in an output format
+type Writer struct {
+	format string
+	out    io.Writer
+}
+
+// NewWriter returns a Writer to out, failing if the format is not supported
+func NewWriter(format string, out io.Writer) (*Writer, error) {
+	format = strings.ToLower(format)
+	if format == "" {
+		format = Table
+	}
+	for _, f := range Formats {
+		if f == format {
+			return &Writer{format: format, out: out}, nil
+		}
+	}
+	return nil, fmt.Errorf("unknown output format %q, expected one of: %s", format, strings.Join(Formats, ", "))
+}
+
+// Format returns the output format of the writer
+func (w *Writer) Format() string {
+	return w.format
+}
+
+// IsHuman returns whether the output is meant to be read rather than parsed.
+// Commands only print progress messages for the human readable formats.
+func (w *Writer) IsHuman() bool {
+	return w.format == Table || w.format == Wide
+}
+
+// Write writes the payload in the output format, using render for
+// the human readable ones.
+func (w *Writer) Write(payload []byte, render Renderer) error {
+	switch w.format {
+	case JSON:
+		var indented bytes.Buffer
+		if err := json.Indent(&indented, payload, "", "  "); err != nil {
+			return fmt.Errorf("invalid JSON payload: %s", err)
+		}
+		indented.WriteByte('\n')
+		_, err := indented.WriteTo(w.out)
+		return err
+	case YAML:
+		var data interface{}
+		if err := json.Unmarshal(payload, &data); err != nil {
+			return fmt.Errorf("invalid JSON payload: %s", err)
+		}
+		out, err := yaml.Marshal(data)
+		if err != nil {
+			return err
+		}
+		_, err = w.out.Write(out)
+		return err
+	default:
+		return render(w.out, payload, w.format == Wide)
+	}
+}
@@ -0,0 +1,70 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2018 Datadog, Inc.
+
+package output
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const testPayload = `{"name":"agent","checks":["cpu","disk"],"pid":42}`
+
+func renderTest(w io.Writer, payload []byte, wide bool) error {
+	if wide {
+		_, err := fmt.Fprintf(w, "wide: %s", payload)
+		return err
+	}
+	_, err := fmt.Fprintf(w, "table: %s", payload)
+	return err
+}
+
+func TestNewWriter(t *testing.T) {
+	w, err := NewWriter("", nil)
+	require.NoError(t, err)
+	assert.Equal(t, Table, w.Format())
+	assert.True(t, w.IsHuman())
+
+	w, err = NewWriter("JSON", nil)
+	require.NoError(t, err)
+	assert.Equal(t, JSON, w.Format())
+	assert.False(t, w.IsHuman())
+
+	_, err = NewWriter("xml", nil)
+	assert.Error(t, err)
+}
+
+func TestWrite(t *testing.T) {
+	for _, tc := range []struct {
+		format   string
+		expected string
+	}{
+		{Table, "table: " + testPayload},
+		{Wide, "wide: " + testPayload},
+		{JSON, "{\n  \"name\": \"agent\",\n  \"checks\": [\n    \"cpu\",\n    \"disk\"\n  ],\n  \"pid\": 42\n}\n"},
+		{YAML, "checks:\n- cpu\n- disk\nname: agent\npid: 42\n"},
+	} {
+		t.Run(tc.format, func(t *testing.T) {
+			var out bytes.Buffer
+			w, err := NewWriter(tc.format, &out)
+			require.NoError(t, err)
+			require.NoError(t, w.Write([]byte(testPayload), renderTest))
+			assert.Equal(t, tc.expected, out.String())
+		})
+	}
+}
+
+func TestWriteInvalidPayload(t *testing.T) {
+	for _, format := range []string{JSON, YAML} {
+		w, err := NewWriter(format, &bytes.Buffer{})
+		require.NoError(t, err)
+		assert.Error(t, w.Write([]byte("not json"), renderTest))
+	}
+}
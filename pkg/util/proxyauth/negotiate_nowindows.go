@@ -0,0 +1,18 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2018 Datadog, Inc.
+
+// +build !windows
+
+package proxyauth
+
+import (
+	"fmt"
+)
+
+// newNegotiateAuthenticator fails, the Negotiate authentication relies on
+// the SSPI of Windows
+func newNegotiateAuthenticator(proxyHost string) (Authenticator, error) {
+	return nil, fmt.Errorf("the negotiate proxy authentication is only supported on Windows")
+}
@@ -0,0 +1,157 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2018 Datadog, Inc.
+
+//go:build windows
+// +build windows
+
+package proxyauth
+
+import (
+	"fmt"
+	"syscall"
+	"unsafe"
+)
+
+var (
+	secur32                        = syscall.NewLazyDLL("secur32.dll")
+	procAcquireCredentialsHandleW  = secur32.NewProc("AcquireCredentialsHandleW")
+	procInitializeSecurityContextW = secur32.NewProc("InitializeSecurityContextW")
+	procDeleteSecurityContext      = secur32.NewProc("DeleteSecurityContext")
+	procFreeCredentialsHandle      = secur32.NewProc("FreeCredentialsHandle")
+	procFreeContextBuffer          = secur32.NewProc("FreeContextBuffer")
+)
+
+// SSPI constants, see sspi.h
+const (
+	secpkgCredOutbound   = 2
+	iscReqAllocateMemory = 0x00000100
+	iscReqConnection     = 0x00000800
+	securityNativeDrep   = 0x00000010
+	secbufferToken       = 2
+	secbufferVersion     = 0
+	secEOK               = 0
+	secIContinueNeeded   = 0x00090312
+)
+
+type secHandle struct {
+	lower uintptr
+	upper uintptr
+}
+
+type secBuffer struct {
+	size       uint32
+	bufferType uint32
+	buffer     *byte
+}
+
+type secBufferDesc struct {
+	version uint32
+	count   uint32
+	buffers *secBuffer
+}
+
+// negotiateAuthenticator uses the Negotiate package of SSPI with the
+// credentials of the user running the agent
+type negotiateAuthenticator struct {
+	target      *uint16
+	credentials secHandle
+	context     secHandle
+	hasContext  bool
+	closed      bool
+}
+
+func newNegotiateAuthenticator(proxyHost string) (Authenticator, error) {
+	target, err := syscall.UTF16PtrFromString("HTTP/" + proxyHost)
+	if err != nil {
+		return nil, err
+	}
+	pkg, err := syscall.UTF16PtrFromString("Negotiate")
+	if err != nil {
+		return nil, err
+	}
+	a := &negotiateAuthenticator{target: target}
+	var expiry int64
+	status, _, _ := procAcquireCredentialsHandleW.Call(
+		0, // the current user
+		uintptr(unsafe.Pointer(pkg)),
+		secpkgCredOutbound,
+		0, 0, 0, 0,
+		uintptr(unsafe.Pointer(&a.credentials)),
+		uintptr(unsafe.Pointer(&expiry)),
+	)
+	if status != secEOK {
+		return nil, fmt.Errorf("could not acquire the Negotiate credentials: error 0x%x", status)
+	}
+	return a, nil
+}
+
+// Scheme returns the scheme of the Proxy-Authorization header
+func (a *negotiateAuthenticator) Scheme() string {
+	return "Negotiate"
+}
+
+// Token returns the next token of the security context
+func (a *negotiateAuthenticator) Token(challenge []byte) ([]byte, error) {
+	if a.closed {
+		return nil, fmt.Errorf("the Negotiate authenticator is closed")
+	}
+
+	var input *secBufferDesc
+	if len(challenge) > 0 {
+		input = &secBufferDesc{
+			version: secbufferVersion,
+			count:   1,
+			buffers: &secBuffer{size: uint32(len(challenge)), bufferType: secbufferToken, buffer: &challenge[0]},
+		}
+	}
+	outputBuffer := secBuffer{bufferType: secbufferToken}
+	output := &secBufferDesc{version: secbufferVersion, count: 1, buffers: &outputBuffer}
+
+	var context uintptr
+	if a.hasContext {
+		context = uintptr(unsafe.Pointer(&a.context))
+	}
+	var attributes uint32
+	var expiry int64
+	status, _, _ := procInitializeSecurityContextW.Call(
+		uintptr(unsafe.Pointer(&a.credentials)),
+		context,
+		uintptr(unsafe.Pointer(a.target)),
+		iscReqAllocateMemory|iscReqConnection,
+		0,
+		securityNativeDrep,
+		uintptr(unsafe.Pointer(input)),
+		0,
+		uintptr(unsafe.Pointer(&a.context)),
+		uintptr(unsafe.Pointer(output)),
+		uintptr(unsafe.Pointer(&attributes)),
+		uintptr(unsafe.Pointer(&expiry)),
+	)
+	if status != secEOK && status != secIContinueNeeded {
+		return nil, fmt.Errorf("could not initialize the Negotiate security context: error 0x%x", status)
+	}
+	a.hasContext = true
+
+	if outputBuffer.buffer == nil {
+		return nil, nil
+	}
+	defer procFreeContextBuffer.Call(uintptr(unsafe.Pointer(outputBuffer.buffer)))
+	token := make([]byte, outputBuffer.size)
+	copy(token, (*[1 << 20]byte)(unsafe.Pointer(outputBuffer.buffer))[:outputBuffer.size:outputBuffer.size])
+	return token, nil
+}
+
+// Close releases the security context and the credentials
+func (a *negotiateAuthenticator) Close() error {
+	if a.closed {
+		return nil
+	}
+	a.closed = true
+	if a.hasContext {
+		procDeleteSecurityContext.Call(uintptr(unsafe.Pointer(&a.context)))
+	}
+	procFreeCredentialsHandle.Call(uintptr(unsafe.Pointer(&a.credentials)))
+	return nil
+}
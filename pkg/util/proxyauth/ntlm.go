@@ -0,0 +1,241 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2018 Datadog, Inc.
+
+package proxyauth
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/md5"
+	"crypto/rand"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+	"unicode/utf16"
+
+	"golang.org/x/crypto/md4"
+)
+
+// NTLM message flags, see [MS-NLMP] 2.2.2.5
+const (
+	ntlmNegotiateUnicode                 = 0x00000001
+	ntlmNegotiateOEM                     = 0x00000002
+	ntlmRequestTarget                    = 0x00000004
+	ntlmNegotiateNTLM                    = 0x00000200
+	ntlmNegotiateAlwaysSign              = 0x00008000
+	ntlmNegotiateExtendedSessionSecurity = 0x00080000
+	ntlmNegotiate128                     = 0x20000000
+	ntlmNegotiate56                      = 0x80000000
+
+	ntlmNegotiateFlags = ntlmNegotiateUnicode | ntlmNegotiateOEM | ntlmRequestTarget |
+		ntlmNegotiateNTLM | ntlmNegotiateAlwaysSign | ntlmNegotiateExtendedSessionSecurity |
+		ntlmNegotiate128 | ntlmNegotiate56
+)
+
+// identifier of the timestamp in the target info of the challenge
+const ntlmAvTimestamp = 7
+
+var ntlmSignature = []byte("NTLMSSP\x00")
+
+// ntlmAuthenticator answers the challenge of a proxy with NTLMv2 messages
+type ntlmAuthenticator struct {
+	domain   string
+	user     string
+	password string
+	// now and clientChallenge are replaced by the tests
+	now             func() time.Time
+	clientChallenge func() ([]byte, error)
+}
+
+// newNTLMAuthenticator returns an authenticator for the given credentials,
+// the user can be prefixed with its domain: DOMAIN\user
+func newNTLMAuthenticator(user, password string) *ntlmAuthenticator {
+	a := &ntlmAuthenticator{
+		user:            user,
+		password:        password,
+		now:             time.Now,
+		clientChallenge: randomChallenge,
+	}
+	if i := strings.Index(user, `\`); i >= 0 {
+		a.domain, a.user = user[:i], user[i+1:]
+	}
+	return a
+}
+
+// Scheme returns the scheme of the Proxy-Authorization header
+func (a *ntlmAuthenticator) Scheme() string {
+	return "NTLM"
+}
+
+// Token returns the negotiate message when the challenge is empty,
+// the authenticate message answering the challenge otherwise
+func (a *ntlmAuthenticator) Token(challenge []byte) ([]byte, error) {
+	if len(challenge) == 0 {
+		return ntlmNegotiateMessage(), nil
+	}
+	return a.authenticateMessage(challenge)
+}
+
+// ntlmNegotiateMessage returns the first message of the handshake,
+// without domain nor workstation
+func ntlmNegotiateMessage() []byte {
+	msg := make([]byte, 32)
+	copy(msg, ntlmSignature)
+	binary.LittleEndian.PutUint32(msg[8:], 1)
+	binary.LittleEndian.PutUint32(msg[12:], ntlmNegotiateFlags)
+	return msg
+}
+
+// ntlmChallenge holds the fields of the challenge message of the proxy
+type ntlmChallenge struct {
+	flags           uint32
+	serverChallenge []byte
+	targetInfo      []byte
+}
+
+func parseNTLMChallenge(msg []byte) (*ntlmChallenge, error) {
+	if len(msg) < 48 || !bytes.Equal(msg[:8], ntlmSignature) || binary.LittleEndian.Uint32(msg[8:]) != 2 {
+		return nil, errors.New("invalid NTLM challenge message")
+	}
+	c := &ntlmChallenge{
+		flags:           binary.LittleEndian.Uint32(msg[20:]),
+		serverChallenge: msg[24:32],
+	}
+	length := int(binary.LittleEndian.Uint16(msg[40:]))
+	offset := int(binary.LittleEndian.Uint32(msg[44:]))
+	if offset+length > len(msg) {
+		return nil, errors.New("invalid target info in the NTLM challenge message")
+	}
+	c.targetInfo = msg[offset : offset+length]
+	return c, nil
+}
+
+// timestamp returns the timestamp of the target info, if any
+func (c *ntlmChallenge) timestamp() []byte {
+	info := c.targetInfo
+	for len(info) >= 4 {
+		id := binary.LittleEndian.Uint16(info)
+		length := int(binary.LittleEndian.Uint16(info[2:]))
+		if len(info) < 4+length {
+			return nil
+		}
+		if id == ntlmAvTimestamp && length == 8 {
+			return info[4:12]
+		}
+		info = info[4+length:]
+	}
+	return nil
+}
+
+// authenticateMessage computes the NTLMv2 response to the challenge, see [MS-NLMP] 3.3.2
+func (a *ntlmAuthenticator) authenticateMessage(challengeMsg []byte) ([]byte, error) {
+	challenge, err := parseNTLMChallenge(challengeMsg)
+	if err != nil {
+		return nil, err
+	}
+	clientChallenge, err := a.clientChallenge()
+	if err != nil {
+		return nil, err
+	}
+
+	timestamp := challenge.timestamp()
+	hasTimestamp := timestamp != nil
+	if !hasTimestamp {
+		timestamp = ntlmTimestamp(a.now())
+	}
+
+	key := ntowfv2(a.user, a.password, a.domain)
+	ntResponse := ntlmv2Response(key, challenge.serverChallenge, clientChallenge, timestamp, challenge.targetInfo)
+	// the LMv2 response is replaced by zeros when the server sent a timestamp
+	lmResponse := make([]byte, 24)
+	if !hasTimestamp {
+		lmResponse = append(hmacMD5(key, challenge.serverChallenge, clientChallenge), clientChallenge...)
+	}
+
+	unicode := challenge.flags&ntlmNegotiateUnicode != 0
+	fields := [][]byte{
+		lmResponse,
+		ntResponse,
+		encodeNTLMString(a.domain, unicode),
+		encodeNTLMString(a.user, unicode),
+		encodeNTLMString("", unicode), // workstation
+		nil,                           // encrypted random session key
+	}
+
+	const headerLength = 64
+	msg := make([]byte, headerLength)
+	copy(msg, ntlmSignature)
+	binary.LittleEndian.PutUint32(msg[8:], 3)
+	offset := headerLength
+	for i, field := range fields {
+		fieldHeader := msg[12+8*i:]
+		binary.LittleEndian.PutUint16(fieldHeader, uint16(len(field)))
+		binary.LittleEndian.PutUint16(fieldHeader[2:], uint16(len(field)))
+		binary.LittleEndian.PutUint32(fieldHeader[4:], uint32(offset))
+		msg = append(msg, field...)
+		offset += len(field)
+	}
+	binary.LittleEndian.PutUint32(msg[60:], challenge.flags&ntlmNegotiateFlags)
+	return msg, nil
+}
+
+// ntowfv2 derives the NTLMv2 key from the credentials
+func ntowfv2(user, password, domain string) []byte {
+	h := md4.New()
+	h.Write(encodeNTLMString(password, true))
+	return hmacMD5(h.Sum(nil), encodeNTLMString(strings.ToUpper(user)+domain, true))
+}
+
+// ntlmv2Response returns the NTProofStr followed by the client blob
+func ntlmv2Response(key, serverChallenge, clientChallenge, timestamp, targetInfo []byte) []byte {
+	blob := new(bytes.Buffer)
+	blob.Write([]byte{1, 1, 0, 0, 0, 0, 0, 0})
+	blob.Write(timestamp)
+	blob.Write(clientChallenge)
+	blob.Write([]byte{0, 0, 0, 0})
+	blob.Write(targetInfo)
+	blob.Write([]byte{0, 0, 0, 0})
+
+	proof := hmacMD5(key, serverChallenge, blob.Bytes())
+	return append(proof, blob.Bytes()...)
+}
+
+func hmacMD5(key []byte, data ...[]byte) []byte {
+	mac := hmac.New(md5.New, key)
+	for _, d := range data {
+		mac.Write(d)
+	}
+	return mac.Sum(nil)
+}
+
+// ntlmTimestamp returns the time in tenths of microseconds since January 1, 1601
+func ntlmTimestamp(t time.Time) []byte {
+	const epochOffset = 116444736000000000
+	timestamp := make([]byte, 8)
+	binary.LittleEndian.PutUint64(timestamp, uint64(t.UnixNano()/100+epochOffset))
+	return timestamp
+}
+
+func encodeNTLMString(s string, unicode bool) []byte {
+	if !unicode {
+		return []byte(s)
+	}
+	encoded := utf16.Encode([]rune(s))
+	b := make([]byte, 2*len(encoded))
+	for i, r := range encoded {
+		binary.LittleEndian.PutUint16(b[2*i:], r)
+	}
+	return b
+}
+
+func randomChallenge() ([]byte, error) {
+	challenge := make([]byte, 8)
+	if _, err := rand.Read(challenge); err != nil {
+		return nil, fmt.Errorf("could not generate the NTLM client challenge: %s", err)
+	}
+	return challenge, nil
+}
@@ -0,0 +1,94 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2018 Datadog, Inc.
+
+package proxyauth
+
+import (
+	"encoding/binary"
+	"encoding/hex"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func decodeHex(t *testing.T, s string) []byte {
+	b, err := hex.DecodeString(s)
+	require.NoError(t, err)
+	return b
+}
+
+// the values of the NTLMv2 authentication example of [MS-NLMP] 4.2.4
+func TestNTLMv2(t *testing.T) {
+	key := ntowfv2("User", "Password", "Domain")
+	assert.Equal(t, "0c868a403bfd7a93a3001ef22ef02e3f", hex.EncodeToString(key))
+
+	serverChallenge := decodeHex(t, "0123456789abcdef")
+	clientChallenge := decodeHex(t, "aaaaaaaaaaaaaaaa")
+	lmResponse := hmacMD5(key, serverChallenge, clientChallenge)
+	assert.Equal(t, "86c35097ac9cec102554764a57cccc19", hex.EncodeToString(lmResponse))
+
+	// the NetBIOS domain and computer names, followed by the end of the list
+	targetInfo := decodeHex(t, "02000c0044006f006d00610069006e0001000c0053006500720076006500720000000000")
+	response := ntlmv2Response(key, serverChallenge, clientChallenge, make([]byte, 8), targetInfo)
+	assert.Equal(t, "68cd0ab851e51c96aabc927bebef6a1c", hex.EncodeToString(response[:16]))
+}
+
+func buildChallenge(flags uint32, targetInfo []byte) []byte {
+	msg := make([]byte, 48)
+	copy(msg, ntlmSignature)
+	binary.LittleEndian.PutUint32(msg[8:], 2)
+	binary.LittleEndian.PutUint32(msg[20:], flags)
+	copy(msg[24:], []byte{1, 2, 3, 4, 5, 6, 7, 8})
+	binary.LittleEndian.PutUint16(msg[40:], uint16(len(targetInfo)))
+	binary.LittleEndian.PutUint16(msg[42:], uint16(len(targetInfo)))
+	binary.LittleEndian.PutUint32(msg[44:], 48)
+	return append(msg, targetInfo...)
+}
+
+// readField returns the payload of a field of the authenticate message
+func readField(msg []byte, index int) []byte {
+	header := msg[12+8*index:]
+	length := int(binary.LittleEndian.Uint16(header))
+	offset := int(binary.LittleEndian.Uint32(header[4:]))
+	return msg[offset : offset+length]
+}
+
+func TestNTLMAuthenticator(t *testing.T) {
+	a := newNTLMAuthenticator(`DOMAIN\user`, "secret")
+	assert.Equal(t, "DOMAIN", a.domain)
+	assert.Equal(t, "user", a.user)
+	a.now = func() time.Time { return time.Unix(0, 0) }
+	a.clientChallenge = func() ([]byte, error) { return []byte("client!!"), nil }
+
+	negotiate, err := a.Token(nil)
+	require.NoError(t, err)
+	assert.Equal(t, ntlmSignature, negotiate[:8])
+	assert.Equal(t, uint32(1), binary.LittleEndian.Uint32(negotiate[8:]))
+
+	_, err = a.Token([]byte("not a challenge"))
+	assert.Error(t, err)
+
+	// timestamp in the target info
+	targetInfo := []byte{7, 0, 8, 0, 1, 2, 3, 4, 5, 6, 7, 8, 0, 0, 0, 0}
+	authenticate, err := a.Token(buildChallenge(ntlmNegotiateFlags, targetInfo))
+	require.NoError(t, err)
+	assert.Equal(t, uint32(3), binary.LittleEndian.Uint32(authenticate[8:]))
+	assert.Equal(t, make([]byte, 24), readField(authenticate, 0), "the LMv2 response is empty with a timestamp")
+	ntResponse := readField(authenticate, 1)
+	assert.Equal(t, []byte{1, 2, 3, 4, 5, 6, 7, 8}, ntResponse[24:32], "the timestamp of the server is used")
+	assert.Equal(t, []byte("client!!"), ntResponse[32:40])
+	assert.Equal(t, encodeNTLMString("DOMAIN", true), readField(authenticate, 2))
+	assert.Equal(t, encodeNTLMString("user", true), readField(authenticate, 3))
+
+	// no timestamp, the OEM encoding
+	authenticate, err = a.Token(buildChallenge(ntlmNegotiateOEM, nil))
+	require.NoError(t, err)
+	assert.Len(t, readField(authenticate, 0), 24)
+	assert.Equal(t, []byte("client!!"), readField(authenticate, 0)[16:])
+	assert.Equal(t, ntlmTimestamp(time.Unix(0, 0)), readField(authenticate, 1)[24:32])
+	assert.Equal(t, []byte("user"), readField(authenticate, 3))
+}
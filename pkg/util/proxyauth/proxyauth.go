@@ -0,0 +1,265 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2018 Datadog, Inc.
+
+// Package proxyauth authenticates the agent on the proxies using a
+// connection-based authentication, NTLM or Negotiate (SPNEGO), which the
+// http.Transport doesn't support. The connections to the intake are tunneled
+// through the proxy with a CONNECT request, authenticated over the same
+// connection before the tunnel is used.
+package proxyauth
+
+import (
+	"bufio"
+	"crypto/tls"
+	"encoding/base64"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// Authentication methods of the proxies
+const (
+	Basic     = "basic"
+	NTLM      = "ntlm"
+	Negotiate = "negotiate"
+)
+
+// An Authenticator produces the tokens of an authentication handshake with a proxy
+type Authenticator interface {
+	// Scheme returns the scheme of the Proxy-Authorization header
+	Scheme() string
+	// Token returns the token answering the challenge of the proxy,
+	// the challenge is empty for the first request
+	Token(challenge []byte) ([]byte, error)
+}
+
+// IsConnectionBased returns whether the authentication method must be handled
+// by a Dialer instead of the http.Transport
+func IsConnectionBased(auth string) bool {
+	auth = strings.ToLower(auth)
+	return auth == NTLM || auth == Negotiate
+}
+
+// newAuthenticator returns an authenticator for a handshake with the proxy
+func newAuthenticator(auth string, proxyURL *url.URL) (Authenticator, error) {
+	switch strings.ToLower(auth) {
+	case NTLM:
+		if proxyURL.User == nil {
+			return nil, fmt.Errorf("the NTLM authentication requires the credentials in the proxy URL")
+		}
+		password, _ := proxyURL.User.Password()
+		return newNTLMAuthenticator(proxyURL.User.Username(), password), nil
+	case Negotiate:
+		return newNegotiateAuthenticator(proxyURL.Hostname())
+	default:
+		return nil, fmt.Errorf("unsupported proxy authentication: %s", auth)
+	}
+}
+
+// A Dialer opens connections tunneled through an authenticated proxy
+type Dialer struct {
+	proxyURL *url.URL
+	auth     string
+	noProxy  []string
+	// Timeout bounds the connection to the proxy and the handshake
+	Timeout time.Duration
+	// TLSConfig is used when the proxy is reached with https
+	TLSConfig *tls.Config
+}
+
+// NewDialer returns a Dialer tunneling the connections through the proxy, the
+// connections to the hosts of the noProxy list are opened directly
+func NewDialer(proxyURL *url.URL, auth string, noProxy []string) (*Dialer, error) {
+	if !IsConnectionBased(auth) {
+		return nil, fmt.Errorf("the %s proxy authentication doesn't need a dialer", auth)
+	}
+	// check the credentials once, the authenticators are created by connection
+	authenticator, err := newAuthenticator(auth, proxyURL)
+	if err != nil {
+		return nil, err
+	}
+	closeAuthenticator(authenticator)
+	return &Dialer{
+		proxyURL: proxyURL,
+		auth:     auth,
+		noProxy:  noProxy,
+		Timeout:  30 * time.Second,
+	}, nil
+}
+
+// Dial connects to addr through the proxy
+func (d *Dialer) Dial(network, addr string) (net.Conn, error) {
+	if d.bypassProxy(addr) {
+		return net.DialTimeout(network, addr, d.Timeout)
+	}
+
+	conn, err := d.dialProxy()
+	if err != nil {
+		return nil, err
+	}
+	conn.SetDeadline(time.Now().Add(d.Timeout))
+	if err := d.connect(conn, addr); err != nil {
+		conn.Close()
+		return nil, err
+	}
+	conn.SetDeadline(time.Time{})
+	return conn, nil
+}
+
+// DialTLS connects to addr through the proxy, then starts a TLS session with
+// it, for the https requests
+func (d *Dialer) DialTLS(tlsConfig *tls.Config) func(network, addr string) (net.Conn, error) {
+	return func(network, addr string) (net.Conn, error) {
+		conn, err := d.Dial(network, addr)
+		if err != nil {
+			return nil, err
+		}
+		cfg := &tls.Config{}
+		if tlsConfig != nil {
+			cfg = tlsConfig.Clone()
+		}
+		if cfg.ServerName == "" {
+			host, _, err := net.SplitHostPort(addr)
+			if err != nil {
+				host = addr
+			}
+			cfg.ServerName = host
+		}
+		tlsConn := tls.Client(conn, cfg)
+		tlsConn.SetDeadline(time.Now().Add(d.Timeout))
+		if err := tlsConn.Handshake(); err != nil {
+			conn.Close()
+			return nil, err
+		}
+		tlsConn.SetDeadline(time.Time{})
+		return tlsConn, nil
+	}
+}
+
+func (d *Dialer) bypassProxy(addr string) bool {
+	host, _, err := net.SplitHostPort(addr)
+	if err != nil {
+		host = addr
+	}
+	for _, noProxy := range d.noProxy {
+		if noProxy == addr || noProxy == host {
+			return true
+		}
+	}
+	return false
+}
+
+func (d *Dialer) dialProxy() (net.Conn, error) {
+	addr := d.proxyURL.Host
+	if d.proxyURL.Port() == "" {
+		if d.proxyURL.Scheme == "https" {
+			addr = net.JoinHostPort(addr, "443")
+		} else {
+			addr = net.JoinHostPort(addr, "80")
+		}
+	}
+	conn, err := net.DialTimeout("tcp", addr, d.Timeout)
+	if err != nil {
+		return nil, fmt.Errorf("could not connect to the proxy: %s", err)
+	}
+	if d.proxyURL.Scheme != "https" {
+		return conn, nil
+	}
+	cfg := &tls.Config{ServerName: d.proxyURL.Hostname()}
+	if d.TLSConfig != nil {
+		cfg = d.TLSConfig.Clone()
+		cfg.ServerName = d.proxyURL.Hostname()
+	}
+	return tls.Client(conn, cfg), nil
+}
+
+// connect opens the tunnel to addr with CONNECT requests, answering the
+// challenges of the proxy until it accepts or rejects the authentication
+func (d *Dialer) connect(conn net.Conn, addr string) error {
+	authenticator, err := newAuthenticator(d.auth, d.proxyURL)
+	if err != nil {
+		return err
+	}
+	defer closeAuthenticator(authenticator)
+	reader := bufio.NewReader(conn)
+	var challenge []byte
+	// the handshakes take two round trips, leave some margin for Negotiate
+	for i := 0; i < 4; i++ {
+		token, err := authenticator.Token(challenge)
+		if err != nil {
+			return fmt.Errorf("could not authenticate on the proxy: %s", err)
+		}
+
+		req := &http.Request{
+			Method: "CONNECT",
+			URL:    &url.URL{Opaque: addr},
+			Host:   addr,
+			Header: make(http.Header),
+		}
+		req.Header.Set("Proxy-Authorization", authenticator.Scheme()+" "+base64.StdEncoding.EncodeToString(token))
+		if err := req.Write(conn); err != nil {
+			return fmt.Errorf("could not send the CONNECT request to the proxy: %s", err)
+		}
+
+		resp, err := http.ReadResponse(reader, req)
+		if err != nil {
+			return fmt.Errorf("could not read the response of the proxy: %s", err)
+		}
+		switch resp.StatusCode {
+		case http.StatusOK:
+			// the tunnel starts right after the response, which has no body
+			if reader.Buffered() > 0 {
+				return fmt.Errorf("unexpected data received from the proxy after the CONNECT request")
+			}
+			return nil
+		case http.StatusProxyAuthRequired:
+			// the body is read to keep using the connection
+			io.Copy(ioutil.Discard, resp.Body)
+			resp.Body.Close()
+			challenge, err = parseChallenge(resp.Header, authenticator.Scheme())
+			if err != nil {
+				return err
+			}
+			if len(challenge) == 0 {
+				return fmt.Errorf("the proxy rejected the %s authentication", authenticator.Scheme())
+			}
+		default:
+			resp.Body.Close()
+			return fmt.Errorf("the proxy refused the connection to %s: %s", addr, resp.Status)
+		}
+	}
+	return fmt.Errorf("the %s authentication on the proxy did not complete", authenticator.Scheme())
+}
+
+// closeAuthenticator releases the resources held by the authenticator, if any
+func closeAuthenticator(authenticator Authenticator) {
+	if closer, ok := authenticator.(io.Closer); ok {
+		closer.Close()
+	}
+}
+
+// parseChallenge returns the token of the Proxy-Authenticate header of the scheme
+func parseChallenge(header http.Header, scheme string) ([]byte, error) {
+	for _, value := range header["Proxy-Authenticate"] {
+		fields := strings.Fields(value)
+		if len(fields) == 0 || !strings.EqualFold(fields[0], scheme) {
+			continue
+		}
+		if len(fields) == 1 {
+			return nil, nil
+		}
+		challenge, err := base64.StdEncoding.DecodeString(fields[1])
+		if err != nil {
+			return nil, fmt.Errorf("invalid %s challenge from the proxy: %s", scheme, err)
+		}
+		return challenge, nil
+	}
+	return nil, fmt.Errorf("the proxy does not support the %s authentication", scheme)
+}
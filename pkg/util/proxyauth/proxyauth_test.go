@@ -0,0 +1,136 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2018 Datadog, Inc.
+
+package proxyauth
+
+import (
+	"bufio"
+	"encoding/base64"
+	"encoding/binary"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// runNTLMProxy accepts a connection, runs an NTLM handshake on it then
+// echoes the data of the tunnel, unless reject is set
+func runNTLMProxy(t *testing.T, listener net.Listener, reject bool) {
+	conn, err := listener.Accept()
+	if err != nil {
+		return
+	}
+	defer conn.Close()
+	reader := bufio.NewReader(conn)
+
+	messageType := func(req *http.Request) uint32 {
+		auth := strings.TrimPrefix(req.Header.Get("Proxy-Authorization"), "NTLM ")
+		msg, err := base64.StdEncoding.DecodeString(auth)
+		if err != nil || len(msg) < 12 {
+			return 0
+		}
+		return binary.LittleEndian.Uint32(msg[8:])
+	}
+
+	req, err := http.ReadRequest(reader)
+	if err != nil || req.Method != "CONNECT" || req.Host != "intake:443" || messageType(req) != 1 {
+		io.WriteString(conn, "HTTP/1.1 400 Bad Request\r\nContent-Length: 0\r\n\r\n")
+		return
+	}
+	challenge := base64.StdEncoding.EncodeToString(buildChallenge(ntlmNegotiateFlags, nil))
+	io.WriteString(conn, "HTTP/1.1 407 Proxy Authentication Required\r\nProxy-Authenticate: NTLM "+challenge+"\r\nContent-Length: 6\r\n\r\ndenied")
+
+	req, err = http.ReadRequest(reader)
+	if err != nil || messageType(req) != 3 {
+		io.WriteString(conn, "HTTP/1.1 400 Bad Request\r\nContent-Length: 0\r\n\r\n")
+		return
+	}
+	if reject {
+		io.WriteString(conn, "HTTP/1.1 407 Proxy Authentication Required\r\nProxy-Authenticate: NTLM\r\nContent-Length: 0\r\n\r\n")
+		return
+	}
+	io.WriteString(conn, "HTTP/1.1 200 Connection established\r\n\r\n")
+	io.Copy(conn, reader)
+}
+
+func newTestDialer(t *testing.T, listener net.Listener) *Dialer {
+	proxyURL, err := url.Parse("http://DOMAIN%5Cuser:secret@" + listener.Addr().String())
+	require.NoError(t, err)
+	dialer, err := NewDialer(proxyURL, "ntlm", []string{"localhost"})
+	require.NoError(t, err)
+	return dialer
+}
+
+func TestDialerNTLM(t *testing.T) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	defer listener.Close()
+	go runNTLMProxy(t, listener, false)
+
+	conn, err := newTestDialer(t, listener).Dial("tcp", "intake:443")
+	require.NoError(t, err)
+	defer conn.Close()
+
+	_, err = io.WriteString(conn, "ping")
+	require.NoError(t, err)
+	buf := make([]byte, 4)
+	_, err = io.ReadFull(conn, buf)
+	require.NoError(t, err)
+	assert.Equal(t, "ping", string(buf))
+}
+
+func TestDialerNTLMRejected(t *testing.T) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	defer listener.Close()
+	go runNTLMProxy(t, listener, true)
+
+	_, err = newTestDialer(t, listener).Dial("tcp", "intake:443")
+	assert.Error(t, err)
+}
+
+func TestDialerNoProxy(t *testing.T) {
+	proxy, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	defer proxy.Close()
+	dialer := newTestDialer(t, proxy)
+
+	assert.True(t, dialer.bypassProxy("localhost:8080"))
+	assert.True(t, dialer.bypassProxy("localhost"))
+	assert.False(t, dialer.bypassProxy("intake:443"))
+}
+
+func TestNewDialer(t *testing.T) {
+	proxyURL, err := url.Parse("http://proxy:3128")
+	require.NoError(t, err)
+
+	_, err = NewDialer(proxyURL, "basic", nil)
+	assert.Error(t, err, "the basic authentication is handled by the http.Transport")
+	_, err = NewDialer(proxyURL, "ntlm", nil)
+	assert.Error(t, err, "NTLM requires credentials")
+
+	assert.True(t, IsConnectionBased("NTLM"))
+	assert.True(t, IsConnectionBased("negotiate"))
+	assert.False(t, IsConnectionBased("basic"))
+	assert.False(t, IsConnectionBased(""))
+}
+
+func TestParseChallenge(t *testing.T) {
+	header := http.Header{}
+	header.Add("Proxy-Authenticate", "Basic realm=\"proxy\"")
+	header.Add("Proxy-Authenticate", "NTLM "+base64.StdEncoding.EncodeToString([]byte("challenge")))
+
+	challenge, err := parseChallenge(header, "NTLM")
+	require.NoError(t, err)
+	assert.Equal(t, "challenge", string(challenge))
+
+	_, err = parseChallenge(header, "Negotiate")
+	assert.Error(t, err)
+}
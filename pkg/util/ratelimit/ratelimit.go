@@ -0,0 +1,91 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2018 Datadog, Inc.
+
+package ratelimit
+
+import (
+	"context"
+	"expvar"
+	"sync"
+	"time"
+)
+
+var (
+	ratelimitExpvars = expvar.NewMap("ratelimit")
+	allowedExpvar    = &expvar.Map{}
+	throttledExpvar  = &expvar.Map{}
+)
+
+func init() {
+	ratelimitExpvars.Set("Allowed", allowedExpvar)
+	ratelimitExpvars.Set("Throttled", throttledExpvar)
+}
+
+// Limiter decides whether an action can happen now or must be throttled
+type Limiter interface {
+	// Allow reports whether one event may happen now
+	Allow() bool
+	// AllowN reports whether n events may happen now
+	AllowN(n int) bool
+	// Wait blocks until one event is allowed or the context is done
+	Wait(ctx context.Context) error
+}
+
+// Hook is called every time a limiter takes a decision, it can be used to
+// report the activity of a limiter in addition to the built-in expvars
+type Hook func(name string, n int, allowed bool)
+
+var (
+	hooksMu sync.RWMutex
+	hooks   []Hook
+)
+
+// AddHook registers a telemetry hook called on every limiter decision
+func AddHook(h Hook) {
+	hooksMu.Lock()
+	defer hooksMu.Unlock()
+	hooks = append(hooks, h)
+}
+
+// resetHooks removes all the registered hooks, used by tests
+func resetHooks() {
+	hooksMu.Lock()
+	defer hooksMu.Unlock()
+	hooks = nil
+}
+
+// report updates the expvars of a limiter and calls the registered hooks
+func report(name string, n int, allowed bool) {
+	if name != "" {
+		if allowed {
+			allowedExpvar.Add(name, int64(n))
+		} else {
+			throttledExpvar.Add(name, int64(n))
+		}
+	}
+
+	hooksMu.RLock()
+	defer hooksMu.RUnlock()
+	for _, h := range hooks {
+		h(name, n, allowed)
+	}
+}
+
+// wait polls allow until it succeeds or the context is done
+func wait(ctx context.Context, allow func() (bool, time.Duration)) error {
+	for {
+		allowed, delay := allow()
+		if allowed {
+			return nil
+		}
+		t := time.NewTimer(delay)
+		select {
+		case <-ctx.Done():
+			t.Stop()
+			return ctx.Err()
+		case <-t.C:
+		}
+	}
+}
@@ -0,0 +1,120 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2018 Datadog, Inc.
+
+package ratelimit
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/DataDog/datadog-agent/pkg/util/clock"
+)
+
+func newMockClock() *clock.Mock {
+	return clock.NewMock(time.Date(2018, 1, 1, 0, 0, 0, 0, time.UTC))
+}
+
+func TestTokenBucket(t *testing.T) {
+	clk := newMockClock()
+	b := newTokenBucket("", 2, 4, clk)
+
+	// full bucket allows a burst
+	assert.True(t, b.AllowN(4))
+	assert.False(t, b.Allow())
+
+	// refilled at 2 tokens per second
+	clk.Add(500 * time.Millisecond)
+	assert.True(t, b.Allow())
+	assert.False(t, b.Allow())
+
+	// never above the burst size
+	clk.Add(time.Hour)
+	assert.Equal(t, float64(4), b.Tokens())
+	assert.False(t, b.AllowN(5))
+	assert.True(t, b.AllowN(4))
+}
+
+func TestTokenBucketWaitDelay(t *testing.T) {
+	clk := newMockClock()
+	b := newTokenBucket("", 10, 1, clk)
+
+	allowed, delay := b.take(1)
+	assert.True(t, allowed)
+	assert.Equal(t, time.Duration(0), delay)
+
+	allowed, delay = b.take(1)
+	assert.False(t, allowed)
+	assert.Equal(t, 100*time.Millisecond, delay)
+}
+
+func TestTokenBucketWaitCanceled(t *testing.T) {
+	b := NewTokenBucket("", 0, 1)
+	assert.True(t, b.Allow())
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	assert.Equal(t, context.DeadlineExceeded, b.Wait(ctx))
+}
+
+func TestSlidingWindow(t *testing.T) {
+	clk := newMockClock()
+	w := newSlidingWindow("", 3, 10*time.Second, clk)
+
+	assert.True(t, w.Allow())
+	clk.Add(4 * time.Second)
+	assert.True(t, w.AllowN(2))
+	assert.False(t, w.Allow())
+	assert.Equal(t, 3, w.Count())
+
+	// the first event leaves the window
+	clk.Add(6 * time.Second)
+	assert.Equal(t, 2, w.Count())
+	assert.True(t, w.Allow())
+	assert.False(t, w.Allow())
+
+	allowed, delay := w.take(2)
+	assert.False(t, allowed)
+	assert.Equal(t, 4*time.Second, delay)
+
+	allowed, delay = w.take(4)
+	assert.False(t, allowed)
+	assert.Equal(t, 10*time.Second, delay)
+}
+
+func TestSlidingWindowWait(t *testing.T) {
+	w := NewSlidingWindow("", 1, 20*time.Millisecond)
+	assert.True(t, w.Allow())
+
+	start := time.Now()
+	assert.NoError(t, w.Wait(context.Background()))
+	assert.True(t, time.Since(start) >= 10*time.Millisecond)
+}
+
+func TestHooksAndExpvars(t *testing.T) {
+	defer resetHooks()
+
+	var allowed, throttled int
+	AddHook(func(name string, n int, ok bool) {
+		assert.Equal(t, "test_limiter", name)
+		if ok {
+			allowed += n
+		} else {
+			throttled += n
+		}
+	})
+
+	b := NewTokenBucket("test_limiter", 0, 2)
+	b.Allow()
+	b.Allow()
+	b.Allow()
+
+	assert.Equal(t, 2, allowed)
+	assert.Equal(t, 1, throttled)
+	assert.Equal(t, "2", allowedExpvar.Get("test_limiter").String())
+	assert.Equal(t, "1", throttledExpvar.Get("test_limiter").String())
+}
@@ -0,0 +1,102 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2018 Datadog, Inc.
+
+package ratelimit
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/DataDog/datadog-agent/pkg/util/clock"
+)
+
+// SlidingWindow is a Limiter allowing at most `limit` events over any
+// period of `window`. Contrary to the TokenBucket, it doesn't allow bursts
+// above the limit when the window boundary is crossed.
+type SlidingWindow struct {
+	name   string
+	limit  int
+	window time.Duration
+	events []time.Time // timestamps of the events in the current window, oldest first
+	clock  clock.Clock
+	m      sync.Mutex
+}
+
+// NewSlidingWindow returns a SlidingWindow. The name is used to report the
+// limiter decisions, an empty name disables the expvars.
+func NewSlidingWindow(name string, limit int, window time.Duration) *SlidingWindow {
+	return newSlidingWindow(name, limit, window, clock.New())
+}
+
+func newSlidingWindow(name string, limit int, window time.Duration, clk clock.Clock) *SlidingWindow {
+	return &SlidingWindow{
+		name:   name,
+		limit:  limit,
+		window: window,
+		events: make([]time.Time, 0, limit),
+		clock:  clk,
+	}
+}
+
+// expire drops the events that left the window, must be called with the
+// lock held
+func (w *SlidingWindow) expire(now time.Time) {
+	cutoff := now.Add(-w.window)
+	i := 0
+	for i < len(w.events) && !w.events[i].After(cutoff) {
+		i++
+	}
+	w.events = w.events[i:]
+}
+
+// take records n events if they fit in the window, otherwise it returns
+// how long to wait for enough events to leave the window
+func (w *SlidingWindow) take(n int) (bool, time.Duration) {
+	w.m.Lock()
+	defer w.m.Unlock()
+
+	now := w.clock.Now()
+	w.expire(now)
+	if len(w.events)+n <= w.limit {
+		for i := 0; i < n; i++ {
+			w.events = append(w.events, now)
+		}
+		return true, 0
+	}
+	if n > w.limit {
+		// will never fit, poll at the window pace
+		return false, w.window
+	}
+	oldest := w.events[len(w.events)+n-w.limit-1]
+	return false, oldest.Add(w.window).Sub(now)
+}
+
+// Allow reports whether one event may happen now
+func (w *SlidingWindow) Allow() bool {
+	return w.AllowN(1)
+}
+
+// AllowN reports whether n events may happen now
+func (w *SlidingWindow) AllowN(n int) bool {
+	allowed, _ := w.take(n)
+	report(w.name, n, allowed)
+	return allowed
+}
+
+// Wait blocks until one event is allowed or the context is done
+func (w *SlidingWindow) Wait(ctx context.Context) error {
+	err := wait(ctx, func() (bool, time.Duration) { return w.take(1) })
+	report(w.name, 1, err == nil)
+	return err
+}
+
+// Count returns the number of events in the current window
+func (w *SlidingWindow) Count() int {
+	w.m.Lock()
+	defer w.m.Unlock()
+	w.expire(w.clock.Now())
+	return len(w.events)
+}
@@ -0,0 +1,107 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2018 Datadog, Inc.
+
+package ratelimit
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/DataDog/datadog-agent/pkg/util/clock"
+)
+
+// TokenBucket is a Limiter refilled with `rate` tokens per second, up to
+// `burst` tokens. Each event consumes one token.
+type TokenBucket struct {
+	name   string
+	rate   float64
+	burst  float64
+	tokens float64
+	last   time.Time
+	clock  clock.Clock
+	m      sync.Mutex
+}
+
+// NewTokenBucket returns a full TokenBucket. The name is used to report the
+// limiter decisions, an empty name disables the expvars.
+func NewTokenBucket(name string, rate float64, burst int) *TokenBucket {
+	return newTokenBucket(name, rate, burst, clock.New())
+}
+
+func newTokenBucket(name string, rate float64, burst int, clk clock.Clock) *TokenBucket {
+	if burst < 1 {
+		burst = 1
+	}
+	return &TokenBucket{
+		name:   name,
+		rate:   rate,
+		burst:  float64(burst),
+		tokens: float64(burst),
+		last:   clk.Now(),
+		clock:  clk,
+	}
+}
+
+// refill adds the tokens accumulated since the last call, must be called
+// with the lock held
+func (b *TokenBucket) refill() {
+	now := b.clock.Now()
+	elapsed := now.Sub(b.last).Seconds()
+	b.last = now
+	if elapsed <= 0 {
+		return
+	}
+	b.tokens += elapsed * b.rate
+	if b.tokens > b.burst {
+		b.tokens = b.burst
+	}
+}
+
+// take consumes n tokens if available, otherwise it returns how long to wait
+// for them to be available
+func (b *TokenBucket) take(n int) (bool, time.Duration) {
+	b.m.Lock()
+	defer b.m.Unlock()
+
+	b.refill()
+	if b.tokens >= float64(n) {
+		b.tokens -= float64(n)
+		return true, 0
+	}
+	if b.rate <= 0 {
+		// the bucket will never be refilled, poll at a slow pace
+		return false, time.Second
+	}
+	missing := float64(n) - b.tokens
+	return false, time.Duration(missing / b.rate * float64(time.Second))
+}
+
+// Allow reports whether one event may happen now
+func (b *TokenBucket) Allow() bool {
+	return b.AllowN(1)
+}
+
+// AllowN reports whether n events may happen now
+func (b *TokenBucket) AllowN(n int) bool {
+	allowed, _ := b.take(n)
+	report(b.name, n, allowed)
+	return allowed
+}
+
+// Wait blocks until one token is available or the context is done
+func (b *TokenBucket) Wait(ctx context.Context) error {
+	err := wait(ctx, func() (bool, time.Duration) { return b.take(1) })
+	report(b.name, 1, err == nil)
+	return err
+}
+
+// Tokens returns the number of tokens currently available
+func (b *TokenBucket) Tokens() float64 {
+	b.m.Lock()
+	defer b.m.Unlock()
+	b.refill()
+	return b.tokens
+}
@@ -10,6 +10,8 @@ import (
 	"fmt"
 	"sync"
 	"time"
+
+	"github.com/DataDog/datadog-agent/pkg/util/clock"
 )
 
 // Retrier implements a configurable retry mechanism than can be embedded
@@ -21,6 +23,7 @@ type Retrier struct {
 	status   Status
 	nextTry  time.Time
 	tryCount int
+	clock    clock.Clock
 }
 
 // SetupRetrier must be called before calling other methods
@@ -41,6 +44,10 @@ func (r *Retrier) SetupRetrier(cfg *Config) error {
 
 	r.Lock()
 	r.cfg = *cfg
+	r.clock = cfg.Clock
+	if r.clock == nil {
+		r.clock = clock.New()
+	}
 	if cfg.Strategy == JustTesting {
 		r.status = OK
 	} else {
@@ -87,7 +94,7 @@ func (r *Retrier) TriggerRetry() *Error {
 
 func (r *Retrier) doTry() *Error {
 	r.RLock()
-	if !r.nextTry.IsZero() && time.Now().Before(r.nextTry) {
+	if !r.nextTry.IsZero() && r.clock.Now().Before(r.nextTry) {
 		r.RUnlock()
 		return r.errorf("try delay not elapsed yet")
 	}
@@ -108,7 +115,7 @@ func (r *Retrier) doTry() *Error {
 				r.status = PermaFail
 			} else {
 				r.status = FailWillRetry
-				r.nextTry = time.Now().Add(r.cfg.RetryDelay - 100*time.Millisecond)
+				r.nextTry = r.clock.Now().Add(r.cfg.RetryDelay - 100*time.Millisecond)
 			}
 		}
 	}
@@ -12,6 +12,8 @@ import (
 
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
+
+	"github.com/DataDog/datadog-agent/pkg/util/clock"
 )
 
 type DummyLogic struct {
@@ -203,3 +205,33 @@ func TestRetryDelayRecover(t *testing.T) {
 	err = mocked.TriggerRetry()
 	assert.Nil(t, err)
 }
+
+func TestRetryDelayClock(t *testing.T) {
+	clk := clock.NewMock(time.Date(2018, 1, 1, 0, 0, 0, 0, time.UTC))
+	mocked := &DummyLogic{}
+	mocked.On("Attempt").Return(errors.New("nope"))
+	config := &Config{
+		Name:          "mocked",
+		AttemptMethod: mocked.Attempt,
+		Strategy:      RetryCount,
+		RetryCount:    5,
+		RetryDelay:    time.Minute,
+		Clock:         clk,
+	}
+	err := mocked.SetupRetrier(config)
+	assert.Nil(t, err)
+
+	err = mocked.TriggerRetry()
+	assert.True(t, IsErrWillRetry(err))
+	assert.Equal(t, clk.Now().Add(time.Minute-100*time.Millisecond), mocked.NextRetry())
+
+	clk.Add(30 * time.Second)
+	err = mocked.TriggerRetry()
+	assert.Contains(t, err.Error(), "try delay not elapsed yet")
+	mocked.AssertNumberOfCalls(t, "Attempt", 1)
+
+	clk.Add(30 * time.Second)
+	err = mocked.TriggerRetry()
+	assert.True(t, IsErrWillRetry(err))
+	mocked.AssertNumberOfCalls(t, "Attempt", 2)
+}
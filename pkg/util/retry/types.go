@@ -5,7 +5,11 @@
 
 package retry
 
-import "time"
+import (
+	"time"
+
+	"github.com/DataDog/datadog-agent/pkg/util/clock"
+)
 
 // Status is returned by Retrier object to inform user classes
 type Status int
@@ -47,4 +51,6 @@ type Config struct {
 	Strategy      Strategy
 	RetryCount    int
 	RetryDelay    time.Duration
+	// Clock times the retry delays, the clock of the system if nil
+	Clock clock.Clock
 }
@@ -0,0 +1,75 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2018 Datadog, Inc.
+
+// Package scrubber redacts the credentials, API keys, passwords and tokens,
+// from the configurations and the texts sent out of the host.
+package scrubber
+
+import (
+	"fmt"
+	"regexp"
+)
+
+// Replacer replaces the matches of Regex by Repl, or by the result of
+// ReplFunc if it is set
+type Replacer struct {
+	Regex    *regexp.Regexp
+	Repl     []byte
+	ReplFunc func(b []byte) []byte
+}
+
+// DefaultReplacers redact the credentials usually found in the agent and the
+// checks configurations
+var DefaultReplacers []Replacer
+
+func init() {
+	apiKeyReplacer := Replacer{
+		Regex: regexp.MustCompile(`[a-fA-F0-9]{27}([a-fA-F0-9]{5})`),
+		Repl:  []byte(`***************************$1`),
+	}
+	uriPasswordReplacer := Replacer{
+		Regex: regexp.MustCompile(`\:\/\/([A-Za-z0-9_]+)\:(.+)\@`),
+		Repl:  []byte(`://$1:********@`),
+	}
+	passwordReplacer := Replacer{
+		Regex: matchYAMLKeyPart(`pass(word)?`),
+		Repl:  []byte(`$1 ********`),
+	}
+	tokenReplacer := Replacer{
+		Regex: matchYAMLKeyPart(`token`),
+		Repl:  []byte(`$1 ********`),
+	}
+	snmpReplacer := Replacer{
+		Regex: matchYAMLKey(`(community_string|authKey|privKey)`),
+		Repl:  []byte(`$1 ********`),
+	}
+	DefaultReplacers = []Replacer{apiKeyReplacer, uriPasswordReplacer, passwordReplacer, tokenReplacer, snmpReplacer}
+}
+
+func matchYAMLKeyPart(part string) *regexp.Regexp {
+	return regexp.MustCompile(fmt.Sprintf(`(\s*(\w|_)*%s(\w|_)*\s*:).+`, part))
+}
+
+func matchYAMLKey(key string) *regexp.Regexp {
+	return regexp.MustCompile(fmt.Sprintf(`(\s*%s\s*:).+`, key))
+}
+
+// Apply runs the replacers, in order, on b
+func Apply(b []byte, replacers []Replacer) []byte {
+	for _, repl := range replacers {
+		if repl.ReplFunc != nil {
+			b = repl.Regex.ReplaceAllFunc(b, repl.ReplFunc)
+		} else {
+			b = repl.Regex.ReplaceAll(b, repl.Repl)
+		}
+	}
+	return b
+}
+
+// ScrubString returns text with the credentials redacted by the
+// DefaultReplacers
+func ScrubString(text string) string {
+	return string(Apply([]byte(text), DefaultReplacers))
+}
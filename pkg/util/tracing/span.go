@@ -0,0 +1,103 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2018 Datadog, Inc.
+
+package tracing
+
+import (
+	"sync"
+	"time"
+)
+
+// Span is a timed operation of the agent. All its methods are safe to call
+// on a nil Span, which is returned when tracing is disabled or the trace
+// is not sampled.
+type Span struct {
+	TraceID  uint64             `json:"trace_id"`
+	SpanID   uint64             `json:"span_id"`
+	ParentID uint64             `json:"parent_id"`
+	Service  string             `json:"service"`
+	Name     string             `json:"name"`
+	Resource string             `json:"resource"`
+	Type     string             `json:"type"`
+	Start    int64              `json:"start"`
+	Duration int64              `json:"duration"`
+	Error    int32              `json:"error"`
+	Meta     map[string]string  `json:"meta,omitempty"`
+	Metrics  map[string]float64 `json:"metrics,omitempty"`
+
+	tracer   *tracer
+	finished bool
+	m        sync.Mutex
+}
+
+// StartSpan starts the root span of a new trace, it returns nil when
+// tracing is disabled or when the trace is not sampled
+func StartSpan(name, resource string) *Span {
+	t := getTracer()
+	if t == nil || !t.sample() {
+		return nil
+	}
+	id := t.newID()
+	return t.newSpan(id, id, 0, name, resource)
+}
+
+// StartChild starts a span for a sub-operation of the span
+func (s *Span) StartChild(name, resource string) *Span {
+	if s == nil {
+		return nil
+	}
+	return s.tracer.newSpan(s.TraceID, s.tracer.newID(), s.SpanID, name, resource)
+}
+
+// SetTag adds a string tag to the span
+func (s *Span) SetTag(key, value string) {
+	if s == nil {
+		return
+	}
+	s.m.Lock()
+	defer s.m.Unlock()
+	if s.Meta == nil {
+		s.Meta = make(map[string]string)
+	}
+	s.Meta[key] = value
+}
+
+// SetMetric adds a numeric tag to the span
+func (s *Span) SetMetric(key string, value float64) {
+	if s == nil {
+		return
+	}
+	s.m.Lock()
+	defer s.m.Unlock()
+	if s.Metrics == nil {
+		s.Metrics = make(map[string]float64)
+	}
+	s.Metrics[key] = value
+}
+
+// Finish ends the span and queues it to be sent, a non-nil error flags
+// the span as failed. Finishing a span twice is a no-op.
+func (s *Span) Finish(err error) {
+	if s == nil {
+		return
+	}
+	s.m.Lock()
+	if s.finished {
+		s.m.Unlock()
+		return
+	}
+	s.finished = true
+	s.Duration = time.Now().UnixNano() - s.Start
+	if err != nil {
+		s.Error = 1
+		if s.Meta == nil {
+			s.Meta = make(map[string]string)
+		}
+		s.Meta["error.msg"] = err.Error()
+	}
+	s.m.Unlock()
+
+	s.tracer.push(s)
+}
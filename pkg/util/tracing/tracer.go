@@ -0,0 +1,224 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2018 Datadog, Inc.
+
+package tracing
+
+import (
+	"bytes"
+	"encoding/json"
+	"expvar"
+	"fmt"
+	"math/rand"
+	"net/http"
+	"sync"
+	"time"
+
+	log "github.com/cihub/seelog"
+
+	"github.com/DataDog/datadog-agent/pkg/config"
+	"github.com/DataDog/datadog-agent/pkg/version"
+)
+
+const (
+	// ServiceName is the service of the spans reported by the agent
+	ServiceName = "datadog-agent"
+
+	flushInterval = 10 * time.Second
+	// maxBufferedSpans bounds the memory used by the spans waiting to be sent
+	maxBufferedSpans = 5000
+	tracesEndpoint   = "/v0.3/traces"
+)
+
+var (
+	tracingExpvars = expvar.NewMap("tracing")
+
+	globalTracer   *tracer
+	globalTracerMu sync.RWMutex
+)
+
+// tracer buffers the finished spans and sends them to the trace-agent
+type tracer struct {
+	url        string
+	sampleRate float64
+	client     *http.Client
+
+	spans []*Span
+	m     sync.Mutex
+
+	rand   *rand.Rand
+	randMu sync.Mutex
+
+	stop chan struct{}
+	done chan struct{}
+}
+
+func newTracer(agentURL string, sampleRate float64) *tracer {
+	return &tracer{
+		url:        agentURL + tracesEndpoint,
+		sampleRate: sampleRate,
+		client:     &http.Client{Timeout: flushInterval},
+		rand:       rand.New(rand.NewSource(time.Now().UnixNano())),
+		stop:       make(chan struct{}),
+		done:       make(chan struct{}),
+	}
+}
+
+// Start enables the tracing of the agent internal operations if
+// `internal_tracing.enabled` is set
+func Start() {
+	if !config.Datadog.GetBool("internal_tracing.enabled") {
+		return
+	}
+
+	globalTracerMu.Lock()
+	defer globalTracerMu.Unlock()
+	if globalTracer != nil {
+		return
+	}
+	t := newTracer(
+		config.Datadog.GetString("internal_tracing.agent_url"),
+		config.Datadog.GetFloat64("internal_tracing.sample_rate"),
+	)
+	go t.run()
+	globalTracer = t
+	log.Infof("Internal tracing enabled, sending spans to %s", t.url)
+}
+
+// Stop disables the tracing and sends the buffered spans
+func Stop() {
+	globalTracerMu.Lock()
+	t := globalTracer
+	globalTracer = nil
+	globalTracerMu.Unlock()
+
+	if t != nil {
+		close(t.stop)
+		<-t.done
+	}
+}
+
+func getTracer() *tracer {
+	globalTracerMu.RLock()
+	defer globalTracerMu.RUnlock()
+	return globalTracer
+}
+
+func (t *tracer) newID() uint64 {
+	t.randMu.Lock()
+	defer t.randMu.Unlock()
+	return uint64(t.rand.Int63())
+}
+
+// sample decides whether a new trace is kept
+func (t *tracer) sample() bool {
+	if t.sampleRate >= 1 {
+		return true
+	}
+	t.randMu.Lock()
+	defer t.randMu.Unlock()
+	return t.rand.Float64() < t.sampleRate
+}
+
+func (t *tracer) newSpan(traceID, spanID, parentID uint64, name, resource string) *Span {
+	span := &Span{
+		TraceID:  traceID,
+		SpanID:   spanID,
+		ParentID: parentID,
+		Service:  ServiceName,
+		Name:     name,
+		Resource: resource,
+		Start:    time.Now().UnixNano(),
+		tracer:   t,
+	}
+	if parentID == 0 {
+		span.Meta = map[string]string{"version": version.AgentVersion}
+	}
+	return span
+}
+
+// push queues a finished span, spans are dropped when the buffer is full
+func (t *tracer) push(s *Span) {
+	t.m.Lock()
+	defer t.m.Unlock()
+	if len(t.spans) >= maxBufferedSpans {
+		tracingExpvars.Add("SpansDropped", 1)
+		return
+	}
+	t.spans = append(t.spans, s)
+}
+
+func (t *tracer) run() {
+	defer close(t.done)
+	ticker := time.NewTicker(flushInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+			t.flush()
+		case <-t.stop:
+			t.flush()
+			return
+		}
+	}
+}
+
+// flush sends the buffered spans grouped by trace
+func (t *tracer) flush() {
+	t.m.Lock()
+	spans := t.spans
+	t.spans = nil
+	t.m.Unlock()
+
+	if len(spans) == 0 {
+		return
+	}
+	if err := t.send(groupByTrace(spans)); err != nil {
+		log.Debugf("Could not send %d internal spans: %s", len(spans), err)
+		tracingExpvars.Add("FlushErrors", 1)
+		tracingExpvars.Add("SpansDropped", int64(len(spans)))
+		return
+	}
+	tracingExpvars.Add("SpansSent", int64(len(spans)))
+}
+
+func (t *tracer) send(traces [][]*Span) error {
+	payload, err := json.Marshal(traces)
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequest("PUT", t.url, bytes.NewReader(payload))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Datadog-Meta-Lang", "go")
+	req.Header.Set("X-Datadog-Trace-Count", fmt.Sprint(len(traces)))
+
+	resp, err := t.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode >= 400 {
+		return fmt.Errorf("unexpected response from the trace-agent: %s", resp.Status)
+	}
+	return nil
+}
+
+// groupByTrace groups the spans by trace, keeping their order
+func groupByTrace(spans []*Span) [][]*Span {
+	index := make(map[uint64]int)
+	var traces [][]*Span
+	for _, s := range spans {
+		i, found := index[s.TraceID]
+		if !found {
+			i = len(traces)
+			index[s.TraceID] = i
+			traces = append(traces, nil)
+		}
+		traces[i] = append(traces[i], s)
+	}
+	return traces
+}
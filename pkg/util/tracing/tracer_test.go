@@ -0,0 +1,108 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2018 Datadog, Inc.
+
+package tracing
+
+import (
+	"encoding/json"
+	"errors"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func setTracer(t *tracer) {
+	globalTracerMu.Lock()
+	defer globalTracerMu.Unlock()
+	globalTracer = t
+}
+
+func TestDisabled(t *testing.T) {
+	span := StartSpan("check.run", "cpu")
+	assert.Nil(t, span)
+
+	// no-ops on a nil span
+	child := span.StartChild("child", "")
+	assert.Nil(t, child)
+	span.SetTag("foo", "bar")
+	span.SetMetric("foo", 1)
+	span.Finish(nil)
+}
+
+func TestSpans(t *testing.T) {
+	tr := newTracer("http://localhost", 1)
+	setTracer(tr)
+	defer setTracer(nil)
+
+	root := StartSpan("aggregator.flush", "")
+	require.NotNil(t, root)
+	child := root.StartChild("aggregator.flush", "series")
+	child.SetMetric("payload.size", 12)
+	child.Finish(errors.New("failed"))
+	root.SetTag("foo", "bar")
+	root.Finish(nil)
+	root.Finish(nil)
+
+	require.Len(t, tr.spans, 2)
+	assert.Equal(t, root.TraceID, child.TraceID)
+	assert.Equal(t, root.SpanID, child.ParentID)
+	assert.Equal(t, uint64(0), root.ParentID)
+	assert.Equal(t, ServiceName, child.Service)
+	assert.Equal(t, int32(1), child.Error)
+	assert.Equal(t, "failed", child.Meta["error.msg"])
+	assert.Equal(t, float64(12), child.Metrics["payload.size"])
+	assert.Equal(t, int32(0), root.Error)
+	assert.Equal(t, "bar", root.Meta["foo"])
+}
+
+func TestSampling(t *testing.T) {
+	setTracer(newTracer("http://localhost", 0))
+	defer setTracer(nil)
+	assert.Nil(t, StartSpan("check.run", "cpu"))
+}
+
+func TestFlush(t *testing.T) {
+	var traces [][]map[string]interface{}
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "PUT", r.Method)
+		assert.Equal(t, tracesEndpoint, r.URL.Path)
+		assert.Equal(t, "2", r.Header.Get("X-Datadog-Trace-Count"))
+		body, _ := ioutil.ReadAll(r.Body)
+		assert.NoError(t, json.Unmarshal(body, &traces))
+	}))
+	defer ts.Close()
+
+	tr := newTracer(ts.URL, 1)
+	setTracer(tr)
+	defer setTracer(nil)
+
+	first := StartSpan("check.run", "cpu")
+	first.StartChild("child", "").Finish(nil)
+	first.Finish(nil)
+	StartSpan("check.run", "memory").Finish(nil)
+	tr.flush()
+
+	require.Len(t, traces, 2)
+	assert.Len(t, traces[0], 2)
+	assert.Equal(t, "child", traces[0][0]["name"])
+	assert.Equal(t, "cpu", traces[0][1]["resource"])
+	assert.Equal(t, "datadog-agent", traces[1][0]["service"])
+	assert.Empty(t, tr.spans)
+}
+
+func TestBufferLimit(t *testing.T) {
+	tr := newTracer("http://localhost", 1)
+	setTracer(tr)
+	defer setTracer(nil)
+
+	for i := 0; i < maxBufferedSpans+10; i++ {
+		StartSpan("check.run", "cpu").Finish(nil)
+	}
+	assert.Len(t, tr.spans, maxBufferedSpans)
+}
@@ -9,6 +9,7 @@ ALL_TAGS = set([
     "apm",
     "consul",
     "cpython",
+    "cri",
     "docker",
     "ec2",
     "etcd",
@@ -29,6 +30,7 @@ PUPPY_TAGS = set([
 ])
 
 LINUX_ONLY_TAGS = [
+    "cri",
     "docker",
     "kubelet",
     "kubeapiserver"